@@ -0,0 +1,66 @@
+// Command sign-release signs a release's checksum file with the release key,
+// writing <file>.sig next to it for /update to verify (see
+// controllers/update.go). A missing key file is created first, and its
+// public key printed to build releases with.
+//
+// It is a release tool, kept out of the client binary so that no end-user
+// build makes or handles the private key:
+//
+//	go run ./cmd/sign-release -key release.key checksums.txt
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"cli-client/crypto"
+)
+
+func main() {
+	keyPath := flag.String("key", "release.key", "release key file (created if missing)")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: sign-release [-key release.key] checksums.txt")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var key *crypto.Identity
+	data, err := os.ReadFile(*keyPath)
+	switch {
+	case err == nil:
+		if key, err = crypto.ParseIdentity(data); err != nil {
+			fatal(fmt.Errorf("%s: %v", *keyPath, err))
+		}
+	case os.IsNotExist(err):
+		if key, err = crypto.NewIdentity(); err == nil {
+			err = os.WriteFile(*keyPath, key.Marshal(), 0600)
+		}
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Printf("Created %s — keep it secret. Build releases with\n", *keyPath)
+		fmt.Printf("  -ldflags \"-X cli-client/controllers.ReleaseKey=%s\"\n", key.PublicKey())
+	default:
+		fatal(err)
+	}
+
+	list, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		fatal(err)
+	}
+	out := flag.Arg(0) + ".sig"
+	if err := os.WriteFile(out, []byte(key.SignRelease(list)+"\n"), 0644); err != nil {
+		fatal(err)
+	}
+	fmt.Printf("Signed %s → %s (key %s)\n", flag.Arg(0), out, crypto.Fingerprint(key.PublicKey()))
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "sign-release: %v\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"cli-client/models"
+	"cli-client/ui"
+	"cli-client/views"
+)
+
+// ── Accounts ──────────────────────────────────────────────────────────────────
+//
+// "accounts" in the config names more identities, each a username on a
+// relay — work and personal, say. The one logged in with is "main".
+// /account <name> (or Alt+S for the next one) switches the chat screen to
+// another: the one being left is parked, and keeps its own connection
+// polling in the background, counting what arrives and mentions of its
+// username in the footer; switching back shows what it received and
+// carries on from there. Only the active account sends, answers /whois and
+// pings or runs its scheduled messages. /account close <name> signs a
+// parked account out. Each account signs with its own identity key (see
+// identity.go).
+
+// parkedLimit is the most messages a parked account keeps; older ones are
+// dropped.
+const parkedLimit = 1000
+
+// account is one signed-in identity. tview event loop only.
+type account struct {
+	name     string
+	username string
+	color    string // tview tag
+	relay    string
+	lastID   string // poll cursor to resume from
+
+	// While parked: its poller and what came in.
+	nc       *NetworkClient
+	spool    []SpooledMessage
+	dropped  int
+	mentions int
+}
+
+// accountName returns the name of the active account.
+func (ac *AppController) accountName() string {
+	if ac.activeAccount == "" {
+		return models.MainAccount
+	}
+	return ac.activeAccount
+}
+
+// accountNames lists main and then the configured accounts, in order.
+func (ac *AppController) accountNames() []string {
+	names := []string{models.MainAccount}
+	for _, a := range ac.App.Config.Accounts {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+// accountCommand handles /account. Usage: /account [name]  /account close
+// <name>. Must be called from the tview event loop.
+func (ac *AppController) accountCommand(arg string) {
+	sub, rest, _ := strings.Cut(arg, " ")
+	sub, rest = strings.ToLower(sub), strings.ToLower(strings.TrimSpace(rest))
+	switch {
+	case sub == "":
+		ac.listAccounts()
+	case sub == "close" && rest != "":
+		ac.closeAccount(rest)
+	default:
+		ac.useAccount(sub)
+	}
+}
+
+// listAccounts prints every account and how it is doing.
+func (ac *AppController) listAccounts() {
+	if len(ac.App.Config.Accounts) == 0 {
+		ac.sendSystem(`One account — add more under "accounts" in the config to be signed in to several relays at once.`)
+		return
+	}
+	ac.sendSystem("[yellow]Accounts[-]  [dim]— /account <name> or Alt+S switches[-]")
+	for _, name := range ac.accountNames() {
+		state := "[dim]not signed in[-]"
+		username, relay := "", ""
+		if a, ok := ac.parked[name]; ok {
+			username, relay = a.username, a.relay
+			state = fmt.Sprintf("parked · %d new", len(a.spool)+a.dropped)
+			if a.mentions > 0 {
+				state += fmt.Sprintf(" · [red]%d mention(s)[-]", a.mentions)
+			}
+		} else if name == ac.accountName() {
+			username, relay = ac.App.CurrentUser.Username, ac.conversationKey()
+			state = "[green]● active[-]"
+		} else if cfg, ok := ac.configuredAccount(name); ok {
+			username, relay = cfg.Username, cfg.Server
+		}
+		ac.sendSystem(fmt.Sprintf("  [cyan]%s[-]  %s on %s  %s", name, views.Escape(username), views.Escape(relay), state))
+	}
+}
+
+// configuredAccount returns the account named name under "accounts".
+func (ac *AppController) configuredAccount(name string) (models.Account, bool) {
+	for _, a := range ac.App.Config.Accounts {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return models.Account{}, false
+}
+
+// nextAccount switches to the account after the active one, for Alt+S.
+func (ac *AppController) nextAccount() {
+	names := ac.accountNames()
+	if len(names) < 2 {
+		ac.sendSystem(`No other accounts — add them under "accounts" in the config.`)
+		return
+	}
+	for i, name := range names {
+		if name == ac.accountName() {
+			ac.useAccount(names[(i+1)%len(names)])
+			return
+		}
+	}
+}
+
+// useAccount makes the account called name the active one, parking the
+// one in use.
+func (ac *AppController) useAccount(name string) {
+	if ac.App.CurrentUser == nil {
+		return
+	}
+	if name == ac.accountName() {
+		ac.sendSystem("You are already on " + views.Escape(name) + ".")
+		return
+	}
+	if ac.lan != nil {
+		ac.sendSystem("Accounts need a relay — /server <url> to leave LAN mode first.")
+		return
+	}
+	target, wasParked := ac.parked[name]
+	if !wasParked {
+		cfg, found := ac.configuredAccount(name)
+		if !found {
+			ac.sendSystem("No account named " + views.Escape(name) + " — /account lists them.")
+			return
+		}
+		target = &account{name: cfg.Name, username: cfg.Username, relay: cfg.Server}
+		if cfg.Color != "" {
+			target.color = models.ParseColorToTag(cfg.Color)
+		}
+	}
+
+	ac.stashDraft()
+	ac.dropTemplate()
+	ac.disarmSchedules()
+	current := &account{
+		name:     ac.accountName(),
+		username: ac.App.CurrentUser.Username,
+		color:    ac.App.GetUserColorTag(ac.App.CurrentUser.Username),
+		relay:    DefaultServerURL,
+	}
+	if ac.netClient != nil {
+		current.lastID = ac.netClient.LastID()
+	}
+	ac.park(current)
+	if wasParked {
+		target.nc.Stop()
+		target.lastID = target.nc.LastID()
+		delete(ac.parked, name)
+	}
+
+	ac.activeAccount = name
+	DefaultServerURL = target.relay
+	ac.App.SetCurrentUser(target.username)
+	ac.setSelf(target.username)
+	ac.loadIdentity()
+	if target.color != "" {
+		ac.App.SetUserColor(target.username, target.color)
+	}
+	if chat, ok := ac.chatView(); ok {
+		chat.SetCurrentUser(target.username)
+	}
+	ac.startNetworkClientFrom(target.lastID)
+	ac.loadSchedules()
+	ac.pushTrust()
+	ac.enterConversation()
+	ac.armFailback()
+	if !wasParked {
+		ac.announce(opJoin)
+	}
+
+	ac.sendSystem(fmt.Sprintf("[dim]── account %s · %s%s[-][dim] on %s ──[-]",
+		name, ac.App.GetUserColorTag(target.username), views.Escape(target.username), views.Escape(target.relay)))
+	if target.dropped > 0 {
+		ac.sendSystem(fmt.Sprintf("[dim]%d older message(s) were dropped while parked.[-]", target.dropped))
+	}
+	for _, sm := range target.spool {
+		ac.addSpooled(sm)
+	}
+	if len(target.spool) > 0 {
+		ac.sendSystem("[dim]── end of what came in while parked ──[-]")
+	}
+	ac.showAccounts()
+}
+
+// park keeps a's connection polling in the background.
+func (ac *AppController) park(a *account) {
+	if ac.parked == nil {
+		ac.parked = make(map[string]*account)
+	}
+	ac.parked[a.name] = a
+	a.nc = NewNetworkClient(
+		nil,
+		a.relay,
+		func(msg *pollMessage) {
+			if !isChatMessage(msg) {
+				return // presence, /whois and pings are answered by the active account only
+			}
+			sm := SpooledMessage{Username: msg.Username, Content: msg.Content, Color: msg.Color, Timestamp: messageTime(msg)}
+			ui.SafeQueueUpdateDraw(ac.app, "parked account", func() {
+				if ac.parked[a.name] != a {
+					return
+				}
+				if len(a.spool) == parkedLimit {
+					a.spool = a.spool[1:]
+					a.dropped++
+				}
+				a.spool = append(a.spool, sm)
+				if sm.Username != a.username && mentions(sm.Content, a.username) {
+					a.mentions++
+				}
+				ac.showAccounts()
+			})
+		},
+		func(connected bool, msg string) {
+			log.Printf("Account %s: %s", a.name, msg)
+		},
+	)
+	a.nc.SetLastID(a.lastID)
+	a.nc.Configure(ac.App.Config)
+	a.nc.Start()
+}
+
+// closeAccount signs out the parked account called name.
+func (ac *AppController) closeAccount(name string) {
+	a, ok := ac.parked[name]
+	switch {
+	case name == models.MainAccount:
+		ac.sendSystem(models.MainAccount + " is the account you logged in with — it stays signed in.")
+		return
+	case !ok:
+		ac.sendSystem(views.Escape(name) + " is not a parked account — /account lists them.")
+		return
+	}
+	a.nc.Stop()
+	delete(ac.parked, name)
+	ac.showAccounts()
+	ac.sendSystem("Signed out of " + views.Escape(name) + ".")
+}
+
+// stopParked stops every parked account's connection, for logout, /detach
+// and exit. Must be called from the tview event loop, or once it has
+// stopped.
+func (ac *AppController) stopParked() {
+	for name, a := range ac.parked {
+		a.nc.Stop()
+		delete(ac.parked, name)
+	}
+}
+
+// showAccounts puts the parked accounts and what came in for them in the
+// footer.
+func (ac *AppController) showAccounts() {
+	chat, ok := ac.chatView()
+	if !ok {
+		return
+	}
+	segment := ""
+	if len(ac.parked) > 0 {
+		segment = "  [dim]as[-] " + views.Escape(ac.accountName())
+		for _, name := range ac.accountNames() {
+			a, ok := ac.parked[name]
+			switch {
+			case !ok:
+			case a.mentions > 0:
+				segment += fmt.Sprintf(" [dim]·[-] %s [red]@%d[-]", views.Escape(name), a.mentions)
+			case len(a.spool) > 0:
+				segment += fmt.Sprintf(" [dim]·[-] %s [yellow]%d[-]", views.Escape(name), len(a.spool)+a.dropped)
+			default:
+				segment += " [dim]· " + views.Escape(name) + "[-]"
+			}
+		}
+	}
+	chat.SetSegment("accounts", segment)
+}
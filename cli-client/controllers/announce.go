@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"cli-client/models"
+	"cli-client/paths"
+	"cli-client/ui"
+	"cli-client/views"
+)
+
+// ── Announcements ─────────────────────────────────────────────────────────────
+//
+// An announcement is a message of type "announcement". The relay accepts it
+// only together with its admin key, so receivers can trust where it came from
+// and render it as a banner instead of a chat line. LAN peers have no relay to
+// vouch for them and their announcements are dropped (see LANNode.readPeer).
+
+// Announce posts an announcement and waits for the relay's answer.
+// Safe to call from any goroutine; blocks for one request.
+func (nc *NetworkClient) Announce(username, content, colorTag, adminKey string) error {
+	resp, err := nc.postSend(sendRequest{
+		AccessKey: serverAccessKey,
+		ClientID:  nc.clientID,
+		Username:  username,
+		Content:   content,
+		Color:     nc.wireColor(colorTag),
+		Type:      msgTypeAnnouncement,
+		AdminKey:  adminKey,
+	})
+	if errors.Is(err, errPowRefused) {
+		return err
+	}
+	if err != nil {
+		return errors.New("relay unreachable")
+	}
+	defer drainClose(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		var sr sendResponse
+		if err := json.NewDecoder(resp.Body).Decode(&sr); err == nil && sr.ID != "" {
+			if sr.Challenge != "" {
+				nc.notePowChallenge(sr.Challenge, 0)
+			}
+			nc.sentIDsMu.Lock()
+			nc.sentIDs[sr.ID] = struct{}{}
+			nc.sentIDsMu.Unlock()
+		}
+		return nil
+	case http.StatusForbidden:
+		return errors.New("the relay rejected admin_key")
+	case http.StatusUnauthorized:
+		return errors.New("the relay rejected the access key")
+	default:
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		log.Printf("Announce: unexpected status %d body=%.120s", resp.StatusCode, raw)
+		return fmt.Errorf("relay answered HTTP %d", resp.StatusCode)
+	}
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// adminReady reports whether an admin-only relay feature can be used right
+// now, explaining why not otherwise. Must be called from the tview event loop.
+func (ac *AppController) adminReady(feature string) bool {
+	switch {
+	case ac.App.CurrentUser == nil:
+		ac.sendSystem("No user logged in.")
+	case ac.lan != nil:
+		ac.sendSystem("Admin commands need a relay — not available in LAN mode.")
+	case ac.netClient == nil:
+		ac.sendSystem("Not connected to a relay.")
+	case ac.App.Config.AdminKey == "":
+		ac.sendSystem("Set [cyan]admin_key[-] in " + views.Escape(paths.Config(models.ConfigFile)) + " to use admin commands.")
+	case !ac.caps.Supports(feature):
+		ac.sendSystem("This relay does not support " + feature + ".")
+	default:
+		return true
+	}
+	return false
+}
+
+// postAnnouncement handles /announce <text>. Must be called from the tview event loop.
+func (ac *AppController) postAnnouncement(text string) {
+	if text == "" {
+		ac.sendSystem("Usage: /announce <text>")
+		return
+	}
+	if !ac.adminReady("announcements") {
+		return
+	}
+
+	ac.lastInput = time.Now()
+	nc, me, key := ac.netClient, ac.App.CurrentUser.Username, ac.App.Config.AdminKey
+	color := ac.App.GetUserColorTag(me)
+	ui.SafeGo("postAnnouncement", func() {
+		err := nc.Announce(me, text, color, key)
+		ui.SafeQueueUpdateDraw(ac.app, "postAnnouncement", func() {
+			if err != nil {
+				ac.sendSystem("[red]Announcement not sent:[-] " + views.Escape(err.Error()))
+				return
+			}
+			ac.App.Session.RecordSent(text)
+			ac.showAnnouncement(me, text)
+		})
+	})
+}
+
+// showAnnouncement adds an announcement banner to the transcript.
+// Must be called from the tview event loop.
+func (ac *AppController) showAnnouncement(from, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	msg := models.NewMessage(from, text)
+	msg.Announcement = true
+	ac.App.AddMessage(msg)
+	for _, sink := range ac.messageSinks() {
+		sink.AddMessage(msg)
+	}
+	ac.notifyAnnouncement(from)
+}
@@ -1,83 +1,435 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"sort"
 	"strings"
 	"time"
 
+	"cli-client/crypto"
 	"cli-client/models"
+	"cli-client/tasks"
+	"cli-client/ui"
 	"cli-client/views"
+	"sync/atomic"
 
 	"github.com/rivo/tview"
 )
 
 type AppController struct {
-	App   *models.AppState
-	Views map[models.Screen]interface{}
-	SM    *StateMachine
+	App     *models.AppState
+	Views   map[models.Screen]views.View
+	SM      *StateMachine
+	screens []models.Screen // Views keys in registration order
 
 	app         *tview.Application
 	netClient   *NetworkClient
 	latencyCtrl *LatencyController
+	detached    *DetachedSession // set by /detach just before the app stops
+
+	// Remote whois/ping — only touched inside the tview event loop
+	probes       map[string]*probe // nonce → outstanding request
+	whoisPrivate bool              // true = refuse remote /whois requests
+	identity     *crypto.Identity  // our identity key; nil if it could not be loaded
+	lastInput    time.Time         // last send or command, for idle time
+	lastHere     time.Time         // last message we sent that used @here
+
+	quietPresence bool // /quiet — hide join/leave/rename lines
+
+	caps *Capabilities // relay handshake result; nil until negotiated
+
+	// Reconnect countdown in the footer — see reconnect.go.
+	reconnectAt      time.Time   // when the next attempt starts
+	reconnectAttempt int         // 0 = connected, no countdown
+	reconnectTimer   *time.Timer // next redraw of the countdown
+	failbackTimer    *time.Timer // next probe of the relays ahead, see failover.go
+
+	quality qualityMeter // connection grade, see quality.go; tview event loop only
+	saver   atomic.Bool  // the grade is poor: skip what can wait
+	// Whether the relay serves /api/stats, once the stats poller has asked;
+	// see statsPollerLoop.
+	statsKnown, statsOK bool
+
+	lan *LANNode // non-nil in LAN mode (/server lan) instead of netClient
+
+	// Read receipts — only touched inside the tview event loop
+	receiptsOn   bool                   // /privacy receipts on|off
+	sent         map[string]*sentRecord // relay ID → our message
+	sentOrder    []string               // relay IDs, oldest first, for eviction
+	pendingSeen  map[string][]string    // sender → IDs shown but not yet acked
+	unseen       map[string]seenRef     // line ID → message acked once it is on screen
+	unseenOrder  []string               // line IDs, oldest first, for eviction
+	receiptTimer *time.Timer
+
+	notifier *NotificationController // bell rules and /dnd
+
+	// Scheduled messages — only touched inside the tview event loop
+	schedules      []*ScheduledMessage // everything in scheduleFile, all users
+	scheduleTimers map[int]*time.Timer // armed entries of the current user
+
+	updating bool // /update running — only touched inside the tview event loop
+	userAnim bool // display mode chosen with /mode, before per-room overrides
+
+	parts *partAssembler // long incoming messages being joined
+	inbox *inbox         // chat messages waiting to be shown
+
+	filter  atomic.Pointer[contentFilter]   // the conversation's filters, see filters.go; nil = none
+	ignored atomic.Pointer[map[string]bool] // usernames not shown, see browse.go; nil = none
+	fill    *templateFill                   // /t asking for placeholders, see templates.go; tview event loop only
+
+	self      atomic.Value // string: our username, for impostor checks, see impostors.go
+	liveSince atomic.Int64 // unix ns: when the relay or LAN connection started
+	impostors impostorLog  // tview event loop only
+
+	focus      focusState // see focus.go; tview event loop only
+	titleShown string     // terminal title last set, see title.go; tview event loop only
+
+	// Conversations — only touched inside the tview event loop
+	rooms    []string             // conversation keys in the order entered
+	activity map[string]time.Time // conversation key → last incoming message
+	unread   map[string]int       // conversation key → messages not on screen yet, see receipts.go
+	panes    [2]string            // conversation key shown in each chat pane, see split.go
+
+	// Polls — only touched inside the tview event loop
+	polls map[string]*models.Message // poll ID → the message showing it
+
+	// Edits — only touched inside the tview event loop, see edits.go
+	editable      map[string]*models.Message // relay ID → received message
+	editableOrder []string                   // relay IDs, oldest first, for eviction
+	lastSent      map[string]*models.Message // conversation key → our latest chat message
+	failed        []*failedSend              // our messages the relay did not accept, see outbox.go
+	history       historyState               // paging back through the relay's history
+	edited        []*models.Message          // edited messages, oldest first, for /diffs
+	diffsOff      bool
+
+	// Lazy bodies — only touched inside the tview event loop
+	pendingBodies map[string]*pendingBody // line ID → placeholder, see bodies.go
+	pendingOrder  []string                // line IDs, oldest first, for eviction
+
+	quietTimer *time.Timer // next quiet_hours boundary — tview event loop only
+	away       awayState   // /away, see away.go — tview event loop only
+	hush       hushes      // /hush, see hush.go
+	repeats    repeats     // collapsed repeats, see repeats.go
+
+	// Accounts — only touched inside the tview event loop, see accounts.go
+	activeAccount string              // "" = models.MainAccount
+	parked        map[string]*account // name → an account polling in the background
+
+	// Invitations — only touched inside the tview event loop, see invite.go
+	invite  *Invite           // from the command line, joined after login
+	invited map[string]string // sender → the fingerprint an invitation gave
+
+	stressing int32       // atomic: 1 while /stress runs, see stress.go
+	slowTimer *time.Timer // clears the slow-frame footer warning — tview event loop only
 }
 
 func NewAppController(app *tview.Application) *AppController {
-	return &AppController{
+	ac := &AppController{
 		App:   models.NewAppState(),
-		Views: make(map[models.Screen]interface{}),
+		Views: make(map[models.Screen]views.View),
 		SM:    NewStateMachine(models.ScreenNone),
 		app:   app,
+
+		probes:    make(map[string]*probe),
+		lastInput: time.Now(),
+
+		sent:        make(map[string]*sentRecord),
+		pendingSeen: make(map[string][]string),
+		unseen:      make(map[string]seenRef),
+
+		notifier:       NewNotificationController(),
+		scheduleTimers: make(map[int]*time.Timer),
+		activity:       make(map[string]time.Time),
+		unread:         make(map[string]int),
+		polls:          make(map[string]*models.Message),
+		editable:       make(map[string]*models.Message),
+		lastSent:       make(map[string]*models.Message),
+		pendingBodies:  make(map[string]*pendingBody),
 	}
+	ac.parts = newPartAssembler(ac.deliverChat)
+	ac.inbox = newInbox()
+	ui.SafeGo("inbox", ac.drainInbox)
+	ac.SM.OnTransition(ac.switchView)
+	ac.SM.SetTransient(models.ScreenLoading)
+	ac.SM.SetTransient(models.ScreenError)
+	ac.SM.Guard(models.ScreenChat, func(models.Screen) error {
+		if ac.App.CurrentUser == nil {
+			return errors.New("not logged in")
+		}
+		return nil
+	})
+	return ac
 }
 
-func (ac *AppController) RegisterView(screen models.Screen, view interface{}) {
+// RegisterView makes view the screen shown for screen. It is told when it
+// is shown and hidden, and gets messages and stats if it implements
+// views.MessageSink or views.StatsSink.
+func (ac *AppController) RegisterView(screen models.Screen, view views.View) {
+	if _, ok := ac.Views[screen]; !ok {
+		ac.screens = append(ac.screens, screen)
+	}
 	ac.Views[screen] = view
 }
 
+// switchView runs the hide/show lifecycle on a screen change.
+func (ac *AppController) switchView(from, to models.Screen) {
+	if v, ok := ac.Views[from]; ok {
+		v.OnHide()
+	}
+	if v, ok := ac.Views[to]; ok {
+		v.OnShow()
+	}
+}
+
+// messageSinks returns the registered views that show the transcript.
+func (ac *AppController) messageSinks() []views.MessageSink {
+	var sinks []views.MessageSink
+	for _, s := range ac.screens {
+		if sink, ok := ac.Views[s].(views.MessageSink); ok {
+			sinks = append(sinks, sink)
+		}
+	}
+	return sinks
+}
+
+// statsSinks returns the registered views that show relay statistics.
+func (ac *AppController) statsSinks() []views.StatsSink {
+	var sinks []views.StatsSink
+	for _, s := range ac.screens {
+		if sink, ok := ac.Views[s].(views.StatsSink); ok {
+			sinks = append(sinks, sink)
+		}
+	}
+	return sinks
+}
+
+// chatView returns the pane chat output goes to. Controllers never look the
+// view up themselves, so which pane a conversation renders into is decided
+// here alone.
+func (ac *AppController) chatView() (*views.ChatView, bool) {
+	split, ok := ac.splitView()
+	if !ok {
+		return nil, false
+	}
+	return split.Active(), true
+}
+
+// ShowFatal switches to the error screen for a condition the app cannot
+// recover from by itself. The user can retry (if retry is non-nil), go back
+// to the previous screen (if there is one) or quit. detail is plain text.
+// Must be called from the tview event loop.
+func (ac *AppController) ShowFatal(title, detail string, retry func()) {
+	log.Printf("Fatal: %s — %s", title, detail)
+	ev, ok := ac.Views[models.ScreenError].(*views.ErrorView)
+	if !ok {
+		ac.app.Stop()
+		return
+	}
+	var actions []views.ErrorAction
+	if retry != nil {
+		actions = append(actions, views.ErrorAction{Label: "Retry", Run: retry})
+	}
+	if ac.SM.CanGoBack() {
+		actions = append(actions, views.ErrorAction{Label: "Back", Run: ac.Back})
+	}
+	actions = append(actions, views.ErrorAction{Label: "Quit", Run: ac.app.Stop})
+	ev.SetError(title, detail, actions...)
+	if err := ac.SM.Transition(models.ScreenError); err != nil {
+		log.Printf("ShowFatal: %v", err)
+		ac.app.Stop()
+	}
+}
+
+// Back returns to the previous screen. Called from the tview event loop.
+func (ac *AppController) Back() {
+	if err := ac.SM.Back(); err != nil {
+		log.Printf("Back: %v", err)
+	}
+}
+
 // OnLoginSubmit — called from the tview event loop.
 // username is the entered username; colorTag is the tview color tag chosen
 // during login (e.g. "[cyan]"). If empty, falls back to hash-based default.
 func (ac *AppController) OnLoginSubmit(username, colorTag string) {
+	ac.enterChat(username, colorTag, "")
+	ac.announce(opJoin)
+	ac.runStartup()
+	ac.acceptInvite()
+}
+
+// ResumeDetached re-attaches to a session left running by /detach: it skips
+// the login prompt, replays what the daemon spooled while we were away and
+// resumes polling from the daemon's cursor. Called from the tview event loop.
+func (ac *AppController) ResumeDetached(s *DetachedSession) {
+	spooled := s.Reattach()
+	DefaultServerURL = s.ServerURL
+
+	ac.enterChat(s.Username, s.Color, s.LastID)
+
+	ac.sendSystem(fmt.Sprintf("[dim]── re-attached · %d message(s) since %s ──[-]",
+		len(spooled), s.DetachedAt.Format("15:04")))
+	for _, sm := range spooled {
+		ac.addSpooled(sm)
+	}
+	switch {
+	case len(spooled) >= summaryHint && ac.App.Config.Summary.URL != "":
+		ac.sendSystem(fmt.Sprintf("[dim]── end of replay · /summary %d to condense it ──[-]", min(len(spooled), maxSummaryCount)))
+	case len(spooled) > 0:
+		ac.sendSystem("[dim]── end of replay ──[-]")
+	}
+	ac.acceptInvite()
+}
+
+// Detached returns the background session started by /detach, or nil if the
+// app exited normally.
+func (ac *AppController) Detached() *DetachedSession {
+	return ac.detached
+}
+
+// enterChat logs the user in, switches to the chat screen and starts the
+// background services. lastID seeds the poll cursor ("" = fresh session).
+func (ac *AppController) enterChat(username, colorTag, lastID string) {
 	ac.App.SetCurrentUser(username)
+	ac.setSelf(username)
+	ac.startTranscripts()
 
 	// Apply the color chosen during login immediately, before any messages render.
 	if colorTag != "" && strings.HasPrefix(colorTag, "[") {
 		ac.App.SetUserColor(username, colorTag)
 	}
 
-	ac.SM.Transition(models.ScreenChat)
+	if err := ac.SM.Transition(models.ScreenChat); err != nil {
+		log.Printf("enterChat: %v", err)
+		return
+	}
 
-	if chat, ok := ac.Views[models.ScreenChat].(*views.ChatView); ok {
+	for _, chat := range ac.chatPanes() {
 		chat.SetCurrentUser(username)
+		chat.SetVisibleFunc(func(ids []string) {
+			ac.fetchBodies(ids)
+			ac.linesSeen(ids)
+		})
+	}
+	if split, ok := ac.splitView(); ok {
+		split.SetFocusFunc(ac.focusPane)
+		split.SetRoomFunc(ac.pickRoom)
+		ac.showRooms()
 	}
 
-	ac.startNetworkClient()
+	ac.startNetworkClientFrom(lastID)
 	ac.startLatencyController()
+	ac.startQualityTicker()
+	ac.loadSchedules()
+
+	ac.App.LoadDrafts()
+	ac.App.LoadBookmarks()
+	ac.App.LoadContacts()
+	ac.loadIdentity()
+	ac.pushTrust()
+	ac.enterConversation()
+	ac.applyQuietHours()
+	ac.armFailback()
+
+	if ac.App.Config.UpdateCheck {
+		ac.checkForUpdate()
+	}
+}
+
+// addSpooled shows a message received in the background, by the /detach
+// daemon or for a parked account. Must be called from the tview event loop.
+func (ac *AppController) addSpooled(sm SpooledMessage) {
+	color := sm.Color
+	if !strings.HasPrefix(color, "[") {
+		color = models.ParseColorToTag(color)
+	}
+	msg := &models.Message{
+		Username:  sm.Username,
+		Content:   sm.Content,
+		Timestamp: sm.Timestamp,
+		Color:     color,
+	}
+	ac.App.AddMessage(msg)
+	ac.App.History.Add(ac.conversationKey(), msg)
+	for _, sink := range ac.messageSinks() {
+		sink.AddMessage(msg)
+	}
+}
+
+// conversationKey names the conversation the user is in, for drafts: the
+// relay URL, or "lan" in LAN mode.
+func (ac *AppController) conversationKey() string {
+	if ac.lan != nil {
+		return "lan"
+	}
+	return DefaultServerURL
+}
+
+// stashDraft files the unsent input under the current conversation.
+// Must be called from the tview event loop (or after it has stopped).
+func (ac *AppController) stashDraft() {
+	if chat, ok := ac.chatView(); ok {
+		ac.App.SetDraft(ac.conversationKey(), chat.Draft())
+	}
+}
+
+// restoreDraft puts the current conversation's draft back in the input.
+// Must be called from the tview event loop.
+func (ac *AppController) restoreDraft() {
+	if chat, ok := ac.chatView(); ok {
+		chat.SetDraft(ac.App.Drafts[ac.conversationKey()])
+	}
 }
 
 // OnSendMessage — called from the tview event loop.
 // The message is displayed optimistically in the UI immediately.
 // The encrypted wire copy is sent to the server asynchronously.
 func (ac *AppController) OnSendMessage(content string) {
+	ac.lastInput = time.Now()
+	if ac.fill != nil {
+		ac.answerTemplate(content)
+		return
+	}
+	ac.sendChat(content)
+	if chat, ok := ac.chatView(); ok {
+		chat.AddToHistory(content)
+	}
+}
+
+// sendChat shows content as our own message and relays it. Used for typed
+// input and for scheduled messages. Must be called from the tview event loop.
+func (ac *AppController) sendChat(content string) {
+	parts := splitMessage(content, ac.maxContent())
+	if len(parts) > maxParts {
+		ac.sendSystem(fmt.Sprintf("[red]Message too long[-] — %d bytes, more than %d parts of %d bytes. Split it up or send a link.",
+			len(content), maxParts, ac.maxContent()))
+		return
+	}
+	if !ac.checkHere(content) {
+		return
+	}
+
 	msg := models.NewMessage(ac.App.CurrentUser.Username, content)
 	msg.Color = ac.App.GetUserColorTag(ac.App.CurrentUser.Username)
 	ac.App.AddMessage(msg)
+	ac.App.History.Add(ac.conversationKey(), msg)
+	ac.lastSent[ac.conversationKey()] = msg
+	ac.App.Session.RecordSent(content)
+	ac.repeats.reset()
 
 	// Display immediately — no waiting for server round-trip.
-	if chat, ok := ac.Views[models.ScreenChat].(*views.ChatView); ok {
-		chat.AddMessage(msg)
-		chat.AddToHistory(content)
+	for _, sink := range ac.messageSinks() {
+		sink.AddMessage(msg)
 	}
 
-	// Fire-and-forget: encrypt and relay to server.
-	// The server echoes this back to us; NetworkClient deduplicates via sentIDs.
-	if ac.netClient != nil {
-		ac.netClient.SendMessage(msg.Username, content, msg.Color)
-	}
+	// Relay it in the background; see outbox.go.
+	ac.relayChat(msg, parts)
 }
 
 // OnCommand — called from the tview event loop.
 func (ac *AppController) OnCommand(command string) {
+	ac.lastInput = time.Now()
 	if len(command) <= 1 {
 		ac.sendSystem("Usage: /<command>  —  type /help for available commands.")
 		return
@@ -91,18 +443,22 @@ func (ac *AppController) OnCommand(command string) {
 		arg = strings.TrimSpace(parts[1])
 	}
 
-	chat, hasChat := ac.Views[models.ScreenChat].(*views.ChatView)
+	chat, hasChat := ac.chatView()
+	ac.dropTemplate()
 
 	switch cmd {
 
 	case "clear":
 		ac.App.Messages = []*models.Message{}
-		if hasChat {
-			chat.ClearMessages()
+		if split, ok := ac.splitView(); ok {
+			ac.forgetPane(split.ActivePane())
+		}
+		for _, sink := range ac.messageSinks() {
+			sink.ClearMessages()
 		}
 
 	case "help":
-		ac.sendSystem("Commands:  /clear  /whois  /nick  /mode [animation|static]  /user_color <color>  /server <url>  /latency  /info  /exit  /help")
+		ac.sendSystem(helpLine())
 
 	case "info":
 		lines := []string{
@@ -113,7 +469,10 @@ func (ac *AppController) OnCommand(command string) {
 			"  [cyan]Author   [-]Mortza Mansory",
 			"  [cyan]License  [-]MIT — free and open-source",
 			"  [cyan]GitHub   [-]https://github.com/mortza-mansory/TTC-cli-messanger",
-			"  [cyan]Version  [-]v1.0.0-dev",
+			"  [cyan]Version  [-]" + buildLine(),
+			"  [cyan]Releases [-]" + releaseKeyLine(),
+			"  [cyan]Terminal [-]" + terminalLine(ac.App.Config.Terminal),
+			ac.protocolLine(),
 			"",
 			"  [green]✓[-] End-to-end AES-256-GCM encrypted relay",
 			"  [green]✓[-] Zero server-side message storage — your device, your data",
@@ -131,23 +490,54 @@ func (ac *AppController) OnCommand(command string) {
 			ac.sendSystem("No user logged in.")
 			return
 		}
+		if arg = ac.resolveAlias(arg); arg != "" && arg != ac.App.CurrentUser.Username {
+			ac.sendProbe(opWhois, arg)
+			return
+		}
 		u := ac.App.CurrentUser
 		colorTag := ac.App.GetUserColorTag(u.Username)
 		colorDisplay := strings.Trim(colorTag, "[]")
 		ac.sendSystem(fmt.Sprintf(
 			"Whois  ▸  user: %s%s[-]  |  color: %s  |  status: online  |  msgs sent: %d",
-			colorTag, u.Username, colorDisplay, ac.countUserMessages(u.Username),
+			colorTag, views.Escape(u.Username), colorDisplay, ac.countUserMessages(u.Username),
 		))
+		if line := ac.impostorWhois(); line != "" {
+			ac.sendSystem(line)
+		}
 
+	// ── /nick ────────────────────────────────────────────────────────────────
+	// Changes the active username. Outgoing messages use the new name from
+	// now on and other clients see a rename announcement.
 	case "nick":
-		if !hasChat {
+		if ac.App.CurrentUser == nil {
+			ac.sendSystem("No user logged in.")
 			return
 		}
-		active := chat.ToggleNickMode()
-		if active {
-			ac.sendSystem("Nick mode ON — ← / → navigates your sent-message history. /nick to turn off.")
-		} else {
-			ac.sendSystem("Nick mode OFF — arrow keys restored to normal.")
+		if arg == "" {
+			ac.sendSystem(fmt.Sprintf("You are %s  —  usage: /nick <newname>", views.Escape(ac.App.CurrentUser.Username)))
+			return
+		}
+		if strings.ContainsAny(arg, " \t") {
+			ac.sendSystem("Nicknames cannot contain spaces.")
+			return
+		}
+		if arg == ac.App.CurrentUser.Username {
+			ac.sendSystem(fmt.Sprintf("You are already %s.", views.Escape(arg)))
+			return
+		}
+		old := ac.App.RenameCurrentUser(arg)
+		ac.setSelf(arg)
+		if hasChat {
+			chat.SetCurrentUser(arg)
+		}
+		colorTag := ac.App.GetUserColorTag(arg)
+		ac.sendSystem(fmt.Sprintf("You are now known as %s%s[-]", colorTag, views.Escape(arg)))
+		f := controlFrame{Op: opNick, Old: old, Color: models.ColorName(colorTag)}
+		ac.signIdentity(&f)
+		ac.sendControl(f)
+		ac.pushTrust()
+		if ac.lan != nil {
+			ac.lan.SetUsername(arg)
 		}
 
 	case "mode":
@@ -165,6 +555,7 @@ func (ac *AppController) OnCommand(command string) {
 		default:
 			label = chat.ToggleAnimationMode()
 		}
+		ac.userAnim = chat.IsAnimationMode()
 		ac.sendSystem(fmt.Sprintf("Display mode → %s", label))
 
 	case "user_color":
@@ -186,12 +577,13 @@ func (ac *AppController) OnCommand(command string) {
 			}
 			colorDisplay := strings.Trim(defaultTag, "[]")
 			ac.sendSystem(fmt.Sprintf("Color reset → %s%s[-] (default)", defaultTag, colorDisplay))
+			ac.announce(opColor)
 			return
 		}
 		colorTag := models.ParseColorToTag(arg)
 		if !strings.HasPrefix(arg, "#") && !models.IsValidNamedColor(arg) {
 			validList := strings.Join(models.ValidNamedColors, ", ")
-			ac.sendSystem(fmt.Sprintf("Unknown color: '%s'  —  valid names: %s  |  or hex: #rrggbb", arg, validList))
+			ac.sendSystem(fmt.Sprintf("Unknown color: '%s'  —  valid names: %s  |  or hex: #rrggbb", views.Escape(arg), validList))
 			return
 		}
 		ac.App.SetUserColor(username, colorTag)
@@ -200,17 +592,26 @@ func (ac *AppController) OnCommand(command string) {
 			colorDisplay = strings.Trim(colorTag, "[]")
 		}
 		ac.sendSystem(fmt.Sprintf("Your color → %s%s[-]  (applies to all your new messages)", colorTag, colorDisplay))
+		ac.announce(opColor)
 
 	// ── /server ──────────────────────────────────────────────────────────────
 	// Changes the relay server URL at runtime and reconnects.
 	// Usage: /server http://myserver.example.com:8080
+	//        /server lan   — serverless mode, peers found via mDNS
 	case "server":
 		if arg == "" {
 			current := DefaultServerURL
 			if ac.netClient != nil {
 				current = ac.netClient.serverURL
 			}
-			ac.sendSystem(fmt.Sprintf("Current server: [cyan]%s[-]  —  usage: /server <url>", current))
+			if ac.lan != nil {
+				current = fmt.Sprintf("LAN mode (port %d)", ac.lan.Port())
+			}
+			ac.sendSystem(fmt.Sprintf("Current server: [cyan]%s[-]  —  usage: /server <url>|lan", views.Escape(current)))
+			return
+		}
+		if arg == "lan" {
+			ac.switchConversation(arg)
 			return
 		}
 		// Validate basic URL shape
@@ -218,13 +619,87 @@ func (ac *AppController) OnCommand(command string) {
 			ac.sendSystem("Invalid URL — must start with http:// or https://")
 			return
 		}
-		DefaultServerURL = arg
-		ac.sendSystem(fmt.Sprintf("Server URL → [cyan]%s[-]  — reconnecting…", arg))
-		// Restart the network client with the new URL
-		ac.stopNetworkClient()
-		ac.startNetworkClient()
+		ac.switchConversation(arg)
+
+	case "rooms":
+		ac.listRooms()
+
+	// ── /split ───────────────────────────────────────────────────────────────
+	// Usage: /split <n|room>|off   (see split.go)
+	case "split":
+		ac.splitCommand(arg)
+
+	case "demo":
+		ac.startDemo()
+
+	case "replay":
+		ac.replay(arg)
+
+	case "bookmark":
+		ac.addBookmark(arg)
+
+	case "bookmarks":
+		ac.showBookmarks()
+
+	case "translate":
+		ac.translate(arg)
+
+	case "summary":
+		ac.summarize(arg)
+
+	case "highlight":
+		ac.highlight(arg)
+
+	case "contact":
+		ac.contact(arg)
+
+	case "contacts":
+		ac.showContacts()
+
+	case "ignore", "unignore":
+		ac.ignore(arg, cmd == "ignore")
+
+	case "verify":
+		ac.verify(arg)
+
+	case "qr":
+		ac.showQR(arg)
+
+	case "hush", "unhush":
+		ac.hushCommand(arg, cmd == "hush")
+
+	case "account":
+		ac.accountCommand(arg)
+
+	case "invite":
+		ac.showInvite()
+
+	case "join":
+		ac.join(arg)
+
+	case "unverify":
+		ac.unverify(arg)
+
+	case "sticker":
+		ac.sendSticker(arg)
+
+	case "template":
+		ac.template(arg)
+
+	case "t":
+		ac.useTemplate(arg)
+
+	case "poll":
+		ac.createPoll(arg)
+
+	case "vote":
+		ac.vote(arg)
 
 	case "latency":
+		if ac.latencyCtrl == nil && ViaTor(DefaultServerURL) {
+			ac.sendSystem("Latency: probe disabled while routed over Tor 🧅 — try /ping <user>.")
+			return
+		}
 		ms := -1
 		if ac.latencyCtrl != nil {
 			ms = ac.latencyCtrl.Current()
@@ -235,6 +710,167 @@ func (ac *AppController) OnCommand(command string) {
 			ac.sendSystem(fmt.Sprintf("Latency: [cyan]%dms[-]  (TCP probe → 1.1.1.1:53, live measurement)", ms))
 		}
 
+	// ── /ping ────────────────────────────────────────────────────────────────
+	// Round-trips a control frame through the relay to another client.
+	case "ping":
+		if ac.App.CurrentUser == nil {
+			ac.sendSystem("No user logged in.")
+			return
+		}
+		if arg == "" {
+			ac.sendSystem("Usage: /ping <user>")
+			return
+		}
+		ac.sendProbe(opPing, ac.resolveAlias(arg))
+
+	// ── /privacy ─────────────────────────────────────────────────────────────
+	// Usage: /privacy whois on|off   (off = refuse remote /whois requests)
+	case "privacy":
+		fields := strings.Fields(strings.ToLower(arg))
+		switch {
+		case len(fields) == 0:
+		case len(fields) == 2 && (fields[1] == "on" || fields[1] == "off") && fields[0] == "whois":
+			ac.whoisPrivate = fields[1] == "off"
+		case len(fields) == 2 && (fields[1] == "on" || fields[1] == "off") && fields[0] == "receipts":
+			ac.receiptsOn = fields[1] == "on"
+		default:
+			ac.sendSystem("Usage: /privacy whois|receipts on|off")
+			return
+		}
+		whois := "[green]on[-]"
+		if ac.whoisPrivate {
+			whois = "[red]off[-] (requests refused)"
+		}
+		receipts := "[red]off[-]"
+		if ac.receiptsOn {
+			receipts = "[green]on[-] (others see when you read; you see who read yours)"
+		}
+		ac.sendSystem("Privacy  ▸  whois: " + whois + "  │  read receipts: " + receipts)
+
+	// ── /quiet ───────────────────────────────────────────────────────────────
+	// Toggles join/leave/rename announcements in the transcript.
+	case "quiet":
+		ac.quietPresence = !ac.quietPresence
+		if ac.quietPresence {
+			ac.sendSystem("Quiet ON — join/leave/rename announcements hidden.")
+		} else {
+			ac.sendSystem("Quiet OFF — presence announcements shown.")
+		}
+
+	// ── /edit, /diffs ────────────────────────────────────────────────────────
+	// Replaces our last message; toggles marking what edits changed.
+	case "edit":
+		ac.editMessage(arg)
+
+	case "retry":
+		ac.retrySends()
+
+	case "diffs":
+		ac.setDiffs(arg)
+
+	case "filters":
+		ac.filters(arg)
+
+	// ── /ephemeral ───────────────────────────────────────────────────────────
+	// Sends a message that every client wipes after the TTL.
+	case "ephemeral":
+		ac.sendEphemeral(arg)
+
+	// ── /schedule ────────────────────────────────────────────────────────────
+	// Queues a message for later: /schedule <when> <text>, list, cancel <id>.
+	case "schedule":
+		ac.scheduleCommand(arg)
+
+	// ── /announce ────────────────────────────────────────────────────────────
+	// Posts a banner every client shows; the relay checks our admin key.
+	case "announce":
+		ac.postAnnouncement(arg)
+
+	// ── /kick /mute /unmute /slowmode ────────────────────────────────────────
+	// Moderation on a relay we hold the admin key for; the relay enforces it.
+	case modKick, modMute, modUnmute, modSlowMode:
+		ac.moderate(cmd, arg)
+
+	// ── /dnd ─────────────────────────────────────────────────────────────────
+	// Do Not Disturb — silences the notification bell, optionally for a while.
+	case "dnd":
+		ac.setDND(arg)
+
+	case "away":
+		ac.setAway(arg)
+
+	case "dashboard":
+		if !hasChat {
+			return
+		}
+		if chat.ToggleDashboard() {
+			chat.UpdateDashboard(ac.App.StatsLog.Since(time.Hour))
+			ac.sendSystem("Dashboard ON — server trends for the last hour.")
+		} else {
+			ac.sendSystem("Dashboard OFF.")
+		}
+
+	// ── /users ───────────────────────────────────────────────────────────────
+	// Lists users seen online — via presence on a relay, via mDNS in LAN mode.
+	case "users":
+		ac.listUsers()
+
+	case "sessionstats":
+		for _, line := range ac.sessionStatsLines() {
+			ac.sendSystem(line)
+		}
+
+	case "reconnect":
+		ac.reconnect()
+	case "netstat":
+		for _, line := range ac.netstatLines() {
+			ac.sendSystem(line)
+		}
+
+	case "memstats":
+		for _, line := range ac.memstatsLines() {
+			ac.sendSystem(line)
+		}
+
+	case "gc":
+		ac.collectGarbage()
+
+	case "stress":
+		ac.stress(arg)
+
+	// ── /detach ──────────────────────────────────────────────────────────────
+	// Hands the relay connection to a background daemon and quits the TUI.
+	// Launching the client again re-attaches and replays what was missed.
+	case "detach":
+		if ac.App.CurrentUser == nil {
+			ac.sendSystem("No user logged in.")
+			return
+		}
+		if ac.lan != nil {
+			ac.sendSystem("/detach needs a relay — not available in LAN mode.")
+			return
+		}
+		serverURL, lastID := DefaultServerURL, ""
+		if ac.netClient != nil {
+			serverURL = ac.netClient.ServerURL()
+			lastID = ac.netClient.LastID()
+		}
+		username := ac.App.CurrentUser.Username
+		ac.stopNetworkClient()
+		s, err := StartDaemon(username, ac.App.GetUserColorTag(username), serverURL, lastID)
+		if err != nil {
+			ac.sendSystem(fmt.Sprintf("[red]Detach failed:[-] %s", views.Escape(err.Error())))
+			ac.startNetworkClientFrom(lastID)
+			return
+		}
+		ac.detached = s
+		ac.app.Stop()
+
+	// ── /update ──────────────────────────────────────────────────────────────
+	// Downloads the latest release, verifies its checksum and swaps it in.
+	case "update":
+		ac.runUpdate()
+
 	case "exit":
 		ac.app.Stop()
 
@@ -248,9 +884,121 @@ func (ac *AppController) OnCommand(command string) {
 func (ac *AppController) sendSystem(text string) {
 	msg := models.NewSystemMessage(text)
 	ac.App.AddMessage(msg)
-	if chat, ok := ac.Views[models.ScreenChat].(*views.ChatView); ok {
-		chat.AddMessage(msg)
+	for _, sink := range ac.messageSinks() {
+		sink.AddMessage(msg)
+	}
+}
+
+// showNotice reports a connection change or background error in the chat
+// view's notice line, or in the transcript when the config asks for that
+// (or there is no chat view). text is trusted tview markup; level is
+// views.NoticeInfo or views.NoticeError.
+func (ac *AppController) showNotice(text string, level int) {
+	chat, ok := ac.chatView()
+	if !ok || ac.App.Config.Notices == models.NoticesTranscript {
+		ac.sendSystem(text)
+		return
+	}
+	chat.ShowNotice(text, level)
+}
+
+// listUsers prints the online user list, with peer addresses in LAN mode.
+func (ac *AppController) listUsers() {
+	addrs := map[string]string{}
+	if ac.lan != nil {
+		for _, p := range ac.lan.Peers() {
+			addrs[p.Username] = p.Addr
+		}
+	}
+	var names []string
+	for name, u := range ac.App.Users {
+		if u.IsOnline && u != ac.App.CurrentUser {
+			names = append(names, name)
+		}
+	}
+	// Favorites first.
+	favorite := func(name string) bool { c, ok := ac.App.Contacts[name]; return ok && c.Favorite }
+	sort.Slice(names, func(i, j int) bool {
+		if fi, fj := favorite(names[i]), favorite(names[j]); fi != fj {
+			return fi
+		}
+		return names[i] < names[j]
+	})
+
+	ac.sendSystem(fmt.Sprintf("Online (%d besides you):", len(names)))
+	for _, name := range names {
+		mark := "  "
+		if favorite(name) {
+			mark = "[yellow]★[-] "
+		}
+		line := mark + views.Colorize(ac.App.GetUserColorTag(name), name)
+		if alias, ok := ac.App.Config.Aliases[name]; ok {
+			line += "  [::i]" + views.Escape(alias) + "[::-]"
+		}
+		if addr, ok := addrs[name]; ok {
+			line += "  [dim]" + addr + "[-]"
+		}
+		if ac.App.Users[name].Away {
+			line += "  [dim]🌙 away[-]"
+		}
+		ac.sendSystem(line)
+	}
+}
+
+// protocolLine summarises the handshake for /info.
+func (ac *AppController) protocolLine() string {
+	switch {
+	case ac.caps == nil:
+		return fmt.Sprintf("  [cyan]Protocol [-]v%d  [dim](relay handshake pending)[-]", ProtocolVersion)
+	case ac.caps.Legacy:
+		return fmt.Sprintf("  [cyan]Protocol [-]v%d  [dim]│[-]  relay: legacy (no handshake) · %s",
+			ProtocolVersion, ac.caps.FeatureList())
+	}
+	return fmt.Sprintf("  [cyan]Protocol [-]v%d  [dim]│[-]  relay: %s v%d · %s",
+		ProtocolVersion, views.Escape(ac.caps.Server), ac.caps.Protocol, views.Escape(ac.caps.FeatureList()))
+}
+
+// terminalLine says which screen profile is in use for /info, given the
+// "terminal" setting.
+func terminalLine(setting string) string {
+	profile := models.TerminalFull
+	if ui.LegacyTerminal() {
+		profile = models.TerminalLegacy
 	}
+	if setting == "" || setting == models.TerminalAuto {
+		return profile + "  [dim](detected; set \"terminal\" to override)[-]"
+	}
+	return profile
+}
+
+// sessionStatsLines renders the /sessionstats panel.
+func (ac *AppController) sessionStatsLines() []string {
+	s := ac.App.Session.Snapshot()
+	avg := "--"
+	if s.AvgLatency >= 0 {
+		avg = fmt.Sprintf("%dms", s.AvgLatency)
+	}
+	lines := []string{
+		"[dim]┌─ Session ───────────────────────────────────────┐[-]",
+		fmt.Sprintf("  [cyan]Uptime       [-]%v", s.Uptime),
+		fmt.Sprintf("  [cyan]Sent         [-]%d msgs  ·  %s", s.MessagesSent, formatBytes(s.BytesSent)),
+		fmt.Sprintf("  [cyan]Received     [-]%d msgs  ·  %s", s.MessagesRecv, formatBytes(s.BytesRecv)),
+		fmt.Sprintf("  [cyan]Peak active  [-]%d", s.PeakActive),
+		fmt.Sprintf("  [cyan]Reconnects   [-]%d", s.Reconnects),
+		fmt.Sprintf("  [cyan]Avg latency  [-]%s", avg),
+	}
+	lines = append(lines, frameLines()...)
+	return append(lines, "[dim]└─────────────────────────────────────────────────┘[-]")
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
 }
 
 func (ac *AppController) countUserMessages(username string) int {
@@ -263,78 +1011,443 @@ func (ac *AppController) countUserMessages(username string) int {
 	return n
 }
 
+// ── Remote whois / ping ───────────────────────────────────────────────────────
+
+// sendProbe sends a whois or ping request to target and arms a timeout.
+// Must be called from the tview event loop.
+func (ac *AppController) sendProbe(op, target string) {
+	if !ac.linked() {
+		ac.sendSystem("Not connected to a relay.")
+		return
+	}
+	nonce := newNonce()
+	ac.probes[nonce] = &probe{op: op, target: target, sent: time.Now()}
+	ac.sendControl(controlFrame{Op: op, To: target, Nonce: nonce})
+	ac.sendSystem(fmt.Sprintf("[dim]%s → %s…[-]", op, views.Escape(target)))
+
+	ui.SafeGo("sendProbe", func() {
+		time.Sleep(probeTimeout)
+		ui.SafeQueueUpdateDraw(ac.app, "sendProbe", func() {
+			if p, ok := ac.probes[nonce]; ok {
+				delete(ac.probes, nonce)
+				ac.sendSystem(fmt.Sprintf("%s %s: no reply within %v.", p.op, views.Escape(p.target), probeTimeout))
+			}
+		})
+	})
+}
+
+func (ac *AppController) sendControl(f controlFrame) {
+	if ac.typedFrames() {
+		ac.transmit(controlWireType(f.Op), encodeControlPayload(f))
+		return
+	}
+	ac.transmit(msgTypeChat, encodeControl(f))
+}
+
+// transmit sends content as the local user over whichever link is active:
+// the relay, or LAN peers in LAN mode. Must be called from the tview event loop.
+func (ac *AppController) transmit(msgType, content string) {
+	if ac.App.CurrentUser == nil {
+		return
+	}
+	me := ac.App.CurrentUser.Username
+	color := ac.App.GetUserColorTag(me)
+	switch {
+	case ac.lan != nil:
+		ac.lan.Send(msgType, me, content, color)
+	case ac.netClient != nil:
+		ac.netClient.SendTyped(msgType, me, content, color)
+	}
+}
+
+// linked reports whether messages can go anywhere right now.
+func (ac *AppController) linked() bool {
+	return ac.lan != nil || ac.netClient != nil
+}
+
+// typedFrames reports whether the link carries typed presence and control
+// messages; otherwise frames are smuggled inside chat content. LAN peers
+// always run a client that understands them.
+func (ac *AppController) typedFrames() bool {
+	return ac.lan != nil || (ac.caps != nil && ac.caps.Supports("types"))
+}
+
+// handleControl acts on a control frame addressed to the local user.
+// Must be called from the tview event loop.
+func (ac *AppController) handleControl(from string, f *controlFrame) {
+	if ac.App.CurrentUser == nil {
+		return
+	}
+	if f.To == "" {
+		switch f.Op {
+		case opEphemeral:
+			ac.handleEphemeral(from, f)
+		case opPoll:
+			ac.handlePoll(from, f)
+		case opVote:
+			ac.handleVote(from, f)
+		case opEdit:
+			ac.handleEdit(from, f)
+		case opReact:
+			ac.handleReact(from, f)
+		default:
+			ac.handlePresence(from, f)
+		}
+		return
+	}
+	if f.To != ac.App.CurrentUser.Username {
+		return
+	}
+	me := ac.App.CurrentUser.Username
+
+	switch f.Op {
+	case opWhois:
+		if ac.whoisPrivate {
+			ac.sendControl(controlFrame{Op: opWhoisRefused, To: from, Nonce: f.Nonce})
+			return
+		}
+		reply := controlFrame{
+			Op:      opWhoisReply,
+			To:      from,
+			Nonce:   f.Nonce,
+			Color:   models.ColorName(ac.App.GetUserColorTag(me)),
+			Version: ClientVersion,
+			IdleMs:  time.Since(ac.lastInput).Milliseconds(),
+			Zone:    ac.sharedZone(),
+		}
+		ac.signIdentity(&reply)
+		ac.sendControl(reply)
+
+	case opPing:
+		ac.sendControl(controlFrame{Op: opPong, To: from, Nonce: f.Nonce})
+
+	case opSeen:
+		ac.handleSeen(from, f)
+
+	case opWhoisReply, opWhoisRefused, opPong:
+		if f.Op == opWhoisReply {
+			ac.checkIdentity(from, f)
+		}
+		suffix := ac.aliasSuffix(from) + "  |  key: " + ac.trustText(from)
+		from = views.Escape(from)
+		p, ok := ac.probes[f.Nonce]
+		if !ok {
+			return // late reply or not ours
+		}
+		delete(ac.probes, f.Nonce)
+		rtt := time.Since(p.sent).Milliseconds()
+
+		switch f.Op {
+		case opPong:
+			ac.sendSystem(fmt.Sprintf("Pong from %s  ▸  rtt: [cyan]%dms[-]", from, rtt))
+		case opWhoisRefused:
+			ac.sendSystem(fmt.Sprintf("Whois  ▸  %s declined to share details.", from))
+		default:
+			colorTag := views.ColorTag(models.ParseColorToTag(f.Color))
+			idle := (time.Duration(f.IdleMs) * time.Millisecond).Round(time.Second)
+			ac.noteZone(p.target, f.Zone)
+			ac.sendSystem(fmt.Sprintf(
+				"Whois  ▸  user: %s%s[-]%s  |  color: %s  |  client: %s  |  rtt: %dms  |  idle: %v%s",
+				colorTag, from, suffix, strings.Trim(colorTag, "[]"), views.Escape(f.Version), rtt, idle, zoneSuffix(f.Zone),
+			))
+		}
+	}
+}
+
 // startNetworkClient creates and starts a NetworkClient using DefaultServerURL.
 func (ac *AppController) startNetworkClient() {
+	ac.startNetworkClientFrom("")
+}
+
+// startNetworkClientFrom is startNetworkClient with the poll cursor seeded to
+// lastID, so a resumed session does not re-fetch already delivered messages.
+func (ac *AppController) startNetworkClientFrom(lastID string) {
 	ac.stopNetworkClient()
+	ac.markLive()
 
 	ac.netClient = NewNetworkClient(
 		ac.app,
 		DefaultServerURL,
 
 		// onMessage: called from the poll goroutine for each decrypted incoming message.
-		func(username, content, colorTag string) {
-			if chat, ok := ac.Views[models.ScreenChat].(*views.ChatView); ok {
-				// AddIncomingMessage already wraps in QueueUpdateDraw — safe here.
-				chat.AddIncomingMessage(username, content, colorTag)
-			}
-		},
+		ac.onIncoming,
 
 		// onStatusChange: called from the poll goroutine on connect/error/reconnect.
 		func(connected bool, msg string) {
-			ac.app.QueueUpdateDraw(func() {
+			if connected {
+				ac.App.Session.RecordConnect()
+			}
+			level := views.NoticeError
+			if connected {
+				level = views.NoticeInfo
+			}
+			ui.SafeQueueUpdateDraw(ac.app, "startNetworkClientFrom", func() {
 				ac.App.IsConnected = connected
-				ac.sendSystem(msg)
-				if chat, ok := ac.Views[models.ScreenChat].(*views.ChatView); ok {
+				ac.showNotice(views.Escape(msg), level)
+				if chat, ok := ac.chatView(); ok {
 					chat.SetOnlineStatus(connected)
 				}
+				if connected {
+					ac.clearReconnect()
+				}
 			})
 		},
 	)
 
+	ac.netClient.OnRejected(func(reason string) {
+		ui.SafeQueueUpdateDraw(ac.app, "startNetworkClientFrom", func() {
+			ac.showNotice("[red]Message not delivered:[-] "+views.Escape(reason), views.NoticeError)
+		})
+	})
+	ac.netClient.OnProofOfWork(func(solving bool) {
+		segment := ""
+		if solving {
+			segment = "  [yellow]⛏ anti-spam check…[-]"
+		}
+		ui.SafeQueueUpdateDraw(ac.app, "startNetworkClientFrom", func() {
+			if chat, ok := ac.chatView(); ok {
+				chat.SetSegment("pow", segment)
+			}
+		})
+	})
+	nc := ac.netClient
+	ac.netClient.OnReconnect(func(at time.Time, attempt int) {
+		ac.showReconnect(nc, at, attempt)
+	})
+	ac.netClient.SetLastID(lastID)
+	ac.netClient.Configure(ac.App.Config)
 	ac.netClient.Start()
-	go ac.statsPollerLoop()
+	ac.resetHistory()
+	ac.applyTorRouting()
+	ac.caps = nil
+	ac.statsKnown = false
+	ui.SafeGo("handshake", func() { ac.negotiate(nc) })
+	ui.SafeGo("stats poller", func() { ac.statsPollerLoop(nc) })
 }
 
-func (ac *AppController) statsPollerLoop() {
+// onIncoming handles one message from the relay or a LAN peer.
+// Called from network goroutines.
+func (ac *AppController) onIncoming(msg *pollMessage) {
+	if msg.Type == msgTypeSystem {
+		ui.SafeQueueUpdateDraw(ac.app, "onIncoming", func() {
+			ac.handleRelayEvent(msg.Content)
+		})
+		return
+	}
+	if msg.Type == msgTypeAnnouncement {
+		ac.App.Session.RecordReceived(msg.Content)
+		ui.SafeQueueUpdateDraw(ac.app, "onIncoming", func() {
+			ac.showAnnouncement(msg.Username, msg.Content)
+		})
+		return
+	}
+	if msg.Type == msgTypeSticker {
+		ac.App.Session.RecordReceived(msg.Content)
+		ui.SafeQueueUpdateDraw(ac.app, "onIncoming", func() {
+			ac.handleSticker(msg.Username, msg.Content, msg.Color, messageTime(msg))
+		})
+		return
+	}
+	if f, ok := decodeControlMessage(msg); ok {
+		ui.SafeQueueUpdateDraw(ac.app, "onIncoming", func() {
+			ac.handleControl(msg.Username, f)
+		})
+		return
+	}
+	if msg.Length > 0 && msg.Content == "" {
+		ac.showHeader(msg)
+		return
+	}
+	if !isChatMessage(msg) {
+		log.Printf("onMessage: ignoring message id=%s of type %q", msg.ID, msg.Type)
+		return
+	}
+	for _, m := range ac.parts.Add(msg) {
+		ac.deliverChat(m)
+	}
+}
+
+// deliverChat shows an incoming chat message, joined from its parts if it
+// was long, by way of the inbox (see inbox.go). Ignored senders are dropped
+// here, since a batch from the inbox is shown and filed without
+// showIncoming. Called from network and timer goroutines.
+func (ac *AppController) deliverChat(msg *pollMessage) {
+	self, _ := ac.self.Load().(string)
+	if msg.Username != self {
+		if ac.isIgnored(msg.Username) {
+			return
+		}
+		if ac.hush.catch(msg.Username, msg.Content, self) || ac.collapseRepeat(msg) {
+			return // see hush.go and repeats.go
+		}
+	}
+	ac.inbox.push(msg)
+}
+
+// showIncoming shows one incoming chat message. Called from the inbox
+// goroutine.
+func (ac *AppController) showIncoming(msg *pollMessage) {
+	if ac.isIgnored(msg.Username) {
+		return
+	}
+	ac.App.Session.RecordReceived(msg.Content)
+	// The history entry's ID tags the line, so /translate can find it.
+	entry := ac.incomingEntry(msg)
+	sinks := ac.messageSinks()
+	if len(sinks) > 0 {
+		// Queued ahead of the line, so the render that adds it reports it.
+		ui.SafeQueueUpdate(ac.app, "watchIncoming", func() {
+			ac.awaitSight(msg.Username, msg.ID, entry.ID)
+		})
+	}
+	if !entry.Impostor {
+		for _, sink := range sinks {
+			// AddIncomingMessage already wraps in QueueUpdateDraw — safe here.
+			sink.AddIncomingMessage(entry.ID, entry.Timestamp, msg.Username, entry.Content, msg.Color)
+		}
+	}
+	if len(sinks) > 0 {
+		ui.SafeQueueUpdateDraw(ac.app, "showIncoming", func() {
+			ac.recordIncoming(msg, entry)
+			ac.repeats.shown(msg, entry.ID)
+			ac.showRepeats()
+			if entry.Impostor {
+				// In its own style, so never animated.
+				for _, sink := range sinks {
+					sink.AddMessage(entry)
+				}
+				ac.noteImpostor(entry)
+			}
+			ac.rememberEditable(msg.ID, entry)
+			ac.seeContact(msg.Username, true)
+			ac.noteActivity()
+			ac.notify(msg.Username, msg.Content)
+			if !entry.Impostor {
+				ac.autoReply(msg.Username, msg.Content)
+			}
+		})
+	}
+}
+
+// negotiate runs the protocol handshake for nc and records the result.
+// Runs as a goroutine; a failed handshake leaves caps nil and is retried
+// the next time the network client is (re)started.
+func (ac *AppController) negotiate(nc *NetworkClient) {
+	caps, err := nc.Handshake()
+	if err != nil {
+		log.Printf("negotiate: handshake failed: %v", err)
+		return
+	}
+	ui.SafeQueueUpdateDraw(ac.app, "negotiate", func() {
+		if ac.netClient != nc {
+			return // client was replaced while we were talking to the relay
+		}
+		ac.caps = caps
+		ac.recordStats()
+		ac.applyClockOffset(caps)
+		if caps.Protocol > ProtocolVersion {
+			ac.sendSystem(fmt.Sprintf(
+				"Relay speaks protocol v%d (this client: v%d) — some features may be unavailable. Consider updating.",
+				caps.Protocol, ProtocolVersion))
+		}
+	})
+}
+
+func (ac *AppController) statsPollerLoop(nc *NetworkClient) {
 	// Poll /api/stats every 8 seconds and push results to the chat header.
-	// Runs as a goroutine alongside the poll loop; stops when netClient stops.
+	// Runs as a goroutine alongside the poll loop; stops when nc stops.
+	// A relay without the endpoint gets a header without the stats row,
+	// and keeps being asked, so the row comes back once it answers.
 	ticker := time.NewTicker(8 * time.Second)
 	defer ticker.Stop()
 
+	known, supported := false, false
+	fetch := func() {
+		if ac.saver.Load() {
+			return // bandwidth saver, see quality.go
+		}
+		ok, answered := ac.fetchAndPushStats(nc)
+		if answered && (!known || ok != supported) {
+			known, supported = true, ok
+			ac.statsChanged(nc, ok)
+		}
+	}
+
 	// Fetch once immediately so header shows data before the first tick.
-	ac.fetchAndPushStats()
+	fetch()
 
 	for {
 		select {
+		case <-nc.stopCh:
+			return
+		case <-tasks.Stopping():
+			return
 		case <-ticker.C:
-			if ac.netClient == nil {
-				return
-			}
-			ac.fetchAndPushStats()
+			fetch()
 		}
 	}
 }
 
-func (ac *AppController) fetchAndPushStats() {
-	if ac.netClient == nil {
-		return
+// fetchAndPushStats fetches the relay stats once and shows them, or hides
+// them if the relay has no /api/stats. answered is false when the fetch
+// failed for some other reason; ok is whether the stats were there.
+func (ac *AppController) fetchAndPushStats(nc *NetworkClient) (ok, answered bool) {
+	stats, err := nc.FetchStats()
+	if errors.Is(err, ErrStatsUnsupported) {
+		for _, sink := range ac.statsSinks() {
+			sink.StatsUnavailable(nc.ServerURL())
+		}
+		return false, true
 	}
-	stats, err := ac.netClient.FetchStats()
 	if err != nil {
-		return // non-critical — silently skip bad fetches
+		return false, false // non-critical — silently skip bad fetches
 	}
-	chat, ok := ac.Views[models.ScreenChat].(*views.ChatView)
-	if !ok {
-		return
+	ac.App.Session.RecordActive(stats.ActiveClients)
+	ac.App.StatsLog.Add(models.StatsSample{
+		At:            time.Now(),
+		TotalMessages: stats.ChatStats.TotalMessages,
+		Active:        stats.ActiveClients,
+		Waiting:       stats.ChatStats.WaitingClients,
+	})
+	for _, sink := range ac.statsSinks() {
+		sink.UpdateStats(
+			stats.ChatStats.TotalMessages,
+			stats.ActiveClients,
+			stats.ChatStats.WaitingClients,
+			stats.ChatStats.MaxWaiters, // reuse maxWaiters as maxMsgs (server exposes 1000 for both)
+			stats.ChatStats.MaxWaiters,
+			nc.ServerURL(),
+		)
+		sink.UpdateDashboard(ac.App.StatsLog.Since(time.Hour))
+	}
+	return true, true
+}
+
+// statsChanged records, from the stats poller, whether nc's relay has
+// /api/stats: on the first answer and whenever that changes.
+func (ac *AppController) statsChanged(nc *NetworkClient, ok bool) {
+	log.Printf("stats: %s serves /api/stats: %v", nc.ServerURL(), ok)
+	ui.SafeQueueUpdate(ac.app, "statsChanged", func() {
+		if ac.netClient != nc {
+			return
+		}
+		ac.statsKnown, ac.statsOK = true, ok
+		ac.recordStats()
+	})
+}
+
+// recordStats sets the "stats" feature of the handshake result to what the
+// stats poller found, so /info shows what the relay does rather than what it
+// advertised. Must be called from the tview event loop.
+func (ac *AppController) recordStats() {
+	if ac.caps == nil || !ac.statsKnown {
+		return // whichever of the two comes last records it
+	}
+	if ac.statsOK {
+		ac.caps.Features["stats"] = true
+	} else {
+		delete(ac.caps.Features, "stats")
 	}
-	chat.UpdateStats(
-		stats.ChatStats.TotalMessages,
-		stats.ActiveClients,
-		stats.ChatStats.WaitingClients,
-		stats.ChatStats.MaxWaiters, // reuse maxWaiters as maxMsgs (server exposes 1000 for both)
-		stats.ChatStats.MaxWaiters,
-		ac.netClient.ServerURL(),
-	)
 }
 
 func (ac *AppController) stopNetworkClient() {
@@ -342,26 +1455,77 @@ func (ac *AppController) stopNetworkClient() {
 		ac.netClient.Stop()
 		ac.netClient = nil
 	}
+	ac.clearReconnect()
+}
+
+// applyTorRouting updates the 🧅 footer indicator for the current relay.
+// The latency probe dials 1.1.1.1 directly, so it is switched off while
+// traffic goes over Tor. Must be called from the tview event loop.
+func (ac *AppController) applyTorRouting() {
+	tor := ac.lan == nil && ViaTor(DefaultServerURL)
+	if chat, ok := ac.chatView(); ok {
+		segment := ""
+		if tor {
+			segment = "  [purple]🧅 tor[-]"
+		}
+		chat.SetSegment("tor", segment)
+	}
+	if tor && ac.latencyCtrl != nil {
+		ac.latencyCtrl.Stop()
+		ac.latencyCtrl = nil
+	} else if !tor && ac.latencyCtrl == nil && ac.App.CurrentUser != nil {
+		ac.startLatencyController()
+	}
 }
 
 func (ac *AppController) startLatencyController() {
+	if ViaTor(DefaultServerURL) {
+		return // see applyTorRouting
+	}
 	if ac.latencyCtrl != nil {
 		ac.latencyCtrl.Stop()
 	}
 	ac.latencyCtrl = NewLatencyController()
 	ac.latencyCtrl.Start(func(ms int) {
+		ac.App.Session.RecordLatency(ms)
 		ac.App.Latency = ms
-		if chat, ok := ac.Views[models.ScreenChat].(*views.ChatView); ok {
-			chat.UpdateLatency(ms)
+		for _, sink := range ac.statsSinks() {
+			sink.UpdateLatency(ms)
 		}
+		ui.SafeQueueUpdateDraw(ac.app, "assessQuality", ac.assessQuality)
 	})
 }
 
+// Shutdown announces that we left and stops background services. Called by
+// main once the tview app has stopped; a /detach hands the session to the
+// daemon instead, so nothing is announced in that case.
+func (ac *AppController) Shutdown() {
+	if ac.detached == nil {
+		ac.announceLeave()
+	}
+	if ac.App.CurrentUser != nil {
+		ac.stashDraft()
+		ac.App.SaveDrafts()
+		ac.App.SaveContacts()
+	}
+	ac.stopQuietHours()
+	ac.StopBot()
+
+	for _, line := range ac.sessionStatsLines() {
+		log.Printf("sessionstats: %s", views.Strip(line))
+	}
+}
+
 // StopBot stops all background services: network client and latency controller.
 func (ac *AppController) StopBot() {
+	ac.stopSchedules()
+	ac.stopFailback()
+	ac.stopParked()
+	ac.stopLAN()
 	ac.stopNetworkClient()
 	if ac.latencyCtrl != nil {
 		ac.latencyCtrl.Stop()
 		ac.latencyCtrl = nil
 	}
+	ac.stopQualityTicker()
 }
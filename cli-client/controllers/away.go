@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cli-client/models"
+	"cli-client/views"
+)
+
+// ── Away ──────────────────────────────────────────────────────────────────────
+//
+// /away [reason] tells everyone we are away, as quiet hours do, until /away
+// off. With "away_reply" on (/away reply on), a message that @mentions us
+// while we are away, in Do Not Disturb or inside quiet hours gets one
+// automatic reply with the reason — at most one per sender an hour. The
+// reply is marked, and a marked message never gets one, so two clients
+// that are both away do not answer each other.
+
+// autoReplyMark starts every automatic reply.
+const autoReplyMark = "(auto-reply)"
+
+// autoReplyEvery is how long a sender waits for a second automatic reply.
+const autoReplyEvery = time.Hour
+
+// awayState is what /away set. tview event loop only.
+type awayState struct {
+	on      bool
+	reason  string               // "" = none given
+	replied map[string]time.Time // sender → our last automatic reply
+}
+
+// setAway handles /away. Usage: /away [reason]  /away off  /away reply on|off
+// Must be called from the tview event loop.
+func (ac *AppController) setAway(arg string) {
+	if sub, rest, _ := strings.Cut(arg, " "); strings.EqualFold(sub, "reply") {
+		ac.setAutoReply(strings.ToLower(strings.TrimSpace(rest)))
+		return
+	}
+	if strings.EqualFold(arg, "off") || (arg == "" && ac.away.on) {
+		if !ac.away.on {
+			ac.sendSystem("You are not away.")
+			return
+		}
+		ac.away.on, ac.away.reason = false, ""
+		if !ac.notifier.Quiet() {
+			ac.announce(opBack)
+		}
+		if chat, ok := ac.chatView(); ok {
+			chat.SetSegment("away", "")
+		}
+		ac.sendSystem("You are back.")
+		return
+	}
+
+	if !ac.away.on && !ac.notifier.Quiet() {
+		ac.announce(opAway)
+	}
+	ac.away.on, ac.away.reason = true, arg
+	label := "away"
+	if arg != "" {
+		label += ": " + arg
+	}
+	if chat, ok := ac.chatView(); ok {
+		chat.SetSegment("away", "  [blue]💤 "+views.Escape(label)+"[-]")
+	}
+	msg := "You are " + views.Escape(label) + " — /away off when you are back."
+	if !ac.App.Config.AwayReply {
+		msg += " [dim]/away reply on answers mentions for you.[-]"
+	}
+	ac.sendSystem(msg)
+}
+
+// setAutoReply handles /away reply.
+func (ac *AppController) setAutoReply(arg string) {
+	switch arg {
+	case "":
+		state := "off"
+		if ac.App.Config.AwayReply {
+			state = "on"
+		}
+		ac.sendSystem("Automatic replies are " + state + "  —  /away reply on|off")
+		return
+	case "on", "off":
+	default:
+		ac.sendSystem("Usage: /away reply on|off")
+		return
+	}
+	ac.App.Config.AwayReply = arg == "on"
+	if err := models.SaveConfigKey("away_reply", ac.App.Config.AwayReply); err != nil {
+		ac.sendSystem("[red]Setting not saved:[-] " + views.Escape(err.Error()) + " — it lasts until you quit.")
+	}
+	if ac.App.Config.AwayReply {
+		ac.sendSystem("Automatic replies ON — a mention while you are away, in Do Not Disturb or quiet hours gets one, once an hour per sender.")
+	} else {
+		ac.sendSystem("Automatic replies OFF.")
+	}
+}
+
+// awayReason says why we are not answering, if we are not.
+func (ac *AppController) awayReason() (string, bool) {
+	if ac.away.on {
+		if ac.away.reason == "" {
+			return "away", true
+		}
+		return "away: " + ac.away.reason, true
+	}
+	if on, until := ac.notifier.DND(); on {
+		if until.IsZero() {
+			return "do not disturb", true
+		}
+		return "do not disturb until " + until.Format("15:04"), true
+	}
+	if ac.notifier.Quiet() {
+		return "quiet hours until " + ac.App.Config.QuietHours.End, true
+	}
+	return "", false
+}
+
+// autoReply answers a message from sender that mentions us, if we are away
+// and automatic replies are on. Must be called from the tview event loop.
+func (ac *AppController) autoReply(sender, content string) {
+	if !ac.App.Config.AwayReply || ac.App.CurrentUser == nil {
+		return
+	}
+	me := ac.App.CurrentUser.Username
+	if sender == me || !mentions(content, me) || strings.HasPrefix(content, autoReplyMark) {
+		return
+	}
+	reason, away := ac.awayReason()
+	if !away {
+		return
+	}
+	now := time.Now()
+	if last, ok := ac.away.replied[sender]; ok && now.Sub(last) < autoReplyEvery {
+		return
+	}
+	if ac.away.replied == nil {
+		ac.away.replied = make(map[string]time.Time)
+	}
+	for user, at := range ac.away.replied {
+		if now.Sub(at) >= autoReplyEvery {
+			delete(ac.away.replied, user)
+		}
+	}
+	ac.away.replied[sender] = now
+	ac.sendChat(fmt.Sprintf("%s @%s I'm %s", autoReplyMark, sender, reason))
+}
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"cli-client/models"
+	"cli-client/ui"
+)
+
+// ── Lazy bodies ───────────────────────────────────────────────────────────────
+//
+// With poll.headers set and a relay that advertises "lazy-bodies", polls
+// carry long chat messages as headers only — ID, sender and body length —
+// and the client shows a placeholder line for each. Once that line is on
+// screen the chat view reports it and the body is fetched from /api/bodies,
+// so skimming a busy room costs only the messages actually looked at.
+//
+// A header-only message is not notified, acknowledged or filed in the
+// history until its body is in; one the relay expired first is shown as
+// expired. Parts of a long message load one by one, so they are shown as
+// sent ("[1/3] …") instead of being joined.
+
+// maxPendingBodies bounds the placeholders remembered; the oldest are
+// forgotten first and stay placeholders.
+const maxPendingBodies = 500
+
+// pendingBody is a chat message shown as a placeholder.
+type pendingBody struct {
+	pm       *pollMessage
+	entry    *models.Message // the line and history entry
+	fetching bool
+}
+
+type bodyEntry struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+}
+
+// FetchBodies asks the relay for the bodies of the header-only messages
+// ids, at most 50. Bodies the relay no longer holds are absent from the
+// result. Safe to call from any goroutine; blocks for one request.
+func (nc *NetworkClient) FetchBodies(ids []string) (map[string]string, error) {
+	params := url.Values{}
+	params.Set("access_key", serverAccessKey)
+	params.Set("client_id", nc.clientID)
+	params.Set("ids", strings.Join(ids, ","))
+
+	resp, err := nc.get(nc.shortClient, "/api/bodies?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer drainClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bodies HTTP %d", resp.StatusCode)
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxPollBody+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > maxPollBody {
+		nc.drops.add(dropOversized)
+		return nil, fmt.Errorf("bodies answer over %d bytes", maxPollBody)
+	}
+	if reason := checkWire(raw, maxWireDepth); reason != "" {
+		nc.drops.add(reason)
+		return nil, fmt.Errorf("parse bodies: %s", reason)
+	}
+	var entries []bodyEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		nc.drops.add(dropSyntax)
+		return nil, fmt.Errorf("parse bodies: %w", err)
+	}
+
+	bodies := make(map[string]string, len(entries))
+	for _, e := range entries {
+		if reason := cleanContent(&e.Content, &nc.drops); reason != "" || e.Content == "" {
+			if reason == "" {
+				reason = dropMissing
+			}
+			nc.drops.add(reason)
+			continue
+		}
+		bodies[e.ID] = e.Content
+	}
+	return bodies, nil
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// showHeader shows a header-only chat message as a placeholder line until
+// fetchBodies fills it in. Called from network goroutines.
+func (ac *AppController) showHeader(pm *pollMessage) {
+	if ac.isIgnored(pm.Username) {
+		return
+	}
+	ui.SafeQueueUpdateDraw(ac.app, "showHeader", func() {
+		entry := models.NewMessage(pm.Username, "")
+		entry.Timestamp = messageTime(pm)
+		entry.Color = ac.incomingColor(pm)
+		entry.Pending = pm.Length
+
+		ac.pendingBodies[entry.ID] = &pendingBody{pm: pm, entry: entry}
+		ac.pendingOrder = append(ac.pendingOrder, entry.ID)
+		if len(ac.pendingOrder) > maxPendingBodies {
+			delete(ac.pendingBodies, ac.pendingOrder[0])
+			ac.pendingOrder = ac.pendingOrder[1:]
+		}
+		for _, sink := range ac.messageSinks() {
+			sink.AddMessage(entry)
+		}
+		ac.noteActivity()
+	})
+}
+
+// fetchBodies loads the bodies of the placeholder lines ids, which just came
+// on screen. Lines already being fetched are skipped. Must be called from
+// the tview event loop.
+func (ac *AppController) fetchBodies(ids []string) {
+	nc := ac.netClient
+	if nc == nil {
+		return
+	}
+	var batch []*pendingBody
+	for _, id := range ids {
+		if p, ok := ac.pendingBodies[id]; ok && !p.fetching && len(batch) < 50 {
+			p.fetching = true
+			batch = append(batch, p)
+		}
+	}
+	if len(batch) == 0 {
+		return
+	}
+	relayIDs := make([]string, len(batch))
+	for i, p := range batch {
+		relayIDs[i] = p.pm.ID
+	}
+
+	ui.SafeGo("fetchBodies", func() {
+		bodies, err := nc.FetchBodies(relayIDs)
+		ui.SafeQueueUpdateDraw(ac.app, "fetchBodies", func() {
+			for _, p := range batch {
+				if _, ok := ac.pendingBodies[p.entry.ID]; !ok {
+					continue // forgotten meanwhile
+				}
+				if err != nil {
+					p.fetching = false // tried again when next on screen
+					continue
+				}
+				ac.fillBody(p, bodies[p.pm.ID])
+			}
+			if err != nil {
+				log.Printf("fetchBodies: %v", err)
+			}
+		})
+	})
+}
+
+// fillBody replaces the placeholder for p with content, or marks it expired
+// if the relay had none, and then handles it like any incoming message.
+// Must be called from the tview event loop.
+func (ac *AppController) fillBody(p *pendingBody, content string) {
+	delete(ac.pendingBodies, p.entry.ID)
+	for i, id := range ac.pendingOrder {
+		if id == p.entry.ID {
+			ac.pendingOrder = append(ac.pendingOrder[:i], ac.pendingOrder[i+1:]...)
+			break
+		}
+	}
+
+	p.entry.Pending = 0
+	if content == "" {
+		p.entry.Expired = true
+	}
+	p.entry.Content = ac.filterText(content)
+	if content != "" {
+		ac.awaitSight(p.pm.Username, p.pm.ID, p.entry.ID)
+	}
+	for _, sink := range ac.messageSinks() {
+		sink.UpdateMessage(p.entry)
+	}
+	if content == "" {
+		return
+	}
+
+	p.pm.Content = content
+	ac.App.Session.RecordReceived(content)
+	ac.recordIncoming(p.pm, p.entry)
+	ac.notify(p.pm.Username, content)
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cli-client/models"
+	"cli-client/views"
+)
+
+// ── Bookmarks ─────────────────────────────────────────────────────────────────
+//
+// /bookmark [n] saves the nth latest chat message of the conversation (the
+// latest by default) to models.BookmarksFile, with a snippet so it still
+// reads sensibly once the session history is gone. /bookmarks opens a pane
+// listing them; choosing one redraws it with the messages around it, if the
+// session history still has them.
+
+const (
+	bookmarkSnippet = 80 // runes of content kept with a bookmark
+	bookmarkContext = 3  // messages shown either side when jumping back
+)
+
+// addBookmark handles /bookmark. Must be called from the tview event loop.
+func (ac *AppController) addBookmark(arg string) {
+	n := 1
+	if arg != "" {
+		v, err := strconv.Atoi(arg)
+		if err != nil || v < 1 {
+			ac.sendSystem("Usage: /bookmark [n]  —  n = 1 for the latest message, 2 for the one before…")
+			return
+		}
+		n = v
+	}
+	room := ac.conversationKey()
+	msg := ac.App.History.Last(room, n)
+	if msg == nil {
+		ac.sendSystem("No such message in this session's history.")
+		return
+	}
+	ac.bookmarkMessage(room, msg)
+}
+
+// bookmarkMessage saves msg, said in conversation room, as a bookmark.
+func (ac *AppController) bookmarkMessage(room string, msg *models.Message) {
+	for _, b := range ac.App.Bookmarks {
+		if b.ID == msg.ID {
+			ac.sendSystem("Already bookmarked.")
+			return
+		}
+	}
+
+	snippet := strings.Join(strings.Fields(msg.Content), " ")
+	if r := []rune(snippet); len(r) > bookmarkSnippet {
+		snippet = string(r[:bookmarkSnippet-1]) + "…"
+	}
+	ac.App.Bookmarks = append(ac.App.Bookmarks, models.Bookmark{
+		ID:       msg.ID,
+		Room:     room,
+		Username: msg.Username,
+		Snippet:  snippet,
+		At:       msg.Timestamp,
+		Saved:    time.Now(),
+	})
+	ac.App.SaveBookmarks()
+	ac.sendSystem(fmt.Sprintf("[yellow]★[-] Bookmarked %s: %s  [dim]— /bookmarks to browse[-]",
+		views.Colorize(ac.App.GetUserColorTag(msg.Username), msg.Username), views.Escape(snippet)))
+}
+
+// showBookmarks handles /bookmarks. Must be called from the tview event loop.
+func (ac *AppController) showBookmarks() {
+	chat, ok := ac.chatView()
+	if !ok {
+		return
+	}
+	if len(ac.App.Bookmarks) == 0 {
+		chat.HideBookmarks()
+		ac.sendSystem("No bookmarks yet — /bookmark saves the latest message.")
+		return
+	}
+	// Newest first.
+	items := make([]views.BookmarkItem, len(ac.App.Bookmarks))
+	for i, b := range ac.App.Bookmarks {
+		title := b.Username + " · " + models.FormatDate(b.At, false) + models.InZone(b.At).Format(" 15:04")
+		if b.Room != ac.conversationKey() {
+			title += " · " + b.Room
+		}
+		items[len(items)-1-i] = views.BookmarkItem{Title: title, Detail: b.Snippet}
+	}
+	index := func(i int) int { return len(ac.App.Bookmarks) - 1 - i }
+	chat.ShowBookmarks(items,
+		func(i int) { ac.openBookmark(ac.App.Bookmarks[index(i)]) },
+		func(i int) {
+			j := index(i)
+			ac.App.Bookmarks = append(ac.App.Bookmarks[:j], ac.App.Bookmarks[j+1:]...)
+			ac.App.SaveBookmarks()
+			ac.showBookmarks()
+		})
+}
+
+// openBookmark redraws a bookmarked message with its context.
+func (ac *AppController) openBookmark(b models.Bookmark) {
+	msgs, at, ok := ac.App.History.Around(b.ID, bookmarkContext)
+	if !ok {
+		ac.sendSystem(fmt.Sprintf("[yellow]★[-] %s · %s · %s: %s",
+			views.Colorize(ac.App.GetUserColorTag(b.Username), b.Username),
+			models.InZone(b.At).Format("Jan 2 15:04"), views.Escape(b.Room), views.Escape(b.Snippet)))
+		ac.sendSystem("[dim]  (no longer in this session's history)[-]")
+		return
+	}
+	ac.sendSystem(fmt.Sprintf("[dim]── bookmark · %s ──[-]", views.Escape(b.Room)))
+	for i, m := range msgs {
+		if i == at {
+			ac.sendSystem("[yellow]★ bookmarked:[-]")
+		}
+		// A copy without the ID, as in /replay.
+		line := *m
+		line.ID = ""
+		for _, sink := range ac.messageSinks() {
+			sink.AddMessage(&line)
+		}
+	}
+	ac.sendSystem("[dim]── end of bookmark ──[-]")
+}
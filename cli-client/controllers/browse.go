@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"slices"
+	"strings"
+
+	"cli-client/models"
+	"cli-client/ui"
+	"cli-client/views"
+)
+
+// ── Browse menu and reactions ─────────────────────────────────────────────────
+//
+// F3 browses the transcript and Enter opens a menu for the marked message
+// (see views/browse.go). Its entries are wired here to what already exists —
+// the draft for replies, /bookmark for pins, /whois and /ignore — plus
+// copying, which goes through the terminal, and reactions.
+//
+// A reaction is a broadcast opReact frame naming the message by its relay
+// ID, sent as a "reaction" message on typed relays like votes. Giving the
+// same emoji again takes it back. Each client that showed the message adds
+// or removes the sender's emoji and redraws the line in place; clients that
+// predate reactions ignore the frame.
+
+const opReact = "react"
+
+// reactionEmoji are the reactions the menu offers, at most six to fit it.
+// Others' reactions are shown whatever they are, if short.
+var reactionEmoji = []string{"👍", "❤️", "😂", "😮", "😢", "🎉"}
+
+// maxReaction is the longest reaction accepted from others, in bytes.
+const maxReaction = 32
+
+// MessageActions returns what the browse menu does with a message.
+func (ac *AppController) MessageActions() views.MessageActions {
+	return views.MessageActions{
+		Copy:   ac.copyMessage,
+		Reply:  ac.replyTo,
+		React:  ac.react,
+		Pin:    ac.pinMessage,
+		Whois:  func(id string) { ac.withSender(id, func(user string) { ac.OnCommand("/whois " + user) }) },
+		Ignore: func(id string) { ac.withSender(id, func(user string) { ac.OnCommand("/ignore " + user) }) },
+		Expand: ac.expandRepeats,
+		Emoji:  reactionEmoji,
+	}
+}
+
+// messageByID returns the chat message with the local ID id from the
+// session history, or nil, saying so.
+func (ac *AppController) messageByID(id string) *models.Message {
+	msgs, at, ok := ac.App.History.Around(id, 0)
+	if !ok {
+		ac.sendSystem("That message is no longer in this session's history.")
+		return nil
+	}
+	return msgs[at]
+}
+
+// withSender runs fn with the username of the message id, unless it is our
+// own. Must be called from the tview event loop.
+func (ac *AppController) withSender(id string, fn func(user string)) {
+	msg := ac.messageByID(id)
+	if msg == nil {
+		return
+	}
+	if ac.App.CurrentUser != nil && msg.Username == ac.App.CurrentUser.Username {
+		ac.sendSystem("That is your own message.")
+		return
+	}
+	fn(msg.Username)
+}
+
+// copyMessage puts a message's text on the clipboard.
+func (ac *AppController) copyMessage(id string) {
+	msg := ac.messageByID(id)
+	if msg == nil {
+		return
+	}
+	if !ui.CopyText(msg.Content) {
+		ac.showNotice("Too long to copy through the terminal.", views.NoticeError)
+		return
+	}
+	ac.showNotice("Copied — if the terminal allows it (tmux needs set-clipboard on).", views.NoticeInfo)
+}
+
+// replyTo starts a message to the sender of id.
+func (ac *AppController) replyTo(id string) {
+	msg := ac.messageByID(id)
+	chat, ok := ac.chatView()
+	if msg == nil || !ok {
+		return
+	}
+	chat.SetDraft("@" + msg.Username + " ")
+}
+
+// pinMessage bookmarks the message id.
+func (ac *AppController) pinMessage(id string) {
+	if msg := ac.messageByID(id); msg != nil {
+		ac.bookmarkMessage(ac.conversationKey(), msg)
+	}
+}
+
+// relayIDOf returns the relay ID msg goes by — ours once the relay
+// acknowledged it, or a received one still remembered for edits.
+func (ac *AppController) relayIDOf(msg *models.Message) (string, bool) {
+	if ac.App.CurrentUser != nil && msg.Username == ac.App.CurrentUser.Username {
+		return ac.relayID(msg.ID)
+	}
+	for id, entry := range ac.editable {
+		if entry == msg {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// react gives emoji to the message id, or takes it back. Must be called
+// from the tview event loop.
+func (ac *AppController) react(id, emoji string) {
+	if ac.App.CurrentUser == nil {
+		ac.sendSystem("No user logged in.")
+		return
+	}
+	msg := ac.messageByID(id)
+	if msg == nil {
+		return
+	}
+	relay, ok := ac.relayIDOf(msg)
+	if !ok {
+		ac.sendSystem("React: that message has not reached the relay, or is too old.")
+		return
+	}
+	if !ac.linked() {
+		ac.sendSystem("Not connected to a relay.")
+		return
+	}
+	ac.applyReaction(msg, ac.App.CurrentUser.Username, emoji)
+	ac.sendControl(controlFrame{Op: opReact, IDs: []string{relay}, Text: emoji})
+}
+
+// handleReact applies a reaction broadcast by another client.
+// Must be called from the tview event loop.
+func (ac *AppController) handleReact(from string, f *controlFrame) {
+	if from == ac.App.CurrentUser.Username || len(f.IDs) != 1 || ac.isIgnored(from) {
+		return // our own echoed back, or not wanted
+	}
+	emoji := strings.TrimSpace(f.Text)
+	if emoji == "" || len(emoji) > maxReaction || strings.ContainsAny(emoji, " \r\n") {
+		return
+	}
+	msg, ok := ac.editable[f.IDs[0]]
+	if !ok {
+		rec, mine := ac.sent[f.IDs[0]]
+		if !mine {
+			return // not seen here
+		}
+		msgs, at, found := ac.App.History.Around(rec.localID, 0)
+		if !found {
+			return
+		}
+		msg = msgs[at]
+	}
+	ac.applyReaction(msg, from, emoji)
+}
+
+// applyReaction records user's emoji on msg and redraws it.
+func (ac *AppController) applyReaction(msg *models.Message, user, emoji string) {
+	msg.React(user, emoji)
+	for _, sink := range ac.messageSinks() {
+		sink.UpdateMessage(msg)
+	}
+}
+
+// ── Ignoring ──────────────────────────────────────────────────────────────────
+//
+// /ignore <user> hides everything user says from now on — chat, stickers,
+// polls and reactions — across conversations. The list is saved under
+// "ignored" in the config; /unignore takes someone off it. The relay and
+// everyone else still see them.
+
+// isIgnored reports whether user's messages are hidden. Safe to call from
+// any goroutine.
+func (ac *AppController) isIgnored(user string) bool {
+	set := ac.ignored.Load()
+	return set != nil && (*set)[user]
+}
+
+// applyIgnored makes the configured list the one isIgnored checks.
+func (ac *AppController) applyIgnored() {
+	set := make(map[string]bool, len(ac.App.Config.Ignored))
+	for _, user := range ac.App.Config.Ignored {
+		set[user] = true
+	}
+	ac.ignored.Store(&set)
+}
+
+// ignore handles /ignore and /unignore. Must be called from the tview
+// event loop.
+func (ac *AppController) ignore(arg string, on bool) {
+	list := ac.App.Config.Ignored
+	if arg == "" {
+		if !on {
+			ac.sendSystem("Usage: /unignore <user>")
+			return
+		}
+		if len(list) == 0 {
+			ac.sendSystem("Nobody is ignored.")
+			return
+		}
+		names := make([]string, len(list))
+		for i, user := range list {
+			names[i] = ac.displayName(user)
+		}
+		ac.sendSystem("Ignored: " + strings.Join(names, ", ") + "  [dim]— /unignore <user>[-]")
+		return
+	}
+	user := ac.resolveAlias(arg)
+	switch i := slices.Index(list, user); {
+	case on && i >= 0:
+		ac.sendSystem(ac.displayName(user) + " is already ignored.")
+		return
+	case on && ac.App.CurrentUser != nil && user == ac.App.CurrentUser.Username:
+		ac.sendSystem("You cannot ignore yourself.")
+		return
+	case on:
+		list = append(slices.Clone(list), user)
+	case i < 0:
+		ac.sendSystem(ac.displayName(user) + " is not ignored.")
+		return
+	default:
+		list = slices.Delete(slices.Clone(list), i, i+1)
+	}
+	ac.App.Config.Ignored = list
+	ac.applyIgnored()
+	if err := models.SaveConfigKey("ignored", list); err != nil {
+		ac.sendSystem("[red]Ignore list not saved:[-] " + views.Escape(err.Error()) + " — it lasts until you quit.")
+	}
+	if on {
+		ac.sendSystem(ac.displayName(user) + " is ignored — their messages are not shown. /unignore to undo.")
+	} else {
+		ac.sendSystem(ac.displayName(user) + " is no longer ignored.")
+	}
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"cli-client/ui"
+)
+
+// ── Long messages ─────────────────────────────────────────────────────────────
+//
+// A chat message longer than the relay accepts is sent as consecutive parts
+// "[1/3] …", "[2/3] …", "[3/3] …" — still readable on clients that do not
+// reassemble. Receivers collect a sender's parts and show them as one message
+// once the last arrives, or what they have after partTimeout.
+
+const (
+	maxParts    = 20
+	partTimeout = 30 * time.Second
+
+	// defaultMaxContent is used when neither the config nor the relay sets a
+	// limit; it keeps a single line renderable.
+	defaultMaxContent = 4096
+	minMaxContent     = 256
+)
+
+// partPrefix matches the "[i/n] " marker at the start of a part.
+var partPrefix = regexp.MustCompile(`^\[(\d{1,2})/(\d{1,2})\] `)
+
+// splitMessage cuts content into parts of at most size bytes, marker
+// included, preferring to cut after whitespace. Content that fits is
+// returned unchanged as the only part.
+func splitMessage(content string, size int) []string {
+	if len(content) <= size {
+		return []string{content}
+	}
+	body := size - len(fmt.Sprintf("[%d/%d] ", maxParts, maxParts))
+
+	var chunks []string
+	for len(content) > body {
+		cut := body
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		if sp := strings.LastIndexAny(content[:cut], " \t\n"); sp > cut*3/4 {
+			cut = sp + 1
+		}
+		chunks = append(chunks, content[:cut])
+		content = content[cut:]
+	}
+	chunks = append(chunks, content)
+
+	for i := range chunks {
+		chunks[i] = fmt.Sprintf("[%d/%d] %s", i+1, len(chunks), chunks[i])
+	}
+	return chunks
+}
+
+// parsePart splits a part into its index, total and text.
+func parsePart(content string) (i, n int, text string, ok bool) {
+	m := partPrefix.FindStringSubmatch(content)
+	if m == nil {
+		return 0, 0, "", false
+	}
+	i, _ = strconv.Atoi(m[1])
+	n, _ = strconv.Atoi(m[2])
+	if n < 2 || n > maxParts || i < 1 || i > n {
+		return 0, 0, "", false
+	}
+	return i, n, content[len(m[0]):], true
+}
+
+// partialMessage is a long message still being received.
+type partialMessage struct {
+	first  *pollMessage // carries sender and color
+	total  int
+	next   int    // index of the part expected next
+	lastID string // relay ID of the last part added
+	text   strings.Builder
+	timer  *time.Timer
+}
+
+// message returns the collected text as one message with the ID of the
+// last part received, so read receipts match what the sender tracks.
+func (p *partialMessage) message(complete bool) *pollMessage {
+	m := *p.first
+	m.ID = p.lastID
+	m.Content = p.text.String()
+	if !complete {
+		m.Content += fmt.Sprintf(" … (incomplete: %d of %d parts)", p.next-1, p.total)
+	}
+	return &m
+}
+
+// partAssembler joins parts per sender. Safe for concurrent use.
+type partAssembler struct {
+	mu      sync.Mutex
+	pending map[string]*partialMessage // sender → message in progress
+	expire  func(msg *pollMessage)     // gets incomplete messages after partTimeout
+}
+
+func newPartAssembler(expire func(msg *pollMessage)) *partAssembler {
+	return &partAssembler{
+		pending: make(map[string]*partialMessage),
+		expire:  expire,
+	}
+}
+
+// Add takes one incoming chat message and returns the messages ready to
+// show: none while a long message is incomplete, the joined message when its
+// last part arrives, and an abandoned partial ahead of anything that
+// interrupts it.
+func (a *partAssembler) Add(msg *pollMessage) []*pollMessage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var ready []*pollMessage
+	i, n, text, isPart := parsePart(msg.Content)
+	p := a.pending[msg.Username]
+	if p != nil && (!isPart || i != p.next || n != p.total) {
+		ready = append(ready, a.drop(msg.Username, false))
+		p = nil
+	}
+	if !isPart || (p == nil && i != 1) {
+		return append(ready, msg) // plain message, or a part we missed the start of
+	}
+
+	if p == nil {
+		p = &partialMessage{first: msg, total: n, next: 1}
+		a.pending[msg.Username] = p
+		sender := msg.Username
+		p.timer = time.AfterFunc(partTimeout, func() {
+			a.mu.Lock()
+			var m *pollMessage
+			if a.pending[sender] == p {
+				m = a.drop(sender, false)
+			}
+			a.mu.Unlock()
+			if m != nil {
+				a.expire(m)
+			}
+		})
+	}
+	p.text.WriteString(text)
+	p.next++
+	p.lastID = msg.ID
+	if p.next > p.total {
+		ready = append(ready, a.drop(msg.Username, true))
+	}
+	return ready
+}
+
+// drop removes sender's partial and returns it as a message.
+// Called with a.mu held.
+func (a *partAssembler) drop(sender string, complete bool) *pollMessage {
+	p := a.pending[sender]
+	p.timer.Stop()
+	delete(a.pending, sender)
+	return p.message(complete)
+}
+
+// SendParts sends the parts of a long chat message one after another, so they
+// reach the relay in order, and calls done as SendTracked does, with the last
+// part's relay ID. A part that fails ends the send: done gets its error and
+// the parts after it are not sent.
+func (nc *NetworkClient) SendParts(username string, parts []string, colorTag string, done func(id string, at time.Time, err error)) {
+	if atomic.LoadInt32(&nc.stopped) == 1 || len(parts) == 0 {
+		return
+	}
+	nc.wake()
+	ui.SafeGo("SendParts", func() {
+		for _, part := range parts[:len(parts)-1] {
+			var failed error
+			nc.sendAsync(msgTypeChat, username, part, colorTag, func(_ string, _ time.Time, err error) { failed = err })
+			if failed != nil {
+				if done != nil {
+					done("", time.Time{}, failed)
+				}
+				return
+			}
+		}
+		nc.sendAsync(msgTypeChat, username, parts[len(parts)-1], colorTag, done)
+	})
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// maxContent is the largest message we send as a single part: the config's
+// max_message_bytes, lowered to the relay's limit if that is smaller.
+// Must be called from the tview event loop.
+func (ac *AppController) maxContent() int {
+	limit := ac.App.Config.MaxMessageBytes
+	if limit <= 0 {
+		limit = defaultMaxContent
+	}
+	if ac.lan == nil && ac.caps != nil && ac.caps.MaxContent > 0 && ac.caps.MaxContent < limit {
+		limit = max(ac.caps.MaxContent, minMaxContent)
+	}
+	return limit
+}
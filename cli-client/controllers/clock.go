@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"cli-client/models"
+)
+
+// ── Clock correction ──────────────────────────────────────────────────────────
+//
+// The relay stamps every message with its own clock. The handshake measures
+// how far that clock is from ours (Capabilities.ClockOffset) and models.Now
+// applies the difference to everything stamped here, so our messages and
+// everyone else's share the relay's epoch and read in order, whatever our
+// clock or theirs says. LAN peers have no common clock; their messages are
+// stamped on arrival.
+
+// maxStampAhead is how far into the future a relay timestamp may be before it
+// is distrusted and the message stamped on arrival instead.
+const maxStampAhead = time.Minute
+
+// messageTime returns when pm was sent on the corrected clock: its relay
+// timestamp, unless it has none or an implausible one. Safe to call from
+// any goroutine.
+func messageTime(pm *pollMessage) time.Time {
+	now := models.Now()
+	if pm.Timestamp.IsZero() || pm.Timestamp.After(now.Add(maxStampAhead)) {
+		return now
+	}
+	return pm.Timestamp
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// applyClockOffset starts correcting timestamps by the offset measured in
+// caps, and says so if our clock is noticeably off.
+// Must be called from the tview event loop.
+func (ac *AppController) applyClockOffset(caps *Capabilities) {
+	if caps.ServerTime.IsZero() {
+		return // legacy relay: nothing to correct against
+	}
+	models.SetClockOffset(caps.ClockOffset)
+	off := caps.ClockOffset.Round(time.Second)
+	if off > -maxClockSkew && off < maxClockSkew {
+		return
+	}
+	if replaying() {
+		return // the relay's clock is as it was when the tape was recorded
+	}
+	dir := "behind"
+	if off < 0 {
+		dir, off = "ahead of", -off
+	}
+	ac.sendSystem(fmt.Sprintf("[yellow]Your clock is %v %s the relay's[-] — message times are corrected to match.", off, dir))
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"strings"
+
+	"cli-client/views"
+)
+
+// ── Command registry ──────────────────────────────────────────────────────────
+//
+// Every slash command handled by OnCommand, in the order /help and the F1
+// overlay list them. Add new commands here as well as to the switch.
+
+type commandInfo struct {
+	name     string
+	args     string
+	category string
+	summary  string
+}
+
+var commands = []commandInfo{
+	{"clear", "", "Chat", "Clear the transcript"},
+	{"replay", "[duration]", "Chat", "Redraw the messages of the last hour, or duration"},
+	{"bookmark", "[n]", "Chat", "Bookmark the latest message, or the nth latest"},
+	{"bookmarks", "", "Chat", "Browse bookmarks and jump back to one"},
+	{"translate", "[n] [lang]", "Chat", "Translate the latest message, or the nth latest, under it"},
+	{"summary", "[n]", "Chat", "Condense the last n messages (default 50) into a few lines"},
+	{"nick", "<name>", "Chat", "Change your username"},
+	{"user_color", "<color>|reset", "Chat", "Set your color — a name or #rrggbb"},
+	{"mode", "[animation|static]", "Chat", "Word-by-word animation or instant lines"},
+	{"sticker", "<name>", "Chat", "Send a sticker; without a name, list them"},
+	{"template", "[save <name> <text>|delete <name>]", "Chat", "Save a snippet with {placeholders}; without arguments, list them"},
+	{"t", "<name> [values…]", "Chat", "Put a template in the input, asking for its placeholders"},
+	{"poll", "\"question\" <option> <option>…", "Chat", "Ask everyone a question with up to 9 answers"},
+	{"vote", "<poll> <n|option>", "Chat", "Vote in a poll, or change your vote"},
+	{"edit", "<text>", "Chat", "Replace your last message, for everyone"},
+	{"diffs", "[on|off]", "Chat", "Mark the words an edit changed, or show only the new text"},
+	{"filters", "[on|off]", "Chat", "Show the content filters, or turn them on or off here"},
+	{"retry", "", "Chat", "Send your messages the relay did not accept again"},
+	{"ephemeral", "<ttl> <text>", "Chat", "Send a message every client wipes after ttl"},
+	{"schedule", "<when> <text>|list|cancel <id>", "Chat", "Send later: 10m, 17:30 or 2006-01-02T15:04"},
+	{"announce", "<text>", "Chat", "Post a banner to everyone (needs admin_key)"},
+
+	{"whois", "[user]", "People", "Show details about yourself or another user"},
+	{"ping", "<user>", "People", "Round-trip time to another client"},
+	{"users", "", "People", "List users seen online"},
+	{"contacts", "", "People", "Everyone seen so far, favorites first, with when they were last seen"},
+	{"ignore", "[user]", "People", "Hide everything user says; without a user, list who is ignored"},
+	{"unignore", "<user>", "People", "Show someone's messages again"},
+	{"hush", "[room|user]", "People", "Silence this conversation or someone, keeping only a count"},
+	{"unhush", "room|<user>", "People", "End a hush and show a digest of what it kept back"},
+	{"verify", "[user [yes|<fingerprint>]]", "People", "Compare identity key fingerprints and mark someone verified"},
+	{"unverify", "<user>", "People", "Take back a verification"},
+	{"qr", "server|invite|fingerprint", "People", "Show the relay URL, an invitation or your key fingerprint as a QR code to scan"},
+	{"contact", "[alias <user> \"<name>\"|unalias <user>|favorite <user>]", "People", "Name someone your own way, or pin them as a favorite"},
+
+	{"highlight", "[add|remove <word> [color] [notify]]", "Privacy & notifications", "Color a word in others' messages, and ring for it"},
+	{"privacy", "[whois|receipts on|off]", "Privacy & notifications", "Show or change what others can see"},
+	{"quiet", "", "Privacy & notifications", "Hide join/leave/rename lines"},
+	{"dnd", "[duration|off]", "Privacy & notifications", "Do Not Disturb — silence the bell"},
+	{"away", "[reason|off|reply on|off]", "Privacy & notifications", "Tell everyone you are away, and answer mentions for you"},
+
+	{"kick", "<user>", "Moderation", "Disconnect a user from the relay (admin)"},
+	{"mute", "<user> [duration]", "Moderation", "Stop a user sending, default 10m (admin)"},
+	{"unmute", "<user>", "Moderation", "Lift a mute early (admin)"},
+	{"slowmode", "<duration>|off", "Moderation", "One message per user per interval (admin)"},
+
+	{"server", "<url>|lan", "Connection", "Switch relay, or go serverless on the LAN"},
+	{"account", "[name|close <name>]", "Connection", "List accounts, switch to one, or sign a parked one out"},
+	{"invite", "", "Connection", "A ttc:// link others can join this conversation with"},
+	{"join", "ttc://…", "Connection", "Join the conversation of an invitation"},
+	{"rooms", "", "Connection", "List conversations and their Alt+1..9 keys"},
+	{"split", "<n|room>|off", "Connection", "Show another conversation beside this one; Ctrl+←/→ switch between them"},
+	{"demo", "", "Connection", "Chat with simulated users — no relay needed"},
+	{"latency", "", "Connection", "Current network latency"},
+	{"info", "", "Connection", "Client version and relay protocol"},
+	{"sessionstats", "", "Connection", "Traffic and uptime for this session"},
+	{"reconnect", "", "Connection", "Try the relay now instead of waiting out the reconnect countdown"},
+	{"netstat", "", "Connection", "Relay link, transport and dropped malformed messages"},
+	{"dashboard", "", "Connection", "Toggle the server trends pane"},
+	{"detach", "", "Connection", "Keep receiving in the background and quit"},
+
+	{"help", "", "App", "List commands — F1 for details"},
+	{"memstats", "", "App", "Heap, goroutines and buffer sizes"},
+	{"gc", "", "App", "Collect garbage and return freed memory to the OS"},
+	{"stress", "<n> [rate|max]", "App", "Inject n synthetic messages and report draw timings"},
+	{"update", "", "App", "Install the latest release (checksum verified)"},
+	{"exit", "", "App", "Quit"},
+}
+
+// numberedCommands take "n", the nth latest message, as their first
+// argument. While one is typed the latest numberedSpan messages show their
+// n (see views/numbers.go).
+var numberedCommands = []string{"bookmark", "translate"}
+
+const numberedSpan = 30
+
+// NumberedCommands lists the commands to number messages for, without the
+// slash.
+func NumberedCommands() []string {
+	return numberedCommands
+}
+
+// NumberedIDs returns the IDs of the conversation's latest numberedSpan
+// messages, latest first: the first is n = 1. Must be called from the tview
+// event loop.
+func (ac *AppController) NumberedIDs() []string {
+	msgs := ac.App.History.Latest(ac.conversationKey(), numberedSpan)
+	ids := make([]string, len(msgs))
+	for i, m := range msgs {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+// CommandHints returns the command registry as the chat view's command bar
+// describes it while a command is typed.
+func CommandHints() []views.CommandHint {
+	hints := make([]views.CommandHint, len(commands))
+	for i, c := range commands {
+		hints[i] = views.CommandHint{Name: c.name, Usage: c.usage(), Summary: c.summary}
+	}
+	return hints
+}
+
+func (c commandInfo) usage() string {
+	if c.args == "" {
+		return "/" + c.name
+	}
+	return "/" + c.name + " " + c.args
+}
+
+// helpLine is the one-line command list printed by /help.
+func helpLine() string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = "/" + c.name
+	}
+	return "Commands:  " + strings.Join(names, "  ") + "   [dim]— F1 for arguments and keys[-]"
+}
+
+// HelpEntries returns the F1 overlay contents: the command registry followed
+// by the chat key bindings.
+func HelpEntries() []views.HelpEntry {
+	entries := make([]views.HelpEntry, 0, len(commands)+len(views.ChatKeybindings))
+	for _, c := range commands {
+		entries = append(entries, views.HelpEntry{Category: c.category, Usage: c.usage(), Summary: c.summary})
+	}
+	return append(entries, views.ChatKeybindings...)
+}
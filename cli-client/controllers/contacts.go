@@ -0,0 +1,276 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cli-client/models"
+	"cli-client/paths"
+	"cli-client/views"
+)
+
+// ── Contacts ──────────────────────────────────────────────────────────────────
+//
+// Everyone who sends a message or a presence announcement becomes a contact
+// (models.ContactsFile), with when and where they were last seen. /contacts
+// opens a pane listing them, favorites first; from there Enter starts an
+// @mention — there are no private messages — and f pins a favorite, which
+// also puts them at the top of /users.
+//
+// /contact alias <user> "<name>" shows user as name in this client: in the
+// transcript (see views/aliases.go), in /whois, /users and join/leave lines,
+// and as a way to find them with Tab. /contact unalias <user> drops it,
+// /contact lists them. Aliases live in the aliases key of the config file,
+// which these commands rewrite; the username on the wire never changes.
+
+const contactUsage = `Usage: /contact alias <user> "<name>"  |  unalias <user>  |  favorite <user>`
+
+// completionScan is how many of the conversation's latest messages Tab
+// looks through for senders, besides the users seen online.
+const completionScan = 200
+
+// contact handles /contact. Must be called from the tview event loop.
+func (ac *AppController) contact(arg string) {
+	args, ok := splitQuoted(arg)
+	if !ok {
+		ac.sendSystem(views.Escape(contactUsage))
+		return
+	}
+	if len(args) == 0 {
+		ac.listAliases()
+		return
+	}
+	aliases := make(map[string]string, len(ac.App.Config.Aliases)+1)
+	for user, alias := range ac.App.Config.Aliases {
+		aliases[user] = alias
+	}
+	switch strings.ToLower(args[0]) {
+	case "favorite", "fav":
+		if len(args) != 2 {
+			ac.sendSystem(views.Escape(contactUsage))
+			return
+		}
+		ac.toggleFavorite(args[1])
+		return
+	case "alias":
+		if len(args) != 3 {
+			ac.sendSystem(views.Escape(contactUsage))
+			return
+		}
+		user, alias := args[1], strings.Join(strings.Fields(args[2]), " ")
+		if alias == "" || len([]rune(alias)) > models.MaxAliasLength {
+			ac.sendSystem(fmt.Sprintf("An alias is 1 to %d characters.", models.MaxAliasLength))
+			return
+		}
+		aliases[user] = alias
+	case "unalias":
+		if len(args) != 2 {
+			ac.sendSystem(views.Escape(contactUsage))
+			return
+		}
+		if _, ok := aliases[args[1]]; !ok {
+			ac.sendSystem("No alias for " + views.Escape(args[1]) + ".")
+			return
+		}
+		delete(aliases, args[1])
+	default:
+		ac.sendSystem(views.Escape(contactUsage))
+		return
+	}
+
+	ac.App.Config.Aliases = aliases
+	for _, chat := range ac.chatPanes() {
+		chat.SetAliases(aliases)
+	}
+	if err := models.SaveConfigKey("aliases", aliases); err != nil {
+		ac.sendSystem("[red]Aliases not saved:[-] " + views.Escape(err.Error()) + " — they last until you quit.")
+	}
+	ac.listAliases()
+}
+
+// seeContact records username as seen now in the active conversation,
+// with a message from them if message is set. Must be called from the tview
+// event loop.
+func (ac *AppController) seeContact(username string, message bool) {
+	if username == "" || (ac.App.CurrentUser != nil && username == ac.App.CurrentUser.Username) {
+		return
+	}
+	c := ac.App.SeeContact(username, ac.conversationKey(), time.Now())
+	if message {
+		c.Messages++
+	}
+}
+
+// toggleFavorite pins or unpins user as a favorite. Must be called from the
+// tview event loop.
+func (ac *AppController) toggleFavorite(user string) {
+	user = ac.resolveAlias(user)
+	c, ok := ac.App.Contacts[user]
+	if !ok {
+		ac.sendSystem("No contact " + views.Escape(user) + " — they become one when they say something.")
+		return
+	}
+	c.Favorite = !c.Favorite
+	ac.App.SaveContacts()
+	if c.Favorite {
+		ac.sendSystem("[yellow]★[-] " + ac.displayName(user) + " is a favorite.")
+	} else {
+		ac.sendSystem(ac.displayName(user) + " is no longer a favorite.")
+	}
+}
+
+// showContacts handles /contacts. Must be called from the tview event loop.
+func (ac *AppController) showContacts() {
+	chat, ok := ac.chatView()
+	if !ok {
+		return
+	}
+	list := ac.App.ContactList()
+	if len(list) == 0 {
+		chat.HideContacts()
+		ac.sendSystem("No contacts yet — everyone you see talking or joining is added.")
+		return
+	}
+	items := make([]views.ContactItem, len(list))
+	for i, c := range list {
+		items[i] = ac.contactItem(c)
+	}
+	chat.ShowContacts(items, views.ContactActions{
+		Mention: func(i int) { chat.SetDraft("@" + list[i].Username + " ") },
+		Favorite: func(i int) {
+			list[i].Favorite = !list[i].Favorite
+			ac.App.SaveContacts()
+			ac.showContacts()
+		},
+		Whois: func(i int) { ac.OnCommand("/whois " + list[i].Username) },
+		Forget: func(i int) {
+			delete(ac.App.Contacts, list[i].Username)
+			ac.App.SaveContacts()
+			ac.pushTrust()
+			ac.showContacts()
+		},
+	})
+}
+
+// contactItem renders c for the contacts pane.
+func (ac *AppController) contactItem(c *models.Contact) views.ContactItem {
+	title := c.Username
+	if alias, ok := ac.App.Config.Aliases[c.Username]; ok {
+		title = alias + " (" + c.Username + ")"
+	}
+	if c.Favorite {
+		title = "★ " + title
+	}
+	title += " · " + ac.trustText(c.Username)
+	if u, ok := ac.App.Users[c.Username]; ok && u.IsOnline {
+		title += " · online"
+	} else {
+		title += " · last seen " + seenAgo(c.LastSeen)
+	}
+	detail := fmt.Sprintf("%d message(s) · first seen %s", c.Messages, models.FormatDate(c.FirstSeen, true))
+	if c.Room != ac.conversationKey() {
+		detail += " · " + c.Room
+	}
+	return views.ContactItem{Title: title, Detail: detail}
+}
+
+// seenAgo describes how long ago t was: "just now", "5m ago", "3h ago", or
+// the date for anything older than a day.
+func seenAgo(t time.Time) string {
+	switch d := time.Since(t); {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return models.FormatDate(t, false)
+	}
+}
+
+// listAliases prints the aliases, by username.
+func (ac *AppController) listAliases() {
+	aliases := ac.App.Config.Aliases
+	if len(aliases) == 0 {
+		ac.sendSystem("No aliases — " + views.Escape(`/contact alias <user> "<name>"`))
+		return
+	}
+	users := make([]string, 0, len(aliases))
+	for user := range aliases {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	ac.sendSystem(fmt.Sprintf("Aliases (%d), saved in %s:", len(users), views.Escape(paths.Config(models.ConfigFile))))
+	for _, user := range users {
+		ac.sendSystem(fmt.Sprintf("  %s[::i]%s[::-][-]  [dim]%s[-]",
+			ac.App.GetUserColorTag(user), views.Escape(aliases[user]), views.Escape(user)))
+	}
+}
+
+// displayName returns username as shown in system lines, escaped: its alias
+// in italics if it has one.
+func (ac *AppController) displayName(username string) string {
+	if alias, ok := ac.App.Config.Aliases[username]; ok {
+		return "[::i]" + views.Escape(alias) + "[::-]"
+	}
+	return views.Escape(username)
+}
+
+// aliasSuffix returns "  |  alias: name" for a /whois line about username,
+// or "" if it has no alias.
+func (ac *AppController) aliasSuffix(username string) string {
+	if alias, ok := ac.App.Config.Aliases[username]; ok {
+		return "  |  alias: [::i]" + views.Escape(alias) + "[::-]"
+	}
+	return ""
+}
+
+// resolveAlias returns the username name stands for: the user whose alias
+// it is, unless someone goes by name itself.
+func (ac *AppController) resolveAlias(name string) string {
+	if _, ok := ac.App.Users[name]; ok {
+		return name
+	}
+	for user, alias := range ac.App.Config.Aliases {
+		if strings.EqualFold(alias, name) {
+			return user
+		}
+	}
+	return name
+}
+
+// CompleteName lists the usernames, other than ours, that start with
+// prefix or whose alias does, for Tab in the input: users seen online,
+// recent senders here, and aliased users. Must be called from the tview
+// event loop.
+func (ac *AppController) CompleteName(prefix string) []string {
+	prefix = strings.ToLower(prefix)
+	seen := make(map[string]bool)
+	var out []string
+	try := func(user string) {
+		if seen[user] || (ac.App.CurrentUser != nil && user == ac.App.CurrentUser.Username) {
+			return
+		}
+		alias, ok := ac.App.Config.Aliases[user]
+		if strings.HasPrefix(strings.ToLower(user), prefix) || (ok && strings.HasPrefix(strings.ToLower(alias), prefix)) {
+			seen[user] = true
+			out = append(out, user)
+		}
+	}
+	for user := range ac.App.Users {
+		try(user)
+	}
+	for _, msg := range ac.App.History.Recent(ac.conversationKey(), completionScan) {
+		if !msg.IsSystem {
+			try(msg.Username)
+		}
+	}
+	for user := range ac.App.Config.Aliases {
+		try(user)
+	}
+	sort.Slice(out, func(i, j int) bool { return strings.ToLower(out[i]) < strings.ToLower(out[j]) })
+	return out
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// ── Control frames ────────────────────────────────────────────────────────────
+//
+// Client-to-client requests (whois, ping) ride the relay as JSON controlFrames.
+// Relays advertising the "types" capability carry them as typed messages
+// ("control", or "presence" for broadcasts) whose content is the bare JSON.
+// Legacy relays only know chat, so there the content is controlPrefix followed
+// by the JSON; input that starts with "/" is always routed to OnCommand, so a
+// typed chat line can never collide with the prefix. Every client receives
+// every frame; only the addressee (To) acts on it. Frames with an empty To are
+// broadcasts — see presence.go.
+
+const controlPrefix = "/ttc:"
+
+const (
+	opWhois        = "whois"
+	opWhoisReply   = "whois_reply"
+	opWhoisRefused = "whois_refused"
+	opPing         = "ping"
+	opPong         = "pong"
+)
+
+// probeTimeout is how long /whois and /ping wait for the remote client.
+const probeTimeout = 10 * time.Second
+
+type controlFrame struct {
+	Op      string   `json:"op"`
+	To      string   `json:"to"`
+	Nonce   string   `json:"nonce,omitempty"`
+	Color   string   `json:"color,omitempty"`
+	Version string   `json:"version,omitempty"`
+	IdleMs  int64    `json:"idle_ms,omitempty"`
+	Old     string   `json:"old,omitempty"`     // previous username for opNick
+	IDs     []string `json:"ids,omitempty"`     // message IDs for opSeen
+	Text    string   `json:"text,omitempty"`    // message body for opEphemeral
+	TTLMs   int64    `json:"ttl_ms,omitempty"`  // lifetime for opEphemeral
+	Poll    string   `json:"poll,omitempty"`    // poll ID for opPoll and opVote
+	Options []string `json:"options,omitempty"` // answers for opPoll
+	Choice  int      `json:"choice,omitempty"`  // 1-based answer for opVote
+	Zone    string   `json:"zone,omitempty"`    // time zone for opJoin and opWhoisReply, if shared
+	Key     string   `json:"key,omitempty"`     // identity key for opJoin, opNick and opWhoisReply
+	Sig     string   `json:"sig,omitempty"`     // Key's claim to the sender's username
+}
+
+// probe is an outstanding /whois or /ping waiting for its reply.
+type probe struct {
+	op     string
+	target string
+	sent   time.Time
+}
+
+// encodeControl returns the legacy chat-content form of f.
+func encodeControl(f controlFrame) string {
+	return controlPrefix + encodeControlPayload(f)
+}
+
+// encodeControlPayload returns the bare JSON form of f for typed messages.
+func encodeControlPayload(f controlFrame) string {
+	data, _ := json.Marshal(f)
+	return string(data)
+}
+
+// controlWireType is the message type a frame is sent as on typed relays.
+func controlWireType(op string) string {
+	switch op {
+	case opJoin, opLeave, opNick, opColor:
+		return msgTypePresence
+	case opVote, opReact:
+		return msgTypeReaction
+	}
+	return msgTypeControl
+}
+
+// decodeControlMessage extracts the control frame from a typed presence,
+// control or reaction message, or from a legacy prefixed chat message.
+func decodeControlMessage(msg *pollMessage) (*controlFrame, bool) {
+	switch msg.Type {
+	case msgTypePresence, msgTypeControl, msgTypeReaction:
+		var f controlFrame
+		if err := json.Unmarshal([]byte(msg.Content), &f); err != nil || f.Op == "" {
+			return nil, false
+		}
+		return &f, true
+	case msgTypeChat:
+		return decodeControl(msg.Content)
+	}
+	return nil, false
+}
+
+func decodeControl(content string) (*controlFrame, bool) {
+	if !strings.HasPrefix(content, controlPrefix) {
+		return nil, false
+	}
+	var f controlFrame
+	if err := json.Unmarshal([]byte(content[len(controlPrefix):]), &f); err != nil || f.Op == "" {
+		return nil, false
+	}
+	return &f, true
+}
+
+// isChatMessage reports whether a relayed message is chat text to display,
+// rather than a control frame or a type this client does not handle.
+func isChatMessage(msg *pollMessage) bool {
+	return msg.Type == msgTypeChat && !strings.HasPrefix(msg.Content, controlPrefix)
+}
+
+func newNonce() string {
+	return fmt.Sprintf("%x", rand.Int63())
+}
@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cli-client/models"
+	"cli-client/views"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// ── Conversation switching ────────────────────────────────────────────────────
+//
+// A conversation is a relay URL or "lan" (see conversationKey). Alt+1..9
+// jumps to the conversations in the order /rooms lists them: those entered
+// this session, then the ones configured under "rooms". Alt+A jumps to the
+// other conversation that last had a message — only the active
+// conversation is connected, so that is the one most recently left busy.
+// The rooms sidebar (F5, views/rooms.go) shows the same list with unread
+// counts and last activity; Enter on a row switches to it.
+
+// roomList returns the conversations in Alt+N order.
+func (ac *AppController) roomList() []string {
+	list := append([]string(nil), ac.rooms...)
+	var configured []string
+	for key := range ac.App.Config.Rooms {
+		configured = append(configured, key)
+	}
+	sort.Strings(configured)
+	for _, key := range configured {
+		if !containsString(list, key) {
+			list = append(list, key)
+		}
+	}
+	return list
+}
+
+// noteConversation records that key was entered, in the focused pane. Must
+// be called from the tview event loop.
+func (ac *AppController) noteConversation(key string) {
+	if !containsString(ac.rooms, key) {
+		ac.rooms = append(ac.rooms, key)
+	}
+	if split, ok := ac.splitView(); ok {
+		ac.panes[split.ActivePane()] = key
+	}
+	ac.showRooms()
+}
+
+// noteActivity records a message in the active conversation. Must be
+// called from the tview event loop.
+func (ac *AppController) noteActivity() {
+	ac.activity[ac.conversationKey()] = time.Now()
+	ac.showRooms()
+}
+
+// switchConversation makes key — a relay URL or "lan" — the active
+// conversation. Must be called from the tview event loop.
+func (ac *AppController) switchConversation(key string) {
+	ac.stashDraft()
+	ac.openConversation(key)
+}
+
+// openConversation connects to key and enters it, once the draft of the
+// conversation left is stashed. Must be called from the tview event loop.
+func (ac *AppController) openConversation(key string) {
+	if key == "lan" {
+		ac.startLAN()
+		ac.enterConversation()
+		return
+	}
+	DefaultServerURL = key
+	ac.sendSystem(fmt.Sprintf("Server URL → [cyan]%s[-]  — reconnecting…", views.Escape(key)))
+	// Restart the network client with the new URL
+	ac.stopLAN()
+	ac.stopNetworkClient()
+	ac.startNetworkClient()
+	ac.enterConversation()
+	ac.armFailback()
+}
+
+// busiestOther returns the conversation other than the active one with the
+// latest message, or "" if none had any.
+func (ac *AppController) busiestOther() string {
+	current := ac.conversationKey()
+	best, bestAt := "", time.Time{}
+	for key, at := range ac.activity {
+		if key != current && at.After(bestAt) {
+			best, bestAt = key, at
+		}
+	}
+	return best
+}
+
+// listRooms prints the conversation list with their Alt+N keys.
+func (ac *AppController) listRooms() {
+	list := ac.roomList()
+	if len(list) == 0 {
+		ac.sendSystem("No conversations yet — /server <url>|lan to open one.")
+		return
+	}
+	current := ac.conversationKey()
+	ac.sendSystem("Conversations:")
+	for i, key := range list {
+		shortcut := "     "
+		if i < 9 {
+			shortcut = fmt.Sprintf("Alt+%d", i+1)
+		}
+		mark := ""
+		if key == current {
+			mark = "  [green]● active[-]"
+		} else if at, ok := ac.activity[key]; ok {
+			mark = "  [dim]last message " + at.Format("15:04") + "[-]"
+		}
+		ac.sendSystem(fmt.Sprintf("  [dim]%s[-]  %s%s", shortcut, views.Escape(key), mark))
+	}
+}
+
+// showRooms fills the rooms sidebar from roomList, open or not. Must be
+// called from the tview event loop.
+func (ac *AppController) showRooms() {
+	split, ok := ac.splitView()
+	if !ok {
+		return
+	}
+	current := ac.conversationKey()
+	today := models.InZone(models.Now()).Format("2006-01-02")
+	list := ac.roomList()
+	items := make([]views.RoomItem, len(list))
+	for i, key := range list {
+		title := strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+		if i < 9 {
+			title = fmt.Sprintf("%d %s", i+1, title)
+		}
+		items[i] = views.RoomItem{Title: title, Unread: ac.unread[key], Active: key == current}
+		at, seen := ac.activity[key]
+		switch when := models.InZone(at); {
+		case key == current:
+			items[i].Detail = "active"
+		case !seen:
+		case when.Format("2006-01-02") == today:
+			items[i].Detail = "last message " + when.Format("15:04")
+		default:
+			items[i].Detail = "last message " + models.FormatDate(at, false)
+		}
+	}
+	split.SetRooms(items)
+}
+
+// pickRoom switches to row i of the rooms sidebar. Called for Enter from
+// the tview event loop.
+func (ac *AppController) pickRoom(i int) {
+	list := ac.roomList()
+	if i < 0 || i >= len(list) || list[i] == ac.conversationKey() {
+		return
+	}
+	ac.switchConversation(list[i])
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// HandleKey runs the conversation shortcuts: Alt+1..9 and Alt+A, and Alt+S
+// for the next account (see accounts.go). It reports whether it used the
+// key. Called from the tview event loop while the chat screen is active.
+func (ac *AppController) HandleKey(event *tcell.EventKey) bool {
+	if event.Key() != tcell.KeyRune || event.Modifiers()&tcell.ModAlt == 0 || ac.App.CurrentUser == nil {
+		return false
+	}
+	r := event.Rune()
+	var target string
+	switch {
+	case r >= '1' && r <= '9':
+		list := ac.roomList()
+		n := int(r - '1')
+		if n >= len(list) {
+			ac.sendSystem(fmt.Sprintf("No conversation %d — /rooms lists them.", n+1))
+			return true
+		}
+		target = list[n]
+	case strings.ToLower(string(r)) == "s":
+		ac.nextAccount()
+		return true
+	case strings.ToLower(string(r)) == "a":
+		if target = ac.busiestOther(); target == "" {
+			ac.sendSystem("No activity in other conversations.")
+			return true
+		}
+	default:
+		return false
+	}
+	if target != ac.conversationKey() {
+		ac.switchConversation(target)
+	}
+	return true
+}
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"cli-client/models"
+	"cli-client/paths"
+)
+
+// ── Crash reports ─────────────────────────────────────────────────────────────
+//
+// When main recovers a panic it calls RecordCrash, which leaves a diagnostics
+// bundle in crashPendingFile. The next start finds it and asks the user to
+// save it under a timestamped name, POST it to crash_report_url, or discard
+// it. Nothing leaves the machine without that choice.
+//
+// The bundle is redacted: message text, usernames and the access key are cut
+// out of the log tail, and of the config only the settings in
+// crashConfigFields are kept — a field added later stays out until it is
+// listed there.
+
+const (
+	crashPendingFile = "ttc_crash.json"
+	crashLogLines    = 200
+)
+
+// CrashReport is the diagnostics bundle written after a panic.
+type CrashReport struct {
+	Time      time.Time                  `json:"time"`
+	Panic     string                     `json:"panic"`
+	Stack     string                     `json:"stack"`
+	Version   string                     `json:"version"`
+	GoVersion string                     `json:"go_version"`
+	Platform  string                     `json:"platform"`
+	Config    map[string]json.RawMessage `json:"config"` // crashConfigFields only
+	Log       []string                   `json:"log"`    // last crashLogLines lines, redacted
+}
+
+// RecordCrash writes the bundle for a recovered panic. logPath is the log
+// file whose tail is included. Errors are only logged — we are already
+// handling a crash.
+func RecordCrash(reason interface{}, stack []byte, logPath string) {
+	r := &CrashReport{
+		Time:      time.Now(),
+		Panic:     redactLogLine(fmt.Sprint(reason)),
+		Stack:     string(stack),
+		Version:   ClientVersion,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Config:    redactConfig(models.LoadConfig()),
+		Log:       tailLog(logPath, crashLogLines),
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err == nil {
+		err = os.WriteFile(paths.State(crashPendingFile), data, 0600)
+	}
+	if err != nil {
+		log.Printf("RecordCrash: %v", err)
+	}
+}
+
+// PendingCrash returns the bundle left by a crash in a previous run, if any.
+func PendingCrash() (*CrashReport, bool) {
+	data, err := os.ReadFile(paths.State(crashPendingFile))
+	if err != nil {
+		return nil, false
+	}
+	var r CrashReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		log.Printf("PendingCrash: ignoring unreadable %s: %v", crashPendingFile, err)
+		os.Remove(paths.State(crashPendingFile))
+		return nil, false
+	}
+	return &r, true
+}
+
+// DiscardCrash forgets the pending bundle.
+func DiscardCrash() {
+	os.Remove(paths.State(crashPendingFile))
+}
+
+// SaveCrash moves the pending bundle to a timestamped file in the state
+// directory and returns its path.
+func SaveCrash(r *CrashReport) (string, error) {
+	name := paths.State("ttc_crash_" + r.Time.Format("20060102-150405") + ".json")
+	if err := os.Rename(paths.State(crashPendingFile), name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// SendCrash POSTs the pending bundle to endpoint and forgets it on success.
+func SendCrash(r *CrashReport, endpoint string) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	resp, err := newHTTPClient(10*time.Second).Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer drainClose(resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	DiscardCrash()
+	return nil
+}
+
+// tailLog returns the last n lines of path, redacted.
+func tailLog(path string, n int) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var lines []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		lines = append(lines, redactLogLine(sc.Text()))
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return lines
+}
+
+var (
+	// key=%q fields that carry message text or names.
+	redactQuoted = regexp.MustCompile(`\b(content|sanitized|user|msg|text)=("(?:[^"\\]|\\.)*"?)`)
+	// Raw response bodies are logged unquoted up to the end of the line.
+	redactBody = regexp.MustCompile(`(raw body \(\d+ bytes\): |body=).*`)
+)
+
+func redactLogLine(s string) string {
+	s = redactQuoted.ReplaceAllString(s, `$1=[redacted]`)
+	s = redactBody.ReplaceAllString(s, `$1[redacted]`)
+	return strings.ReplaceAll(s, serverAccessKey, "[access-key]")
+}
+
+// crashConfigFields are the config settings, by JSON name, that a crash
+// bundle carries: how the client connects and draws, nothing that names
+// anyone or unlocks anything. Keys, relay URLs, accounts, aliases, lists and
+// templates stay out.
+var crashConfigFields = []string{
+	"transports", "poll", "heartbeat", "connect_timeout", "tor_proxy",
+	"paste_lines", "max_message_bytes", "notices", "terminal", "layout",
+	"scrollback", "time_zone", "share_time_zone", "locale", "first_weekday",
+	"terminal_title", "compose_preview", "update_check", "keep_repeats",
+	"away_reply", "slow_frame_ms", "slow_frame_warning",
+}
+
+// redactConfig returns the crashConfigFields of cfg, with credentials
+// dropped from the Tor proxy URL.
+func redactConfig(cfg *models.Config) map[string]json.RawMessage {
+	c := *cfg
+	c.TorProxy = stripURLSecrets(c.TorProxy)
+	var all map[string]json.RawMessage
+	if data, err := json.Marshal(&c); err != nil || json.Unmarshal(data, &all) != nil {
+		return nil
+	}
+	kept := make(map[string]json.RawMessage, len(crashConfigFields))
+	for _, name := range crashConfigFields {
+		if v, ok := all[name]; ok {
+			kept[name] = v
+		}
+	}
+	return kept
+}
+
+// stripURLSecrets drops user info, path and query from a URL.
+func stripURLSecrets(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || raw == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
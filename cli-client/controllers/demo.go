@@ -0,0 +1,365 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"cli-client/tasks"
+	"cli-client/ui"
+)
+
+// ── Demo relay ────────────────────────────────────────────────────────────────
+//
+// /demo (or starting with --demo) connects to a relay simulated inside the
+// client: a handful of users chatting in several colors, with bursts, long
+// messages, edits, tview-looking brackets, emoji, wide and right-to-left
+// text, and replies to what you say. It answers at DemoServerURL — a reserved name that
+// never resolves — so everything above the HTTP transport, from polling to
+// the wire checks to drawing, runs exactly as against a real relay. Good for
+// trying themes, animation and performance without a live relay.
+//
+// The simulator speaks /health, /api/send, /api/poll (format 2) and
+// /api/stats; the rest answer 404, as on a relay that predates them. It only
+// produces traffic while it is being polled.
+
+// DemoServerURL is the address of the simulated relay.
+const DemoServerURL = "http://demo.invalid"
+
+const (
+	demoHold    = 25 * time.Second // long-poll hold, a little under the real relay's
+	demoKeep    = 1000             // messages kept, as the relay's default -max-msgs
+	demoBatch   = 50               // most messages in one poll
+	demoIdleFor = 30 * time.Second // stop talking when nobody has polled for this long
+)
+
+type demoUser struct {
+	name  string
+	color string // as other clients send it: a name, #rrggbb or a tview tag
+}
+
+var demoUsers = []demoUser{
+	{"cyber_punk", "green"},
+	{"gopher_dev", "#ff79c6"},
+	{"anon_x", "[yellow]"},
+	{"مریم", "cyan"},
+	{"tanaka_san", "#8be9fd"},
+	{"null_ptr", "red"},
+}
+
+var demoChatter = []string{
+	"hey all 👋",
+	"anyone else on the night shift?",
+	"just pushed a fix, CI is green 🟢",
+	"lol",
+	"brb coffee ☕",
+	"that's what she said",
+	"ok who broke main",
+	"works on my machine™",
+	"sounds good",
+	"+1",
+	"have you tried turning it off and on again",
+	"🚀🚀🚀",
+	"👩‍💻 pair programming later?",
+	"🇮🇷 🇩🇪 🇯🇵 flags render ok here?",
+	"سلام به همه، امروز چطورید؟",
+	"今日はいい天気ですね ☀️",
+	"café, naïve, jalapeño — accents ok?",
+	"arr[0] = arr[len(arr)-1]",
+	"[red]this is not red[-], it's just text",
+	"tview tags look like [yellow] and [::b] — they should show as typed",
+	"[] and [[]] and [-]",
+	"map[string][]int{} is my favourite type",
+	"regex: ^[a-z0-9_]{3,16}$",
+	"go test ./... 🙈",
+	"the relay is fast today",
+}
+
+var demoLong = []string{
+	"Long one, sorry. I spent the afternoon chasing a bug where the long poll came back with an empty array instead of a 204, " +
+		"and the client treated that as a hiccup and backed off for thirty seconds. Turned out a proxy in front of the relay " +
+		"was rewriting the status. Moral of the story: log the status code before you log anything else. Also, proxies. 🙃",
+	"Reading list for the weekend: (1) the tview docs on regions [\"a\"] and [\"\"] — note the quotes; (2) something on " +
+		"long polling vs SSE vs WebSockets, and why a relay might pick the boring one on purpose; (3) a paper on " +
+		"terminal width of emoji, because 👨‍👩‍👧‍👦 is one glyph, seven code points and either two or eight columns " +
+		"depending on who you ask.",
+	"Status update:\n- poll loop: done\n- reconnect with backoff: done\n- [colors] in usernames: escaped\n- " +
+		"right-to-left: سلام دنیا should stay readable\n- still todo: make the dashboard less ugly on 80 columns",
+	strings.Repeat("This line just keeps going to see how wrapping behaves at the edge of the terminal. ", 8),
+}
+
+var demoReplies = []string{
+	"@%s agreed",
+	"@%s haha yes",
+	"@%s wait, really?",
+	"@%s 👍",
+	"@%s can you say more?",
+	"@%s [citation needed]",
+	"@%s same here 🙂",
+}
+
+// demoRelay is the simulated relay. Its methods are safe for concurrent use.
+type demoRelay struct {
+	startOnce sync.Once
+
+	mu       sync.Mutex
+	msgs     []pollMessage
+	nextID   int
+	arrived  chan struct{} // closed and replaced when a message is added
+	lastPoll time.Time
+	me       string        // the username the client sends as
+	mention  chan struct{} // the user said something
+}
+
+var demo = &demoRelay{arrived: make(chan struct{}), mention: make(chan struct{}, 1)}
+
+// demoRouter sends requests for DemoServerURL to the simulator and the rest
+// to next.
+type demoRouter struct{ next http.RoundTripper }
+
+func (d demoRouter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == demoHost() {
+		return demo.RoundTrip(req)
+	}
+	return d.next.RoundTrip(req)
+}
+
+func demoHost() string {
+	u, _ := url.Parse(DemoServerURL)
+	return u.Host
+}
+
+// add stores a message and wakes held polls. Must be called with mu held.
+func (d *demoRelay) add(m pollMessage) string {
+	d.nextID++
+	m.ID = fmt.Sprintf("demo_%d", d.nextID)
+	m.Timestamp = time.Now().UTC()
+	if m.Type == "" {
+		m.Type = msgTypeChat
+	}
+	d.msgs = append(d.msgs, m)
+	if len(d.msgs) > demoKeep {
+		d.msgs = d.msgs[len(d.msgs)-demoKeep:]
+	}
+	close(d.arrived)
+	d.arrived = make(chan struct{})
+	return m.ID
+}
+
+// post adds a chat message from a simulated user and returns its ID.
+func (d *demoRelay) post(u demoUser, text string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.add(pollMessage{Username: u.name, Content: text, Color: u.color})
+}
+
+// after returns up to demoBatch messages following lastID, and a channel
+// closed when the next message arrives. Must be called with mu held.
+func (d *demoRelay) after(lastID string) ([]pollMessage, <-chan struct{}) {
+	start := 0
+	for i := len(d.msgs) - 1; i >= 0; i-- {
+		if d.msgs[i].ID == lastID {
+			start = i + 1
+			break
+		}
+	}
+	out := d.msgs[start:]
+	if len(out) > demoBatch {
+		out = out[:demoBatch]
+	}
+	return append([]pollMessage(nil), out...), d.arrived
+}
+
+func (d *demoRelay) RoundTrip(req *http.Request) (*http.Response, error) {
+	d.startOnce.Do(func() { ui.SafeGo("demo relay", d.run) })
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+
+	switch req.URL.Path {
+	case "/health":
+		return demoResponse(req, http.StatusOK, "OK"), nil
+	case "/api/send":
+		return d.send(req), nil
+	case "/api/poll":
+		return d.poll(req)
+	case "/api/stats":
+		d.mu.Lock()
+		total := len(d.msgs)
+		d.mu.Unlock()
+		return demoJSON(req, map[string]interface{}{
+			"chat_stats": map[string]int{
+				"total_messages":  total,
+				"waiting_clients": 1,
+				"max_waiters":     demoKeep,
+			},
+			"active_clients": len(demoUsers) + 1,
+			"status":         "ok",
+		}), nil
+	}
+	return demoResponse(req, http.StatusNotFound, "404 page not found\n"), nil
+}
+
+func (d *demoRelay) send(req *http.Request) *http.Response {
+	var body struct {
+		Username string `json:"username"`
+		Content  string `json:"content"`
+		Color    string `json:"color"`
+		Type     string `json:"type"`
+	}
+	if err := json.NewDecoder(io.LimitReader(req.Body, maxPollBody)).Decode(&body); err != nil {
+		return demoResponse(req, http.StatusBadRequest, "Bad request\n")
+	}
+	d.mu.Lock()
+	id := d.add(pollMessage{Username: body.Username, Content: body.Content, Color: body.Color, Type: body.Type})
+	d.me = body.Username
+	d.mu.Unlock()
+
+	if (body.Type == "" || body.Type == msgTypeChat) && !strings.HasPrefix(body.Content, controlPrefix) {
+		select {
+		case d.mention <- struct{}{}:
+		default:
+		}
+	}
+	return demoJSON(req, map[string]string{"status": "sent", "id": id, "time": time.Now().UTC().Format(time.RFC3339)})
+}
+
+func (d *demoRelay) poll(req *http.Request) (*http.Response, error) {
+	lastID := req.URL.Query().Get("last_id")
+	d.mu.Lock()
+	d.lastPoll = time.Now()
+	msgs, arrived := d.after(lastID)
+	d.mu.Unlock()
+
+	if len(msgs) == 0 {
+		select {
+		case <-arrived:
+			d.mu.Lock()
+			msgs, _ = d.after(lastID)
+			d.mu.Unlock()
+		case <-time.After(demoHold):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	if len(msgs) == 0 {
+		return demoResponse(req, http.StatusNoContent, ""), nil
+	}
+	return demoJSON(req, msgs), nil
+}
+
+// demoTypos are lines a simulated user sends and then fixes with an edit.
+var demoTypos = [][2]string{
+	{"teh relay is fast today", "the relay is fast today"},
+	{"anyone else on the nigth shift?", "anyone else on the night shift?"},
+	{"just pushed a fix, CI is red 🔴", "just pushed a fix, CI is green 🟢"},
+	{"lunch at 12? or 1230", "lunch at 12:30? or 13:00"},
+}
+
+// run is the simulated conversation: chatter at a human pace, now and then a
+// long message, a burst or an edit, and a reply soon after the user says
+// something.
+func (d *demoRelay) run() {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	pick := func() demoUser { return demoUsers[rnd.Intn(len(demoUsers))] }
+	pause := func(min, max time.Duration) bool {
+		select {
+		case <-time.After(min + time.Duration(rnd.Int63n(int64(max-min)))):
+			return true
+		case <-tasks.Stopping():
+			return false
+		}
+	}
+	log.Printf("TRACE demo relay: started")
+
+	for {
+		replyTo := false
+		select {
+		case <-d.mention:
+			replyTo = true
+			if !pause(time.Second, 3*time.Second) {
+				return
+			}
+		case <-time.After(time.Duration(800+rnd.Intn(3200)) * time.Millisecond):
+		case <-tasks.Stopping():
+			return
+		}
+
+		d.mu.Lock()
+		idle := time.Since(d.lastPoll) > demoIdleFor
+		me := d.me
+		d.mu.Unlock()
+		if idle {
+			continue // nobody is watching
+		}
+
+		switch n := rnd.Intn(20); {
+		case replyTo && me != "" && rnd.Intn(10) < 7:
+			d.post(pick(), fmt.Sprintf(demoReplies[rnd.Intn(len(demoReplies))], me))
+		case n == 0:
+			// A burst: a few users talking over each other.
+			count := 10 + rnd.Intn(30)
+			for i := 0; i < count; i++ {
+				d.post(pick(), demoChatter[rnd.Intn(len(demoChatter))])
+				if !pause(20*time.Millisecond, 80*time.Millisecond) {
+					return
+				}
+			}
+		case n == 1:
+			u, typo := pick(), demoTypos[rnd.Intn(len(demoTypos))]
+			id := d.post(u, typo[0])
+			if !pause(2*time.Second, 6*time.Second) {
+				return
+			}
+			// As a legacy relay: the frame rides in chat content.
+			d.post(u, encodeControl(controlFrame{Op: opEdit, IDs: []string{id}, Text: typo[1]}))
+		case n < 3:
+			d.post(pick(), demoLong[rnd.Intn(len(demoLong))])
+		default:
+			d.post(pick(), demoChatter[rnd.Intn(len(demoChatter))])
+		}
+	}
+}
+
+func demoResponse(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func demoJSON(req *http.Request, v interface{}) *http.Response {
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(v)
+	resp := demoResponse(req, http.StatusOK, buf.String())
+	resp.Header.Set("Content-Type", "application/json")
+	return resp
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// startDemo handles /demo: it switches to the simulated relay. /server or
+// Alt+1..9 switch back. Must be called from the tview event loop.
+func (ac *AppController) startDemo() {
+	if ac.lan == nil && ac.netClient != nil && ac.netClient.serverURL == DemoServerURL {
+		ac.sendSystem("Already in the demo — /server <url> or Alt+1..9 to leave.")
+		return
+	}
+	ac.switchConversation(DemoServerURL)
+	ac.sendSystem("[yellow]Demo:[-] everyone here is simulated and nothing leaves this machine. /server <url> or Alt+1..9 to leave.")
+}
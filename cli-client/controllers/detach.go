@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"cli-client/models"
+	"cli-client/paths"
+)
+
+// ── Detach / re-attach ────────────────────────────────────────────────────────
+//
+// /detach hands the relay connection over to a headless copy of the binary
+// ("cli-client daemon …") and quits the TUI. The daemon keeps long-polling and
+// appends every incoming message to a spool file. The next TUI launch finds
+// the state file, stops the daemon, replays the spool and carries on polling
+// from the daemon's last ID so nothing is missed in between.
+
+const (
+	detachStateFile = "ttc_detached.json"
+	detachSpoolFile = "ttc_detached.spool"
+)
+
+// DetachedSession is the on-disk record of a running background daemon.
+type DetachedSession struct {
+	PID        int       `json:"pid"`
+	Username   string    `json:"username"`
+	Color      string    `json:"color"`
+	ServerURL  string    `json:"server_url"`
+	LastID     string    `json:"last_id"`
+	DetachedAt time.Time `json:"detached_at"`
+}
+
+// SpooledMessage is one message received by the daemon while detached.
+// ResumeID is the poll cursor after the batch this message arrived in.
+type SpooledMessage struct {
+	ResumeID  string    `json:"resume_id"`
+	Username  string    `json:"username"`
+	Content   string    `json:"content"`
+	Color     string    `json:"color"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// StartDaemon launches a detached copy of this binary that keeps polling
+// serverURL on behalf of username, and records it in the state file.
+func StartDaemon(username, colorTag, serverURL, lastID string) (*DetachedSession, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locate executable: %w", err)
+	}
+
+	// Start from an empty spool — anything left over belongs to a session
+	// that was already re-attached or abandoned.
+	os.Remove(paths.State(detachSpoolFile))
+
+	var args []string
+	if paths.Portable() {
+		args = append(args, "--portable") // spool beside the binary too
+	}
+	args = append(args, "daemon", "-server", serverURL, "-last-id", lastID)
+	cmd := exec.Command(exe, args...)
+	cmd.Stdin = nil
+	cmd.Stdout = nil
+	cmd.Stderr = nil
+	cmd.SysProcAttr = daemonSysProcAttr()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start daemon: %w", err)
+	}
+
+	s := &DetachedSession{
+		PID:        cmd.Process.Pid,
+		Username:   username,
+		Color:      colorTag,
+		ServerURL:  serverURL,
+		LastID:     lastID,
+		DetachedAt: time.Now(),
+	}
+	// We never Wait on the daemon — it outlives this process.
+	cmd.Process.Release()
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(paths.State(detachStateFile), data, 0600); err != nil {
+		return nil, fmt.Errorf("write %s: %w", detachStateFile, err)
+	}
+	log.Printf("TRACE StartDaemon: pid=%d server=%s lastID=%q", s.PID, serverURL, lastID)
+	return s, nil
+}
+
+// LoadDetachedSession returns the session left behind by /detach, if any.
+func LoadDetachedSession() (*DetachedSession, bool) {
+	data, err := os.ReadFile(paths.State(detachStateFile))
+	if err != nil {
+		return nil, false
+	}
+	var s DetachedSession
+	if err := json.Unmarshal(data, &s); err != nil || s.Username == "" {
+		log.Printf("LoadDetachedSession: ignoring unreadable %s: %v", detachStateFile, err)
+		os.Remove(paths.State(detachStateFile))
+		return nil, false
+	}
+	return &s, true
+}
+
+// Reattach stops the background daemon and returns everything it spooled.
+// The state and spool files are removed afterwards; LastID is advanced to the
+// newest spooled message so polling resumes exactly where the daemon left off.
+func (s *DetachedSession) Reattach() []SpooledMessage {
+	if p, err := os.FindProcess(s.PID); err == nil {
+		if err := p.Kill(); err != nil {
+			log.Printf("Reattach: daemon pid=%d already gone: %v", s.PID, err)
+		}
+	}
+
+	var msgs []SpooledMessage
+	if f, err := os.Open(paths.State(detachSpoolFile)); err == nil {
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 64*1024), 1024*1024)
+		for sc.Scan() {
+			var m SpooledMessage
+			if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+				continue // partial line from the kill — drop it
+			}
+			msgs = append(msgs, m)
+		}
+		f.Close()
+	}
+	if len(msgs) > 0 {
+		s.LastID = msgs[len(msgs)-1].ResumeID
+	}
+
+	os.Remove(paths.State(detachSpoolFile))
+	os.Remove(paths.State(detachStateFile))
+	log.Printf("TRACE Reattach: pid=%d spooled=%d lastID=%q", s.PID, len(msgs), s.LastID)
+	return msgs
+}
+
+// RunDaemon is the entry point for "cli-client daemon". It polls the relay
+// without a UI and appends incoming messages to the spool until killed.
+// Returns the process exit code.
+func RunDaemon(args []string) int {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	serverURL := fs.String("server", DefaultServerURL, "relay server URL")
+	lastID := fs.String("last-id", "", "resume polling after this message ID")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	spool, err := os.OpenFile(paths.State(detachSpoolFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		log.Printf("RunDaemon: open spool: %v", err)
+		return 1
+	}
+	defer spool.Close()
+
+	var spoolMu sync.Mutex
+	var nc *NetworkClient
+	nc = NewNetworkClient(
+		nil,
+		*serverURL,
+		func(msg *pollMessage) {
+			if !isChatMessage(msg) {
+				return // presence/whois/ping addressed to the TUI — nobody to answer
+			}
+			line, err := json.Marshal(SpooledMessage{
+				ResumeID:  nc.LastID(),
+				Username:  msg.Username,
+				Content:   msg.Content,
+				Color:     msg.Color,
+				Timestamp: messageTime(msg),
+			})
+			if err != nil {
+				return
+			}
+			spoolMu.Lock()
+			defer spoolMu.Unlock()
+			spool.Write(append(line, '\n'))
+			spool.Sync()
+		},
+		func(connected bool, msg string) {
+			log.Printf("daemon: %s", msg)
+		},
+	)
+	nc.SetLastID(*lastID)
+	cfg := models.LoadConfig()
+	if err := SetTorProxy(cfg.TorProxy); err != nil {
+		log.Printf("daemon: %v", err)
+	}
+	SetConnectTimeout(time.Duration(cfg.ConnectTimeout))
+	nc.Configure(cfg)
+	nc.Start()
+	log.Printf("daemon: polling %s as pid=%d", *serverURL, os.Getpid())
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	<-sigCh
+	nc.Stop()
+	return 0
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package controllers
+
+import "syscall"
+
+// daemonSysProcAttr puts the daemon in its own session so closing the
+// terminal (SIGHUP to the foreground process group) does not take it down.
+func daemonSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package controllers
+
+import "syscall"
+
+// detachedProcess is DETACHED_PROCESS from the Win32 API: the daemon gets
+// no console, so closing the terminal window does not terminate it.
+const detachedProcess = 0x00000008
+
+func daemonSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: detachedProcess}
+}
@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"cli-client/models"
+)
+
+// ── cli-client doctor ─────────────────────────────────────────────────────────
+//
+// A non-TUI health check for scripts and monitoring. Each check prints one
+// line; the exit code follows the Nagios plugin convention so the command
+// can be dropped straight into existing monitoring:
+//
+//	0 OK · 1 WARNING · 2 CRITICAL · 3 UNKNOWN (bad usage)
+
+const (
+	doctorOK       = 0
+	doctorWarning  = 1
+	doctorCritical = 2
+	doctorUnknown  = 3
+
+	doctorSkipped = -1 // check not applicable; does not affect the exit code
+)
+
+// maxClockSkew is the largest client/server clock difference reported as OK.
+const maxClockSkew = 5 * time.Second
+
+type doctorResult struct {
+	name   string
+	status int
+	detail string
+}
+
+// RunDoctor is the entry point for "cli-client doctor". Returns the exit code.
+func RunDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	serverURL := fs.String("server", DefaultServerURL, "relay server URL")
+	timeout := fs.Duration("timeout", 5*time.Second, "per-check timeout")
+	if err := fs.Parse(args); err != nil {
+		return doctorUnknown
+	}
+
+	u, err := url.Parse(*serverURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		fmt.Fprintf(os.Stderr, "doctor: invalid server URL %q\n", *serverURL)
+		return doctorUnknown
+	}
+
+	cfg := models.LoadConfig()
+	if err := SetTorProxy(cfg.TorProxy); err != nil {
+		fmt.Fprintf(os.Stderr, "doctor: %v\n", err)
+		return doctorUnknown
+	}
+	SetConnectTimeout(time.Duration(cfg.ConnectTimeout))
+	tor := ViaTor(*serverURL)
+
+	fmt.Printf("TTC doctor — %s\n", *serverURL)
+	if tor {
+		fmt.Println("🧅 routed over Tor")
+	}
+	fmt.Println()
+
+	var results []doctorResult
+	if tor {
+		results = append(results, doctorResult{"dns", doctorSkipped, "resolved by the Tor proxy"})
+	} else {
+		results = append(results, checkDNS(u, *timeout))
+	}
+	health, serverDate := checkHealth(*serverURL, *timeout)
+	results = append(results, health)
+	if tor {
+		results = append(results, doctorResult{"tls", doctorSkipped, "not checked through the proxy"})
+	} else {
+		results = append(results, checkTLS(u, *timeout))
+	}
+	results = append(results, checkClockSkew(serverDate))
+	results = append(results, checkAccessKey(*serverURL, *timeout))
+
+	worst := doctorOK
+	for _, r := range results {
+		label := "SKIP"
+		switch r.status {
+		case doctorOK:
+			label = "PASS"
+		case doctorWarning:
+			label = "WARN"
+		case doctorCritical:
+			label = "FAIL"
+		}
+		fmt.Printf("  [%s] %-11s %s\n", label, r.name, r.detail)
+		if r.status > worst {
+			worst = r.status
+		}
+	}
+
+	fmt.Println()
+	switch worst {
+	case doctorOK:
+		fmt.Println("All checks passed.")
+	case doctorWarning:
+		fmt.Println("Relay reachable with warnings.")
+	default:
+		fmt.Println("Relay NOT healthy.")
+	}
+	return worst
+}
+
+func checkDNS(u *url.URL, timeout time.Duration) doctorResult {
+	host := u.Hostname()
+	if net.ParseIP(host) != nil {
+		return doctorResult{"dns", doctorSkipped, "host is an IP literal"}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return doctorResult{"dns", doctorCritical, err.Error()}
+	}
+	return doctorResult{"dns", doctorOK,
+		fmt.Sprintf("%s → %s (%dms)", host, addrs[0], time.Since(start).Milliseconds())}
+}
+
+// checkHealth also returns the server's Date header for the clock-skew check.
+func checkHealth(serverURL string, timeout time.Duration) (doctorResult, time.Time) {
+	start := time.Now()
+	resp, err := newHTTPClient(timeout).Get(serverURL + "/health")
+	if err != nil {
+		return doctorResult{"health", doctorCritical, err.Error()}, time.Time{}
+	}
+	resp.Body.Close()
+	rtt := time.Since(start)
+
+	serverDate, _ := http.ParseTime(resp.Header.Get("Date"))
+	if resp.StatusCode != http.StatusOK {
+		return doctorResult{"health", doctorCritical,
+			fmt.Sprintf("HTTP %d", resp.StatusCode)}, serverDate
+	}
+	// Shift by half the round trip so the skew estimate is centred.
+	if !serverDate.IsZero() {
+		serverDate = serverDate.Add(rtt / 2)
+	}
+	return doctorResult{"health", doctorOK,
+		fmt.Sprintf("HTTP 200 in %dms", rtt.Milliseconds())}, serverDate
+}
+
+func checkTLS(u *url.URL, timeout time.Duration) doctorResult {
+	if u.Scheme != "https" {
+		return doctorResult{"tls", doctorSkipped, "plain http — traffic is not encrypted in transit"}
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(u.Hostname(), port),
+		&tls.Config{ServerName: u.Hostname()})
+	if err != nil {
+		return doctorResult{"tls", doctorCritical, err.Error()}
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return doctorResult{"tls", doctorCritical, "no peer certificate"}
+	}
+	left := time.Until(certs[0].NotAfter)
+	detail := fmt.Sprintf("valid, expires in %d days", int(left.Hours()/24))
+	if left < 14*24*time.Hour {
+		return doctorResult{"tls", doctorWarning, detail}
+	}
+	return doctorResult{"tls", doctorOK, detail}
+}
+
+func checkClockSkew(serverDate time.Time) doctorResult {
+	if serverDate.IsZero() {
+		return doctorResult{"clock", doctorSkipped, "server sent no Date header"}
+	}
+	skew := time.Since(serverDate).Round(time.Second)
+	detail := fmt.Sprintf("local clock is %v off the server", skew)
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		return doctorResult{"clock", doctorWarning, detail}
+	}
+	return doctorResult{"clock", doctorOK, detail}
+}
+
+// checkAccessKey opens a short poll. A 401 means the key was rejected; a
+// held-open long-poll (client-side timeout) or any 2xx means it was accepted.
+func checkAccessKey(serverURL string, timeout time.Duration) doctorResult {
+	params := url.Values{}
+	params.Set("access_key", serverAccessKey)
+	params.Set("client_id", generateClientID())
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		serverURL+"/api/poll?"+params.Encode(), nil)
+	if err != nil {
+		return doctorResult{"access key", doctorCritical, err.Error()}
+	}
+	resp, err := newHTTPClient(0).Do(req)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return doctorResult{"access key", doctorOK, "accepted (long-poll held open)"}
+	}
+	if err != nil {
+		return doctorResult{"access key", doctorCritical, err.Error()}
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return doctorResult{"access key", doctorCritical, "rejected by relay (HTTP 401)"}
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return doctorResult{"access key", doctorOK, fmt.Sprintf("accepted (HTTP %d)", resp.StatusCode)}
+	}
+	return doctorResult{"access key", doctorWarning, fmt.Sprintf("unexpected HTTP %d", resp.StatusCode)}
+}
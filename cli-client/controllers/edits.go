@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	"cli-client/models"
+)
+
+// ── Edits ─────────────────────────────────────────────────────────────────────
+//
+// /edit <text> replaces our latest chat message in the active conversation.
+// The new text goes out as a broadcast opEdit frame naming the message by
+// its relay ID; each client that showed the message, and knows it came from
+// the frame's sender, swaps the text and redraws the line in place with the
+// changed words marked (see views/diff.go). /diffs off shows the new text
+// alone. Clients that predate edits ignore the frame and keep the original.
+
+const opEdit = "edit"
+
+// maxEditable is how many received messages are remembered for edits, and
+// how many edited ones for /diffs to redraw.
+const maxEditable = 500
+
+// rememberEditable records that the received message with relay ID id is
+// shown as entry. Must be called from the tview event loop.
+func (ac *AppController) rememberEditable(id string, entry *models.Message) {
+	if id == "" {
+		return
+	}
+	if _, ok := ac.editable[id]; !ok {
+		ac.editableOrder = append(ac.editableOrder, id)
+	}
+	ac.editable[id] = entry
+	if len(ac.editableOrder) > maxEditable {
+		delete(ac.editable, ac.editableOrder[0])
+		ac.editableOrder = ac.editableOrder[1:]
+	}
+}
+
+// relayID returns the relay ID our message localID went out as, if the
+// relay has acknowledged it.
+func (ac *AppController) relayID(localID string) (string, bool) {
+	for i := len(ac.sentOrder) - 1; i >= 0; i-- {
+		id := ac.sentOrder[i]
+		if rec, ok := ac.sent[id]; ok && rec.localID == localID {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// editMessage handles /edit. Must be called from the tview event loop.
+func (ac *AppController) editMessage(arg string) {
+	if ac.App.CurrentUser == nil {
+		ac.sendSystem("No user logged in.")
+		return
+	}
+	text := strings.TrimSpace(arg)
+	if text == "" {
+		ac.sendSystem("Usage: /edit <text>  —  replace your last message")
+		return
+	}
+	msg := ac.lastSent[ac.conversationKey()]
+	if msg == nil {
+		ac.sendSystem("Nothing to edit — you have not said anything here yet.")
+		return
+	}
+	if text == msg.Content {
+		ac.sendSystem("Edit: no change.")
+		return
+	}
+	id, ok := ac.relayID(msg.ID)
+	if !ok {
+		ac.sendSystem("Edit: your last message has not reached the relay yet.")
+		return
+	}
+	f := controlFrame{Op: opEdit, IDs: []string{id}, Text: text}
+	if len(encodeControl(f)) > ac.maxContent() {
+		ac.sendSystem("Edit: too long — an edit must fit in one message.")
+		return
+	}
+	ac.lastInput = time.Now()
+	ac.applyEdit(msg, text)
+	ac.sendControl(f)
+}
+
+// handleEdit applies an edit broadcast by another client.
+// Must be called from the tview event loop.
+func (ac *AppController) handleEdit(from string, f *controlFrame) {
+	if from == ac.App.CurrentUser.Username || len(f.IDs) != 1 || strings.TrimSpace(f.Text) == "" {
+		return // our own echoed back, or junk
+	}
+	text := ac.filterText(f.Text)
+	msg, ok := ac.editable[f.IDs[0]]
+	if !ok || msg.Username != from || msg.Content == text {
+		return // not seen here, someone else's message, or a repeat
+	}
+	ac.applyEdit(msg, text)
+}
+
+// applyEdit replaces msg's text and redraws it.
+func (ac *AppController) applyEdit(msg *models.Message, text string) {
+	if msg.Previous == "" {
+		ac.edited = append(ac.edited, msg)
+		if len(ac.edited) > maxEditable {
+			ac.edited = ac.edited[1:]
+		}
+	}
+	msg.Previous, msg.Content = msg.Content, text
+	for _, sink := range ac.messageSinks() {
+		sink.UpdateMessage(msg)
+	}
+}
+
+// setDiffs handles /diffs. Must be called from the tview event loop.
+func (ac *AppController) setDiffs(arg string) {
+	switch strings.ToLower(arg) {
+	case "":
+		ac.diffsOff = !ac.diffsOff
+	case "on", "off":
+		ac.diffsOff = strings.ToLower(arg) == "off"
+	default:
+		ac.sendSystem("Usage: /diffs [on|off]")
+		return
+	}
+	for _, chat := range ac.chatPanes() {
+		chat.SetDiffs(!ac.diffsOff)
+		for _, msg := range ac.edited {
+			chat.UpdateMessage(msg)
+		}
+	}
+	if ac.diffsOff {
+		ac.sendSystem("Diffs OFF — edited messages show only their new text.")
+	} else {
+		ac.sendSystem("Diffs ON — edited messages mark the words that changed.")
+	}
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	"cli-client/models"
+	"cli-client/ui"
+	"cli-client/views"
+)
+
+// ── Ephemeral messages ────────────────────────────────────────────────────────
+//
+// /ephemeral <ttl> <text> broadcasts the text in an opEphemeral control frame
+// instead of as chat, so relays and clients that predate it never display or
+// spool it. Each client, the sender included, shows the line with a ⌛ marker
+// and wipes it from the view and history once ttl has passed since it arrived
+// — measured locally, so clock skew between clients does not matter.
+
+const opEphemeral = "ephemeral"
+
+const maxEphemeralTTL = 24 * time.Hour
+
+// sendEphemeral handles /ephemeral. Must be called from the tview event loop.
+func (ac *AppController) sendEphemeral(arg string) {
+	if ac.App.CurrentUser == nil {
+		ac.sendSystem("No user logged in.")
+		return
+	}
+	parts := strings.SplitN(arg, " ", 2)
+	ttl, err := time.ParseDuration(parts[0])
+	if len(parts) < 2 || strings.TrimSpace(parts[1]) == "" || err != nil || ttl < time.Second || ttl > maxEphemeralTTL {
+		ac.sendSystem("Usage: /ephemeral <ttl> <text>  —  ttl from 1s to 24h, e.g. /ephemeral 30s the code is 4711")
+		return
+	}
+	text := strings.TrimSpace(parts[1])
+	me := ac.App.CurrentUser.Username
+
+	ac.lastInput = time.Now()
+	ac.App.Session.RecordSent(text)
+	ac.showEphemeral(me, text, ac.App.GetUserColorTag(me), ttl)
+	ac.sendControl(controlFrame{Op: opEphemeral, Color: models.ColorName(ac.App.GetUserColorTag(me)), Text: text, TTLMs: ttl.Milliseconds()})
+}
+
+// handleEphemeral displays an ephemeral message broadcast by another client.
+// Must be called from the tview event loop.
+func (ac *AppController) handleEphemeral(from string, f *controlFrame) {
+	if from == ac.App.CurrentUser.Username || ac.isIgnored(from) {
+		return // our own broadcast echoed back, shown when sent, or ignored
+	}
+	ttl := time.Duration(f.TTLMs) * time.Millisecond
+	if f.Text == "" || ttl <= 0 {
+		return
+	}
+	if ttl > maxEphemeralTTL {
+		ttl = maxEphemeralTTL
+	}
+	ac.App.Session.RecordReceived(f.Text)
+	color := views.ColorTag(models.ParseColorToTag(f.Color))
+	if f.Color == "" {
+		color = ac.App.GetUserColorTag(from)
+	}
+	ac.showEphemeral(from, f.Text, color, ttl)
+	ac.notify(from, f.Text)
+}
+
+// showEphemeral adds the message to the history and view and arms its expiry.
+func (ac *AppController) showEphemeral(from, text, color string, ttl time.Duration) {
+	msg := models.NewMessage(from, text)
+	msg.Color = color
+	msg.ExpiresAt = msg.Timestamp.Add(ttl)
+	ac.App.AddMessage(msg)
+	for _, sink := range ac.messageSinks() {
+		sink.AddMessage(msg)
+	}
+
+	id := msg.ID
+	time.AfterFunc(ttl, func() {
+		ui.SafeQueueUpdateDraw(ac.app, "showEphemeral", func() {
+			m := ac.App.ExpireMessage(id)
+			if m == nil {
+				return // cleared in the meantime
+			}
+			for _, sink := range ac.messageSinks() {
+				sink.UpdateMessage(m)
+			}
+		})
+	})
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"cli-client/ui"
+	"cli-client/views"
+)
+
+// ── Relay failover ────────────────────────────────────────────────────────────
+//
+// "relays" in the config lists relay URLs in priority order; the first is
+// the primary. At startup the first one that answers is used. When the
+// relay in use fails failoverAttempts reconnects in a row, the client moves
+// on to the next one in the list, wrapping around, and says so in a banner.
+// While on anything but the primary, the relays ahead of it in the list are
+// probed every failbackEvery, and the first that answers is moved back to.
+// A relay chosen with /server that is not in the list is left alone.
+
+const (
+	failoverAttempts = 3
+	failbackEvery    = time.Minute
+)
+
+// FirstReachable returns the first of primary and then relays, in order,
+// that answers a health check. If none does it returns primary's error.
+func FirstReachable(primary string, relays []string) (string, error) {
+	firstErr := CheckServerConnectivity(primary)
+	if firstErr == nil {
+		return primary, nil
+	}
+	for _, relay := range relays {
+		if relay == primary {
+			continue
+		}
+		if err := CheckServerConnectivity(relay); err == nil {
+			log.Printf("Failover: %s unreachable at startup, using %s", primary, relay)
+			return relay, nil
+		}
+	}
+	return primary, firstErr
+}
+
+// relayIndex returns where the relay in use is in the list, or -1.
+func (ac *AppController) relayIndex() int {
+	if ac.lan != nil {
+		return -1
+	}
+	for i, relay := range ac.App.Config.Relays {
+		if relay == DefaultServerURL {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkFailover moves to the next relay in the list once the one in use has
+// failed attempt reconnects. Must be called from the tview event loop.
+func (ac *AppController) checkFailover(attempt int) {
+	relays := ac.App.Config.Relays
+	i := ac.relayIndex()
+	if attempt < failoverAttempts || i < 0 || len(relays) < 2 {
+		return
+	}
+	next := relays[(i+1)%len(relays)]
+	ac.moveRelay(next, fmt.Sprintf("[yellow]⚠ Relay %s is not answering — switched to %s.[-]",
+		views.Escape(DefaultServerURL), views.Escape(next)))
+}
+
+// moveRelay reconnects to relay, in the same conversation as far as the
+// rest of the client is concerned, and shows banner. Must be called from
+// the tview event loop.
+func (ac *AppController) moveRelay(relay, banner string) {
+	log.Printf("Failover: %s → %s", DefaultServerURL, relay)
+	ac.stashDraft()
+	ac.rekeyHeld(DefaultServerURL, relay)
+	DefaultServerURL = relay
+	ac.stopNetworkClient()
+	ac.startNetworkClient()
+	ac.enterConversation()
+	ac.announce(opJoin)
+	ac.sendSystem(banner)
+	ac.showNotice(banner, views.NoticeError)
+	ac.armFailback()
+}
+
+// armFailback starts probing the relays ahead of the one in use, if it is
+// not the primary. Must be called from the tview event loop.
+func (ac *AppController) armFailback() {
+	ac.stopFailback()
+	i := ac.relayIndex()
+	if i <= 0 {
+		return
+	}
+	ahead := append([]string(nil), ac.App.Config.Relays[:i]...)
+	current := DefaultServerURL
+	ac.failbackTimer = time.AfterFunc(failbackEvery, func() {
+		ui.SafeGo("failback", func() {
+			back := ""
+			for _, relay := range ahead {
+				if CheckServerConnectivity(relay) == nil {
+					back = relay
+					break
+				}
+			}
+			ui.SafeQueueUpdateDraw(ac.app, "failback", func() {
+				if DefaultServerURL != current {
+					return // moved since; that move armed its own probe
+				}
+				if ac.lan != nil || ac.App.CurrentUser == nil {
+					return // off the relays, or shutting down
+				}
+				if back == "" {
+					ac.armFailback()
+					return
+				}
+				ac.moveRelay(back, fmt.Sprintf("[green]Relay %s is back — switched to it from %s.[-]",
+					views.Escape(back), views.Escape(current)))
+			})
+		})
+	})
+}
+
+// stopFailback stops probing the relays ahead, for LAN mode or shutdown.
+// Must be called from the tview event loop.
+func (ac *AppController) stopFailback() {
+	if ac.failbackTimer != nil {
+		ac.failbackTimer.Stop()
+		ac.failbackTimer = nil
+	}
+}
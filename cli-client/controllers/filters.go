@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"cli-client/models"
+	"cli-client/paths"
+	"cli-client/views"
+)
+
+// ── Content filters ───────────────────────────────────────────────────────────
+//
+// The filters section of the config rewrites others' messages before they
+// are shown: profanity masks a built-in list of swear words ("f***"),
+// strip_links replaces URLs with "<link>", and rules are the user's own
+// regexp rewrites, applied in that order. A conversation's "filters": false
+// under rooms shows its messages unfiltered; /filters on|off sets that for
+// the conversation in use.
+//
+// The filtered text is what the transcript and the session history keep —
+// /search and /replay see what was shown. Notifications still look at what
+// was sent, so a mention inside a stripped link rings all the same.
+
+// profanityPattern matches the built-in swear words as whole words.
+var profanityPattern = regexp.MustCompile(`(?i)\b(?:` +
+	`(?:mother)?fuck(?:s|ed|er|ers|ing|in)?|(?:bull)?shit(?:s|ty|ting|ted)?|` +
+	`bitch(?:es|y)?|bastards?|assholes?|dickheads?|cunts?|wank(?:er|ers)?|twats?|` +
+	`piss(?:ed)?|bollocks)\b`)
+
+// linkPattern matches a URL, up to the next space.
+var linkPattern = regexp.MustCompile(`(?i)\b(?:(?:https?|ftp)://|www\.)\S+`)
+
+// linkText replaces a stripped link.
+const linkText = "<link>"
+
+// contentFilter rewrites incoming message text.
+type contentFilter struct {
+	profanity, links bool
+	rules            []filterRule
+}
+
+type filterRule struct {
+	pattern *regexp.Regexp
+	replace string
+}
+
+// newContentFilter compiles cfg; it returns nil for no filters.
+func newContentFilter(cfg models.FilterConfig) *contentFilter {
+	if !cfg.Enabled() {
+		return nil
+	}
+	f := &contentFilter{profanity: cfg.Profanity, links: cfg.StripLinks}
+	for _, r := range cfg.Rules {
+		// Patterns are checked on the way in (Config.validate).
+		f.rules = append(f.rules, filterRule{regexp.MustCompile(r.Pattern), r.Replace})
+	}
+	return f
+}
+
+// apply returns text with the filters applied. A nil f leaves it alone.
+func (f *contentFilter) apply(text string) string {
+	if f == nil {
+		return text
+	}
+	if f.profanity {
+		text = profanityPattern.ReplaceAllStringFunc(text, maskWord)
+	}
+	if f.links {
+		text = linkPattern.ReplaceAllStringFunc(text, func(link string) string {
+			// Sentence punctuation after a link is not part of it.
+			trimmed := strings.TrimRight(link, ".,;:!?)'\"")
+			return linkText + link[len(trimmed):]
+		})
+	}
+	for _, r := range f.rules {
+		text = r.pattern.ReplaceAllString(text, r.replace)
+	}
+	return text
+}
+
+// maskWord keeps the first letter of word and stars the rest.
+func maskWord(word string) string {
+	_, size := utf8.DecodeRuneInString(word)
+	return word[:size] + strings.Repeat("*", utf8.RuneCountInString(word[size:]))
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// filterText applies the filters of the conversation in use to text from
+// someone else. Safe to call from any goroutine.
+func (ac *AppController) filterText(text string) string {
+	return ac.filter.Load().apply(text)
+}
+
+// applyFilters sets the filters for the conversation in use: the configured
+// ones unless the conversation turns them off. Must be called from the
+// tview event loop.
+func (ac *AppController) applyFilters() {
+	var f *contentFilter
+	if on := ac.roomConfig().Filters; on == nil || *on {
+		f = newContentFilter(ac.App.Config.Filters)
+	}
+	ac.filter.Store(f)
+}
+
+// filters handles /filters. Must be called from the tview event loop.
+func (ac *AppController) filters(arg string) {
+	key := ac.conversationKey()
+	switch strings.ToLower(arg) {
+	case "":
+	case "on", "off":
+		on := strings.ToLower(arg) == "on"
+		rooms := make(map[string]models.RoomConfig, len(ac.App.Config.Rooms)+1)
+		for k, r := range ac.App.Config.Rooms {
+			rooms[k] = r
+		}
+		room := rooms[key]
+		room.Filters = &on
+		rooms[key] = room
+		ac.App.Config.Rooms = rooms
+		ac.applyFilters()
+		if err := models.SaveConfigKey("rooms", rooms); err != nil {
+			ac.sendSystem("[red]Filter setting not saved:[-] " + views.Escape(err.Error()) + " — it lasts until you quit.")
+		}
+	default:
+		ac.sendSystem("Usage: /filters [on|off]")
+		return
+	}
+
+	cfg := ac.App.Config.Filters
+	if !cfg.Enabled() {
+		ac.sendSystem("No filters configured — add a [yellow]filters[-] section to " +
+			views.Escape(paths.Config(models.ConfigFile)) + ".")
+		return
+	}
+	state := "[green]on[-]"
+	if ac.filter.Load() == nil {
+		state = "[red]off[-]"
+	}
+	ac.sendSystem("Filters are " + state + " in " + views.Escape(key) + " — /filters on|off. New messages only:")
+	if cfg.Profanity {
+		ac.sendSystem("  profanity  [dim]swear words masked[-]")
+	}
+	if cfg.StripLinks {
+		ac.sendSystem("  strip_links  [dim]URLs shown as " + views.Escape(linkText) + "[-]")
+	}
+	for _, r := range cfg.Rules {
+		ac.sendSystem("  " + views.Escape(r.Pattern) + " [dim]→[-] " + views.Escape(r.Replace))
+	}
+}
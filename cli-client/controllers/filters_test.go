@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"testing"
+
+	"cli-client/models"
+)
+
+func TestContentFilterApply(t *testing.T) {
+	f := newContentFilter(models.FilterConfig{
+		Profanity:  true,
+		StripLinks: true,
+		Rules:      []models.FilterRule{{Pattern: `(?i)\bjira-(\d+)`, Replace: "JIRA #$1"}},
+	})
+	tests := []struct{ in, want string }{
+		{"what the Fuck happened", "what the F*** happened"},
+		{"bullshit, shitake and Scunthorpe", "b*******, shitake and Scunthorpe"},
+		{"see https://example.com/a?b=c.", "see <link>."},
+		{"(www.example.com) or ftp://x", "(<link>) or <link>"},
+		{"fixed in jira-42", "fixed in JIRA #42"},
+	}
+	for _, tt := range tests {
+		if got := f.apply(tt.in); got != tt.want {
+			t.Errorf("apply(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := newContentFilter(models.FilterConfig{}).apply("shit"); got != "shit" {
+		t.Errorf("no filters: got %q, want the text unchanged", got)
+	}
+}
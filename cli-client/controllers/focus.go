@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"cli-client/models"
+	"cli-client/ui"
+)
+
+// ── Focus ─────────────────────────────────────────────────────────────────────
+//
+// Terminals that report focus (see ui/title.go) tell the client when its
+// window is left and entered again. Leaving it puts an unread divider at the
+// end of the transcript; chat messages that arrive below it are counted as
+// unread, for the terminal title (title.go), and their read receipts are
+// held back. Coming back marks them read: the count clears and the held
+// receipts go out. A divider nothing arrived under is taken away again.
+//
+// While the window has focus the messages are in plain sight, so the bell
+// stays quiet unless notify.when_focused says otherwise. Terminals that never
+// report focus never look away, and keep ringing as before.
+
+// focusState is what the client knows of the terminal's focus. Owned by the
+// tview event loop.
+type focusState struct {
+	known  bool // the terminal has reported focus at least once
+	away   bool
+	unread int
+}
+
+// WatchFocus follows the terminal's focus. Call it once, before the event
+// loop starts.
+func (ac *AppController) WatchFocus() {
+	ui.SetFocusHandler(func(focused bool) {
+		ui.SafeQueueUpdateDraw(ac.app, "WatchFocus", func() { ac.setFocused(focused) })
+	})
+}
+
+// setFocused records that the terminal lost or got back focus. Must be
+// called from the tview event loop.
+func (ac *AppController) setFocused(focused bool) {
+	if ac.focus.known && ac.focus.away == !focused {
+		return
+	}
+	ac.focus.known = true
+	ac.focus.away = !focused
+	chat, hasChat := ac.chatView()
+	if !focused {
+		if hasChat {
+			chat.MarkUnread("[dim]── unread since " + models.InZone(models.Now()).Format("15:04") + " ──[-]")
+		}
+		return
+	}
+
+	if ac.focus.unread == 0 && hasChat {
+		chat.ClearUnreadMark()
+	}
+	ac.focus.unread = 0
+	ac.showTitle()
+	if len(ac.pendingSeen) > 0 && ac.receiptTimer == nil {
+		ac.flushReceipts()
+	}
+}
+
+// countUnread counts an incoming chat message if nobody is looking. Must be
+// called from the tview event loop.
+func (ac *AppController) countUnread() {
+	if ac.focus.away {
+		ac.focus.unread++
+		ac.showTitle()
+	}
+}
+
+// lookingAt reports whether the terminal is known to have focus, so new
+// messages are seen as they arrive. Must be called from the tview event
+// loop.
+func (ac *AppController) lookingAt() bool {
+	return ac.focus.known && !ac.focus.away
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"cli-client/ui"
+)
+
+// ── Slow frames ───────────────────────────────────────────────────────────────
+//
+// ui.Frames times every update and draw of the event loop (see
+// views/frames.go) and logs the slow ones. With slow_frame_warning set the
+// footer also shows the latest slow frame for a few seconds; /sessionstats
+// reports the percentiles either way.
+
+const (
+	slowWarningFor = 5 * time.Second
+	slowWarningGap = time.Second // a warning is a frame too: no more than one a second
+)
+
+// roundFrame rounds a frame time for display.
+func roundFrame(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(100 * time.Microsecond)
+}
+
+// frameLines is the frame-time part of /sessionstats.
+func frameLines() []string {
+	st := ui.Frames.Stats()
+	if st.Count == 0 {
+		return []string{"  [cyan]Frames       [-]--"}
+	}
+	return []string{
+		fmt.Sprintf("  [cyan]Frames       [-]%d  ·  p50 %v  ·  p95 %v  ·  p99 %v  ·  max %v", st.Count,
+			roundFrame(st.P50), roundFrame(st.P95), roundFrame(st.P99), roundFrame(st.Max)),
+		fmt.Sprintf("  [cyan]Slow frames  [-]%d over %v", st.Slow, st.Threshold),
+	}
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// WatchFrames applies the slow-frame settings from the config. Call it once,
+// before the event loop starts.
+func (ac *AppController) WatchFrames() {
+	cfg := ac.App.Config
+	ui.Frames.SetThreshold(time.Duration(cfg.SlowFrameMS) * time.Millisecond)
+	if !cfg.SlowFrameWarning {
+		return
+	}
+	var shown time.Time // tview event loop only
+	ui.Frames.OnSlow(func(_ string, took time.Duration) {
+		if time.Since(shown) < slowWarningGap {
+			return
+		}
+		shown = time.Now()
+		go ui.SafeQueueUpdateDraw(ac.app, "WatchFrames", func() { ac.showSlowFrame(took) })
+	})
+}
+
+// showSlowFrame puts took in the footer until slowWarningFor passes without
+// another. Must be called from the tview event loop.
+func (ac *AppController) showSlowFrame(took time.Duration) {
+	chat, ok := ac.chatView()
+	if !ok {
+		return
+	}
+	chat.SetSegment("slow", fmt.Sprintf("  [yellow]⚠ slow frame %v[-]", took.Round(time.Millisecond)))
+	if ac.slowTimer != nil {
+		ac.slowTimer.Stop()
+	}
+	ac.slowTimer = time.AfterFunc(slowWarningFor, func() {
+		ui.SafeQueueUpdateDraw(ac.app, "showSlowFrame", func() { chat.SetSegment("slow", "") })
+	})
+}
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ── Protocol handshake ────────────────────────────────────────────────────────
+//
+// POST /api/hello exchanges protocol versions and feature lists so optional
+// features can check Capabilities.Supports before relying on the relay.
+// Relays that predate the handshake answer 404/405 and are treated as
+// protocol 0 with the original send/poll/stats feature set.
+
+// ProtocolVersion is the relay wire protocol this client speaks.
+const ProtocolVersion = 1
+
+// ClientFeatures is advertised to the relay during the handshake.
+var ClientFeatures = []string{"control-frames", "presence", "whois", "ping", "schema-v2", "types"}
+
+// legacyFeatures is what every pre-handshake relay is known to support.
+var legacyFeatures = []string{"send", "poll", "stats"}
+
+type helloRequest struct {
+	AccessKey string   `json:"access_key"`
+	ClientID  string   `json:"client_id"`
+	Protocol  int      `json:"protocol"`
+	Client    string   `json:"client"`
+	Features  []string `json:"features"`
+}
+
+type helloResponse struct {
+	Protocol   int       `json:"protocol"`
+	Server     string    `json:"server"`
+	Features   []string  `json:"features"`
+	Time       time.Time `json:"time"`
+	MaxContent int       `json:"max_content"`
+}
+
+// Capabilities is the negotiated outcome of the handshake.
+type Capabilities struct {
+	Protocol   int
+	Server     string
+	Features   map[string]bool
+	Legacy     bool      // relay has no /api/hello
+	ServerTime time.Time // relay clock at handshake; zero for legacy relays
+	// ClockOffset is how far the relay's clock runs ahead of ours, taking
+	// ServerTime as read halfway through the round trip. 0 when unknown.
+	ClockOffset time.Duration
+	MaxContent  int // largest message the relay accepts, in bytes; 0 = not stated
+}
+
+// Supports reports whether the relay advertised feature.
+func (c *Capabilities) Supports(feature string) bool {
+	return c != nil && c.Features[feature]
+}
+
+// FeatureList returns the advertised features sorted for display.
+func (c *Capabilities) FeatureList() string {
+	names := make([]string, 0, len(c.Features))
+	for f := range c.Features {
+		names = append(names, f)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func newCapabilities(protocol int, server string, features []string) *Capabilities {
+	c := &Capabilities{Protocol: protocol, Server: server, Features: make(map[string]bool)}
+	for _, f := range features {
+		c.Features[f] = true
+	}
+	return c
+}
+
+// Handshake performs the version/capability exchange with the relay.
+func (nc *NetworkClient) Handshake() (*Capabilities, error) {
+	body, err := json.Marshal(helloRequest{
+		AccessKey: serverAccessKey,
+		ClientID:  nc.clientID,
+		Protocol:  ProtocolVersion,
+		Client:    "cli-client/" + ClientVersion,
+		Features:  ClientFeatures,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sent := time.Now()
+	resp, err := nc.post(nc.shortClient, "/api/hello", body)
+	if err != nil {
+		return nil, err
+	}
+	defer drainClose(resp.Body)
+	rtt := time.Since(sent)
+	log.Printf("TRACE Handshake: status=%d", resp.StatusCode)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var hr helloResponse
+		if err := json.NewDecoder(resp.Body).Decode(&hr); err != nil {
+			return nil, fmt.Errorf("decode hello: %w", err)
+		}
+		caps := newCapabilities(hr.Protocol, hr.Server, hr.Features)
+		caps.ServerTime = hr.Time
+		if !hr.Time.IsZero() {
+			caps.ClockOffset = hr.Time.Sub(sent.Add(rtt / 2))
+		}
+		caps.MaxContent = hr.MaxContent
+		if caps.Supports("plain-colors") {
+			atomic.StoreInt32(&nc.plainColors, 1)
+		}
+		if caps.Supports("lazy-bodies") {
+			atomic.StoreInt32(&nc.lazyBodies, 1)
+		}
+		return caps, nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		caps := newCapabilities(0, "legacy relay", legacyFeatures)
+		caps.Legacy = true
+		return caps, nil
+	case http.StatusUnauthorized:
+		return nil, fmt.Errorf("server rejected access key")
+	}
+	return nil, fmt.Errorf("hello HTTP %d", resp.StatusCode)
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ── Heartbeat ─────────────────────────────────────────────────────────────────
+//
+// A long poll or stream can hang on a dead connection until its timeout
+// runs out (40s for a poll) while the header still says ONLINE. The
+// heartbeat asks the relay's /health every config "heartbeat" interval;
+// after heartbeatMisses unanswered in a row it reports the link lost and
+// cancels the receive request, so the receive loop starts reconnecting at
+// once. When /health answers again it reports the link back and cuts the
+// reconnect backoff short.
+// Any HTTP answer counts: the question is whether the relay is there, not
+// how it feels.
+
+// heartbeatMisses is how many checks in a row must go unanswered before
+// the link counts as lost — one lost packet is not an outage.
+const heartbeatMisses = 2
+
+// errHeartbeatLost is why a receive request the heartbeat cancelled ended.
+var errHeartbeatLost = errors.New("relay stopped answering the heartbeat")
+
+// receiveContext returns the context for one receive request (a poll or a
+// stream), which the heartbeat cancels when the relay stops answering. The
+// caller must call cancel when the request is over.
+func (nc *NetworkClient) receiveContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(nc.ctx)
+	nc.recvMu.Lock()
+	nc.recvCancel = cancel
+	nc.recvMu.Unlock()
+	return ctx, func() { cancel(nil) }
+}
+
+// dropReceive cancels the receive request in flight, if any.
+func (nc *NetworkClient) dropReceive() {
+	nc.recvMu.Lock()
+	cancel := nc.recvCancel
+	nc.recvMu.Unlock()
+	if cancel != nil {
+		cancel(errHeartbeatLost)
+	}
+}
+
+// revive ends a reconnect wait early. Never blocks.
+func (nc *NetworkClient) revive() {
+	select {
+	case nc.reviveCh <- struct{}{}:
+	default:
+	}
+}
+
+// HeartbeatRTT returns how long the last answered heartbeat took, and false
+// if none has been answered yet or the heartbeat is off.
+func (nc *NetworkClient) HeartbeatRTT() (time.Duration, bool) {
+	rtt := atomic.LoadInt64(&nc.beatRTT)
+	return time.Duration(rtt), rtt > 0
+}
+
+// HeartbeatInterval returns how often the relay is checked; 0 = off.
+func (nc *NetworkClient) HeartbeatInterval() time.Duration { return nc.heartbeat }
+
+// heartbeatLoop checks the relay with client until Stop. Runs as a
+// goroutine.
+func (nc *NetworkClient) heartbeatLoop(client *http.Client) {
+	ticker := time.NewTicker(nc.heartbeat)
+	defer ticker.Stop()
+	misses := 0
+	for {
+		select {
+		case <-nc.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		resp, err := nc.get(client, "/health")
+		if err == nil {
+			drainClose(resp.Body)
+		}
+		if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+			misses++
+			if misses == heartbeatMisses && atomic.CompareAndSwapInt32(&nc.linked, 1, 0) {
+				log.Printf("heartbeat: %s missed %d checks, dropping the receive request", nc.serverURL, misses)
+				nc.notifyStatus(false, "Relay stopped answering — reconnecting…")
+				nc.dropReceive()
+			}
+			continue
+		}
+		atomic.StoreInt64(&nc.beatRTT, int64(time.Since(start)))
+		if misses >= heartbeatMisses {
+			// The next long poll may be held for 30s before it proves the
+			// link, so the relay answering is taken as the link being back.
+			log.Printf("heartbeat: %s answers again", nc.serverURL)
+			if atomic.CompareAndSwapInt32(&nc.linked, 0, 1) {
+				nc.notifyStatus(true, fmt.Sprintf("Relay at %s answering again", nc.serverURL))
+			}
+			nc.revive()
+		}
+		misses = 0
+	}
+}
+
+// heartbeatText describes nc's heartbeat for /netstat.
+func heartbeatText(nc *NetworkClient) string {
+	every := nc.HeartbeatInterval()
+	if every <= 0 {
+		return "off"
+	}
+	rtt, ok := nc.HeartbeatRTT()
+	if !ok {
+		return fmt.Sprintf("every %v  ·  no answer yet", every)
+	}
+	return fmt.Sprintf("every %v  ·  last answer %v", every, rtt.Round(time.Millisecond))
+}
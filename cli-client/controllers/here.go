@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+)
+
+// ── @here ─────────────────────────────────────────────────────────────────────
+//
+// A chat message that mentions "@here" rings the bell on every client in the
+// conversation, whatever its notify rules — /dnd, quiet hours and a room's
+// notify "none" still silence it. So it cannot be used to spam, each user
+// gets one @here per hereCooldown: the sending client refuses sooner ones,
+// receiving clients ignore them, and relays reject them (-here-cooldown).
+
+const hereCooldown = 5 * time.Minute
+
+// mentionsHere reports whether content mentions "@here" as a whole word.
+func mentionsHere(content string) bool {
+	return mentions(content, "here")
+}
+
+// allowHere reports whether an @here from sender may ring the bell, and
+// starts sender's cooldown if so.
+func (n *NotificationController) allowHere(sender string, now time.Time) bool {
+	if last, ok := n.lastHere[sender]; ok && now.Sub(last) < hereCooldown {
+		return false
+	}
+	n.lastHere[sender] = now
+	return true
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// checkHere reports whether content may be sent as far as the @here
+// cooldown goes, explaining why not if it may not, and starts the cooldown
+// when content uses @here. Must be called from the tview event loop.
+func (ac *AppController) checkHere(content string) bool {
+	if !mentionsHere(content) {
+		return true
+	}
+	if wait := hereCooldown - time.Since(ac.lastHere); wait > 0 {
+		ac.sendSystem(fmt.Sprintf("[yellow]@here[-] was used less than %v ago — wait %v, or leave it out.",
+			hereCooldown, wait.Round(time.Second)))
+		return false
+	}
+	ac.lastHere = time.Now()
+	return true
+}
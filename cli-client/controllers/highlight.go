@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"cli-client/models"
+	"cli-client/paths"
+	"cli-client/views"
+)
+
+// ── Highlights ────────────────────────────────────────────────────────────────
+//
+// /highlight add <word> [color] [notify] adds a keyword that the chat view
+// shows bold in its own color in others' messages (see views/highlight.go);
+// with notify it also rings the bell, unless the conversation's notify level
+// is none. /highlight remove <word> drops one, /highlight lists them. Rules
+// live in the highlights key of the config file, which these commands
+// rewrite.
+
+const highlightUsage = "Usage: /highlight add <word> [color] [notify]  |  remove <word>  —  quote a phrase"
+
+// highlight handles /highlight. Must be called from the tview event loop.
+func (ac *AppController) highlight(arg string) {
+	args, ok := splitQuoted(arg)
+	if !ok {
+		ac.sendSystem(views.Escape(highlightUsage))
+		return
+	}
+	if len(args) == 0 {
+		ac.listHighlights()
+		return
+	}
+	rules := ac.App.Config.Highlights
+	switch strings.ToLower(args[0]) {
+	case "add":
+		if len(args) < 2 || len(args) > 4 || strings.TrimSpace(args[1]) == "" {
+			ac.sendSystem(views.Escape(highlightUsage))
+			return
+		}
+		rule := models.HighlightRule{Word: strings.Join(strings.Fields(args[1]), " ")}
+		for _, opt := range args[2:] {
+			switch {
+			case strings.EqualFold(opt, "notify"):
+				rule.Notify = true
+			case rule.Color == "" && models.IsValidColor(opt):
+				rule.Color = strings.ToLower(opt)
+			default:
+				ac.sendSystem(fmt.Sprintf("Unknown color: '%s'  —  valid names: %s  |  or hex: #rrggbb",
+					views.Escape(opt), strings.Join(models.ValidNamedColors, ", ")))
+				return
+			}
+		}
+		rules = append(withoutHighlight(rules, rule.Word), rule)
+	case "remove", "rm", "del":
+		if len(args) != 2 {
+			ac.sendSystem(views.Escape(highlightUsage))
+			return
+		}
+		kept := withoutHighlight(rules, args[1])
+		if len(kept) == len(rules) {
+			ac.sendSystem("No highlight for " + views.Escape(args[1]) + ".")
+			return
+		}
+		rules = kept
+	default:
+		ac.sendSystem(views.Escape(highlightUsage))
+		return
+	}
+
+	ac.App.Config.Highlights = rules
+	for _, chat := range ac.chatPanes() {
+		chat.SetHighlights(rules)
+	}
+	if err := models.SaveConfigKey("highlights", rules); err != nil {
+		ac.sendSystem("[red]Highlights not saved:[-] " + views.Escape(err.Error()) + " — they last until you quit.")
+	}
+	ac.listHighlights()
+}
+
+// listHighlights prints the highlight rules.
+func (ac *AppController) listHighlights() {
+	rules := ac.App.Config.Highlights
+	if len(rules) == 0 {
+		ac.sendSystem("No highlights — " + views.Escape("/highlight add <word> [color] [notify]"))
+		return
+	}
+	ac.sendSystem(fmt.Sprintf("Highlights (%d), saved in %s:", len(rules), views.Escape(paths.Config(models.ConfigFile))))
+	for _, r := range rules {
+		color := r.Color
+		if color == "" {
+			color = "yellow"
+		}
+		line := "  " + models.ParseColorToTag(color) + views.Escape(r.Word) + "[-]  [dim]" + color
+		if r.Notify {
+			line += " · 🔔 notify"
+		}
+		ac.sendSystem(line + "[-]")
+	}
+}
+
+// highlightNotifies reports whether content has a highlighted word whose
+// rule rings the bell.
+func (ac *AppController) highlightNotifies(content string) bool {
+	text := " " + strings.Join(words(content), " ") + " "
+	for _, r := range ac.App.Config.Highlights {
+		if kw := strings.Join(words(r.Word), " "); r.Notify && kw != "" && strings.Contains(text, " "+kw+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutHighlight returns rules less the one for word, compared the way
+// the highlighter matches.
+func withoutHighlight(rules []models.HighlightRule, word string) []models.HighlightRule {
+	word = strings.Join(strings.Fields(word), " ")
+	var out []models.HighlightRule
+	for _, r := range rules {
+		if !strings.EqualFold(strings.Join(strings.Fields(r.Word), " "), word) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
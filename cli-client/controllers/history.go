@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"cli-client/models"
+	"cli-client/ui"
+	"cli-client/views"
+)
+
+// ── Relay history ─────────────────────────────────────────────────────────────
+//
+// A relay that advertises "history" serves the messages it still holds from
+// before a given one on /api/history. PgUp at the top of the transcript
+// loads the historyPage messages before the oldest one shown and puts them
+// above it. Once the relay has nothing older, or turns out to keep no
+// history at all (no "history" feature, or a 404 from a relay that never
+// answered the handshake), a divider marks the top and PgUp stops asking.
+//
+// Backfilled lines are only drawn: they are not notified, acknowledged or
+// filed in the session history, which /replay and /search read in arrival
+// order.
+
+// historyPage is how many messages one PgUp at the top loads.
+const historyPage = 50
+
+// ErrHistoryUnsupported is returned by FetchHistory for a relay that keeps
+// no history.
+var ErrHistoryUnsupported = errors.New("relay has no /api/history")
+
+type historyResponse struct {
+	Messages json.RawMessage `json:"messages"`
+	More     bool            `json:"more"`
+}
+
+// FirstID returns the relay ID of the oldest message received, where paging
+// back through the relay's history starts, or "" if none arrived yet.
+func (nc *NetworkClient) FirstID() string {
+	nc.lastIDMu.Lock()
+	defer nc.lastIDMu.Unlock()
+	return nc.firstID
+}
+
+// FetchHistory returns up to limit messages from before the one with relay
+// ID before, oldest first, and whether the relay holds older ones. Safe to
+// call from any goroutine; blocks for one request.
+func (nc *NetworkClient) FetchHistory(before string, limit int) ([]*pollMessage, bool, error) {
+	params := url.Values{}
+	params.Set("access_key", serverAccessKey)
+	params.Set("client_id", nc.clientID)
+	params.Set("before", before)
+	params.Set("limit", strconv.Itoa(limit))
+
+	resp, err := nc.get(nc.shortClient, "/api/history?"+params.Encode())
+	if err != nil {
+		return nil, false, err
+	}
+	defer drainClose(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return nil, false, ErrHistoryUnsupported
+	default:
+		return nil, false, fmt.Errorf("history HTTP %d", resp.StatusCode)
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxPollBody+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(raw) > maxPollBody {
+		nc.drops.add(dropOversized)
+		return nil, false, fmt.Errorf("history answer over %d bytes", maxPollBody)
+	}
+	if reason := checkWire(raw, maxWireDepth); reason != "" {
+		nc.drops.add(reason)
+		return nil, false, fmt.Errorf("parse history: %s", reason)
+	}
+	var hr historyResponse
+	if err := json.Unmarshal(raw, &hr); err != nil || hr.Messages == nil {
+		nc.drops.add(dropSyntax)
+		return nil, false, fmt.Errorf("parse history: %v", err)
+	}
+	msgs, err := parsePollMessages(hr.Messages, &nc.drops)
+	if err != nil {
+		return nil, false, err
+	}
+	return msgs, hr.More, nil
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// historyState is how far back the transcript has been paged. Owned by the
+// tview event loop.
+type historyState struct {
+	before  string // relay ID of the oldest backfilled message
+	loading bool
+	done    bool // nothing older, or no history on this relay
+}
+
+// resetHistory starts paging over, for a new relay or LAN mode. Must be
+// called from the tview event loop.
+func (ac *AppController) resetHistory() {
+	ac.history = historyState{}
+	if chat, ok := ac.chatView(); ok {
+		var fn func()
+		if ac.lan == nil {
+			fn = ac.loadOlder
+		}
+		chat.SetTopFunc(fn)
+	}
+}
+
+// loadOlder fetches the page before the oldest message shown, for PgUp at
+// the top of the transcript. Must be called from the tview event loop.
+func (ac *AppController) loadOlder() {
+	nc := ac.netClient
+	h := &ac.history
+	if nc == nil || ac.lan != nil || h.loading || h.done {
+		return
+	}
+	if ac.caps != nil && !ac.caps.Supports("history") {
+		ac.endHistory("this relay keeps no history")
+		return
+	}
+	before := h.before
+	if before == "" {
+		before = nc.FirstID()
+	}
+	if before == "" {
+		return // nothing received yet to page back from
+	}
+
+	h.loading = true
+	ui.SafeGo("loadOlder", func() {
+		msgs, more, err := nc.FetchHistory(before, historyPage)
+		ui.SafeQueueUpdateDraw(ac.app, "loadOlder", func() {
+			if ac.netClient != nc {
+				return // another relay by now
+			}
+			h.loading = false
+			switch {
+			case errors.Is(err, ErrHistoryUnsupported):
+				ac.endHistory("this relay keeps no history")
+			case err != nil:
+				log.Printf("loadOlder: %v", err)
+				ac.showNotice("[red]Could not load older messages:[-] "+views.Escape(err.Error()), views.NoticeError)
+			default:
+				ac.showOlder(msgs, more)
+			}
+		})
+	})
+}
+
+// showOlder puts msgs, a page of the relay's history, above the
+// transcript. Must be called from the tview event loop.
+func (ac *AppController) showOlder(msgs []*pollMessage, more bool) {
+	if len(msgs) > 0 {
+		ac.history.before = msgs[0].ID
+	}
+	lines := make([]*models.Message, 0, len(msgs))
+	for _, pm := range msgs {
+		if entry := ac.historyEntry(pm); entry != nil {
+			lines = append(lines, entry)
+		}
+	}
+	if chat, ok := ac.chatView(); ok {
+		chat.PrependMessages(lines)
+	}
+	if !more {
+		ac.endHistory("start of what the relay keeps")
+	}
+}
+
+// endHistory marks the top of the transcript with a divider saying why
+// there is nothing older. Must be called from the tview event loop.
+func (ac *AppController) endHistory(why string) {
+	ac.history.done = true
+	if chat, ok := ac.chatView(); ok {
+		chat.SetTopFunc(nil)
+		chat.PrependMessages([]*models.Message{models.NewSystemMessage("[dim]── " + why + " ──[-]")})
+	}
+}
+
+// historyEntry is the line for pm, a message from the relay's history, or
+// nil for one that is not shown as a line.
+func (ac *AppController) historyEntry(pm *pollMessage) *models.Message {
+	if _, ok := decodeControlMessage(pm); ok {
+		return nil
+	}
+	var entry *models.Message
+	switch {
+	case pm.Type == msgTypeSticker:
+		if !isStickerName(pm.Content) {
+			return nil
+		}
+		entry = models.NewMessage(pm.Username, stickerText(pm.Content))
+		entry.Sticker = pm.Content
+	case pm.Type == msgTypeAnnouncement:
+		entry = models.NewMessage(pm.Username, pm.Content)
+		entry.Announcement = true
+	case isChatMessage(pm):
+		entry = models.NewMessage(pm.Username, ac.filterText(pm.Content))
+	default:
+		return nil
+	}
+	entry.Timestamp = messageTime(pm)
+	entry.Color = views.ColorTag(ac.incomingColor(pm))
+	return entry
+}
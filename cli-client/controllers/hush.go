@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cli-client/views"
+)
+
+// ── Hushing ───────────────────────────────────────────────────────────────────
+//
+// /hush room silences the current conversation entirely and /hush <user>
+// one person in every conversation: their chat messages are not shown and
+// do not ring. They are only counted, and /unhush shows a digest — how many
+// from whom, and how many mentioned us — before the live feed carries on.
+// (/mute is the relay-side moderation command.) Hushes last until /unhush
+// or the client quits.
+
+// hushDigest counts what a hush kept back.
+type hushDigest struct {
+	since    time.Time
+	senders  map[string]int
+	total    int
+	mentions int
+}
+
+// hushes is what is hushed. Safe to use from any goroutine.
+type hushes struct {
+	mu    sync.Mutex
+	room  string                 // the conversation entered last
+	rooms map[string]*hushDigest // conversation key → digest
+	users map[string]*hushDigest // username → digest
+}
+
+// enter records the conversation now shown.
+func (h *hushes) enter(room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.room = room
+}
+
+// roomHushed reports whether the conversation now shown is hushed.
+func (h *hushes) roomHushed() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.rooms[h.room]
+	return ok
+}
+
+// catch counts a chat message from sender and reports whether it is
+// hushed. me is our username, for counting mentions.
+func (h *hushes) catch(sender, content, me string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	d, ok := h.rooms[h.room]
+	if !ok {
+		if d, ok = h.users[sender]; !ok {
+			return false
+		}
+	}
+	d.total++
+	d.senders[sender]++
+	if me != "" && mentions(content, me) {
+		d.mentions++
+	}
+	return true
+}
+
+// hush starts a hush on key in set; false if there already is one.
+func (h *hushes) hush(set *map[string]*hushDigest, key string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := (*set)[key]; ok {
+		return false
+	}
+	if *set == nil {
+		*set = make(map[string]*hushDigest)
+	}
+	(*set)[key] = &hushDigest{since: time.Now(), senders: make(map[string]int)}
+	return true
+}
+
+// unhush ends the hush on key in set and returns its digest, or nil if
+// there was none.
+func (h *hushes) unhush(set *map[string]*hushDigest, key string) *hushDigest {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	d := (*set)[key]
+	delete(*set, key)
+	return d
+}
+
+// list returns the hushed conversations and users, sorted.
+func (h *hushes) list() (rooms, users []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for key := range h.rooms {
+		rooms = append(rooms, key)
+	}
+	for user := range h.users {
+		users = append(users, user)
+	}
+	sort.Strings(rooms)
+	sort.Strings(users)
+	return rooms, users
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// hushCommand handles /hush and /unhush. Usage: /hush [room|<user>]
+// /unhush room|<user>. Must be called from the tview event loop.
+func (ac *AppController) hushCommand(arg string, on bool) {
+	if arg == "" {
+		if !on {
+			ac.sendSystem("Usage: /unhush room|<user>")
+			return
+		}
+		rooms, users := ac.hush.list()
+		if len(rooms) == 0 && len(users) == 0 {
+			ac.sendSystem("Nothing is hushed — /hush room or /hush <user>.")
+			return
+		}
+		for _, key := range rooms {
+			ac.sendSystem("Hushed conversation: " + views.Escape(key))
+		}
+		for _, user := range users {
+			ac.sendSystem("Hushed: " + ac.displayName(user))
+		}
+		return
+	}
+
+	set, key, what := &ac.hush.users, ac.resolveAlias(arg), ""
+	if strings.EqualFold(arg, "room") {
+		set, key, what = &ac.hush.rooms, ac.conversationKey(), "This conversation"
+	} else {
+		what = ac.displayName(key)
+		if on && ac.App.CurrentUser != nil && key == ac.App.CurrentUser.Username {
+			ac.sendSystem("You cannot hush yourself.")
+			return
+		}
+	}
+
+	if on {
+		if !ac.hush.hush(set, key) {
+			ac.sendSystem(what + " is already hushed.")
+			return
+		}
+		ac.sendSystem(what + " is hushed — nothing is shown, only counted. /unhush " + views.Escape(arg) + " for a digest.")
+		ac.showHush()
+		return
+	}
+	d := ac.hush.unhush(set, key)
+	if d == nil {
+		ac.sendSystem(what + " is not hushed.")
+		return
+	}
+	ac.showHush()
+	ac.sendSystem(fmt.Sprintf("[dim]── %s[-][dim] was hushed for %v ──[-]", what, time.Since(d.since).Round(time.Second)))
+	ac.sendSystem(hushSummary(d, ac.displayName))
+}
+
+// hushSummary describes d in one line: the count, the busiest senders and
+// the mentions.
+func hushSummary(d *hushDigest, name func(string) string) string {
+	if d.total == 0 {
+		return "Nothing came in meanwhile."
+	}
+	senders := make([]string, 0, len(d.senders))
+	for s := range d.senders {
+		senders = append(senders, s)
+	}
+	sort.Slice(senders, func(i, j int) bool {
+		if d.senders[senders[i]] != d.senders[senders[j]] {
+			return d.senders[senders[i]] > d.senders[senders[j]]
+		}
+		return senders[i] < senders[j]
+	})
+	parts := make([]string, 0, 6)
+	for i, s := range senders {
+		if i == 5 {
+			parts = append(parts, fmt.Sprintf("%d more", len(senders)-5))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s %d", name(s), d.senders[s]))
+	}
+	line := fmt.Sprintf("%d message(s) — %s", d.total, strings.Join(parts, ", "))
+	if d.mentions > 0 {
+		line += fmt.Sprintf(" · [red]%d mention(s) of you[-]", d.mentions)
+	}
+	return line
+}
+
+// showHush shows in the footer whether the conversation is hushed. Must be
+// called from the tview event loop.
+func (ac *AppController) showHush() {
+	chat, ok := ac.chatView()
+	if !ok {
+		return
+	}
+	segment := ""
+	if ac.hush.roomHushed() {
+		segment = "  [gray]🔕 hushed[-]"
+	}
+	chat.SetSegment("hush", segment)
+}
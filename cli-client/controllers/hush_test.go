@@ -0,0 +1,30 @@
+package controllers
+
+import "testing"
+
+func TestHushDigest(t *testing.T) {
+	var h hushes
+	h.enter("https://relay.example.org")
+	h.hush(&h.users, "bob")
+	if h.catch("alice", "hi", "me") {
+		t.Error("caught a message from someone not hushed")
+	}
+	h.hush(&h.rooms, "https://relay.example.org")
+	for _, m := range []struct{ from, text string }{
+		{"alice", "hi"}, {"bob", "@me look"}, {"alice", "and again"}, {"carol", "hey"},
+	} {
+		if !h.catch(m.from, m.text, "me") {
+			t.Errorf("message from %s not caught in a hushed conversation", m.from)
+		}
+	}
+	d := h.unhush(&h.rooms, "https://relay.example.org")
+	got := hushSummary(d, func(s string) string { return s })
+	want := "4 message(s) — alice 2, bob 1, carol 1 · [red]1 mention(s) of you[-]"
+	if got != want {
+		t.Errorf("hushSummary = %q, want %q", got, want)
+	}
+	h.enter("lan")
+	if !h.catch("bob", "hi", "me") || h.catch("alice", "hi", "me") {
+		t.Error("a hushed user is not hushed in every conversation")
+	}
+}
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"cli-client/crypto"
+	"cli-client/models"
+	"cli-client/paths"
+	"cli-client/views"
+)
+
+// ── Identity keys and trust ───────────────────────────────────────────────────
+//
+// The client's identity key (crypto.Identity, kept in identityFile) goes out
+// signed for our username in join and rename announcements and /whois
+// replies. Every other account (see accounts.go) has a key of its own, so
+// the accounts cannot be linked by their fingerprint. The first key seen for a contact is pinned (trust on first use);
+// /verify compares fingerprints to mark it verified. A different key for a
+// pinned name is warned about and pinned in its place, dropping any
+// verification. Messages themselves are not signed: the badge says whose
+// key the name was last announced with.
+
+const identityFile = "ttc_identity.key"
+
+// accountIdentityFile returns the identity key file of the account called
+// name: identityFile for main, ttc_identity_<name>.key for the others.
+func accountIdentityFile(name string) string {
+	if name == models.MainAccount {
+		return identityFile
+	}
+	return "ttc_identity_" + name + ".key"
+}
+
+// isAccountIdentityFile reports whether file is the identity key file of an
+// account other than main.
+func isAccountIdentityFile(file string) bool {
+	name, ok := strings.CutPrefix(file, "ttc_identity_")
+	name, key := strings.CutSuffix(name, ".key")
+	return ok && key && name != models.MainAccount && models.IsTemplateName(name)
+}
+
+const verifyUsage = "Usage: /verify [user [yes|<fingerprint>]]  |  /unverify <user>"
+
+// loadIdentity reads the active account's identity key file, creating it
+// on first use. Without a key, announcements go out unsigned.
+func (ac *AppController) loadIdentity() {
+	file := accountIdentityFile(ac.accountName())
+	path := paths.Data(file)
+	ac.identity = nil
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if ac.identity, err = crypto.ParseIdentity(data); err != nil {
+			log.Printf("loadIdentity: %s: %v — not announcing a key", file, err)
+		}
+		return
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		log.Printf("loadIdentity: %v", err)
+		return
+	}
+	id, err := crypto.NewIdentity()
+	if err == nil {
+		err = os.WriteFile(path, id.Marshal(), 0600)
+	}
+	if err != nil {
+		log.Printf("loadIdentity: creating %s: %v", file, err)
+		return
+	}
+	ac.identity = id
+}
+
+// signIdentity adds our identity key to f, an announcement or /whois reply.
+func (ac *AppController) signIdentity(f *controlFrame) {
+	if ac.identity == nil || ac.App.CurrentUser == nil {
+		return
+	}
+	f.Key = ac.identity.PublicKey()
+	f.Sig = ac.identity.Sign(ac.App.CurrentUser.Username)
+}
+
+// checkIdentity pins or checks the identity key in f, a frame from from.
+// Must be called from the tview event loop.
+func (ac *AppController) checkIdentity(from string, f *controlFrame) {
+	if f.Key == "" {
+		return
+	}
+	if !crypto.VerifyIdentity(f.Key, from, f.Sig) {
+		ac.sendSystem(fmt.Sprintf("[red]⚠ %s sent an identity key with a bad signature — ignored.[-]", ac.displayName(from)))
+		return
+	}
+	c := ac.App.SeeContact(from, ac.conversationKey(), time.Now())
+	switch {
+	case c.Key == f.Key:
+		return
+	case c.Key == "":
+		c.Key, c.Trust = f.Key, models.TrustPinned
+		ac.sendSystem(fmt.Sprintf("[dim]Pinned %s's identity key %s — /verify %s to compare it.[-]",
+			ac.displayName(from), crypto.Fingerprint(f.Key), views.Escape(from)))
+	default:
+		was := "pinned"
+		if c.Trust == models.TrustVerified {
+			was = "verified"
+		}
+		c.Key, c.Trust = f.Key, models.TrustPinned
+		ac.sendSystem(fmt.Sprintf("[red::b]⚠ %s's identity key changed![-::-] [red]It differs from the %s one — "+
+			"a reinstall, or someone else using the name. New key %s; /verify %s once you are sure.[-]",
+			ac.displayName(from), was, crypto.Fingerprint(f.Key), views.Escape(from)))
+	}
+	ac.checkInvited(from, c)
+	ac.App.SaveContacts()
+	ac.pushTrust()
+}
+
+// pushTrust shows the current trust levels in the chat panes.
+func (ac *AppController) pushTrust() {
+	if ac.App.CurrentUser == nil {
+		return
+	}
+	levels := make(map[string]models.Trust, len(ac.App.Contacts))
+	for user := range ac.App.Contacts {
+		levels[user] = ac.App.TrustOf(user)
+	}
+	for _, chat := range ac.chatPanes() {
+		chat.SetTrust(ac.App.CurrentUser.Username, levels)
+	}
+}
+
+// trustText describes username's trust level for /whois and /contacts.
+func (ac *AppController) trustText(username string) string {
+	switch ac.App.TrustOf(username) {
+	case models.TrustVerified:
+		return "✓ verified"
+	case models.TrustPinned:
+		return "○ pinned"
+	default:
+		return "? no key"
+	}
+}
+
+// verify handles /verify. Must be called from the tview event loop.
+func (ac *AppController) verify(arg string) {
+	fields := strings.Fields(arg)
+	if len(fields) == 0 {
+		if ac.identity == nil {
+			ac.sendSystem("No identity key — see the log for why.")
+			return
+		}
+		ac.sendSystem("Your identity key fingerprint: [cyan]" + crypto.Fingerprint(ac.identity.PublicKey()) + "[-]")
+		return
+	}
+	user := ac.resolveAlias(fields[0])
+	c, ok := ac.App.Contacts[user]
+	if !ok || c.Key == "" {
+		ac.sendSystem("No identity key for " + views.Escape(user) + " yet — it arrives when they join or answer /whois.")
+		return
+	}
+	theirs := crypto.Fingerprint(c.Key)
+	if len(fields) == 1 {
+		ac.sendSystem(fmt.Sprintf("%s  ▸  %s  [dim](%s)[-]", ac.displayName(user), theirs, ac.trustText(user)))
+		if ac.identity != nil {
+			ac.sendSystem("You  ▸  " + crypto.Fingerprint(ac.identity.PublicKey()))
+		}
+		ac.sendSystem(fmt.Sprintf("[dim]Compare both over another channel (in person, a call), then /verify %s yes.[-]", views.Escape(user)))
+		return
+	}
+	if answer := strings.Join(fields[1:], ""); !strings.EqualFold(answer, "yes") {
+		if !strings.EqualFold(answer, strings.ReplaceAll(theirs, " ", "")) {
+			ac.sendSystem(fmt.Sprintf("[red]⚠ That fingerprint does not match %s's key %s.[-]", ac.displayName(user), theirs))
+			return
+		}
+	}
+	c.Trust = models.TrustVerified
+	ac.App.SaveContacts()
+	ac.pushTrust()
+	ac.sendSystem(fmt.Sprintf("[green]✓[-] %s is verified.", ac.displayName(user)))
+}
+
+// unverify handles /unverify. Must be called from the tview event loop.
+func (ac *AppController) unverify(arg string) {
+	user := ac.resolveAlias(strings.TrimSpace(arg))
+	if user == "" {
+		ac.sendSystem(views.Escape(verifyUsage))
+		return
+	}
+	c, ok := ac.App.Contacts[user]
+	if !ok || c.Trust != models.TrustVerified {
+		ac.sendSystem(views.Escape(user) + " is not verified.")
+		return
+	}
+	c.Trust = models.TrustPinned
+	ac.App.SaveContacts()
+	ac.pushTrust()
+	ac.sendSystem(ac.displayName(user) + " is back to a pinned key.")
+}
@@ -0,0 +1,21 @@
+package controllers
+
+import "testing"
+
+func TestAccountIdentityFile(t *testing.T) {
+	if got := accountIdentityFile("main"); got != identityFile {
+		t.Errorf("main's key file = %q, want %q", got, identityFile)
+	}
+	work := accountIdentityFile("work")
+	if work == identityFile || !isAccountIdentityFile(work) {
+		t.Errorf("work's key file %q is shared or not recognized", work)
+	}
+	for _, name := range []string{
+		identityFile, "ttc_identity_main.key", "ttc_identity_.key",
+		"ttc_identity_../x.key", "ttc_identity_Work.key", "ttc_identity_work.txt",
+	} {
+		if isAccountIdentityFile(name) {
+			t.Errorf("%q taken for another account's key file", name)
+		}
+	}
+}
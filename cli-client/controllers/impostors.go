@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cli-client/models"
+	"cli-client/views"
+)
+
+// ── Impostors ─────────────────────────────────────────────────────────────────
+//
+// Nothing stops another client from posting under our username. Our own
+// sends never reach onIncoming — NetworkClient drops their echoes by relay
+// ID — so a chat message or sticker that arrives with our name, in any
+// case, came from someone else — unless it is older than the connection:
+// the relay hands its recent messages to every client that connects, ours
+// from before a restart or a /server switch included, and those cannot be
+// told apart. Anything else with our name is shown with a ⚠ impostor badge
+// (see views/trust.go), the first one of a session is warned about, and
+// /whois counts them.
+
+// impostorLog is what the session has seen of impostors. Owned by the tview
+// event loop.
+type impostorLog struct {
+	count int
+	last  time.Time
+}
+
+// setSelf records our username for impostor checks off the event loop.
+// Must be called from the tview event loop whenever the username changes.
+func (ac *AppController) setSelf(username string) {
+	ac.self.Store(username)
+}
+
+// markLive records that a relay or LAN connection starts now. Messages
+// from before it are backlog. Must be called from the tview event loop.
+func (ac *AppController) markLive() {
+	ac.liveSince.Store(models.Now().UnixNano())
+}
+
+// isBacklog reports whether a message sent at was sent before the current
+// connection started. Safe to call from any goroutine.
+func (ac *AppController) isBacklog(at time.Time) bool {
+	return at.UnixNano() < ac.liveSince.Load()
+}
+
+// isImpostor reports whether username, on a message sent at that is not
+// one of our own sends, claims to be us. Safe to call from any goroutine.
+func (ac *AppController) isImpostor(username string, at time.Time) bool {
+	self, _ := ac.self.Load().(string)
+	return self != "" && strings.EqualFold(username, self) && !ac.isBacklog(at)
+}
+
+// noteImpostor counts a message from someone posing as us and warns about
+// the first. Must be called from the tview event loop.
+func (ac *AppController) noteImpostor(msg *models.Message) {
+	ac.impostors.count++
+	ac.impostors.last = msg.Timestamp
+	if ac.impostors.count == 1 {
+		ac.sendSystem(fmt.Sprintf(
+			"[yellow::b]⚠ Another client is posting as %s[-::-] — its messages are marked [black:yellow:b]⚠ impostor[-:-:-]. /whois counts them.",
+			views.Escape(msg.Username)))
+	}
+}
+
+// impostorWhois is the /whois line about impostors, or "" if none were
+// seen. Must be called from the tview event loop.
+func (ac *AppController) impostorWhois() string {
+	n := ac.impostors.count
+	if n == 0 {
+		return ""
+	}
+	plural := "s"
+	if n == 1 {
+		plural = ""
+	}
+	return fmt.Sprintf("[yellow]⚠ %d message%s from another client using your name this session, the last at %s[-]",
+		n, plural, models.InZone(ac.impostors.last).Format("15:04"))
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"fmt"
+	"sync"
+
+	"cli-client/models"
+	"cli-client/tasks"
+	"cli-client/ui"
+)
+
+// ── Inbox ─────────────────────────────────────────────────────────────────────
+//
+// Incoming chat goes through one bounded queue, drained by one goroutine
+// that hands the view a message or a batch at a time and waits until it is
+// drawn. While the view is busy, new messages wait and go in the next batch,
+// so a backlog sync of a thousand messages costs a few redraws rather than a
+// thousand. Past maxInbox waiting messages the oldest skip the view: they
+// still go to the history, ring the bell and count as received, and a
+// "+N more messages" line stands in for them until /replay.
+
+// maxInbox is the most messages waiting to be shown before the oldest are
+// filed unshown.
+const maxInbox = 200
+
+// inbox is the queue between the network goroutines and the view. Safe to
+// use from any goroutine.
+type inbox struct {
+	mu      sync.Mutex
+	msgs    []*pollMessage // to be shown, oldest first
+	skipped []*pollMessage // pushed out of msgs; filed unshown
+	wake    chan struct{}  // one pending signal for drainInbox
+}
+
+func newInbox() *inbox {
+	return &inbox{wake: make(chan struct{}, 1)}
+}
+
+// push queues msg, pushing the oldest out to skipped past maxInbox.
+func (q *inbox) push(msg *pollMessage) {
+	q.mu.Lock()
+	q.msgs = append(q.msgs, msg)
+	if n := len(q.msgs) - maxInbox; n > 0 {
+		q.skipped = append(q.skipped, q.msgs[:n]...)
+		q.msgs = append([]*pollMessage(nil), q.msgs[n:]...)
+	}
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Len returns how many messages are waiting, shown or skipped.
+func (q *inbox) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.msgs) + len(q.skipped)
+}
+
+// take empties the queue.
+func (q *inbox) take() (skipped, msgs []*pollMessage) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	skipped, msgs = q.skipped, q.msgs
+	q.skipped, q.msgs = nil, nil
+	return skipped, msgs
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// drainInbox shows queued messages for as long as the app runs. A lone
+// message takes the usual path, animation included; anything more is shown
+// as one batch. Runs as a goroutine.
+func (ac *AppController) drainInbox() {
+	for {
+		select {
+		case <-ac.inbox.wake:
+		case <-tasks.Stopping():
+			return
+		}
+		skipped, msgs := ac.inbox.take()
+		if len(skipped) == 0 && len(msgs) == 0 {
+			continue
+		}
+		if len(skipped) == 0 && len(msgs) == 1 {
+			ac.showIncoming(msgs[0])
+			ui.SafeQueueUpdateDraw(ac.app, "drainInbox", func() {}) // wait for its draw
+		} else {
+			ui.SafeQueueUpdateDraw(ac.app, "drainInbox", func() { ac.showBatch(skipped, msgs) })
+		}
+	}
+}
+
+// showBatch files skipped in the history and shows msgs under a line
+// counting the skipped ones. Must be called from the tview event loop.
+func (ac *AppController) showBatch(skipped, msgs []*pollMessage) {
+	sinks := ac.messageSinks()
+	for _, pm := range skipped {
+		ac.App.Session.RecordReceived(pm.Content)
+		if len(sinks) > 0 {
+			ac.recordIncoming(pm, ac.incomingEntry(pm))
+			ac.notify(pm.Username, pm.Content)
+		}
+	}
+	for _, pm := range msgs {
+		ac.App.Session.RecordReceived(pm.Content)
+	}
+	if len(sinks) == 0 {
+		return
+	}
+
+	lines := make([]*models.Message, 0, len(msgs)+1)
+	if len(skipped) > 0 {
+		note := models.NewSystemMessage(fmt.Sprintf(
+			"[dim]+%d more messages arrived faster than they could be shown — /replay to read them[-]", len(skipped)))
+		ac.App.AddMessage(note)
+		lines = append(lines, note)
+	}
+	for _, pm := range msgs {
+		entry := ac.incomingEntry(pm)
+		ac.recordIncoming(pm, entry)
+		ac.repeats.shown(pm, entry.ID)
+		ac.awaitSight(pm.Username, pm.ID, entry.ID)
+		if entry.Impostor {
+			ac.noteImpostor(entry)
+		}
+		ac.notify(pm.Username, pm.Content)
+		lines = append(lines, entry)
+	}
+	for _, sink := range sinks {
+		sink.AddMessages(lines)
+	}
+	ac.showRepeats()
+	ac.noteActivity()
+}
+
+// incomingEntry is the history entry for the received chat message pm.
+func (ac *AppController) incomingEntry(pm *pollMessage) *models.Message {
+	entry := models.NewMessage(pm.Username, ac.filterText(pm.Content))
+	entry.Timestamp = messageTime(pm)
+	entry.Impostor = ac.isImpostor(pm.Username, entry.Timestamp)
+	return entry
+}
@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"cli-client/models"
+	"cli-client/paths"
+	"cli-client/ui"
+)
+
+// ── Single instance ───────────────────────────────────────────────────────────
+//
+// The TUI holds a lock file in the state directory for as long as it runs
+// and listens on a Unix socket beside it. A second launch finds the lock, sees the socket answer and
+// refuses to open a duplicate session; "cli-client send <text>" uses the same
+// socket to post a message through the running client instead.
+//
+// One JSON request per connection, one JSON reply:
+//
+//	→ {"op":"send","text":"hi"}
+//	← {"ok":true}  or  {"error":"not logged in yet"}
+
+const (
+	instanceLockFile   = "ttc_instance.lock"
+	instanceSocketFile = "ttc_instance.sock"
+
+	instanceTimeout = 5 * time.Second
+)
+
+// ErrAlreadyRunning is returned by AcquireInstance when another client is
+// running with the same state directory.
+var ErrAlreadyRunning = errors.New("cli-client is already running")
+
+type instanceRequest struct {
+	Op   string `json:"op"`
+	Text string `json:"text,omitempty"`
+}
+
+type instanceReply struct {
+	OK    bool   `json:"ok,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Instance is the lock and socket held by the running TUI.
+type Instance struct {
+	ln        net.Listener
+	closeOnce sync.Once
+}
+
+// AcquireInstance takes the single-instance lock and opens the socket. It
+// returns ErrAlreadyRunning (with the holder's pid) if another client
+// answers on the socket; a lock left behind by a crash is taken over.
+func AcquireInstance() (*Instance, error) {
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(paths.State(instanceLockFile), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			break
+		}
+		if !errors.Is(err, os.ErrExist) || attempt > 0 {
+			return nil, fmt.Errorf("lock %s: %w", instanceLockFile, err)
+		}
+		if conn, err := net.DialTimeout("unix", paths.State(instanceSocketFile), time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%w (pid %s)", ErrAlreadyRunning, lockHolder())
+		}
+		log.Printf("AcquireInstance: taking over stale lock of pid %s", lockHolder())
+		os.Remove(paths.State(instanceLockFile))
+	}
+
+	os.Remove(paths.State(instanceSocketFile)) // left behind if the last holder crashed
+	ln, err := net.Listen("unix", paths.State(instanceSocketFile))
+	if err != nil {
+		os.Remove(paths.State(instanceLockFile))
+		return nil, fmt.Errorf("listen on %s: %w", instanceSocketFile, err)
+	}
+	os.Chmod(paths.State(instanceSocketFile), 0600)
+	log.Printf("TRACE AcquireInstance: pid=%d socket=%s", os.Getpid(), instanceSocketFile)
+	return &Instance{ln: ln}, nil
+}
+
+// lockHolder returns the pid written in the lock file, or "?".
+func lockHolder() string {
+	data, _ := os.ReadFile(paths.State(instanceLockFile))
+	if pid := strings.TrimSpace(string(data)); pid != "" {
+		return pid
+	}
+	return "?"
+}
+
+// serve answers requests until Close, handing each text to forward.
+func (in *Instance) serve(forward func(text string) error) {
+	for {
+		conn, err := in.ln.Accept()
+		if err != nil {
+			return // closed
+		}
+		ui.SafeGo("instance request", func() {
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(instanceTimeout))
+			var req instanceRequest
+			reply := instanceReply{OK: true}
+			if err := json.NewDecoder(conn).Decode(&req); err != nil {
+				reply = instanceReply{Error: "bad request"}
+			} else if req.Op != "send" {
+				reply = instanceReply{Error: fmt.Sprintf("unknown op %q", req.Op)}
+			} else if err := forward(req.Text); err != nil {
+				reply = instanceReply{Error: err.Error()}
+			}
+			json.NewEncoder(conn).Encode(reply)
+		})
+	}
+}
+
+// Close stops listening and releases the lock. Safe on a nil Instance.
+func (in *Instance) Close() {
+	if in == nil {
+		return
+	}
+	in.closeOnce.Do(func() {
+		in.ln.Close()
+		os.Remove(paths.State(instanceSocketFile))
+		os.Remove(paths.State(instanceLockFile))
+	})
+}
+
+// RunSend is the entry point for "cli-client send". It posts a message
+// through the running client. Returns the process exit code.
+func RunSend(args []string) int {
+	fs := flag.NewFlagSet("send", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: cli-client send <message…>")
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	text := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if text == "" {
+		fs.Usage()
+		return 2
+	}
+
+	conn, err := net.DialTimeout("unix", paths.State(instanceSocketFile), instanceTimeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "send: no running cli-client — start one first")
+		return 1
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(instanceTimeout))
+
+	if err := json.NewEncoder(conn).Encode(instanceRequest{Op: "send", Text: text}); err != nil {
+		fmt.Fprintf(os.Stderr, "send: %v\n", err)
+		return 1
+	}
+	var reply instanceReply
+	if err := json.NewDecoder(conn).Decode(&reply); err != nil {
+		fmt.Fprintf(os.Stderr, "send: no reply from the running client: %v\n", err)
+		return 1
+	}
+	if !reply.OK {
+		fmt.Fprintf(os.Stderr, "send: %s\n", reply.Error)
+		return 1
+	}
+	return 0
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// ServeInstance posts messages sent with "cli-client send" until in is closed.
+func (ac *AppController) ServeInstance(in *Instance) {
+	ui.SafeGo("instance", func() { in.serve(ac.forwardMessage) })
+}
+
+// forwardMessage sends text as if it had been typed. Called from instance
+// request goroutines.
+func (ac *AppController) forwardMessage(text string) error {
+	if strings.HasPrefix(text, "/") {
+		return errors.New("commands cannot be forwarded, only messages")
+	}
+	result := make(chan error, 1)
+	ui.SafeQueueUpdateDraw(ac.app, "forwardMessage", func() {
+		if ac.App.CurrentUser == nil || ac.SM.Current() != models.ScreenChat {
+			result <- errors.New("not logged in yet")
+			return
+		}
+		ac.sendChat(text)
+		result <- nil
+	})
+	select {
+	case err := <-result:
+		return err
+	default:
+		return errors.New("the client is shutting down")
+	}
+}
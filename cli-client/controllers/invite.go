@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"cli-client/crypto"
+	"cli-client/models"
+	"cli-client/views"
+)
+
+// ── Invitations ───────────────────────────────────────────────────────────────
+//
+// An invitation is a ttc:// link to a conversation:
+//
+//	ttc://relay.example.org:8080/chat?from=alice&key=48660b47…&tls=0
+//
+// The host and path are the relay's (https, or http with tls=0), or "lan"
+// for LAN mode. from and key are optional: who sent it and the fingerprint
+// of their identity key, without spaces. /invite makes one for the current
+// conversation; /join <link>, or the link as a command-line argument, opens
+// the conversation and keeps it under "rooms" in the config so /rooms lists
+// it from then on. With a key, the sender is verified as soon as their key
+// is seen and matches — or warned about if it does not.
+
+const inviteScheme = "ttc"
+
+// fingerprintHex matches a fingerprint as an invitation carries it.
+var fingerprintHex = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Invite is a parsed invitation.
+type Invite struct {
+	Relay string // relay URL, or "lan"
+	From  string // who sent it; "" = not said
+	Key   string // fingerprint of From's identity key, no spaces; "" = none
+}
+
+// ParseInvite parses a ttc:// link.
+func ParseInvite(link string) (Invite, error) {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Scheme != inviteScheme || u.Host == "" {
+		return Invite{}, errors.New("not an invitation — it starts with ttc://")
+	}
+	q := u.Query()
+	inv := Invite{
+		From: q.Get("from"),
+		Key:  strings.ToLower(strings.ReplaceAll(q.Get("key"), " ", "")),
+	}
+	switch {
+	case u.Host == "lan":
+		inv.Relay = "lan"
+	case q.Get("tls") == "0":
+		inv.Relay = strings.TrimSuffix("http://"+u.Host+u.EscapedPath(), "/")
+	default:
+		inv.Relay = strings.TrimSuffix("https://"+u.Host+u.EscapedPath(), "/")
+	}
+	if inv.Key != "" && !fingerprintHex.MatchString(inv.Key) {
+		return Invite{}, errors.New("the invitation's key is not a fingerprint")
+	}
+	if inv.Key != "" && inv.From == "" {
+		return Invite{}, errors.New("the invitation has a key but not whose it is")
+	}
+	return inv, nil
+}
+
+// String returns inv as a ttc:// link.
+func (inv Invite) String() string {
+	u := url.URL{Scheme: inviteScheme}
+	q := url.Values{}
+	if inv.Relay == "lan" {
+		u.Host = "lan"
+	} else if r, err := url.Parse(inv.Relay); err == nil {
+		u.Host, u.Path = r.Host, r.Path
+		if r.Scheme == "http" {
+			q.Set("tls", "0")
+		}
+	}
+	if inv.From != "" {
+		q.Set("from", inv.From)
+	}
+	if inv.Key != "" {
+		q.Set("key", inv.Key)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// SetInvite has the client join inv once logged in, for a link given on the
+// command line. Must be called before the chat screen is entered.
+func (ac *AppController) SetInvite(inv Invite) {
+	ac.invite = &inv
+}
+
+// ownInvite returns an invitation to the current conversation from us.
+func (ac *AppController) ownInvite() Invite {
+	inv := Invite{Relay: ac.conversationKey()}
+	if ac.App.CurrentUser != nil {
+		inv.From = ac.App.CurrentUser.Username
+	}
+	if ac.identity != nil && inv.From != "" {
+		inv.Key = strings.ReplaceAll(crypto.Fingerprint(ac.identity.PublicKey()), " ", "")
+	}
+	return inv
+}
+
+// showInvite handles /invite. Must be called from the tview event loop.
+func (ac *AppController) showInvite() {
+	inv := ac.ownInvite()
+	ac.sendSystem("Invitation to this conversation: [cyan]" + views.Escape(inv.String()) + "[-]")
+	msg := "[dim]They paste it into /join, or start the client with it; /qr invite shows it as a QR code."
+	if inv.Relay == "lan" {
+		msg += " It only works on this network."
+	}
+	ac.sendSystem(msg + "[-]")
+}
+
+// join handles /join. Must be called from the tview event loop.
+func (ac *AppController) join(arg string) {
+	inv, err := ParseInvite(arg)
+	if err != nil {
+		if arg != "" {
+			ac.sendSystem("[red]" + views.Escape(err.Error()) + "[-]")
+		}
+		ac.sendSystem("Usage: /join ttc://…  —  /invite makes one for this conversation")
+		return
+	}
+	ac.joinInvite(inv)
+}
+
+// acceptInvite joins the invitation given on the command line, if any.
+// Must be called from the tview event loop.
+func (ac *AppController) acceptInvite() {
+	if ac.invite == nil {
+		return
+	}
+	inv := *ac.invite
+	ac.invite = nil
+	ac.joinInvite(inv)
+}
+
+// joinInvite opens the conversation inv is for, keeps it under "rooms",
+// and checks the sender's key against it. Must be called from the tview
+// event loop.
+func (ac *AppController) joinInvite(inv Invite) {
+	if _, ok := ac.App.Config.Rooms[inv.Relay]; !ok {
+		rooms := make(map[string]models.RoomConfig, len(ac.App.Config.Rooms)+1)
+		for k, r := range ac.App.Config.Rooms {
+			rooms[k] = r
+		}
+		rooms[inv.Relay] = models.RoomConfig{}
+		ac.App.Config.Rooms = rooms
+		if err := models.SaveConfigKey("rooms", rooms); err != nil {
+			ac.sendSystem("[red]Conversation not saved:[-] " + views.Escape(err.Error()) + " — /rooms lists it until you quit.")
+		}
+	}
+
+	from := "an invitation"
+	if inv.From != "" {
+		from = ac.displayName(inv.From) + "'s invitation"
+	}
+	if inv.Relay == ac.conversationKey() {
+		ac.sendSystem("This is the conversation of " + from + ".")
+	} else {
+		ac.sendSystem("Joining " + views.Escape(inv.Relay) + " from " + from + ".")
+		ac.switchConversation(inv.Relay)
+	}
+
+	if inv.Key == "" || ac.App.CurrentUser == nil || inv.From == ac.App.CurrentUser.Username {
+		return
+	}
+	if ac.invited == nil {
+		ac.invited = make(map[string]string)
+	}
+	ac.invited[inv.From] = inv.Key
+	if c, ok := ac.App.Contacts[inv.From]; ok && c.Key != "" {
+		ac.checkInvited(inv.From, c)
+		ac.App.SaveContacts()
+		ac.pushTrust()
+		return
+	}
+	ac.sendSystem(fmt.Sprintf("[dim]%s's key will be checked against the invitation when it arrives.[-]", ac.displayName(inv.From)))
+}
+
+// checkInvited verifies c, whose key was just seen, if an invitation said
+// what it should be, and warns if it was something else. The caller saves
+// the contacts. Must be called from the tview event loop.
+func (ac *AppController) checkInvited(from string, c *models.Contact) {
+	want, ok := ac.invited[from]
+	if !ok {
+		return
+	}
+	delete(ac.invited, from)
+	got := crypto.Fingerprint(c.Key)
+	if strings.ReplaceAll(got, " ", "") != want {
+		ac.sendSystem(fmt.Sprintf("[red::b]⚠ %s's identity key does not match the invitation![-::-] [red]It is %s — "+
+			"someone else using the name, or an old invitation. Not verified.[-]", ac.displayName(from), got))
+		return
+	}
+	c.Trust = models.TrustVerified
+	ac.sendSystem(fmt.Sprintf("[green]✓[-] %s's key matches the invitation — verified.", ac.displayName(from)))
+}
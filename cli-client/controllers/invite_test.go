@@ -0,0 +1,37 @@
+package controllers
+
+import "testing"
+
+func TestInviteRoundTrip(t *testing.T) {
+	tests := []struct {
+		inv  Invite
+		link string
+	}{
+		{Invite{Relay: "https://relay.example.org"}, "ttc://relay.example.org"},
+		{Invite{Relay: "http://10.0.0.5:8080/chat"}, "ttc://10.0.0.5:8080/chat?tls=0"},
+		{Invite{Relay: "lan", From: "alice", Key: "48660b47aaaabbbbccccddddeeeeffff"},
+			"ttc://lan?from=alice&key=48660b47aaaabbbbccccddddeeeeffff"},
+	}
+	for _, tt := range tests {
+		if got := tt.inv.String(); got != tt.link {
+			t.Errorf("%+v.String() = %q, want %q", tt.inv, got, tt.link)
+		}
+		got, err := ParseInvite(tt.link)
+		if err != nil || got != tt.inv {
+			t.Errorf("ParseInvite(%q) = %+v, %v, want %+v", tt.link, got, err, tt.inv)
+		}
+	}
+}
+
+func TestParseInviteRejects(t *testing.T) {
+	for _, link := range []string{
+		"https://relay.example.org",
+		"ttc://",
+		"ttc://relay.example.org?from=alice&key=1234",
+		"ttc://relay.example.org?key=48660b47aaaabbbbccccddddeeeeffff",
+	} {
+		if _, err := ParseInvite(link); err == nil {
+			t.Errorf("ParseInvite(%q) accepted it", link)
+		}
+	}
+}
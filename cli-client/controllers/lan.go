@@ -0,0 +1,526 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cli-client/models"
+	"cli-client/ui"
+	"cli-client/views"
+)
+
+// ── LAN mode ──────────────────────────────────────────────────────────────────
+//
+// "/server lan" drops the relay and talks to other clients on the same
+// network directly — for classrooms and air-gapped rooms with no server.
+// Each client listens on a random TCP port and advertises it over mDNS as an
+// instance of _ttc._tcp.local; peers are found by querying for that service
+// every lanQueryInterval. A message is written as one JSON line (the same
+// fields as a v2 poll entry) to every known peer. There is no access key and
+// no relay in between: anyone on the LAN running the client can join.
+
+const (
+	lanQueryInterval = 10 * time.Second
+	lanPeerTTL       = 35 * time.Second // forget peers not heard from for this long
+	lanDialTimeout   = 2 * time.Second
+	lanMaxLine       = 64 * 1024
+)
+
+// LANPeer is another client discovered on the local network.
+type LANPeer struct {
+	Instance string
+	Username string
+	Addr     string // host:port of its TCP listener
+	LastSeen time.Time
+}
+
+// LANNode is the serverless counterpart of NetworkClient.
+type LANNode struct {
+	instance string
+	host     string
+	port     uint16
+
+	tcp   net.Listener
+	udp   *net.UDPConn
+	group *net.UDPAddr
+
+	mu       sync.Mutex
+	username string
+	peers    map[string]*LANPeer // instance → peer
+	conns    map[string]net.Conn // instance → outbound connection
+
+	seq     uint64
+	stopped int32
+	stopCh  chan struct{}
+	drops   dropCounter // malformed lines from peers, see wire.go
+
+	onMessage func(msg *pollMessage)
+	onPeers   func(found, lost []LANPeer)
+}
+
+// StartLANNode opens the TCP listener and mDNS socket and starts discovery.
+// Callbacks run on the node's goroutines.
+func StartLANNode(username string, onMessage func(msg *pollMessage), onPeers func(found, lost []LANPeer)) (*LANNode, error) {
+	tcp, err := net.Listen("tcp4", ":0")
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	group, err := net.ResolveUDPAddr("udp4", mdnsGroup)
+	if err != nil {
+		tcp.Close()
+		return nil, err
+	}
+	udp, err := net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		tcp.Close()
+		return nil, fmt.Errorf("mdns: %w", err)
+	}
+
+	tag := fmt.Sprintf("%06x", rand.Int63n(1<<24))
+	n := &LANNode{
+		instance:  fmt.Sprintf("%s-%s.%s", dnsLabel(username), tag, lanService),
+		host:      "ttc-" + tag + ".local.",
+		port:      uint16(tcp.Addr().(*net.TCPAddr).Port),
+		tcp:       tcp,
+		udp:       udp,
+		group:     group,
+		username:  username,
+		peers:     make(map[string]*LANPeer),
+		conns:     make(map[string]net.Conn),
+		stopCh:    make(chan struct{}),
+		onMessage: onMessage,
+		onPeers:   onPeers,
+	}
+	log.Printf("TRACE StartLANNode: instance=%q port=%d", n.instance, n.port)
+
+	ui.SafeGo("lan accept", n.acceptLoop)
+	ui.SafeGo("lan mdns", n.mdnsReadLoop)
+	ui.SafeGo("lan discovery", n.discoveryLoop)
+	return n, nil
+}
+
+// Port returns the TCP port peers connect to.
+func (n *LANNode) Port() int { return int(n.port) }
+
+// Drops returns the malformed lines dropped so far, by reason.
+func (n *LANNode) Drops() map[string]int { return n.drops.Snapshot() }
+
+// Neutralized returns how many control sequences were stripped from
+// message content so far.
+func (n *LANNode) Neutralized() int { return n.drops.Neutralized() }
+
+// Peers returns a snapshot of the currently known peers.
+func (n *LANNode) Peers() []LANPeer {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := make([]LANPeer, 0, len(n.peers))
+	for _, p := range n.peers {
+		out = append(out, *p)
+	}
+	return out
+}
+
+// SetUsername changes the advertised name (after /nick) and re-announces.
+func (n *LANNode) SetUsername(username string) {
+	n.mu.Lock()
+	n.username = username
+	n.mu.Unlock()
+	n.announce(mdnsTTL)
+}
+
+// Send delivers a message to every peer in the background and returns the
+// message ID it was sent with.
+func (n *LANNode) Send(msgType, username, content, colorTag string) string {
+	line, id := n.encode(msgType, username, content, colorTag)
+	go n.deliver(line)
+	return id
+}
+
+// SendWait delivers a message to every peer and returns when done. Each peer
+// is bounded by lanDialTimeout, so this never hangs on a vanished peer.
+func (n *LANNode) SendWait(msgType, username, content, colorTag string) {
+	line, _ := n.encode(msgType, username, content, colorTag)
+	n.deliver(line)
+}
+
+func (n *LANNode) encode(msgType, username, content, colorTag string) ([]byte, string) {
+	id := fmt.Sprintf("lan-%s-%d", strings.TrimSuffix(n.host, ".local."), atomic.AddUint64(&n.seq, 1))
+	line, _ := json.Marshal(pollMessage{
+		Username:  username,
+		Content:   content,
+		Color:     models.ColorName(colorTag),
+		ID:        id,
+		Timestamp: time.Now().UTC(),
+		Type:      msgType,
+	})
+	return append(line, '\n'), id
+}
+
+func (n *LANNode) deliver(line []byte) {
+	if atomic.LoadInt32(&n.stopped) == 1 {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, p := range n.Peers() {
+		p := p
+		wg.Add(1)
+		ui.SafeGo("lan send", func() {
+			defer wg.Done()
+			// One retry on a fresh connection: a cached one may have gone stale.
+			for attempt := 0; attempt < 2; attempt++ {
+				if err := n.writeTo(p, line); err == nil {
+					return
+				} else if attempt == 1 {
+					log.Printf("LAN send to %s (%s): %v", p.Username, p.Addr, err)
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func (n *LANNode) writeTo(p LANPeer, line []byte) error {
+	n.mu.Lock()
+	conn := n.conns[p.Instance]
+	n.mu.Unlock()
+
+	if conn == nil {
+		c, err := net.DialTimeout("tcp4", p.Addr, lanDialTimeout)
+		if err != nil {
+			return err
+		}
+		conn = c
+		n.mu.Lock()
+		if old := n.conns[p.Instance]; old != nil {
+			old.Close()
+		}
+		n.conns[p.Instance] = conn
+		n.mu.Unlock()
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(lanDialTimeout))
+	if _, err := conn.Write(line); err != nil {
+		n.dropConn(p.Instance, conn)
+		return err
+	}
+	return nil
+}
+
+func (n *LANNode) dropConn(instance string, conn net.Conn) {
+	conn.Close()
+	n.mu.Lock()
+	if n.conns[instance] == conn {
+		delete(n.conns, instance)
+	}
+	n.mu.Unlock()
+}
+
+// Stop says goodbye over mDNS and closes every socket.
+func (n *LANNode) Stop() {
+	if !atomic.CompareAndSwapInt32(&n.stopped, 0, 1) {
+		return
+	}
+	n.announce(0)
+	close(n.stopCh)
+	n.udp.Close()
+	n.tcp.Close()
+	n.mu.Lock()
+	for _, c := range n.conns {
+		c.Close()
+	}
+	n.conns = map[string]net.Conn{}
+	n.mu.Unlock()
+}
+
+// ── Inbound messages ──
+
+func (n *LANNode) acceptLoop() {
+	for {
+		conn, err := n.tcp.Accept()
+		if err != nil {
+			if atomic.LoadInt32(&n.stopped) == 0 {
+				log.Printf("LAN accept: %v", err)
+			}
+			return
+		}
+		ui.SafeGo("lan peer", func() { n.readPeer(conn) })
+	}
+}
+
+func (n *LANNode) readPeer(conn net.Conn) {
+	defer conn.Close()
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(make([]byte, 4096), lanMaxLine)
+	for sc.Scan() {
+		msg, reason := parseWireMessage(sc.Bytes(), &n.drops)
+		if reason == "" && msg.Username == "" {
+			reason = dropMissing
+		}
+		if reason != "" {
+			log.Printf("LAN: bad line from %s", conn.RemoteAddr())
+			n.drops.add(reason)
+			continue
+		}
+		if atomic.LoadInt32(&n.stopped) == 1 {
+			continue
+		}
+		if msg.Type == msgTypeAnnouncement || msg.Type == msgTypeSystem {
+			continue // only a relay can vouch for these
+		}
+		msg.Timestamp = time.Time{} // the peer's clock; stamped on arrival instead
+		n.onMessage(msg)
+	}
+}
+
+// ── Discovery ──
+
+func (n *LANNode) discoveryLoop() {
+	n.announce(mdnsTTL)
+	n.query()
+
+	ticker := time.NewTicker(lanQueryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.stopCh:
+			return
+		case <-ticker.C:
+			n.query()
+			n.expirePeers()
+		}
+	}
+}
+
+func (n *LANNode) query() {
+	if _, err := n.udp.WriteToUDP(buildQuery(lanService), n.group); err != nil {
+		log.Printf("TRACE LAN query: %v", err)
+	}
+}
+
+// announce multicasts our instance record; ttl 0 withdraws it.
+func (n *LANNode) announce(ttl uint32) {
+	n.mu.Lock()
+	txt := []string{"user=" + n.username, "v=" + fmt.Sprint(ProtocolVersion)}
+	n.mu.Unlock()
+	pkt := buildAnnouncement(lanService, n.instance, n.host, n.port, txt, localIPv4s(), ttl)
+	if _, err := n.udp.WriteToUDP(pkt, n.group); err != nil {
+		log.Printf("TRACE LAN announce: %v", err)
+	}
+}
+
+func (n *LANNode) mdnsReadLoop() {
+	buf := make([]byte, 9000)
+	for {
+		size, src, err := n.udp.ReadFromUDP(buf)
+		if err != nil {
+			if atomic.LoadInt32(&n.stopped) == 0 {
+				log.Printf("LAN mdns read: %v", err)
+			}
+			return
+		}
+		pkt, err := parseDNS(buf[:size])
+		if err != nil {
+			continue // not ours to worry about
+		}
+		if !pkt.Response {
+			for _, q := range pkt.Questions {
+				if strings.EqualFold(q.Name, lanService) && (q.Type == dnsTypePTR || q.Type == 255) {
+					n.announce(mdnsTTL)
+					break
+				}
+			}
+			continue
+		}
+		n.learn(pkt, src.IP)
+	}
+}
+
+// learn records every peer advertised in an mDNS response. Peers are
+// reached at the packet's source address rather than the A records, which
+// may list interfaces we cannot route to.
+func (n *LANNode) learn(pkt *dnsPacket, from net.IP) {
+	var found, lost []LANPeer
+	n.mu.Lock()
+	for _, ptr := range pkt.Records {
+		if ptr.Type != dnsTypePTR || !strings.EqualFold(ptr.Name, lanService) || ptr.Target == n.instance {
+			continue
+		}
+		inst := ptr.Target
+		if ptr.TTL == 0 {
+			if p, ok := n.peers[inst]; ok {
+				lost = append(lost, *p)
+				delete(n.peers, inst)
+			}
+			continue
+		}
+
+		var port uint16
+		var user string
+		for _, r := range pkt.Records {
+			if !strings.EqualFold(r.Name, inst) {
+				continue
+			}
+			switch r.Type {
+			case dnsTypeSRV:
+				port = r.Port
+			case dnsTypeTXT:
+				for _, kv := range r.TXT {
+					if strings.HasPrefix(kv, "user=") {
+						user = kv[len("user="):]
+					}
+				}
+			}
+		}
+		if port == 0 || user == "" {
+			continue
+		}
+
+		addr := net.JoinHostPort(from.String(), fmt.Sprint(port))
+		p, ok := n.peers[inst]
+		if !ok || p.Username != user || p.Addr != addr {
+			p = &LANPeer{Instance: inst, Username: user, Addr: addr, LastSeen: time.Now()}
+			n.peers[inst] = p
+			found = append(found, *p)
+		}
+		p.LastSeen = time.Now()
+	}
+	n.mu.Unlock()
+
+	if (len(found) > 0 || len(lost) > 0) && n.onPeers != nil {
+		n.onPeers(found, lost)
+	}
+}
+
+func (n *LANNode) expirePeers() {
+	var lost []LANPeer
+	n.mu.Lock()
+	for inst, p := range n.peers {
+		if time.Since(p.LastSeen) > lanPeerTTL {
+			lost = append(lost, *p)
+			delete(n.peers, inst)
+			if c := n.conns[inst]; c != nil {
+				c.Close()
+				delete(n.conns, inst)
+			}
+		}
+	}
+	n.mu.Unlock()
+	if len(lost) > 0 && n.onPeers != nil {
+		n.onPeers(nil, lost)
+	}
+}
+
+// localIPv4s lists this host's non-loopback IPv4 addresses for the A records.
+func localIPv4s() []net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, a := range addrs {
+		if ipn, ok := a.(*net.IPNet); ok && !ipn.IP.IsLoopback() && ipn.IP.To4() != nil {
+			ips = append(ips, ipn.IP.To4())
+		}
+	}
+	return ips
+}
+
+// dnsLabel turns a username into a safe DNS label.
+func dnsLabel(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('-')
+		}
+	}
+	label := strings.Trim(b.String(), "-")
+	if len(label) > 40 {
+		label = label[:40]
+	}
+	if label == "" {
+		label = "user"
+	}
+	return label
+}
+
+// ── AppController glue ──
+
+// startLAN swaps the relay connection for a LAN node.
+// Must be called from the tview event loop.
+func (ac *AppController) startLAN() {
+	if ac.App.CurrentUser == nil {
+		return
+	}
+	if ac.lan != nil {
+		ac.sendSystem(fmt.Sprintf("Already in LAN mode (port %d).", ac.lan.Port()))
+		return
+	}
+	node, err := StartLANNode(ac.App.CurrentUser.Username, ac.onIncoming, func(found, lost []LANPeer) {
+		ui.SafeQueueUpdateDraw(ac.app, "startLAN", func() { ac.onLANPeers(found, lost) })
+	})
+	if err != nil {
+		ac.sendSystem(fmt.Sprintf("[red]LAN mode failed:[-] %s", views.Escape(err.Error())))
+		return
+	}
+	if nc := ac.netClient; nc != nil {
+		// Told in the background, so a slow relay does not hold up the
+		// switch; its client stops once the leave is through.
+		leave := ac.relayLeave(nc)
+		ac.netClient = nil
+		ui.SafeGo("leave", func() {
+			leave()
+			nc.Stop()
+		})
+	}
+	ac.stopNetworkClient()
+	ac.stopFailback()
+	ac.caps = nil
+	ac.lan = node
+	ac.markLive()
+	ac.resetHistory()
+	ac.announce(opJoin)
+
+	ac.sendSystem(fmt.Sprintf("LAN mode — listening on port %d, discovering peers via mDNS…  (/users to list, /server <url> to go back)", node.Port()))
+	if chat, ok := ac.chatView(); ok {
+		chat.SetOnlineStatus(true)
+	}
+	ac.applyTorRouting()
+}
+
+// stopLAN leaves LAN mode, if active.
+func (ac *AppController) stopLAN() {
+	if ac.lan != nil {
+		ac.lan.Stop()
+		ac.lan = nil
+	}
+}
+
+// onLANPeers keeps the user list in step with discovery.
+// Must be called from the tview event loop.
+func (ac *AppController) onLANPeers(found, lost []LANPeer) {
+	for _, p := range found {
+		ac.trackUser(p.Username).IsOnline = true
+		if !ac.quietPresence {
+			ac.sendSystem(fmt.Sprintf("[dim]LAN: found %s at %s[-]", views.Escape(p.Username), p.Addr))
+		}
+	}
+	for _, p := range lost {
+		if u, ok := ac.App.Users[p.Username]; ok {
+			u.IsOnline = false
+		}
+		if !ac.quietPresence {
+			ac.sendSystem(fmt.Sprintf("[dim]LAN: lost %s[-]", views.Escape(p.Username)))
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"net"
 	"sync/atomic"
 	"time"
+
+	"cli-client/ui"
 )
 
 // LatencyController measures real network latency by TCP-dialing a public host.
@@ -30,7 +32,7 @@ func (lc *LatencyController) Current() int {
 // onUpdate is called from the goroutine each time a new value is ready;
 // callers that need to update the UI must wrap it in QueueUpdateDraw.
 func (lc *LatencyController) Start(onUpdate func(ms int)) {
-	go func() {
+	ui.SafeGo("latency", func() {
 		// Probe immediately so the first real value appears fast.
 		lc.probe(onUpdate)
 
@@ -45,7 +47,7 @@ func (lc *LatencyController) Start(onUpdate func(ms int)) {
 				lc.probe(onUpdate)
 			}
 		}
-	}()
+	})
 }
 
 func (lc *LatencyController) probe(onUpdate func(ms int)) {
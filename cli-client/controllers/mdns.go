@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+)
+
+// ── Minimal mDNS codec ────────────────────────────────────────────────────────
+//
+// Just enough of RFC 6762/6763 for LAN mode: build a PTR query for our
+// service, build the PTR/SRV/TXT/A answer that advertises one instance, and
+// parse either back. Names are written uncompressed; compressed names from
+// other responders are read correctly.
+
+const (
+	mdnsGroup  = "224.0.0.251:5353"
+	lanService = "_ttc._tcp.local."
+
+	dnsTypeA   = 1
+	dnsTypePTR = 12
+	dnsTypeTXT = 16
+	dnsTypeSRV = 33
+
+	dnsClassIN    = 1
+	dnsCacheFlush = 0x8000 // top bit of the class field in mDNS answers
+
+	mdnsTTL = 120
+)
+
+// dnsRecord is one parsed resource record (or question, with TTL 0).
+type dnsRecord struct {
+	Name   string
+	Type   uint16
+	TTL    uint32
+	Target string   // PTR target or SRV target
+	Port   uint16   // SRV
+	TXT    []string // TXT
+	IP     net.IP   // A
+}
+
+type dnsPacket struct {
+	Response  bool
+	Questions []dnsRecord
+	Records   []dnsRecord // answers + authority + additional
+}
+
+var errDNSShort = errors.New("mdns: truncated packet")
+
+// ── Encoding ──
+
+func appendName(b []byte, name string) []byte {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if len(label) > 63 {
+			label = label[:63]
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+func appendRR(b []byte, name string, typ, class uint16, ttl uint32, rdata []byte) []byte {
+	b = appendName(b, name)
+	b = binary.BigEndian.AppendUint16(b, typ)
+	b = binary.BigEndian.AppendUint16(b, class)
+	b = binary.BigEndian.AppendUint32(b, ttl)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(rdata)))
+	return append(b, rdata...)
+}
+
+func dnsHeader(flags uint16, qd, an, ar int) []byte {
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint16(b[2:], flags)
+	binary.BigEndian.PutUint16(b[4:], uint16(qd))
+	binary.BigEndian.PutUint16(b[6:], uint16(an))
+	binary.BigEndian.PutUint16(b[10:], uint16(ar))
+	return b
+}
+
+// buildQuery asks for every instance of service.
+func buildQuery(service string) []byte {
+	b := dnsHeader(0, 1, 0, 0)
+	b = appendName(b, service)
+	b = binary.BigEndian.AppendUint16(b, dnsTypePTR)
+	return binary.BigEndian.AppendUint16(b, dnsClassIN)
+}
+
+// buildAnnouncement advertises instance (a full name under service) on port,
+// reachable at ips. A ttl of 0 is a goodbye: peers drop the instance.
+func buildAnnouncement(service, instance, host string, port uint16, txt []string, ips []net.IP, ttl uint32) []byte {
+	b := dnsHeader(0x8400, 0, 1, 2+len(ips)) // response, authoritative
+
+	b = appendRR(b, service, dnsTypePTR, dnsClassIN, ttl, appendName(nil, instance))
+
+	srv := make([]byte, 6)
+	binary.BigEndian.PutUint16(srv[4:], port)
+	srv = appendName(srv, host)
+	b = appendRR(b, instance, dnsTypeSRV, dnsClassIN|dnsCacheFlush, ttl, srv)
+
+	var t []byte
+	for _, s := range txt {
+		t = append(t, byte(len(s)))
+		t = append(t, s...)
+	}
+	b = appendRR(b, instance, dnsTypeTXT, dnsClassIN|dnsCacheFlush, ttl, t)
+
+	for _, ip := range ips {
+		b = appendRR(b, host, dnsTypeA, dnsClassIN|dnsCacheFlush, ttl, ip.To4())
+	}
+	return b
+}
+
+// ── Decoding ──
+
+// readName decodes a possibly compressed name starting at off. Returns the
+// name and the offset just past it in the original (uncompressed) position.
+func readName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	end := -1
+	for hops := 0; hops < 32; hops++ {
+		if off >= len(msg) {
+			return "", 0, errDNSShort
+		}
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			return strings.Join(labels, ".") + ".", end, nil
+		case n&0xC0 == 0xC0:
+			if off+1 >= len(msg) {
+				return "", 0, errDNSShort
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3FFF)
+		default:
+			if off+1+n > len(msg) {
+				return "", 0, errDNSShort
+			}
+			labels = append(labels, string(msg[off+1:off+1+n]))
+			off += 1 + n
+		}
+	}
+	return "", 0, errors.New("mdns: name compression loop")
+}
+
+func parseDNS(msg []byte) (*dnsPacket, error) {
+	if len(msg) < 12 {
+		return nil, errDNSShort
+	}
+	p := &dnsPacket{Response: msg[2]&0x80 != 0}
+	qd := int(binary.BigEndian.Uint16(msg[4:]))
+	rr := int(binary.BigEndian.Uint16(msg[6:])) +
+		int(binary.BigEndian.Uint16(msg[8:])) +
+		int(binary.BigEndian.Uint16(msg[10:]))
+	off := 12
+
+	for i := 0; i < qd; i++ {
+		name, next, err := readName(msg, off)
+		if err != nil || next+4 > len(msg) {
+			return nil, errDNSShort
+		}
+		p.Questions = append(p.Questions, dnsRecord{Name: name, Type: binary.BigEndian.Uint16(msg[next:])})
+		off = next + 4
+	}
+
+	for i := 0; i < rr; i++ {
+		name, next, err := readName(msg, off)
+		if err != nil || next+10 > len(msg) {
+			return nil, errDNSShort
+		}
+		r := dnsRecord{
+			Name: name,
+			Type: binary.BigEndian.Uint16(msg[next:]),
+			TTL:  binary.BigEndian.Uint32(msg[next+4:]),
+		}
+		rdlen := int(binary.BigEndian.Uint16(msg[next+8:]))
+		rd := next + 10
+		if rd+rdlen > len(msg) {
+			return nil, errDNSShort
+		}
+		data := msg[rd : rd+rdlen]
+
+		switch r.Type {
+		case dnsTypePTR:
+			r.Target, _, err = readName(msg, rd)
+		case dnsTypeSRV:
+			if rdlen < 7 {
+				return nil, errDNSShort
+			}
+			r.Port = binary.BigEndian.Uint16(data[4:])
+			r.Target, _, err = readName(msg, rd+6)
+		case dnsTypeTXT:
+			for j := 0; j < len(data); {
+				n := int(data[j])
+				if j+1+n > len(data) {
+					break
+				}
+				r.TXT = append(r.TXT, string(data[j+1:j+1+n]))
+				j += 1 + n
+			}
+		case dnsTypeA:
+			if rdlen == 4 {
+				r.IP = net.IP(append([]byte(nil), data...))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.Records = append(p.Records, r)
+		off = rd + rdlen
+	}
+	return p, nil
+}
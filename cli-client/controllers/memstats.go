@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"cli-client/models"
+	"cli-client/tasks"
+	"cli-client/ui"
+)
+
+// ── Memory diagnostics ────────────────────────────────────────────────────────
+//
+// /memstats shows heap use, goroutines and the size of the buffers a long
+// session grows; /gc collects and hands freed memory back to the OS. With
+// pprof_addr set, net/http/pprof is served on that loopback address for
+// heap and goroutine profiles, e.g.
+//
+//	go tool pprof http://localhost:6060/debug/pprof/heap
+
+// StartProfiler serves the pprof endpoints on addr, which the config has
+// checked is a loopback address. Failures are logged, not fatal.
+func StartProfiler(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	ui.SafeGo("StartProfiler", func() {
+		log.Printf("pprof: serving on http://%s/debug/pprof/", addr)
+		if err := srv.ListenAndServe(); err != nil {
+			log.Printf("pprof: %v", err)
+		}
+	})
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// memstatsLines is the /memstats report. Must be called from the tview
+// event loop.
+func (ac *AppController) memstatsLines() []string {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	transcript := "--"
+	if chat, ok := ac.chatView(); ok {
+		transcript = formatBytes(int64(chat.TranscriptSize()))
+		if n := chat.Archived(); n > 0 {
+			transcript += fmt.Sprintf("  ·  %d older entries on disk", n)
+		}
+	}
+	lines := []string{
+		"[dim]┌─ Memory ────────────────────────────────────────┐[-]",
+		fmt.Sprintf("  [cyan]Heap         [-]%s in use  ·  %s from the OS", formatBytes(int64(m.HeapInuse)), formatBytes(int64(m.HeapSys))),
+		fmt.Sprintf("  [cyan]Objects      [-]%d live", m.HeapObjects),
+		fmt.Sprintf("  [cyan]GC           [-]%d runs  ·  last %s", m.NumGC, sinceGC(m.LastGC)),
+		fmt.Sprintf("  [cyan]Goroutines   [-]%d", runtime.NumGoroutine()),
+		"  [cyan]Tasks        [-]" + taskSummary(),
+		"  [cyan]Transcript   [-]" + transcript,
+		fmt.Sprintf("  [cyan]History      [-]%d of %d messages", ac.App.History.Len(), models.HistorySize),
+		fmt.Sprintf("  [cyan]Messages     [-]%d own and system", len(ac.App.Messages)),
+		fmt.Sprintf("  [cyan]Inbox        [-]%d waiting  ·  %d bodies to fetch", ac.inbox.Len(), len(ac.pendingBodies)),
+	}
+	if addr := ac.App.Config.PprofAddr; addr != "" {
+		lines = append(lines, "  [cyan]pprof        [-]http://"+addr+"/debug/pprof/")
+	}
+	return append(lines, "[dim]└─────────────────────────────────────────────────┘[-]")
+}
+
+// sinceGC renders the time of the last collection for /memstats.
+func sinceGC(last uint64) string {
+	if last == 0 {
+		return "never"
+	}
+	return time.Since(time.Unix(0, int64(last))).Round(time.Second).String() + " ago"
+}
+
+// taskSummary lists the registered goroutines for /memstats, by name.
+func taskSummary() string {
+	names := tasks.Running()
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// collectGarbage handles /gc. Must be called from the tview event loop.
+func (ac *AppController) collectGarbage() {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	debug.FreeOSMemory() // a full collection, then returns what it can
+	runtime.ReadMemStats(&after)
+	ac.sendSystem(fmt.Sprintf("Garbage collected — heap %s → %s, %s held from the OS.",
+		formatBytes(int64(before.HeapInuse)), formatBytes(int64(after.HeapInuse)),
+		formatBytes(int64(after.HeapSys-after.HeapReleased))))
+}
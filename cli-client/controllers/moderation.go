@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cli-client/ui"
+	"cli-client/views"
+)
+
+// ── Moderation ────────────────────────────────────────────────────────────────
+//
+// Relays started with -admin-key enforce /kick, /mute and /slowmode sent with
+// that key (POST /api/moderate). The relay reports each action as a "system"
+// message whose content is a moderationEvent, which every client shows.
+
+// Moderation actions understood by /api/moderate.
+const (
+	modKick     = "kick"
+	modMute     = "mute"
+	modUnmute   = "unmute"
+	modSlowMode = "slowmode"
+)
+
+// defaultMute is the /mute length when none is given.
+const defaultMute = 10 * time.Minute
+
+type moderateRequest struct {
+	AccessKey string `json:"access_key"`
+	ClientID  string `json:"client_id"`
+	AdminKey  string `json:"admin_key"`
+	Action    string `json:"action"`
+	Username  string `json:"username,omitempty"`
+	Seconds   int64  `json:"seconds"`
+}
+
+// moderationEvent is the content of a relay "system" message.
+type moderationEvent struct {
+	Action  string `json:"action"`
+	Target  string `json:"target"`
+	Seconds int64  `json:"seconds"`
+}
+
+// Moderate asks the relay to apply an admin action and waits for the answer.
+// Safe to call from any goroutine; blocks for one request.
+func (nc *NetworkClient) Moderate(adminKey, action, username string, seconds int64) error {
+	body, err := json.Marshal(moderateRequest{
+		AccessKey: serverAccessKey,
+		ClientID:  nc.clientID,
+		AdminKey:  adminKey,
+		Action:    action,
+		Username:  username,
+		Seconds:   seconds,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := nc.post(nc.httpClient, "/api/moderate", body)
+	if err != nil {
+		return errors.New("relay unreachable")
+	}
+	defer drainClose(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusForbidden:
+		return errors.New("the relay rejected admin_key")
+	case http.StatusBadRequest, http.StatusNotFound:
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.New(strings.TrimSpace(string(raw)))
+	default:
+		return fmt.Errorf("relay answered HTTP %d", resp.StatusCode)
+	}
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// moderate handles /kick <user>, /mute <user> [duration], /unmute <user> and
+// /slowmode <duration|off>. Must be called from the tview event loop.
+func (ac *AppController) moderate(action, arg string) {
+	fields := strings.Fields(arg)
+	var (
+		target  string
+		seconds int64
+		usage   string
+	)
+	switch action {
+	case modKick, modUnmute:
+		usage = "/" + action + " <user>"
+		if len(fields) == 1 {
+			target = fields[0]
+		}
+	case modMute:
+		usage = "/mute <user> [duration]  —  minutes or e.g. 1h; default 10m"
+		if len(fields) == 1 || len(fields) == 2 {
+			target, seconds = fields[0], int64(defaultMute/time.Second)
+			if len(fields) == 2 {
+				d, ok := parseModerationDuration(fields[1])
+				if !ok || d < time.Second {
+					target = ""
+				}
+				seconds = int64(d / time.Second)
+			}
+		}
+	case modSlowMode:
+		usage = "/slowmode <duration|off>  —  e.g. /slowmode 10s"
+		if len(fields) == 1 {
+			if d, ok := parseModerationDuration(fields[0]); ok {
+				target, seconds = "*", int64(d/time.Second)
+			}
+		}
+	}
+	if target == "" {
+		ac.sendSystem("Usage: " + usage)
+		return
+	}
+	if target == "*" {
+		target = ""
+	}
+	if !ac.adminReady("moderation") {
+		return
+	}
+
+	nc, key := ac.netClient, ac.App.Config.AdminKey
+	ui.SafeGo("moderate", func() {
+		err := nc.Moderate(key, action, target, seconds)
+		if err == nil {
+			return // the relay's system message reports it
+		}
+		ui.SafeQueueUpdateDraw(ac.app, "moderate", func() {
+			ac.sendSystem(fmt.Sprintf("[red]/%s failed:[-] %s", action, views.Escape(err.Error())))
+		})
+	})
+}
+
+// parseModerationDuration accepts a Go duration, a plain number of minutes,
+// or "off" (0).
+func parseModerationDuration(s string) (time.Duration, bool) {
+	if strings.EqualFold(s, "off") {
+		return 0, true
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return time.Duration(n) * time.Minute, true
+	}
+	d, err := time.ParseDuration(s)
+	return d, err == nil && d >= 0
+}
+
+// handleRelayEvent shows a "system" message from the relay. Kicked clients
+// stop talking to the relay. Must be called from the tview event loop.
+func (ac *AppController) handleRelayEvent(content string) {
+	var ev moderationEvent
+	if err := json.Unmarshal([]byte(content), &ev); err != nil || ev.Action == "" {
+		return
+	}
+	me := ""
+	if ac.App.CurrentUser != nil {
+		me = ac.App.CurrentUser.Username
+	}
+	isMe := ev.Target != "" && strings.EqualFold(ev.Target, me)
+	who := views.Colorize("[cyan]", ev.Target)
+	d := (time.Duration(ev.Seconds) * time.Second).String()
+
+	switch ev.Action {
+	case modKick:
+		if isMe {
+			ac.stopNetworkClient()
+			if chat, ok := ac.chatView(); ok {
+				chat.SetOnlineStatus(false)
+			}
+			ac.sendSystem("[red]⚖ You were kicked from the relay.[-] Restart the client to rejoin.")
+			return
+		}
+		ac.sendSystem("⚖ " + who + " was kicked from the relay.")
+	case modMute:
+		if isMe {
+			ac.sendSystem("[red]⚖ You are muted for " + d + ".[-]")
+			return
+		}
+		ac.sendSystem("⚖ " + who + " is muted for " + d + ".")
+	case modUnmute:
+		if isMe {
+			ac.sendSystem("⚖ You are no longer muted.")
+			return
+		}
+		ac.sendSystem("⚖ " + who + " is no longer muted.")
+	case modSlowMode:
+		if ev.Seconds == 0 {
+			ac.sendSystem("⚖ Slow mode off.")
+			return
+		}
+		ac.sendSystem("⚖ Slow mode on — one message every " + d + ".")
+	}
+}
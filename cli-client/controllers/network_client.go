@@ -1,18 +1,24 @@
 package controllers
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math/rand"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"bytes"
+	"cli-client/models"
+	"cli-client/ui"
+
 	"github.com/rivo/tview"
 )
 
@@ -22,89 +28,66 @@ const serverAccessKey = "secure_chat_key_2024"
 
 // ── Wire types ────────────────────────────────────────────────────────────────
 
+// Message types carried in the wire "type" field. Anything other than chat
+// holds a client-defined JSON payload in Content.
+const (
+	msgTypeChat     = "chat"
+	msgTypeSystem   = "system"
+	msgTypePresence = "presence"
+	msgTypeControl  = "control"
+	msgTypeReaction = "reaction"
+
+	// msgTypeSticker carries only a sticker name in Content, on relays that
+	// advertise "stickers".
+	msgTypeSticker = "sticker"
+
+	// msgTypeAnnouncement is a banner broadcast; the relay only accepts it
+	// with its admin key, so its sender is trusted.
+	msgTypeAnnouncement = "announcement"
+)
+
 type sendRequest struct {
 	AccessKey string `json:"access_key"`
 	ClientID  string `json:"client_id"`
 	Username  string `json:"username"`
 	Content   string `json:"content"`
 	Color     string `json:"color"`
+	Type      string `json:"type,omitempty"`
+	AdminKey  string `json:"admin_key,omitempty"`
+
+	PowChallenge string `json:"pow_challenge,omitempty"`
+	PowNonce     string `json:"pow_nonce,omitempty"`
 }
 
 type sendResponse struct {
-	Status string `json:"status"`
-	ID     string `json:"id"`
-	Time   string `json:"time"`
+	Status    string `json:"status"`
+	ID        string `json:"id"`
+	Time      string `json:"time"`
+	Challenge string `json:"challenge"` // proof of work for the next send
 }
 
+// pollMessage is one entry of the /api/poll response.
+//
+// The v2 schema has fixed keys:
+//
+//	{"username": "...", "content": "...", "color": "...", "id": "...",
+//	 "timestamp": "...", "type": "chat"}
+//
+// The legacy v1 format uses the username itself as the key holding the
+// content: {"alice": "hi", "color": "...", "id": "...", "timestamp": "..."}.
+// Both are accepted; an entry is v2 when it has both "username" and
+// "content" keys, which a v1 entry (exactly one user key) never has.
 type pollMessage struct {
-	Username  string
-	Content   string
-	Color     string
-	ID        string
-	Timestamp time.Time
-}
-
-var knownPollKeys = map[string]bool{
-	"color":     true,
-	"id":        true,
-	"timestamp": true,
-}
-
-// parsePollMessages parses the raw JSON array from /api/poll.
-// Logs every step so the last line before a crash identifies the bad message.
-func parsePollMessages(data []byte) ([]*pollMessage, error) {
-	log.Printf("TRACE parsePollMessages: raw body (%d bytes): %.500s", len(data), data)
-
-	var rawList []map[string]json.RawMessage
-	if err := json.Unmarshal(data, &rawList); err != nil {
-		log.Printf("TRACE parsePollMessages: unmarshal error: %v", err)
-		return nil, fmt.Errorf("parse poll array: %w", err)
-	}
-	log.Printf("TRACE parsePollMessages: parsed %d entries", len(rawList))
-
-	msgs := make([]*pollMessage, 0, len(rawList))
-	for i, raw := range rawList {
-		log.Printf("TRACE parsePollMessages: entry[%d] keys=%v", i, mapKeys(raw))
-		msg := &pollMessage{}
-
-		if v, ok := raw["color"]; ok {
-			json.Unmarshal(v, &msg.Color)
-		}
-		if v, ok := raw["id"]; ok {
-			json.Unmarshal(v, &msg.ID)
-		}
-		if v, ok := raw["timestamp"]; ok {
-			json.Unmarshal(v, &msg.Timestamp)
-		}
-
-		for key, val := range raw {
-			if knownPollKeys[key] {
-				continue
-			}
-			msg.Username = key
-			json.Unmarshal(val, &msg.Content)
-			break
-		}
-
-		log.Printf("TRACE parsePollMessages: entry[%d] id=%q user=%q color=%q content=%.80q",
-			i, msg.ID, msg.Username, msg.Color, msg.Content)
-
-		if msg.Username == "" || msg.Content == "" || msg.ID == "" {
-			log.Printf("TRACE parsePollMessages: entry[%d] SKIPPED (malformed)", i)
-			continue
-		}
-		msgs = append(msgs, msg)
-	}
-	log.Printf("TRACE parsePollMessages: returning %d valid messages", len(msgs))
-	return msgs, nil
-}
-
-func mapKeys(m map[string]json.RawMessage) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
-	return keys
+	Username  string    `json:"username"`
+	Content   string    `json:"content"`
+	Color     string    `json:"color"`
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+
+	// Length is the body size of a chat message sent as a header only
+	// (poll.headers); Content is then empty until fetched, see bodies.go.
+	Length int `json:"length"`
 }
 
 // ── NetworkClient ─────────────────────────────────────────────────────────────
@@ -114,35 +97,79 @@ type NetworkClient struct {
 	clientID  string
 	app       *tview.Application
 
-	httpClient *http.Client
-	stopped    int32
-	stopCh     chan struct{}
+	httpClient   *http.Client // long-poll and send
+	shortClient  *http.Client // stats and handshake
+	streamClient *http.Client // SSE; no overall timeout, see streamIdleTimeout
+	stopped      int32
+	stopCh       chan struct{}
+	ctx          context.Context // carried by every relay request; Stop cancels it
+	cancel       context.CancelFunc
+	wakeCh       chan struct{} // cuts an adaptive idle pause short on send
+
+	// Heartbeat — see heartbeat.go.
+	heartbeat  time.Duration           // how often /health is checked; 0 = off
+	reviveCh   chan struct{}           // cuts a reconnect backoff short
+	linked     int32                   // atomic: 1 while the receive link is up
+	beatRTT    int64                   // atomic: last answered heartbeat, in ns
+	recvMu     sync.Mutex              // guards recvCancel
+	recvCancel context.CancelCauseFunc // cancels the receive request in flight
+
+	pollCfg    models.PollConfig
+	transports []string // receive transports in fallback order
 
 	lastIDMu sync.Mutex
 	lastID   string
+	firstID  string // oldest message received, see history.go
 
-	sentIDsMu sync.Mutex
-	sentIDs   map[string]struct{}
+	sentIDsMu   sync.Mutex
+	sentIDs     map[string]struct{}
+	inFlight    map[string]int      // echoKey → our sends the relay has not answered yet
+	earlyEchoes map[string]struct{} // IDs of our messages echoed before the answer
 
-	onMessage      func(username, content, colorTag string)
+	onMessage      func(msg *pollMessage)
 	onStatusChange func(connected bool, msg string)
+	onRejected     func(reason string) // relay refused a send (mute, slow mode)
+	onPow          func(solving bool)
+	onReconnect    func(at time.Time, attempt int) // see reconnect.go
+
+	powMu        sync.Mutex
+	powChallenge string // unused challenge for the next send
+	powBits      int
+
+	plainColors int32 // relay advertised "plain-colors"; see wireColor
+	lazyBodies  int32 // relay advertised "lazy-bodies"; see bodies.go
+
+	transport atomic.Value // string: the receive transport in use, for /netstat
+	drops     dropCounter  // malformed messages from the relay, see wire.go
 }
 
 func NewNetworkClient(
 	app *tview.Application,
 	serverURL string,
-	onMessage func(username, content, colorTag string),
+	onMessage func(msg *pollMessage),
 	onStatusChange func(connected bool, msg string),
 ) *NetworkClient {
 	cid := generateClientID()
 	log.Printf("TRACE NewNetworkClient: url=%s clientID=%s", serverURL, cid)
+	ctx, cancel := context.WithCancel(context.Background())
 	return &NetworkClient{
 		serverURL:      serverURL,
 		clientID:       cid,
 		app:            app,
-		httpClient:     &http.Client{Timeout: 40 * time.Second},
+		ctx:            ctx,
+		cancel:         cancel,
+		httpClient:     newHTTPClient(time.Duration(models.DefaultConfig().Poll.Timeout)),
+		shortClient:    newHTTPClient(requestTimeout),
+		streamClient:   newHTTPClient(0),
 		stopCh:         make(chan struct{}),
+		wakeCh:         make(chan struct{}, 1),
+		reviveCh:       make(chan struct{}, 1),
+		heartbeat:      time.Duration(models.DefaultConfig().Heartbeat),
+		pollCfg:        models.DefaultConfig().Poll,
+		transports:     models.DefaultConfig().Transports,
 		sentIDs:        make(map[string]struct{}),
+		inFlight:       make(map[string]int),
+		earlyEchoes:    make(map[string]struct{}),
 		onMessage:      onMessage,
 		onStatusChange: onStatusChange,
 	}
@@ -154,23 +181,109 @@ func generateClientID() string {
 }
 
 func (nc *NetworkClient) Start() {
-	log.Printf("TRACE NetworkClient.Start: launching pollLoop goroutine")
-	go nc.pollLoop()
+	log.Printf("TRACE NetworkClient.Start: launching receiveLoop goroutine transports=%v", nc.transports)
+	ui.SafeGo("receive", nc.receiveLoop)
+	if nc.heartbeat > 0 {
+		client := newHTTPClient(nc.heartbeat)
+		ui.SafeGo("heartbeat", func() { nc.heartbeatLoop(client) })
+	}
 }
 
 func (nc *NetworkClient) SendMessage(username, content, colorTag string) {
+	nc.SendTyped(msgTypeChat, username, content, colorTag)
+}
+
+// SendTyped sends a message of the given wire type. Only use non-chat types
+// when the relay advertised the "types" capability.
+func (nc *NetworkClient) SendTyped(msgType, username, content, colorTag string) {
 	if atomic.LoadInt32(&nc.stopped) == 1 {
 		return
 	}
-	log.Printf("TRACE NetworkClient.SendMessage: user=%q content=%.60q color=%q", username, content, colorTag)
-	go nc.sendAsync(username, content, colorTag)
+	log.Printf("TRACE NetworkClient.SendTyped: type=%s user=%q content=%.60q color=%q", msgType, username, content, colorTag)
+	nc.wake()
+	ui.SafeGo("send", func() { nc.sendAsync(msgType, username, content, colorTag, nil) })
 }
 
+// SendTracked is SendTyped with done called (from the send goroutine) once
+// the send is over: with the ID and time the relay gave the message, or with
+// why it was not accepted. A legacy relay may give neither ID nor time.
+func (nc *NetworkClient) SendTracked(msgType, username, content, colorTag string, done func(id string, at time.Time, err error)) {
+	if atomic.LoadInt32(&nc.stopped) == 1 {
+		return
+	}
+	nc.wake()
+	ui.SafeGo("send", func() { nc.sendAsync(msgType, username, content, colorTag, done) })
+}
+
+// SendMessageWait is SendMessage that blocks until the relay answered, the
+// request failed, or timeout elapsed. Used at shutdown, when a background send
+// would die with the process.
+func (nc *NetworkClient) SendMessageWait(msgType, username, content, colorTag string, timeout time.Duration) {
+	if atomic.LoadInt32(&nc.stopped) == 1 {
+		return
+	}
+	done := make(chan struct{})
+	ui.SafeGo("SendMessageWait", func() {
+		defer close(done)
+		nc.sendAsync(msgType, username, content, colorTag, nil)
+	})
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Printf("TRACE SendMessageWait: gave up after %v", timeout)
+	}
+}
+
+// Stop ends the receive loop and the heartbeat and aborts every request to
+// the relay still in flight, a held long poll included. No callback runs
+// once Stop has returned.
 func (nc *NetworkClient) Stop() {
 	if atomic.CompareAndSwapInt32(&nc.stopped, 0, 1) {
 		log.Printf("TRACE NetworkClient.Stop: closing stopCh")
 		close(nc.stopCh)
+		nc.cancel()
+	}
+}
+
+// isStopped reports whether Stop has been called.
+func (nc *NetworkClient) isStopped() bool {
+	return atomic.LoadInt32(&nc.stopped) == 1
+}
+
+// get and post make a request to the relay that Stop aborts.
+func (nc *NetworkClient) get(client *http.Client, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(nc.ctx, http.MethodGet, nc.serverURL+path, nil)
+	if err != nil {
+		return nil, err
 	}
+	return client.Do(req)
+}
+
+func (nc *NetworkClient) post(client *http.Client, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(nc.ctx, http.MethodPost, nc.serverURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return client.Do(req)
+}
+
+// Configure applies transport and poll settings from the client config.
+// Call before Start.
+func (nc *NetworkClient) Configure(cfg *models.Config) {
+	nc.pollCfg = cfg.Poll
+	nc.transports = cfg.Transports
+	if cfg.Poll.Headers {
+		nc.transports = []string{transportPoll} // the stream always carries bodies
+	}
+	nc.httpClient = newHTTPClient(time.Duration(cfg.Poll.Timeout))
+	nc.heartbeat = time.Duration(cfg.Heartbeat)
+}
+
+// OnRejected sets the callback for sends the relay refused, e.g. while muted
+// or in slow mode. It runs on the send goroutine. Call before Start.
+func (nc *NetworkClient) OnRejected(fn func(reason string)) {
+	nc.onRejected = fn
 }
 
 // ServerURL returns the relay server base URL this client is connected to.
@@ -178,74 +291,166 @@ func (nc *NetworkClient) ServerURL() string {
 	return nc.serverURL
 }
 
+// Transport returns the receive transport in use ("sse" or "poll"), or ""
+// before the receive loop has started.
+func (nc *NetworkClient) Transport() string {
+	t, _ := nc.transport.Load().(string)
+	return t
+}
+
+// Drops returns the malformed messages dropped so far, by reason.
+func (nc *NetworkClient) Drops() map[string]int { return nc.drops.Snapshot() }
+
+// Neutralized returns how many control sequences were stripped from
+// message content so far.
+func (nc *NetworkClient) Neutralized() int { return nc.drops.Neutralized() }
+
+// LastID returns the ID of the newest message seen by the poll loop.
+func (nc *NetworkClient) LastID() string {
+	nc.lastIDMu.Lock()
+	defer nc.lastIDMu.Unlock()
+	return nc.lastID
+}
+
+// SetLastID seeds the poll cursor. Call before Start to resume a session
+// without re-fetching messages that were already delivered.
+func (nc *NetworkClient) SetLastID(id string) {
+	nc.lastIDMu.Lock()
+	nc.lastID = id
+	nc.lastIDMu.Unlock()
+}
+
 // ── Send ──────────────────────────────────────────────────────────────────────
 
-func (nc *NetworkClient) sendAsync(username, content, colorTag string) {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("PANIC NetworkClient.sendAsync: %v", r)
-		}
-	}()
+// wireColor converts a tview color tag to what the relay expects: the plain
+// name or #rrggbb when it advertised "plain-colors", the tag itself for older
+// relays, which reject anything else.
+func (nc *NetworkClient) wireColor(colorTag string) string {
+	if atomic.LoadInt32(&nc.plainColors) == 1 {
+		return models.ColorName(colorTag)
+	}
+	return colorTag
+}
+
+// errUnreachable is why a send failed that got no answer from the relay.
+var errUnreachable = errors.New("relay unreachable")
+
+// relayFailed is why a send failed that the relay answered with a 5xx
+// status: it broke, as opposed to refusing the message.
+type relayFailed int
 
+func (e relayFailed) Error() string { return fmt.Sprintf("relay answered HTTP %d", int(e)) }
+
+// transportFailure reports whether a send failed on the way — the relay
+// unreachable or failing — rather than being refused: muted, slow mode,
+// too large, bad key and the like say nothing about the connection.
+func transportFailure(err error) bool {
+	var failed relayFailed
+	return errors.Is(err, errUnreachable) || errors.As(err, &failed)
+}
+
+// sendAsync sends one message and, if done is set, calls it as SendTracked
+// describes. Neither done nor any other callback runs once Stop was called.
+func (nc *NetworkClient) sendAsync(msgType, username, content, colorTag string, done func(id string, at time.Time, err error)) {
+	fail := func(err error) {
+		if done != nil {
+			done("", time.Time{}, err)
+		}
+	}
 	log.Printf("TRACE sendAsync: building request user=%q content=%.60q", username, content)
 	body := sendRequest{
 		AccessKey: serverAccessKey,
 		ClientID:  nc.clientID,
 		Username:  username,
 		Content:   content,
-		Color:     colorTag,
+		Color:     nc.wireColor(colorTag),
 	}
-	bodyJSON, err := json.Marshal(body)
-	if err != nil {
-		log.Printf("TRACE sendAsync: marshal error: %v", err)
-		return
+	if msgType != msgTypeChat {
+		body.Type = msgType // omitted for chat so legacy relays see the old shape
 	}
-
+	echo := echoKey(msgType, username, content)
+	nc.sentIDsMu.Lock()
+	nc.inFlight[echo]++
+	nc.sentIDsMu.Unlock()
+	defer nc.answered(echo)
 	log.Printf("TRACE sendAsync: POST %s/api/send", nc.serverURL)
-	resp, err := nc.httpClient.Post(
-		nc.serverURL+"/api/send",
-		"application/json",
-		bytes.NewReader(bodyJSON),
-	)
+	resp, err := nc.postSend(body)
+	if nc.isStopped() {
+		if err == nil {
+			drainClose(resp.Body)
+		}
+		return
+	}
+	if errors.Is(err, errPowRefused) {
+		if nc.onRejected != nil {
+			nc.onRejected(err.Error())
+		}
+		fail(err)
+		return
+	}
 	if err != nil {
 		log.Printf("TRACE sendAsync: POST error: %v", err)
 		nc.notifyStatus(false, "Message send failed — server unreachable.")
+		fail(errUnreachable)
 		return
 	}
-	defer resp.Body.Close()
+	defer drainClose(resp.Body)
 	log.Printf("TRACE sendAsync: POST status=%d", resp.StatusCode)
 
 	switch resp.StatusCode {
 	case http.StatusUnauthorized:
 		nc.notifyStatus(false, "Server rejected access key.")
+		fail(errors.New("access key rejected"))
+	case http.StatusForbidden, http.StatusTooManyRequests, http.StatusRequestEntityTooLarge:
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		log.Printf("TRACE sendAsync: refused status=%d body=%.120s", resp.StatusCode, raw)
+		reason := strings.TrimSpace(string(raw))
+		if nc.onRejected != nil {
+			nc.onRejected(reason)
+		}
+		if reason == "" {
+			reason = http.StatusText(resp.StatusCode)
+		}
+		fail(errors.New(reason))
 	case http.StatusOK, http.StatusCreated:
 		var sr sendResponse
-		if err := json.NewDecoder(resp.Body).Decode(&sr); err == nil && sr.ID != "" {
-			log.Printf("TRACE sendAsync: server assigned id=%q", sr.ID)
-			nc.sentIDsMu.Lock()
+		if err := json.NewDecoder(resp.Body).Decode(&sr); err != nil || sr.ID == "" {
+			if done != nil {
+				done("", time.Time{}, nil) // a legacy relay: accepted, nothing more
+			}
+			return
+		}
+		log.Printf("TRACE sendAsync: server assigned id=%q", sr.ID)
+		if sr.Challenge != "" {
+			nc.notePowChallenge(sr.Challenge, 0)
+		}
+		nc.sentIDsMu.Lock()
+		if _, echoed := nc.earlyEchoes[sr.ID]; echoed {
+			delete(nc.earlyEchoes, sr.ID)
+		} else {
 			nc.sentIDs[sr.ID] = struct{}{}
-			nc.sentIDsMu.Unlock()
+		}
+		nc.sentIDsMu.Unlock()
+		if done != nil {
+			at, _ := time.Parse(time.RFC3339, sr.Time)
+			done(sr.ID, at, nil)
 		}
 	default:
 		raw, _ := io.ReadAll(resp.Body)
 		log.Printf("TRACE sendAsync: unexpected status %d body=%.120s", resp.StatusCode, raw)
+		if resp.StatusCode >= http.StatusInternalServerError {
+			fail(relayFailed(resp.StatusCode))
+		} else {
+			fail(fmt.Errorf("relay answered HTTP %d", resp.StatusCode))
+		}
 	}
 }
 
 // ── Poll loop ─────────────────────────────────────────────────────────────────
 
-func (nc *NetworkClient) pollLoop() {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("PANIC NetworkClient.pollLoop: %v", r)
-		}
-	}()
-
-	backoff := 1 * time.Second
-	const maxBackoff = 30 * time.Second
-	firstConnect := true
-	wasConnected := false
+func (nc *NetworkClient) pollLoop(link *linkState) {
 	iteration := 0
+	idle := time.Duration(nc.pollCfg.Interval)
 
 	for {
 		iteration++
@@ -254,31 +459,16 @@ func (nc *NetworkClient) pollLoop() {
 			return
 		}
 
-		log.Printf("TRACE pollLoop[%d]: calling poll(), lastID=%q", iteration, nc.lastID)
+		log.Printf("TRACE pollLoop[%d]: calling poll(), lastID=%q", iteration, nc.LastID())
 		msgs, err := nc.poll()
 		if err != nil {
 			log.Printf("TRACE pollLoop[%d]: poll error: %v", iteration, err)
-			if firstConnect {
-				nc.notifyStatus(false, fmt.Sprintf("Cannot reach server at %s", nc.serverURL))
-			} else if wasConnected {
-				nc.notifyStatus(false, fmt.Sprintf("Connection lost — reconnecting in %v…", backoff))
-			}
-			wasConnected = false
-			select {
-			case <-nc.stopCh:
+			if !nc.linkDown(link) {
 				return
-			case <-time.After(backoff):
 			}
-			backoff = minDur(backoff*2, maxBackoff)
 			continue
 		}
-
-		if firstConnect || !wasConnected {
-			nc.notifyStatus(true, fmt.Sprintf("Connected to relay at %s", nc.serverURL))
-		}
-		backoff = 1 * time.Second
-		firstConnect = false
-		wasConnected = true
+		nc.linkUp(link)
 
 		log.Printf("TRACE pollLoop[%d]: poll returned %d messages (nil=%v)", iteration, len(msgs), msgs == nil)
 
@@ -289,13 +479,32 @@ func (nc *NetworkClient) pollLoop() {
 			log.Printf("TRACE pollLoop[%d]: msg[%d] dispatch complete", iteration, idx)
 		}
 
-		if msgs == nil {
-			select {
-			case <-nc.stopCh:
-				return
-			case <-time.After(500 * time.Millisecond):
-			}
+		if len(msgs) > 0 {
+			idle = time.Duration(nc.pollCfg.Interval)
+			continue
 		}
+		select {
+		case <-nc.stopCh:
+			return
+		case <-nc.wakeCh:
+			log.Printf("TRACE pollLoop[%d]: woken by send, idle pause reset", iteration)
+			idle = time.Duration(nc.pollCfg.Interval)
+			continue
+		case <-time.After(idle):
+		}
+		if nc.pollCfg.Adaptive {
+			idle = minDur(idle*2, time.Duration(nc.pollCfg.MaxInterval))
+			log.Printf("TRACE pollLoop[%d]: quiet, next idle pause %v", iteration, idle)
+		}
+	}
+}
+
+// wake resets an adaptive idle pause; activity on our side means replies
+// are likely soon. Never blocks.
+func (nc *NetworkClient) wake() {
+	select {
+	case nc.wakeCh <- struct{}{}:
+	default:
 	}
 }
 
@@ -307,12 +516,18 @@ func (nc *NetworkClient) poll() ([]*pollMessage, error) {
 	params := url.Values{}
 	params.Set("access_key", serverAccessKey)
 	params.Set("client_id", nc.clientID)
+	params.Set("format", "2") // ignored by legacy relays; the parser accepts both
+	if nc.pollCfg.Headers && atomic.LoadInt32(&nc.lazyBodies) == 1 {
+		params.Set("bodies", "lazy")
+	}
 	if lastID != "" {
 		params.Set("last_id", lastID)
 	}
 
 	log.Printf("TRACE poll: GET %s/api/poll lastID=%q", nc.serverURL, lastID)
-	req, err := http.NewRequest(http.MethodGet, nc.serverURL+"/api/poll?"+params.Encode(), nil)
+	ctx, cancel := nc.receiveContext()
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, nc.serverURL+"/api/poll?"+params.Encode(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -321,7 +536,7 @@ func (nc *NetworkClient) poll() ([]*pollMessage, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainClose(resp.Body)
 	log.Printf("TRACE poll: response status=%d", resp.StatusCode)
 
 	switch resp.StatusCode {
@@ -332,16 +547,24 @@ func (nc *NetworkClient) poll() ([]*pollMessage, error) {
 	case http.StatusUnauthorized:
 		return nil, fmt.Errorf("server rejected access key")
 
+	case http.StatusForbidden:
+		return nil, fmt.Errorf("relay refused this client (kicked)")
+
 	case http.StatusOK:
-		rawBody, err := io.ReadAll(resp.Body)
+		rawBody, err := io.ReadAll(io.LimitReader(resp.Body, maxPollBody+1))
 		if err != nil {
 			return nil, fmt.Errorf("read poll body: %w", err)
 		}
 		log.Printf("TRACE poll: 200 body=%d bytes", len(rawBody))
-		msgs, err := parsePollMessages(rawBody)
+		msgs, err := parsePollMessages(rawBody, &nc.drops)
 		if err != nil {
 			return nil, err
 		}
+		if ctx.Err() != nil {
+			// Stopped or dropped while reading: leave lastID where it was,
+			// so a resumed session fetches these again.
+			return nil, ctx.Err()
+		}
 		if len(msgs) > 0 {
 			nc.lastIDMu.Lock()
 			nc.lastID = msgs[len(msgs)-1].ID
@@ -357,11 +580,23 @@ func (nc *NetworkClient) poll() ([]*pollMessage, error) {
 }
 
 func (nc *NetworkClient) handleIncoming(msg *pollMessage) {
+	if nc.isStopped() {
+		return
+	}
+	nc.lastIDMu.Lock()
+	if nc.firstID == "" {
+		nc.firstID = msg.ID
+	}
+	nc.lastIDMu.Unlock()
 	log.Printf("TRACE handleIncoming: checking sentIDs for id=%q", msg.ID)
 	nc.sentIDsMu.Lock()
 	_, isMine := nc.sentIDs[msg.ID]
 	if isMine {
 		delete(nc.sentIDs, msg.ID)
+	} else if nc.inFlight[echoKey(msg.Type, msg.Username, msg.Content)] > 0 {
+		// Ours, echoed before the relay answered the send with its ID.
+		isMine = true
+		nc.earlyEchoes[msg.ID] = struct{}{}
 	}
 	nc.sentIDsMu.Unlock()
 
@@ -370,17 +605,35 @@ func (nc *NetworkClient) handleIncoming(msg *pollMessage) {
 		return
 	}
 
-	log.Printf("TRACE handleIncoming: calling onMessage user=%q color=%q content=%.80q",
-		msg.Username, msg.Color, msg.Content)
+	log.Printf("TRACE handleIncoming: calling onMessage type=%s user=%q color=%q content=%.80q",
+		msg.Type, msg.Username, msg.Color, msg.Content)
 	if nc.onMessage != nil {
-		nc.onMessage(msg.Username, msg.Content, msg.Color)
+		nc.onMessage(msg)
 	}
 	log.Printf("TRACE handleIncoming: onMessage returned for id=%q", msg.ID)
 }
 
+// echoKey identifies a send by what the relay echoes back, for matching an
+// echo that arrives before the send's answer.
+func echoKey(msgType, username, content string) string {
+	if msgType == "" {
+		msgType = msgTypeChat // as legacy relays echo chat
+	}
+	return msgType + "\x00" + username + "\x00" + content
+}
+
+// answered ends the wait for the relay's answer to a send with echo key.
+func (nc *NetworkClient) answered(echo string) {
+	nc.sentIDsMu.Lock()
+	defer nc.sentIDsMu.Unlock()
+	if nc.inFlight[echo]--; nc.inFlight[echo] <= 0 {
+		delete(nc.inFlight, echo)
+	}
+}
+
 func (nc *NetworkClient) notifyStatus(connected bool, msg string) {
 	log.Printf("TRACE notifyStatus: connected=%v msg=%q", connected, msg)
-	if nc.onStatusChange != nil {
+	if nc.onStatusChange != nil && !nc.isStopped() {
 		nc.onStatusChange(connected, msg)
 	}
 }
@@ -389,13 +642,12 @@ func (nc *NetworkClient) notifyStatus(connected bool, msg string) {
 
 func CheckServerConnectivity(serverURL string) error {
 	log.Printf("TRACE CheckServerConnectivity: GET %s/health", serverURL)
-	client := &http.Client{Timeout: 3 * time.Second}
-	resp, err := client.Get(serverURL + "/health")
+	resp, err := newHTTPClient(3 * time.Second).Get(serverURL + "/health")
 	if err != nil {
 		log.Printf("TRACE CheckServerConnectivity: error: %v", err)
 		return fmt.Errorf("relay server not available at %s: %w", serverURL, err)
 	}
-	resp.Body.Close()
+	drainClose(resp.Body)
 	log.Printf("TRACE CheckServerConnectivity: status=%d", resp.StatusCode)
 	if resp.StatusCode >= 500 {
 		return fmt.Errorf("relay server returned HTTP %d", resp.StatusCode)
@@ -425,19 +677,26 @@ type ServerStats struct {
 
 // FetchStats calls GET /api/stats and returns the parsed result.
 // Uses a short 5-second timeout — stats are non-critical, failure is silent.
+// ErrStatsUnsupported is returned by FetchStats for a relay without
+// /api/stats, as self-hosted ones may be.
+var ErrStatsUnsupported = errors.New("relay has no /api/stats")
+
 func (nc *NetworkClient) FetchStats() (*ServerStats, error) {
 	params := url.Values{}
 	params.Set("access_key", serverAccessKey)
 	params.Set("client_id", nc.clientID)
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get(nc.serverURL + "/api/stats?" + params.Encode())
+	resp, err := nc.get(nc.shortClient, "/api/stats?"+params.Encode())
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainClose(resp.Body)
 
-	if resp.StatusCode != http.StatusOK {
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return nil, ErrStatsUnsupported
+	default:
 		return nil, fmt.Errorf("stats HTTP %d", resp.StatusCode)
 	}
 
@@ -0,0 +1,380 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"cli-client/internal/relaytest"
+	"cli-client/models"
+)
+
+// Integration tests: a real NetworkClient against the fake relay in
+// internal/relaytest.
+
+const waitFor = 5 * time.Second
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard) // the network layer traces every step
+	os.Exit(m.Run())
+}
+
+// testClient is a started NetworkClient with its callbacks on channels.
+type testClient struct {
+	*NetworkClient
+	msgs   chan *pollMessage
+	status chan bool
+}
+
+// newTestClient starts a poll-only client for relay; tweaks adjust its
+// config first.
+func newTestClient(t *testing.T, relay *relaytest.Relay, tweaks ...func(*models.Config)) *testClient {
+	t.Helper()
+	tc := &testClient{
+		msgs:   make(chan *pollMessage, 1000),
+		status: make(chan bool, 100),
+	}
+	tc.NetworkClient = NewNetworkClient(nil, relay.URL,
+		func(msg *pollMessage) { tc.msgs <- msg },
+		func(connected bool, _ string) {
+			select {
+			case tc.status <- connected:
+			default:
+			}
+		},
+	)
+	cfg := models.DefaultConfig()
+	cfg.Transports = []string{transportPoll}
+	for _, tweak := range tweaks {
+		tweak(cfg)
+	}
+	tc.Configure(cfg)
+	tc.Start()
+	t.Cleanup(tc.Stop)
+	return tc
+}
+
+// next returns the next delivered message, failing the test after waitFor.
+func (tc *testClient) next(t *testing.T) *pollMessage {
+	t.Helper()
+	select {
+	case msg := <-tc.msgs:
+		return msg
+	case <-time.After(waitFor):
+		t.Fatal("no message delivered")
+		return nil
+	}
+}
+
+// waitStatus waits until the client reports the given connection state.
+func (tc *testClient) waitStatus(t *testing.T, connected bool) {
+	t.Helper()
+	deadline := time.After(waitFor)
+	for {
+		select {
+		case got := <-tc.status:
+			if got == connected {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("connected=%v never reported", connected)
+		}
+	}
+}
+
+func TestPollDeliversMessagesInOrder(t *testing.T) {
+	relay := relaytest.New()
+	defer relay.Close()
+	tc := newTestClient(t, relay)
+
+	relay.Post("alice", "hello", "green")
+	last := relay.Post("bob", "[red]not markup[-] 🙂", "cyan")
+
+	for _, want := range []struct{ user, content, color string }{
+		{"alice", "hello", "green"},
+		{"bob", "[red]not markup[-] 🙂", "cyan"},
+	} {
+		msg := tc.next(t)
+		if msg.Username != want.user || msg.Content != want.content || msg.Color != want.color {
+			t.Fatalf("got %s/%q/%s, want %s/%q/%s", msg.Username, msg.Content, msg.Color, want.user, want.content, want.color)
+		}
+		if msg.Type != msgTypeChat {
+			t.Errorf("type = %q, want chat", msg.Type)
+		}
+	}
+	if got := tc.LastID(); got != last {
+		t.Errorf("LastID = %q, want %q", got, last)
+	}
+}
+
+func TestBurstIsDeliveredCompletely(t *testing.T) {
+	relay := relaytest.New()
+	defer relay.Close()
+	tc := newTestClient(t, relay)
+
+	ids := relay.Burst("carol", 120) // more than one poll's worth
+	for i, id := range ids {
+		if msg := tc.next(t); msg.ID != id {
+			t.Fatalf("message %d: got %s, want %s", i, msg.ID, id)
+		}
+	}
+}
+
+func TestSendReachesRelay(t *testing.T) {
+	relay := relaytest.New()
+	defer relay.Close()
+	tc := newTestClient(t, relay)
+
+	acked := make(chan string, 1)
+	tc.SendTracked(msgTypeChat, "me", "hi there", "[green]", func(id string, _ time.Time, err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		acked <- id
+	})
+
+	var id string
+	select {
+	case id = <-acked:
+	case <-time.After(waitFor):
+		t.Fatal("send never acknowledged")
+	}
+	sent := relay.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("relay got %d sends, want 1", len(sent))
+	}
+	if sent[0].ID != id || sent[0].Username != "me" || sent[0].Content != "hi there" || sent[0].Color != "[green]" {
+		t.Errorf("relay got %+v, ack %s", sent[0], id)
+	}
+}
+
+func TestRefusedPartEndsSend(t *testing.T) {
+	relay := relaytest.New()
+	defer relay.Close()
+	relay.Fail(relaytest.Send, relaytest.Fault{}, relaytest.Fault{Status: http.StatusTooManyRequests, Body: "slow mode"})
+	tc := newTestClient(t, relay)
+
+	failed := make(chan error, 1)
+	tc.SendParts("me", []string{"[1/3] a", "[2/3] b", "[3/3] c"}, "[green]", func(_ string, _ time.Time, err error) {
+		failed <- err
+	})
+	select {
+	case err := <-failed:
+		if err == nil || err.Error() != "slow mode" {
+			t.Errorf("err = %v, want slow mode", err)
+		}
+	case <-time.After(waitFor):
+		t.Fatal("send never finished")
+	}
+	if sent := relay.Sent(); len(sent) != 1 {
+		t.Errorf("relay got %d parts, want only the one before the refusal", len(sent))
+	}
+}
+
+// slowAcks holds each answer to a send back for a while after the relay
+// gave it, so the echo of the message arrives first.
+type slowAcks struct{ http.RoundTripper }
+
+func (s slowAcks) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := s.RoundTripper.RoundTrip(req)
+	if err == nil && req.URL.Path == relaytest.Send {
+		time.Sleep(300 * time.Millisecond)
+	}
+	return resp, err
+}
+
+func TestEarlyEchoIsNotDelivered(t *testing.T) {
+	defer func(saved http.RoundTripper) { relayTransport = saved }(relayTransport)
+	relayTransport = slowAcks{sharedTransport}
+	relay := relaytest.New()
+	defer relay.Close()
+	tc := newTestClient(t, relay)
+
+	acked := make(chan string, 1)
+	tc.SendTracked(msgTypeChat, "me", "hi there", "[green]", func(id string, _ time.Time, _ error) { acked <- id })
+	select {
+	case <-acked:
+	case <-time.After(waitFor):
+		t.Fatal("send never acknowledged")
+	}
+	// The same text under our name from someone else is theirs.
+	other := relay.Post("me", "hi there", "green")
+	if msg := tc.next(t); msg.ID != other {
+		t.Errorf("got %s (%q), want only the other client's %s", msg.ID, msg.Content, other)
+	}
+}
+
+func TestRejectedKeyReportsDisconnect(t *testing.T) {
+	relay := relaytest.New()
+	defer relay.Close()
+	relay.Fail(relaytest.Poll, relaytest.Fault{Status: http.StatusUnauthorized})
+	tc := newTestClient(t, relay)
+
+	tc.waitStatus(t, false)
+	tc.waitStatus(t, true) // the next poll, after the backoff, is answered
+}
+
+func TestHeartbeatRestartsHungPoll(t *testing.T) {
+	relay := relaytest.New()
+	defer relay.Close()
+	relay.SetHold(time.Minute) // polls hang, as on a dead connection
+	tc := newTestClient(t, relay, func(cfg *models.Config) {
+		cfg.Heartbeat = models.Duration(500 * time.Millisecond)
+	})
+	relay.Post("alice", "hello", "green")
+	tc.next(t)
+	tc.waitStatus(t, true)
+	polls := relay.Requests(relaytest.Poll)
+
+	// Two checks in a row outlast the heartbeat's timeout.
+	relay.Fail(relaytest.Health, relaytest.Fault{Delay: time.Second}, relaytest.Fault{Delay: time.Second})
+	tc.waitStatus(t, false)
+	tc.waitStatus(t, true) // /health answers again
+
+	deadline := time.Now().Add(waitFor)
+	for relay.Requests(relaytest.Poll) <= polls {
+		if time.Now().After(deadline) {
+			t.Fatal("the hung poll was never replaced")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	relay.Post("alice", "after the outage", "green")
+	if msg := tc.next(t); msg.Content != "after the outage" {
+		t.Fatalf("got %q", msg.Content)
+	}
+}
+
+func TestStopAbortsHeldPoll(t *testing.T) {
+	relay := relaytest.New()
+	defer relay.Close()
+	relay.SetHold(time.Minute)
+	tc := newTestClient(t, relay, func(cfg *models.Config) { cfg.Heartbeat = 0 })
+	relay.Post("alice", "hello", "green")
+	tc.next(t)
+	tc.waitStatus(t, true)
+
+	polls := relay.Requests(relaytest.Poll)
+	deadline := time.Now().Add(waitFor)
+	for relay.Requests(relaytest.Poll) <= polls {
+		if time.Now().After(deadline) {
+			t.Fatal("no poll after the first")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	lastID := tc.LastID()
+	tc.Stop()
+
+	// A poll still held would return this and move the cursor past it,
+	// so a resumed session would never see it.
+	relay.Post("alice", "after stop", "green")
+	select {
+	case connected := <-tc.status:
+		t.Errorf("connected=%v reported after Stop", connected)
+	case msg := <-tc.msgs:
+		t.Errorf("%q delivered after Stop", msg.Content)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if got := tc.LastID(); got != lastID {
+		t.Errorf("lastID moved to %q after Stop, want %q", got, lastID)
+	}
+}
+
+func TestMalformedPollBodyIsCountedAndSkipped(t *testing.T) {
+	relay := relaytest.New()
+	defer relay.Close()
+	relay.Fail(relaytest.Poll, relaytest.Fault{Body: `[{"id":`})
+	tc := newTestClient(t, relay)
+
+	relay.Post("alice", "after the bad body", "green")
+	if msg := tc.next(t); msg.Content != "after the bad body" {
+		t.Fatalf("got %q", msg.Content)
+	}
+	if n := tc.Drops()[dropSyntax]; n != 1 {
+		t.Errorf("%s drops = %d, want 1", dropSyntax, n)
+	}
+}
+
+func TestSlowPollStillDelivers(t *testing.T) {
+	relay := relaytest.New()
+	defer relay.Close()
+	relay.Fail(relaytest.Poll, relaytest.Fault{Delay: 300 * time.Millisecond})
+	tc := newTestClient(t, relay)
+
+	relay.Post("alice", "late", "green")
+	if msg := tc.next(t); msg.Content != "late" {
+		t.Fatalf("got %q", msg.Content)
+	}
+}
+
+func TestFetchStats(t *testing.T) {
+	relay := relaytest.New()
+	defer relay.Close()
+	nc := NewNetworkClient(nil, relay.URL, nil, nil)
+
+	relay.Burst("dave", 3)
+	stats, err := nc.FetchStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.ChatStats.TotalMessages != 3 {
+		t.Errorf("total = %d, want 3", stats.ChatStats.TotalMessages)
+	}
+
+	relay.Fail(relaytest.Stats, relaytest.Fault{Status: http.StatusUnauthorized})
+	if _, err := nc.FetchStats(); err == nil {
+		t.Error("401 from /api/stats: no error")
+	}
+}
+
+func TestParsePollMessagesFromRelay(t *testing.T) {
+	relay := relaytest.New()
+	defer relay.Close()
+	relay.Post("alice", "line one\nline two", "green")
+	relay.Post("bob", "second", "cyan")
+
+	body := rawPoll(t, relay, "")
+	var drops dropCounter
+	msgs, err := parsePollMessages(body, &drops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || msgs[0].Content != "line one\nline two" || msgs[1].Username != "bob" {
+		t.Fatalf("parsed %+v", msgs)
+	}
+	if msgs[0].Timestamp.IsZero() {
+		t.Error("timestamp not parsed")
+	}
+
+	// last_id picks up after the given message.
+	first := msgs[0].ID
+	if msgs, _ = parsePollMessages(rawPoll(t, relay, first), &drops); len(msgs) != 1 || msgs[0].Username != "bob" {
+		t.Fatalf("after %s: parsed %+v", first, msgs)
+	}
+	if len(drops.Snapshot()) != 0 {
+		t.Errorf("drops = %v", drops.Snapshot())
+	}
+}
+
+// rawPoll fetches one /api/poll body from relay, as the client asks for it.
+func rawPoll(t *testing.T, relay *relaytest.Relay, lastID string) []byte {
+	t.Helper()
+	q := url.Values{"access_key": {serverAccessKey}, "client_id": {"test"}, "format": {"2"}, "last_id": {lastID}}
+	resp, err := http.Get(fmt.Sprintf("%s%s?%s", relay.URL, relaytest.Poll, q.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("poll: HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"cli-client/models"
+	"cli-client/ui"
+)
+
+// ── Notifications ─────────────────────────────────────────────────────────────
+//
+// Incoming chat messages are checked against the "notify" rules in the config
+// file; a match rings the terminal bell. /dnd [duration] silences everything
+// until it is turned off or the duration runs out, and so do quiet hours
+// (see quiet.go).
+
+// NotificationController evaluates notify rules and tracks Do Not Disturb.
+// Only touched inside the tview event loop.
+type NotificationController struct {
+	dndOn    bool
+	dndUntil time.Time // zero = until /dnd off
+	dndTimer *time.Timer
+	quiet    bool // inside the quiet_hours window
+
+	lastHere map[string]time.Time // sender → last @here that rang, see here.go
+}
+
+func NewNotificationController() *NotificationController {
+	return &NotificationController{lastHere: make(map[string]time.Time)}
+}
+
+// DND reports whether Do Not Disturb is on and, if timed, when it ends.
+func (n *NotificationController) DND() (bool, time.Time) {
+	return n.dndOn, n.dndUntil
+}
+
+// SetDND turns Do Not Disturb on for d, or until ClearDND when d is 0.
+// onExpire runs on the timer goroutine once a timed DND ends by itself.
+func (n *NotificationController) SetDND(d time.Duration, onExpire func()) {
+	n.ClearDND()
+	n.dndOn = true
+	if d <= 0 {
+		return
+	}
+	n.dndUntil = time.Now().Add(d)
+	n.dndTimer = time.AfterFunc(d, onExpire)
+}
+
+// ClearDND turns Do Not Disturb off.
+func (n *NotificationController) ClearDND() {
+	if n.dndTimer != nil {
+		n.dndTimer.Stop()
+		n.dndTimer = nil
+	}
+	n.dndOn = false
+	n.dndUntil = time.Time{}
+}
+
+// Quiet reports whether quiet hours are in effect.
+func (n *NotificationController) Quiet() bool {
+	return n.quiet
+}
+
+// SetQuiet starts or ends quiet hours, which silence the bell like DND.
+func (n *NotificationController) SetQuiet(on bool) {
+	n.quiet = on
+}
+
+// expired reports whether a timed DND has run out — the expiry callback
+// checks this so a stale timer cannot end a DND that was set again since.
+func (n *NotificationController) expired() bool {
+	return n.dndOn && !n.dndUntil.IsZero() && !time.Now().Before(n.dndUntil)
+}
+
+// ShouldNotify reports whether a message from sender should ring the bell.
+func (n *NotificationController) ShouldNotify(rules models.NotifyConfig, me, sender, content string) bool {
+	// Checked first so the sender's cooldown runs even while silenced.
+	here := sender != me && mentionsHere(content) && n.allowHere(sender, time.Now())
+	if n.dndOn || n.quiet || !rules.Bell || sender == me {
+		return false
+	}
+	if here {
+		return true
+	}
+	if !rules.Mentions && len(rules.Keywords) == 0 && len(rules.Users) == 0 {
+		return true
+	}
+	if rules.Mentions && mentions(content, me) {
+		return true
+	}
+	for _, u := range rules.Users {
+		if strings.EqualFold(u, sender) {
+			return true
+		}
+	}
+	text := " " + strings.Join(words(content), " ") + " "
+	for _, k := range rules.Keywords {
+		if kw := strings.Join(words(k), " "); kw != "" && strings.Contains(text, " "+kw+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// words lower-cases s and splits it into runs of letters and digits.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return !isWordRune(r) })
+}
+
+// mentions reports whether content contains "@name" as a whole word.
+func mentions(content, name string) bool {
+	if name == "" {
+		return false
+	}
+	lower, tag := strings.ToLower(content), "@"+strings.ToLower(name)
+	for i := 0; ; {
+		j := strings.Index(lower[i:], tag)
+		if j < 0 {
+			return false
+		}
+		end := i + j + len(tag)
+		if r, _ := utf8.DecodeRuneInString(lower[end:]); end == len(lower) || !isWordRune(r) {
+			return true
+		}
+		i = end
+	}
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// notify rings the bell for an incoming chat message if the rules say so,
+// and counts it for the terminal title. Must be called from inside
+// QueueUpdateDraw.
+func (ac *AppController) notify(sender, content string) {
+	if ac.App.CurrentUser == nil {
+		return
+	}
+	ac.countUnread()
+	rules, ok := ac.roomNotifyRules()
+	if !ok {
+		return
+	}
+	if ac.lookingAt() && !ac.App.Config.Notify.WhenFocused {
+		return // on screen already
+	}
+	me := ac.App.CurrentUser.Username
+	if !ac.notifier.ShouldNotify(rules, me, sender, content) &&
+		!(ac.highlightNotifies(content) && ac.notifier.ShouldNotify(models.NotifyConfig{Bell: rules.Bell}, me, sender, content)) {
+		return
+	}
+	if chat, ok := ac.chatView(); ok {
+		chat.Bell()
+	}
+}
+
+// notifyAnnouncement rings the bell for a relay announcement. Unlike chat it
+// ignores /dnd and per-conversation levels; notify.announcements turns it off.
+// Must be called from inside QueueUpdateDraw.
+func (ac *AppController) notifyAnnouncement(sender string) {
+	rules := ac.App.Config.Notify
+	if ac.App.CurrentUser == nil || sender == ac.App.CurrentUser.Username || !rules.Bell || !rules.Announcements {
+		return
+	}
+	if chat, ok := ac.chatView(); ok {
+		chat.Bell()
+	}
+}
+
+// setDND handles /dnd. Usage: /dnd (toggle)  /dnd <duration>  /dnd off
+// Must be called from the tview event loop.
+func (ac *AppController) setDND(arg string) {
+	chat, hasChat := ac.chatView()
+	on, _ := ac.notifier.DND()
+
+	var d time.Duration
+	switch strings.ToLower(arg) {
+	case "":
+		if on {
+			arg = "off"
+		}
+	case "off", "on":
+	default:
+		v, err := time.ParseDuration(arg)
+		if err != nil || v <= 0 {
+			ac.sendSystem("Usage: /dnd [duration|off]  —  e.g. /dnd 30m, /dnd 1h30m")
+			return
+		}
+		d = v
+	}
+
+	if strings.ToLower(arg) == "off" {
+		ac.notifier.ClearDND()
+		if hasChat {
+			chat.SetSegment("dnd", "")
+		}
+		ac.sendSystem("Do Not Disturb OFF — notifications back on.")
+		return
+	}
+
+	ac.notifier.SetDND(d, func() {
+		ui.SafeQueueUpdateDraw(ac.app, "setDND", func() {
+			if !ac.notifier.expired() {
+				return
+			}
+			ac.notifier.ClearDND()
+			if chat, ok := ac.chatView(); ok {
+				chat.SetSegment("dnd", "")
+			}
+			ac.sendSystem("Do Not Disturb ended — notifications back on.")
+		})
+	})
+	label := "on"
+	if _, until := ac.notifier.DND(); !until.IsZero() {
+		label = "until " + until.Format("15:04")
+	}
+	if hasChat {
+		chat.SetSegment("dnd", "  [yellow]🔕 dnd "+label+"[-]")
+	}
+	ac.sendSystem(fmt.Sprintf("Do Not Disturb ON (%s) — bell silenced. /dnd off to end early.", label))
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"cli-client/models"
+	"cli-client/ui"
+)
+
+// ── Local echo ────────────────────────────────────────────────────────────────
+//
+// Our chat messages are shown the moment they are sent, before the relay has
+// them. Each line stays linked to its send: once the relay accepts the
+// message the line takes the relay's time, and if it does not, the line
+// turns red with the reason until /retry sends it again in place.
+
+// maxFailed is how many unsent messages /retry remembers.
+const maxFailed = 50
+
+// failedSend is one of our messages the relay did not accept.
+type failedSend struct {
+	msg   *models.Message
+	parts []string
+	key   string // conversationKey it was sent in
+}
+
+// relayChat sends msg, already on screen, as parts. The relay echoes it back
+// to us; NetworkClient deduplicates via sentIDs. Long messages go as
+// numbered parts; receipts track the last one.
+// Must be called from the tview event loop.
+func (ac *AppController) relayChat(msg *models.Message, parts []string) {
+	if ac.holdSend(msg, parts) {
+		return // see quality.go
+	}
+	switch {
+	case ac.lan != nil:
+		var id string
+		for _, part := range parts {
+			id = ac.lan.Send(msgTypeChat, msg.Username, part, msg.Color)
+		}
+		ac.trackSent(msg.ID, id)
+	case ac.netClient != nil:
+		key := ac.conversationKey()
+		ac.netClient.SendParts(msg.Username, parts, msg.Color, func(id string, at time.Time, err error) {
+			ui.SafeQueueUpdate(ac.app, "sendDone", func() {
+				ac.sendDone(msg, parts, key, id, at, err)
+			})
+		})
+	}
+}
+
+// sendDone reconciles the line of msg with how its send ended.
+// Must be called from the tview event loop.
+func (ac *AppController) sendDone(msg *models.Message, parts []string, key, id string, at time.Time, err error) {
+	switch {
+	case err != nil:
+		ac.failSend(&failedSend{msg: msg, parts: parts, key: key}, err.Error())
+		if transportFailure(err) {
+			ac.noteSendFail() // a refusal says nothing about the link
+		}
+		return
+	case at.IsZero() || at.Equal(msg.Timestamp):
+		ac.trackSent(msg.ID, id)
+		return
+	default:
+		ac.trackSent(msg.ID, id)
+		msg.Timestamp = at
+	}
+	for _, sink := range ac.messageSinks() {
+		sink.UpdateMessage(msg)
+	}
+}
+
+// failSend marks f's line as not sent, for /retry.
+func (ac *AppController) failSend(f *failedSend, reason string) {
+	f.msg.Failed = reason
+	ac.failed = append(ac.failed, f)
+	if len(ac.failed) > maxFailed {
+		ac.failed = ac.failed[1:]
+	}
+	for _, sink := range ac.messageSinks() {
+		sink.UpdateMessage(f.msg)
+	}
+}
+
+// retrySends handles /retry: our messages in this conversation that the
+// relay did not accept are sent again, oldest first, on the lines they
+// already have. Must be called from the tview event loop.
+func (ac *AppController) retrySends() {
+	if ac.lan == nil && ac.netClient == nil {
+		ac.sendSystem("Not connected to a relay.")
+		return
+	}
+	key := ac.conversationKey()
+	var keep []*failedSend
+	n := 0
+	for _, f := range ac.failed {
+		if f.key != key {
+			keep = append(keep, f)
+			continue
+		}
+		f.msg.Failed = ""
+		for _, sink := range ac.messageSinks() {
+			sink.UpdateMessage(f.msg)
+		}
+		ac.relayChat(f.msg, f.parts)
+		n++
+	}
+	ac.failed = keep
+	switch n {
+	case 0:
+		ac.sendSystem("Nothing to retry — the relay accepted everything you sent here.")
+	case 1:
+		ac.sendSystem("Sending your message again…")
+	default:
+		ac.sendSystem(fmt.Sprintf("Sending %d messages again…", n))
+	}
+}
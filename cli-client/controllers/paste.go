@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	"cli-client/ui"
+)
+
+// ── Large pastes ──────────────────────────────────────────────────────────────
+//
+// A paste of paste_lines lines or more is not typed into the single-line input;
+// main asks whether to send it as one code block, split it into one message per
+// line, or drop it.
+
+// pasteLineGap paces split pastes below the relay's rate limit.
+const pasteLineGap = 300 * time.Millisecond
+
+// PasteLines returns the number of non-empty lines in text.
+func PasteLines(text string) int {
+	n := 0
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// SendCodeBlock sends text as one message that clients show line by line.
+// Must be called from the tview event loop.
+func (ac *AppController) SendCodeBlock(text string) {
+	text = strings.Trim(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if ac.App.CurrentUser == nil || strings.TrimSpace(text) == "" {
+		return
+	}
+	ac.lastInput = time.Now()
+	ac.sendChat("```\n" + text + "\n```")
+}
+
+// SendLines sends every non-empty line of text as its own message, in order
+// and paced by pasteLineGap. Must be called from the tview event loop.
+func (ac *AppController) SendLines(text string) {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	ac.lastInput = time.Now()
+	ac.sendLines(lines)
+}
+
+func (ac *AppController) sendLines(lines []string) {
+	if len(lines) == 0 || ac.App.CurrentUser == nil {
+		return
+	}
+	ac.sendChat(lines[0])
+	if len(lines) == 1 {
+		return
+	}
+	time.AfterFunc(pasteLineGap, func() {
+		ui.SafeQueueUpdateDraw(ac.app, "sendLines", func() { ac.sendLines(lines[1:]) })
+	})
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"cli-client/models"
+	"cli-client/views"
+)
+
+// ── Polls ─────────────────────────────────────────────────────────────────────
+//
+// /poll "question" option option… broadcasts an opPoll control frame, which
+// every client shows as a bar chart. /vote broadcasts an opVote frame, sent
+// as a "reaction" message on typed relays, and each client moves the voter's
+// vote and redraws the chart in place. Polls and votes are keyed by a short
+// random ID, so echoes and repeats change nothing. A client only knows the
+// polls posted while it was connected.
+
+const (
+	opPoll = "poll"
+	opVote = "vote"
+)
+
+const (
+	maxPollQuestion = 200 // runes
+	maxPollOption   = 40  // runes
+	maxPollID       = 16
+)
+
+// createPoll handles /poll. Must be called from the tview event loop.
+func (ac *AppController) createPoll(arg string) {
+	if ac.App.CurrentUser == nil {
+		ac.sendSystem("No user logged in.")
+		return
+	}
+	args, ok := splitQuoted(arg)
+	if !ok || len(args) < 3 || len(args) > 1+models.MaxPollOptions || !validPoll(args[0], args[1:]) {
+		ac.sendSystem(fmt.Sprintf(`Usage: /poll "question" <option> <option>…  —  2 to %d options; quote any with spaces`, models.MaxPollOptions))
+		return
+	}
+	if !ac.linked() {
+		ac.sendSystem("Not connected to a relay.")
+		return
+	}
+	me := ac.App.CurrentUser.Username
+	color := ac.App.GetUserColorTag(me)
+	id := fmt.Sprintf("%04x", rand.Intn(1<<16))
+	ac.showPoll(me, color, models.NewPoll(id, args[0], args[1:]))
+	ac.sendControl(controlFrame{Op: opPoll, Poll: id, Color: models.ColorName(color), Text: args[0], Options: args[1:]})
+}
+
+// vote handles /vote. Must be called from the tview event loop.
+func (ac *AppController) vote(arg string) {
+	if ac.App.CurrentUser == nil {
+		ac.sendSystem("No user logged in.")
+		return
+	}
+	parts := strings.SplitN(strings.TrimSpace(arg), " ", 2)
+	if len(parts) < 2 {
+		ac.sendSystem("Usage: /vote <poll> <n|option>  —  the poll ID is shown under it")
+		return
+	}
+	msg, ok := ac.polls[strings.ToLower(parts[0])]
+	if !ok {
+		ac.sendSystem("No poll " + views.Escape(parts[0]) + " in this session.")
+		return
+	}
+	p := msg.Poll
+	choice := strings.Trim(strings.TrimSpace(parts[1]), `"`)
+	option := -1
+	if n, err := strconv.Atoi(choice); err == nil {
+		option = n - 1
+	} else {
+		for i, o := range p.Options {
+			if strings.EqualFold(o, choice) {
+				option = i
+			}
+		}
+	}
+	if option < 0 || option >= len(p.Options) {
+		ac.sendSystem(fmt.Sprintf("Poll %s has options 1 to %d.", p.ID, len(p.Options)))
+		return
+	}
+	ac.applyVote(ac.App.CurrentUser.Username, p.ID, option)
+	ac.sendControl(controlFrame{Op: opVote, Poll: p.ID, Choice: option + 1})
+}
+
+// handlePoll shows a poll broadcast by another client.
+// Must be called from the tview event loop.
+func (ac *AppController) handlePoll(from string, f *controlFrame) {
+	if _, seen := ac.polls[f.Poll]; seen || f.Poll == "" || len(f.Poll) > maxPollID {
+		return // our own echoed back, a repeat, or junk
+	}
+	if ac.isIgnored(from) {
+		return
+	}
+	if len(f.Options) < 2 || len(f.Options) > models.MaxPollOptions || !validPoll(f.Text, f.Options) {
+		return
+	}
+	color := views.ColorTag(models.ParseColorToTag(f.Color))
+	if f.Color == "" {
+		color = ac.App.GetUserColorTag(from)
+	}
+	ac.App.Session.RecordReceived(f.Text)
+	ac.showPoll(from, color, models.NewPoll(f.Poll, f.Text, f.Options))
+	ac.noteActivity()
+	ac.notify(from, f.Text)
+}
+
+// handleVote counts a vote broadcast by another client.
+// Must be called from the tview event loop.
+func (ac *AppController) handleVote(from string, f *controlFrame) {
+	ac.applyVote(from, f.Poll, f.Choice-1)
+}
+
+// showPoll adds a poll to the history and view.
+func (ac *AppController) showPoll(from, color string, p *models.Poll) {
+	msg := models.NewMessage(from, p.Question)
+	msg.Color = color
+	msg.Poll = p
+	ac.polls[p.ID] = msg
+	ac.App.AddMessage(msg)
+	ac.App.History.Add(ac.conversationKey(), msg)
+	for _, sink := range ac.messageSinks() {
+		sink.AddMessage(msg)
+	}
+}
+
+// applyVote records voter's vote and redraws the poll. Votes for polls this
+// client never saw are dropped.
+func (ac *AppController) applyVote(voter, id string, option int) {
+	msg, ok := ac.polls[id]
+	if !ok || !msg.Poll.Vote(voter, option) {
+		return
+	}
+	for _, sink := range ac.messageSinks() {
+		sink.UpdateMessage(msg)
+	}
+}
+
+// validPoll reports whether question and options are non-empty single
+// lines within the length limits.
+func validPoll(question string, options []string) bool {
+	ok := func(s string, max int) bool {
+		return strings.TrimSpace(s) != "" && !strings.ContainsAny(s, "\r\n") && len([]rune(s)) <= max
+	}
+	if !ok(question, maxPollQuestion) {
+		return false
+	}
+	for _, o := range options {
+		if !ok(o, maxPollOption) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitQuoted splits s at spaces, keeping "double-quoted" runs together.
+// ok is false if a quote is left open.
+func splitQuoted(s string) (args []string, ok bool) {
+	var b strings.Builder
+	quoted, started := false, false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted, started = !quoted, true
+		case r == ' ' && !quoted:
+			if started {
+				args = append(args, b.String())
+				b.Reset()
+				started = false
+			}
+		default:
+			b.WriteRune(r)
+			started = true
+		}
+	}
+	if quoted {
+		return nil, false
+	}
+	if started {
+		args = append(args, b.String())
+	}
+	return args, true
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/bits"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// ── Proof of work ─────────────────────────────────────────────────────────────
+//
+// Relays started with -pow-bits answer a send without proof of work with 428
+// and a challenge: find a nonce such that SHA-256(challenge+nonce) starts with
+// that many zero bits. Each accepted send returns the challenge for the next
+// one, so after the first message only the hashing is added, not a round trip.
+
+// maxPowBits is the hardest challenge we attempt; each bit doubles the work,
+// and 24 bits already take a second or two on a slow machine.
+const maxPowBits = 24
+
+// errPowRefused means the relay's challenge was too hard or kept failing.
+var errPowRefused = errors.New("relay demands more proof of work than this client will do")
+
+// powRequired is the 428 response body.
+type powRequired struct {
+	Challenge string `json:"challenge"`
+	Bits      int    `json:"bits"`
+}
+
+// OnProofOfWork sets a callback run when solving a challenge starts (true)
+// and ends (false). It runs on the send goroutine. Call before Start.
+func (nc *NetworkClient) OnProofOfWork(fn func(solving bool)) {
+	nc.onPow = fn
+}
+
+// notePowChallenge stores the challenge for the next send. bits == 0 keeps
+// the last known difficulty.
+func (nc *NetworkClient) notePowChallenge(challenge string, bits int) {
+	nc.powMu.Lock()
+	defer nc.powMu.Unlock()
+	nc.powChallenge = challenge
+	if bits > 0 {
+		nc.powBits = bits
+	}
+}
+
+// takePowChallenge returns the stored challenge, if any, and forgets it:
+// the relay accepts each one only once.
+func (nc *NetworkClient) takePowChallenge() (string, int) {
+	nc.powMu.Lock()
+	defer nc.powMu.Unlock()
+	c := nc.powChallenge
+	nc.powChallenge = ""
+	return c, nc.powBits
+}
+
+// postSend POSTs body to /api/send, solving proof-of-work challenges as the
+// relay asks for them. The caller closes the response body.
+func (nc *NetworkClient) postSend(body sendRequest) (*http.Response, error) {
+	challenge, bits := nc.takePowChallenge()
+	for attempt := 0; attempt < 3; attempt++ {
+		body.PowChallenge, body.PowNonce = "", ""
+		if challenge != "" {
+			nonce, err := nc.solvePow(challenge, bits)
+			if err != nil {
+				return nil, err
+			}
+			body.PowChallenge, body.PowNonce = challenge, nonce
+		}
+
+		bodyJSON, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := nc.post(nc.httpClient, "/api/send", bodyJSON)
+		if err != nil || resp.StatusCode != http.StatusPreconditionRequired {
+			return resp, err
+		}
+
+		var pr powRequired
+		err = json.NewDecoder(resp.Body).Decode(&pr)
+		drainClose(resp.Body)
+		if err != nil || pr.Challenge == "" {
+			return nil, fmt.Errorf("bad proof-of-work challenge from relay")
+		}
+		log.Printf("TRACE postSend: relay wants proof of work, bits=%d", pr.Bits)
+		challenge, bits = pr.Challenge, pr.Bits
+		nc.powMu.Lock()
+		nc.powBits = bits // later challenges arrive without it
+		nc.powMu.Unlock()
+	}
+	return nil, errPowRefused
+}
+
+// solvePow finds a nonce for challenge. It gives up if the client is
+// stopped or the difficulty is above maxPowBits.
+func (nc *NetworkClient) solvePow(challenge string, bits int) (string, error) {
+	if bits > maxPowBits {
+		return "", errPowRefused
+	}
+	if nc.onPow != nil {
+		nc.onPow(true)
+		defer nc.onPow(false)
+	}
+
+	buf := []byte(challenge)
+	for n := uint64(0); ; n++ {
+		if n&0xffff == 0 && atomic.LoadInt32(&nc.stopped) == 1 {
+			return "", errors.New("client stopped")
+		}
+		buf = strconv.AppendUint(buf[:len(challenge)], n, 10)
+		sum := sha256.Sum256(buf)
+		if leadingZeroBits(sum[:]) >= bits {
+			return string(buf[len(challenge):]), nil
+		}
+	}
+}
+
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, x := range b {
+		if x != 0 {
+			return n + bits.LeadingZeros8(x)
+		}
+		n += 8
+	}
+	return n
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cli-client/models"
+	"cli-client/views"
+)
+
+// ── Presence ──────────────────────────────────────────────────────────────────
+//
+// Presence events are broadcast control frames (To == "", wire type
+// "presence" on typed relays) announcing that a user joined, left, went away
+// or came back, or changed their nick or color. They are rendered as dim
+// system lines unless /quiet is on, and keep AppState.Users roughly in sync.
+
+const (
+	opJoin  = "join"
+	opLeave = "leave"
+	opNick  = "nick"
+	opColor = "color"
+)
+
+// leaveTimeout bounds how long shutdown waits for the leave announcement.
+const leaveTimeout = 2 * time.Second
+
+// announce broadcasts a presence event for the local user.
+// Must be called from the tview event loop.
+func (ac *AppController) announce(op string) {
+	if ac.App.CurrentUser == nil {
+		return
+	}
+	me := ac.App.CurrentUser.Username
+	f := controlFrame{Op: op, Color: ac.App.GetUserColorTag(me)}
+	if op == opJoin {
+		f.Zone = ac.sharedZone()
+		ac.signIdentity(&f)
+	}
+	ac.sendControl(f)
+}
+
+// announceLeave sends the leave event synchronously so it reaches the relay
+// before the process exits. Safe to call after the tview app has stopped.
+func (ac *AppController) announceLeave() {
+	if ac.App.CurrentUser == nil {
+		return
+	}
+	me := ac.App.CurrentUser.Username
+	f := controlFrame{Op: opLeave}
+	if ac.lan != nil {
+		ac.lan.SendWait(msgTypePresence, me, encodeControlPayload(f), ac.App.GetUserColorTag(me))
+		return
+	}
+	if ac.netClient == nil {
+		return
+	}
+	ac.relayLeave(ac.netClient)()
+}
+
+// relayLeave returns a func that sends the leave event to the relay through
+// nc and waits for it. What it needs is read now, so the func may run on
+// any goroutine. Call from the tview event loop, or once it has stopped.
+func (ac *AppController) relayLeave(nc *NetworkClient) func() {
+	me := ac.App.CurrentUser.Username
+	color := ac.App.GetUserColorTag(me)
+	f := controlFrame{Op: opLeave}
+	typ, content := msgTypeChat, encodeControl(f)
+	if ac.typedFrames() {
+		typ, content = msgTypePresence, encodeControlPayload(f)
+	}
+	return func() { nc.SendMessageWait(typ, me, content, color, leaveTimeout) }
+}
+
+// handlePresence renders and records a broadcast presence event.
+// Must be called from the tview event loop.
+func (ac *AppController) handlePresence(from string, f *controlFrame) {
+	if ac.App.CurrentUser != nil && from == ac.App.CurrentUser.Username {
+		return // our own announcement echoed back
+	}
+
+	colorTag := views.ColorTag(models.ParseColorToTag(f.Color))
+	if f.Color == "" {
+		colorTag = ac.App.GetUserColorTag(from)
+	}
+	name := ac.displayName(from)
+
+	var line string
+	switch f.Op {
+	case opJoin:
+		u := ac.trackUser(from)
+		u.IsOnline = true
+		u.Away = false
+		u.Color = colorTag
+		ac.noteZone(from, f.Zone)
+		ac.checkIdentity(from, f)
+		line = fmt.Sprintf("→ %s%s[-] joined", colorTag, name)
+
+	case opLeave:
+		ac.trackUser(from).IsOnline = false
+		line = fmt.Sprintf("← %s%s[-] left", colorTag, name)
+
+	case opNick:
+		if f.Old == "" {
+			return
+		}
+		if old, ok := ac.App.Users[f.Old]; ok {
+			old.IsOnline = false
+		}
+		u := ac.trackUser(from)
+		u.IsOnline = true
+		u.Color = colorTag
+		ac.checkIdentity(from, f)
+		line = fmt.Sprintf("%s%s[-] is now known as %s%s[-]",
+			colorTag, ac.displayName(f.Old), colorTag, name)
+
+	case opAway:
+		ac.trackUser(from).Away = true
+		line = fmt.Sprintf("🌙 %s%s[-] is away", colorTag, name)
+
+	case opBack:
+		ac.trackUser(from).Away = false
+		line = fmt.Sprintf("%s%s[-] is back", colorTag, name)
+
+	case opColor:
+		ac.trackUser(from).Color = colorTag
+		line = fmt.Sprintf("%s%s[-] changed color → %s%s[-]",
+			colorTag, name, colorTag, strings.Trim(colorTag, "[]"))
+
+	default:
+		return
+	}
+
+	if !ac.quietPresence {
+		ac.sendSystem("[dim]" + line + "[-]")
+	}
+}
+
+// trackUser returns the AppState entry for username, creating it if needed.
+func (ac *AppController) trackUser(username string) *models.User {
+	u, ok := ac.App.Users[username]
+	if !ok {
+		u = models.NewUser(username)
+		ac.App.Users[username] = u
+	}
+	u.LastSeen = time.Now()
+	ac.seeContact(username, false)
+	return u
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"cli-client/models"
+)
+
+// ── Compose preview ───────────────────────────────────────────────────────────
+//
+// The chat view's F4 strip previews the message being typed (see
+// views/preview.go). ComposePreview builds it the way sendChat would, and
+// notes what sending would do besides showing it.
+
+// ComposePreview returns the message text would be sent as, and a note on
+// sending it; nil before login. Must be called from the tview event loop.
+func (ac *AppController) ComposePreview(text string) (*models.Message, string) {
+	if ac.App.CurrentUser == nil {
+		return nil, ""
+	}
+	me := ac.App.CurrentUser.Username
+	msg := models.NewMessage(me, text)
+	msg.Color = ac.App.GetUserColorTag(me)
+
+	note := ""
+	switch parts := len(splitMessage(text, ac.maxContent())); {
+	case parts > maxParts:
+		note = fmt.Sprintf("too long — %d bytes, more than %d parts", len(text), maxParts)
+	case parts > 1:
+		note = fmt.Sprintf("goes in %d parts", parts)
+	}
+	if mentionsHere(text) {
+		if wait := hereCooldown - time.Since(ac.lastHere); wait > 0 {
+			note = fmt.Sprintf("@here is on cooldown for %v", wait.Round(time.Second))
+		} else if note == "" {
+			note = "@here rings everyone"
+		}
+	}
+	return msg, note
+}
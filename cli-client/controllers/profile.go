@@ -0,0 +1,294 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"cli-client/crypto"
+	"cli-client/models"
+	"cli-client/paths"
+
+	"golang.org/x/term"
+)
+
+// ── cli-client export-profile / import-profile ────────────────────────────────
+//
+// Moves a user's local state to another machine as one passphrase-encrypted
+// file. Every file in profileFiles that exists is packed, and the identity
+// keys of accounts other than main; state files added later only need to
+// be listed there. The passphrase is read from the
+// terminal, or from TTC_PROFILE_PASSPHRASE for scripted use.
+
+const (
+	profileFormat     = "ttc-profile"
+	profileVersion    = 1
+	profileIterations = 600000 // PBKDF2 rounds — slows down guessing
+	profilePassEnv    = "TTC_PROFILE_PASSPHRASE"
+)
+
+// maxProfileIterations is the most rounds an archive may ask for, so a
+// damaged or crafted one cannot keep import-profile busy for hours.
+const maxProfileIterations = 10 * profileIterations
+
+// profileFiles is the local state that makes up a profile. Archives name
+// the files without a directory, so they unpack wherever the paths are.
+var profileFiles = []string{
+	models.ConfigFile,
+	models.DraftsFile,
+	models.BookmarksFile,
+	models.ContactsFile,
+	identityFile,
+	scheduleFile,
+}
+
+// accountKeyFiles returns the identity key files of accounts other than
+// main found on this machine, sorted.
+func accountKeyFiles() []string {
+	matches, _ := filepath.Glob(paths.Data("ttc_identity_*.key"))
+	var names []string
+	for _, m := range matches {
+		if name := filepath.Base(m); isAccountIdentityFile(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// profilePath returns where the profile file name lives on this machine.
+func profilePath(name string) string {
+	if name == models.ConfigFile {
+		return paths.Config(name)
+	}
+	return paths.Data(name)
+}
+
+// AdoptLegacyFiles moves profile files left in the working directory by
+// versions that kept everything there, unless the new place already has one.
+func AdoptLegacyFiles() {
+	for _, name := range profileFiles {
+		dst := profilePath(name)
+		if samePath(name, dst) {
+			continue
+		}
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+		if _, err := os.Stat(name); err != nil {
+			continue
+		}
+		if err := os.Rename(name, dst); err != nil {
+			log.Printf("AdoptLegacyFiles: %v", err)
+			continue
+		}
+		log.Printf("AdoptLegacyFiles: moved %s to %s", name, dst)
+	}
+}
+
+// samePath reports whether two paths name the same file.
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// profileArchive is the on-disk export: the encrypted payload plus what is
+// needed to re-derive its key.
+type profileArchive struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	Created    string `json:"created"`
+	Salt       string `json:"salt"` // base64
+	Iterations int    `json:"iterations"`
+	Data       string `json:"data"` // crypto.GlobalCrypto ciphertext of profilePayload
+}
+
+// profilePayload maps file name → contents.
+type profilePayload map[string][]byte
+
+// RunExportProfile is the entry point for "cli-client export-profile".
+// Returns the exit code.
+func RunExportProfile(args []string) int {
+	fs := flag.NewFlagSet("export-profile", flag.ContinueOnError)
+	out := fs.String("o", "ttc_profile.ttcp", "archive to write")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	payload := profilePayload{}
+	for _, name := range append(append([]string(nil), profileFiles...), accountKeyFiles()...) {
+		data, err := os.ReadFile(profilePath(name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "export-profile: %v\n", err)
+			return 1
+		}
+		payload[name] = data
+	}
+	if len(payload) == 0 {
+		fmt.Fprintln(os.Stderr, "export-profile: nothing to export in this directory")
+		return 1
+	}
+
+	pass, err := readPassphrase(true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export-profile: %v\n", err)
+		return 1
+	}
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		fmt.Fprintf(os.Stderr, "export-profile: %v\n", err)
+		return 1
+	}
+	plain, _ := json.Marshal(payload)
+	sealed, err := crypto.NewPassphraseCrypto(pass, salt, profileIterations).Encrypt(plain)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export-profile: %v\n", err)
+		return 1
+	}
+
+	archive, _ := json.MarshalIndent(profileArchive{
+		Format:     profileFormat,
+		Version:    profileVersion,
+		Created:    time.Now().Format(time.RFC3339),
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Iterations: profileIterations,
+		Data:       sealed,
+	}, "", "  ")
+	if err := os.WriteFile(*out, archive, 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "export-profile: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Exported %d file(s) to %s\n", len(payload), *out)
+	return 0
+}
+
+// RunImportProfile is the entry point for "cli-client import-profile".
+// Returns the exit code.
+func RunImportProfile(args []string) int {
+	fs := flag.NewFlagSet("import-profile", flag.ContinueOnError)
+	force := fs.Bool("force", false, "overwrite existing files")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: cli-client import-profile [-force] <archive>")
+		return 2
+	}
+
+	raw, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import-profile: %v\n", err)
+		return 1
+	}
+	var a profileArchive
+	if err := json.Unmarshal(raw, &a); err != nil || a.Format != profileFormat {
+		fmt.Fprintf(os.Stderr, "import-profile: %s is not a profile archive\n", fs.Arg(0))
+		return 1
+	}
+	if a.Version > profileVersion {
+		fmt.Fprintf(os.Stderr, "import-profile: archive version %d is newer than this client supports\n", a.Version)
+		return 1
+	}
+	salt, err := base64.StdEncoding.DecodeString(a.Salt)
+	if err != nil || a.Iterations < 1 || a.Iterations > maxProfileIterations {
+		fmt.Fprintln(os.Stderr, "import-profile: archive header is damaged")
+		return 1
+	}
+
+	pass, err := readPassphrase(false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import-profile: %v\n", err)
+		return 1
+	}
+	plain, err := crypto.NewPassphraseCrypto(pass, salt, a.Iterations).Decrypt(a.Data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import-profile: wrong passphrase or damaged archive")
+		return 1
+	}
+	var payload profilePayload
+	if err := json.Unmarshal(plain, &payload); err != nil {
+		fmt.Fprintf(os.Stderr, "import-profile: %v\n", err)
+		return 1
+	}
+
+	// Only ever write the known state files — never a path from the archive.
+	names := append([]string(nil), profileFiles...)
+	for name := range payload {
+		if isAccountIdentityFile(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[len(profileFiles):])
+	var existing []string
+	for _, name := range names {
+		if _, ok := payload[name]; !ok {
+			continue
+		}
+		if _, err := os.Stat(profilePath(name)); err == nil {
+			existing = append(existing, profilePath(name))
+		}
+	}
+	if len(existing) > 0 && !*force {
+		fmt.Fprintf(os.Stderr, "import-profile: would overwrite %v — rerun with -force\n", existing)
+		return 1
+	}
+	n := 0
+	for _, name := range names {
+		data, ok := payload[name]
+		if !ok {
+			continue
+		}
+		if err := os.WriteFile(profilePath(name), data, 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "import-profile: %v\n", err)
+			return 1
+		}
+		n++
+	}
+	fmt.Printf("Imported %d file(s) from %s (exported %s)\n", n, fs.Arg(0), a.Created)
+	return 0
+}
+
+// readPassphrase reads the archive passphrase without echo, asking twice
+// when confirm is set.
+func readPassphrase(confirm bool) (string, error) {
+	if pass := os.Getenv(profilePassEnv); pass != "" {
+		return pass, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no terminal to ask for the passphrase — set %s", profilePassEnv)
+	}
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	pass, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(pass) == 0 {
+		return "", errors.New("empty passphrase")
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if string(again) != string(pass) {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return string(pass), nil
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"cli-client/crypto"
+	"cli-client/qr"
+	"cli-client/views"
+)
+
+// ── QR codes ──────────────────────────────────────────────────────────────────
+//
+// /qr server shows the relay URL as a QR code, for a friend to join the same
+// relay from their phone; /qr invite shows an invitation to the conversation
+// (see invite.go); /qr fingerprint shows our identity key's fingerprint, for
+// them to compare with /verify. The code is drawn with half
+// blocks, dark on light with the quiet zone scanners need, one row of text
+// per system line.
+
+// qrQuiet is the quiet zone around a code, in modules.
+const qrQuiet = 4
+
+// showQR handles /qr. Must be called from the tview event loop.
+func (ac *AppController) showQR(arg string) {
+	var text, caption string
+	switch strings.ToLower(strings.TrimSpace(arg)) {
+	case "server":
+		if ac.lan != nil {
+			ac.sendSystem("LAN mode has no relay to share — peers on the network find each other.")
+			return
+		}
+		text, caption = DefaultServerURL, "Relay "+DefaultServerURL
+	case "invite":
+		text = ac.ownInvite().String()
+		caption = "Invitation " + text
+	case "fingerprint":
+		if ac.identity == nil {
+			ac.sendSystem("No identity key — see the log for why.")
+			return
+		}
+		text = crypto.Fingerprint(ac.identity.PublicKey())
+		caption = "Your identity key fingerprint " + text
+	default:
+		ac.sendSystem("Usage: /qr server|invite|fingerprint")
+		return
+	}
+
+	code, err := qr.Encode(text)
+	if err != nil {
+		ac.sendSystem("[red]No QR code:[-] " + views.Escape(err.Error()))
+		return
+	}
+	lines := code.Lines(qrQuiet)
+	ac.sendSystem("[yellow]" + views.Escape(caption) + "[-]")
+	if chat, ok := ac.chatView(); ok {
+		// System lines start with "▸ ", two columns.
+		if width := chat.MessageWidth(); width > 0 && width < len([]rune(lines[0]))+2 {
+			ac.sendSystem(fmt.Sprintf("[red]The transcript is %d columns wide and the code needs %d — widen the terminal and try again.[-]",
+				width, len([]rune(lines[0]))+2))
+			return
+		}
+	}
+	for _, line := range lines {
+		ac.sendSystem("[black:white]" + line + "[-:-]")
+	}
+}
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"cli-client/models"
+	"cli-client/ui"
+)
+
+// ── Connection quality ────────────────────────────────────────────────────────
+//
+// Latency, failed reconnects and sends that failed on the way — not those
+// the relay refused — over the last qualityWindow add up to a grade shown
+// in the header: good, degraded or poor. Latency is the relay heartbeat's
+// round trip where there is one, the latency probe's otherwise. While the
+// grade is poor the client saves bandwidth — the stats
+// poller skips its fetches — and holds chat messages back instead of letting
+// each one fail: they keep their lines, marked held, and go out together, in
+// order, once the grade is better. A held message meant for a conversation
+// that was left in the meantime fails as usual, for /retry there.
+
+// qualityWindow is how far back failures count.
+const qualityWindow = 2 * time.Minute
+
+// qualityTick is how often the grade is taken again on its own, so that
+// failures age out of the window with nothing else happening: on Tor there
+// is no latency probe, and held sends make no events.
+const qualityTick = 10 * time.Second
+
+// Latency thresholds, in milliseconds.
+const (
+	degradedLatency = 300
+	poorLatency     = 1000
+)
+
+type connQuality int
+
+const (
+	qualityGood connQuality = iota
+	qualityDegraded
+	qualityPoor
+)
+
+func (q connQuality) String() string {
+	switch q {
+	case qualityDegraded:
+		return "degraded"
+	case qualityPoor:
+		return "poor"
+	}
+	return "good"
+}
+
+// classify grades a connection: latencyMs is the latest round trip (-1 =
+// unknown), linkFails and sendFails the failures in the window, and down
+// whether the receive link is down now.
+func classify(latencyMs, linkFails, sendFails int, down bool) connQuality {
+	switch {
+	case down && linkFails >= 2, sendFails >= 3, latencyMs >= poorLatency:
+		return qualityPoor
+	case down, linkFails > 0, sendFails > 0, latencyMs >= degradedLatency:
+		return qualityDegraded
+	}
+	return qualityGood
+}
+
+// qualityMeter keeps what the grade is made of. tview event loop only.
+type qualityMeter struct {
+	grade     connQuality
+	assessed  bool
+	linkFails []time.Time
+	sendFails []time.Time
+	held      []*failedSend // chat messages waiting for a better grade, oldest first
+	releasing bool          // a held message is on its way; the next follows its answer
+	stop      chan struct{} // ends the qualityTick loop; nil = not running
+}
+
+// startQualityTicker grades the connection every qualityTick until
+// stopQualityTicker. Must be called from the tview event loop.
+func (ac *AppController) startQualityTicker() {
+	ac.stopQualityTicker()
+	stop := make(chan struct{})
+	ac.quality.stop = stop
+	ui.SafeGo("qualityTicker", func() {
+		ticker := time.NewTicker(qualityTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ui.SafeQueueUpdateDraw(ac.app, "assessQuality", ac.assessQuality)
+			}
+		}
+	})
+}
+
+// stopQualityTicker ends the loop startQualityTicker began, if any.
+func (ac *AppController) stopQualityTicker() {
+	if ac.quality.stop != nil {
+		close(ac.quality.stop)
+		ac.quality.stop = nil
+	}
+}
+
+// recent drops the times in list older than the window.
+func recent(list []time.Time, now time.Time) []time.Time {
+	i := 0
+	for i < len(list) && now.Sub(list[i]) > qualityWindow {
+		i++
+	}
+	return list[i:]
+}
+
+// noteLinkFail records a failed reconnect. Must be called from the tview
+// event loop.
+func (ac *AppController) noteLinkFail() {
+	ac.quality.linkFails = append(ac.quality.linkFails, time.Now())
+	ac.assessQuality()
+}
+
+// noteSendFail records a send that failed on the way: the relay was
+// unreachable or failing (see transportFailure). Must be called from the
+// tview event loop.
+func (ac *AppController) noteSendFail() {
+	ac.quality.sendFails = append(ac.quality.sendFails, time.Now())
+	ac.assessQuality()
+}
+
+// latencyMs returns the latest round trip, or -1.
+func (ac *AppController) latencyMs() int {
+	if ac.netClient != nil {
+		if rtt, ok := ac.netClient.HeartbeatRTT(); ok {
+			return int(rtt.Milliseconds())
+		}
+	}
+	if ac.latencyCtrl != nil {
+		return ac.latencyCtrl.Current()
+	}
+	return -1
+}
+
+// assessQuality grades the connection again and acts on a change. Must be
+// called from the tview event loop.
+func (ac *AppController) assessQuality() {
+	m := &ac.quality
+	if ac.lan != nil || ac.netClient == nil || ac.App.CurrentUser == nil {
+		if m.assessed {
+			m.assessed = false
+			ac.saver.Store(false)
+			if chat, ok := ac.chatView(); ok {
+				chat.SetConnectionQuality("")
+			}
+		}
+		return
+	}
+	now := time.Now()
+	m.linkFails, m.sendFails = recent(m.linkFails, now), recent(m.sendFails, now)
+	grade := classify(ac.latencyMs(), len(m.linkFails), len(m.sendFails), ac.reconnectAttempt > 0)
+	if m.assessed && grade == m.grade {
+		return
+	}
+	was := m.grade
+	m.grade, m.assessed = grade, true
+	ac.saver.Store(grade == qualityPoor)
+	if chat, ok := ac.chatView(); ok {
+		chat.SetConnectionQuality(grade.String())
+	}
+	switch {
+	case grade == qualityPoor:
+		ac.sendSystem("[red]Connection poor[-] — saving bandwidth, and holding what you send until it improves.")
+	case was == qualityPoor:
+		if n := len(m.held); n > 0 {
+			ac.sendSystem(fmt.Sprintf("Connection better — sending the %d held messages.", n))
+		} else {
+			ac.sendSystem("Connection better.")
+		}
+		ac.releaseHeld()
+	}
+}
+
+// holdSend keeps msg, already on screen, back while the connection is poor,
+// or behind the held messages still going out. It reports false if it did
+// not.
+func (ac *AppController) holdSend(msg *models.Message, parts []string) bool {
+	m := &ac.quality
+	poor := m.assessed && m.grade == qualityPoor
+	if ac.lan != nil || (!poor && len(m.held) == 0 && !m.releasing) {
+		return false
+	}
+	ac.quality.held = append(ac.quality.held, &failedSend{msg: msg, parts: parts, key: ac.conversationKey()})
+	if chat, ok := ac.chatView(); ok {
+		chat.SetLineSuffix(msg.ID, " [dim]⏸ held[-]")
+	}
+	return true
+}
+
+// releaseHeld starts sending the held messages, unless they are going out
+// already. Must be called from the tview event loop.
+func (ac *AppController) releaseHeld() {
+	if !ac.quality.releasing {
+		ac.releaseNext()
+	}
+}
+
+// releaseNext sends the oldest held message, and the next one once the
+// relay has answered, until none are left or the connection is poor again.
+func (ac *AppController) releaseNext() {
+	m := &ac.quality
+	m.releasing = false
+	for len(m.held) > 0 && m.grade != qualityPoor && ac.netClient != nil {
+		f := m.held[0]
+		m.held = m.held[1:]
+		if chat, ok := ac.chatView(); ok {
+			chat.SetLineSuffix(f.msg.ID, "")
+		}
+		if f.key != ac.conversationKey() {
+			ac.failSend(f, "held while you left the conversation")
+			continue
+		}
+		m.releasing = true
+		ac.netClient.SendParts(f.msg.Username, f.parts, f.msg.Color, func(id string, at time.Time, err error) {
+			ui.SafeQueueUpdate(ac.app, "releaseNext", func() {
+				ac.sendDone(f.msg, f.parts, f.key, id, at, err)
+				ac.releaseNext()
+			})
+		})
+		return
+	}
+}
+
+// rekeyHeld moves the held messages of conversation from to to, for a
+// failover to another relay of the same conversation.
+func (ac *AppController) rekeyHeld(from, to string) {
+	for _, f := range ac.quality.held {
+		if f.key == from {
+			f.key = to
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		latency, linkFails, sendFails int
+		down                          bool
+		want                          connQuality
+	}{
+		{40, 0, 0, false, qualityGood},
+		{-1, 0, 0, false, qualityGood},
+		{350, 0, 0, false, qualityDegraded},
+		{40, 1, 0, false, qualityDegraded},
+		{40, 0, 1, false, qualityDegraded},
+		{40, 1, 0, true, qualityDegraded},
+		{40, 2, 0, true, qualityPoor},
+		{40, 2, 0, false, qualityDegraded}, // back up after failing
+		{40, 0, 3, false, qualityPoor},
+		{1200, 0, 0, false, qualityPoor},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.latency, tt.linkFails, tt.sendFails, tt.down); got != tt.want {
+			t.Errorf("classify(%d, %d, %d, %v) = %v, want %v",
+				tt.latency, tt.linkFails, tt.sendFails, tt.down, got, tt.want)
+		}
+	}
+}
+
+func TestRecent(t *testing.T) {
+	now := time.Now()
+	list := []time.Time{now.Add(-3 * time.Minute), now.Add(-time.Minute), now}
+	if got := recent(list, now); len(got) != 2 || !got[0].Equal(list[1]) {
+		t.Errorf("recent kept %v", got)
+	}
+}
+
+func TestTransportFailure(t *testing.T) {
+	for err, want := range map[error]bool{
+		errUnreachable:                        true,
+		relayFailed(502):                      true,
+		errors.New("Slow mode: wait 5s"):      false,
+		errors.New("Muted for 1m0s"):          false,
+		errors.New("access key rejected"):     false,
+		fmt.Errorf("relay answered HTTP 400"): false,
+		errPowRefused:                         false,
+	} {
+		if got := transportFailure(err); got != want {
+			t.Errorf("transportFailure(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
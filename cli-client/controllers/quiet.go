@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"log"
+	"time"
+
+	"cli-client/ui"
+)
+
+// ── Quiet hours ───────────────────────────────────────────────────────────────
+//
+// The "quiet_hours" config window silences the bell the way /dnd does and
+// tells everyone we are away: an opAway presence event when it starts and
+// opBack when it ends — unless /away says so already. The footer shows 🌙
+// while it lasts. A timer re-checks the window at each boundary, so nothing
+// polls.
+
+const (
+	opAway = "away"
+	opBack = "back"
+)
+
+// applyQuietHours brings the quiet state in line with the clock and arms
+// the timer for the next boundary. Must be called from the tview event loop.
+func (ac *AppController) applyQuietHours() {
+	if ac.quietTimer != nil {
+		ac.quietTimer.Stop()
+		ac.quietTimer = nil
+	}
+	q := ac.App.Config.QuietHours
+	if !q.Enabled() || ac.App.CurrentUser == nil {
+		return
+	}
+
+	quiet, next := q.At(time.Now())
+	if quiet != ac.notifier.Quiet() {
+		ac.notifier.SetQuiet(quiet)
+		segment := ""
+		switch {
+		case quiet:
+			segment = "  [blue]🌙 quiet until " + q.End + "[-]"
+			if !ac.away.on {
+				ac.announce(opAway)
+			}
+		case !ac.away.on:
+			ac.announce(opBack)
+		}
+		if chat, ok := ac.chatView(); ok {
+			chat.SetSegment("quiet", segment)
+		}
+		log.Printf("Quiet hours: quiet=%v until %s", quiet, next.Format("Jan 2 15:04"))
+	}
+
+	ac.quietTimer = time.AfterFunc(time.Until(next), func() {
+		ui.SafeQueueUpdateDraw(ac.app, "applyQuietHours", ac.applyQuietHours)
+	})
+}
+
+// stopQuietHours disarms the quiet-hours timer.
+func (ac *AppController) stopQuietHours() {
+	if ac.quietTimer != nil {
+		ac.quietTimer.Stop()
+		ac.quietTimer = nil
+	}
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"cli-client/ui"
+)
+
+// ── Read receipts ─────────────────────────────────────────────────────────────
+//
+// Opt-in (/privacy receipts on). When the line of a chat message is on
+// screen — as it is added if the view is at the end, or once scrolled to if
+// the view is scrolled back — we queue its relay ID; lines skipped over
+// are never acked. Every receiptFlushDelay the queue is sent as one "seen"
+// control frame per original sender, or once the terminal has focus again if
+// it is away (see focus.go). A sender with receipts on counts the distinct
+// readers of each of its messages and shows "seen by N" on the line.
+// Receipts are reciprocal: with them off we neither send nor display any.
+// Until its line is on screen a message also counts as unread in the room
+// list (see conversations.go), receipts on or off.
+
+const opSeen = "seen"
+
+const (
+	receiptFlushDelay = 2 * time.Second
+	maxTrackedSent    = 500 // own messages remembered for receipt counting
+	maxUnseen         = 500 // lines not on screen yet, watched; the oldest are forgotten
+)
+
+// seenRef is an incoming message whose line has not been on screen yet.
+type seenRef struct {
+	sender, id string // for its read receipt
+	room       string // its conversation, for the unread count
+	pane       int    // the chat pane showing it, see split.go
+}
+
+// sentRecord links one of our messages on the wire to its line in the view.
+type sentRecord struct {
+	localID string
+	seenBy  map[string]bool
+}
+
+// trackSent remembers that our message localID went out as serverID.
+// Must be called from the tview event loop.
+func (ac *AppController) trackSent(localID, serverID string) {
+	if _, ok := ac.sent[serverID]; ok {
+		return
+	}
+	ac.sent[serverID] = &sentRecord{localID: localID, seenBy: map[string]bool{}}
+	ac.sentOrder = append(ac.sentOrder, serverID)
+	if len(ac.sentOrder) > maxTrackedSent {
+		delete(ac.sent, ac.sentOrder[0])
+		ac.sentOrder = ac.sentOrder[1:]
+	}
+}
+
+// awaitSight counts message id from sender as unread until its line,
+// lineID, is on screen, and then queues its receipt. Call it before the
+// line is added to the focused pane. Must be called from the tview event
+// loop.
+func (ac *AppController) awaitSight(sender, id, lineID string) {
+	split, ok := ac.splitView()
+	if !ok || (ac.App.CurrentUser != nil && sender == ac.App.CurrentUser.Username) {
+		return
+	}
+	room := ac.conversationKey()
+	ac.unseen[lineID] = seenRef{sender: sender, id: id, room: room, pane: split.ActivePane()}
+	ac.unread[room]++
+	ac.unseenOrder = append(ac.unseenOrder, lineID)
+	if len(ac.unseenOrder) > maxUnseen {
+		ac.forgetUnseen(ac.unseenOrder[0])
+		ac.unseenOrder = ac.unseenOrder[1:]
+	}
+	split.Active().WatchLine(lineID)
+	ac.showRooms()
+}
+
+// linesSeen queues the receipts of the lines ids, which just came on
+// screen, and takes them off the unread counts. Must be called from the
+// tview event loop.
+func (ac *AppController) linesSeen(ids []string) {
+	seen := false
+	for _, lineID := range ids {
+		if ref, ok := ac.forgetUnseen(lineID); ok {
+			ac.queueReceipt(ref.sender, ref.id)
+			seen = true
+		}
+	}
+	if seen {
+		ac.showRooms()
+	}
+}
+
+// forgetUnseen stops waiting for lineID to be on screen, and returns what
+// was waiting.
+func (ac *AppController) forgetUnseen(lineID string) (seenRef, bool) {
+	ref, ok := ac.unseen[lineID]
+	if !ok {
+		return ref, false
+	}
+	delete(ac.unseen, lineID)
+	if ac.unread[ref.room]--; ac.unread[ref.room] <= 0 {
+		delete(ac.unread, ref.room)
+	}
+	return ref, true
+}
+
+// forgetPane stops waiting for the lines of pane, about to be cleared.
+func (ac *AppController) forgetPane(pane int) {
+	for lineID, ref := range ac.unseen {
+		if ref.pane == pane {
+			ac.forgetUnseen(lineID)
+		}
+	}
+	ac.showRooms()
+}
+
+// queueReceipt records that message id from sender is now on screen.
+// Must be called from the tview event loop.
+func (ac *AppController) queueReceipt(sender, id string) {
+	if !ac.receiptsOn || id == "" || ac.App.CurrentUser == nil || sender == ac.App.CurrentUser.Username {
+		return
+	}
+	ac.pendingSeen[sender] = append(ac.pendingSeen[sender], id)
+	if ac.receiptTimer == nil && !ac.focus.away {
+		ac.receiptTimer = time.AfterFunc(receiptFlushDelay, func() {
+			ui.SafeQueueUpdate(ac.app, "flushReceipts", ac.flushReceipts)
+		})
+	}
+}
+
+// flushReceipts sends the queued acks, unless the terminal is out of focus:
+// then they wait until it is back (see focus.go). Runs in the tview event
+// loop.
+func (ac *AppController) flushReceipts() {
+	ac.receiptTimer = nil
+	if ac.focus.away {
+		return
+	}
+	pending := ac.pendingSeen
+	ac.pendingSeen = make(map[string][]string)
+	if !ac.receiptsOn {
+		return
+	}
+	for sender, ids := range pending {
+		ac.sendControl(controlFrame{Op: opSeen, To: sender, IDs: ids})
+	}
+}
+
+// handleSeen counts a reader for each of our messages listed in f.
+// Must be called from the tview event loop.
+func (ac *AppController) handleSeen(from string, f *controlFrame) {
+	if !ac.receiptsOn {
+		return
+	}
+	chat, hasChat := ac.chatView()
+	for _, id := range f.IDs {
+		rec, ok := ac.sent[id]
+		if !ok || rec.seenBy[from] {
+			continue
+		}
+		rec.seenBy[from] = true
+		if hasChat {
+			chat.SetLineSuffix(rec.localID, fmt.Sprintf(" [dim]✓ seen by %d[-]", len(rec.seenBy)))
+		}
+	}
+}
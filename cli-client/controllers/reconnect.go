@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"cli-client/ui"
+)
+
+// ── Reconnecting ──────────────────────────────────────────────────────────────
+//
+// After a failed exchange the receive loop waits before trying again, twice
+// as long each time up to maxBackoff. Each wait is jittered to between half
+// and all of that, so clients that lost a relay together do not all come
+// back in the same second. The {reconnect} footer segment counts the wait
+// down with the attempt number, and /reconnect cuts it short.
+
+// jitter returns a wait of between half and all of backoff.
+func jitter(backoff time.Duration) time.Duration {
+	half := backoff / 2
+	if half <= 0 {
+		return backoff
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// OnReconnect sets a callback run when the receive loop starts waiting to
+// reconnect, with when it will try and which attempt that is, and with a
+// zero time once it is connected again. It runs on the receive goroutine.
+// Call before Start.
+func (nc *NetworkClient) OnReconnect(fn func(at time.Time, attempt int)) {
+	nc.onReconnect = fn
+}
+
+func (nc *NetworkClient) notifyReconnect(at time.Time, attempt int) {
+	if nc.onReconnect != nil && !nc.isStopped() {
+		nc.onReconnect(at, attempt)
+	}
+}
+
+// Reconnect ends a reconnect wait now. Never blocks.
+func (nc *NetworkClient) Reconnect() {
+	nc.revive()
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// showReconnect records a reconnect wait from the network client and starts
+// the footer countdown; a zero at clears it. Safe to call from any goroutine.
+func (ac *AppController) showReconnect(nc *NetworkClient, at time.Time, attempt int) {
+	ui.SafeQueueUpdateDraw(ac.app, "showReconnect", func() {
+		if ac.netClient != nc {
+			return
+		}
+		ac.reconnectAt, ac.reconnectAttempt = at, attempt
+		ac.tickReconnect()
+		if attempt > 0 {
+			ac.noteLinkFail()
+		} else {
+			ac.assessQuality()
+		}
+		ac.checkFailover(attempt)
+	})
+}
+
+// tickReconnect redraws the {reconnect} segment and, while a wait is on,
+// arms itself for the next second. Must be called from the tview event loop.
+func (ac *AppController) tickReconnect() {
+	if ac.reconnectTimer != nil {
+		ac.reconnectTimer.Stop()
+		ac.reconnectTimer = nil
+	}
+	chat, ok := ac.chatView()
+	if !ok {
+		return
+	}
+	if ac.reconnectAttempt == 0 {
+		chat.SetSegment("reconnect", "")
+		return
+	}
+	left := time.Until(ac.reconnectAt)
+	if left <= 0 {
+		// Trying now; the next wait or the connection replaces this.
+		chat.SetSegment("reconnect", fmt.Sprintf("  [red]⟳ reconnecting… (attempt %d)[-]", ac.reconnectAttempt))
+		return
+	}
+	secs := int((left + time.Second - 1) / time.Second)
+	chat.SetSegment("reconnect", fmt.Sprintf("  [red]⟳ reconnecting in %ds… (attempt %d)[-]", secs, ac.reconnectAttempt))
+	ac.reconnectTimer = time.AfterFunc(left-time.Duration(secs-1)*time.Second, func() {
+		ui.SafeQueueUpdateDraw(ac.app, "tickReconnect", ac.tickReconnect)
+	})
+}
+
+// clearReconnect drops the countdown, for a relay client that is going
+// away. Must be called from the tview event loop.
+func (ac *AppController) clearReconnect() {
+	ac.reconnectAt, ac.reconnectAttempt = time.Time{}, 0
+	ac.tickReconnect()
+}
+
+// reconnect handles /reconnect. Must be called from the tview event loop.
+func (ac *AppController) reconnect() {
+	switch {
+	case ac.lan != nil:
+		ac.sendSystem("In LAN mode there is no relay to reconnect to.")
+	case ac.netClient == nil:
+		ac.sendSystem("Not connected to a relay.")
+	case ac.reconnectAttempt == 0:
+		ac.sendSystem("Connected — nothing to reconnect.")
+	default:
+		ac.sendSystem(fmt.Sprintf("Reconnecting now (attempt %d)…", ac.reconnectAttempt))
+		ac.reconnectAt = time.Now()
+		ac.tickReconnect()
+		ac.netClient.Reconnect()
+	}
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"cli-client/models"
+	"cli-client/ui"
+	"cli-client/views"
+)
+
+// ── Repeats ───────────────────────────────────────────────────────────────────
+//
+// When someone sends the same text several times in a row, only the first
+// is shown, with a ×N count after it that goes up with each repeat, instead
+// of a screenful of copies. Anything else received or sent in between
+// starts over. In browse mode (F3), "Expand repeats" lists every copy with
+// the time it came under the line. Repeats do not ring. "keep_repeats" in
+// the config shows every copy instead.
+
+// maxRuns is how many collapsed lines can still be expanded.
+const maxRuns = 200
+
+// repeatRun is an incoming message and the copies of it that followed.
+type repeatRun struct {
+	first    *pollMessage
+	id       string      // its line ID once shown; "" before
+	times    []time.Time // when each repeat came
+	expanded int         // repeats already listed by expanding
+}
+
+// repeats tracks the latest run. Safe to use from any goroutine.
+type repeats struct {
+	mu    sync.Mutex
+	last  *repeatRun
+	byID  map[string]*repeatRun // line ID → collapsed run, for expanding
+	order []string              // line IDs, oldest first, for eviction
+}
+
+// repeat reports whether msg repeats the latest message, counting it if
+// so; otherwise msg starts a new run.
+func (r *repeats) repeat(msg *pollMessage) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if last := r.last; last != nil && last.first.Username == msg.Username && last.first.Content == msg.Content {
+		last.times = append(last.times, messageTime(msg))
+		return true
+	}
+	r.last = &repeatRun{first: msg}
+	return false
+}
+
+// reset ends the latest run: something else came between.
+func (r *repeats) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.last = nil
+}
+
+// shown records that msg, if it started the latest run, is shown as the
+// line id.
+func (r *repeats) shown(msg *pollMessage, id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last != nil && r.last.first == msg {
+		r.last.id = id
+	}
+}
+
+// latest returns the latest run's line ID and copy count once it is shown
+// and has repeats, filing it for expanding.
+func (r *repeats) latest() (id string, count int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run := r.last
+	if run == nil || run.id == "" || len(run.times) == 0 {
+		return "", 0
+	}
+	if _, ok := r.byID[run.id]; !ok {
+		if r.byID == nil {
+			r.byID = make(map[string]*repeatRun)
+		}
+		r.byID[run.id] = run
+		r.order = append(r.order, run.id)
+		if len(r.order) > maxRuns {
+			delete(r.byID, r.order[0])
+			r.order = r.order[1:]
+		}
+	}
+	return run.id, len(run.times) + 1
+}
+
+// expand returns the repeats of the line id not yet listed, and the text
+// they repeat; ok is false if nothing was collapsed into it.
+func (r *repeats) expand(id string) (times []time.Time, content string, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.byID[id]
+	if !ok {
+		return nil, "", false
+	}
+	times = append(times, run.times[run.expanded:]...)
+	run.expanded = len(run.times)
+	return times, run.first.Content, true
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// collapseRepeat reports whether msg repeats the message before it and so
+// is not to be shown, and counts it on that one's line. Called from
+// network goroutines.
+func (ac *AppController) collapseRepeat(msg *pollMessage) bool {
+	if ac.App.Config.KeepRepeats || !ac.repeats.repeat(msg) {
+		return false
+	}
+	ac.App.Session.RecordReceived(msg.Content)
+	ui.SafeQueueUpdateDraw(ac.app, "collapseRepeat", ac.showRepeats)
+	return true
+}
+
+// showRepeats puts the count of the latest run on its line. Must be called
+// from the tview event loop.
+func (ac *AppController) showRepeats() {
+	id, count := ac.repeats.latest()
+	if id == "" {
+		return
+	}
+	if chat, ok := ac.chatView(); ok {
+		chat.SetLineSuffix(id, fmt.Sprintf(" [yellow]×%d[-]", count))
+	}
+}
+
+// expandRepeats lists the copies collapsed into the line id, for the
+// browse menu. Must be called from the tview event loop.
+func (ac *AppController) expandRepeats(id string) {
+	times, content, ok := ac.repeats.expand(id)
+	chat, hasChat := ac.chatView()
+	switch {
+	case !hasChat:
+		return
+	case !ok:
+		ac.showNotice("No repeats were collapsed into that message.", views.NoticeInfo)
+		return
+	case len(times) == 0:
+		ac.showNotice("Its repeats are already listed under it.", views.NoticeInfo)
+		return
+	}
+	for _, t := range times {
+		chat.AddNote(id, models.InZone(t).Format("15:04:05")+"  "+content)
+	}
+}
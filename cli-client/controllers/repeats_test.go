@@ -0,0 +1,38 @@
+package controllers
+
+import "testing"
+
+func TestRepeats(t *testing.T) {
+	var r repeats
+	first := &pollMessage{Username: "bob", Content: "spam"}
+	if r.repeat(first) {
+		t.Fatal("the first message counted as a repeat")
+	}
+	r.shown(first, "m1")
+	for i := 0; i < 6; i++ {
+		if !r.repeat(&pollMessage{Username: "bob", Content: "spam"}) {
+			t.Fatalf("copy %d not counted as a repeat", i+1)
+		}
+	}
+	if id, count := r.latest(); id != "m1" || count != 7 {
+		t.Errorf("latest = %q, %d; want m1, 7", id, count)
+	}
+	if r.repeat(&pollMessage{Username: "alice", Content: "spam"}) {
+		t.Error("the same text from someone else counted as a repeat")
+	}
+	r.reset()
+	if r.repeat(&pollMessage{Username: "alice", Content: "spam"}) {
+		t.Error("a repeat counted across a reset")
+	}
+
+	times, content, ok := r.expand("m1")
+	if !ok || len(times) != 6 || content != "spam" {
+		t.Errorf("expand = %d times, %q, %v; want 6, spam, true", len(times), content, ok)
+	}
+	if times, _, _ := r.expand("m1"); len(times) != 0 {
+		t.Errorf("expanding again listed %d repeats, want none", len(times))
+	}
+	if _, _, ok := r.expand("m2"); ok {
+		t.Error("expanded a line nothing was collapsed into")
+	}
+}
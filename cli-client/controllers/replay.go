@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cli-client/models"
+)
+
+// ── Replay ────────────────────────────────────────────────────────────────────
+//
+// /replay [duration] redraws the active conversation's messages from the
+// last hour (or duration) out of the session history, without animation and
+// between dividers — handy after /clear or a re-attach. The history keeps
+// chat only; ephemeral messages and system lines are never in it.
+
+const defaultReplay = time.Hour
+
+// recordIncoming files msg, made from the received pm, in the history.
+// Must be called from the tview event loop.
+func (ac *AppController) recordIncoming(pm *pollMessage, msg *models.Message) {
+	msg.Color = ac.incomingColor(pm)
+	ac.App.History.Add(ac.conversationKey(), msg)
+}
+
+// incomingColor is the color tag for the received pm.
+func (ac *AppController) incomingColor(pm *pollMessage) string {
+	switch {
+	case pm.Color == "":
+		return ac.App.GetUserColorTag(pm.Username)
+	case strings.HasPrefix(pm.Color, "["):
+		return pm.Color
+	}
+	return models.ParseColorToTag(pm.Color)
+}
+
+// replay handles /replay. Must be called from the tview event loop.
+func (ac *AppController) replay(arg string) {
+	span, label := defaultReplay, "1h"
+	if arg != "" {
+		d, err := time.ParseDuration(arg)
+		if err != nil || d <= 0 {
+			ac.sendSystem("Usage: /replay [duration]  —  e.g. /replay 30m (default 1h)")
+			return
+		}
+		span, label = d, arg
+	}
+
+	msgs := ac.App.History.Since(ac.conversationKey(), span)
+	if len(msgs) == 0 {
+		ac.sendSystem(fmt.Sprintf("No messages in the last %s.", label))
+		return
+	}
+	ac.sendSystem(fmt.Sprintf("[dim]── replay · last %s · %d message(s) ──[-]", label, len(msgs)))
+	for _, m := range msgs {
+		// A copy without the ID, so receipts and expiry keep finding the
+		// original line rather than this one.
+		line := *m
+		line.ID = ""
+		for _, sink := range ac.messageSinks() {
+			sink.AddMessage(&line)
+		}
+	}
+	ac.sendSystem("[dim]── end of replay ──[-]")
+}
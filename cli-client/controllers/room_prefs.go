@@ -0,0 +1,55 @@
+package controllers
+
+import "cli-client/models"
+
+// ── Per-conversation preferences ──────────────────────────────────────────────
+//
+// The "rooms" section of the config overrides display and notification
+// settings for one conversation — a relay URL, or "lan". They are applied
+// each time the active conversation changes, and everything not overridden
+// falls back to the global setting.
+
+// roomConfig returns the overrides for the active conversation.
+func (ac *AppController) roomConfig() models.RoomConfig {
+	return ac.App.Config.Rooms[ac.conversationKey()]
+}
+
+// enterConversation restores what belongs to the conversation just entered:
+// its draft and its preferences. Must be called from the tview event loop.
+func (ac *AppController) enterConversation() {
+	ac.noteConversation(ac.conversationKey())
+	ac.hush.enter(ac.conversationKey())
+	ac.repeats.reset()
+	ac.showHush()
+	ac.restoreDraft()
+	ac.applyFilters()
+	ac.applyIgnored()
+	ac.showTitle()
+
+	chat, ok := ac.chatView()
+	if !ok {
+		return
+	}
+	room := ac.roomConfig()
+	anim := ac.userAnim
+	if room.Animation != nil {
+		anim = *room.Animation
+	}
+	chat.SetAnimationMode(anim)
+	chat.SetAccent(room.Accent)
+}
+
+// roomNotifyRules narrows the global notify rules by the conversation's
+// notify level. ok is false when the conversation never notifies.
+func (ac *AppController) roomNotifyRules() (rules models.NotifyConfig, ok bool) {
+	rules = ac.App.Config.Notify
+	switch ac.roomConfig().Notify {
+	case "none":
+		return rules, false
+	case "all":
+		return models.NotifyConfig{Bell: rules.Bell}, true
+	case "mentions":
+		return models.NotifyConfig{Bell: rules.Bell, Mentions: true}, true
+	}
+	return rules, true
+}
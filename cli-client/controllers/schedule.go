@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cli-client/models"
+	"cli-client/paths"
+	"cli-client/ui"
+	"cli-client/views"
+)
+
+// ── Scheduled messages ────────────────────────────────────────────────────────
+//
+// /schedule <when> <text> queues a chat message for later. Pending entries are
+// kept in scheduleFile so they survive a restart; entries that fell due while
+// the client was closed go out as soon as their owner logs in again.
+//
+//   /schedule 10m stand-up in 5        relative (any Go duration)
+//   /schedule 17:30 going home         next 17:30 local time
+//   /schedule 2026-01-02T09:00 hello   absolute local time
+//   /schedule list
+//   /schedule cancel <id>
+
+const scheduleFile = "ttc_schedule.json"
+
+// ScheduledMessage is one pending message in scheduleFile.
+type ScheduledMessage struct {
+	ID       int       `json:"id"`
+	Username string    `json:"username"` // only this user's session sends it
+	Content  string    `json:"content"`
+	At       time.Time `json:"at"`
+}
+
+// loadSchedules reads scheduleFile and arms the current user's entries.
+// Must be called from the tview event loop.
+func (ac *AppController) loadSchedules() {
+	data, err := os.ReadFile(paths.Data(scheduleFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("loadSchedules: %v", err)
+		}
+		return
+	}
+	var list []*ScheduledMessage
+	if err := json.Unmarshal(data, &list); err != nil {
+		log.Printf("loadSchedules: ignoring unreadable %s: %v", scheduleFile, err)
+		return
+	}
+	ac.schedules = list
+
+	armed := 0
+	for _, s := range ac.schedules {
+		if s.Username == ac.App.CurrentUser.Username {
+			ac.armSchedule(s)
+			armed++
+		}
+	}
+	if armed > 0 {
+		ac.sendSystem(fmt.Sprintf("%d scheduled message(s) pending — /schedule list", armed))
+	}
+	ac.showScheduleCount()
+}
+
+// showScheduleCount updates the {scheduled} footer segment.
+func (ac *AppController) showScheduleCount() {
+	if chat, ok := ac.chatView(); ok {
+		segment := ""
+		if n := len(ac.scheduleTimers); n > 0 {
+			segment = fmt.Sprintf("  [cyan]⏰ %d[-]", n)
+		}
+		chat.SetSegment("scheduled", segment)
+	}
+}
+
+// saveSchedules writes every pending entry back to scheduleFile.
+func (ac *AppController) saveSchedules() {
+	if len(ac.schedules) == 0 {
+		os.Remove(paths.Data(scheduleFile))
+		return
+	}
+	data, err := json.MarshalIndent(ac.schedules, "", "  ")
+	if err == nil {
+		err = os.WriteFile(paths.Data(scheduleFile), data, 0600)
+	}
+	if err != nil {
+		log.Printf("saveSchedules: %v", err)
+	}
+}
+
+// disarmSchedules stops every armed timer, for another user to take over.
+// Their entries stay in scheduleFile.
+func (ac *AppController) disarmSchedules() {
+	for id, t := range ac.scheduleTimers {
+		t.Stop()
+		delete(ac.scheduleTimers, id)
+	}
+}
+
+// armSchedule starts the timer for s; an overdue entry fires right away.
+func (ac *AppController) armSchedule(s *ScheduledMessage) {
+	id := s.ID
+	ac.scheduleTimers[id] = time.AfterFunc(time.Until(s.At), func() {
+		ui.SafeQueueUpdateDraw(ac.app, "armSchedule", func() { ac.fireSchedule(id) })
+	})
+}
+
+// fireSchedule sends entry id if it is still pending. Runs in the tview
+// event loop.
+func (ac *AppController) fireSchedule(id int) {
+	s, i := ac.findSchedule(id)
+	if s == nil || ac.App.CurrentUser == nil || s.Username != ac.App.CurrentUser.Username {
+		return
+	}
+	delete(ac.scheduleTimers, id)
+	ac.schedules = append(ac.schedules[:i], ac.schedules[i+1:]...)
+	ac.saveSchedules()
+	ac.showScheduleCount()
+
+	if late := time.Since(s.At); late > time.Minute {
+		ac.sendSystem(fmt.Sprintf("[dim]Scheduled message #%d was due %s — sending now.[-]", s.ID, scheduleTime(s.At)))
+	}
+	ac.sendChat(s.Content)
+}
+
+func (ac *AppController) findSchedule(id int) (*ScheduledMessage, int) {
+	for i, s := range ac.schedules {
+		if s.ID == id && ac.App.CurrentUser != nil && s.Username == ac.App.CurrentUser.Username {
+			return s, i
+		}
+	}
+	return nil, -1
+}
+
+// stopSchedules disarms all timers; pending entries stay in scheduleFile.
+func (ac *AppController) stopSchedules() {
+	for id, t := range ac.scheduleTimers {
+		t.Stop()
+		delete(ac.scheduleTimers, id)
+	}
+}
+
+// scheduleCommand handles /schedule. Must be called from the tview event loop.
+func (ac *AppController) scheduleCommand(arg string) {
+	if ac.App.CurrentUser == nil {
+		ac.sendSystem("No user logged in.")
+		return
+	}
+	fields := strings.Fields(arg)
+	switch {
+	case len(fields) == 0:
+		ac.sendSystem("Usage: /schedule <10m|17:30|2006-01-02T15:04> <text>  │  /schedule list  │  /schedule cancel <id>")
+
+	case fields[0] == "list":
+		var mine []*ScheduledMessage
+		for _, s := range ac.schedules {
+			if s.Username == ac.App.CurrentUser.Username {
+				mine = append(mine, s)
+			}
+		}
+		if len(mine) == 0 {
+			ac.sendSystem("No scheduled messages.")
+			return
+		}
+		sort.Slice(mine, func(i, j int) bool { return mine[i].At.Before(mine[j].At) })
+		ac.sendSystem(fmt.Sprintf("Scheduled (%d):", len(mine)))
+		for _, s := range mine {
+			ac.sendSystem(fmt.Sprintf("  [cyan]#%d[-]  %s  (in %s)  %s",
+				s.ID, scheduleTime(s.At), time.Until(s.At).Round(time.Second), views.Escape(s.Content)))
+		}
+
+	case fields[0] == "cancel":
+		if len(fields) != 2 {
+			ac.sendSystem("Usage: /schedule cancel <id>")
+			return
+		}
+		id, _ := strconv.Atoi(strings.TrimPrefix(fields[1], "#"))
+		s, i := ac.findSchedule(id)
+		if s == nil {
+			ac.sendSystem(fmt.Sprintf("No scheduled message #%s — see /schedule list", fields[1]))
+			return
+		}
+		if t := ac.scheduleTimers[id]; t != nil {
+			t.Stop()
+			delete(ac.scheduleTimers, id)
+		}
+		ac.schedules = append(ac.schedules[:i], ac.schedules[i+1:]...)
+		ac.saveSchedules()
+		ac.showScheduleCount()
+		ac.sendSystem(fmt.Sprintf("Cancelled scheduled message #%d.", id))
+
+	default:
+		at, err := parseScheduleTime(fields[0], time.Now())
+		text := strings.TrimSpace(strings.TrimPrefix(arg, fields[0]))
+		if err != nil {
+			ac.sendSystem(fmt.Sprintf("Cannot schedule: %v", err))
+			return
+		}
+		if text == "" {
+			ac.sendSystem("Cannot schedule an empty message.")
+			return
+		}
+		next := 1
+		for _, s := range ac.schedules {
+			if s.ID >= next {
+				next = s.ID + 1
+			}
+		}
+		s := &ScheduledMessage{ID: next, Username: ac.App.CurrentUser.Username, Content: text, At: at}
+		ac.schedules = append(ac.schedules, s)
+		ac.saveSchedules()
+		ac.armSchedule(s)
+		ac.showScheduleCount()
+		ac.sendSystem(fmt.Sprintf("Scheduled [cyan]#%d[-] for %s (in %s).",
+			s.ID, scheduleTime(at), time.Until(at).Round(time.Second)))
+	}
+}
+
+// scheduleTime shows when a scheduled message is due, in the local time
+// /schedule takes it in and in the locale's language.
+func scheduleTime(t time.Time) string {
+	l := models.CurrentLocale()
+	return l.Format(t, l.Short+" 15:04")
+}
+
+// parseScheduleTime turns a /schedule time into an absolute local time:
+// a duration ("10m"), a clock time ("17:30", the next one to come) or a
+// date and time ("2006-01-02T15:04").
+func parseScheduleTime(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("delay must be positive")
+		}
+		return now.Add(d), nil
+	}
+	if t, err := time.ParseInLocation("15:04", s, now.Location()); err == nil {
+		at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+		return at, nil
+	}
+	if at, err := time.ParseInLocation("2006-01-02T15:04", s, now.Location()); err == nil {
+		if !at.After(now) {
+			return time.Time{}, fmt.Errorf("%s is in the past", scheduleTime(at))
+		}
+		return at, nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognised time %q — use 10m, 17:30 or 2006-01-02T15:04", s)
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cli-client/models"
+	"cli-client/views"
+)
+
+// ── Split view ────────────────────────────────────────────────────────────────
+//
+// /split <room> opens a second chat pane beside the focused one for another
+// conversation from /rooms, by number or key; /split off closes it again.
+// Each pane keeps its own transcript and scroll position. Only one
+// conversation is connected at a time (see conversations.go), so Ctrl+Left
+// and Ctrl+Right switch to the conversation of the pane they focus, and the
+// pane left stands still — offline — until it is focused again.
+
+const splitUsage = "Usage: /split <n|room>|off — n as numbered by /rooms"
+
+// splitBacklog is how many of a conversation's latest messages a pane
+// opened for it starts with.
+const splitBacklog = 200
+
+// splitView returns the chat screen's panes.
+func (ac *AppController) splitView() (*views.SplitView, bool) {
+	split, ok := ac.Views[models.ScreenChat].(*views.SplitView)
+	return split, ok
+}
+
+// chatPanes returns every chat pane, shown or not, for settings that apply
+// to all of them.
+func (ac *AppController) chatPanes() []*views.ChatView {
+	if split, ok := ac.splitView(); ok {
+		return split.Panes()
+	}
+	return nil
+}
+
+// splitCommand runs /split. Must be called from the tview event loop.
+func (ac *AppController) splitCommand(arg string) {
+	split, ok := ac.splitView()
+	if !ok {
+		return
+	}
+	arg = strings.TrimSpace(arg)
+	switch {
+	case arg == "":
+		ac.sendSystem(splitUsage)
+		return
+	case strings.EqualFold(arg, "off"):
+		if !split.IsOpen() {
+			ac.sendSystem("The chat is not split.")
+			return
+		}
+		split.SetOpen(false)
+		return
+	}
+
+	key := arg
+	list := ac.roomList()
+	if n, err := strconv.Atoi(arg); err == nil {
+		if n < 1 || n > len(list) {
+			ac.sendSystem(fmt.Sprintf("No conversation %d — /rooms lists them.", n))
+			return
+		}
+		key = list[n-1]
+	} else if !containsString(list, key) {
+		ac.sendSystem("No conversation " + views.Escape(key) + " — /rooms lists them.")
+		return
+	}
+	if key == ac.conversationKey() {
+		ac.sendSystem("That is this conversation — /split another one from /rooms.")
+		return
+	}
+
+	other := 1 - split.ActivePane()
+	ac.panes[other] = key
+	pane := split.Pane(other)
+	ac.forgetPane(other)
+	pane.ClearMessages()
+	pane.AddMessages(ac.App.History.Recent(key, splitBacklog))
+	arrow := "Ctrl+→"
+	if other == 0 {
+		arrow = "Ctrl+←"
+	}
+	pane.AddMessage(models.NewSystemMessage(fmt.Sprintf(
+		"[dim]%s — %s to switch to it; only the focused pane is connected.[-]", views.Escape(key), arrow)))
+	pane.SetOnlineStatus(false)
+	split.SetOpen(true)
+}
+
+// focusPane moves the focus to pane i and switches to its conversation.
+// The pane left keeps its transcript and goes offline. Called for
+// Ctrl+Left and Ctrl+Right from the tview event loop.
+func (ac *AppController) focusPane(i int) {
+	split, ok := ac.splitView()
+	if !ok {
+		return
+	}
+	ac.stashDraft()
+	split.Active().SetOnlineStatus(false)
+	split.Focus(i)
+	if key := ac.panes[i]; key != "" && key != ac.conversationKey() {
+		ac.openConversation(key)
+		return
+	}
+	split.Active().SetOnlineStatus(ac.App.IsConnected || ac.lan != nil)
+	ac.enterConversation()
+}
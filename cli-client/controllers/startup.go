@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"strings"
+
+	"cli-client/views"
+)
+
+// ── Startup commands ──────────────────────────────────────────────────────────
+//
+// The "startup" list in the config holds commands run after every login, in
+// order, as if typed: "/server lan", "/mode static", "/dnd 2h". Each is
+// echoed dim above its output so the transcript shows where a setting came
+// from. Commands that end the session are skipped, and a re-attach after
+// /detach runs none — that session is being continued, not started.
+
+// startupSkipped are the commands a startup list may not run.
+var startupSkipped = map[string]bool{"exit": true, "detach": true, "update": true}
+
+// runStartup runs the configured startup commands. Must be called from the
+// tview event loop.
+func (ac *AppController) runStartup() {
+	for _, command := range ac.App.Config.Startup {
+		name, _, _ := strings.Cut(strings.TrimPrefix(command, "/"), " ")
+		if startupSkipped[strings.ToLower(name)] {
+			ac.sendSystem("[dim]startup: skipped " + views.Escape(command) + " — not allowed at startup[-]")
+			continue
+		}
+		ac.sendSystem("[dim]startup: " + views.Escape(command) + "[-]")
+		ac.OnCommand(command)
+	}
+}
@@ -1,18 +1,40 @@
 package controllers
 
-import "cli-client/models"
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"cli-client/models"
+)
+
+// maxHistory bounds how many screens Back can walk through.
+const maxHistory = 16
+
+var errNoHistory = errors.New("no previous screen")
 
 type StateMachine struct {
-	current models.Screen
-	onEnter map[models.Screen]func()
-	onExit  map[models.Screen]func()
+	current      models.Screen
+	onEnter      map[models.Screen]func()
+	onExit       map[models.Screen]func()
+	onTransition []func(from, to models.Screen)
+
+	// guards veto entering a screen; a non-nil error leaves the current
+	// screen in place.
+	guards map[models.Screen]func(from models.Screen) error
+	// history holds the screens Back returns to, most recent last.
+	// Transient screens never enter it.
+	history   []models.Screen
+	transient map[models.Screen]bool
 }
 
 func NewStateMachine(initial models.Screen) *StateMachine {
 	return &StateMachine{
-		current: initial,
-		onEnter: make(map[models.Screen]func()),
-		onExit:  make(map[models.Screen]func()),
+		current:   initial,
+		onEnter:   make(map[models.Screen]func()),
+		onExit:    make(map[models.Screen]func()),
+		guards:    make(map[models.Screen]func(models.Screen) error),
+		transient: make(map[models.Screen]bool),
 	}
 }
 
@@ -24,20 +46,104 @@ func (sm *StateMachine) OnExit(screen models.Screen, fn func()) {
 	sm.onExit[screen] = fn
 }
 
-func (sm *StateMachine) Transition(to models.Screen) {
+// OnTransition registers fn to run after every transition, once the new
+// screen's OnEnter hook has run.
+func (sm *StateMachine) OnTransition(fn func(from, to models.Screen)) {
+	sm.onTransition = append(sm.onTransition, fn)
+}
+
+// Guard registers fn to approve every transition into screen. It is given
+// the screen being left; returning an error cancels the transition.
+func (sm *StateMachine) Guard(screen models.Screen, fn func(from models.Screen) error) {
+	sm.guards[screen] = fn
+}
+
+// SetTransient marks screen as one Back never returns to — the loading
+// splash and the error screen.
+func (sm *StateMachine) SetTransient(screen models.Screen) {
+	sm.transient[screen] = true
+}
+
+// Transition moves to screen to, recording the screen left in the history.
+// A transition to the current screen is a no-op.
+func (sm *StateMachine) Transition(to models.Screen) error {
 	if sm.current == to {
+		return nil
+	}
+	if err := sm.check(to); err != nil {
+		return err
+	}
+	sm.push(sm.current)
+	sm.move(to)
+	return nil
+}
+
+// Back returns to the most recent non-transient screen, skipping any whose
+// guard now refuses entry. The history is left untouched if nothing can be
+// returned to.
+func (sm *StateMachine) Back() error {
+	for i := len(sm.history) - 1; i >= 0; i-- {
+		to := sm.history[i]
+		if to == sm.current || sm.check(to) != nil {
+			continue
+		}
+		sm.history = sm.history[:i]
+		sm.move(to)
+		return nil
+	}
+	return errNoHistory
+}
+
+// CanGoBack reports whether Back has a screen to return to.
+func (sm *StateMachine) CanGoBack() bool {
+	for i := len(sm.history) - 1; i >= 0; i-- {
+		if to := sm.history[i]; to != sm.current && sm.check(to) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (sm *StateMachine) Current() models.Screen {
+	return sm.current
+}
+
+// check runs the guard for to, if any.
+func (sm *StateMachine) check(to models.Screen) error {
+	fn, ok := sm.guards[to]
+	if !ok {
+		return nil
+	}
+	if err := fn(sm.current); err != nil {
+		log.Printf("TRACE state: %s → %s refused: %v", sm.current, to, err)
+		return fmt.Errorf("%s: %w", to, err)
+	}
+	return nil
+}
+
+func (sm *StateMachine) push(screen models.Screen) {
+	if screen == models.ScreenNone || sm.transient[screen] {
 		return
 	}
+	sm.history = append(sm.history, screen)
+	if len(sm.history) > maxHistory {
+		sm.history = sm.history[len(sm.history)-maxHistory:]
+	}
+}
+
+// move runs the exit, enter and transition hooks for a change to screen to.
+func (sm *StateMachine) move(to models.Screen) {
+	from := sm.current
+	log.Printf("TRACE state: %s → %s (history %v)", from, to, sm.history)
 	// Call OnExit for the current screen if registered
-	if fn, ok := sm.onExit[sm.current]; ok {
+	if fn, ok := sm.onExit[from]; ok {
 		fn()
 	}
 	sm.current = to
 	if fn, ok := sm.onEnter[to]; ok {
 		fn()
 	}
-}
-
-func (sm *StateMachine) Current() models.Screen {
-	return sm.current
+	for _, fn := range sm.onTransition {
+		fn(from, to)
+	}
 }
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	"cli-client/models"
+	"cli-client/views"
+)
+
+// ── Stickers ──────────────────────────────────────────────────────────────────
+//
+// /sticker <name> sends one of the built-in stickers (views.StickerNames) as
+// a msgTypeSticker message holding just the name; every client draws the art
+// itself. Relays without the "stickers" feature only carry chat, so there the
+// sticker goes as its ":name:" text, which is also what clients show for a
+// name they do not know.
+
+// stickerText is the plain-text form of sticker name.
+func stickerText(name string) string {
+	return ":" + name + ":"
+}
+
+// isStickerName mirrors the relay's check: 1 to 32 of a-z, 0-9, '-' or '_'.
+func isStickerName(s string) bool {
+	if s == "" || len(s) > 32 {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// sendSticker handles /sticker. Must be called from the tview event loop.
+func (ac *AppController) sendSticker(arg string) {
+	if ac.App.CurrentUser == nil {
+		ac.sendSystem("No user logged in.")
+		return
+	}
+	name := strings.ToLower(strings.TrimSpace(arg))
+	if !views.IsSticker(name) {
+		if name != "" {
+			ac.sendSystem("Unknown sticker " + views.Escape(stickerText(name)) + ".")
+		}
+		ac.sendSystem("Usage: /sticker <name>  —  " + strings.Join(views.StickerNames(), ", "))
+		return
+	}
+	if !ac.linked() {
+		ac.sendSystem("Not connected to a relay.")
+		return
+	}
+	me := ac.App.CurrentUser.Username
+
+	ac.lastInput = time.Now()
+	ac.App.Session.RecordSent(stickerText(name))
+	ac.showSticker(me, name, ac.App.GetUserColorTag(me), false)
+	if ac.lan == nil && !ac.caps.Supports("stickers") {
+		ac.transmit(msgTypeChat, stickerText(name))
+		return
+	}
+	ac.transmit(msgTypeSticker, name)
+}
+
+// handleSticker displays a sticker sent by another client at at. colorTag
+// is the wire color, as in AddIncomingMessage. Must be called from the tview
+// event loop.
+func (ac *AppController) handleSticker(from, name, colorTag string, at time.Time) {
+	if !isStickerName(name) || ac.isIgnored(from) {
+		return
+	}
+	switch {
+	case colorTag == "":
+		colorTag = ac.App.GetUserColorTag(from)
+	case !strings.HasPrefix(colorTag, "["):
+		colorTag = models.ParseColorToTag(colorTag)
+	}
+	msg := ac.showSticker(from, name, views.ColorTag(colorTag), ac.isImpostor(from, at))
+	if msg.Impostor {
+		ac.noteImpostor(msg)
+	}
+	ac.noteActivity()
+	ac.notify(from, stickerText(name))
+}
+
+// showSticker adds the sticker to the history and view, and returns its
+// line.
+func (ac *AppController) showSticker(from, name, color string, impostor bool) *models.Message {
+	msg := models.NewMessage(from, stickerText(name))
+	msg.Color = color
+	msg.Sticker = name
+	msg.Impostor = impostor
+	ac.App.AddMessage(msg)
+	ac.App.History.Add(ac.conversationKey(), msg)
+	for _, sink := range ac.messageSinks() {
+		sink.AddMessage(msg)
+	}
+	return msg
+}
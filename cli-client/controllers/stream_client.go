@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"cli-client/models"
+	"cli-client/paths"
+)
+
+// ── Receive transports ────────────────────────────────────────────────────────
+//
+// Incoming messages arrive over one of two transports, tried in the order
+// given by Config.Transports:
+//
+//	"sse"  — one long-lived GET /api/stream (Server-Sent Events)
+//	"poll" — the classic GET /api/poll long-poll loop
+//
+// A transport the relay does not offer (404/405, or a response that is not an
+// event stream) is dropped for the rest of the session and the next one takes
+// over. A stream that goes silent past streamIdleTimeout — typically a proxy
+// buffering the response — counts the same. Plain network errors are retried
+// on the same transport with backoff.
+
+const (
+	transportSSE  = "sse"
+	transportPoll = "poll"
+)
+
+// streamIdleTimeout is how long an open stream may stay silent. The relay
+// sends a keep-alive comment every 15s.
+const streamIdleTimeout = 45 * time.Second
+
+// errTransportUnavailable means the relay cannot serve this transport at all.
+var errTransportUnavailable = errors.New("transport not available on this relay")
+
+// linkState tracks connection health across reconnects and transports, so
+// status messages and backoff carry over when falling back. Owned by the
+// receive goroutine.
+type linkState struct {
+	backoff      time.Duration
+	attempt      int // reconnect attempts since the link was last up
+	firstConnect bool
+	wasConnected bool
+}
+
+const maxBackoff = 30 * time.Second
+
+func newLinkState() *linkState {
+	return &linkState{backoff: time.Second, firstConnect: true}
+}
+
+// linkUp records a successful exchange with the relay.
+func (nc *NetworkClient) linkUp(link *linkState) {
+	// The heartbeat may have reported the link back already; it sets linked.
+	back := atomic.CompareAndSwapInt32(&nc.linked, 0, 1)
+	if (link.firstConnect || !link.wasConnected) && back {
+		nc.notifyStatus(true, fmt.Sprintf("Connected to relay at %s", nc.serverURL))
+	}
+	link.attempt = 0
+	select {
+	case <-nc.reviveCh: // a revive meant for a backoff we skipped
+	default:
+	}
+	link.backoff = time.Second
+	link.firstConnect = false
+	link.wasConnected = true
+}
+
+// linkDown records a failed exchange and waits out the jittered backoff (see
+// reconnect.go). Returns false if the client was stopped while waiting.
+func (nc *NetworkClient) linkDown(link *linkState) bool {
+	wait := jitter(link.backoff)
+	link.attempt++
+	// The heartbeat may have reported the loss already; it clears linked.
+	lost := atomic.CompareAndSwapInt32(&nc.linked, 1, 0)
+	if link.firstConnect {
+		nc.notifyStatus(false, fmt.Sprintf("Cannot reach server at %s", nc.serverURL))
+	} else if link.wasConnected && lost {
+		nc.notifyStatus(false, fmt.Sprintf("Connection lost — reconnecting in %v…", wait.Round(100*time.Millisecond)))
+	}
+	link.wasConnected = false
+	nc.notifyReconnect(time.Now().Add(wait), link.attempt)
+	select {
+	case <-nc.stopCh:
+		return false
+	case <-nc.reviveCh:
+		log.Printf("TRACE linkDown: reconnecting now, attempt %d", link.attempt)
+	case <-time.After(wait):
+	}
+	link.backoff = minDur(link.backoff*2, maxBackoff)
+	return true
+}
+
+// receiveLoop runs the configured transports in fallback order until Stop.
+func (nc *NetworkClient) receiveLoop() {
+	link := newLinkState()
+	for i, t := range nc.transports {
+		last := i == len(nc.transports)-1
+		nc.transport.Store(t)
+		switch t {
+		case transportSSE:
+			if nc.streamLoop(link, last) {
+				return
+			}
+			log.Printf("receiveLoop: SSE unavailable, falling back")
+		case transportPoll:
+			nc.pollLoop(link)
+			return
+		default:
+			log.Printf("receiveLoop: unknown transport %q, skipping", t)
+		}
+	}
+	log.Printf("receiveLoop: no usable transport in %v", nc.transports)
+	nc.notifyStatus(false, "No usable transport for this relay — check \"transports\" in "+paths.Config(models.ConfigFile))
+}
+
+// streamLoop consumes /api/stream until Stop (returns true) or until the
+// relay turns out not to support it (returns false). When it is the last
+// transport it never gives up and keeps reconnecting instead.
+func (nc *NetworkClient) streamLoop(link *linkState, last bool) bool {
+	for {
+		if atomic.LoadInt32(&nc.stopped) == 1 {
+			return true
+		}
+		err := nc.stream(link)
+		if atomic.LoadInt32(&nc.stopped) == 1 {
+			return true
+		}
+		if errors.Is(err, errTransportUnavailable) && !last {
+			log.Printf("TRACE streamLoop: %v", err)
+			return false
+		}
+		if err == nil {
+			continue // relay closed the stream at its lifetime limit
+		}
+		log.Printf("TRACE streamLoop: stream error: %v", err)
+		if !nc.linkDown(link) {
+			return true
+		}
+	}
+}
+
+// stream opens one SSE connection and dispatches its events until it ends.
+func (nc *NetworkClient) stream(link *linkState) error {
+	params := url.Values{}
+	params.Set("access_key", serverAccessKey)
+	params.Set("client_id", nc.clientID)
+	lastID := nc.LastID()
+	if lastID != "" {
+		params.Set("last_id", lastID)
+	}
+
+	ctx, cancel := nc.receiveContext()
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, nc.serverURL+"/api/stream?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+
+	log.Printf("TRACE stream: GET %s/api/stream lastID=%q", nc.serverURL, lastID)
+	resp, err := nc.streamClient.Do(req)
+	if err != nil {
+		return err
+	}
+	// Closed, not drained: an open stream never ends, so draining would
+	// block on its keep-alives with nothing left to cancel it.
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return errTransportUnavailable
+	case http.StatusUnauthorized:
+		return fmt.Errorf("server rejected access key")
+	case http.StatusForbidden:
+		return fmt.Errorf("relay refused this client (kicked)")
+	default:
+		return fmt.Errorf("unexpected HTTP %d", resp.StatusCode)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return errTransportUnavailable
+	}
+	nc.linkUp(link)
+
+	// Cancel the request if the relay goes quiet for too long.
+	watchdog := time.AfterFunc(streamIdleTimeout, cancel)
+	defer watchdog.Stop()
+
+	var id, event string
+	var data strings.Builder
+	streaming := false
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		watchdog.Reset(streamIdleTimeout)
+		streaming = true
+		line := sc.Text()
+
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				nc.dispatchEvent(id, event, data.String())
+			}
+			id, event = "", ""
+			data.Reset()
+		case strings.HasPrefix(line, ":"):
+			// comment / keep-alive
+		case strings.HasPrefix(line, "id:"):
+			id = strings.TrimSpace(line[3:])
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(line[6:])
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(line[5:], " "))
+		}
+	}
+	if ctx.Err() != nil && atomic.LoadInt32(&nc.stopped) == 0 {
+		if errors.Is(context.Cause(ctx), errHeartbeatLost) {
+			return errHeartbeatLost
+		}
+		if !streaming {
+			return errTransportUnavailable // not even a keep-alive: something buffers the stream
+		}
+		return fmt.Errorf("stream silent for %v", streamIdleTimeout)
+	}
+	return sc.Err()
+}
+
+// dispatchEvent handles one complete SSE event.
+func (nc *NetworkClient) dispatchEvent(id, event, data string) {
+	switch event {
+	case "", "message":
+	case "error":
+		log.Printf("stream: relay error: %s", data)
+		return
+	default:
+		log.Printf("TRACE stream: ignoring event %q", event)
+		return
+	}
+
+	msg, reason := parseWireMessage([]byte(data), &nc.drops)
+	if reason == "" && msg.ID == "" {
+		if reason = checkText(id, maxIDLen); reason == "" {
+			msg.ID = id
+		}
+	}
+	if reason == "" && (msg.Username == "" || msg.Content == "" || msg.ID == "") {
+		reason = dropMissing
+	}
+	if reason != "" {
+		log.Printf("stream: bad event data id=%.40q", id)
+		nc.drops.add(reason)
+		return
+	}
+	nc.SetLastID(msg.ID)
+	nc.handleIncoming(msg)
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"cli-client/models"
+	"cli-client/tasks"
+	"cli-client/ui"
+	"cli-client/views"
+)
+
+// ── Stress test ───────────────────────────────────────────────────────────────
+//
+// /stress <n> [rate|max] is a developer command: it feeds n synthetic
+// messages (the demo relay's users and lines, see demo.go) straight into
+// the views' AddIncomingMessage at rate per second, then reports how long
+// each frame took (as timed by ui.Frames), how long the view took to
+// catch up, and how much the heap grew. The messages skip the network, the
+// history, receipts and notifications, so the numbers are the renderer's
+// and the animation scheduler's alone.
+
+const (
+	defaultStressRate = 200 // messages per second
+	maxStressCount    = 100000
+	maxStressRate     = 100000
+)
+
+var errStressUsage = errors.New("usage")
+
+// parseStressArgs parses "<n> [rate|max]"; rate 0 means as fast as possible.
+func parseStressArgs(arg string) (n, rate int, err error) {
+	fields := strings.Fields(arg)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, 0, errStressUsage
+	}
+	n, err = strconv.Atoi(fields[0])
+	if err != nil || n < 1 || n > maxStressCount {
+		return 0, 0, fmt.Errorf("count must be 1–%d", maxStressCount)
+	}
+	rate = defaultStressRate
+	if len(fields) == 2 {
+		if fields[1] == "max" {
+			return n, 0, nil
+		}
+		rate, err = strconv.Atoi(fields[1])
+		if err != nil || rate < 1 || rate > maxStressRate {
+			return 0, 0, fmt.Errorf("rate must be 1–%d per second, or max", maxStressRate)
+		}
+	}
+	return n, rate, nil
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// stress handles /stress. Must be called from the tview event loop.
+func (ac *AppController) stress(arg string) {
+	n, rate, err := parseStressArgs(arg)
+	if err != nil {
+		if err != errStressUsage {
+			ac.sendSystem("Stress: " + err.Error() + ".")
+		}
+		ac.sendSystem(fmt.Sprintf("Usage: /stress <n> [rate|max]  —  inject n synthetic messages, %d/s by default", defaultStressRate))
+		return
+	}
+	sinks := ac.messageSinks()
+	if len(sinks) == 0 {
+		ac.sendSystem("Stress: no chat view to draw into.")
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&ac.stressing, 0, 1) {
+		ac.sendSystem("Stress: a run is already going.")
+		return
+	}
+	pace := "as fast as possible"
+	if rate > 0 {
+		pace = fmt.Sprintf("%d/s", rate)
+	}
+	mode := "static"
+	if chat, ok := ac.chatView(); ok && chat.IsAnimationMode() {
+		mode = "animation"
+	}
+	ac.sendSystem(fmt.Sprintf("[yellow]Stress:[-] injecting %d messages at %s in %s mode…", n, pace, mode))
+	ui.SafeGo("stress", func() {
+		defer atomic.StoreInt32(&ac.stressing, 0)
+		ac.runStress(sinks, n, rate, mode)
+	})
+}
+
+// runStress injects the messages and reports. Runs as a goroutine.
+func (ac *AppController) runStress(sinks []views.MessageSink, n, rate int, mode string) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	ui.Frames.Capture()
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		if rate > 0 {
+			due := start.Add(time.Duration(i) * time.Second / time.Duration(rate))
+			if wait := time.Until(due); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-tasks.Stopping():
+					ui.Frames.EndCapture()
+					return
+				}
+			}
+		}
+		u := demoUsers[rnd.Intn(len(demoUsers))]
+		text := demoChatter[rnd.Intn(len(demoChatter))]
+		if rnd.Intn(10) == 0 {
+			text = demoLong[rnd.Intn(len(demoLong))]
+		}
+		for _, sink := range sinks {
+			sink.AddIncomingMessage("", models.Now(), u.name, text, u.color)
+		}
+	}
+	injected := time.Since(start)
+	ui.SafeQueueUpdateDraw(ac.app, "runStress", func() {}) // everything queued before this has been drawn
+	drained := time.Since(start)
+	frames := ui.Frames.EndCapture()
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	lines := []string{
+		"[dim]┌─ Stress ────────────────────────────────────────┐[-]",
+		fmt.Sprintf("  [cyan]Messages     [-]%d in %s mode", n, mode),
+		fmt.Sprintf("  [cyan]Injected     [-]in %v  ·  %.0f/s", injected.Round(time.Millisecond), float64(n)/injected.Seconds()),
+		fmt.Sprintf("  [cyan]Drawn        [-]after %v  ·  %v behind the last message",
+			drained.Round(time.Millisecond), (drained - injected).Round(time.Millisecond)),
+		fmt.Sprintf("  [cyan]Frames       [-]%d  ·  %.1f per message", len(frames), float64(len(frames))/float64(n)),
+		fmt.Sprintf("  [cyan]Frame time   [-]p50 %v  ·  p95 %v  ·  p99 %v  ·  max %v",
+			roundFrame(ui.Percentile(frames, 50)), roundFrame(ui.Percentile(frames, 95)),
+			roundFrame(ui.Percentile(frames, 99)), roundFrame(ui.Percentile(frames, 100))),
+		fmt.Sprintf("  [cyan]Heap         [-]%s → %s  ·  %d GC runs",
+			formatBytes(int64(before.HeapInuse)), formatBytes(int64(after.HeapInuse)), after.NumGC-before.NumGC),
+	}
+	if mode == "animation" {
+		lines = append(lines, "  [dim]Animations may still be running.[-]")
+	}
+	lines = append(lines, "[dim]└─────────────────────────────────────────────────┘[-]")
+	ui.SafeQueueUpdateDraw(ac.app, "runStress", func() {
+		for _, l := range lines {
+			ac.sendSystem(l)
+		}
+	})
+}
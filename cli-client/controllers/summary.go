@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cli-client/models"
+	"cli-client/ui"
+	"cli-client/views"
+)
+
+// ── Summary ───────────────────────────────────────────────────────────────────
+//
+// /summary [n] sends the last n chat messages of the conversation to the
+// OpenAI-compatible chat completions endpoint in the summary config block
+// and shows its answer as a few bullet lines in a system block — a catch-up
+// after /detach or a long time away. Like /translate, nothing leaves the
+// client unless asked.
+
+const (
+	defaultSummaryCount = 50
+	maxSummaryCount     = 500
+	defaultSummaryLines = 5
+	summaryTimeout      = 60 * time.Second // local models can be slow
+	maxSummaryLine      = 300              // runes shown per bullet
+	summaryHint         = 20               // a re-attach replaying this many suggests /summary
+)
+
+// summaryPrompt is the system prompt; %d is the most bullet lines wanted.
+const summaryPrompt = "You summarize group chat conversations. Reply with at most %d short bullet points, " +
+	"one per line, covering what was discussed, decided or asked. No introduction and no closing remarks."
+
+type chatCompletionRequest struct {
+	Model    string        `json:"model,omitempty"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Error json.RawMessage `json:"error"` // an object with a message, or a string
+}
+
+// summaryTranscript renders msgs as the text to summarize, one per line.
+func summaryTranscript(msgs []*models.Message) string {
+	var b strings.Builder
+	for _, m := range msgs {
+		fmt.Fprintf(&b, "[%s] %s: %s\n", m.FormatTime(), m.Username, m.Content)
+	}
+	return b.String()
+}
+
+// Summarize asks the server in cfg to condense transcript into at most
+// lines bullet lines, returned without their bullets. Safe to call from any
+// goroutine; blocks for one request.
+func Summarize(cfg models.SummaryConfig, transcript string, lines int) ([]string, error) {
+	body, err := json.Marshal(chatCompletionRequest{
+		Model: cfg.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: fmt.Sprintf(summaryPrompt, lines)},
+			{Role: "user", Content: transcript},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
+	}
+	resp, err := newHTTPClient(summaryTimeout).Do(req)
+	if err != nil {
+		return nil, errors.New("summary server unreachable")
+	}
+	defer drainClose(resp.Body)
+
+	var out chatCompletionResponse
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 256*1024))
+	if err := json.Unmarshal(raw, &out); err != nil && resp.StatusCode/100 == 2 {
+		return nil, errors.New("unexpected answer from the summary server")
+	}
+	switch {
+	case len(out.Error) > 0 && string(out.Error) != "null":
+		return nil, errors.New(completionError(out.Error))
+	case resp.StatusCode/100 != 2:
+		return nil, fmt.Errorf("summary server answered HTTP %d", resp.StatusCode)
+	case len(out.Choices) == 0:
+		return nil, errors.New("the summary server sent no answer")
+	}
+	bullets := summaryBullets(out.Choices[0].Message.Content, lines)
+	if len(bullets) == 0 {
+		return nil, errors.New("the summary came back empty")
+	}
+	return bullets, nil
+}
+
+// completionError extracts the text of an OpenAI-style error field.
+func completionError(raw json.RawMessage) string {
+	var obj struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(raw, &obj) == nil && obj.Message != "" {
+		return obj.Message
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil && s != "" {
+		return s
+	}
+	return "the summary server reported an error"
+}
+
+// summaryBullets splits a model's answer into at most limit lines, dropping
+// blank lines and the bullet or number each starts with. If any line has a
+// bullet, lines without one ("Here is a summary:") are dropped too.
+func summaryBullets(text string, limit int) []string {
+	lines := strings.Split(text, "\n")
+	bulleted := false
+	for _, line := range lines {
+		if _, ok := stripBullet(line); ok {
+			bulleted = true
+			break
+		}
+	}
+	var out []string
+	for _, line := range lines {
+		line, ok := stripBullet(line)
+		if line == "" || (bulleted && !ok) {
+			continue
+		}
+		if r := []rune(line); len(r) > maxSummaryLine {
+			line = string(r[:maxSummaryLine-1]) + "…"
+		}
+		out = append(out, line)
+		if len(out) == limit {
+			break
+		}
+	}
+	return out
+}
+
+// stripBullet trims line and removes a leading "-", "*", "•" or "1." and
+// the like. ok reports whether there was one.
+func stripBullet(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if trimmed := strings.TrimLeft(line, "-*•·"); trimmed != line {
+		return strings.TrimSpace(trimmed), true
+	}
+	if i := strings.IndexAny(line, ".)"); i > 0 && i <= 3 && strings.HasPrefix(line[i+1:], " ") {
+		if _, err := strconv.Atoi(line[:i]); err == nil {
+			return strings.TrimSpace(line[i+1:]), true
+		}
+	}
+	return line, false
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// summarize handles /summary. Must be called from the tview event loop.
+func (ac *AppController) summarize(arg string) {
+	cfg := ac.App.Config.Summary
+	if cfg.URL == "" {
+		ac.sendSystem("Summaries are off — set summary.url in the config to an OpenAI-compatible chat completions endpoint.")
+		return
+	}
+	n := defaultSummaryCount
+	if arg != "" {
+		v, err := strconv.Atoi(arg)
+		if err != nil || v < 1 || v > maxSummaryCount {
+			ac.sendSystem(fmt.Sprintf("Usage: /summary %s  —  condense the last n messages, 1 to %d (default %d)",
+				views.Escape("[n]"), maxSummaryCount, defaultSummaryCount))
+			return
+		}
+		n = v
+	}
+	msgs := ac.App.History.Recent(ac.conversationKey(), n)
+	if len(msgs) == 0 {
+		ac.sendSystem("Nothing to summarize in this session's history.")
+		return
+	}
+	lines := cfg.Lines
+	if lines <= 0 {
+		lines = defaultSummaryLines
+	}
+	count, transcript := len(msgs), summaryTranscript(msgs)
+	ac.sendSystem(fmt.Sprintf("[dim]Summarizing the last %d message(s)…[-]", count))
+	ui.SafeGo("summarize", func() {
+		bullets, err := Summarize(cfg, transcript, lines)
+		ui.SafeQueueUpdateDraw(ac.app, "summarize", func() {
+			if err != nil {
+				log.Printf("summary: %v", err)
+				ac.showNotice("Summary failed: "+views.Escape(err.Error()), views.NoticeError)
+				return
+			}
+			title := fmt.Sprintf("┌─ Summary · last %d message(s) ", count)
+			ac.sendSystem("[dim]" + title + strings.Repeat("─", max(1, 50-len([]rune(title)))) + "┐[-]")
+			for _, b := range bullets {
+				ac.sendSystem("  [cyan]•[-] " + views.Escape(b))
+			}
+			ac.sendSystem("[dim]└─────────────────────────────────────────────────┘[-]")
+		})
+	})
+}
@@ -0,0 +1,457 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ── Record and replay ─────────────────────────────────────────────────────────
+//
+// --record FILE writes every HTTP exchange the client makes to a tape: the
+// request, the response status, each chunk of the body as it arrived (so an
+// SSE stream keeps its pacing) and how it ended. --replay FILE answers the
+// client's requests from such a tape instead of the network, so a UI bug
+// caused by one particular relay payload can be reproduced without a relay.
+// (Unrelated to /replay, which redraws messages from the session history.)
+//
+// The tape is JSON lines, one event per line, written as it happens:
+//
+//	{"kind":"start","version":"v1.0.0-dev","time":"2026-10-16T09:00:00Z"}
+//	{"kind":"request","seq":1,"ms":12,"method":"GET","url":"http://…/api/poll?access_key=REDACTED&…"}
+//	{"kind":"response","seq":1,"ms":40,"status":200,"type":"application/json"}
+//	{"kind":"data","seq":1,"ms":41,"data":"[{\"id\":\"msg_1\",…}]"}
+//	{"kind":"end","seq":1,"ms":41}
+//
+// ms counts from the start of the tape. Bodies that are not valid UTF-8 are
+// kept exactly, base64-encoded in data64. Access keys in URLs and request
+// bodies are replaced by REDACTED; message contents are not.
+//
+// On replay each request takes the next exchange recorded for the same
+// method and path, in order, and gets its response after the recorded delay.
+// A GET with nothing left on the tape waits until the client gives up on it,
+// as a quiet long poll would; anything else gets 503.
+
+const tapeRedacted = "REDACTED"
+
+// Tape event kinds.
+const (
+	tapeStart    = "start"
+	tapeRequest  = "request"
+	tapeResponse = "response"
+	tapeData     = "data"
+	tapeEnd      = "end"
+	tapeError    = "error"
+)
+
+// relayTransport carries every request made with newHTTPClient: to the demo
+// relay or over sharedTransport, unless RecordTape or ReplayTape replaced it,
+// which they must do before the first client is created.
+var relayTransport http.RoundTripper = demoRouter{next: sharedTransport}
+
+type tapeEvent struct {
+	Kind    string `json:"kind"`
+	Seq     int    `json:"seq,omitempty"`
+	MS      int64  `json:"ms,omitempty"`
+	Version string `json:"version,omitempty"`
+	Time    string `json:"time,omitempty"`
+
+	Method string `json:"method,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Status int    `json:"status,omitempty"`
+	Type   string `json:"type,omitempty"` // response Content-Type
+	Data   string `json:"data,omitempty"`
+	Data64 string `json:"data64,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// setData stores b in Data, or in Data64 if it is not valid UTF-8.
+func (e *tapeEvent) setData(b []byte) {
+	if utf8.Valid(b) {
+		e.Data = string(b)
+	} else {
+		e.Data64 = base64.StdEncoding.EncodeToString(b)
+	}
+}
+
+func (e *tapeEvent) data() ([]byte, error) {
+	if e.Data64 != "" {
+		return base64.StdEncoding.DecodeString(e.Data64)
+	}
+	return []byte(e.Data), nil
+}
+
+var accessKeyJSON = regexp.MustCompile(`("(?:access|admin)_key"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// redactURL returns u with any access key in the query replaced.
+func redactURL(u *url.URL) string {
+	q := u.Query()
+	for _, k := range []string{"access_key", "admin_key"} {
+		if q.Has(k) {
+			q.Set(k, tapeRedacted)
+		}
+	}
+	c := *u
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// ── Recording ─────────────────────────────────────────────────────────────────
+
+// tapeRecorder is an http.RoundTripper that writes what passes through next
+// to a tape.
+type tapeRecorder struct {
+	next  http.RoundTripper
+	start time.Time
+
+	mu  sync.Mutex
+	w   io.Writer
+	seq int
+}
+
+func newTapeRecorder(w io.Writer, next http.RoundTripper) *tapeRecorder {
+	r := &tapeRecorder{next: next, start: time.Now(), w: w}
+	r.write(tapeEvent{Kind: tapeStart, Version: ClientVersion, Time: r.start.UTC().Format(time.RFC3339)})
+	return r
+}
+
+// write appends e to the tape, unbuffered so a crash loses nothing.
+func (r *tapeRecorder) write(e tapeEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e.Kind != tapeStart {
+		e.MS = time.Since(r.start).Milliseconds()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	r.w.Write(append(line, '\n'))
+}
+
+func (r *tapeRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	r.seq++
+	seq := r.seq
+	r.mu.Unlock()
+
+	ev := tapeEvent{Kind: tapeRequest, Seq: seq, Method: req.Method, URL: redactURL(req.URL)}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		ev.setData(accessKeyJSON.ReplaceAll(body, []byte(`$1"`+tapeRedacted+`"`)))
+	}
+	r.write(ev)
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		r.write(tapeEvent{Kind: tapeError, Seq: seq, Error: err.Error()})
+		return nil, err
+	}
+	r.write(tapeEvent{Kind: tapeResponse, Seq: seq, Status: resp.StatusCode, Type: resp.Header.Get("Content-Type")})
+	resp.Body = &tapeBody{ReadCloser: resp.Body, r: r, seq: seq}
+	return resp, nil
+}
+
+// tapeBody records a response body chunk by chunk as the client reads it.
+type tapeBody struct {
+	io.ReadCloser
+	r    *tapeRecorder
+	seq  int
+	once sync.Once
+}
+
+func (b *tapeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		ev := tapeEvent{Kind: tapeData, Seq: b.seq}
+		ev.setData(p[:n])
+		b.r.write(ev)
+	}
+	switch {
+	case err == io.EOF:
+		b.finish(nil)
+	case err != nil:
+		b.finish(err)
+	}
+	return n, err
+}
+
+func (b *tapeBody) Close() error {
+	b.finish(nil) // closed before EOF: the client stopped reading here
+	return b.ReadCloser.Close()
+}
+
+func (b *tapeBody) finish(err error) {
+	b.once.Do(func() {
+		if err != nil {
+			b.r.write(tapeEvent{Kind: tapeError, Seq: b.seq, Error: err.Error()})
+			return
+		}
+		b.r.write(tapeEvent{Kind: tapeEnd, Seq: b.seq})
+	})
+}
+
+// ── Replaying ─────────────────────────────────────────────────────────────────
+
+// tapeChunk is one piece of a recorded body, due after the response.
+type tapeChunk struct {
+	after time.Duration
+	data  []byte
+}
+
+// tapeExchange is one recorded request and what came back.
+type tapeExchange struct {
+	latency time.Duration // request to response (or to error)
+	status  int
+	ctype   string
+	chunks  []tapeChunk
+	err     string        // the request failed, or the body broke off
+	endAt   time.Duration // when the body ended, after the response
+	ended   bool          // the body ended normally
+}
+
+// tapePlayer is an http.RoundTripper that answers from a tape.
+type tapePlayer struct {
+	mu      sync.Mutex
+	queue   map[string][]*tapeExchange // method + path → exchanges, oldest first
+	warned  map[string]bool
+	pending int
+}
+
+func tapeKey(method string, u *url.URL) string { return method + " " + u.Path }
+
+// loadTape reads a tape written by tapeRecorder.
+func loadTape(r io.Reader) (*tapePlayer, error) {
+	p := &tapePlayer{queue: make(map[string][]*tapeExchange), warned: make(map[string]bool)}
+	type open struct {
+		x        *tapeExchange
+		req, res int64 // ms
+	}
+	bySeq := make(map[int]*open)
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxPollBody*2)
+	line := 0
+	for sc.Scan() {
+		line++
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var e tapeEvent
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if e.Kind == tapeStart {
+			continue
+		}
+		o := bySeq[e.Seq]
+		if e.Kind == tapeRequest {
+			u, err := url.Parse(e.URL)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			o = &open{x: &tapeExchange{}, req: e.MS}
+			bySeq[e.Seq] = o
+			key := tapeKey(e.Method, u)
+			p.queue[key] = append(p.queue[key], o.x)
+			p.pending++
+			continue
+		}
+		if o == nil {
+			return nil, fmt.Errorf("line %d: %s for unknown request %d", line, e.Kind, e.Seq)
+		}
+		switch e.Kind {
+		case tapeResponse:
+			o.res = e.MS
+			o.x.latency = time.Duration(e.MS-o.req) * time.Millisecond
+			o.x.status, o.x.ctype = e.Status, e.Type
+		case tapeData:
+			data, err := e.data()
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			o.x.chunks = append(o.x.chunks, tapeChunk{after: time.Duration(e.MS-o.res) * time.Millisecond, data: data})
+		case tapeEnd, tapeError:
+			if o.x.status == 0 {
+				o.x.latency = time.Duration(e.MS-o.req) * time.Millisecond
+			}
+			o.x.endAt = time.Duration(e.MS-o.res) * time.Millisecond
+			o.x.err, o.x.ended = e.Error, e.Kind == tapeEnd
+		default:
+			return nil, fmt.Errorf("line %d: unknown event %q", line, e.Kind)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if p.pending == 0 {
+		return nil, errors.New("no requests on the tape")
+	}
+	return p, nil
+}
+
+// next takes the next exchange recorded for req, or nil.
+func (p *tapePlayer) next(req *http.Request) *tapeExchange {
+	key := tapeKey(req.Method, req.URL)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	q := p.queue[key]
+	if len(q) == 0 {
+		if !p.warned[key] {
+			p.warned[key] = true
+			log.Printf("TRACE replay: nothing (more) on the tape for %s", key)
+		}
+		return nil
+	}
+	p.queue[key] = q[1:]
+	p.pending--
+	if p.pending == 0 {
+		log.Printf("TRACE replay: tape finished")
+	}
+	return q[0]
+}
+
+func (p *tapePlayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		drainClose(req.Body)
+	}
+	ctx := req.Context()
+	x := p.next(req)
+	if x == nil {
+		if req.Method == http.MethodGet {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &http.Response{
+			Status:     "503 Service Unavailable",
+			StatusCode: http.StatusServiceUnavailable,
+			Header:     http.Header{"Content-Type": {"text/plain"}},
+			Body:       io.NopCloser(strings.NewReader("not on the replay tape\n")),
+			Request:    req,
+		}, nil
+	}
+
+	if err := sleepCtx(ctx, x.latency); err != nil {
+		return nil, err
+	}
+	if x.status == 0 {
+		return nil, fmt.Errorf("replay: %s", x.err)
+	}
+
+	pr, pw := io.Pipe()
+	go playBody(ctx, x, pw)
+	header := http.Header{}
+	if x.ctype != "" {
+		header.Set("Content-Type", x.ctype)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", x.status, http.StatusText(x.status)),
+		StatusCode:    x.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          pr,
+		ContentLength: -1,
+		Request:       req,
+	}, nil
+}
+
+// playBody writes x's body to pw at its recorded pace. A body that was never
+// finished on the tape — the client quit mid-stream — stays open until the
+// request is cancelled.
+func playBody(ctx context.Context, x *tapeExchange, pw *io.PipeWriter) {
+	start := time.Now()
+	wait := func(at time.Duration) error { return sleepCtx(ctx, at-time.Since(start)) }
+	for _, c := range x.chunks {
+		if err := wait(c.after); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if _, err := pw.Write(c.data); err != nil {
+			return // the client closed the body
+		}
+	}
+	switch {
+	case x.ended:
+		wait(x.endAt)
+		pw.Close()
+	case x.err != "":
+		wait(x.endAt)
+		pw.CloseWithError(fmt.Errorf("replay: %s", x.err))
+	default:
+		<-ctx.Done()
+		pw.CloseWithError(ctx.Err())
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// replaying reports whether requests are answered from a tape.
+func replaying() bool {
+	_, ok := relayTransport.(*tapePlayer)
+	return ok
+}
+
+// ── Entry points ──────────────────────────────────────────────────────────────
+
+// RecordTape starts writing every HTTP exchange to the tape at path,
+// replacing any file there. Call it before any client is created; the
+// returned function closes the tape.
+func RecordTape(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	relayTransport = newTapeRecorder(f, relayTransport)
+	log.Printf("TRACE RecordTape: recording to %s", path)
+	return func() { f.Close() }, nil
+}
+
+// ReplayTape answers every HTTP request from the tape at path; nothing goes
+// out on the network. Call it before any client is created.
+func ReplayTape(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	p, err := loadTape(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	relayTransport = p
+	log.Printf("TRACE ReplayTape: %d exchanges from %s", p.pending, path)
+	return nil
+}
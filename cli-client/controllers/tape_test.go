@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"cli-client/internal/relaytest"
+)
+
+func TestTapeReplaysRecordedSession(t *testing.T) {
+	defer func(saved http.RoundTripper) { relayTransport = saved }(relayTransport)
+
+	// Record: a malformed body, then three messages.
+	relay := relaytest.New()
+	relay.Fail(relaytest.Poll, relaytest.Fault{Body: "[\xff\xfe]"})
+	var tape bytes.Buffer
+	rec := newTapeRecorder(&tape, sharedTransport)
+	relayTransport = rec
+
+	live := newTestClient(t, relay)
+	relay.Post("alice", "one", "green")
+	relay.Post("bob", "[two] 🙂", "cyan")
+	relay.Post("alice", "three", "green")
+	var want []string
+	for i := 0; i < 3; i++ {
+		want = append(want, live.next(t).Content)
+	}
+	live.Stop()
+	relay.Close()
+
+	rec.mu.Lock()
+	recorded := tape.String()
+	rec.mu.Unlock()
+	if strings.Contains(recorded, relaytest.AccessKey) {
+		t.Error("access key on the tape")
+	}
+
+	// Replay with the relay gone.
+	player, err := loadTape(strings.NewReader(recorded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	relayTransport = player
+	replayed := newTestClient(t, relay)
+	for i, w := range want {
+		if got := replayed.next(t).Content; got != w {
+			t.Fatalf("message %d: got %q, want %q", i, got, w)
+		}
+	}
+	if n := replayed.Drops()[dropEncoding]; n != 1 {
+		t.Errorf("%s drops on replay = %d, want 1", dropEncoding, n)
+	}
+}
+
+func TestLoadTapeRejectsGarbage(t *testing.T) {
+	for _, tape := range []string{
+		"",
+		`{"kind":"start"}`,
+		`{"kind":"response","seq":7,"status":200}`,
+		"not json",
+	} {
+		if _, err := loadTape(strings.NewReader(tape)); err == nil {
+			t.Errorf("loadTape(%q): no error", tape)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"cli-client/models"
+	"cli-client/views"
+)
+
+// ── Templates ─────────────────────────────────────────────────────────────────
+//
+// /template save <name> <text> keeps a snippet under "templates" in the
+// config; /t <name> puts it in the input to be looked over and sent. A
+// {placeholder} in the text is asked for first: the input asks for each one
+// in turn and the next line sent is its value. Values can also follow the
+// name, in order, the last taking the rest of the line — /t refund Alice
+// 12.50 — and only the missing ones are asked for. Any command while asking
+// drops the template.
+
+// templateField matches a placeholder.
+var templateField = regexp.MustCompile(`\{(\w+)\}`)
+
+// templateFill is a template waiting for the values of its placeholders.
+type templateFill struct {
+	name   string
+	text   string
+	fields []string          // placeholders, each once, in order of appearance
+	values map[string]string // the ones answered
+}
+
+// next returns the first placeholder not yet answered, or "".
+func (f *templateFill) next() string {
+	for _, field := range f.fields {
+		if _, ok := f.values[field]; !ok {
+			return field
+		}
+	}
+	return ""
+}
+
+// expand returns the text with every placeholder replaced by its value.
+func (f *templateFill) expand() string {
+	return templateField.ReplaceAllStringFunc(f.text, func(m string) string {
+		return f.values[m[1:len(m)-1]]
+	})
+}
+
+// template handles /template. Must be called from the tview event loop.
+func (ac *AppController) template(arg string) {
+	sub, rest, _ := strings.Cut(arg, " ")
+	name, text, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	sub, name, text = strings.ToLower(sub), strings.ToLower(name), strings.TrimSpace(text)
+	templates := ac.App.Config.Templates
+
+	switch sub {
+	case "":
+		if len(templates) == 0 {
+			ac.sendSystem("No templates yet — /template save <name> <text>, with {placeholders} asked for by /t.")
+			return
+		}
+		ac.sendSystem("[yellow]Templates[-]  [dim]— /t <name> [values…][-]")
+		names := make([]string, 0, len(templates))
+		for n := range templates {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			ac.sendSystem(fmt.Sprintf("  [cyan]%s[-]  %s", n, views.Escape(templates[n])))
+		}
+		return
+
+	case "save":
+		if !models.IsTemplateName(name) || text == "" {
+			ac.sendSystem("Usage: /template save <name> <text>  —  names are 1 to 32 of a-z, 0-9, - and _")
+			return
+		}
+		templates = maps.Clone(templates)
+		if templates == nil {
+			templates = make(map[string]string)
+		}
+		templates[name] = text
+
+	case "delete":
+		if _, ok := templates[name]; !ok {
+			ac.sendSystem("No template named " + views.Escape(name) + ".")
+			return
+		}
+		templates = maps.Clone(templates)
+		delete(templates, name)
+
+	default:
+		ac.sendSystem("Usage: /template [save <name> <text>|delete <name>]")
+		return
+	}
+
+	ac.App.Config.Templates = templates
+	if err := models.SaveConfigKey("templates", templates); err != nil {
+		ac.sendSystem("[red]Templates not saved:[-] " + views.Escape(err.Error()) + " — the change lasts until you quit.")
+	}
+	if sub == "delete" {
+		ac.sendSystem("Template " + name + " deleted.")
+		return
+	}
+	msg := "Template " + name + " saved — /t " + name
+	if fields := templateFields(text); len(fields) > 0 {
+		msg += " asks for " + strings.Join(fields, ", ")
+	}
+	ac.sendSystem(views.Escape(msg) + ".")
+}
+
+// templateFields lists the placeholders of text, each once, in order.
+func templateFields(text string) []string {
+	var fields []string
+	for _, m := range templateField.FindAllStringSubmatch(text, -1) {
+		if !slices.Contains(fields, m[1]) {
+			fields = append(fields, m[1])
+		}
+	}
+	return fields
+}
+
+// useTemplate handles /t. Must be called from the tview event loop.
+func (ac *AppController) useTemplate(arg string) {
+	name, values, _ := strings.Cut(arg, " ")
+	name = strings.ToLower(name)
+	text, ok := ac.App.Config.Templates[name]
+	if !ok {
+		if name != "" {
+			ac.sendSystem("No template named " + views.Escape(name) + ".")
+		}
+		ac.sendSystem("Usage: /t <name> [values…]  —  /template lists them")
+		return
+	}
+	f := &templateFill{name: name, text: text, fields: templateFields(text), values: make(map[string]string)}
+	words := strings.Fields(values)
+	for i, field := range f.fields {
+		if i >= len(words) {
+			break
+		}
+		if i == len(f.fields)-1 {
+			f.values[field] = strings.Join(words[i:], " ")
+			break
+		}
+		f.values[field] = words[i]
+	}
+	ac.fill = f
+	if f.next() != "" {
+		ac.sendSystem("[dim]Template " + name + " — type each value and press Enter; a /command drops it.[-]")
+	}
+	ac.askTemplate()
+}
+
+// askTemplate asks for the next placeholder of ac.fill, or, with all of them
+// answered, puts the expanded text in the input.
+func (ac *AppController) askTemplate() {
+	f := ac.fill
+	chat, ok := ac.chatView()
+	if !ok {
+		ac.fill = nil
+		return
+	}
+	if field := f.next(); field != "" {
+		chat.AskInput(fmt.Sprintf("%s? (%s, %d of %d)", field, f.name, len(f.values)+1, len(f.fields)))
+		return
+	}
+	ac.fill = nil
+	chat.AskInput("")
+	chat.SetDraft(f.expand())
+}
+
+// answerTemplate takes line as the value of the placeholder being asked
+// for.
+func (ac *AppController) answerTemplate(line string) {
+	ac.fill.values[ac.fill.next()] = strings.TrimSpace(line)
+	ac.askTemplate()
+}
+
+// dropTemplate stops asking for placeholders.
+func (ac *AppController) dropTemplate() {
+	if ac.fill == nil {
+		return
+	}
+	ac.sendSystem("[dim]Template " + ac.fill.name + " dropped.[-]")
+	ac.fill = nil
+	if chat, ok := ac.chatView(); ok {
+		chat.AskInput("")
+	}
+}
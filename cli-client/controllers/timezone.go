@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"cli-client/models"
+	"cli-client/views"
+)
+
+// ── Time zone hints ───────────────────────────────────────────────────────────
+//
+// With share_time_zone set, our time zone rides along in the join
+// announcement and in /whois replies (controlFrame.Zone), so /whois can show
+// someone's local time. It is never sent otherwise.
+
+// maxZoneName bounds the zone name accepted from a peer.
+const maxZoneName = 64
+
+// sharedZone returns the zone name to send to peers, or "" if we don't share
+// it. Must be called from the tview event loop.
+func (ac *AppController) sharedZone() string {
+	if !ac.App.Config.ShareTimeZone {
+		return ""
+	}
+	loc, err := models.LoadZone(ac.App.Config.TimeZone)
+	if err != nil {
+		return ""
+	}
+	return models.ZoneName(loc)
+}
+
+// theirTime renders the local time in a zone a peer shared, or "" if it is
+// missing or not a zone we can resolve.
+func theirTime(zone string) string {
+	if zone == "" || len(zone) > maxZoneName {
+		return ""
+	}
+	loc, err := models.LoadZone(zone)
+	if err != nil || loc == nil {
+		return ""
+	}
+	now := models.Now().In(loc)
+	return "their local time: " + now.Format("15:04") + " (" + views.Escape(zone) + ")"
+}
+
+// noteZone records the zone a peer shared, if it resolves.
+func (ac *AppController) noteZone(user, zone string) {
+	if theirTime(zone) != "" {
+		ac.trackUser(user).Zone = zone
+	}
+}
+
+// zoneSuffix is theirTime as a "  |  …" suffix for /whois, or "".
+func zoneSuffix(zone string) string {
+	if s := theirTime(zone); s != "" {
+		return "  |  " + s
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// ── Terminal title ────────────────────────────────────────────────────────────
+//
+// With "terminal_title" on, the terminal window — and the tmux or screen
+// window, see ui/title.go — is named after the conversation, "TTC — host".
+// Chat messages that arrived while the terminal was out of focus are counted
+// into it, "TTC — host (3 unread)", until focus comes back (see focus.go).
+
+// showTitle sets the terminal title for the current conversation. Must be
+// called from the tview event loop.
+func (ac *AppController) showTitle() {
+	if !ac.App.Config.TerminalTitle || ac.App.CurrentUser == nil {
+		return
+	}
+	title := "TTC — " + ac.roomTitle()
+	if n := ac.focus.unread; n > 0 {
+		title += fmt.Sprintf(" (%d unread)", n)
+	}
+	if title != ac.titleShown {
+		ac.titleShown = title
+		ac.app.SetTitle(title)
+	}
+}
+
+// roomTitle names the current conversation for the title: the relay's host,
+// or "LAN".
+func (ac *AppController) roomTitle() string {
+	if ac.lan != nil {
+		return "LAN"
+	}
+	if u, err := url.Parse(DefaultServerURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return DefaultServerURL
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cli-client/models"
+	"cli-client/paths"
+	"sort"
+)
+
+// ── Daily transcripts ─────────────────────────────────────────────────────────
+//
+// With "transcripts" set in the config, every chat message filed in the
+// session history — sent, received, or replayed after a detach — is also
+// appended to a file for its day under transcriptDir in the data directory:
+// 2006-01-02.txt as plain lines to grep, or 2006-01-02.jsonl with one JSON
+// object per line. Days follow time_zone. System lines are not written,
+// and edits and expiries do not rewrite what is already there.
+//
+// The relay hands its recent messages to every client that connects, so
+// after a restart or a /server switch some arrive again. Those from before
+// the connection (see isBacklog) are only written if they are newer than
+// the conversation's last line — found in the newest day file at start.
+
+const transcriptDir = "transcripts"
+
+// transcriptEntry is one line of a JSON transcript.
+type transcriptEntry struct {
+	Time     time.Time `json:"time"`
+	Room     string    `json:"room"`
+	Username string    `json:"username"`
+	Content  string    `json:"content"`
+	Sticker  string    `json:"sticker,omitempty"`
+}
+
+// transcriptWriter appends messages to the day files. Only used inside the
+// tview event loop.
+type transcriptWriter struct {
+	format  string               // models.TranscriptsText or models.TranscriptsJSON
+	backlog func(time.Time) bool // whether a message sent then is from before the connection
+	last    map[string]time.Time // room → latest message written
+	failed  bool                 // an error was logged; later ones are not
+}
+
+// ext is the day files' extension.
+func (w *transcriptWriter) ext() string {
+	if w.format == models.TranscriptsJSON {
+		return ".jsonl"
+	}
+	return ".txt"
+}
+
+// write appends msg, said in room, to the file for its day.
+func (w *transcriptWriter) write(room string, msg *models.Message) {
+	if msg.IsSystem || msg.Pending > 0 {
+		return
+	}
+	last := w.last[room]
+	if w.backlog(msg.Timestamp) && !msg.Timestamp.After(last) {
+		return // handed over again on connecting
+	}
+	if msg.Timestamp.After(last) {
+		w.last[room] = msg.Timestamp
+	}
+
+	at := models.InZone(msg.Timestamp)
+	var line []byte
+	if w.format == models.TranscriptsJSON {
+		line, _ = json.Marshal(transcriptEntry{
+			Time:     msg.Timestamp,
+			Room:     room,
+			Username: msg.Username,
+			Content:  msg.Content,
+			Sticker:  msg.Sticker,
+		})
+		line = append(line, '\n')
+	} else {
+		// Lines after the first are indented, so every line that starts
+		// without a tab starts a message.
+		content := strings.ReplaceAll(msg.Content, "\n", "\n\t")
+		line = []byte(fmt.Sprintf("%s  %s  <%s> %s\n", at.Format("15:04:05"), room, msg.Username, content))
+	}
+
+	dir := paths.Data(transcriptDir)
+	err := os.MkdirAll(dir, 0700)
+	if err == nil {
+		err = appendFile(filepath.Join(dir, at.Format("2006-01-02")+w.ext()), line)
+	}
+	if err != nil && !w.failed {
+		w.failed = true
+		log.Printf("transcripts: %v — not logging further errors", err)
+	}
+}
+
+// loadLast reads when each conversation's last line was said from the
+// newest day file.
+func (w *transcriptWriter) loadLast() {
+	files, _ := filepath.Glob(filepath.Join(paths.Data(transcriptDir), "*"+w.ext()))
+	if len(files) == 0 {
+		return
+	}
+	sort.Strings(files) // dated names sort by day
+	newest := files[len(files)-1]
+	data, err := os.ReadFile(newest)
+	if err != nil {
+		log.Printf("transcripts: %v", err)
+		return
+	}
+	day := strings.TrimSuffix(filepath.Base(newest), w.ext())
+	loc := models.InZone(time.Now()).Location()
+	for _, line := range strings.Split(string(data), "\n") {
+		var room string
+		var at time.Time
+		if w.format == models.TranscriptsJSON {
+			var e transcriptEntry
+			if json.Unmarshal([]byte(line), &e) != nil {
+				continue
+			}
+			room, at = e.Room, e.Time
+		} else {
+			fields := strings.SplitN(line, "  ", 3)
+			if len(fields) < 3 || strings.HasPrefix(line, "\t") {
+				continue // a message's later lines
+			}
+			var err error
+			if at, err = time.ParseInLocation("2006-01-02 15:04:05", day+" "+fields[0], loc); err != nil {
+				continue
+			}
+			room = fields[1]
+		}
+		if at.After(w.last[room]) {
+			w.last[room] = at
+		}
+	}
+}
+
+// appendFile adds data to the end of path, creating it if needed.
+func appendFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// startTranscripts writes the session history to the day files from now on,
+// if the config asks for it. Must be called from the tview event loop.
+func (ac *AppController) startTranscripts() {
+	format := ac.App.Config.Transcripts
+	if format == "" {
+		ac.App.History.OnAdd(nil)
+		return
+	}
+	w := &transcriptWriter{format: format, backlog: ac.isBacklog, last: make(map[string]time.Time)}
+	w.loadLast()
+	ac.App.History.OnAdd(w.write)
+}
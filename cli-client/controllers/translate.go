@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"cli-client/models"
+	"cli-client/ui"
+	"cli-client/views"
+)
+
+// ── Translation ───────────────────────────────────────────────────────────────
+//
+// /translate [n] [lang] sends the nth latest chat message of the conversation
+// to the LibreTranslate-compatible server in the translate config block and
+// shows the answer as a dim line under the original. The message text leaves
+// the client for that server, so nothing is translated unless asked.
+
+const (
+	defaultTranslateTarget = "en"
+	translateTimeout       = 15 * time.Second
+)
+
+type translateRequest struct {
+	Q      string `json:"q"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Format string `json:"format"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+type translateResponse struct {
+	TranslatedText   string `json:"translatedText"`
+	DetectedLanguage struct {
+		Language string `json:"language"`
+	} `json:"detectedLanguage"`
+	Error string `json:"error"`
+}
+
+// Translate asks the server in cfg to translate text into target, detecting
+// the source language. It returns the translation and the detected language,
+// if the server reported one. Safe to call from any goroutine; blocks for one
+// request.
+func Translate(cfg models.TranslateConfig, text, target string) (string, string, error) {
+	body, err := json.Marshal(translateRequest{
+		Q:      text,
+		Source: "auto",
+		Target: target,
+		Format: "text",
+		APIKey: cfg.APIKey,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	resp, err := newHTTPClient(translateTimeout).Post(cfg.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", "", errors.New("translation server unreachable")
+	}
+	defer drainClose(resp.Body)
+
+	var out translateResponse
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err := json.Unmarshal(raw, &out); err != nil && resp.StatusCode/100 == 2 {
+		return "", "", errors.New("unexpected answer from the translation server")
+	}
+	switch {
+	case out.Error != "":
+		return "", "", errors.New(out.Error)
+	case resp.StatusCode/100 != 2:
+		return "", "", fmt.Errorf("translation server answered HTTP %d", resp.StatusCode)
+	}
+	return out.TranslatedText, out.DetectedLanguage.Language, nil
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// translate handles /translate. Must be called from the tview event loop.
+func (ac *AppController) translate(arg string) {
+	cfg := ac.App.Config.Translate
+	if cfg.URL == "" {
+		ac.sendSystem("Translation is off — set translate.url in the config to a LibreTranslate server.")
+		return
+	}
+	n, target := 1, cfg.Target
+	if target == "" {
+		target = defaultTranslateTarget
+	}
+	for _, f := range strings.Fields(arg) {
+		v, err := strconv.Atoi(f)
+		switch {
+		case err == nil && v >= 1:
+			n = v
+		case err == nil || len(f) > 8:
+			ac.sendSystem("Usage: /translate [n] [lang]  —  n = 1 for the latest message; lang e.g. de (default " + target + ")")
+			return
+		default:
+			target = strings.ToLower(f)
+		}
+	}
+
+	msg := ac.App.History.Last(ac.conversationKey(), n)
+	if msg == nil {
+		ac.sendSystem("No such message in this session's history.")
+		return
+	}
+	id, text := msg.ID, msg.Content
+	ui.SafeGo("translate", func() {
+		out, from, err := Translate(cfg, text, target)
+		ui.SafeQueueUpdateDraw(ac.app, "translate", func() {
+			if err != nil {
+				log.Printf("translate: %v", err)
+				ac.showNotice("Translation failed: "+views.Escape(err.Error()), views.NoticeError)
+				return
+			}
+			note := target + ": " + out
+			if from != "" && from != target {
+				note = from + " → " + note
+			}
+			if chat, ok := ac.chatView(); ok && id != "" && chat.AddNote(id, note) {
+				return
+			}
+			// The line is gone (cleared, or never tagged): show it on its own.
+			ac.sendSystem("[dim]↳ " + views.Escape(note) + "[-]")
+		})
+	})
+}
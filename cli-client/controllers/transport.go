@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"context"
+)
+
+// ── HTTP transport ────────────────────────────────────────────────────────────
+//
+// Every request to the relay (send, poll, stats, handshake, health) goes
+// through one shared Transport so idle keep-alive connections are reused
+// instead of re-dialing and re-handshaking TLS for each call. HTTP/2 is
+// negotiated via ALPN on https relays; plain http stays on HTTP/1.1.
+//
+// With a Tor SOCKS5 proxy configured (Config.TorProxy) every relay request is
+// routed through it, and host names are resolved by the proxy, so .onion
+// relay URLs work and no DNS query leaks. A .onion relay without a
+// configured proxy uses Tor's default local port.
+
+const (
+	dialTimeout         = 10 * time.Second // until SetConnectTimeout
+	tlsHandshakeTimeout = 10 * time.Second
+
+	// responseHeaderTimeout must outlast the server's 30s long-poll hold and
+	// the largest poll timeout models.Config accepts.
+	responseHeaderTimeout = 2 * time.Minute
+
+	requestTimeout = 5 * time.Second // stats, handshake and other short calls
+)
+
+// defaultTorProxy is the SOCKS port of a stock local Tor daemon.
+const defaultTorProxy = "socks5://127.0.0.1:9050"
+
+var torProxy atomic.Pointer[url.URL] // nil = no Tor proxy configured
+
+var connectTimeout atomic.Int64 // ns; 0 = dialTimeout
+
+var sharedTransport = &http.Transport{
+	Proxy:                 relayProxy,
+	DialContext:           dialRelay,
+	ForceAttemptHTTP2:     true, // a custom DialContext disables it otherwise
+	TLSHandshakeTimeout:   tlsHandshakeTimeout,
+	ResponseHeaderTimeout: responseHeaderTimeout,
+	ExpectContinueTimeout: time.Second,
+	MaxIdleConns:          16,
+	MaxIdleConnsPerHost:   4, // poll + concurrent sends + stats
+	IdleConnTimeout:       90 * time.Second,
+}
+
+// SetTorProxy routes all relay traffic through the given SOCKS5 proxy, e.g.
+// "socks5://127.0.0.1:9050". An empty string turns it off.
+func SetTorProxy(raw string) error {
+	if raw == "" {
+		torProxy.Store(nil)
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "socks5" || u.Host == "" {
+		return fmt.Errorf("tor proxy must look like socks5://host:port, got %q", raw)
+	}
+	torProxy.Store(u)
+	sharedTransport.CloseIdleConnections() // drop direct connections
+	return nil
+}
+
+// SetConnectTimeout bounds how long opening a connection to the relay (or
+// the Tor proxy) may take. It does not limit how long a request then waits
+// for its answer, which for a long poll is most of a minute.
+func SetConnectTimeout(d time.Duration) {
+	connectTimeout.Store(int64(d))
+}
+
+// dialRelay dials with the connect timeout in force.
+func dialRelay(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}
+	if t := connectTimeout.Load(); t > 0 {
+		d.Timeout = time.Duration(t)
+	}
+	return d.DialContext(ctx, network, addr)
+}
+
+// relayProxy picks the proxy for a relay request.
+func relayProxy(req *http.Request) (*url.URL, error) {
+	if p := torProxy.Load(); p != nil {
+		return p, nil
+	}
+	if isOnion(req.URL.Hostname()) {
+		log.Printf("TRACE relayProxy: %s is an onion address, using %s", req.URL.Host, defaultTorProxy)
+		return url.Parse(defaultTorProxy)
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// ViaTor reports whether requests to serverURL are routed over Tor.
+func ViaTor(serverURL string) bool {
+	if torProxy.Load() != nil {
+		return true
+	}
+	u, err := url.Parse(serverURL)
+	return err == nil && isOnion(u.Hostname())
+}
+
+func isOnion(host string) bool {
+	return strings.HasSuffix(strings.ToLower(host), ".onion")
+}
+
+// newHTTPClient returns a client on the shared transport (or the record or
+// replay tape, see tape.go) with an overall per-request timeout.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: relayTransport, Timeout: timeout}
+}
+
+// drainClose reads the rest of body before closing it so the underlying
+// connection goes back to the idle pool instead of being torn down.
+func drainClose(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, 64*1024))
+	body.Close()
+}
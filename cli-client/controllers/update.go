@@ -0,0 +1,329 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"cli-client/crypto"
+	"cli-client/ui"
+	"cli-client/views"
+)
+
+// ── Updates ───────────────────────────────────────────────────────────────────
+//
+// With "update_check" on, the client asks GitHub for the latest release when
+// a chat session starts and shows a footer hint if it is newer. /update then
+// downloads this platform's binary, verifies it against the release's
+// checksums.txt (sha256sum format) and swaps it in place of the running
+// executable; the new version runs from the next start.
+//
+// checksums.txt itself must come with checksums.txt.sig, a signature by the
+// release key this binary was built with (ReleaseKey, see version.go).
+// cmd/sign-release, a separate release tool, makes one. A build without a
+// key installs nothing.
+//
+// Release assets are expected to be named cli-client_<goos>_<goarch>, plus
+// ".exe" on Windows.
+
+// releasesURL is the GitHub API endpoint for the latest release.
+const releasesURL = "https://api.github.com/repos/mortza-mansory/TTC-cli-messanger/releases/latest"
+
+const maxUpdateSize = 100 << 20 // refuse absurdly large downloads
+
+const (
+	checksumsAsset = "checksums.txt"
+	signatureAsset = checksumsAsset + ".sig"
+)
+
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Release is the part of a GitHub release the updater needs.
+type Release struct {
+	Tag    string         `json:"tag_name"`
+	Assets []releaseAsset `json:"assets"`
+}
+
+// LatestRelease fetches the newest published release.
+func LatestRelease() (*Release, error) {
+	req, err := http.NewRequest(http.MethodGet, releasesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "cli-client/"+ClientVersion)
+	resp, err := newHTTPClient(15 * time.Second).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer drainClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub HTTP %d", resp.StatusCode)
+	}
+	var r Release
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("decode release: %w", err)
+	}
+	return &r, nil
+}
+
+// Newer reports whether the release is newer than this build.
+func (r *Release) Newer() bool {
+	return compareVersions(r.Tag, ClientVersion) > 0
+}
+
+func (r *Release) asset(name string) (releaseAsset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return releaseAsset{}, false
+}
+
+// binaryAssetName is the release asset for this platform.
+func binaryAssetName() string {
+	name := "cli-client_" + runtime.GOOS + "_" + runtime.GOARCH
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Install downloads this platform's binary from r, checks it against the
+// release checksums and replaces the running executable with it.
+func (r *Release) Install() error {
+	bin, ok := r.asset(binaryAssetName())
+	if !ok {
+		return fmt.Errorf("release %s has no %s", r.Tag, binaryAssetName())
+	}
+	if ReleaseKey == "" {
+		return errors.New("this build has no release key to verify downloads with — install releases by hand")
+	}
+	sums, ok := r.asset(checksumsAsset)
+	if !ok {
+		return fmt.Errorf("release %s has no %s — refusing to install unverified", r.Tag, checksumsAsset)
+	}
+	sig, ok := r.asset(signatureAsset)
+	if !ok {
+		return fmt.Errorf("release %s has no %s — refusing to install unverified", r.Tag, signatureAsset)
+	}
+
+	client := newHTTPClient(5 * time.Minute)
+	list, err := fetchAsset(client, sums.URL, checksumsAsset)
+	if err != nil {
+		return err
+	}
+	signature, err := fetchAsset(client, sig.URL, signatureAsset)
+	if err != nil {
+		return err
+	}
+	if !crypto.VerifyRelease(ReleaseKey, list, strings.TrimSpace(string(signature))) {
+		return fmt.Errorf("%s is not signed by this build's release key", checksumsAsset)
+	}
+	want, err := findChecksum(list, bin.Name)
+	if err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate executable: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("locate executable: %w", err)
+	}
+
+	// Download next to the executable so the final rename stays on one
+	// filesystem.
+	tmp := exe + ".new"
+	got, err := download(client, bin.URL, tmp)
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if got != want {
+		os.Remove(tmp)
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", bin.Name, got, want)
+	}
+
+	// A running binary cannot be overwritten on Windows, but it can be
+	// renamed — move it aside first, then put the new one in its place.
+	old := exe + ".old"
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("move current binary aside: %w", err)
+	}
+	if err := os.Rename(tmp, exe); err != nil {
+		os.Rename(old, exe)
+		return fmt.Errorf("install new binary: %w", err)
+	}
+	log.Printf("update: installed %s over %s (previous kept as %s)", r.Tag, exe, old)
+	return nil
+}
+
+// fetchAsset downloads a small release asset, such as the checksum file.
+func fetchAsset(client *http.Client, url, name string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer drainClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: HTTP %d", name, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return data, nil
+}
+
+// findChecksum returns the sha256 listed for name in a sha256sum file.
+func findChecksum(list []byte, name string) (string, error) {
+	for _, line := range strings.Split(string(list), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no entry for %s", checksumsAsset, name)
+}
+
+// download writes url to path (mode 0755) and returns its sha256.
+func download(client *http.Client, url, path string) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer drainClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download: HTTP %d", resp.StatusCode)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), io.LimitReader(resp.Body, maxUpdateSize+1))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("download: %w", err)
+	}
+	if n > maxUpdateSize {
+		return "", fmt.Errorf("download: larger than %d MB", maxUpdateSize>>20)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// compareVersions orders "v1.2.3" style versions; a pre-release suffix
+// ("v1.0.0-dev") sorts before the plain release.
+func compareVersions(a, b string) int {
+	parse := func(v string) ([3]int, bool) {
+		v = strings.TrimPrefix(v, "v")
+		pre := false
+		if i := strings.IndexAny(v, "-+"); i >= 0 {
+			pre = v[i] == '-'
+			v = v[:i]
+		}
+		var n [3]int
+		for i, p := range strings.SplitN(v, ".", 3) {
+			n[i], _ = strconv.Atoi(p)
+		}
+		return n, pre
+	}
+	na, preA := parse(a)
+	nb, preB := parse(b)
+	for i := range na {
+		if na[i] != nb[i] {
+			if na[i] > nb[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preB:
+		return 1
+	}
+	return -1
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// checkForUpdate looks for a newer release in the background and shows the
+// footer hint when there is one. Called from the tview event loop.
+func (ac *AppController) checkForUpdate() {
+	ui.SafeGo("checkForUpdate", func() {
+		r, err := LatestRelease()
+		if err != nil {
+			log.Printf("update check: %v", err)
+			return
+		}
+		if !r.Newer() {
+			return
+		}
+		ui.SafeQueueUpdateDraw(ac.app, "checkForUpdate", func() {
+			if chat, ok := ac.chatView(); ok {
+				chat.SetSegment("update", "  [green]⬆ "+views.Escape(r.Tag)+"[-]")
+			}
+			if ReleaseKey == "" {
+				ac.sendSystem(fmt.Sprintf("Version [green]%s[-] is available.", views.Escape(r.Tag)))
+				return
+			}
+			ac.sendSystem(fmt.Sprintf("Version [green]%s[-] is available — /update to install it.", views.Escape(r.Tag)))
+		})
+	})
+}
+
+// runUpdate handles /update. Must be called from the tview event loop.
+func (ac *AppController) runUpdate() {
+	if ac.updating {
+		ac.sendSystem("Update already in progress…")
+		return
+	}
+	ac.updating = true
+	ac.sendSystem("Checking for updates…")
+	ui.SafeGo("runUpdate", func() {
+		msg := ac.installUpdate()
+		ui.SafeQueueUpdateDraw(ac.app, "runUpdate", func() {
+			ac.updating = false
+			ac.sendSystem(msg)
+		})
+	})
+}
+
+// installUpdate does the work for /update and returns the outcome to show.
+// Runs on its own goroutine.
+func (ac *AppController) installUpdate() string {
+	r, err := LatestRelease()
+	if err != nil {
+		return fmt.Sprintf("[red]Update check failed:[-] %v", err)
+	}
+	if !r.Newer() {
+		return fmt.Sprintf("You are up to date (%s).", ClientVersion)
+	}
+	if err := r.Install(); err != nil {
+		return fmt.Sprintf("[red]Update to %s failed:[-] %s", views.Escape(r.Tag), views.Escape(err.Error()))
+	}
+	return fmt.Sprintf("Installed [green]%s[-] (signature and checksum verified) — restart the client to use it.", views.Escape(r.Tag))
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"runtime/debug"
+	"strings"
+
+	"cli-client/crypto"
+	"cli-client/views"
+)
+
+// Build metadata. Release builds set these with the linker:
+//
+//	go build -ldflags "-X cli-client/controllers.ClientVersion=v1.2.0
+//	  -X cli-client/controllers.Commit=$(git rev-parse HEAD)
+//	  -X cli-client/controllers.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+//	  -X cli-client/controllers.ReleaseKey=<public key>"
+//
+// Anything left unset is filled in from what the Go toolchain records about
+// the build — the module version for "go install …@v1.2.0", the VCS revision
+// and commit time for a build from a checkout.
+var (
+	// ClientVersion is reported in /info and to remote clients answering
+	// /whois.
+	ClientVersion string
+	// Commit is the source revision the binary was built from.
+	Commit string
+	// BuildDate is when the binary was built, RFC 3339.
+	BuildDate string
+	// ReleaseKey is the Base64 Ed25519 public key release checksum files
+	// are signed with. Without it /update refuses to install anything.
+	ReleaseKey string
+)
+
+// devVersion is the version of a build that says nothing about its own.
+const devVersion = "v0.0.0-dev"
+
+func init() {
+	info, ok := debug.ReadBuildInfo()
+	if ok && ClientVersion == "" && strings.HasPrefix(info.Main.Version, "v") {
+		ClientVersion = info.Main.Version
+	}
+	if ClientVersion == "" {
+		ClientVersion = devVersion
+	}
+	if !ok {
+		return
+	}
+	var modified bool
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if Commit == "" {
+				Commit = s.Value
+			}
+		case "vcs.time":
+			if BuildDate == "" {
+				BuildDate = s.Value
+			}
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if modified && Commit != "" && !strings.HasSuffix(Commit, "-dirty") {
+		Commit += "-dirty"
+	}
+}
+
+// shortCommit is Commit cut to the usual twelve characters.
+func shortCommit() string {
+	rev, dirty := strings.CutSuffix(Commit, "-dirty")
+	if len(rev) > 12 {
+		rev = rev[:12]
+	}
+	if dirty {
+		rev += "-dirty"
+	}
+	return rev
+}
+
+// buildLine is the version with its commit and build date, for /info.
+func buildLine() string {
+	line := views.Escape(ClientVersion)
+	if Commit != "" {
+		line += "  [dim]│[-]  commit " + views.Escape(shortCommit())
+	}
+	if BuildDate != "" {
+		line += "  [dim]│[-]  built " + views.Escape(BuildDate)
+	}
+	return line
+}
+
+// releaseKeyLine is what /info says about the key updates are verified
+// with.
+func releaseKeyLine() string {
+	if ReleaseKey == "" {
+		return "[dim]no release key in this build — /update is off[-]"
+	}
+	return "signed, key " + crypto.Fingerprint(ReleaseKey)
+}
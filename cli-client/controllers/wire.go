@@ -0,0 +1,451 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"cli-client/views"
+)
+
+// ── Wire parsing ──────────────────────────────────────────────────────────────
+//
+// Everything a relay or LAN peer sends passes through here before it can
+// reach the UI. A whole body is rejected if it is too large, nested deeper
+// than the message schema ever is, not valid UTF-8, or not JSON. Within a
+// good body a single entry is dropped if a field is not a string, is longer
+// than its limit, or is required and empty, or if a one-line field carries
+// control characters. Every drop is counted by reason and shown in
+// /netstat. Message content is never dropped for control characters: they
+// are stripped instead — ANSI escape sequences whole, so none of one
+// reaches the screen — and counted as neutralized.
+
+const (
+	maxPollBody    = 4 << 20 // one /api/poll response
+	maxPollEntries = 500     // the relay sends at most 50 per poll
+	maxWireDepth   = 3       // array → object → one stray nested value, at most
+
+	maxUsernameLen  = 64
+	maxContentLen   = 64 << 10
+	maxIDLen        = 128
+	maxColorLen     = 32
+	maxTypeLen      = 32
+	maxTimestampLen = 64
+)
+
+// Reasons a message is dropped, in /netstat order.
+const (
+	dropOversized = "oversized"
+	dropNested    = "nested too deep"
+	dropEncoding  = "invalid UTF-8"
+	dropSyntax    = "invalid JSON"
+	dropFieldType = "non-string field"
+	dropFieldLen  = "field too long"
+	dropControl   = "control characters"
+	dropMissing   = "missing field"
+)
+
+var dropReasons = []string{
+	dropOversized, dropNested, dropEncoding, dropSyntax,
+	dropFieldType, dropFieldLen, dropControl, dropMissing,
+}
+
+// dropCounter counts malformed input by reason, and the control sequences
+// stripped from content. The zero value is ready to use; safe to call from
+// any goroutine.
+type dropCounter struct {
+	mu          sync.Mutex
+	n           map[string]int
+	neutralized int
+}
+
+func (d *dropCounter) add(reason string) {
+	d.mu.Lock()
+	if d.n == nil {
+		d.n = make(map[string]int)
+	}
+	d.n[reason]++
+	d.mu.Unlock()
+	log.Printf("dropped malformed message: %s", reason)
+}
+
+// strip counts n control sequences stripped from content.
+func (d *dropCounter) strip(n int) {
+	if n == 0 {
+		return
+	}
+	d.mu.Lock()
+	d.neutralized += n
+	d.mu.Unlock()
+	log.Printf("neutralized %d control sequence(s) in content", n)
+}
+
+// Neutralized returns how many control sequences were stripped from
+// content so far.
+func (d *dropCounter) Neutralized() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.neutralized
+}
+
+// Snapshot returns the counts so far; reasons never seen are absent.
+func (d *dropCounter) Snapshot() map[string]int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make(map[string]int, len(d.n))
+	for k, v := range d.n {
+		out[k] = v
+	}
+	return out
+}
+
+// checkWire rejects data that is not valid UTF-8 or nests deeper than
+// maxDepth, before the JSON decoder allocates anything for it. Returns the
+// drop reason, or "" if data may be decoded.
+func checkWire(data []byte, maxDepth int) string {
+	if !utf8.Valid(data) {
+		return dropEncoding
+	}
+	depth, inString, escaped := 0, false, false
+	for _, b := range data {
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+		case b == '"':
+			inString = true
+		case b == '[' || b == '{':
+			if depth++; depth > maxDepth {
+				return dropNested
+			}
+		case b == ']' || b == '}':
+			depth--
+		}
+	}
+	return ""
+}
+
+// legacyPollKeys are the non-username keys of the v1 format.
+var legacyPollKeys = map[string]bool{
+	"color":     true,
+	"id":        true,
+	"timestamp": true,
+}
+
+// parsePollMessages parses the raw JSON array from /api/poll, counting every
+// entry it drops in drops.
+// Logs every step so the last line before a crash identifies the bad message.
+func parsePollMessages(data []byte, drops *dropCounter) ([]*pollMessage, error) {
+	log.Printf("TRACE parsePollMessages: raw body (%d bytes): %.500s", len(data), data)
+
+	if len(data) > maxPollBody {
+		drops.add(dropOversized)
+		return nil, fmt.Errorf("poll body over %d bytes", maxPollBody)
+	}
+	if reason := checkWire(data, maxWireDepth); reason != "" {
+		drops.add(reason)
+		return nil, fmt.Errorf("parse poll array: %s", reason)
+	}
+
+	var rawList []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &rawList); err != nil {
+		log.Printf("TRACE parsePollMessages: unmarshal error: %v", err)
+		drops.add(dropSyntax)
+		return nil, fmt.Errorf("parse poll array: %w", err)
+	}
+	log.Printf("TRACE parsePollMessages: parsed %d entries", len(rawList))
+	if len(rawList) > maxPollEntries {
+		for range rawList[maxPollEntries:] {
+			drops.add(dropOversized)
+		}
+		rawList = rawList[:maxPollEntries]
+	}
+
+	msgs := make([]*pollMessage, 0, len(rawList))
+	for i, raw := range rawList {
+		log.Printf("TRACE parsePollMessages: entry[%d] keys=%v", i, mapKeys(raw))
+		msg, reason := parsePollEntry(raw, drops)
+		if reason == "" && (msg.Username == "" || (msg.Content == "" && msg.Length == 0) || msg.ID == "") {
+			reason = dropMissing
+		}
+		if reason != "" {
+			log.Printf("TRACE parsePollMessages: entry[%d] SKIPPED (%s)", i, reason)
+			drops.add(reason)
+			continue
+		}
+
+		log.Printf("TRACE parsePollMessages: entry[%d] id=%q type=%q user=%q color=%q content=%.80q",
+			i, msg.ID, msg.Type, msg.Username, msg.Color, msg.Content)
+		msgs = append(msgs, msg)
+	}
+	log.Printf("TRACE parsePollMessages: returning %d valid messages", len(msgs))
+	return msgs, nil
+}
+
+// parseWireMessage parses a single message object, as carried by one SSE
+// event or one LAN line, counting what it strips from the content in
+// drops. Returns the drop reason if it must not be shown.
+func parseWireMessage(data []byte, drops *dropCounter) (*pollMessage, string) {
+	if len(data) > maxContentLen+4096 {
+		return nil, dropOversized
+	}
+	if reason := checkWire(data, maxWireDepth-1); reason != "" {
+		return nil, reason
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, dropSyntax
+	}
+	msg, reason := parsePollEntry(raw, drops)
+	msg.Length = 0 // headers only come from lazy polls
+	return msg, reason
+}
+
+// parsePollEntry decodes one poll entry in either the v2 or the legacy
+// format and checks every field. A non-empty reason means msg must be
+// dropped; msg.Type defaults to chat. Control sequences stripped from the
+// content are counted in drops.
+func parsePollEntry(raw map[string]json.RawMessage, drops *dropCounter) (msg *pollMessage, reason string) {
+	msg = &pollMessage{}
+
+	field := func(key string, dst *string, max int) {
+		v, ok := raw[key]
+		if !ok || reason != "" {
+			return
+		}
+		if key == "content" {
+			reason = decodeContent(v, dst, drops)
+			return
+		}
+		reason = decodeField(v, dst, max)
+	}
+	field("color", &msg.Color, maxColorLen)
+	field("id", &msg.ID, maxIDLen)
+	if v, ok := raw["timestamp"]; ok && reason == "" {
+		var ts string
+		if reason = decodeField(v, &ts, maxTimestampLen); reason == "" {
+			json.Unmarshal(v, &msg.Timestamp) // an odd format just loses the time
+		}
+	}
+
+	_, hasUser := raw["username"]
+	_, hasContent := raw["content"]
+	if hasUser && hasContent {
+		field("username", &msg.Username, maxUsernameLen)
+		field("content", &msg.Content, maxContentLen)
+		field("type", &msg.Type, maxTypeLen)
+		if v, ok := raw["length"]; ok && reason == "" {
+			// A header: the body is fetched later, and only a chat body.
+			if json.Unmarshal(v, &msg.Length) != nil {
+				reason = dropFieldType
+			} else if msg.Length < 0 || msg.Length > maxContentLen {
+				reason = dropFieldLen
+			} else if msg.Content != "" || (msg.Type != "" && msg.Type != msgTypeChat) {
+				msg.Length = 0 // not a header; an empty body is then missing
+			}
+		}
+	} else {
+		// Legacy: the one key that is not a known field is the username.
+		for key, val := range raw {
+			if legacyPollKeys[key] {
+				continue
+			}
+			if reason == "" {
+				reason = checkText(key, maxUsernameLen)
+			}
+			msg.Username = key
+			if reason == "" {
+				reason = decodeContent(val, &msg.Content, drops)
+			}
+			break
+		}
+	}
+
+	if msg.Type == "" {
+		msg.Type = msgTypeChat
+	}
+	return msg, reason
+}
+
+// decodeField decodes a JSON string into dst and checks it with checkText.
+func decodeField(v json.RawMessage, dst *string, max int) string {
+	if err := json.Unmarshal(v, dst); err != nil {
+		return dropFieldType
+	}
+	return checkText(*dst, max)
+}
+
+// decodeContent decodes message content into dst and cleans it with
+// cleanContent.
+func decodeContent(v json.RawMessage, dst *string, drops *dropCounter) string {
+	if err := json.Unmarshal(v, dst); err != nil {
+		return dropFieldType
+	}
+	return cleanContent(dst, drops)
+}
+
+// checkText enforces a one-line field's length limit and rejects control
+// characters.
+func checkText(s string, max int) string {
+	if len(s) > max {
+		return dropFieldLen
+	}
+	for _, r := range s {
+		if isControl(r) {
+			return dropControl
+		}
+	}
+	return ""
+}
+
+// cleanContent enforces the content length limit on *s and strips its
+// control sequences, counting them in drops.
+func cleanContent(s *string, drops *dropCounter) string {
+	if len(*s) > maxContentLen {
+		return dropFieldLen
+	}
+	var n int
+	*s, n = neutralize(*s)
+	drops.strip(n)
+	return ""
+}
+
+// isControl reports whether r is a C0 or C1 control character or DEL.
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0)
+}
+
+// neutralize strips what a terminal would act on from s, keeping newlines
+// and tabs: ANSI escape sequences whole, and any other control character.
+// It returns the cleaned text and how many sequences and characters it
+// took out.
+func neutralize(s string) (string, int) {
+	if strings.IndexFunc(s, func(r rune) bool { return isControl(r) && r != '\n' && r != '\t' }) < 0 {
+		return s, 0
+	}
+	rs := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	n := 0
+	for i := 0; i < len(rs); i++ {
+		if r := rs[i]; !isControl(r) || r == '\n' || r == '\t' {
+			b.WriteRune(r)
+			continue
+		}
+		i = sequenceEnd(rs, i)
+		n++
+	}
+	return b.String(), n
+}
+
+// sequenceEnd returns the index of the last rune of the control sequence
+// that starts at rs[i]: CSI up to its final byte, OSC, DCS, SOS, PM and APC
+// up to BEL or ST, in their 7-bit ESC forms too, and ESC with its
+// intermediates and final character. Anything else is one rune long.
+func sequenceEnd(rs []rune, i int) int {
+	r := rs[i]
+	if r == 0x1b {
+		if i+1 == len(rs) {
+			return i
+		}
+		i++
+		if rs[i] < 0x40 || rs[i] > 0x5f {
+			for i+1 < len(rs) && rs[i] >= 0x20 && rs[i] <= 0x2f {
+				i++ // intermediates, then the final character
+			}
+			return i
+		}
+		r = rs[i] + 0x40 // ESC [ is CSI, ESC ] is OSC, and so on
+	}
+	switch r {
+	case 0x9b: // CSI: parameters and intermediates, then a final byte
+		for i+1 < len(rs) && rs[i+1] >= 0x20 && rs[i+1] <= 0x3f {
+			i++
+		}
+		if i+1 < len(rs) && rs[i+1] >= 0x40 && rs[i+1] <= 0x7e {
+			i++
+		}
+	case 0x9d, 0x90, 0x98, 0x9e, 0x9f: // a string, up to BEL or ST
+		for i+1 < len(rs) {
+			i++
+			switch {
+			case rs[i] == 0x07 || rs[i] == 0x9c:
+				return i
+			case rs[i] == 0x1b && i+1 < len(rs) && rs[i+1] == '\\':
+				return i + 1
+			}
+		}
+	}
+	return i
+}
+
+func mapKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// ── AppController glue ────────────────────────────────────────────────────────
+
+// netstatLines renders the /netstat panel.
+// Must be called from the tview event loop.
+func (ac *AppController) netstatLines() []string {
+	var link, transport, lastID string
+	beat := "--"
+	var drops map[string]int
+	var neutralized int
+	switch {
+	case ac.lan != nil:
+		link = fmt.Sprintf("LAN  ·  %d peers", len(ac.lan.Peers()))
+		transport, lastID = "tcp", "--"
+		drops, neutralized = ac.lan.Drops(), ac.lan.Neutralized()
+	case ac.netClient != nil:
+		link = views.Escape(ac.netClient.ServerURL())
+		transport, lastID = ac.netClient.Transport(), ac.netClient.LastID()
+		drops, neutralized = ac.netClient.Drops(), ac.netClient.Neutralized()
+		beat = heartbeatText(ac.netClient)
+	default:
+		return []string{"Not connected."}
+	}
+	if transport == "" {
+		transport = "--"
+	}
+	if lastID == "" {
+		lastID = "--"
+	}
+
+	total := 0
+	for _, n := range drops {
+		total += n
+	}
+	lines := []string{
+		"[dim]┌─ Network ───────────────────────────────────────┐[-]",
+		"  [cyan]Link         [-]" + link,
+		"  [cyan]Transport    [-]" + transport,
+		"  [cyan]Last ID      [-]" + views.Escape(lastID),
+		"  [cyan]Heartbeat    [-]" + beat,
+		fmt.Sprintf("  [cyan]Dropped      [-]%d malformed", total),
+		fmt.Sprintf("  [cyan]Neutralized  [-]%d control sequences", neutralized),
+	}
+	if ac.quality.assessed {
+		lines = append(lines, fmt.Sprintf("  [cyan]Quality      [-]%s  [dim]%d failed reconnects, %d failed sends in %v; %d held[-]",
+			ac.quality.grade, len(ac.quality.linkFails), len(ac.quality.sendFails), qualityWindow, len(ac.quality.held)))
+	}
+	for _, r := range dropReasons {
+		if n := drops[r]; n > 0 {
+			lines = append(lines, fmt.Sprintf("    [dim]%-20s[-]%d", r, n))
+		}
+	}
+	return append(lines, "[dim]└─────────────────────────────────────────────────┘[-]")
+}
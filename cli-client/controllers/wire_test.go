@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNeutralize(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+		n        int
+	}{
+		{"plain\ttext\nok", "plain\ttext\nok", 0},
+		{"\x1b[31mred\x1b[0m", "red", 2},
+		{"\x1b[?1049h\x1b[2Jgone", "gone", 2},
+		{"\x1b]0;pwned\x07title", "title", 1},
+		{"\x1b]52;c;Zm9v\x1b\\clipboard", "clipboard", 1},
+		{"\x1bPq#0;2;0;0;0\x1b\\sixel", "sixel", 1},
+		{"\x1b(Bcharset\x1bcreset", "charsetreset", 2},
+		{"\u009b2Jc1 \u009d0;x\u009cdone", "c1 done", 2},
+		{"a\rb\x00c\x7f", "abc", 3},
+		{"cut\x1b[12", "cut", 1},
+		{"end\x1b", "end", 1},
+	} {
+		got, n := neutralize(tc.in)
+		if got != tc.want || n != tc.n {
+			t.Errorf("neutralize(%q) = %q, %d; want %q, %d", tc.in, got, n, tc.want, tc.n)
+		}
+	}
+}
+
+func TestParseWireMessageNeutralizes(t *testing.T) {
+	var drops dropCounter
+	msg, reason := parseWireMessage([]byte(`{"id":"1","username":"eve","content":"\u001b[2Jhi"}`), &drops)
+	if reason != "" || msg.Content != "hi" {
+		t.Fatalf("parsed %q, reason %q", msg.Content, reason)
+	}
+	if drops.Neutralized() != 1 || len(drops.Snapshot()) != 0 {
+		t.Errorf("neutralized %d, drops %v", drops.Neutralized(), drops.Snapshot())
+	}
+	if _, reason = parseWireMessage([]byte(`{"id":"2","username":"e\u001bve","content":"hi"}`), &drops); reason != dropControl {
+		t.Errorf("control characters in a username: reason %q, want %q", reason, dropControl)
+	}
+}
+
+// malformed holds one message object per drop reason. Each is as bad
+// wrapped in a poll array as it is on its own.
+var malformed = []struct {
+	reason, entry string
+}{
+	{dropOversized, "{" + strings.Repeat(" ", maxPollBody) + "}"},
+	{dropNested, `{"id":"1","username":"eve","content":{"x":[1]}}`},
+	{dropEncoding, "{\"id\":\"1\",\"username\":\"eve\",\"content\":\"\xff\"}"},
+	{dropSyntax, `{"id":"1","username":`},
+	{dropFieldType, `{"id":1,"username":"eve","content":"hi"}`},
+	{dropFieldLen, `{"id":"1","username":"` + strings.Repeat("e", maxUsernameLen+1) + `","content":"hi"}`},
+	{dropControl, `{"id":"1","username":"e\u0007ve","content":"hi"}`},
+	{dropMissing, `{"id":"1","username":"eve","content":""}`},
+}
+
+func TestParsePollMessagesDrops(t *testing.T) {
+	for _, tc := range malformed {
+		var drops dropCounter
+		msgs, _ := parsePollMessages([]byte("["+tc.entry+"]"), &drops)
+		if got := drops.Snapshot(); len(msgs) != 0 || len(got) != 1 || got[tc.reason] != 1 {
+			t.Errorf("%s: %d messages, drops %v", tc.reason, len(msgs), got)
+		}
+	}
+}
+
+func TestParseWireMessageDrops(t *testing.T) {
+	for _, tc := range malformed {
+		if tc.reason == dropMissing {
+			continue // the caller knows which fields it needs
+		}
+		var drops dropCounter
+		if _, reason := parseWireMessage([]byte(tc.entry), &drops); reason != tc.reason {
+			t.Errorf("%s: reason %q", tc.reason, reason)
+		}
+	}
+}
+
+func FuzzParsePollMessages(f *testing.F) {
+	f.Add([]byte(`[{"id":"1","username":"bob","content":"hi","color":"cyan","type":"chat"}]`))
+	f.Add([]byte(`[{"id":"2","username":"bob","content":"","length":900},{"bob":"legacy","id":"3"}]`))
+	f.Add([]byte(`[{"id":"4","username":"bob","content":"\u001b[2Jhi\u009b1m"}]`))
+	for _, tc := range malformed[1:] {
+		f.Add([]byte("[" + tc.entry + "]"))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var drops dropCounter
+		msgs, err := parsePollMessages(data, &drops)
+		dropped := total(drops.Snapshot())
+		if err != nil {
+			if len(msgs) != 0 || dropped != 1 {
+				t.Fatalf("rejected (%v) with %d messages and %d drops", err, len(msgs), dropped)
+			}
+			return
+		}
+		if !utf8.Valid(data) || depth(data) > maxWireDepth {
+			t.Fatalf("accepted a body that is not UTF-8 or nests %d deep", depth(data))
+		}
+		var entries []json.RawMessage
+		json.Unmarshal(data, &entries)
+		if len(msgs)+dropped != len(entries) {
+			t.Fatalf("%d entries: %d kept, %d dropped", len(entries), len(msgs), dropped)
+		}
+		for _, msg := range msgs {
+			checkParsed(t, msg)
+		}
+	})
+}
+
+func FuzzParseWireMessage(f *testing.F) {
+	f.Add([]byte(`{"id":"1","username":"bob","content":"hi","color":"cyan","type":"chat"}`))
+	f.Add([]byte(`{"bob":"legacy","timestamp":"2026-10-16T12:00:00Z"}`))
+	f.Add([]byte(`{"id":"4","username":"bob","content":"\u001b]0;x\u0007hi"}`))
+	for _, tc := range malformed[1:] {
+		f.Add([]byte(tc.entry))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var drops dropCounter
+		msg, reason := parseWireMessage(data, &drops)
+		if reason != "" {
+			if !slices.Contains(dropReasons, reason) {
+				t.Fatalf("unknown drop reason %q", reason)
+			}
+			return
+		}
+		if !utf8.Valid(data) || depth(data) > maxWireDepth-1 {
+			t.Fatalf("accepted a message that is not UTF-8 or nests %d deep", depth(data))
+		}
+		if msg.Length != 0 {
+			t.Fatalf("a header from a single message: length %d", msg.Length)
+		}
+		checkParsed(t, msg)
+	})
+}
+
+// checkParsed fails t unless every field of msg keeps to its limits.
+func checkParsed(t *testing.T, msg *pollMessage) {
+	t.Helper()
+	for _, f := range []struct {
+		name, s string
+		max     int
+	}{
+		{"username", msg.Username, maxUsernameLen},
+		{"color", msg.Color, maxColorLen},
+		{"id", msg.ID, maxIDLen},
+		{"type", msg.Type, maxTypeLen},
+	} {
+		if len(f.s) > f.max || !utf8.ValidString(f.s) || strings.IndexFunc(f.s, isControl) >= 0 {
+			t.Fatalf("%s %q passed", f.name, f.s)
+		}
+	}
+	if len(msg.Content) > maxContentLen || !utf8.ValidString(msg.Content) {
+		t.Fatalf("content of %d bytes passed", len(msg.Content))
+	}
+	if strings.IndexFunc(msg.Content, func(r rune) bool { return isControl(r) && r != '\n' && r != '\t' }) >= 0 {
+		t.Fatalf("content %q kept control characters", msg.Content)
+	}
+	if msg.Length < 0 || msg.Length > maxContentLen {
+		t.Fatalf("length %d passed", msg.Length)
+	}
+}
+
+// depth is how deep JSON data nests, read with the standard decoder; -1
+// if it is not JSON.
+func depth(data []byte) int {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	n, deepest := 0, 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return deepest
+		}
+		if err != nil {
+			return -1
+		}
+		switch tok {
+		case json.Delim('['), json.Delim('{'):
+			n++
+			deepest = max(deepest, n)
+		case json.Delim(']'), json.Delim('}'):
+			n--
+		}
+	}
+}
+
+func total(counts map[string]int) int {
+	n := 0
+	for _, v := range counts {
+		n += v
+	}
+	return n
+}
@@ -13,6 +13,7 @@ package crypto
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
@@ -39,6 +40,34 @@ func NewGlobalCrypto() *GlobalCrypto {
 	return &GlobalCrypto{key: globalKey}
 }
 
+// NewPassphraseCrypto returns a GlobalCrypto keyed from a user passphrase
+// instead of the shared one — for local files such as exported profiles.
+// The same passphrase, salt and iterations always give the same key.
+func NewPassphraseCrypto(passphrase string, salt []byte, iterations int) *GlobalCrypto {
+	return &GlobalCrypto{key: pbkdf2SHA256([]byte(passphrase), salt, iterations)}
+}
+
+// pbkdf2SHA256 is PBKDF2 (RFC 8018) with HMAC-SHA-256, producing one
+// 32-byte block — all AES-256 needs.
+func pbkdf2SHA256(password, salt []byte, iterations int) [32]byte {
+	prf := hmac.New(sha256.New, password)
+	prf.Write(salt)
+	prf.Write([]byte{0, 0, 0, 1}) // block index 1
+	u := prf.Sum(nil)
+
+	var key [32]byte
+	copy(key[:], u)
+	for i := 1; i < iterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
+
 // Encrypt encrypts plaintext with AES-256-GCM and returns a Base64 string.
 // A fresh random 12-byte nonce is prepended to each ciphertext, so the same
 // plaintext produces different output on every call.
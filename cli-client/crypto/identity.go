@@ -0,0 +1,87 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// ── Identity keys ─────────────────────────────────────────────────────────────
+//
+// Each client has an Ed25519 key pair of its own. It signs a claim that the
+// public key belongs to a username; peers pin the first key they see for a
+// name and compare fingerprints out of band to verify it. Unlike the shared
+// key above, nothing is encrypted with it.
+
+// identityClaim is what an identity signature covers.
+const identityClaim = "ttc-identity-v1\n"
+
+// Identity is this client's key pair.
+type Identity struct {
+	priv ed25519.PrivateKey
+}
+
+// NewIdentity generates a fresh key pair.
+func NewIdentity() (*Identity, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{priv: priv}, nil
+}
+
+// ParseIdentity reads a key pair saved by Marshal.
+func ParseIdentity(data []byte) (*Identity, error) {
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.New("not an identity key")
+	}
+	return &Identity{priv: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+// Marshal returns the private key in the form ParseIdentity reads. Keep it
+// secret.
+func (id *Identity) Marshal() []byte {
+	return []byte(base64.StdEncoding.EncodeToString(id.priv.Seed()) + "\n")
+}
+
+// PublicKey returns the public key, Base64-encoded, as sent to peers.
+func (id *Identity) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(id.priv.Public().(ed25519.PublicKey))
+}
+
+// Sign returns a signature claiming the public key for username.
+func (id *Identity) Sign(username string) string {
+	msg := identityClaim + username + "\n" + id.PublicKey()
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(id.priv, []byte(msg)))
+}
+
+// VerifyIdentity reports whether sig is a valid claim by the public key pub
+// (Base64, from PublicKey) for username.
+func VerifyIdentity(pub, username, sig string) bool {
+	key, err := base64.StdEncoding.DecodeString(pub)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return false
+	}
+	s, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(key, []byte(identityClaim+username+"\n"+pub), s)
+}
+
+// Fingerprint returns a short form of the public key pub for people to
+// compare: eight groups of four hex digits from its SHA-256.
+func Fingerprint(pub string) string {
+	sum := sha256.Sum256([]byte(pub))
+	h := hex.EncodeToString(sum[:16])
+	groups := make([]string, 0, 8)
+	for i := 0; i < len(h); i += 4 {
+		groups = append(groups, h[i:i+4])
+	}
+	return strings.Join(groups, " ")
+}
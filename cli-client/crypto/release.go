@@ -0,0 +1,36 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+)
+
+// ── Release signatures ────────────────────────────────────────────────────────
+//
+// Whoever publishes releases keeps an Ed25519 key pair — the same format as
+// an Identity — and signs each release's checksums.txt with it. Builds carry
+// the public key, so /update can tell a published checksum file from one
+// swapped in alongside a tampered binary.
+
+// releaseClaim keeps a release signature from passing as anything else.
+const releaseClaim = "ttc-release-v1\n"
+
+// SignRelease returns a signature over a release's checksum file.
+func (id *Identity) SignRelease(checksums []byte) string {
+	msg := append([]byte(releaseClaim), checksums...)
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(id.priv, msg))
+}
+
+// VerifyRelease reports whether sig is a valid signature by the public key
+// pub (Base64, from PublicKey) over checksums.
+func VerifyRelease(pub string, checksums []byte, sig string) bool {
+	key, err := base64.StdEncoding.DecodeString(pub)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return false
+	}
+	s, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(key, append([]byte(releaseClaim), checksums...), s)
+}
@@ -0,0 +1,300 @@
+// Package relaytest runs a fake relay for tests of the client's network
+// layer. It speaks enough of the relay protocol for NetworkClient to talk to
+// it — /health, /api/send, /api/poll (format 2) and /api/stats — and lets a
+// test script how it misbehaves: slow answers, refused keys, malformed
+// bodies and bursts of messages.
+//
+// Endpoints it does not serve (/api/stream, /api/handshake, …) answer 404,
+// which the client treats as an older relay.
+package relaytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AccessKey is the access key the client is built with. Requests with any
+// other key get 401, as from the real relay.
+const AccessKey = "secure_chat_key_2024"
+
+// Endpoints that can be given faults with Relay.Fail.
+const (
+	Health = "/health"
+	Send   = "/api/send"
+	Poll   = "/api/poll"
+	Stats  = "/api/stats"
+)
+
+// pollBatch is the most messages one poll returns, as on the real relay.
+const pollBatch = 50
+
+// Message is one message held by the relay, in its poll (format 2) shape.
+type Message struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	Content   string    `json:"content"`
+	Color     string    `json:"color"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Fault is how the relay answers one request instead of normally.
+type Fault struct {
+	Delay  time.Duration // wait this long first; then answer normally unless Status or Body is set
+	Status int           // answer with this status code
+	Body   string        // answer with this raw body (status 200 unless Status is set)
+}
+
+// Relay is a running fake relay. Its methods are safe for concurrent use.
+type Relay struct {
+	URL string // base URL, e.g. http://127.0.0.1:41234
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	msgs     []Message
+	sent     []Message // what clients sent through /api/send
+	nextID   int
+	faults   map[string][]Fault
+	requests map[string]int
+	hold     time.Duration
+	arrived  chan struct{} // closed and replaced when a message is added
+}
+
+// New starts a relay. Call Close when done.
+func New() *Relay {
+	r := &Relay{
+		faults:   make(map[string][]Fault),
+		requests: make(map[string]int),
+		hold:     100 * time.Millisecond,
+		arrived:  make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(Health, r.handle(Health, r.health))
+	mux.HandleFunc(Send, r.handle(Send, r.send))
+	mux.HandleFunc(Poll, r.handle(Poll, r.poll))
+	mux.HandleFunc(Stats, r.handle(Stats, r.stats))
+	r.srv = httptest.NewServer(mux)
+	r.URL = r.srv.URL
+	return r
+}
+
+// Close shuts the relay down, ending any poll it is holding.
+func (r *Relay) Close() {
+	r.srv.CloseClientConnections()
+	r.srv.Close()
+}
+
+// SetHold sets how long a poll with nothing to return waits for a message
+// before answering 204. The real relay holds for 30s; the default here is
+// 100ms so tests stay quick.
+func (r *Relay) SetHold(d time.Duration) {
+	r.mu.Lock()
+	r.hold = d
+	r.mu.Unlock()
+}
+
+// Fail queues faults for endpoint. Each request to it takes the next one;
+// once they are used up it answers normally again.
+func (r *Relay) Fail(endpoint string, faults ...Fault) {
+	r.mu.Lock()
+	r.faults[endpoint] = append(r.faults[endpoint], faults...)
+	r.mu.Unlock()
+}
+
+// Requests returns how many requests endpoint has had.
+func (r *Relay) Requests(endpoint string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests[endpoint]
+}
+
+// Post adds a chat message from another user, as if sent by another client,
+// and returns its ID.
+func (r *Relay) Post(username, content, color string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.add(Message{Username: username, Content: content, Color: color, Type: "chat"})
+}
+
+// Burst posts n numbered messages from username at once and returns their IDs.
+func (r *Relay) Burst(username string, n int) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = r.add(Message{Username: username, Content: fmt.Sprintf("burst %d", i+1), Color: "cyan", Type: "chat"})
+	}
+	return ids
+}
+
+// Sent returns the messages clients sent through /api/send, oldest first.
+func (r *Relay) Sent() []Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Message(nil), r.sent...)
+}
+
+// add stores m and wakes held polls. Must be called with mu held.
+func (r *Relay) add(m Message) string {
+	r.nextID++
+	m.ID = "msg_" + strconv.Itoa(r.nextID)
+	m.Timestamp = time.Now().UTC()
+	r.msgs = append(r.msgs, m)
+	close(r.arrived)
+	r.arrived = make(chan struct{})
+	return m.ID
+}
+
+// after returns up to pollBatch messages following lastID, and a channel
+// closed when the next message arrives. Must be called with mu held.
+func (r *Relay) after(lastID string) ([]Message, <-chan struct{}) {
+	start := 0
+	for i, m := range r.msgs {
+		if m.ID == lastID {
+			start = i + 1
+			break
+		}
+	}
+	out := r.msgs[start:]
+	if len(out) > pollBatch {
+		out = out[:pollBatch]
+	}
+	return append([]Message(nil), out...), r.arrived
+}
+
+// ── Handlers ──────────────────────────────────────────────────────────────────
+
+// handle counts the request, applies the next queued fault and otherwise
+// hands it to h.
+func (r *Relay) handle(endpoint string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		r.requests[endpoint]++
+		var f Fault
+		queued := len(r.faults[endpoint]) > 0
+		if queued {
+			f = r.faults[endpoint][0]
+			r.faults[endpoint] = r.faults[endpoint][1:]
+		}
+		r.mu.Unlock()
+
+		if f.Delay > 0 {
+			select {
+			case <-time.After(f.Delay):
+			case <-req.Context().Done():
+				return
+			}
+		}
+		switch {
+		case f.Body != "":
+			status := f.Status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(f.Body))
+		case f.Status != 0:
+			http.Error(w, http.StatusText(f.Status), f.Status)
+		default:
+			h(w, req)
+		}
+	}
+}
+
+func (r *Relay) health(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+func (r *Relay) send(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		AccessKey string `json:"access_key"`
+		Username  string `json:"username"`
+		Content   string `json:"content"`
+		Color     string `json:"color"`
+		Type      string `json:"type"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if body.AccessKey != AccessKey {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if body.Type == "" {
+		body.Type = "chat"
+	}
+
+	r.mu.Lock()
+	m := Message{Username: body.Username, Content: body.Content, Color: body.Color, Type: body.Type}
+	m.ID = r.add(m)
+	r.sent = append(r.sent, m)
+	r.mu.Unlock()
+
+	writeJSON(w, map[string]string{"status": "ok", "id": m.ID, "time": time.Now().UTC().Format(time.RFC3339)})
+}
+
+func (r *Relay) poll(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	if q.Get("access_key") != AccessKey {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	r.mu.Lock()
+	msgs, arrived := r.after(q.Get("last_id"))
+	hold := r.hold
+	r.mu.Unlock()
+
+	if len(msgs) == 0 {
+		select {
+		case <-arrived:
+			r.mu.Lock()
+			msgs, _ = r.after(q.Get("last_id"))
+			r.mu.Unlock()
+		case <-time.After(hold):
+		case <-req.Context().Done():
+			return
+		}
+	}
+	if len(msgs) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, msgs)
+}
+
+func (r *Relay) stats(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Query().Get("access_key") != AccessKey {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	r.mu.Lock()
+	total := len(r.msgs)
+	r.mu.Unlock()
+	writeJSON(w, map[string]interface{}{
+		"chat_stats": map[string]int{
+			"total_messages":  total,
+			"waiting_clients": 0,
+			"max_waiters":     1000,
+		},
+		"active_clients": 1,
+		"status":         "ok",
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
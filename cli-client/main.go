@@ -1,26 +1,51 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"runtime/debug"
+	"strings"
 	"time"
+	_ "time/tzdata" // IANA zones for time_zone on systems without a zone database
 
 	"cli-client/controllers"
 	"cli-client/models"
+	"cli-client/paths"
+	"cli-client/tasks"
+	"cli-client/ui"
 	"cli-client/views"
 
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
+// logPath is where the session log goes; crash reports include its tail.
+// Set in init, once --portable has been seen.
+var logPath string
+
+// shutdownWait is how long exit waits for background goroutines to stop
+// before logging the ones that did not.
+const shutdownWait = 2 * time.Second
+
 var logFile *os.File
 
 func init() {
+	// --portable keeps everything beside the binary. It has to be known
+	// before the log is opened, so it is taken off os.Args here.
+	if len(os.Args) > 1 && (os.Args[1] == "--portable" || os.Args[1] == "-portable") {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+		if err := paths.SetPortable(); err != nil {
+			fmt.Println("Cannot locate the binary for --portable:", err)
+		}
+	}
+	logPath = paths.State("error.txt")
+
 	var err error
 	// Open with append+create so multiple runs accumulate — easier to correlate
 	// a crash with the session that produced it.
-	logFile, err = os.OpenFile("error.txt",
+	logFile, err = os.OpenFile(logPath,
 		os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		fmt.Println("Failed to open error log file:", err)
@@ -68,73 +93,227 @@ func logError(format string, args ...interface{}) {
 	}
 }
 
-// recoverFromPanic is called via defer in goroutines. It catches software
-// panics (interface conversion, nil dereference that reached user code, etc.)
-// and writes the full stack trace to error.txt before re-returning.
+// reportPanic is the ui package's panic handler. It gets software panics
+// (interface conversion, nil dereference that reached user code, etc.)
+// recovered in queued updates, goroutines and screen hooks, and writes the
+// full stack trace to error.txt.
 // Fatal runtime errors (concurrent map writes, etc.) are NOT caught here —
 // they are captured by the stderr redirect set up in init().
-func recoverFromPanic() {
-	if r := recover(); r != nil {
-		entry := fmt.Sprintf(
-			"[%s] PANIC RECOVERED: %v\n--- stack trace ---\n%s-------------------\n",
-			time.Now().Format("2006-01-02 15:04:05.000"),
-			r,
-			string(debug.Stack()),
-		)
-		if logFile != nil {
-			logFile.WriteString(entry)
-			logFile.Sync()
-		}
+// A diagnostics bundle is left for the post-crash prompt on the next start.
+func reportPanic(name string, r any, stack []byte) {
+	entry := fmt.Sprintf(
+		"[%s] PANIC RECOVERED in %s: %v\n--- stack trace ---\n%s-------------------\n",
+		time.Now().Format("2006-01-02 15:04:05.000"),
+		name,
+		r,
+		string(stack),
+	)
+	if logFile != nil {
+		logFile.WriteString(entry)
+		logFile.Sync()
 	}
+	controllers.RecordCrash(r, stack, logPath)
 }
 
 func main() {
 	defer func() {
 		if r := recover(); r != nil {
+			stack := debug.Stack()
 			entry := fmt.Sprintf(
 				"[%s] FATAL PANIC in main: %v\n--- stack trace ---\n%s-------------------\n",
 				time.Now().Format("2006-01-02 15:04:05.000"),
 				r,
-				string(debug.Stack()),
+				string(stack),
 			)
 			if logFile != nil {
 				logFile.WriteString(entry)
 				logFile.Sync()
+			}
+			controllers.RecordCrash(r, stack, logPath)
+			if logFile != nil {
 				logFile.Close()
 			}
 			os.Exit(1)
 		}
 	}()
 
+	// Files from versions that kept everything in the working directory.
+	controllers.AdoptLegacyFiles()
+
+	// ── Subcommands ───────────────────────────────────────────────────────────
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "daemon":
+			// Headless poller started by /detach — see controllers/detach.go.
+			os.Exit(controllers.RunDaemon(os.Args[2:]))
+		case "doctor":
+			os.Exit(controllers.RunDoctor(os.Args[2:]))
+		case "export-profile":
+			os.Exit(controllers.RunExportProfile(os.Args[2:]))
+		case "import-profile":
+			os.Exit(controllers.RunImportProfile(os.Args[2:]))
+		case "send":
+			// Posts through the running client — see controllers/instance.go.
+			os.Exit(controllers.RunSend(os.Args[2:]))
+		}
+	}
+
+	// --record FILE / --replay FILE — see controllers/tape.go.
+	closeTape, err := startTape(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	// A ttc:// invitation opens its conversation — see controllers/invite.go.
+	invite, err := inviteArg(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	demoMode := hasFlag(os.Args[1:], "demo")
+	// --demo talks to the relay simulated in controllers/demo.go.
+	if demoMode {
+		controllers.DefaultServerURL = controllers.DemoServerURL
+	}
+
+	instance, err := controllers.AcquireInstance()
+	switch {
+	case errors.Is(err, controllers.ErrAlreadyRunning):
+		fmt.Fprintf(os.Stderr, "%v. Use `cli-client send <message>` to post through it.\n", err)
+		os.Exit(1)
+	case err != nil:
+		logError("single-instance lock: %v", err) // run anyway, just without it
+	}
+
 	app := tview.NewApplication()
+	ui.InstallFrameMeter(app)
+	ui.SetPanicHandler(reportPanic)
 	pages := tview.NewPages()
 
 	ctrl := controllers.NewAppController(app)
+	ctrl.App.Config = models.LoadConfig()
+	if relays := ctrl.App.Config.Relays; len(relays) > 0 && !demoMode {
+		controllers.DefaultServerURL = relays[0] // the primary, see controllers/failover.go
+	}
+	if invite != nil && !demoMode {
+		if invite.Relay != "lan" {
+			controllers.DefaultServerURL = invite.Relay
+		}
+		ctrl.SetInvite(*invite)
+	}
+	if err := controllers.SetTorProxy(ctrl.App.Config.TorProxy); err != nil {
+		logError("tor proxy: %v", err)
+	}
+	controllers.SetConnectTimeout(time.Duration(ctrl.App.Config.ConnectTimeout))
+	// The screen is made here rather than by Run so the legacy console
+	// profile can wrap it. Run would fail the same way, and log it.
+	if screen, err := ui.NewScreen(ctrl.App.Config.Terminal); err != nil {
+		logError("screen: %v", err)
+	} else {
+		app.SetScreen(screen)
+	}
+	app.EnablePaste(true) // after SetScreen, which does not apply it
+	if loc, err := models.LoadZone(ctrl.App.Config.TimeZone); err == nil {
+		models.SetDisplayZone(loc)
+	}
+	models.SetLocale(models.ResolveLocale(ctrl.App.Config.Locale, ctrl.App.Config.FirstWeekday))
+	if addr := ctrl.App.Config.PprofAddr; addr != "" {
+		controllers.StartProfiler(addr)
+	}
+	if instance != nil {
+		ctrl.ServeInstance(instance)
+	}
+	ctrl.WatchFrames()
+	ctrl.WatchFocus()
 
 	loadingView := views.NewLoadingView(app)
+	errorView := views.NewErrorView(app)
 	loginView := views.NewLoginView(app, ctrl.OnLoginSubmit)
-	chatView := views.NewChatView(
-		app,
-		ctrl.OnSendMessage,
-		ctrl.OnCommand,
-	)
+	// The chat screen has two panes, side by side after /split (see
+	// views/split.go). Both are set up alike.
+	newChatPane := func(scrollbackFile string) *views.ChatView {
+		chatView := views.NewChatView(
+			app,
+			ctrl.OnSendMessage,
+			ctrl.OnCommand,
+		)
+		chatView.SetFooterFormat(ctrl.App.Config.Footer)
+		chatView.SetLayout(ctrl.App.Config.Layout.MaxWidth, ctrl.App.Config.Layout.HangingIndent)
+		chatView.SetScrollback(ctrl.App.Config.Scrollback, models.NewScrollback(scrollbackFile))
+		chatView.SetHighlights(ctrl.App.Config.Highlights)
+		chatView.SetAliases(ctrl.App.Config.Aliases)
+		chatView.SetCompleter(ctrl.CompleteName)
+		chatView.SetNumbering(controllers.NumberedCommands(), ctrl.NumberedIDs)
+		chatView.SetMessageActions(ctrl.MessageActions())
+		chatView.SetCommandHints(controllers.CommandHints())
+		chatView.SetComposePreview(ctrl.ComposePreview)
+		chatView.SetPreview(ctrl.App.Config.ComposePreview)
+		chatView.SetPasteHandler(func(text string) bool {
+			limit := ctrl.App.Config.PasteLines
+			if limit == 0 || controllers.PasteLines(text) < limit {
+				return false
+			}
+			showPastePrompt(app, pages, ctrl, text, chatView.InputPrimitive())
+			return true
+		})
+		return chatView
+	}
+	chatScreen := views.NewSplitView(app,
+		newChatPane(models.ScrollbackFile), newChatPane(models.SplitScrollbackFile))
 
 	ctrl.RegisterView(models.ScreenLoading, loadingView)
 	ctrl.RegisterView(models.ScreenLogin, loginView)
-	ctrl.RegisterView(models.ScreenChat, chatView)
+	ctrl.RegisterView(models.ScreenChat, chatScreen)
+	ctrl.RegisterView(models.ScreenError, errorView)
 
-	pages.AddPage("loading", loadingView.GetPrimitive(), true, true)
+	// F1 help overlay — drawn over the chat page, which stays visible behind it.
+	closeHelp := func() {
+		pages.HidePage("help")
+		app.SetFocus(chatScreen.Active().InputPrimitive())
+	}
+	helpView := views.NewHelpView(app, controllers.HelpEntries(), closeHelp)
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if ctrl.SM.Current() != models.ScreenChat {
+			return event
+		}
+		if ctrl.HandleKey(event) {
+			return nil
+		}
+		if event.Key() != tcell.KeyF1 {
+			return event
+		}
+		if name, _ := pages.GetFrontPage(); name == "help" {
+			closeHelp()
+			return nil
+		}
+		helpView.Reset()
+		pages.ShowPage("help")
+		app.SetFocus(helpView.InputPrimitive())
+		return nil
+	})
+
+	pages.AddPage("loading", loadingView.Primitive(), true, true)
 	pages.AddPage("login", loginView.Primitive(), true, false)
-	pages.AddPage("chat", chatView.Primitive(), true, false)
+	pages.AddPage("chat", chatScreen.Primitive(), true, false)
+	pages.AddPage("help", helpView.Primitive(), true, false)
+	pages.AddPage("error", errorView.Primitive(), true, false)
 
 	// ── LOADING ───────────────────────────────────────────────────────────────
+	var startLoading func() // progress steps, connectivity check, then login
 	ctrl.SM.OnEnter(models.ScreenLoading, func() {
-		defer recoverFromPanic()
+		defer ui.Recover("enter loading")
 		pages.SwitchToPage("loading")
 
-		go func() {
-			defer recoverFromPanic()
+		// The last run crashed — ask what to do with its report first.
+		if report, ok := controllers.PendingCrash(); ok {
+			showCrashPrompt(app, pages, report, ctrl.App.Config.CrashReportURL, startLoading)
+			return
+		}
+		startLoading()
+	})
 
+	startLoading = func() {
+		ui.SafeGo("loading", func() {
 			steps := []struct {
 				progress int
 				label    string
@@ -155,30 +334,34 @@ func main() {
 				}
 			}
 
+			// A previous /detach left a daemon polling on our behalf — talk to
+			// the same relay it was using.
+			detached, hasDetached := controllers.LoadDetachedSession()
+			if hasDetached {
+				controllers.DefaultServerURL = detached.ServerURL
+			}
+
 			loadingView.SetStatus("Contacting relay server…")
-			connErr := controllers.CheckServerConnectivity(controllers.DefaultServerURL)
+			relays := ctrl.App.Config.Relays
+			if hasDetached || demoMode || (invite != nil && invite.Relay != "lan") {
+				relays = nil
+			}
+			serverURL, connErr := controllers.FirstReachable(controllers.DefaultServerURL, relays)
+			controllers.DefaultServerURL = serverURL
 
 			if connErr != nil {
 				logError("Server connectivity check failed: %v", connErr)
-				app.QueueUpdateDraw(func() {
-					defer recoverFromPanic()
-					loadingView.ShowFatalError(
-						fmt.Sprintf("Server not reachable — %s", controllers.DefaultServerURL),
+				ui.SafeQueueUpdateDraw(app, "server unreachable", func() {
+					ctrl.ShowFatal(
+						"Server not reachable",
+						connErr.Error(),
+						func() {
+							if err := ctrl.SM.Transition(models.ScreenLoading); err != nil {
+								logError("retry: %v", err)
+							}
+						},
 					)
-					loadingView.SetCountdown(4)
 				})
-
-				for i := 3; i >= 0; i-- {
-					time.Sleep(1 * time.Second)
-					remaining := i
-					app.QueueUpdateDraw(func() {
-						defer recoverFromPanic()
-						loadingView.SetCountdown(remaining)
-					})
-				}
-
-				time.Sleep(200 * time.Millisecond)
-				app.Stop()
 				return
 			}
 
@@ -186,52 +369,206 @@ func main() {
 			loadingView.SetStatus("Connected  ✓")
 			time.Sleep(300 * time.Millisecond)
 
-			app.QueueUpdateDraw(func() {
-				defer recoverFromPanic()
-				ctrl.SM.Transition(models.ScreenLogin)
+			if hasDetached {
+				loadingView.SetStatus(fmt.Sprintf("Re-attaching as @%s…", views.Escape(detached.Username)))
+				time.Sleep(300 * time.Millisecond)
+				ui.SafeQueueUpdateDraw(app, "resume detached", func() {
+					ctrl.ResumeDetached(detached)
+				})
+				return
+			}
+
+			ui.SafeQueueUpdateDraw(app, "show login", func() {
+				if err := ctrl.SM.Transition(models.ScreenLogin); err != nil {
+					logError("login: %v", err)
+				}
 			})
-		}()
-	})
+		})
+	}
 
 	// ── LOGIN ─────────────────────────────────────────────────────────────────
 	ctrl.SM.OnEnter(models.ScreenLogin, func() {
-		defer recoverFromPanic()
+		defer ui.Recover("enter login")
 		pages.SwitchToPage("login")
-		loginView.StartUsernamePrompt()
-		app.SetFocus(loginView.Primitive())
 	})
 
 	// ── CHAT ──────────────────────────────────────────────────────────────────
 	ctrl.SM.OnEnter(models.ScreenChat, func() {
-		defer recoverFromPanic()
+		defer ui.Recover("enter chat")
 		pages.SwitchToPage("chat")
-		app.SetFocus(chatView.InputPrimitive())
+	})
+
+	// ── ERROR ─────────────────────────────────────────────────────────────────
+	ctrl.SM.OnEnter(models.ScreenError, func() {
+		defer ui.Recover("enter error")
+		pages.SwitchToPage("error")
 	})
 
 	// ── CHAT EXIT ─────────────────────────────────────────────────────────────
 	ctrl.SM.OnExit(models.ScreenChat, func() {
-		defer recoverFromPanic()
+		defer ui.Recover("exit chat")
 		ctrl.StopBot()
-		if chat, ok := ctrl.Views[models.ScreenChat].(*views.ChatView); ok {
-			chat.Stop()
-		}
+		chatScreen.Stop()
 	})
 
-	go func() {
-		defer recoverFromPanic()
+	ui.SafeGo("start", func() {
 		time.Sleep(100 * time.Millisecond)
-		app.QueueUpdateDraw(func() {
-			defer recoverFromPanic()
-			ctrl.SM.Transition(models.ScreenLoading)
+		ui.SafeQueueUpdateDraw(app, "start", func() {
+			if err := ctrl.SM.Transition(models.ScreenLoading); err != nil {
+				logError("start: %v", err)
+			}
 		})
-	}()
+	})
 
 	if err := app.SetRoot(pages, true).Run(); err != nil {
 		logError("Application error: %v", err)
 	}
+	tasks.Stop() // the event loop is gone; background loops wind down
+	instance.Close()
+
+	ctrl.Shutdown()
+	tasks.Shutdown(shutdownWait)
+	closeTape()
+
+	if s := ctrl.Detached(); s != nil {
+		log.Printf("Detached — daemon pid=%d", s.PID)
+		fmt.Printf("Detached. Background session running as pid %d — run the client again to re-attach.\n", s.PID)
+	}
 
 	log.Printf("Application exited cleanly")
 	if logFile != nil {
 		logFile.Close()
 	}
 }
+
+// startTape handles --record FILE and --replay FILE (or --record=FILE). It
+// returns the function that closes a recording.
+func startTape(args []string) (func(), error) {
+	var record, replay string
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") || (name != "record" && name != "replay") {
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--%s needs a file name", name)
+			}
+			i++
+			value = args[i]
+		}
+		if name == "record" {
+			record = value
+		} else {
+			replay = value
+		}
+	}
+
+	switch {
+	case record != "" && replay != "":
+		return nil, errors.New("--record and --replay cannot be used together")
+	case record != "":
+		closeTape, err := controllers.RecordTape(record)
+		if err != nil {
+			return nil, fmt.Errorf("--record: %w", err)
+		}
+		return closeTape, nil
+	case replay != "":
+		if err := controllers.ReplayTape(replay); err != nil {
+			return nil, fmt.Errorf("--replay: %w", err)
+		}
+	}
+	return func() {}, nil
+}
+
+// hasFlag reports whether the boolean flag -name or --name is in args.
+func hasFlag(args []string, name string) bool {
+	for _, a := range args {
+		if a == "-"+name || a == "--"+name {
+			return true
+		}
+	}
+	return false
+}
+
+// inviteArg returns the ttc:// invitation among args, or nil if there is
+// none.
+func inviteArg(args []string) (*controllers.Invite, error) {
+	for _, a := range args {
+		if !strings.HasPrefix(a, "ttc://") {
+			continue
+		}
+		inv, err := controllers.ParseInvite(a)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", a, err)
+		}
+		return &inv, nil
+	}
+	return nil, nil
+}
+
+// showPastePrompt asks how to send a paste of many lines. Must be called
+// from the tview event loop.
+func showPastePrompt(app *tview.Application, pages *tview.Pages, ctrl *controllers.AppController, text string, input tview.Primitive) {
+	modal := tview.NewModal()
+	modal.SetText(fmt.Sprintf("You pasted %d lines.\n\nSend them as one code block, or as one message per line?",
+		controllers.PasteLines(text)))
+	modal.AddButtons([]string{"Code block", "Split", "Cancel"})
+	modal.SetDoneFunc(func(_ int, label string) {
+		pages.RemovePage("paste")
+		app.SetFocus(input)
+		switch label {
+		case "Code block":
+			ctrl.SendCodeBlock(text)
+		case "Split":
+			ctrl.SendLines(text)
+		}
+	})
+	pages.AddPage("paste", modal, true, true)
+	app.SetFocus(modal)
+}
+
+// showCrashPrompt asks what to do with the report left by the previous run's
+// crash, then calls done to carry on starting up. Must be called from the
+// tview event loop.
+func showCrashPrompt(app *tview.Application, pages *tview.Pages, report *controllers.CrashReport, endpoint string, done func()) {
+	buttons := []string{"Save report", "Discard"}
+	if endpoint != "" {
+		buttons = []string{"Save report", "Send report", "Discard"}
+	}
+	modal := tview.NewModal()
+	modal.SetText(fmt.Sprintf(
+		"The last session crashed (%s).\n\nA diagnostics report was prepared — stack trace, versions, "+
+			"settings and recent log lines, with messages, names and credentials removed.",
+		report.Time.Format("Jan 2 15:04")))
+	modal.AddButtons(buttons)
+	modal.SetDoneFunc(func(_ int, label string) {
+		switch label {
+		case "Save report":
+			name, err := controllers.SaveCrash(report)
+			if err != nil {
+				logError("save crash report: %v", err)
+				break
+			}
+			// Keep the modal up so the path can be read, then carry on.
+			modal.ClearButtons()
+			modal.SetText("Crash report saved to " + name)
+			modal.AddButtons([]string{"OK"})
+			return
+		case "Send report":
+			ui.SafeGo("send crash report", func() {
+				if err := controllers.SendCrash(report, endpoint); err != nil {
+					logError("send crash report: %v", err)
+					return
+				}
+				log.Printf("Crash report sent to %s", endpoint)
+			})
+		case "Discard":
+			controllers.DiscardCrash()
+		}
+		pages.RemovePage("crash")
+		done()
+	})
+	pages.AddPage("crash", modal, true, true)
+	app.SetFocus(modal)
+}
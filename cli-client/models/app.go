@@ -8,8 +8,18 @@ type AppState struct {
 	UserColors  map[string]string // username → tview color tag override e.g. "[#ff00ff]"
 	Latency     int
 	IsConnected bool
+	Session     *SessionStats
+	StatsLog    *StatsHistory // last hour of /api/stats samples for /dashboard
+	Config      *Config
+	Drafts      map[string]string   // conversation key → unsent input
+	History     *History            // chat messages of this session, for /replay
+	Bookmarks   []Bookmark          // oldest first, see BookmarksFile
+	Contacts    map[string]*Contact // username → contact, see ContactsFile
 }
 
+// StatsHistorySize covers one hour of samples at the 8-second stats interval.
+const StatsHistorySize = 3600 / 8
+
 // NewAppState creates a new application state
 func NewAppState() *AppState {
 	return &AppState{
@@ -19,12 +29,39 @@ func NewAppState() *AppState {
 		UserColors:  make(map[string]string),
 		Latency:     18,
 		IsConnected: true,
+		Session:     NewSessionStats(),
+		StatsLog:    NewStatsHistory(StatsHistorySize),
+		Config:      DefaultConfig(),
+		Drafts:      make(map[string]string),
+		History:     NewHistory(HistorySize),
+		Contacts:    make(map[string]*Contact),
 	}
 }
 
-// AddMessage adds a message to the chat
+// AddMessage adds a message to the chat, dropping the oldest beyond the
+// configured scrollback, as the transcript does.
 func (a *AppState) AddMessage(msg *Message) {
 	a.Messages = append(a.Messages, msg)
+	if a.Config == nil || a.Config.Scrollback == 0 {
+		return
+	}
+	for len(a.Messages) > a.Config.Scrollback {
+		a.Messages[0] = nil // the next append that grows the slice lets it go
+		a.Messages = a.Messages[1:]
+	}
+}
+
+// ExpireMessage wipes the content of message id from the history and marks
+// it expired. Returns the message, or nil if it is no longer in the history.
+func (a *AppState) ExpireMessage(id string) *Message {
+	for _, m := range a.Messages {
+		if m.ID == id {
+			m.Content = ""
+			m.Expired = true
+			return m
+		}
+	}
+	return nil
 }
 
 // GetMessages returns all messages
@@ -38,6 +75,20 @@ func (a *AppState) SetCurrentUser(username string) {
 	a.Users[username] = a.CurrentUser
 }
 
+// RenameCurrentUser changes the logged-in user's name, carrying over their
+// color override and user entry. Returns the previous username.
+func (a *AppState) RenameCurrentUser(newName string) string {
+	old := a.CurrentUser.Username
+	a.CurrentUser.Username = newName
+	delete(a.Users, old)
+	a.Users[newName] = a.CurrentUser
+	if tag, ok := a.UserColors[old]; ok {
+		delete(a.UserColors, old)
+		a.UserColors[newName] = tag
+	}
+	return old
+}
+
 // GetUserColorTag returns the tview color tag for a user.
 // Checks the manual override map first; falls back to the hash-based default.
 func (a *AppState) GetUserColorTag(username string) string {
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+
+	"cli-client/paths"
+)
+
+// BookmarksFile keeps bookmarked messages across restarts.
+const BookmarksFile = "ttc_bookmarks.json"
+
+// Bookmark is a message the user marked with /bookmark. The snippet is kept
+// because the message itself only lives in the session history.
+type Bookmark struct {
+	ID       string    `json:"id"`   // Message.ID in the session history
+	Room     string    `json:"room"` // conversation key
+	Username string    `json:"username"`
+	Snippet  string    `json:"snippet"`
+	At       time.Time `json:"at"`    // when the message was said
+	Saved    time.Time `json:"saved"` // when it was bookmarked
+}
+
+// LoadBookmarks reads BookmarksFile into a.Bookmarks. A missing or
+// unreadable file leaves no bookmarks.
+func (a *AppState) LoadBookmarks() {
+	data, err := os.ReadFile(paths.Data(BookmarksFile))
+	if err != nil {
+		return
+	}
+	var bookmarks []Bookmark
+	if err := json.Unmarshal(data, &bookmarks); err != nil {
+		log.Printf("LoadBookmarks: ignoring unreadable %s: %v", BookmarksFile, err)
+		return
+	}
+	a.Bookmarks = bookmarks
+}
+
+// SaveBookmarks writes a.Bookmarks to BookmarksFile, or removes it when empty.
+func (a *AppState) SaveBookmarks() {
+	if len(a.Bookmarks) == 0 {
+		os.Remove(paths.Data(BookmarksFile))
+		return
+	}
+	data, err := json.MarshalIndent(a.Bookmarks, "", "  ")
+	if err == nil {
+		err = os.WriteFile(paths.Data(BookmarksFile), data, 0600)
+	}
+	if err != nil {
+		log.Printf("SaveBookmarks: %v", err)
+	}
+}
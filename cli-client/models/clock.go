@@ -0,0 +1,76 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// clockOffset is how far the relay's clock runs ahead of ours, in
+// nanoseconds, as last measured by the handshake. 0 = no correction.
+var clockOffset atomic.Int64
+
+// SetClockOffset records how far the relay's clock runs ahead of ours.
+// Safe to call from any goroutine.
+func SetClockOffset(d time.Duration) {
+	clockOffset.Store(int64(d))
+}
+
+// ClockOffset returns the offset set by SetClockOffset.
+func ClockOffset() time.Duration {
+	return time.Duration(clockOffset.Load())
+}
+
+// Now returns the current time on the relay's clock, so our own messages and
+// relayed ones share one epoch however far our clock is off. Timestamps are
+// shown in the zone set by SetDisplayZone.
+func Now() time.Time {
+	return time.Now().Add(ClockOffset())
+}
+
+// displayZone is where times are shown; nil = the local time zone.
+var displayZone atomic.Pointer[time.Location]
+
+// SetDisplayZone sets the time zone times are shown in. nil = local time.
+// Safe to call from any goroutine.
+func SetDisplayZone(loc *time.Location) {
+	displayZone.Store(loc)
+}
+
+// InZone returns t in the display time zone.
+func InZone(t time.Time) time.Time {
+	if loc := displayZone.Load(); loc != nil {
+		return t.In(loc)
+	}
+	return t.Local()
+}
+
+// LoadZone resolves a time zone as written in the config or sent by a peer:
+// "" or "local" for local time, "UTC", an IANA name such as "Europe/Berlin",
+// or a fixed offset "UTC+03:30". A nil location means local time.
+func LoadZone(name string) (*time.Location, error) {
+	switch {
+	case name == "" || strings.EqualFold(name, "local"):
+		return nil, nil
+	case strings.EqualFold(name, "utc"):
+		return time.UTC, nil
+	case strings.HasPrefix(name, "UTC+") || strings.HasPrefix(name, "UTC-"):
+		t, err := time.Parse("-07:00", name[3:])
+		if err != nil {
+			return nil, fmt.Errorf("bad offset %q", name)
+		}
+		_, off := t.Zone()
+		return time.FixedZone(name, off), nil
+	}
+	return time.LoadLocation(name)
+}
+
+// ZoneName names the time zone loc for peers, in a form LoadZone accepts.
+// Local time has no portable name, so it is sent as its current offset.
+func ZoneName(loc *time.Location) string {
+	if loc != nil && loc != time.Local {
+		return loc.String()
+	}
+	return "UTC" + time.Now().Format("-07:00")
+}
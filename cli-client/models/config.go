@@ -0,0 +1,705 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"cli-client/paths"
+)
+
+// ConfigFile is the user-editable client configuration, read from the
+// config directory (see package paths) at startup. A missing file means
+// all defaults.
+const ConfigFile = "ttc_config.json"
+
+// Config holds the client settings loaded from ConfigFile. Keys left out of
+// the file keep their DefaultConfig values.
+type Config struct {
+	// Transports lists the receive transports to try, in order: "sse"
+	// (Server-Sent Events on /api/stream) and "poll" (long polling). Later
+	// entries are fallbacks for relays or networks where earlier ones fail.
+	Transports []string   `json:"transports"`
+	Poll       PollConfig `json:"poll"`
+
+	// Heartbeat is how often the relay's /health is checked while
+	// connected. Two missed in a row mark the link lost and restart the
+	// receive request, which may otherwise hang unnoticed until it times
+	// out. 0 turns it off.
+	Heartbeat Duration `json:"heartbeat"`
+
+	// ConnectTimeout bounds opening a connection to the relay, or to the
+	// Tor proxy. How long an answer may take once connected is separate:
+	// Poll.Timeout for the long poll, a few seconds for everything else.
+	ConnectTimeout Duration `json:"connect_timeout"`
+
+	// TorProxy is a SOCKS5 proxy URL ("socks5://127.0.0.1:9050") that all
+	// relay traffic is routed through. Empty = direct, except .onion relays,
+	// which always go through Tor's default port.
+	TorProxy string `json:"tor_proxy"`
+
+	Notify NotifyConfig `json:"notify"`
+
+	// Highlights are words shown in their own color wherever they appear
+	// in others' messages. /highlight edits them.
+	Highlights []HighlightRule `json:"highlights"`
+
+	// Filters rewrite others' messages before they are shown: swear words
+	// masked, links stripped, or user rules applied. A room can turn them
+	// off with "filters": false.
+	Filters FilterConfig `json:"filters"`
+
+	// Aliases maps usernames to the names we see them under. Only the
+	// display changes; mentions, /whois and the relay still use the
+	// username. /contact alias edits them.
+	Aliases map[string]string `json:"aliases"`
+
+	// Ignored are usernames whose messages are not shown. /ignore and
+	// /unignore edit them.
+	Ignored []string `json:"ignored"`
+
+	// Templates maps names to saved snippets, with {placeholders} asked
+	// for when /t uses one. /template edits them.
+	Templates map[string]string `json:"templates"`
+
+	// QuietHours silences the bell and marks us away every day between
+	// two local times. Both empty = off.
+	QuietHours QuietHoursConfig `json:"quiet_hours"`
+
+	// AwayReply answers a mention, once an hour per sender, while /away,
+	// /dnd or quiet hours are on. /away reply edits it.
+	AwayReply bool `json:"away_reply"`
+
+	// KeepRepeats shows every copy of a message sent several times in a
+	// row instead of the first with a ×N count.
+	KeepRepeats bool `json:"keep_repeats"`
+
+	// Footer is the status bar template, e.g. "{server} │ {mode} │ {clock}".
+	// Any other text, tview color tags included, is shown as written.
+	// Empty = the built-in layout.
+	Footer string `json:"footer"`
+
+	// Relays lists relay URLs in priority order, the first being the one
+	// connected to at startup; the client fails over down the list and
+	// back. Empty = the built-in relay only.
+	Relays []string `json:"relays"`
+
+	// Accounts are more identities to be signed in as alongside the one
+	// logged in with, each on its own relay; /account switches between
+	// them. Empty = just the one.
+	Accounts []Account `json:"accounts"`
+
+	// CrashReportURL receives a diagnostics bundle by POST when, after a
+	// crash, the user chooses to send it. Empty = sending is not offered.
+	CrashReportURL string `json:"crash_report_url"`
+
+	// PasteLines is how many lines a paste needs before the client asks how
+	// to send it. 0 = never ask; pastes are joined into one line.
+	PasteLines int `json:"paste_lines"`
+
+	// MaxMessageBytes is the largest message sent in one piece; longer ones
+	// go as numbered parts. The relay's own limit applies if it is lower.
+	// 0 = 4096.
+	MaxMessageBytes int `json:"max_message_bytes"`
+
+	// AdminKey is the relay's admin key (its -admin-key flag), needed to
+	// post /announce. Empty = not an admin.
+	AdminKey string `json:"admin_key"`
+
+	// Notices is where connection changes and background errors are shown:
+	// NoticesBanner (a line above the input that clears itself) or
+	// NoticesTranscript (system messages, as before). "" = NoticesBanner.
+	Notices string `json:"notices"`
+
+	// Transcripts appends every chat message to a file per day under the
+	// data directory: TranscriptsText or TranscriptsJSON (one object per
+	// line). "" = off.
+	Transcripts string `json:"transcripts"`
+
+	// Terminal is how the screen is drawn: TerminalFull (Unicode borders,
+	// every color), TerminalLegacy (ASCII borders, 16 colors, no dim text)
+	// or TerminalAuto, which picks legacy on consoles that need it.
+	// "" = TerminalAuto.
+	Terminal string `json:"terminal"`
+
+	Layout LayoutConfig `json:"layout"`
+
+	// Scrollback is how many transcript lines are kept in memory. Older
+	// ones move to ScrollbackFile and come back with PgUp. 0 = keep all.
+	Scrollback int `json:"scrollback"`
+
+	// TimeZone is where message times and the clock are shown: "local",
+	// "UTC", or an IANA name such as "Europe/Berlin". "" = "local".
+	TimeZone string `json:"time_zone"`
+	// ShareTimeZone tells others our time zone when we join and in /whois
+	// replies, so they can see our local time.
+	ShareTimeZone bool `json:"share_time_zone"`
+	// Locale is the language dates are shown in, e.g. "de" or "en_GB".
+	// "" = from LC_ALL, LC_TIME or LANG. See locale.go.
+	Locale string `json:"locale"`
+	// FirstWeekday is the day weeks start on for the day separators:
+	// "monday", "sunday" and so on. "" = the locale's.
+	FirstWeekday string `json:"first_weekday"`
+
+	Translate TranslateConfig `json:"translate"`
+	Summary   SummaryConfig   `json:"summary"`
+
+	// TerminalTitle names the terminal window (and tmux or screen window)
+	// after the conversation, with a count of messages that arrived while
+	// the terminal was out of focus.
+	TerminalTitle bool `json:"terminal_title"`
+
+	// Startup lists commands run after every login, in order, as if typed:
+	// "/mode static", "/dnd 2h".
+	Startup []string `json:"startup"`
+
+	// ComposePreview opens the preview strip above the input at startup;
+	// F4 toggles it.
+	ComposePreview bool `json:"compose_preview"`
+
+	// UpdateCheck asks GitHub for a newer release when a chat session starts.
+	UpdateCheck bool `json:"update_check"`
+
+	// PprofAddr serves net/http/pprof on this loopback address, e.g.
+	// "localhost:6060", for chasing leaks in long sessions. "" = off.
+	PprofAddr string `json:"pprof_addr"`
+
+	// SlowFrameMS is how long, in milliseconds, an update and the draw after
+	// it may take before the frame is logged as slow. 0 = 50.
+	SlowFrameMS int `json:"slow_frame_ms"`
+	// SlowFrameWarning also shows slow frames in the footer.
+	SlowFrameWarning bool `json:"slow_frame_warning"`
+
+	// Rooms overrides settings per conversation, keyed by relay URL (as
+	// given to /server) or "lan" for LAN mode.
+	Rooms map[string]RoomConfig `json:"rooms"`
+}
+
+// Account is one identity under "accounts".
+type Account struct {
+	Name     string `json:"name"`     // what /account calls it: 1 to 32 of a-z, 0-9, - and _
+	Server   string `json:"server"`   // relay URL
+	Username string `json:"username"` // who to be there
+	Color    string `json:"color"`    // a /user_color name; "" = the username's own
+}
+
+// MainAccount is what /account calls the identity logged in with, so no
+// configured account may use it.
+const MainAccount = "main"
+
+// RoomConfig is one conversation's overrides; zero fields keep the global
+// setting.
+type RoomConfig struct {
+	Animation *bool  `json:"animation,omitempty"` // word-by-word display; nil = as set by /mode
+	Notify    string `json:"notify,omitempty"`    // "all", "mentions" or "none"; "" = the notify rules
+	Accent    string `json:"accent,omitempty"`    // border color: a /user_color name or #rrggbb
+	Filters   *bool  `json:"filters,omitempty"`   // false = show messages unfiltered; nil = as configured
+}
+
+// MinScrollback is the smallest scrollback limit, more than a screenful.
+const MinScrollback = 200
+
+// MaxAliasLength is the longest alias, in characters.
+const MaxAliasLength = 32
+
+// IsTemplateName reports whether name can name a template: 1 to 32 of
+// a-z, 0-9, '-' or '_'.
+func IsTemplateName(name string) bool {
+	return templateNamePattern.MatchString(name)
+}
+
+// Values for Config.Notices.
+const (
+	NoticesBanner     = "banner"
+	NoticesTranscript = "transcript"
+)
+
+// Values for Config.Transcripts.
+const (
+	TranscriptsText = "text"
+	TranscriptsJSON = "json"
+)
+
+// Values for Config.Terminal.
+const (
+	TerminalAuto   = "auto"
+	TerminalFull   = "full"
+	TerminalLegacy = "legacy"
+)
+
+// FooterSegments are the {names} a Footer template may use.
+var FooterSegments = []string{
+	"server", "mode", "clock", "latency", "user", "status", "scroll", // chat view
+	"tor", "dnd", "quiet", "hush", "away", "accounts", "scheduled", "update", "pow", "slow", "reconnect", // controllers, empty when inactive
+}
+
+// NotifyConfig decides which incoming messages ring the terminal bell.
+//
+// A message notifies if it matches any rule: it @mentions us, contains one
+// of Keywords as a whole word, or comes from one of Users. With no rules at
+// all every message notifies. /dnd silences everything regardless, except
+// relay announcements when Announcements is set.
+type NotifyConfig struct {
+	Bell          bool     `json:"bell"`          // false = never ring
+	Mentions      bool     `json:"mentions"`      // "@yourname" anywhere in the text
+	Keywords      []string `json:"keywords"`      // case-insensitive whole words
+	Users         []string `json:"users"`         // senders that always notify
+	Announcements bool     `json:"announcements"` // ring for announcements, even during /dnd
+	WhenFocused   bool     `json:"when_focused"`  // ring while the terminal has focus too
+}
+
+// HighlightRule emphasizes Word, case-insensitive and as a whole word (or
+// words), in incoming messages.
+type HighlightRule struct {
+	Word   string `json:"word"`
+	Color  string `json:"color"`  // a /user_color name or #rrggbb; "" = yellow
+	Notify bool   `json:"notify"` // also ring the bell, like a notify keyword
+}
+
+// FilterConfig rewrites the text of incoming messages. All off by default.
+type FilterConfig struct {
+	Profanity  bool         `json:"profanity"`   // mask a built-in list of swear words
+	StripLinks bool         `json:"strip_links"` // replace URLs with "<link>"
+	Rules      []FilterRule `json:"rules"`       // applied in order, after the two above
+}
+
+// Enabled reports whether any filter is configured.
+func (f FilterConfig) Enabled() bool {
+	return f.Profanity || f.StripLinks || len(f.Rules) > 0
+}
+
+// FilterRule replaces each match of Pattern, a Go regular expression (add
+// "(?i)" to ignore case), with Replace, in which $1 stands for the first
+// group.
+type FilterRule struct {
+	Pattern string `json:"pattern"`
+	Replace string `json:"replace"`
+}
+
+// LayoutConfig shapes the message area.
+type LayoutConfig struct {
+	// MaxWidth caps the message column, centered on wider terminals.
+	// 0 = use the full width.
+	MaxWidth int `json:"max_width"`
+	// HangingIndent starts wrapped lines under the message body rather
+	// than at the left edge.
+	HangingIndent bool `json:"hanging_indent"`
+}
+
+// TranslateConfig points /translate at a LibreTranslate-compatible server.
+// The text of every message translated is sent to it, so it should be one
+// you trust; it is reached through tor_proxy like the relay.
+type TranslateConfig struct {
+	URL    string `json:"url"`     // the /translate endpoint; empty = /translate off
+	Target string `json:"target"`  // language to translate into; "" = "en"
+	APIKey string `json:"api_key"` // sent as api_key if the server needs one
+}
+
+// SummaryConfig points /summary at an OpenAI-compatible chat completions
+// endpoint — a local one such as Ollama or llama.cpp, or a hosted one. The
+// messages summarized are sent to it, so it should be one you trust; it is
+// reached through tor_proxy like the relay.
+type SummaryConfig struct {
+	URL    string `json:"url"`     // the /v1/chat/completions endpoint; empty = /summary off
+	Model  string `json:"model"`   // model name to ask for, e.g. "llama3.2"
+	APIKey string `json:"api_key"` // sent as a bearer token if the server needs one
+	Lines  int    `json:"lines"`   // most bullet lines to show; 0 = 5
+}
+
+// QuietHoursConfig is a daily window in local time, "HH:MM" to "HH:MM".
+// A window that ends before it starts runs over midnight: "23:00" to
+// "08:00" is the night.
+type QuietHoursConfig struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Enabled reports whether a window is configured.
+func (q QuietHoursConfig) Enabled() bool {
+	return q.Start != "" && q.End != ""
+}
+
+// At reports whether now falls inside the window, and when that next
+// changes. It must only be called on a validated config.
+func (q QuietHoursConfig) At(now time.Time) (quiet bool, next time.Time) {
+	start, _ := clockMinutes(q.Start)
+	end, _ := clockMinutes(q.End)
+	m := now.Hour()*60 + now.Minute()
+	if start < end {
+		quiet = m >= start && m < end
+	} else {
+		quiet = m >= start || m < end
+	}
+	boundary := start
+	if quiet {
+		boundary = end
+	}
+	next = time.Date(now.Year(), now.Month(), now.Day(), boundary/60, boundary%60, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return quiet, next
+}
+
+// clockMinutes parses "HH:MM" into minutes since midnight.
+func clockMinutes(s string) (int, bool) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// PollConfig tunes the long-poll loop.
+//
+// After a poll that returns messages the client re-polls immediately. After
+// an empty one it pauses for Interval. With Adaptive set, each further empty
+// poll doubles the pause up to MaxInterval, and any traffic (incoming or our
+// own send) snaps it back to Interval — a quiet room costs far fewer requests.
+type PollConfig struct {
+	Interval    Duration `json:"interval"`
+	MaxInterval Duration `json:"max_interval"`
+	Timeout     Duration `json:"timeout"` // whole long-poll request; must exceed the relay's 30s hold
+	Adaptive    bool     `json:"adaptive"`
+
+	// Headers polls only message headers; the body of a long message is
+	// fetched once its line is on screen. Relays without "lazy-bodies"
+	// send bodies anyway.
+	Headers bool `json:"headers"`
+}
+
+// DefaultConfig returns the built-in settings.
+func DefaultConfig() *Config {
+	return &Config{
+		Transports:     []string{"sse", "poll"},
+		Heartbeat:      Duration(2 * time.Second),
+		ConnectTimeout: Duration(10 * time.Second),
+		Poll: PollConfig{
+			Interval:    Duration(500 * time.Millisecond),
+			MaxInterval: Duration(30 * time.Second),
+			Timeout:     Duration(40 * time.Second),
+		},
+		Notify:     NotifyConfig{Bell: true, Mentions: true, Announcements: true},
+		PasteLines: 5,
+		Layout:     LayoutConfig{HangingIndent: true},
+		Scrollback: 5000,
+	}
+}
+
+// LoadConfig reads ConfigFile over the defaults. A missing file is not an
+// error; an unreadable or invalid one is logged and the defaults are used.
+func LoadConfig() *Config {
+	cfg := DefaultConfig()
+	path := paths.Config(ConfigFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("LoadConfig: %v — using defaults", err)
+		}
+		return cfg
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		log.Printf("LoadConfig: %s: %v — using defaults", path, err)
+		return DefaultConfig()
+	}
+	if err := cfg.validate(); err != nil {
+		log.Printf("LoadConfig: %s: %v — using defaults", path, err)
+		return DefaultConfig()
+	}
+	return cfg
+}
+
+// SaveConfigKey sets the top-level key of ConfigFile to v, for settings a
+// command changes (/highlight). The other keys are kept, though the file is
+// rewritten indented with its keys sorted. A file that does not parse is
+// left alone and reported.
+func SaveConfigKey(key string, v interface{}) error {
+	path := paths.Config(ConfigFile)
+	doc := map[string]json.RawMessage{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if doc == nil {
+		doc = map[string]json.RawMessage{} // the file held null
+	}
+	doc[key] = value
+	data, err = json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+var (
+	footerSegmentPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+	hexColorPattern      = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+	templateNamePattern  = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+)
+
+func (c *Config) validate() error {
+	if len(c.Transports) == 0 {
+		return fmt.Errorf("transports: list at least one of \"sse\", \"poll\"")
+	}
+	for _, t := range c.Transports {
+		if t != "sse" && t != "poll" {
+			return fmt.Errorf("transports: unknown transport %q", t)
+		}
+	}
+
+	if c.Heartbeat < 0 || (c.Heartbeat > 0 && c.Heartbeat < Duration(500*time.Millisecond)) {
+		return fmt.Errorf("heartbeat: must be 0 (off) or at least 500ms")
+	}
+	if c.ConnectTimeout < Duration(time.Second) || c.ConnectTimeout > Duration(2*time.Minute) {
+		return fmt.Errorf("connect_timeout: must be between 1s and 2m")
+	}
+
+	if c.TorProxy != "" {
+		u, err := url.Parse(c.TorProxy)
+		if err != nil || u.Scheme != "socks5" || u.Host == "" {
+			return fmt.Errorf("tor_proxy: must look like socks5://127.0.0.1:9050")
+		}
+	}
+
+	for _, k := range c.Notify.Keywords {
+		if strings.TrimSpace(k) == "" {
+			return fmt.Errorf("notify: keywords must not be empty")
+		}
+	}
+	for _, u := range c.Notify.Users {
+		if strings.TrimSpace(u) == "" {
+			return fmt.Errorf("notify: users must not be empty")
+		}
+	}
+
+	for _, h := range c.Highlights {
+		if strings.TrimSpace(h.Word) == "" {
+			return fmt.Errorf("highlights: word must not be empty")
+		}
+		if h.Color != "" && !IsValidColor(h.Color) {
+			return fmt.Errorf("highlights[%q].color: unknown color %q", h.Word, h.Color)
+		}
+	}
+
+	for i, r := range c.Filters.Rules {
+		if r.Pattern == "" {
+			return fmt.Errorf("filters.rules[%d].pattern: must not be empty", i)
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("filters.rules[%d].pattern: %v", i, err)
+		}
+	}
+
+	for user, alias := range c.Aliases {
+		if strings.TrimSpace(user) == "" || strings.TrimSpace(alias) == "" {
+			return fmt.Errorf("aliases: usernames and aliases must not be empty")
+		}
+		if strings.ContainsAny(alias, "\r\n") || len([]rune(alias)) > MaxAliasLength {
+			return fmt.Errorf("aliases[%q]: must be one line of at most %d characters", user, MaxAliasLength)
+		}
+	}
+
+	for _, m := range footerSegmentPattern.FindAllStringSubmatch(c.Footer, -1) {
+		known := false
+		for _, s := range FooterSegments {
+			known = known || s == m[1]
+		}
+		if !known {
+			return fmt.Errorf("footer: unknown segment {%s}", m[1])
+		}
+	}
+
+	for name, text := range c.Templates {
+		if !IsTemplateName(name) {
+			return fmt.Errorf("templates[%q]: names are 1 to 32 of a-z, 0-9, - and _", name)
+		}
+		if strings.TrimSpace(text) == "" {
+			return fmt.Errorf("templates[%q]: must not be empty", name)
+		}
+	}
+
+	for i, command := range c.Startup {
+		if !strings.HasPrefix(command, "/") || len(command) < 2 || strings.ContainsAny(command, "\r\n") {
+			return fmt.Errorf("startup[%d]: must be one /command", i)
+		}
+	}
+
+	if c.PasteLines < 0 {
+		return fmt.Errorf("paste_lines: must not be negative")
+	}
+	if c.Scrollback < 0 || (c.Scrollback > 0 && c.Scrollback < MinScrollback) {
+		return fmt.Errorf("scrollback: must be 0 (keep all) or at least %d", MinScrollback)
+	}
+	if c.SlowFrameMS < 0 {
+		return fmt.Errorf("slow_frame_ms: must not be negative")
+	}
+
+	switch c.Notices {
+	case "", NoticesBanner, NoticesTranscript:
+	default:
+		return fmt.Errorf("notices: must be %q or %q", NoticesBanner, NoticesTranscript)
+	}
+
+	switch c.Transcripts {
+	case "", TranscriptsText, TranscriptsJSON:
+	default:
+		return fmt.Errorf("transcripts: must be %q or %q", TranscriptsText, TranscriptsJSON)
+	}
+
+	switch c.Terminal {
+	case "", TerminalAuto, TerminalFull, TerminalLegacy:
+	default:
+		return fmt.Errorf("terminal: must be %q, %q or %q", TerminalAuto, TerminalFull, TerminalLegacy)
+	}
+
+	if _, err := LoadZone(c.TimeZone); err != nil {
+		return fmt.Errorf("time_zone: %v", err)
+	}
+	if _, ok := LookupLocale(c.Locale); c.Locale != "" && !ok {
+		return fmt.Errorf("locale: %q is not one of %s", c.Locale, strings.Join(LocaleNames(), ", "))
+	}
+	if _, err := ParseWeekday(c.FirstWeekday); err != nil {
+		return fmt.Errorf("first_weekday: %v", err)
+	}
+
+	if c.Layout.MaxWidth != 0 && c.Layout.MaxWidth < 40 {
+		return fmt.Errorf("layout: max_width must be 0 or at least 40")
+	}
+
+	if c.MaxMessageBytes != 0 && c.MaxMessageBytes < 256 {
+		return fmt.Errorf("max_message_bytes: must be at least 256")
+	}
+
+	if q := c.QuietHours; q.Start != "" || q.End != "" {
+		start, ok1 := clockMinutes(q.Start)
+		end, ok2 := clockMinutes(q.End)
+		if !ok1 || !ok2 {
+			return fmt.Errorf("quiet_hours: start and end must both be HH:MM")
+		}
+		if start == end {
+			return fmt.Errorf("quiet_hours: start and end must differ")
+		}
+	}
+
+	for key, r := range c.Rooms {
+		switch r.Notify {
+		case "", "all", "mentions", "none":
+		default:
+			return fmt.Errorf("rooms[%q].notify: must be \"all\", \"mentions\" or \"none\"", key)
+		}
+		if r.Accent != "" && !IsValidNamedColor(r.Accent) && !hexColorPattern.MatchString(r.Accent) {
+			return fmt.Errorf("rooms[%q].accent: unknown color %q", key, r.Accent)
+		}
+	}
+
+	if c.Translate.URL != "" {
+		u, err := url.Parse(c.Translate.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("translate: url must be an http:// or https:// URL")
+		}
+	}
+
+	if c.Summary.URL != "" {
+		u, err := url.Parse(c.Summary.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("summary: url must be an http:// or https:// URL")
+		}
+	}
+	if c.Summary.Lines < 0 {
+		return fmt.Errorf("summary: lines must not be negative")
+	}
+
+	seen := map[string]bool{MainAccount: true}
+	for i, a := range c.Accounts {
+		if !templateNamePattern.MatchString(a.Name) {
+			return fmt.Errorf("accounts[%d].name: names are 1 to 32 of a-z, 0-9, - and _", i)
+		}
+		if seen[a.Name] {
+			return fmt.Errorf("accounts[%d].name: %q is taken", i, a.Name)
+		}
+		seen[a.Name] = true
+		u, err := url.Parse(a.Server)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("accounts[%d].server: must be an http:// or https:// URL", i)
+		}
+		c.Accounts[i].Server = strings.TrimRight(a.Server, "/")
+		if a.Username == "" || strings.ContainsAny(a.Username, " \t") {
+			return fmt.Errorf("accounts[%d].username: must be set, without spaces", i)
+		}
+		if a.Color != "" && !IsValidNamedColor(a.Color) {
+			return fmt.Errorf("accounts[%d].color: unknown color %q", i, a.Color)
+		}
+	}
+
+	for i, relay := range c.Relays {
+		u, err := url.Parse(relay)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("relays[%d]: must be an http:// or https:// URL", i)
+		}
+		c.Relays[i] = strings.TrimRight(relay, "/")
+	}
+
+	if c.CrashReportURL != "" {
+		u, err := url.Parse(c.CrashReportURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("crash_report_url: must be an http:// or https:// URL")
+		}
+	}
+
+	if c.PprofAddr != "" {
+		host, _, err := net.SplitHostPort(c.PprofAddr)
+		ip := net.ParseIP(host)
+		if err != nil || (host != "localhost" && (ip == nil || !ip.IsLoopback())) {
+			return fmt.Errorf("pprof_addr: must be a loopback host:port, e.g. localhost:6060")
+		}
+	}
+
+	p := c.Poll
+	if p.Interval < 0 || p.MaxInterval < p.Interval {
+		return fmt.Errorf("poll: need 0 <= interval <= max_interval")
+	}
+	if p.Adaptive && p.Interval == 0 {
+		return fmt.Errorf("poll: adaptive needs a non-zero interval to grow from")
+	}
+	// The relay holds an idle poll for 30s; anything shorter times out
+	// every quiet poll, and the transport gives up on headers after 2m.
+	if p.Timeout <= Duration(30*time.Second) || p.Timeout > Duration(2*time.Minute) {
+		return fmt.Errorf("poll: timeout must be above 30s and at most 2m")
+	}
+	return nil
+}
+
+// Duration is a time.Duration that reads and writes as "500ms", "2m" etc.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"500ms\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
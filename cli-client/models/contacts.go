@@ -0,0 +1,116 @@
+package models
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"cli-client/paths"
+)
+
+// ContactsFile keeps the people we have seen across restarts.
+const ContactsFile = "ttc_contacts.json"
+
+// Contact is someone seen in a conversation: by a message from them or by
+// their presence announcements.
+type Contact struct {
+	Username  string    `json:"username"`
+	Favorite  bool      `json:"favorite,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Room      string    `json:"room"` // conversation key where last seen
+	Messages  int       `json:"messages"`
+
+	// Key is their identity public key, pinned the first time it was seen,
+	// and Trust how far it is trusted. No key means TrustNone.
+	Key   string `json:"key,omitempty"`
+	Trust Trust  `json:"trust,omitempty"`
+}
+
+// Trust is how far a contact's identity key is trusted.
+type Trust string
+
+const (
+	TrustNone     Trust = ""         // no identity key seen
+	TrustPinned   Trust = "pinned"   // the first key seen, trusted on first use
+	TrustVerified Trust = "verified" // fingerprint compared by the user
+)
+
+// TrustOf returns how far username's identity key is trusted.
+func (a *AppState) TrustOf(username string) Trust {
+	if c, ok := a.Contacts[username]; ok && c.Key != "" {
+		return c.Trust
+	}
+	return TrustNone
+}
+
+// LoadContacts reads ContactsFile into a.Contacts. A missing or unreadable
+// file leaves no contacts.
+func (a *AppState) LoadContacts() {
+	data, err := os.ReadFile(paths.Data(ContactsFile))
+	if err != nil {
+		return
+	}
+	var list []*Contact
+	if err := json.Unmarshal(data, &list); err != nil {
+		log.Printf("LoadContacts: ignoring unreadable %s: %v", ContactsFile, err)
+		return
+	}
+	contacts := make(map[string]*Contact, len(list))
+	for _, c := range list {
+		if c != nil && c.Username != "" {
+			contacts[c.Username] = c
+		}
+	}
+	a.Contacts = contacts
+}
+
+// SaveContacts writes a.Contacts to ContactsFile, or removes it when empty.
+func (a *AppState) SaveContacts() {
+	if len(a.Contacts) == 0 {
+		os.Remove(paths.Data(ContactsFile))
+		return
+	}
+	data, err := json.MarshalIndent(a.ContactList(), "", "  ")
+	if err == nil {
+		err = os.WriteFile(paths.Data(ContactsFile), data, 0600)
+	}
+	if err != nil {
+		log.Printf("SaveContacts: %v", err)
+	}
+}
+
+// SeeContact records that username was seen in room at at, adding them to
+// the contacts if new.
+func (a *AppState) SeeContact(username, room string, at time.Time) *Contact {
+	c, ok := a.Contacts[username]
+	if !ok {
+		c = &Contact{Username: username, FirstSeen: at}
+		a.Contacts[username] = c
+	}
+	if at.After(c.LastSeen) {
+		c.LastSeen, c.Room = at, room
+	}
+	return c
+}
+
+// ContactList returns the contacts favorites first, then the most recently
+// seen first.
+func (a *AppState) ContactList() []*Contact {
+	list := make([]*Contact, 0, len(a.Contacts))
+	for _, c := range a.Contacts {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Favorite != list[j].Favorite {
+			return list[i].Favorite
+		}
+		if !list[i].LastSeen.Equal(list[j].LastSeen) {
+			return list[i].LastSeen.After(list[j].LastSeen)
+		}
+		return list[i].Username < list[j].Username
+	})
+	return list
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	"cli-client/paths"
+)
+
+// DraftsFile keeps unsent input per conversation across restarts.
+const DraftsFile = "ttc_drafts.json"
+
+// LoadDrafts reads DraftsFile into a.Drafts. A missing or unreadable file
+// leaves no drafts.
+func (a *AppState) LoadDrafts() {
+	data, err := os.ReadFile(paths.Data(DraftsFile))
+	if err != nil {
+		return
+	}
+	drafts := make(map[string]string)
+	if err := json.Unmarshal(data, &drafts); err != nil {
+		log.Printf("LoadDrafts: ignoring unreadable %s: %v", DraftsFile, err)
+		return
+	}
+	a.Drafts = drafts
+}
+
+// SaveDrafts writes a.Drafts to DraftsFile, or removes it when empty.
+func (a *AppState) SaveDrafts() {
+	if len(a.Drafts) == 0 {
+		os.Remove(paths.Data(DraftsFile))
+		return
+	}
+	data, err := json.MarshalIndent(a.Drafts, "", "  ")
+	if err == nil {
+		err = os.WriteFile(paths.Data(DraftsFile), data, 0600)
+	}
+	if err != nil {
+		log.Printf("SaveDrafts: %v", err)
+	}
+}
+
+// SetDraft records text as the draft for conversation key; "" drops it.
+func (a *AppState) SetDraft(key, text string) {
+	if text == "" {
+		delete(a.Drafts, key)
+		return
+	}
+	a.Drafts[key] = text
+}
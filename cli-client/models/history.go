@@ -0,0 +1,133 @@
+package models
+
+import "time"
+
+// HistorySize is how many chat messages History keeps, across all
+// conversations.
+const HistorySize = 2000
+
+// History is the session's record of chat messages — sent, received and
+// replayed after a detach — for /replay. Unlike AppState.Messages it
+// survives /clear. Only touched inside the tview event loop.
+type History struct {
+	entries  []historyEntry
+	capacity int
+	onAdd    func(room string, msg *Message)
+}
+
+type historyEntry struct {
+	room string // conversation key: a relay URL or "lan"
+	msg  *Message
+}
+
+// NewHistory returns a History holding up to capacity messages.
+func NewHistory(capacity int) *History {
+	return &History{capacity: capacity}
+}
+
+// Add records msg as said in conversation room, dropping the oldest message
+// once the history is full.
+func (h *History) Add(room string, msg *Message) {
+	h.entries = append(h.entries, historyEntry{room: room, msg: msg})
+	if len(h.entries) > h.capacity {
+		h.entries = append(h.entries[:0:0], h.entries[len(h.entries)-h.capacity:]...)
+	}
+	if h.onAdd != nil {
+		h.onAdd(room, msg)
+	}
+}
+
+// OnAdd sets fn to be called with every message Add records, e.g. to keep a
+// transcript. nil = none.
+func (h *History) OnAdd(fn func(room string, msg *Message)) {
+	h.onAdd = fn
+}
+
+// Len returns how many messages the history holds.
+func (h *History) Len() int {
+	return len(h.entries)
+}
+
+// Last returns room's nth most recent message (1 = the latest), or nil.
+func (h *History) Last(room string, n int) *Message {
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		if h.entries[i].room != room {
+			continue
+		}
+		if n--; n == 0 {
+			return h.entries[i].msg
+		}
+	}
+	return nil
+}
+
+// Latest returns up to n of room's latest messages, latest first, so the
+// ith is Last(room, i+1).
+func (h *History) Latest(room string, n int) []*Message {
+	var out []*Message
+	for i := len(h.entries) - 1; i >= 0 && len(out) < n; i-- {
+		if h.entries[i].room == room {
+			out = append(out, h.entries[i].msg)
+		}
+	}
+	return out
+}
+
+// Recent returns up to n of room's latest messages, oldest first, leaving
+// out expired ones and those whose body has not been fetched.
+func (h *History) Recent(room string, n int) []*Message {
+	var out []*Message
+	for i := len(h.entries) - 1; i >= 0 && len(out) < n; i-- {
+		e := h.entries[i]
+		if e.room == room && !e.msg.Expired && e.msg.Pending == 0 {
+			out = append(out, e.msg)
+		}
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// Around returns the message with id and up to span messages either side of
+// it from the same conversation, oldest first, with the index of the
+// message itself. ok is false if id is no longer in the history.
+func (h *History) Around(id string, span int) (msgs []*Message, at int, ok bool) {
+	pos := -1
+	for i, e := range h.entries {
+		if e.msg.ID == id {
+			pos = i
+			break
+		}
+	}
+	if pos < 0 {
+		return nil, 0, false
+	}
+	room := h.entries[pos].room
+	var before []*Message
+	for i := pos - 1; i >= 0 && len(before) < span; i-- {
+		if h.entries[i].room == room {
+			before = append([]*Message{h.entries[i].msg}, before...)
+		}
+	}
+	msgs = append(before, h.entries[pos].msg)
+	for i := pos + 1; i < len(h.entries) && len(msgs) < len(before)+1+span; i++ {
+		if h.entries[i].room == room {
+			msgs = append(msgs, h.entries[i].msg)
+		}
+	}
+	return msgs, len(before), true
+}
+
+// Since returns room's messages newer than d, oldest first. Messages whose
+// content has expired are left out.
+func (h *History) Since(room string, d time.Duration) []*Message {
+	cutoff := Now().Add(-d)
+	var out []*Message
+	for _, e := range h.entries {
+		if e.room == room && e.msg.Timestamp.After(cutoff) && !e.msg.Expired {
+			out = append(out, e.msg)
+		}
+	}
+	return out
+}
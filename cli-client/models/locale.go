@@ -0,0 +1,240 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ── Locale ────────────────────────────────────────────────────────────────────
+//
+// Dates are shown in the user's language: the day separators in the
+// transcript, when contacts were first and last seen, schedules and
+// bookmarks. "locale" in the config picks one, such as "de" or "en_GB";
+// unset, it comes from LC_ALL, LC_TIME or LANG as for other programs, and a
+// language not known here is shown in English. "first_weekday" overrides
+// where the locale starts the week. Times of day stay 24-hour everywhere.
+
+// Locale names months and weekdays and lays dates out in one language.
+// Layouts are Go layouts whose "January", "Jan" and "Monday" are replaced by
+// the locale's names.
+type Locale struct {
+	Months       [12]string
+	ShortMonths  [12]string
+	Weekdays     [7]string // Sunday first, as time.Weekday
+	Today        string
+	Yesterday    string
+	Short        string // day and month, "Jan 2"
+	ShortYear    string // and the year, "Jan 2, 2006"
+	Long         string // with the weekday, "Monday, January 2"
+	LongYear     string // and the year
+	FirstWeekday time.Weekday
+}
+
+// locales are the languages known, by language and, for regional
+// variants, language_REGION.
+var locales = map[string]*Locale{
+	"en": {
+		Months:      [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		ShortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		Weekdays:    [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		Today:       "Today", Yesterday: "Yesterday",
+		Short: "Jan 2", ShortYear: "Jan 2, 2006",
+		Long: "Monday, January 2", LongYear: "Monday, January 2, 2006",
+		FirstWeekday: time.Sunday,
+	},
+	"en_GB": {
+		Months:      [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		ShortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		Weekdays:    [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		Today:       "Today", Yesterday: "Yesterday",
+		Short: "2 Jan", ShortYear: "2 Jan 2006",
+		Long: "Monday 2 January", LongYear: "Monday 2 January 2006",
+		FirstWeekday: time.Monday,
+	},
+	"de": {
+		Months:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		ShortMonths: [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		Weekdays:    [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		Today:       "Heute", Yesterday: "Gestern",
+		Short: "2. Jan", ShortYear: "2. Jan 2006",
+		Long: "Monday, 2. January", LongYear: "Monday, 2. January 2006",
+		FirstWeekday: time.Monday,
+	},
+	"fr": {
+		Months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		ShortMonths: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		Weekdays:    [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		Today:       "Aujourd’hui", Yesterday: "Hier",
+		Short: "2 Jan", ShortYear: "2 Jan 2006",
+		Long: "Monday 2 January", LongYear: "Monday 2 January 2006",
+		FirstWeekday: time.Monday,
+	},
+	"es": {
+		Months:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		ShortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		Weekdays:    [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		Today:       "Hoy", Yesterday: "Ayer",
+		Short: "2 Jan", ShortYear: "2 Jan 2006",
+		Long: "Monday, 2 de January", LongYear: "Monday, 2 de January de 2006",
+		FirstWeekday: time.Monday,
+	},
+	"it": {
+		Months:      [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		ShortMonths: [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		Weekdays:    [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		Today:       "Oggi", Yesterday: "Ieri",
+		Short: "2 Jan", ShortYear: "2 Jan 2006",
+		Long: "Monday 2 January", LongYear: "Monday 2 January 2006",
+		FirstWeekday: time.Monday,
+	},
+	"pt": {
+		Months:      [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		ShortMonths: [12]string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+		Weekdays:    [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		Today:       "Hoje", Yesterday: "Ontem",
+		Short: "2 Jan", ShortYear: "2 Jan 2006",
+		Long: "Monday, 2 de January", LongYear: "Monday, 2 de January de 2006",
+		FirstWeekday: time.Monday,
+	},
+	"nl": {
+		Months:      [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		ShortMonths: [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		Weekdays:    [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		Today:       "Vandaag", Yesterday: "Gisteren",
+		Short: "2 Jan", ShortYear: "2 Jan 2006",
+		Long: "Monday 2 January", LongYear: "Monday 2 January 2006",
+		FirstWeekday: time.Monday,
+	},
+}
+
+func init() {
+	// Regional variants that differ only in where the week starts.
+	pt := *locales["pt"]
+	pt.FirstWeekday = time.Sunday
+	locales["pt_BR"] = &pt
+}
+
+// locale is the one dates are shown in; nil = English.
+var locale atomic.Pointer[Locale]
+
+// SetLocale sets the locale dates are shown in. Safe to call from any
+// goroutine.
+func SetLocale(l *Locale) {
+	locale.Store(l)
+}
+
+// CurrentLocale returns the locale set by SetLocale.
+func CurrentLocale() *Locale {
+	if l := locale.Load(); l != nil {
+		return l
+	}
+	return locales["en"]
+}
+
+// LookupLocale finds the locale for a name as written in the config or a
+// POSIX locale variable, e.g. "de", "en_GB" or "pt_BR.UTF-8".
+func LookupLocale(name string) (*Locale, bool) {
+	name, _, _ = strings.Cut(name, ".")
+	name, _, _ = strings.Cut(name, "@")
+	lang, region, _ := strings.Cut(strings.ReplaceAll(name, "-", "_"), "_")
+	lang = strings.ToLower(lang)
+	if l, ok := locales[lang+"_"+strings.ToUpper(region)]; ok {
+		return l, true
+	}
+	l, ok := locales[lang]
+	return l, ok
+}
+
+// LocaleNames lists the locales known, sorted.
+func LocaleNames() []string {
+	names := make([]string, 0, len(locales))
+	for name := range locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveLocale returns the locale for the "locale" and "first_weekday"
+// config keys, taking the language from the environment if name is empty.
+// Anything unknown is English.
+func ResolveLocale(name, firstWeekday string) *Locale {
+	if name == "" {
+		for _, key := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+			if name = os.Getenv(key); name != "" {
+				break
+			}
+		}
+	}
+	l, ok := LookupLocale(name)
+	if !ok {
+		l = locales["en"]
+	}
+	if wd, err := ParseWeekday(firstWeekday); err == nil && firstWeekday != "" {
+		own := *l
+		own.FirstWeekday = wd
+		l = &own
+	}
+	return l
+}
+
+// ParseWeekday reads a weekday name in English, such as "monday" or "Sun".
+// "" is Sunday.
+func ParseWeekday(name string) (time.Weekday, error) {
+	if name == "" {
+		return time.Sunday, nil
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if long := strings.ToLower(d.String()); strings.EqualFold(name, long) || strings.EqualFold(name, long[:3]) {
+			return d, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("%q is not a weekday", name)
+}
+
+// Format formats t with layout in the locale's language.
+func (l *Locale) Format(t time.Time, layout string) string {
+	// Private-use runes hold the names' places; Format copies them as is.
+	layout = strings.NewReplacer("January", "\uE000", "Monday", "\uE001", "Jan", "\uE002").Replace(layout)
+	return strings.NewReplacer(
+		"\uE000", l.Months[t.Month()-1],
+		"\uE001", l.Weekdays[t.Weekday()],
+		"\uE002", l.ShortMonths[t.Month()-1],
+	).Replace(t.Format(layout))
+}
+
+// FormatDate returns the day and month of t in the display time zone and
+// locale, with the year if withYear is set.
+func FormatDate(t time.Time, withYear bool) string {
+	l := CurrentLocale()
+	if withYear {
+		return l.Format(InZone(t), l.ShortYear)
+	}
+	return l.Format(InZone(t), l.Short)
+}
+
+// DayLabel names the day of t as of now, both in the display time zone:
+// today, yesterday, the weekday for an earlier day of this week, or else the
+// date, with the year if it is not this one.
+func DayLabel(t, now time.Time) string {
+	l := CurrentLocale()
+	t, now = InZone(t), InZone(now)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	week := today.AddDate(0, 0, -(int(today.Weekday())-int(l.FirstWeekday)+7)%7)
+	switch {
+	case day.Equal(today):
+		return l.Today
+	case day.Equal(today.AddDate(0, 0, -1)):
+		return l.Yesterday
+	case !day.Before(week) && day.Before(today):
+		return l.Format(t, "Monday")
+	case t.Year() == now.Year():
+		return l.Format(t, l.Long)
+	}
+	return l.Format(t, l.LongYear)
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
 
 // Message represents a chat message.
 // Color is a tview color tag string e.g. "[green]" or "[#ff00ff]".
@@ -10,7 +14,19 @@ type Message struct {
 	Content   string
 	Timestamp time.Time
 	IsSystem  bool
-	Color     string // tview color tag — used for both username label and content text
+	Color     string    // tview color tag — used for both username label and content text
+	ExpiresAt time.Time // /ephemeral — zero = permanent
+	Expired   bool      // content has been wiped after ExpiresAt
+	Sticker   string    // sticker name; Content then holds its ":name:" text form
+	Poll      *Poll     // /poll — Content then holds the question
+	Pending   int       // poll.headers: body size in bytes, not fetched yet; Content is empty
+	Previous  string    // /edit: Content before the latest edit; "" = never edited
+	Failed    string    // our message the relay did not accept: why; "" = sent or sending
+	Impostor  bool      // claims our username but came from another client
+
+	Announcement bool // admin broadcast from the relay — shown as a banner
+
+	Reactions []Reaction // in the order first given; see React
 }
 
 // NewMessage creates a new outgoing message with the default hash-based color.
@@ -21,7 +37,7 @@ func NewMessage(username, content string) *Message {
 		ID:        generateMessageID(),
 		Username:  username,
 		Content:   content,
-		Timestamp: time.Now(),
+		Timestamp: Now(),
 		IsSystem:  false,
 		Color:     GetUsernameColor(username), // tview tag e.g. "[magenta]"
 	}
@@ -33,17 +49,51 @@ func NewSystemMessage(content string) *Message {
 		ID:        generateMessageID(),
 		Username:  "SYSTEM",
 		Content:   content,
-		Timestamp: time.Now(),
+		Timestamp: Now(),
 		IsSystem:  true,
 		Color:     "[yellow]",
 	}
 }
 
-// FormatTime returns the formatted timestamp for display.
+// FormatTime returns the formatted timestamp for display, in the display
+// time zone.
 func (m *Message) FormatTime() string {
-	return m.Timestamp.Format("15:04")
+	return InZone(m.Timestamp).Format("15:04")
 }
 
+var messageSeq uint64
+
+// generateMessageID returns a process-unique local ID (timestamp + sequence).
 func generateMessageID() string {
-	return time.Now().Format("20060102150405")
+	return fmt.Sprintf("%s-%d", time.Now().Format("20060102150405"), atomic.AddUint64(&messageSeq, 1))
+}
+
+// Reaction is one emoji given to a message, and by whom.
+type Reaction struct {
+	Emoji string
+	Users []string
+}
+
+// React adds user's emoji to the message's reactions, or takes it back if
+// user already gave it. Only touched inside the tview event loop.
+func (m *Message) React(user, emoji string) {
+	for i, r := range m.Reactions {
+		if r.Emoji != emoji {
+			continue
+		}
+		for j, u := range r.Users {
+			if u == user {
+				r.Users = append(r.Users[:j:j], r.Users[j+1:]...)
+				if len(r.Users) == 0 {
+					m.Reactions = append(m.Reactions[:i:i], m.Reactions[i+1:]...)
+				} else {
+					m.Reactions[i] = r
+				}
+				return
+			}
+		}
+		m.Reactions[i].Users = append(r.Users, user)
+		return
+	}
+	m.Reactions = append(m.Reactions, Reaction{Emoji: emoji, Users: []string{user}})
 }
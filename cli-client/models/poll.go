@@ -0,0 +1,41 @@
+package models
+
+// MaxPollOptions is the most answers a poll can offer.
+const MaxPollOptions = 9
+
+// Poll is a /poll question and the votes cast on it so far. Each voter has
+// one vote; voting again moves it. Only touched inside the tview event loop.
+type Poll struct {
+	ID       string
+	Question string
+	Options  []string
+	votes    map[string]int // voter → index into Options
+}
+
+// NewPoll returns a poll with no votes.
+func NewPoll(id, question string, options []string) *Poll {
+	return &Poll{ID: id, Question: question, Options: options, votes: make(map[string]int)}
+}
+
+// Vote records voter's choice of Options[option], replacing any earlier
+// vote. It reports false, changing nothing, if option is out of range or
+// the vote is unchanged.
+func (p *Poll) Vote(voter string, option int) bool {
+	if option < 0 || option >= len(p.Options) {
+		return false
+	}
+	if prev, ok := p.votes[voter]; ok && prev == option {
+		return false
+	}
+	p.votes[voter] = option
+	return true
+}
+
+// Tally returns the number of votes for each option and in all.
+func (p *Poll) Tally() (counts []int, total int) {
+	counts = make([]int, len(p.Options))
+	for _, option := range p.votes {
+		counts[option]++
+	}
+	return counts, len(p.votes)
+}
@@ -7,4 +7,21 @@ const (
 	ScreenLoading Screen = iota
 	ScreenLogin
 	ScreenChat
+	ScreenError // fatal condition — retry, go back or quit
 )
+
+func (s Screen) String() string {
+	switch s {
+	case ScreenNone:
+		return "none"
+	case ScreenLoading:
+		return "loading"
+	case ScreenLogin:
+		return "login"
+	case ScreenChat:
+		return "chat"
+	case ScreenError:
+		return "error"
+	}
+	return "unknown"
+}
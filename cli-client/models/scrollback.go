@@ -0,0 +1,106 @@
+package models
+
+import (
+	"io"
+	"log"
+	"os"
+
+	"cli-client/paths"
+)
+
+// ScrollbackFile holds the transcript lines moved out of memory once the
+// transcript is longer than Config.Scrollback. It only lasts a session: it
+// is emptied when the client starts. SplitScrollbackFile is the same for
+// the second chat pane, shown by /split.
+const (
+	ScrollbackFile      = "ttc_scrollback.txt"
+	SplitScrollbackFile = "ttc_scrollback_split.txt"
+)
+
+// Scrollback is a stack of transcript entries on disk — an entry being a
+// line with the lines that belong under it — newest at the end of the
+// file. Only the offsets are kept in memory. Only touched inside the tview
+// event loop.
+type Scrollback struct {
+	path    string
+	offsets []int64 // where each entry starts
+	size    int64   // where the next one goes
+}
+
+// NewScrollback returns an empty archive at file, such as ScrollbackFile,
+// in the state directory, removing what an earlier session left there.
+func NewScrollback(file string) *Scrollback {
+	s := &Scrollback{path: paths.State(file)}
+	s.Reset()
+	return s
+}
+
+// Push appends entries, oldest first.
+func (s *Scrollback) Push(entries []string) {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		log.Printf("Scrollback: %v — %d entries dropped", err, len(entries))
+		return
+	}
+	defer f.Close()
+	for _, e := range entries {
+		if _, err := io.WriteString(f, e); err != nil {
+			log.Printf("Scrollback: %v — entries dropped", err)
+			return
+		}
+		s.offsets = append(s.offsets, s.size)
+		s.size += int64(len(e))
+	}
+}
+
+// Pop takes back up to n of the newest entries, oldest first. An entry
+// that cannot be read back is lost, with the ones after it.
+func (s *Scrollback) Pop(n int) []string {
+	n = min(n, len(s.offsets))
+	if n == 0 {
+		return nil
+	}
+	from := s.offsets[len(s.offsets)-n]
+	f, err := os.OpenFile(s.path, os.O_RDWR, 0600)
+	if err != nil {
+		log.Printf("Scrollback: %v", err)
+		s.offsets, s.size = nil, 0
+		return nil
+	}
+	defer f.Close()
+	data := make([]byte, s.size-from)
+	if _, err := f.ReadAt(data, from); err != nil {
+		log.Printf("Scrollback: %v", err)
+		s.offsets, s.size = nil, 0
+		return nil
+	}
+	if err := f.Truncate(from); err != nil {
+		log.Printf("Scrollback: %v", err)
+	}
+
+	entries := make([]string, 0, n)
+	text := string(data)
+	starts := s.offsets[len(s.offsets)-n:]
+	for i, start := range starts {
+		end := s.size
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		entries = append(entries, text[start-from:end-from])
+	}
+	s.offsets, s.size = s.offsets[:len(s.offsets)-n], from
+	return entries
+}
+
+// Len returns how many entries the archive holds.
+func (s *Scrollback) Len() int {
+	return len(s.offsets)
+}
+
+// Reset empties the archive.
+func (s *Scrollback) Reset() {
+	s.offsets, s.size = nil, 0
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Scrollback: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+// SessionStats holds per-session counters shown by /sessionstats.
+// It is updated from both the tview event loop and network goroutines, so
+// every access goes through the mutex.
+type SessionStats struct {
+	mu sync.Mutex
+
+	StartedAt     time.Time
+	MessagesSent  int
+	MessagesRecv  int
+	BytesSent     int64
+	BytesRecv     int64
+	PeakActive    int
+	Connects      int
+	latencySum    int64
+	latencySample int
+}
+
+// NewSessionStats starts the session clock.
+func NewSessionStats() *SessionStats {
+	return &SessionStats{StartedAt: time.Now()}
+}
+
+// RecordSent counts an outgoing chat message.
+func (s *SessionStats) RecordSent(content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.MessagesSent++
+	s.BytesSent += int64(len(content))
+}
+
+// RecordReceived counts an incoming chat message.
+func (s *SessionStats) RecordReceived(content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.MessagesRecv++
+	s.BytesRecv += int64(len(content))
+}
+
+// RecordActive keeps the highest active-client count seen in /api/stats.
+func (s *SessionStats) RecordActive(active int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if active > s.PeakActive {
+		s.PeakActive = active
+	}
+}
+
+// RecordConnect counts a successful (re)connection to the relay.
+func (s *SessionStats) RecordConnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Connects++
+}
+
+// RecordLatency adds a latency sample in milliseconds.
+func (s *SessionStats) RecordLatency(ms int) {
+	if ms < 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latencySum += int64(ms)
+	s.latencySample++
+}
+
+// SessionSnapshot is a consistent copy of SessionStats for rendering.
+type SessionSnapshot struct {
+	Uptime       time.Duration
+	MessagesSent int
+	MessagesRecv int
+	BytesSent    int64
+	BytesRecv    int64
+	PeakActive   int
+	Reconnects   int
+	AvgLatency   int // -1 = no samples yet
+}
+
+// Snapshot returns the current counters.
+func (s *SessionStats) Snapshot() SessionSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap := SessionSnapshot{
+		Uptime:       time.Since(s.StartedAt).Round(time.Second),
+		MessagesSent: s.MessagesSent,
+		MessagesRecv: s.MessagesRecv,
+		BytesSent:    s.BytesSent,
+		BytesRecv:    s.BytesRecv,
+		PeakActive:   s.PeakActive,
+		AvgLatency:   -1,
+	}
+	if s.Connects > 1 {
+		snap.Reconnects = s.Connects - 1
+	}
+	if s.latencySample > 0 {
+		snap.AvgLatency = int(s.latencySum / int64(s.latencySample))
+	}
+	return snap
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+// StatsSample is one /api/stats reading.
+type StatsSample struct {
+	At            time.Time
+	TotalMessages int
+	Active        int
+	Waiting       int
+}
+
+// StatsHistory is a fixed-size ring buffer of stats samples. Written by the
+// stats poller goroutine and read by the dashboard, so it is mutex-guarded.
+type StatsHistory struct {
+	mu      sync.Mutex
+	samples []StatsSample
+	next    int
+	full    bool
+}
+
+// NewStatsHistory returns a ring buffer holding up to capacity samples.
+func NewStatsHistory(capacity int) *StatsHistory {
+	return &StatsHistory{samples: make([]StatsSample, capacity)}
+}
+
+// Add records a sample, overwriting the oldest once the buffer is full.
+func (h *StatsHistory) Add(s StatsSample) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.samples[h.next] = s
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Since returns the samples newer than d, oldest first.
+func (h *StatsHistory) Since(d time.Duration) []StatsSample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ordered := h.samples[:h.next]
+	if h.full {
+		ordered = append(append([]StatsSample{}, h.samples[h.next:]...), h.samples[:h.next]...)
+	}
+	cutoff := time.Now().Add(-d)
+	out := make([]StatsSample, 0, len(ordered))
+	for _, s := range ordered {
+		if s.At.After(cutoff) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
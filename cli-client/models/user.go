@@ -10,6 +10,8 @@ type User struct {
 	Username string
 	Color    string // tview color tag e.g. "[magenta]"
 	IsOnline bool
+	Away     bool   // announced quiet hours
+	Zone     string // time zone they shared, for LoadZone; "" = unknown
 	LastSeen time.Time
 }
 
@@ -88,12 +90,28 @@ func ParseColorToTag(s string) string {
 	return "[" + strings.ToLower(s) + "]"
 }
 
+// ColorName is the reverse of ParseColorToTag for the wire: "[cyan]" → "cyan",
+// "[#ff8800]" → "#ff8800". Tags belong to the renderer; peers get plain values
+// and convert them with ParseColorToTag when they draw. Other input passes
+// through unchanged.
+func ColorName(tag string) string {
+	if strings.HasPrefix(tag, "[") && strings.HasSuffix(tag, "]") {
+		return tag[1 : len(tag)-1]
+	}
+	return tag
+}
+
 // ValidNamedColors is the list of named colors users can choose via /user_color.
 var ValidNamedColors = []string{
 	"red", "green", "blue", "cyan", "magenta", "yellow",
 	"white", "orange", "purple", "teal", "lime", "pink",
 }
 
+// IsValidColor reports whether s is one of ValidNamedColors or #rrggbb.
+func IsValidColor(s string) bool {
+	return IsValidNamedColor(s) || hexColorPattern.MatchString(strings.TrimSpace(s))
+}
+
 // IsValidNamedColor returns true if s is a supported named color.
 func IsValidNamedColor(s string) bool {
 	s = strings.ToLower(strings.TrimSpace(s))
@@ -0,0 +1,143 @@
+// Package paths decides where the client keeps its files.
+//
+// By default they follow the platform conventions:
+//
+//	            config                   data                       state (log, lock, spool)
+//	Linux/BSD   $XDG_CONFIG_HOME/ttc     $XDG_DATA_HOME/ttc         $XDG_STATE_HOME/ttc
+//	            (~/.config/ttc)          (~/.local/share/ttc)       (~/.local/state/ttc)
+//	macOS       ~/Library/Application Support/ttc, unless the XDG variables are set
+//	Windows     %APPDATA%\ttc            %LOCALAPPDATA%\ttc         %LOCALAPPDATA%\ttc
+//
+// In portable mode (--portable) all three are the directory holding the
+// binary, so a copy on a USB stick carries its settings with it.
+package paths
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// appDir is the directory created under each base directory.
+const appDir = "ttc"
+
+type kind int
+
+const (
+	config kind = iota
+	data
+	state
+)
+
+var (
+	mu       sync.Mutex
+	portable bool
+	dirs     [3]string // resolved on first use
+	made     [3]bool   // MkdirAll already done
+)
+
+// SetPortable keeps every file beside the binary. Call it before any path
+// is resolved.
+func SetPortable() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	dir := filepath.Dir(exe)
+
+	mu.Lock()
+	defer mu.Unlock()
+	portable = true
+	dirs = [3]string{dir, dir, dir}
+	return nil
+}
+
+// Portable reports whether SetPortable was called.
+func Portable() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return portable
+}
+
+// Config returns where the user-edited configuration file name lives.
+func Config(name string) string { return join(config, name) }
+
+// Data returns where the user's own data file name lives: drafts,
+// bookmarks, scheduled messages — what a profile export carries.
+func Data(name string) string { return join(data, name) }
+
+// State returns where the runtime file name lives: the log, the
+// single-instance lock, crash reports and the detached session.
+func State(name string) string { return join(state, name) }
+
+// Dirs returns the config, data and state directories.
+func Dirs() (configDir, dataDir, stateDir string) {
+	return dir(config), dir(data), dir(state)
+}
+
+func join(k kind, name string) string {
+	return filepath.Join(dir(k), name)
+}
+
+// dir resolves and creates the directory for k. If it cannot be created the
+// working directory is used, as versions before this package did.
+func dir(k kind) string {
+	mu.Lock()
+	defer mu.Unlock()
+	if dirs[k] == "" {
+		dirs = platformDirs()
+	}
+	if !made[k] {
+		made[k] = true
+		if err := os.MkdirAll(dirs[k], 0700); err != nil {
+			log.Printf("paths: %v — using the working directory", err)
+			dirs[k] = "."
+		}
+	}
+	return dirs[k]
+}
+
+// platformDirs returns the default config, data and state directories.
+func platformDirs() [3]string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return [3]string{".", ".", "."}
+	}
+
+	if runtime.GOOS == "windows" {
+		roaming := envDir("APPDATA", filepath.Join(home, "AppData", "Roaming"))
+		local := envDir("LOCALAPPDATA", filepath.Join(home, "AppData", "Local"))
+		return [3]string{
+			filepath.Join(roaming, appDir),
+			filepath.Join(local, appDir),
+			filepath.Join(local, appDir),
+		}
+	}
+
+	cfg := filepath.Join(home, ".config")
+	dat := filepath.Join(home, ".local", "share")
+	st := filepath.Join(home, ".local", "state")
+	if runtime.GOOS == "darwin" {
+		support := filepath.Join(home, "Library", "Application Support")
+		cfg, dat, st = support, support, support
+	}
+	return [3]string{
+		filepath.Join(envDir("XDG_CONFIG_HOME", cfg), appDir),
+		filepath.Join(envDir("XDG_DATA_HOME", dat), appDir),
+		filepath.Join(envDir("XDG_STATE_HOME", st), appDir),
+	}
+}
+
+// envDir returns the directory in the environment variable key, or fallback
+// if it is unset or relative (the XDG spec says to ignore relative paths).
+func envDir(key, fallback string) string {
+	if v := os.Getenv(key); filepath.IsAbs(v) {
+		return v
+	}
+	return fallback
+}
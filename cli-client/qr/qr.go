@@ -0,0 +1,495 @@
+// Package qr encodes short texts as QR codes for the terminal.
+//
+// Only what the client needs is here: byte mode, error correction level M
+// (about 15% of the code may be damaged), versions 1 to 10 — up to 213
+// bytes, plenty for a relay URL, an invitation or a fingerprint. The mask is
+// chosen by the standard's penalty rules, so phones read the result as
+// easily as any other generator's.
+package qr
+
+import (
+	"errors"
+	"strings"
+)
+
+// MaxBytes is the longest text Encode takes.
+const MaxBytes = 213
+
+// ErrTooLong is returned for a text longer than MaxBytes.
+var ErrTooLong = errors.New("qr: text too long for a QR code")
+
+// Code is an encoded QR code.
+type Code struct {
+	Size    int // modules per side, without the quiet zone
+	modules [][]bool
+}
+
+// Dark reports whether the module in column x, row y is dark.
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// version describes one QR version at error correction level M.
+type version struct {
+	ecPerBlock int
+	blocks     int // with shortData data codewords each
+	shortData  int
+	longBlocks int // with shortData+1 data codewords each, after the others
+	align      []int
+}
+
+// versions are 1 to 10, level M, from the standard's tables.
+var versions = []version{
+	{10, 1, 16, 0, nil},
+	{16, 1, 28, 0, []int{6, 18}},
+	{26, 1, 44, 0, []int{6, 22}},
+	{18, 2, 32, 0, []int{6, 26}},
+	{24, 2, 43, 0, []int{6, 30}},
+	{16, 4, 27, 0, []int{6, 34}},
+	{18, 4, 31, 0, []int{6, 22, 38}},
+	{22, 2, 38, 2, []int{6, 24, 42}},
+	{22, 3, 36, 2, []int{6, 26, 46}},
+	{26, 4, 43, 1, []int{6, 28, 50}},
+}
+
+func (v version) dataCodewords() int {
+	return v.blocks*v.shortData + v.longBlocks*(v.shortData+1)
+}
+
+// Encode returns text as the smallest QR code that holds it.
+func Encode(text string) (*Code, error) {
+	for i, v := range versions {
+		n := i + 1
+		countBits := 8
+		if n >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(text) > 8*v.dataCodewords() {
+			continue
+		}
+		data := encodeData(text, countBits, v.dataCodewords())
+		return build(n, v, interleave(v, data)), nil
+	}
+	return nil, ErrTooLong
+}
+
+// ── Data ──────────────────────────────────────────────────────────────────────
+
+// bitWriter appends bits, most significant first.
+type bitWriter struct {
+	bytes []byte
+	n     int // bits written
+}
+
+func (w *bitWriter) write(value, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.bytes = append(w.bytes, 0)
+		}
+		if value>>i&1 == 1 {
+			w.bytes[w.n/8] |= 0x80 >> (w.n % 8)
+		}
+		w.n++
+	}
+}
+
+// encodeData lays text out in byte mode and pads it to capacity codewords.
+func encodeData(text string, countBits, capacity int) []byte {
+	var w bitWriter
+	w.write(0b0100, 4) // byte mode
+	w.write(len(text), countBits)
+	for i := 0; i < len(text); i++ {
+		w.write(int(text[i]), 8)
+	}
+	w.write(0, min(4, capacity*8-w.n)) // terminator
+	if w.n%8 != 0 {
+		w.write(0, 8-w.n%8)
+	}
+	for pad := 0xEC; len(w.bytes) < capacity; pad ^= 0xEC ^ 0x11 {
+		w.bytes = append(w.bytes, byte(pad))
+	}
+	return w.bytes
+}
+
+// interleave splits data into the version's blocks, adds each block's error
+// correction, and interleaves them as the standard lays them out.
+func interleave(v version, data []byte) []byte {
+	var blocks, ecs [][]byte
+	divisor := rsDivisor(v.ecPerBlock)
+	for i := 0; i < v.blocks+v.longBlocks; i++ {
+		n := v.shortData
+		if i >= v.blocks {
+			n++
+		}
+		blocks = append(blocks, data[:n])
+		ecs = append(ecs, rsRemainder(data[:n], divisor))
+		data = data[n:]
+	}
+	var out []byte
+	for i := 0; i <= v.shortData; i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+			}
+		}
+	}
+	for i := 0; i < v.ecPerBlock; i++ {
+		for _, ec := range ecs {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+// ── Reed-Solomon over GF(256), polynomial 0x11D ───────────────────────────────
+
+func gfMul(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		carry := z & 0x80
+		z <<= 1
+		if carry != 0 {
+			z ^= 0x1D
+		}
+		if y>>i&1 == 1 {
+			z ^= x
+		}
+	}
+	return z
+}
+
+// rsDivisor returns the generator polynomial of the given degree, highest
+// term first and its leading 1 left out.
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder returns the error correction codewords for data.
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMul(d, factor)
+		}
+	}
+	return result
+}
+
+// ── Matrix ────────────────────────────────────────────────────────────────────
+
+// matrix is a code being drawn: the modules and which of them are function
+// patterns, which neither data nor masks touch.
+type matrix struct {
+	size     int
+	dark     [][]bool
+	function [][]bool
+}
+
+func newMatrix(size int) *matrix {
+	m := &matrix{size: size}
+	m.dark = make([][]bool, size)
+	m.function = make([][]bool, size)
+	for i := range m.dark {
+		m.dark[i] = make([]bool, size)
+		m.function[i] = make([]bool, size)
+	}
+	return m
+}
+
+func (m *matrix) set(x, y int, dark bool) {
+	m.dark[y][x] = dark
+	m.function[y][x] = true
+}
+
+// build draws a complete code of version n from its codewords.
+func build(n int, v version, codewords []byte) *Code {
+	size := 17 + 4*n
+	m := newMatrix(size)
+
+	for i := 0; i < size; i++ {
+		m.set(6, i, i%2 == 0)
+		m.set(i, 6, i%2 == 0)
+	}
+	m.finder(3, 3)
+	m.finder(size-4, 3)
+	m.finder(3, size-4)
+	last := len(v.align) - 1
+	for i, ax := range v.align {
+		for j, ay := range v.align {
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue // the finders are there
+			}
+			m.alignment(ax, ay)
+		}
+	}
+	m.format(0) // reserve the area; drawn for real once the mask is chosen
+	if n >= 7 {
+		m.version(n)
+	}
+	m.place(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		m.applyMask(mask)
+		m.format(mask)
+		if p := m.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		m.applyMask(mask) // undo
+	}
+	m.applyMask(best)
+	m.format(best)
+	return &Code{Size: size, modules: m.dark}
+}
+
+// finder draws a finder pattern centered on x, y, with its separator.
+func (m *matrix) finder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= m.size || yy >= m.size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			m.set(xx, yy, d != 2 && d != 4)
+		}
+	}
+}
+
+// alignment draws an alignment pattern centered on x, y.
+func (m *matrix) alignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			m.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// format draws both copies of the format information for level M and mask,
+// and the dark module.
+func (m *matrix) format(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+	for i := 0; i <= 5; i++ {
+		m.set(8, i, bit(i))
+	}
+	m.set(8, 7, bit(6))
+	m.set(8, 8, bit(7))
+	m.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		m.set(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.set(8, m.size-15+i, bit(i))
+	}
+	m.set(8, m.size-8, true)
+}
+
+// formatBits returns the 15 format bits for level M and mask.
+func formatBits(mask int) int {
+	data := 0b00<<3 | mask // level M
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// version draws both copies of the version information.
+func (m *matrix) version(n int) {
+	bits := versionBits(n)
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 == 1
+		a, b := m.size-11+i%3, i/3
+		m.set(a, b, dark)
+		m.set(b, a, dark)
+	}
+}
+
+// versionBits returns the 18 version bits for version n.
+func versionBits(n int) int {
+	rem := n
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	return n<<12 | rem
+}
+
+// place lays the codewords out in the standard's zigzag, two columns at a
+// time from the bottom right, skipping the function patterns.
+func (m *matrix) place(codewords []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < m.size; vert++ {
+			y := vert
+			if upward {
+				y = m.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if m.function[y][x] || i >= len(codewords)*8 {
+					continue // past the end: remainder bits, left light
+				}
+				m.dark[y][x] = codewords[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules mask selects; applying it twice undoes it.
+func (m *matrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !m.function[y][x] {
+				m.dark[y][x] = !m.dark[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the matrix by the standard's four rules; lower reads better.
+func (m *matrix) penalty() int {
+	total := 0
+	row := make([]bool, m.size)
+	col := make([]bool, m.size)
+	for i := 0; i < m.size; i++ {
+		for j := 0; j < m.size; j++ {
+			row[j], col[j] = m.dark[i][j], m.dark[j][i]
+		}
+		total += linePenalty(row) + linePenalty(col)
+	}
+	dark := 0
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.dark[y][x] {
+				dark++
+			}
+			if x+1 < m.size && y+1 < m.size {
+				c := m.dark[y][x]
+				if c == m.dark[y][x+1] && c == m.dark[y+1][x] && c == m.dark[y+1][x+1] {
+					total += 3
+				}
+			}
+		}
+	}
+	cells := m.size * m.size
+	total += (abs(dark*20-cells*10)+cells-1)/cells*10 - 10
+	return total
+}
+
+// finderLike are the finder-like runs rule 3 penalizes, with light space on
+// one side.
+var finderLike = []string{"10111010000", "00001011101"}
+
+// linePenalty scores one row or column for rules 1 and 3.
+func linePenalty(line []bool) int {
+	total := 0
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			total += 3 + run - 5
+		}
+		run = 1
+	}
+	var b strings.Builder
+	for _, dark := range line {
+		if dark {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+	s := b.String()
+	for _, p := range finderLike {
+		for i := 0; i+len(p) <= len(s); i++ {
+			if s[i:i+len(p)] == p {
+				total += 40
+			}
+		}
+	}
+	return total
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ── Terminal ──────────────────────────────────────────────────────────────────
+
+// Lines renders the code with half blocks, two rows of modules to a line,
+// inside a quiet zone of quiet modules. "█", "▀" and "▄" are dark, so it
+// needs dark text on a light background.
+func (c *Code) Lines(quiet int) []string {
+	size := c.Size + 2*quiet
+	dark := func(x, y int) bool {
+		x, y = x-quiet, y-quiet
+		return x >= 0 && y >= 0 && x < c.Size && y < c.Size && c.modules[y][x]
+	}
+	var lines []string
+	for y := 0; y < size; y += 2 {
+		var b strings.Builder
+		for x := 0; x < size; x++ {
+			switch top, bottom := dark(x, y), dark(x, y+1); {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
@@ -0,0 +1,79 @@
+package qr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// "HELLO WORLD" at 1-M, from the standard's worked example.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("rsRemainder = %v, want %v", got, want)
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	if got, want := formatBits(0), 0b101010000010010; got != want {
+		t.Errorf("formatBits(0) = %015b, want %015b", got, want)
+	}
+	if got, want := versionBits(7), 0b000111110010010100; got != want {
+		t.Errorf("versionBits(7) = %018b, want %018b", got, want)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		text string
+		size int
+	}{
+		{"https://relay.example.org", 25},
+		{strings.Repeat("a", 14), 21},
+		{strings.Repeat("a", 15), 25},
+		{strings.Repeat("a", 152), 49},
+		{strings.Repeat("a", 153), 53},
+		{strings.Repeat("a", MaxBytes), 57},
+	}
+	for _, tt := range tests {
+		c, err := Encode(tt.text)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes): %v", len(tt.text), err)
+		}
+		if c.Size != tt.size {
+			t.Errorf("Encode(%d bytes) size = %d, want %d", len(tt.text), c.Size, tt.size)
+		}
+		// Finder corners, the dark module, and a format that reads back
+		// as level M with one of the eight masks.
+		for _, at := range [][2]int{{0, 0}, {c.Size - 1, 0}, {0, c.Size - 1}, {8, c.Size - 8}} {
+			if !c.Dark(at[0], at[1]) {
+				t.Errorf("module %v is light", at)
+			}
+		}
+		format := 0
+		for i := 0; i < 8; i++ {
+			if c.Dark(c.Size-1-i, 8) {
+				format |= 1 << i
+			}
+		}
+		for i := 8; i < 15; i++ {
+			if c.Dark(8, c.Size-15+i) {
+				format |= 1 << i
+			}
+		}
+		valid := false
+		for mask := 0; mask < 8; mask++ {
+			valid = valid || format == formatBits(mask)
+		}
+		if !valid {
+			t.Errorf("Encode(%d bytes) format bits %015b are not level M", len(tt.text), format)
+		}
+		if lines := c.Lines(4); len(lines) != (c.Size+9)/2 {
+			t.Errorf("Lines(4) = %d lines, want %d", len(lines), (c.Size+9)/2)
+		}
+	}
+	if _, err := Encode(strings.Repeat("a", MaxBytes+1)); err != ErrTooLong {
+		t.Errorf("Encode(%d bytes) error = %v, want ErrTooLong", MaxBytes+1, err)
+	}
+}
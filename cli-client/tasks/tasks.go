@@ -0,0 +1,99 @@
+// Package tasks keeps track of the client's long-running goroutines by name.
+//
+// Goroutines started with Go are registered until they return. At exit,
+// Shutdown tells them to stop, waits for them, and logs the names of any
+// that are still running when the deadline passes — those are leaks.
+package tasks
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	mu      sync.Mutex
+	running = make(map[uint64]string) // task ID → name
+	nextID  uint64
+	changed = make(chan struct{}, 1) // signalled when a task returns
+
+	stopOnce sync.Once
+	stopping = make(chan struct{})
+)
+
+// Go runs fn in a new goroutine registered under name until fn returns.
+func Go(name string, fn func()) {
+	mu.Lock()
+	nextID++
+	id := nextID
+	running[id] = name
+	mu.Unlock()
+
+	go func() {
+		defer func() {
+			mu.Lock()
+			delete(running, id)
+			mu.Unlock()
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		}()
+		fn()
+	}()
+}
+
+// Stopping is closed by Stop. Loops that have no owner of their own to stop
+// them should select on it.
+func Stopping() <-chan struct{} { return stopping }
+
+// Stop closes Stopping. Call it once the tview event loop has exited: from
+// then on QueueUpdate never returns, so nothing should wait on it.
+func Stop() {
+	stopOnce.Do(func() { close(stopping) })
+}
+
+// Running returns the names of the registered goroutines still running,
+// sorted, with a count after names that are running more than once.
+func Running() []string {
+	mu.Lock()
+	counts := make(map[string]int, len(running))
+	for _, name := range running {
+		counts[name]++
+	}
+	mu.Unlock()
+
+	names := make([]string, 0, len(counts))
+	for name, n := range counts {
+		if n > 1 {
+			name = fmt.Sprintf("%s ×%d", name, n)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Shutdown calls Stop and waits up to timeout for every registered
+// goroutine to return. It logs and returns the names of those still running.
+func Shutdown(timeout time.Duration) []string {
+	Stop()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		left := Running()
+		if len(left) == 0 {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-deadline.C:
+			log.Printf("tasks: still running %v after shutdown: %s", timeout, strings.Join(left, ", "))
+			return left
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+)
+
+// ── Clipboard ─────────────────────────────────────────────────────────────────
+//
+// Text is copied with OSC 52, which most terminals honor and which also
+// works over SSH; the terminal may ask before allowing it, or ignore it.
+// Under tmux it needs "set -g set-clipboard on"; GNU screen passes it on
+// when wrapped in a DCS string.
+
+// maxClipboard is the most copied in one sequence; some terminals drop
+// longer ones whole.
+const maxClipboard = 74994 // 100000 bytes of base64, less the framing
+
+// CopyText asks the terminal to put text on the system clipboard. It
+// reports false if text was too long to send.
+func CopyText(text string) bool {
+	if len(text) > maxClipboard {
+		return false
+	}
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+	if os.Getenv("STY") != "" {
+		seq = "\x1bP" + seq + "\x1b\\"
+	}
+	fmt.Fprint(os.Stdout, seq)
+	return true
+}
@@ -0,0 +1,186 @@
+package ui
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ── Frame timing ──────────────────────────────────────────────────────────────
+//
+// A frame is one queued update and the draw that follows it: everything the
+// tview event loop does between two chances to read a key. Updates queued
+// with SafeQueueUpdateDraw are timed, and InstallFrameMeter hooks the draw
+// itself, so each frame is timed in two parts. One slower than the threshold
+// is logged with the name of its update, and reported to the OnSlow
+// function. Draws with no timed update before them — key presses, resizes —
+// are frames too.
+
+// DefaultSlowFrame is the threshold above which a frame is slow.
+const DefaultSlowFrame = 50 * time.Millisecond
+
+// frameWindow is how many recent frames the percentiles are taken over.
+const frameWindow = 10000
+
+// FrameMeter times frames. Its methods are safe to call from any goroutine.
+type FrameMeter struct {
+	// tview event loop only
+	began   time.Time
+	update  time.Duration // timed updates since the last draw
+	updater string        // the name of the slowest of them
+
+	mu        sync.Mutex
+	recent    []time.Duration // ring of the last frameWindow frames
+	next      int
+	total     int
+	slow      int
+	threshold time.Duration
+	onSlow    func(name string, took time.Duration)
+	capture   []time.Duration
+	capturing bool
+}
+
+// Frames is the meter for the application's event loop.
+var Frames = &FrameMeter{threshold: DefaultSlowFrame}
+
+// FrameStats summarises the recent frames.
+type FrameStats struct {
+	Count              int // frames since start
+	Slow               int // of those, slower than the threshold
+	Threshold          time.Duration
+	P50, P95, P99, Max time.Duration // over the last frameWindow frames
+}
+
+// InstallFrameMeter hooks Frames onto app's draw, keeping any functions
+// already set. Call it once, before Run.
+func InstallFrameMeter(app *tview.Application) {
+	m := Frames
+	before, after := app.GetBeforeDrawFunc(), app.GetAfterDrawFunc()
+	app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+		m.began = time.Now()
+		return before != nil && before(screen)
+	})
+	app.SetAfterDrawFunc(func(screen tcell.Screen) {
+		if after != nil {
+			after(screen)
+		}
+		draw := time.Since(m.began)
+		m.record(m.update+draw, m.updater)
+		m.update, m.updater = 0, ""
+	})
+}
+
+// timed runs f, an update named name, on the tview event loop, counting
+// its time towards the frame it is drawn in.
+func timed(name string, f func()) {
+	start := time.Now()
+	defer func() {
+		took := time.Since(start)
+		m := Frames
+		if m.updater == "" || took > m.update {
+			m.updater = name
+		}
+		m.update += took
+	}()
+	f()
+}
+
+// SetThreshold sets how long a frame may take before it is slow; 0 restores
+// DefaultSlowFrame.
+func (m *FrameMeter) SetThreshold(d time.Duration) {
+	if d <= 0 {
+		d = DefaultSlowFrame
+	}
+	m.mu.Lock()
+	m.threshold = d
+	m.mu.Unlock()
+}
+
+// OnSlow sets a function called with the callback's name and the frame time
+// after each slow frame. It is called from the tview event loop, mid-draw:
+// it must not block or queue updates and wait for them.
+func (m *FrameMeter) OnSlow(fn func(name string, took time.Duration)) {
+	m.mu.Lock()
+	m.onSlow = fn
+	m.mu.Unlock()
+}
+
+func (m *FrameMeter) record(took time.Duration, updater string) {
+	m.mu.Lock()
+	if len(m.recent) < frameWindow {
+		m.recent = append(m.recent, took)
+	} else {
+		m.recent[m.next] = took
+		m.next = (m.next + 1) % frameWindow
+	}
+	m.total++
+	if m.capturing {
+		m.capture = append(m.capture, took)
+	}
+	slow := took > m.threshold
+	if slow {
+		m.slow++
+	}
+	onSlow := m.onSlow
+	m.mu.Unlock()
+
+	if !slow {
+		return
+	}
+	name := updater
+	if name == "" {
+		name = "draw" // no timed update: a key press or resize
+	}
+	log.Printf("frames: slow frame %v in %s", took.Round(time.Millisecond/10), name)
+	if onSlow != nil {
+		onSlow(name, took)
+	}
+}
+
+// Stats returns the frame counts and percentiles.
+func (m *FrameMeter) Stats() FrameStats {
+	m.mu.Lock()
+	sorted := append([]time.Duration(nil), m.recent...)
+	st := FrameStats{Count: m.total, Slow: m.slow, Threshold: m.threshold}
+	m.mu.Unlock()
+
+	sortDurations(sorted)
+	st.P50, st.P95 = Percentile(sorted, 50), Percentile(sorted, 95)
+	st.P99, st.Max = Percentile(sorted, 99), Percentile(sorted, 100)
+	return st
+}
+
+// Capture starts collecting every frame time until EndCapture, for a
+// measurement such as /stress.
+func (m *FrameMeter) Capture() {
+	m.mu.Lock()
+	m.capturing, m.capture = true, nil
+	m.mu.Unlock()
+}
+
+// EndCapture stops collecting and returns the frame times since Capture,
+// sorted.
+func (m *FrameMeter) EndCapture() []time.Duration {
+	m.mu.Lock()
+	frames := m.capture
+	m.capturing, m.capture = false, nil
+	m.mu.Unlock()
+	sortDurations(frames)
+	return frames
+}
+
+// Percentile returns the p-th percentile (0–100) of sorted, or 0.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(float64(len(sorted)-1)*p/100)]
+}
+
+func sortDurations(d []time.Duration) {
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+}
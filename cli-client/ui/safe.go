@@ -0,0 +1,97 @@
+// Package ui holds the plumbing between goroutines and the tview event loop:
+// queuing updates that survive panics and shutdown, starting goroutines
+// that cannot take the client down, and timing frames (see frames.go).
+package ui
+
+import (
+	"log"
+	"runtime/debug"
+	"sync"
+
+	"cli-client/tasks"
+
+	"github.com/rivo/tview"
+)
+
+// ── Recovery ──────────────────────────────────────────────────────────────────
+//
+// Every update queued here and every goroutine started here recovers its own
+// panics and reports them to one handler, named after the work that failed.
+// A panic in one message's animation or one relay request costs that piece
+// of work, not the session.
+
+var (
+	panicMu      sync.Mutex
+	panicHandler = func(name string, r any, stack []byte) {
+		log.Printf("PANIC %s: %v\n%s", name, r, stack)
+	}
+)
+
+// SetPanicHandler sets the function recovered panics are reported to, with
+// the name of the update or goroutine, the panic value and the stack. The
+// handler may run on several goroutines at once. The default logs them.
+func SetPanicHandler(fn func(name string, r any, stack []byte)) {
+	panicMu.Lock()
+	panicHandler = fn
+	panicMu.Unlock()
+}
+
+// Recover reports a panic in the work called name, and stops it there. Use
+// it deferred — defer ui.Recover("name") — in code that runs outside
+// SafeGo and SafeQueueUpdateDraw, such as screen hooks.
+func Recover(name string) {
+	if r := recover(); r != nil {
+		report(name, r)
+	}
+}
+
+func report(name string, r any) {
+	stack := debug.Stack()
+	panicMu.Lock()
+	handler := panicHandler
+	panicMu.Unlock()
+	handler(name, r, stack)
+}
+
+// SafeGo runs fn in a new goroutine registered with tasks under name, and
+// recovers a panic in it.
+func SafeGo(name string, fn func()) {
+	tasks.Go(name, func() {
+		defer Recover(name)
+		fn()
+	})
+}
+
+// SafeQueueUpdateDraw runs fn, named name, on app's event loop and redraws,
+// timing the frame and recovering a panic in fn. It returns once fn has run,
+// or at once when the client is shutting down: the event loop is gone then,
+// and fn is dropped. Never call it from inside the tview event loop.
+func SafeQueueUpdateDraw(app *tview.Application, name string, fn func()) {
+	queue(app.QueueUpdateDraw, name, fn)
+}
+
+// SafeQueueUpdate is SafeQueueUpdateDraw without the redraw, for state that
+// is not on screen.
+func SafeQueueUpdate(app *tview.Application, name string, fn func()) {
+	queue(app.QueueUpdate, name, fn)
+}
+
+func queue(enqueue func(func()) *tview.Application, name string, fn func()) {
+	select {
+	case <-tasks.Stopping():
+		return
+	default:
+	}
+	done := make(chan struct{})
+	// Enqueuing blocks while the updates channel is full, so it happens on
+	// its own goroutine: a stopped event loop never empties it.
+	go enqueue(func() {
+		defer close(done)
+		defer Recover(name)
+		timed(name, fn)
+	})
+	select {
+	case <-done:
+	case <-tasks.Stopping():
+	}
+}
@@ -0,0 +1,191 @@
+package ui
+
+import (
+	"log"
+	"runtime"
+	"sync/atomic"
+
+	"cli-client/models"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// ── Legacy consoles ───────────────────────────────────────────────────────────
+//
+// The old Windows console (conhost without VT processing) shows box-drawing
+// characters as junk in most code pages, cannot do more than 16 colors and
+// has no dim attribute. On such a terminal every cell is translated on its
+// way to the screen: lines and blocks become ASCII, other colors the
+// nearest of the 16, and dim text gray. The views draw as usual and never
+// know.
+//
+// Config "terminal" picks the profile; "auto" looks at what the terminal
+// reports once it is set up.
+
+// legacyActive is whether the legacy profile is in use, for /info.
+var legacyActive atomic.Bool
+
+// NewScreen returns an initialized screen for the terminal profile (one of
+// the models.Terminal* values), to hand to tview's SetScreen.
+func NewScreen(profile string) (tcell.Screen, error) {
+	s, err := tcell.NewScreen()
+	if err != nil {
+		return nil, err
+	}
+	cs := &termScreen{Screen: s, profile: profile}
+	if err := cs.Init(); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// LegacyTerminal reports whether the screen is drawn with the legacy
+// profile. Safe to call from any goroutine.
+func LegacyTerminal() bool {
+	return legacyActive.Load()
+}
+
+// termScreen passes everything to the terminal's screen, translating
+// cells when the legacy profile is on and keeping track of the title and
+// focus (see title.go).
+type termScreen struct {
+	tcell.Screen
+	profile string
+	ready   bool // Init has run; tview's SetScreen calls it again
+	legacy  bool
+
+	titled    bool // a multiplexer window name was set
+	unfocused bool // the terminal reported losing focus; PollEvent only
+}
+
+func (s *termScreen) Init() error {
+	if s.ready {
+		return nil
+	}
+	if err := s.Screen.Init(); err != nil {
+		return err
+	}
+	s.ready = true
+	s.Screen.EnableFocus()
+	switch s.profile {
+	case models.TerminalLegacy:
+		s.legacy = true
+	case models.TerminalFull:
+		s.legacy = false
+	default:
+		s.legacy = needsLegacy(s.Screen)
+	}
+	legacyActive.Store(s.legacy)
+	log.Printf("terminal: %d colors, legacy profile %v (%q)", s.Screen.Colors(), s.legacy, s.profile)
+	return nil
+}
+
+func (s *termScreen) Fini() {
+	s.ready = false
+	s.Screen.Fini()
+	s.clearTitle()
+}
+
+func (s *termScreen) SetContent(x, y int, primary rune, combining []rune, style tcell.Style) {
+	if s.legacy {
+		primary, combining, style = asciiRune(primary), nil, basicStyle(style)
+	}
+	s.Screen.SetContent(x, y, primary, combining, style)
+}
+
+// needsLegacy reports whether a freshly initialized screen needs the legacy
+// profile: it cannot show box-drawing characters, or it is a Windows
+// console without VT processing, which tcell reports as 16 colors.
+func needsLegacy(s tcell.Screen) bool {
+	if !s.CanDisplay('─', false) {
+		return true
+	}
+	return runtime.GOOS == "windows" && s.Colors() <= 16
+}
+
+// basicPalette is the 16 colors every console has.
+var basicPalette = func() []tcell.Color {
+	p := make([]tcell.Color, 16)
+	for i := range p {
+		p[i] = tcell.PaletteColor(i)
+	}
+	return p
+}()
+
+// basicStyle maps style onto basicPalette, and dim text to gray.
+func basicStyle(style tcell.Style) tcell.Style {
+	fg, bg, attrs := style.Decompose()
+	if attrs&tcell.AttrDim != 0 {
+		style = style.Dim(false)
+		if fg == tcell.ColorDefault {
+			fg = tcell.ColorGray
+		}
+	}
+	return style.Foreground(basicColor(fg)).Background(basicColor(bg))
+}
+
+func basicColor(c tcell.Color) tcell.Color {
+	if !c.Valid() || (c >= tcell.ColorBlack && c <= tcell.ColorWhite) {
+		return c // default, reset, or already one of the 16
+	}
+	return tcell.FindColor(c, basicPalette)
+}
+
+// asciiRune is r as the old console can show it: box drawing and block
+// elements as ASCII, along with the few symbols the views draw frames and
+// markers with. Anything else is left alone.
+func asciiRune(r rune) rune {
+	switch {
+	case r < 0x80:
+		return r
+	case r >= 0x2500 && r <= 0x257f: // box drawing
+		switch r {
+		case '─', '━', '┄', '┅', '┈', '┉', '╌', '╍', '╴', '╶', '╸', '╺', '╼', '╾':
+			return '-'
+		case '═':
+			return '='
+		case '│', '┃', '┆', '┇', '┊', '┋', '╎', '╏', '║', '╵', '╷', '╹', '╻', '╽', '╿':
+			return '|'
+		case '╱':
+			return '/'
+		case '╲':
+			return '\\'
+		case '╳':
+			return 'X'
+		}
+		return '+'
+	case r >= 0x2580 && r <= 0x259f: // block elements
+		switch r {
+		case '░':
+			return '.'
+		case '▒':
+			return ':'
+		}
+		return '#'
+	}
+	switch r {
+	case '—', '–':
+		return '-'
+	case '…', '⋯':
+		return '.'
+	case '→', '▸':
+		return '>'
+	case '←':
+		return '<'
+	case '↑':
+		return '^'
+	case '↓':
+		return 'v'
+	case '●', '•', '◈', '★':
+		return '*'
+	case '○':
+		return 'o'
+	case '✓':
+		return '+'
+	case '✗':
+		return 'x'
+	case '⚠':
+		return '!'
+	}
+	return r
+}
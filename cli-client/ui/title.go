@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// ── Title and focus ───────────────────────────────────────────────────────────
+//
+// tcell sets the window title on xterm-like terminals but sends nothing
+// under tmux or screen, whose terminfo entries have no title capability. The
+// client's screen sets the pane title there itself (OSC 2, which tmux shows
+// in the outer terminal's title with "set-titles on") and the window name
+// (ESC k, honored with "allow-rename on"). On exit the name is cleared, so
+// tmux goes back to naming the window after the running command, and the
+// pane title goes back to the host name as tmux has it by default.
+//
+// Terminals that report focus (tmux needs "set -g focus-events on") tell the
+// screen when the window is left and entered again; a key press counts as
+// entering it too. Changes go to the handler set with SetFocusHandler.
+
+var (
+	focusMu      sync.Mutex
+	focusHandler func(focused bool)
+)
+
+// SetFocusHandler sets the function told when the terminal loses or gets
+// back focus. It runs on tview's event polling goroutine, so it must queue
+// any UI work. nil stops the reports.
+func SetFocusHandler(fn func(focused bool)) {
+	focusMu.Lock()
+	focusHandler = fn
+	focusMu.Unlock()
+}
+
+// inMultiplexer reports whether the client runs inside tmux or screen.
+func inMultiplexer() bool {
+	term := os.Getenv("TERM")
+	return os.Getenv("TMUX") != "" || strings.HasPrefix(term, "screen") || strings.HasPrefix(term, "tmux")
+}
+
+// SetTitle sets the window title, and under a multiplexer the window name.
+func (s *termScreen) SetTitle(title string) {
+	s.Screen.SetTitle(title)
+	if inMultiplexer() {
+		s.titled = true
+		title = strings.Map(titleRune, title)
+		fmt.Fprintf(os.Stdout, "\x1b]2;%s\x1b\\\x1bk%s\x1b\\", title, title)
+	}
+}
+
+// clearTitle hands the multiplexer's pane title and window name back after
+// exit.
+func (s *termScreen) clearTitle() {
+	if !s.titled {
+		return
+	}
+	s.titled = false
+	host, _ := os.Hostname()
+	fmt.Fprintf(os.Stdout, "\x1b]2;%s\x1b\\\x1bk\x1b\\", strings.Map(titleRune, host))
+}
+
+// titleRune drops control characters, which would end the sequence early.
+func titleRune(r rune) rune {
+	if r < 0x20 || r == 0x7f {
+		return -1
+	}
+	return r
+}
+
+// PollEvent passes events on to tview, noting focus changes on the way.
+func (s *termScreen) PollEvent() tcell.Event {
+	ev := s.Screen.PollEvent()
+	switch ev := ev.(type) {
+	case *tcell.EventFocus:
+		s.setFocused(ev.Focused)
+	case *tcell.EventKey:
+		s.setFocused(true)
+	}
+	return ev
+}
+
+// setFocused reports a change of focus. Only called from PollEvent.
+func (s *termScreen) setFocused(focused bool) {
+	if s.unfocused != focused {
+		return // no change
+	}
+	s.unfocused = !focused
+	focusMu.Lock()
+	fn := focusHandler
+	focusMu.Unlock()
+	if fn != nil {
+		fn(focused)
+	}
+}
@@ -0,0 +1,30 @@
+package views
+
+// ── Aliases ───────────────────────────────────────────────────────────────────
+//
+// Aliases from the config replace usernames in the transcript, in italics so
+// a local name can't pass for someone's real one. Only the display changes:
+// the username is still what is sent, mentioned and looked up.
+
+// SetAliases sets the names shown for users (username → alias) from now on;
+// lines already in the transcript keep theirs. Safe to call from any
+// goroutine.
+func (c *ChatView) SetAliases(aliases map[string]string) {
+	m := make(map[string]string, len(aliases))
+	for user, alias := range aliases {
+		m[user] = alias
+	}
+	c.aliases.Store(&m)
+}
+
+// nameLabel renders username as a message label: "[username]", or its
+// alias in italics, followed by its trust badge. Safe to call from any
+// goroutine.
+func (c *ChatView) nameLabel(username string) string {
+	if m := c.aliases.Load(); m != nil {
+		if alias, ok := (*m)[username]; ok {
+			return "[::i]" + Label(alias) + "[::-]" + c.trustBadge(username)
+		}
+	}
+	return Label(username) + c.trustBadge(username)
+}
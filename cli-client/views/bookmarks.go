@@ -0,0 +1,84 @@
+package views
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ── Bookmarks pane ─────────────────────────────────────────────────────────
+//
+// /bookmarks lists the saved messages in a pane above the input. Enter jumps
+// to the selected one, d deletes it and Esc closes the pane; the controller
+// decides what jumping means.
+
+// BookmarkItem is one row of the bookmarks pane, in plain text.
+type BookmarkItem struct {
+	Title  string // "alice · Oct 16 15:04"
+	Detail string // the snippet
+}
+
+// bookmarksHeight is the pane's height: border, four two-line rows, border.
+const bookmarksHeight = 10
+
+// ShowBookmarks opens the pane on items, or refreshes it if it is open, and
+// gives it focus. onOpen and onDelete get the index of the chosen item;
+// the pane closes itself before onOpen runs. The contacts pane and the
+// browse menu are closed.
+// Must be called from the tview event loop.
+func (c *ChatView) ShowBookmarks(items []BookmarkItem, onOpen, onDelete func(i int)) {
+	if c.bookmarks == nil {
+		c.bookmarks = tview.NewList()
+		c.bookmarks.SetBackgroundColor(tcell.ColorBlack)
+		c.bookmarks.SetBorder(true)
+		c.bookmarks.SetBorderColor(tcell.ColorDarkCyan)
+		c.bookmarks.SetTitle(" bookmarks · Enter jump · d delete · Esc close ")
+		c.bookmarks.SetMainTextColor(tcell.ColorWhite)
+		c.bookmarks.SetSecondaryTextColor(tcell.ColorGray)
+		c.bookmarks.SetSelectedBackgroundColor(tcell.ColorDarkCyan)
+	}
+	c.HideContacts()
+	c.HideMessageMenu()
+
+	selected := c.bookmarks.GetCurrentItem()
+	c.bookmarks.Clear()
+	for _, it := range items {
+		c.bookmarks.AddItem(Escape(it.Title), Escape(it.Detail), 0, nil)
+	}
+	if selected < len(items) {
+		c.bookmarks.SetCurrentItem(selected)
+	}
+	c.bookmarks.SetSelectedFunc(func(i int, _, _ string, _ rune) {
+		c.HideBookmarks()
+		onOpen(i)
+	})
+	c.bookmarks.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			c.HideBookmarks()
+			return nil
+		case event.Key() == tcell.KeyDelete, event.Key() == tcell.KeyRune && event.Rune() == 'd':
+			if c.bookmarks.GetItemCount() > 0 {
+				onDelete(c.bookmarks.GetCurrentItem())
+			}
+			return nil
+		}
+		return event
+	})
+
+	if !c.bookmarksVisible {
+		c.bookmarksVisible = true
+		c.layout()
+	}
+	c.app.SetFocus(c.bookmarks)
+}
+
+// HideBookmarks closes the pane and puts the cursor back in the input.
+// Must be called from the tview event loop.
+func (c *ChatView) HideBookmarks() {
+	if !c.bookmarksVisible {
+		return
+	}
+	c.bookmarksVisible = false
+	c.layout()
+	c.app.SetFocus(c.pasteField)
+}
@@ -0,0 +1,239 @@
+package views
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ── Browse mode ───────────────────────────────────────────────────────────────
+//
+// F3 starts browsing the transcript: ↑/↓ (and PgUp/PgDn, Home/End) move a
+// marker over the messages, scrolling to keep it on screen, and Enter opens
+// a menu of what can be done with the marked one — copy, reply, react, pin,
+// whois and ignore its sender, or list the repeats collapsed into it — and
+// show it in full if it was cut short (see fold.go). Esc or F3 again stops
+// browsing and goes back to the latest message. Only messages with an ID
+// take part; system lines are skipped. What each action means is up to the
+// controller.
+
+// MessageActions are the entries of the browse menu. Each gets the ID of
+// the marked message; the menu closes itself before they run.
+type MessageActions struct {
+	Copy   func(id string)        // c
+	Reply  func(id string)        // r
+	React  func(id, emoji string) // e, then one of Emoji
+	Pin    func(id string)        // p
+	Whois  func(id string)        // w
+	Ignore func(id string)        // i
+	Expand func(id string)        // x
+	Emoji  []string               // the reactions offered
+}
+
+// messageRegion matches the region tag that starts a message's line.
+var messageRegion = regexp.MustCompile(`\["([^"\[\]]+)"\]`)
+
+// SetMessageActions sets what the browse menu offers. Must be called from
+// the tview event loop.
+func (c *ChatView) SetMessageActions(actions MessageActions) {
+	c.actions = actions
+}
+
+// browseIDs lists the IDs of the messages in the transcript, oldest first.
+func (c *ChatView) browseIDs() []string {
+	var ids []string
+	for _, m := range messageRegion.FindAllStringSubmatch(c.committedText, -1) {
+		if m[1] != unreadRegion && !strings.HasPrefix(m[1], dayRegion) {
+			ids = append(ids, m[1])
+		}
+	}
+	return ids
+}
+
+// toggleBrowse handles F3.
+func (c *ChatView) toggleBrowse() {
+	if c.browseID != "" {
+		c.stopBrowse()
+		return
+	}
+	ids := c.browseIDs()
+	if len(ids) == 0 {
+		c.ShowNotice("Nothing to browse yet.", NoticeInfo)
+		return
+	}
+	c.browseID = ids[len(ids)-1]
+	c.renderMessages()
+}
+
+// stopBrowse drops the marker and follows new messages again.
+func (c *ChatView) stopBrowse() {
+	c.HideMessageMenu()
+	c.browseID = ""
+	c.renderMessages()
+	c.ScrollToLatest()
+}
+
+// browseKey handles a key in the input while browsing. Keys that do not
+// move the marker end browsing and go on to the input.
+func (c *ChatView) browseKey(event *tcell.EventKey) *tcell.EventKey {
+	ids := c.browseIDs()
+	at := -1
+	for i, id := range ids {
+		if id == c.browseID {
+			at = i
+		}
+	}
+	if at < 0 {
+		// Cleared or replaced under us.
+		c.stopBrowse()
+		return nil
+	}
+	_, height := c.window()
+	step := max(height/2, 1)
+	switch event.Key() {
+	case tcell.KeyUp:
+		at--
+	case tcell.KeyDown:
+		at++
+	case tcell.KeyPgUp:
+		at -= step
+	case tcell.KeyPgDn:
+		at += step
+	case tcell.KeyHome:
+		at = 0
+	case tcell.KeyEnd:
+		at = len(ids) - 1
+	case tcell.KeyEnter:
+		c.showMessageMenu(c.browseID)
+		return nil
+	case tcell.KeyEscape, tcell.KeyF3:
+		c.stopBrowse()
+		return nil
+	default:
+		c.stopBrowse()
+		return event
+	}
+	c.browseID = ids[min(max(at, 0), len(ids)-1)]
+	c.renderMessages()
+	return nil
+}
+
+// markBrowsed puts the marker on the browsed message's line in text.
+func (c *ChatView) markBrowsed(text string) string {
+	if c.browseID == "" {
+		return text
+	}
+	tag := `["` + c.browseID + `"]`
+	return strings.Replace(text, tag, tag+"[black:yellow]▶[-:-] ", 1)
+}
+
+// keepBrowsedInView scrolls the marked line onto the screen if it is not.
+func (c *ChatView) keepBrowsedInView() {
+	if c.browseID == "" || c.browseRow < 0 {
+		return
+	}
+	first, height := c.window()
+	if c.browseRow >= first && c.browseRow < first+height {
+		return
+	}
+	first = max(c.browseRow-height/2, 0)
+	if first >= c.rows-height {
+		c.ScrollToLatest()
+		return
+	}
+	c.scrolledBack, c.atTop = true, first == 0
+	c.messageView.ScrollTo(first, 0)
+	c.redrawFooter()
+	c.reportVisible()
+}
+
+// showMessageMenu opens the menu for the message id and gives it focus.
+func (c *ChatView) showMessageMenu(id string) {
+	if c.menu == nil {
+		c.menu = tview.NewList()
+		c.menu.SetBackgroundColor(tcell.ColorBlack)
+		c.menu.SetBorder(true)
+		c.menu.SetBorderColor(tcell.ColorDarkCyan)
+		c.menu.SetMainTextColor(tcell.ColorWhite)
+		c.menu.SetShortcutColor(tcell.ColorYellow)
+		c.menu.SetSelectedBackgroundColor(tcell.ColorDarkCyan)
+		c.menu.ShowSecondaryText(false)
+		c.menu.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+			if event.Key() == tcell.KeyEscape {
+				c.HideMessageMenu()
+				return nil
+			}
+			return event
+		})
+	}
+	c.HideBookmarks()
+	c.HideContacts()
+
+	a := c.actions
+	run := func(fn func(string)) func() {
+		return func() {
+			c.HideMessageMenu()
+			if fn != nil {
+				fn(id)
+			}
+		}
+	}
+	c.menu.Clear()
+	c.menu.SetTitle(" message · Enter choose · Esc back ")
+	if _, ok := c.folded[id]; ok {
+		c.menu.AddItem("Show more", "", 'm', func() {
+			c.HideMessageMenu()
+			c.unfold(id)
+		})
+	}
+	c.menu.AddItem("Copy text", "", 'c', run(a.Copy))
+	c.menu.AddItem("Reply", "", 'r', run(func(id string) {
+		c.stopBrowse()
+		if a.Reply != nil {
+			a.Reply(id)
+		}
+	}))
+	c.menu.AddItem("React…", "", 'e', func() { c.showReactions(id) })
+	c.menu.AddItem("Pin (bookmark)", "", 'p', run(a.Pin))
+	c.menu.AddItem("Whois sender", "", 'w', run(a.Whois))
+	c.menu.AddItem("Ignore sender", "", 'i', run(a.Ignore))
+	c.menu.AddItem("Expand repeats", "", 'x', run(a.Expand))
+	c.menu.SetCurrentItem(0)
+
+	if !c.menuVisible {
+		c.menuVisible = true
+		c.layout()
+	}
+	c.app.SetFocus(c.menu)
+}
+
+// showReactions turns the menu into a choice of reactions to id.
+func (c *ChatView) showReactions(id string) {
+	c.menu.Clear()
+	c.menu.SetTitle(" react · Enter choose · Esc back ")
+	for i, emoji := range c.actions.Emoji {
+		shortcut := rune(0)
+		if i < 9 {
+			shortcut = rune('1' + i)
+		}
+		c.menu.AddItem(emoji, "", shortcut, func() {
+			c.HideMessageMenu()
+			if c.actions.React != nil {
+				c.actions.React(id, emoji)
+			}
+		})
+	}
+}
+
+// HideMessageMenu closes the menu and puts the cursor back in the input,
+// still browsing. Must be called from the tview event loop.
+func (c *ChatView) HideMessageMenu() {
+	if !c.menuVisible {
+		return
+	}
+	c.menuVisible = false
+	c.layout()
+	c.app.SetFocus(c.pasteField)
+}
@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"regexp"
 	"strings"
 	"sync/atomic"
 	"time"
 
 	"cli-client/models"
+	"cli-client/tasks"
+	"cli-client/ui"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -21,22 +24,32 @@ var DebugLogFile *os.File
 
 type ChatView struct {
 	app           *tview.Application
-	container     *tview.Flex
+	container     *chatContainer
 	header        *tview.TextView
-	messageView   *tview.TextView
+	messageView   *messagePane
 	inputField    *tview.InputField
+	pasteField    *pasteField // inputField with large pastes intercepted
 	footer        *tview.TextView
 	commandBar    *tview.TextView
+	dashboard     *tview.TextView
+	notice        *tview.TextView
+	bookmarks     *tview.List // created on first /bookmarks
+	contacts      *tview.List // created on first /contacts
+	menu          *tview.List // created on first browse menu
 	onSendMessage func(string)
 	onCommand     func(string)
 
 	stopped  int32 // atomic: 1 = stopped
 	animMode int32 // atomic: 1 = word-by-word, 0 = static
 
+	aliases atomic.Pointer[map[string]string] // username → local name; see aliases.go
+	trust   atomic.Pointer[trustLevels]       // identity key trust; see trust.go
+
 	// Header state — only touched inside tview event loop
 	headerUsername string
 	headerLatency  int
 	headerOnline   bool
+	headerQuality  string // "good", "degraded", "poor"; "" = not assessed
 
 	// Server stats — updated by UpdateStats(), only in tview event loop
 	statsTotalMsgs  int
@@ -45,12 +58,94 @@ type ChatView struct {
 	statsMaxMsgs    int
 	statsMaxWaiters int
 	statsServerURL  string
-
-	// Nick mode / message history — only touched inside tview event loop
-	nickActive  bool
+	statsHidden     bool // the relay has no /api/stats; row 2 of the header is dropped
+	bellPending     bool // ring the terminal bell after the next draw
+
+	// Footer — only touched inside tview event loop
+	footerFormat   string            // config "footer"; "" = DefaultFooterFormat
+	footerSegments map[string]string // {name} → text contributed by controllers
+
+	// Dashboard pane — only touched inside tview event loop
+	dashboardVisible bool
+	dashSamples      []models.StatsSample
+
+	// Transcript layout — only touched inside tview event loop
+	hangingIndent bool         // wrapped lines start under the message body
+	compact       bool         // small terminal: one-line header, no command bar
+	hideDiffs     bool         // /diffs off: edited messages show only their new text
+	accent        tcell.Color  // border color, see SetAccent
+	unfocused     bool         // the other pane of a split has the focus; see split.go
+	highlights    *highlighter // keywords marked in others' messages; nil = none
+
+	// Scrolling and lazy bodies — only touched inside tview event loop.
+	// See message_pane.go and scrollback.go.
+	scrolledBack bool           // PgUp left the end; new lines don't pull it back
+	atTop        bool           // scrolled back all the way to the first row
+	unseen       int            // messages added below the screen while scrolled back
+	rows         int            // wrapped rows at the last render
+	pending      map[string]int // id → row of lines still waiting for a body
+	watched      map[string]int // id → row of lines to report once on screen
+	onVisible    func([]string) // told which pending and watched lines are on screen
+	onTop        func()         // PgUp at the top; nil = nothing older to load
+	scrollback   int            // most transcript lines kept in memory; 0 = all
+	archive      Archive        // where lines beyond scrollback go; nil = dropped
+
+	// Long messages — only touched inside tview event loop. See fold.go.
+	folded map[string]func() string // id → its line in full, for Show more
+	whole  map[string]bool          // ids shown in full, not to be cut again
+
+	// Day separators — only touched inside tview event loop. See days.go.
+	firstDay string // of the earliest message, as dayLayout; "" = none yet
+	lastDay  string // of the latest
+	labelDay string // today, when the separators were last named
+
+	// Bookmarks and contacts panes — only touched inside tview event loop
+	bookmarksVisible bool
+	contactsVisible  bool
+
+	// Notice line — only touched inside tview event loop
+	noticeVisible bool
+	noticeGen     int // bumped per notice; a stale dismiss timer bails out
+
+	// Sent-message history (↑/↓) — only touched inside tview event loop
 	sentHistory []string
 	historyIdx  int // -1 = not browsing
 
+	// Unsent chat text — only touched inside tview event loop. Survives
+	// clearing the field to type a /command, see trackDraft.
+	draft string
+
+	// Tab completion — only touched inside tview event loop. See complete.go.
+	completer   func(prefix string) []string
+	completions []string // candidates for the word being completed
+	completion  int      // index of the one shown
+	completed   string   // input text after the last Tab; anything else starts over
+	completeAt  string   // input text before the word being completed
+
+	// Input hints — only touched inside tview event loop. See hints.go.
+	hints []CommandHint
+	hint  string // command bar text for the command being typed; "" = the default
+	tip   int    // placeholderTips index
+	ask   string // placeholder asking for a value, instead of the tip; "" = none
+
+	// Message numbers — only touched inside tview event loop. See numbers.go.
+	numberCommands []string
+	numberIDs      func() []string
+	numbersPinned  bool // F2
+	numbersTyped   bool // a numbering command is in the input
+
+	// Browse mode — only touched inside tview event loop. See browse.go.
+	browseID    string // the marked message; "" = not browsing
+	browseRow   int    // its row at the last render; -1 = not shown
+	menuVisible bool
+	actions     MessageActions
+
+	// Compose preview — only touched inside tview event loop. See preview.go.
+	preview        *tview.TextView
+	previewVisible bool // F4
+	previewRows    int  // the strip's height
+	compose        func(text string) (*models.Message, string)
+
 	// ── Message render model ──────────────────────────────────────────────
 	// All fields below are ONLY ever read/written from inside QueueUpdateDraw
 	// (i.e. the tview event loop), so no mutex is needed.
@@ -84,22 +179,41 @@ func NewChatView(
 		headerLatency:   18,
 		headerOnline:    true,
 		inFlight:        make(map[int]string),
+		footerSegments:  make(map[string]string),
 		statsMaxMsgs:    1000,
 		statsMaxWaiters: 1000,
 		statsServerURL:  "localhost:8034",
+		hangingIndent:   true,
+		accent:          tcell.ColorDarkCyan,
+		pending:         make(map[string]int),
+		watched:         make(map[string]int),
+		folded:          make(map[string]func() string),
+		whole:           make(map[string]bool),
 	}
 	// Default to STATIC mode. Animation mode (word-by-word) involves a
 	// goroutine that reads from a channel while holding a QueueUpdateDraw
 	// slot — if that path is the crash source, static mode will stay stable.
 	// Users can switch with /mode animation once confirmed working.
 	atomic.StoreInt32(&c.animMode, 0)
+	// The bell needs the tcell screen, which tview only hands out while
+	// drawing — chain onto the after-draw hook rather than replace it.
+	prev := app.GetAfterDrawFunc()
+	app.SetAfterDrawFunc(func(screen tcell.Screen) {
+		if prev != nil {
+			prev(screen)
+		}
+		if c.bellPending {
+			c.bellPending = false
+			screen.Beep()
+		}
+	})
 	c.buildUI()
 	c.startClockTicker()
 	return c
 }
 
 func (c *ChatView) Primitive() tview.Primitive      { return c.container }
-func (c *ChatView) InputPrimitive() tview.Primitive { return c.inputField }
+func (c *ChatView) InputPrimitive() tview.Primitive { return c.pasteField }
 func (c *ChatView) GetPrimitive() tview.Primitive   { return c.container }
 
 // ── UI construction ────────────────────────────────────────────────────────
@@ -115,7 +229,7 @@ func (c *ChatView) buildUI() {
 	c.header.SetBorderColor(tcell.ColorDarkCyan)
 	c.header.SetBorderPadding(0, 0, 1, 1)
 
-	c.messageView = tview.NewTextView()
+	c.messageView = &messagePane{TextView: tview.NewTextView(), onResize: c.renderMessages}
 	c.messageView.SetDynamicColors(true)
 	c.messageView.SetScrollable(true)
 	c.messageView.SetWordWrap(true)
@@ -130,7 +244,7 @@ func (c *ChatView) buildUI() {
 
 	c.inputField = tview.NewInputField()
 	c.inputField.SetLabel("  > ")
-	c.inputField.SetPlaceholder("Type a message or /command...")
+	c.inputField.SetPlaceholder(placeholderTips[0])
 	c.inputField.SetFieldBackgroundColor(tcell.ColorBlack)
 	c.inputField.SetFieldTextColor(tcell.ColorWhite)
 	c.inputField.SetDoneFunc(func(key tcell.Key) {
@@ -138,35 +252,68 @@ func (c *ChatView) buildUI() {
 			text := c.inputField.GetText()
 			if text != "" {
 				if strings.HasPrefix(text, "/") {
+					c.numbersPinned = false
 					c.onCommand(text)
+					c.inputField.SetText(c.draft) // give back what was being composed
 				} else {
+					c.ScrollToLatest() // see what we just said
+					c.draft = ""
+					c.inputField.SetText("") // before, so onSendMessage may set a draft
 					c.onSendMessage(text)
 				}
-				c.inputField.SetText("")
 				c.historyIdx = -1
+				c.nextTip()
 			}
 		}
 	})
 
-	// ── Arrow-key capture for nick-mode history navigation ─────────────────
-	// When nick mode is OFF  → keys behave normally.
-	// When nick mode is ON:
-	//   ← (Left)  → go to previous (older) sent message.
-	//               Only activates when the field is empty OR already in history,
-	//               so normal left-cursor movement still works while typing fresh text.
-	//   → (Right) → go to next (newer) sent message / clears at the newest end.
+	c.inputField.SetChangedFunc(func(text string) {
+		c.trackDraft(text)
+		c.numbersForInput(text)
+		c.hintForInput(text)
+		c.updatePreview(text)
+	})
+	c.pasteField = &pasteField{InputField: c.inputField}
+
+	// ── Arrow-key capture for sent-message history ─────────────────────────
+	// The input is a single line, so Up/Down have no cursor meaning and are
+	// always available for history, shell-style:
+	//   ↑ (Up)   → go to previous (older) sent message.
+	//   ↓ (Down) → go to next (newer) sent message / clears at the newest end.
 	c.inputField.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if !c.nickActive {
-			return event
+		if c.browseID != "" {
+			if event = c.browseKey(event); event == nil {
+				return nil
+			}
 		}
-		fieldEmpty := c.inputField.GetText() == ""
-		inHistory := c.historyIdx >= 0
-
 		switch event.Key() {
-		case tcell.KeyLeft:
-			if !fieldEmpty && !inHistory {
-				return event // editing a fresh message — let cursor move
+		case tcell.KeyPgUp:
+			c.scrollPage(-1)
+			return nil
+		case tcell.KeyPgDn:
+			c.scrollPage(1)
+			return nil
+		case tcell.KeyEnd:
+			// Plain End as well while the field is empty, for terminals
+			// that send no Ctrl+End.
+			if event.Modifiers()&tcell.ModCtrl == 0 && c.inputField.GetText() != "" {
+				return event // the field's own End: cursor to the end
 			}
+			c.ScrollToLatest()
+			return nil
+		case tcell.KeyTab:
+			c.completeWord()
+			return nil
+		case tcell.KeyF2:
+			c.toggleNumbers()
+			return nil
+		case tcell.KeyF3:
+			c.toggleBrowse()
+			return nil
+		case tcell.KeyF4:
+			c.togglePreview()
+			return nil
+		case tcell.KeyUp:
 			if len(c.sentHistory) == 0 {
 				return nil
 			}
@@ -178,10 +325,7 @@ func (c *ChatView) buildUI() {
 			c.inputField.SetText(c.sentHistory[c.historyIdx])
 			return nil // consumed
 
-		case tcell.KeyRight:
-			if !fieldEmpty && !inHistory {
-				return event // editing a fresh message — let cursor move
-			}
+		case tcell.KeyDown:
 			if c.historyIdx < 0 {
 				return nil
 			}
@@ -204,138 +348,405 @@ func (c *ChatView) buildUI() {
 	// initial content drawn after stats fields are set
 	c.redrawFooter()
 
-	c.container = tview.NewFlex()
+	c.dashboard = tview.NewTextView()
+	c.dashboard.SetDynamicColors(true)
+	c.dashboard.SetBackgroundColor(tcell.ColorBlack)
+	c.dashboard.SetBorder(true)
+	c.dashboard.SetBorderColor(tcell.ColorDarkCyan)
+	c.dashboard.SetTitle(" server · last hour ")
+	c.dashboard.SetBorderPadding(0, 0, 1, 1)
+
+	c.notice = tview.NewTextView()
+	c.notice.SetDynamicColors(true)
+	c.notice.SetTextAlign(tview.AlignLeft)
+	c.notice.SetBackgroundColor(tcell.ColorBlack)
+
+	c.container = &chatContainer{Flex: tview.NewFlex(), onResize: c.fitHeight}
 	c.container.SetDirection(tview.FlexRow)
 	c.container.SetBackgroundColor(tcell.ColorBlack)
-	c.container.AddItem(c.header, 5, 0, false) // 5 = border top + 2 content lines + border bottom
-	c.container.AddItem(c.messageView, 0, 1, false)
-	c.container.AddItem(c.commandBar, 1, 0, false)
-	c.container.AddItem(c.inputField, 3, 0, true)
-	c.container.AddItem(c.footer, 1, 0, false)
+	c.layout()
 
 	c.redrawHeader()
 }
 
-// ── Message render engine ──────────────────────────────────────────────────
-
-// sanitizeContent escapes raw user-supplied text for safe rendering inside
-// a tview TextView with SetDynamicColors(true).
-//
-// tview treats anything matching `[word]` as a color/style tag. User messages
-// can contain arbitrary `[` characters (URLs, code snippets, IRC nicks like
-// `[nick]`). An unmatched or unrecognised `[` sequence causes tview to panic
-// with an index-out-of-bounds — a fatal error that recover() cannot catch.
-//
-// The fix: replace every `[` in user content with `[[]` (tview's own escape
-// for a literal `[`). We do NOT escape color tags we intentionally construct
-// in format strings — only raw content that came from outside the app.
-func sanitizeContent(s string) string {
-	return strings.ReplaceAll(s, "[", "[[]")
-}
-
-// safeColorTag validates that a color tag from external sources is well-formed
-// before inserting it raw into a tview format string.
-//
-// A valid tview color tag must:
-//   - Start with "["
-//   - End with "]"
-//   - Contain no nested "[" that would start a second tag
-//
-// Anything that doesn't satisfy these rules is replaced with "[white]" so we
-// never hand tview a malformed tag that would cause a fatal index panic.
-func safeColorTag(tag string) string {
-	if len(tag) < 3 {
-		return "[white]"
+// layout (re)builds the container rows. Optional panes such as the
+// dashboard are inserted in their fixed position, so toggling one never
+// reorders the rest. Must be called from the tview event loop once running.
+func (c *ChatView) layout() {
+	c.container.Clear()
+	switch {
+	case c.compact:
+		c.container.AddItem(c.header, 1, 0, false)
+	case c.statsHidden:
+		c.container.AddItem(c.header, 4, 0, false) // one line less: no stats row
+	default:
+		c.container.AddItem(c.header, 5, 0, false) // 5 = border top + 2 content lines + border bottom
 	}
-	if tag[0] != '[' || tag[len(tag)-1] != ']' {
-		return "[white]"
+	if c.dashboardVisible {
+		c.container.AddItem(c.dashboard, 5, 0, false) // border + 3 sparklines + border
+	}
+	c.container.AddItem(c.messageView, 0, 1, false)
+	if c.bookmarksVisible {
+		c.container.AddItem(c.bookmarks, bookmarksHeight, 0, false)
 	}
-	// Must not contain a second "[" inside (would nest tags)
-	inner := tag[1 : len(tag)-1]
-	if strings.ContainsAny(inner, "[]") {
-		return "[white]"
+	if c.contactsVisible {
+		c.container.AddItem(c.contacts, bookmarksHeight, 0, false)
 	}
-	return tag
+	if c.menuVisible {
+		c.container.AddItem(c.menu, c.menu.GetItemCount()+2, 0, false) // + border
+	}
+	if c.noticeVisible {
+		c.container.AddItem(c.notice, 1, 0, false)
+	}
+	if c.previewVisible && c.preview != nil {
+		c.container.AddItem(c.preview, c.previewRows, 0, false)
+	}
+	if !c.compact {
+		c.container.AddItem(c.commandBar, 1, 0, false)
+	}
+	c.container.AddItem(c.pasteField, 3, 0, true)
+	c.container.AddItem(c.footer, 1, 0, false)
 }
 
+// ── Message render engine ──────────────────────────────────────────────────
+
 // renderMessages rebuilds the messageView from the committed buffer plus all
 // active in-flight animation lines. Must always be called from the tview event loop.
 func (c *ChatView) renderMessages() {
 	log.Printf("TRACE renderMessages: committedLen=%d inFlightCount=%d nextAnimID=%d",
 		len(c.committedText), len(c.inFlight), c.nextAnimID)
+	c.trimScrollback()
 	text := c.committedText
 	for i := 0; i < c.nextAnimID; i++ {
 		if line, ok := c.inFlight[i]; ok {
 			text += line
 		}
 	}
-	log.Printf("TRACE renderMessages: total text len=%d calling SetText", len(text))
+	text = c.wrapText(c.markBrowsed(c.numberLines(text)), c.messageView.width)
+	log.Printf("TRACE renderMessages: total text len=%d width=%d calling SetText", len(text), c.messageView.width)
 	// Flush to disk BEFORE SetText — if tview crashes inside SetText (e.g. from
 	// a bad color tag sequence we missed), the log is already on disk.
 	if DebugLogFile != nil {
 		DebugLogFile.Sync()
 	}
 	c.messageView.SetText(text)
-	log.Printf("TRACE renderMessages: SetText done, calling ScrollToEnd")
-	c.messageView.ScrollToEnd()
+	if !c.scrolledBack {
+		log.Printf("TRACE renderMessages: SetText done, calling ScrollToEnd")
+		c.messageView.ScrollToEnd()
+	}
+	c.reportVisible()
+	c.keepBrowsedInView()
 	log.Printf("TRACE renderMessages: DONE")
 }
 
 // ── Message formatting ────────────────────────────────────────────────────
 
-// formatLine renders a Message into a tview-tagged string.
+// formatLine renders a Message into a tview-tagged string. Edited messages
+// mark what their latest edit changed unless diffs are off (see diff.go),
+// and others' messages get their keywords highlighted (see highlight.go).
 //
 // Output format:   [HH:MM] [username] message body
 //
 // Both the username label (in brackets) and the message content share the
 // same color so the entire line visually "belongs" to that user.
-// [[] is tview's escape sequence for a literal "[" character.
-func formatLine(msg *models.Message) string {
+func (c *ChatView) formatLine(msg *models.Message) string {
 	if msg.IsSystem {
 		// System messages are trusted internal strings — they may contain tview
 		// color markup like [cyan]name[-] intentionally. Do NOT sanitize them.
-		return fmt.Sprintf("[yellow]▸ %s[-]\n", msg.Content)
+		return fmt.Sprintf("[yellow]▸ %s%s[-]\n", bodyMark, msg.Content)
 	}
-	color := safeColorTag(msg.Color)
-	if color == "" {
-		color = "[white]"
+	if msg.Sticker != "" {
+		return formatSticker(msg, c.senderLabel(msg))
 	}
-	ts := msg.FormatTime()
-	safeUser := sanitizeContent(msg.Username) // escapes [ inside username
-	safeContent := sanitizeContent(msg.Content)
-	// [ts] and [username] are NOT valid tview color names so tview passes them
-	// through as literal bracket-wrapped text — no [[] escaping needed.
-	// [%s] for timestamp → passes through (digits+colon = never a color name)
-	// [[]%s] for username → [[] is tview escape for literal "[", so output is [username]
-	return fmt.Sprintf("[gray][%s][-] %s[[]%s][-] %s%s[-]\n",
-		ts, color, safeUser, color, safeContent)
+	if msg.Poll != nil {
+		return formatPoll(msg, c.senderLabel(msg))
+	}
+	color := ColorTag(msg.Color)
+	if msg.Impostor {
+		color = impostorColor
+	}
+	ts := Label(msg.FormatTime())
+	label := c.senderLabel(msg)
+	if msg.Expired {
+		return fmt.Sprintf("[gray]%s[-] %s%s[-] %s[dim]⌛ message expired[-]\n", ts, color, label, bodyMark)
+	}
+	if msg.Pending > 0 {
+		return fmt.Sprintf("[gray]%s[-] %s%s[-] %s[dim]⋯ %d bytes[-]\n", ts, color, label, bodyMark, msg.Pending)
+	}
+	content, hidden := msg.Content, 0
+	if !c.whole[msg.ID] && msg.Previous == "" {
+		content, hidden = fold(content)
+	}
+	safeContent := Escape(content)
+	if msg.Username != c.headerUsername {
+		safeContent = c.highlights.apply(content, color)
+	}
+	if block, ok := formatCodeBlock(content); ok {
+		safeContent = block
+	}
+	if msg.Previous != "" {
+		safeContent = editedBody(msg, !c.hideDiffs)
+	}
+	if hidden > 0 && msg.ID != "" {
+		safeContent += foldMark(hidden)
+		c.folded[msg.ID] = func() string { return c.format(msg) }
+	}
+	if !msg.ExpiresAt.IsZero() {
+		safeContent += "[-] [dim]⌛"
+	}
+	if msg.Failed != "" {
+		color = "[red]"
+		safeContent += "[-] [red::b]✗ not sent[-::-] [red](" + Escape(msg.Failed) + ") — /retry"
+	}
+	if len(msg.Reactions) > 0 {
+		safeContent += "[-] " + formatReactions(msg.Reactions)
+	}
+	return fmt.Sprintf("[gray]%s[-] %s%s[-] %s%s%s[-]\n",
+		ts, color, label, bodyMark, color, safeContent)
+}
+
+// formatReactions renders a message's reactions as "👍 2  🎉 1", dim.
+func formatReactions(reactions []models.Reaction) string {
+	parts := make([]string, len(reactions))
+	for i, r := range reactions {
+		parts[i] = fmt.Sprintf("%s %d", Escape(r.Emoji), len(r.Users))
+	}
+	return "[dim]" + strings.Join(parts, "  ")
+}
+
+// formatBanner renders an announcement: a highlighted title bar filled to
+// the pane's width, then the text in bold.
+func formatBanner(msg *models.Message) string {
+	title := Escape(fmt.Sprintf(" 📢 ANNOUNCEMENT · %s · %s ", msg.Username, msg.FormatTime()))
+	return fmt.Sprintf("[black:yellow:b]%s%s[-:-:-]\n[yellow::b]%s[-::-]\n", title, fillMark, Escape(msg.Content))
+}
+
+// format renders msg for the transcript.
+func (c *ChatView) format(msg *models.Message) string {
+	if !msg.Announcement {
+		return c.formatLine(msg)
+	}
+	return formatBanner(msg)
+}
+
+// formatCodeBlock renders content of the form "```\n…\n```" as indented
+// lines under the sender's name. ok is false for anything else.
+func formatCodeBlock(content string) (string, bool) {
+	if !strings.HasPrefix(content, "```\n") || !strings.HasSuffix(content, "\n```") || len(content) < 8 {
+		return "", false
+	}
+	lines := strings.Split(content[4:len(content)-4], "\n")
+	var b strings.Builder
+	b.WriteString("[dim]code[-]")
+	for _, line := range lines {
+		b.WriteString("\n  [gray]│[-] " + bodyMark + "[white]")
+		b.WriteString(Escape(strings.TrimRight(line, "\r")))
+		b.WriteString("[-]")
+	}
+	return b.String(), true
+}
+
+// incomingBody renders incoming content: a code block, or text with its
+// keywords highlighted.
+func (c *ChatView) incomingBody(content, colorTag string) string {
+	if block, ok := formatCodeBlock(content); ok {
+		return block
+	}
+	return c.highlights.apply(content, colorTag)
 }
 
 // incomingPrefix builds the formatted prefix for an incoming message line.
-//
-// We do NOT escape [ with [[] here. tview passes unrecognised tags (those
-// whose content is not a valid color name) through as literal text.
-// [10:48] and [username] are never valid tview colors, so they display as-is.
-// Real color directives like [red] and [-] work as normal.
-func incomingPrefix(colorTag, username string) string {
-	ts := time.Now().Format("15:04")
-	safeUser := sanitizeContent(username) // escapes any [ inside the username itself
-	return fmt.Sprintf("[gray][%s][-] %s[[]%s][-] %s",
-		ts, colorTag, safeUser, colorTag)
+// colorTag must already have been through ColorTag; label is the sender's,
+// from nameLabel.
+func incomingPrefix(at time.Time, colorTag, label string) string {
+	return fmt.Sprintf("[gray]%s[-] %s%s[-] %s%s",
+		Label(models.InZone(at).Format("15:04")), colorTag, label, bodyMark, colorTag)
 }
 
 // ── Public message API ────────────────────────────────────────────────────
 
-// AddMessage displays a message instantly (own messages, system messages).
+// AddMessage displays a message instantly (own messages, system messages,
+// announcements).
 // Must be called from the tview event loop.
 //
 // By appending to committedText (never to the raw messageView text), we
 // guarantee the message survives any concurrent animation redraws.
+//
+// Non-system lines are wrapped in a region tagged with msg.ID so
+// SetLineSuffix can annotate them later.
 func (c *ChatView) AddMessage(msg *models.Message) {
-	c.committedText += formatLine(msg)
+	c.commit(msg)
+	c.renderMessages()
+}
+
+// AddMessages is AddMessage for a batch, rendered once — a backlog that
+// arrives faster than it could be drawn line by line.
+// Must be called from the tview event loop.
+func (c *ChatView) AddMessages(msgs []*models.Message) {
+	for _, msg := range msgs {
+		c.commit(msg)
+	}
 	c.renderMessages()
 }
 
+// PrependMessages adds msgs, all older than anything shown, above the
+// transcript, and scrolls to the last page of them with the line that was
+// on top just below. Must be called from the tview event loop.
+func (c *ChatView) PrependMessages(msgs []*models.Message) {
+	if len(msgs) == 0 {
+		return
+	}
+	var b strings.Builder
+	days, after := c.olderDays(msgs)
+	for i, msg := range msgs {
+		line := c.format(msg)
+		if !msg.IsSystem && !msg.Announcement {
+			line = tagLine(msg.ID, line)
+		}
+		b.WriteString(days[i] + line)
+	}
+	c.prependText(b.String() + after)
+}
+
+// prependText puts text, formatted lines, above the transcript and scrolls
+// as PrependMessages does.
+func (c *ChatView) prependText(text string) {
+	before := c.rows
+	c.committedText = text + c.committedText
+	c.renderMessages()
+
+	_, height := c.window()
+	first := max(c.rows-before-height+1, 0)
+	if first >= c.rows-height {
+		c.ScrollToLatest() // it all fits
+		return
+	}
+	c.scrolledBack, c.atTop = true, first == 0
+	c.messageView.ScrollTo(first, 0)
+	c.redrawFooter()
+	c.reportVisible()
+}
+
+// commit appends msg to committedText without rendering.
+func (c *ChatView) commit(msg *models.Message) {
+	line := c.format(msg)
+	if !msg.IsSystem && !msg.Announcement {
+		line = tagLine(msg.ID, line)
+	}
+	if msg.Pending > 0 && msg.ID != "" {
+		c.pending[msg.ID] = -1 // placed by the next render
+	}
+	c.committedText += c.dayBreak(msg.Timestamp) + line
+	c.noteUnseen()
+}
+
+// tagLine wraps a formatted line in region tags for id, so SetLineSuffix,
+// UpdateMessage and AddNote can find it again. An empty id leaves it as is.
+func tagLine(id, line string) string {
+	if id == "" {
+		return line
+	}
+	return `["` + id + `"]` + strings.TrimSuffix(line, "\n") + `[""]` + "\n"
+}
+
+// noteMark starts every line added by AddNote, after its indent.
+const noteMark = "[dim]↳ " + bodyMark
+
+// AddNote adds text, dim and indented to the message body, on a line of its
+// own under the line tagged id — a translation, say. text is shown as
+// written, not as markup. It reports false if that line is not in the
+// transcript (cleared, or still being animated).
+// Must be called from the tview event loop.
+func (c *ChatView) AddNote(id, text string) bool {
+	start := strings.Index(c.committedText, `["`+id+`"]`)
+	if start < 0 {
+		return false
+	}
+	// A code block spans several lines; the note goes after the last.
+	stop := strings.Index(c.committedText[start:], `[""]`)
+	if stop < 0 {
+		return false
+	}
+	nl := strings.IndexByte(c.committedText[start+stop:], '\n')
+	if nl < 0 {
+		return false
+	}
+	end := start + stop + nl + 1
+	line := c.committedText[start:end]
+	// Earlier notes stay above this one.
+	for {
+		next := c.committedText[end:]
+		if !strings.HasPrefix(strings.TrimLeft(next, " "), noteMark) {
+			break
+		}
+		end += strings.IndexByte(next, '\n') + 1
+	}
+	if i := strings.IndexByte(line, '\n'); i < len(line)-1 {
+		line = line[:i] // the indent follows the first line
+	}
+	line = regionTag.ReplaceAllString(line, "")
+	indent := 0
+	if i := strings.Index(line, bodyMark); i >= 0 {
+		indent = tview.TaggedStringWidth(line[:i])
+	}
+	text = strings.Join(strings.Fields(text), " ") // one line, however long
+	note := strings.Repeat(" ", indent) + noteMark + Escape(text) + "[-]\n"
+	c.committedText = c.committedText[:end] + note + c.committedText[end:]
+	c.renderMessages()
+	return true
+}
+
+// SetLineSuffix replaces the trailing annotation (e.g. read receipts) on the
+// line added by AddMessage for message id. A no-op if the line was cleared.
+// Must be called from the tview event loop.
+func (c *ChatView) SetLineSuffix(id, suffix string) {
+	start := strings.Index(c.committedText, `["`+id+`"]`)
+	if start < 0 {
+		return
+	}
+	end := strings.Index(c.committedText[start:], `[""]`)
+	if end < 0 {
+		return
+	}
+	end += start + len(`[""]`)
+	nl := strings.IndexByte(c.committedText[end:], '\n')
+	if nl < 0 {
+		return
+	}
+	c.committedText = c.committedText[:end] + suffix + c.committedText[end+nl:]
+	c.renderMessages()
+}
+
+// UpdateMessage re-renders the lines added by AddMessage for msg, e.g. after
+// an ephemeral message expired, a poll got a vote or a message was edited.
+// A no-op if they were cleared. Must be called from the tview event loop.
+func (c *ChatView) UpdateMessage(msg *models.Message) {
+	if msg.Pending == 0 {
+		delete(c.pending, msg.ID)
+	}
+	line := `["` + msg.ID + `"]` + strings.TrimSuffix(c.format(msg), "\n") + `[""]`
+	if c.replaceLine(msg.ID, line) {
+		c.renderMessages()
+	}
+}
+
+// replaceLine puts line in place of the region tagged id, which may span
+// several lines, keeping any suffix after it. It reports false if there is
+// no such region.
+func (c *ChatView) replaceLine(id, line string) bool {
+	start := strings.Index(c.committedText, `["`+id+`"]`)
+	if start < 0 {
+		return false
+	}
+	stop := strings.Index(c.committedText[start:], `[""]`)
+	if stop < 0 {
+		return false
+	}
+	end := start + stop + len(`[""]`)
+	c.committedText = c.committedText[:start] + line + c.committedText[end:]
+	return true
+}
+
 // AddIncomingMessage displays a message from another user.
 //
 //	colorTag — tview color tag from the wire format, e.g. "[green]" or "[#ff00ff]".
@@ -348,7 +759,7 @@ func (c *ChatView) AddMessage(msg *models.Message) {
 // progress are appended to committedText and will NOT be lost.
 //
 // Safe to call from any goroutine.
-func (c *ChatView) AddIncomingMessage(username, content, colorTag string) {
+func (c *ChatView) AddIncomingMessage(id string, at time.Time, username, content, colorTag string) {
 	log.Printf("TRACE AddIncomingMessage: ENTER user=%q color=%q content=%.80q", username, colorTag, content)
 
 	if atomic.LoadInt32(&c.stopped) == 1 {
@@ -357,14 +768,14 @@ func (c *ChatView) AddIncomingMessage(username, content, colorTag string) {
 	}
 
 	// Normalise and validate color tag.
-	// safeColorTag MUST run last — it rejects any tag that would crash tview.
+	// ColorTag MUST run last — it rejects any tag that would crash tview.
 	if colorTag == "" {
 		colorTag = models.GetUsernameColor(username)
 	}
 	if !strings.HasPrefix(colorTag, "[") {
 		colorTag = models.ParseColorToTag(colorTag)
 	}
-	colorTag = safeColorTag(colorTag) // reject malformed tags from the server
+	colorTag = ColorTag(colorTag) // reject malformed tags from the server
 	log.Printf("TRACE AddIncomingMessage: normalised+validated colorTag=%q", colorTag)
 
 	words := strings.Fields(content)
@@ -373,27 +784,27 @@ func (c *ChatView) AddIncomingMessage(username, content, colorTag string) {
 		return
 	}
 
-	prefix := incomingPrefix(colorTag, username)
+	prefix := incomingPrefix(at, colorTag, c.nameLabel(username))
 	log.Printf("TRACE AddIncomingMessage: prefix built, animMode=%d", atomic.LoadInt32(&c.animMode))
 
 	// ── STATIC mode ────────────────────────────────────────────────────────
-	if atomic.LoadInt32(&c.animMode) == 0 {
+	// Code blocks are always static: animating word by word loses the lines.
+	// So are long messages, which would take minutes (see fold.go).
+	_, isCode := formatCodeBlock(content)
+	if atomic.LoadInt32(&c.animMode) == 0 || isCode || len(content) > animateChars {
 		log.Printf("TRACE AddIncomingMessage: static mode, queuing draw for user=%q", username)
-		c.app.QueueUpdateDraw(func() {
+		c.draw("incoming message", func() {
 			log.Printf("TRACE static draw: ENTER event loop for user=%q", username)
-			if atomic.LoadInt32(&c.stopped) == 1 {
-				log.Printf("TRACE static draw: stopped, bailing")
-				return
+			short, hidden := fold(content)
+			sanitized := c.incomingBody(short, colorTag)
+			if hidden > 0 && id != "" {
+				sanitized += foldMark(hidden)
+				c.folded[id] = func() string { return prefix + c.incomingBody(content, colorTag) + "[-]\n" }
 			}
-			defer func() {
-				if r := recover(); r != nil {
-					log.Printf("PANIC static draw (from %s): %v", username, r)
-				}
-			}()
-			sanitized := sanitizeContent(content)
 			log.Printf("TRACE static draw: sanitized content=%.80q", sanitized)
 			log.Printf("TRACE static draw: committedText len before=%d", len(c.committedText))
-			c.committedText += prefix + sanitized + "[-]\n" // prefix already ends with colorTag
+			c.committedText += c.dayBreak(at) + tagLine(id, prefix+sanitized+"[-]\n") // prefix already ends with colorTag
+			c.noteUnseen()
 			log.Printf("TRACE static draw: committedText len after=%d inFlight count=%d", len(c.committedText), len(c.inFlight))
 			log.Printf("TRACE static draw: calling renderMessages")
 			c.renderMessages()
@@ -412,25 +823,29 @@ func (c *ChatView) AddIncomingMessage(username, content, colorTag string) {
 	log.Printf("TRACE AddIncomingMessage: anim mode, allocating slot for user=%q", username)
 	type animSlot struct{ id, gen int }
 	slotCh := make(chan animSlot, 1)
-	c.app.QueueUpdateDraw(func() {
+	// Not c.draw: the animation goroutine waits for a slot even when the view
+	// has stopped, so this update always runs and answers.
+	ui.SafeQueueUpdateDraw(c.app, "animation start", func() {
 		log.Printf("TRACE anim-init: ENTER event loop for user=%q", username)
+		sent := false
 		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("PANIC anim-init (from %s): %v", username, r)
+			if !sent { // stopped, or renderMessages panicked
 				slotCh <- animSlot{-1, -1}
 			}
 		}()
 		if atomic.LoadInt32(&c.stopped) == 1 {
 			log.Printf("TRACE anim-init: stopped, sending -1 slot")
-			slotCh <- animSlot{-1, -1}
 			return
 		}
 		animID := c.nextAnimID
 		c.nextAnimID++
 		gen := c.inFlightGen
 		log.Printf("TRACE anim-init: allocated animID=%d gen=%d inFlight count=%d", animID, gen, len(c.inFlight))
+		c.committedText += c.dayBreak(at) // above whatever comes while it is animated
 		c.inFlight[animID] = prefix + "[dim]▋[-]"
+		c.noteUnseen()
 		slotCh <- animSlot{animID, gen}
+		sent = true
 		log.Printf("TRACE anim-init: calling renderMessages")
 		c.renderMessages()
 		log.Printf("TRACE anim-init: renderMessages returned, sent slot")
@@ -438,15 +853,14 @@ func (c *ChatView) AddIncomingMessage(username, content, colorTag string) {
 	log.Printf("TRACE AddIncomingMessage: anim init QueueUpdateDraw enqueued")
 
 	// Step 2 (goroutine): drip words one at a time, updating only our slot.
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("PANIC word-anim goroutine (from %s): %v", username, r)
-			}
-		}()
-
+	ui.SafeGo("animation", func() {
 		log.Printf("TRACE anim-goroutine: waiting for slot user=%q", username)
-		slot := <-slotCh
+		var slot animSlot
+		select {
+		case slot = <-slotCh:
+		case <-tasks.Stopping(): // the event loop is gone; the slot never comes
+			return
+		}
 		log.Printf("TRACE anim-goroutine: got slot id=%d gen=%d user=%q", slot.id, slot.gen, username)
 		if slot.id < 0 || atomic.LoadInt32(&c.stopped) == 1 {
 			log.Printf("TRACE anim-goroutine: aborting (id=%d stopped=%d)", slot.id, atomic.LoadInt32(&c.stopped))
@@ -457,7 +871,7 @@ func (c *ChatView) AddIncomingMessage(username, content, colorTag string) {
 
 		built := ""
 		for i, word := range words {
-			if atomic.LoadInt32(&c.stopped) == 1 {
+			if c.halted() {
 				return
 			}
 
@@ -477,27 +891,18 @@ func (c *ChatView) AddIncomingMessage(username, content, colorTag string) {
 			snapshot := built
 
 			wordIdx := i
-			c.app.QueueUpdateDraw(func() {
+			c.draw("animation word", func() {
 				log.Printf("TRACE word-tick: ENTER event loop animID=%d word[%d]=%q isLast=%v user=%q", animID, wordIdx, snapshot, isLast, username)
-				defer func() {
-					if r := recover(); r != nil {
-						log.Printf("PANIC word-anim draw (from %s): %v", username, r)
-					}
-				}()
-				if atomic.LoadInt32(&c.stopped) == 1 {
-					log.Printf("TRACE word-tick: stopped, bailing animID=%d", animID)
-					return
-				}
 				if c.inFlightGen != myGen {
 					log.Printf("TRACE word-tick: stale gen (mine=%d current=%d), bailing animID=%d", myGen, c.inFlightGen, animID)
 					return
 				}
-				sanitized := sanitizeContent(snapshot)
+				sanitized := c.highlights.apply(snapshot, colorTag)
 				log.Printf("TRACE word-tick: sanitized=%.60q committedLen=%d inFlightCount=%d", sanitized, len(c.committedText), len(c.inFlight))
 				if isLast {
 					log.Printf("TRACE word-tick: LAST WORD — committing animID=%d", animID)
 					delete(c.inFlight, animID)
-					c.committedText += prefix + sanitized + "[-]\n"
+					c.committedText += tagLine(id, prefix+sanitized+"[-]\n")
 					log.Printf("TRACE word-tick: committed, new committedLen=%d", len(c.committedText))
 				} else {
 					c.inFlight[animID] = prefix + sanitized + " [dim]▋[-]"
@@ -507,29 +912,40 @@ func (c *ChatView) AddIncomingMessage(username, content, colorTag string) {
 				log.Printf("TRACE word-tick: renderMessages returned animID=%d", animID)
 			})
 		}
-	}()
+	})
 }
 
 // SetMessages bulk-loads a slice of messages without animation.
 // Replaces committedText entirely and clears any in-flight animations.
 func (c *ChatView) SetMessages(messages []*models.Message) {
-	if atomic.LoadInt32(&c.stopped) == 1 {
-		return
-	}
-	c.app.QueueUpdateDraw(func() {
-		if atomic.LoadInt32(&c.stopped) == 1 {
-			return
-		}
+	c.draw("SetMessages", func() {
 		var b strings.Builder
+		c.firstDay, c.lastDay = "", ""
 		for _, msg := range messages {
-			b.WriteString(formatLine(msg))
+			b.WriteString(c.dayBreak(msg.Timestamp) + c.format(msg))
 		}
 		c.committedText = b.String()
 		c.inFlight = make(map[int]string) // discard any in-flight animations
+		c.pending = make(map[string]int)  // the lines are untagged
+		c.watched = make(map[string]int)
+		c.folded = make(map[string]func() string)
+		c.scrolledBack, c.atTop, c.unseen = false, false, 0
+		c.resetArchive()
+		c.redrawFooter()
 		c.renderMessages()
 	})
 }
 
+// TranscriptSize returns the bytes of transcript text held for redraws.
+// Must be called from the tview event loop.
+func (c *ChatView) TranscriptSize() int {
+	n := len(c.committedText)
+	for _, line := range c.inFlight {
+		n += len(line)
+	}
+	return n
+}
+
 // ClearMessages wipes the message area and all in-flight animation state.
 // Must be called from the tview event loop.
 //
@@ -540,37 +956,44 @@ func (c *ChatView) ClearMessages() {
 	c.committedText = ""
 	c.inFlight = make(map[int]string)
 	c.inFlightGen++ // invalidate all queued animation callbacks
+	c.pending = make(map[string]int)
+	c.watched = make(map[string]int)
+	c.folded = make(map[string]func() string)
+	c.firstDay, c.lastDay = "", ""
+	c.scrolledBack, c.atTop = false, false
+	c.unseen = 0
+	c.resetArchive()
+	c.redrawFooter()
 	c.renderMessages()
 }
 
 // ── Header ─────────────────────────────────────────────────────────────────
 
 func (c *ChatView) startClockTicker() {
-	go func() {
+	ui.SafeGo("clock", func() {
 		ticker := time.NewTicker(1 * time.Second)
 		defer ticker.Stop()
 		for range ticker.C {
-			if atomic.LoadInt32(&c.stopped) == 1 {
+			if c.halted() {
 				return
 			}
-			c.app.QueueUpdateDraw(func() {
-				if atomic.LoadInt32(&c.stopped) == 1 {
-					return
-				}
+			c.draw("clock", func() {
 				c.redrawHeader()
+				c.redrawFooter() // {clock}
+				c.relabelDays()
 			})
 		}
-	}()
+	})
 }
 
 // redrawHeader repaints the header content.
 //
-// Row 1:  [GLOBAL]  HH:MM:SS  @username    ●ONLINE/OFFLINE  LATENCY:Xms
+// Row 1:  [GLOBAL]  HH:MM:SS  @username    ●ONLINE/OFFLINE  LATENCY:Xms  link: good
 // Row 2:  msgs ▓▓▓▓▓░░░░░ 47/1000  │  ●●●○○ 3 active  │  0 waiting
 //
 // Must be called from within the tview event loop.
 func (c *ChatView) redrawHeader() {
-	clock := time.Now().Format("15:04:05")
+	clock := models.InZone(models.Now()).Format("15:04:05")
 
 	// ── Row 1 ────────────────────────────────────────────────────────────────
 	onlineStr := "[red]● OFFLINE[-]"
@@ -580,7 +1003,7 @@ func (c *ChatView) redrawHeader() {
 
 	userStr := ""
 	if c.headerUsername != "" {
-		userStr = fmt.Sprintf("  [yellow]@%s[-]", c.headerUsername)
+		userStr = fmt.Sprintf("  [yellow]@%s[-]", Escape(c.headerUsername))
 	}
 
 	latencyColor := "green"
@@ -595,8 +1018,26 @@ func (c *ChatView) redrawHeader() {
 		latencyStr = fmt.Sprintf("[dim]ping: [%s]%dms[-][-]", latencyColor, c.headerLatency)
 	}
 
-	row1 := fmt.Sprintf("[cyan]◈ GLOBAL[-]  [dim]%s[-]%s    %s   %s",
-		clock, userStr, onlineStr, latencyStr)
+	qualityStr := ""
+	switch c.headerQuality {
+	case "good":
+		qualityStr = "  [dim]link: [green]good[-][-]"
+	case "degraded":
+		qualityStr = "  [dim]link: [yellow]degraded[-][-]"
+	case "poor":
+		qualityStr = "  [dim]link: [red::b]poor[-::-][-]"
+	}
+
+	row1 := fmt.Sprintf("[cyan]◈ GLOBAL[-]  [dim]%s[-]%s    %s   %s%s",
+		clock, userStr, onlineStr, latencyStr, qualityStr)
+	if c.compact && !c.statsHidden {
+		c.header.SetText(fmt.Sprintf("%s   [dim]│ %d active[-]", row1, c.statsActive))
+		return
+	}
+	if c.compact || c.statsHidden {
+		c.header.SetText(row1)
+		return
+	}
 
 	// ── Row 2: live server stats ─────────────────────────────────────────────
 	// Active users: up to 5 colored dots, then "+N"
@@ -636,13 +1077,7 @@ func (c *ChatView) redrawHeader() {
 // UpdateStats refreshes the server stats displayed in the header and footer.
 // Safe to call from any goroutine.
 func (c *ChatView) UpdateStats(totalMsgs, active, waiting, maxMsgs, maxWaiters int, serverURL string) {
-	if atomic.LoadInt32(&c.stopped) == 1 {
-		return
-	}
-	c.app.QueueUpdateDraw(func() {
-		if atomic.LoadInt32(&c.stopped) == 1 {
-			return
-		}
+	c.draw("UpdateStats", func() {
 		c.statsTotalMsgs = totalMsgs
 		c.statsActive = active
 		c.statsWaiting = waiting
@@ -651,11 +1086,94 @@ func (c *ChatView) UpdateStats(totalMsgs, active, waiting, maxMsgs, maxWaiters i
 		if serverURL != "" {
 			c.statsServerURL = serverURL
 		}
+		c.showStats(true)
 		c.redrawHeader()
 		c.redrawFooter()
 	})
 }
 
+// StatsUnavailable collapses the header to its first row, leaving out the
+// zeros a relay without /api/stats would otherwise show.
+// Safe to call from any goroutine.
+func (c *ChatView) StatsUnavailable(serverURL string) {
+	c.draw("StatsUnavailable", func() {
+		if serverURL != "" {
+			c.statsServerURL = serverURL
+		}
+		c.showStats(false)
+		c.redrawHeader()
+		c.redrawFooter()
+	})
+}
+
+// showStats shows or hides the stats row, resizing the header to match.
+func (c *ChatView) showStats(show bool) {
+	if c.statsHidden == !show {
+		return
+	}
+	c.statsHidden = !show
+	c.layout()
+}
+
+// SetSegment sets the text a controller contributes to the footer as
+// {name}; "" hides it. text is trusted tview markup.
+// Must be called from the tview event loop.
+func (c *ChatView) SetSegment(name, text string) {
+	if text == "" {
+		delete(c.footerSegments, name)
+	} else {
+		c.footerSegments[name] = text
+	}
+	c.redrawFooter()
+}
+
+// SetFooterFormat replaces the footer template (see DefaultFooterFormat);
+// "" restores the default. Must be called from the tview event loop.
+func (c *ChatView) SetFooterFormat(format string) {
+	c.footerFormat = Sanitize(format)
+	c.redrawFooter()
+}
+
+// Bell rings the terminal bell on the next screen update.
+// Must be called from inside QueueUpdateDraw so that update happens.
+func (c *ChatView) Bell() {
+	c.bellPending = true
+}
+
+// SetPasteHandler sets the callback for text pasted into the input field.
+// It returns true if it took care of the paste; otherwise the text goes into
+// the field as one line. Must be called from the tview event loop.
+func (c *ChatView) SetPasteHandler(fn func(text string) bool) {
+	c.pasteField.onPaste = fn
+}
+
+// SetAccent recolors the header and dashboard borders: a color name or
+// "#rrggbb", "" = the default dark cyan. Must be called from the tview event loop.
+func (c *ChatView) SetAccent(color string) {
+	c.accent = tcell.ColorDarkCyan
+	if color != "" {
+		c.accent = tcell.GetColor(strings.ToLower(color))
+	}
+	c.paintBorders()
+}
+
+// setFocused dims the borders of a pane without the focus, so the focused
+// one of a split stands out.
+func (c *ChatView) setFocused(focused bool) {
+	c.unfocused = !focused
+	c.paintBorders()
+}
+
+// paintBorders colors the header and dashboard borders.
+func (c *ChatView) paintBorders() {
+	color := c.accent
+	if c.unfocused {
+		color = tcell.ColorDimGray
+	}
+	c.header.SetBorderColor(color)
+	c.dashboard.SetBorderColor(color)
+}
+
 // SetCurrentUser pushes the logged-in username to the header.
 // Must be called from the tview event loop.
 func (c *ChatView) SetCurrentUser(username string) {
@@ -680,13 +1198,7 @@ func (c *ChatView) SetOnlineStatus(online bool) {
 // SetOnlineStatusAsync updates the online indicator from any goroutine.
 // Use this ONLY when NOT already inside a QueueUpdateDraw callback.
 func (c *ChatView) SetOnlineStatusAsync(online bool) {
-	if atomic.LoadInt32(&c.stopped) == 1 {
-		return
-	}
-	c.app.QueueUpdateDraw(func() {
-		if atomic.LoadInt32(&c.stopped) == 1 {
-			return
-		}
+	c.draw("SetOnlineStatusAsync", func() {
 		c.headerOnline = online
 		c.redrawHeader()
 	})
@@ -695,15 +1207,62 @@ func (c *ChatView) SetOnlineStatusAsync(online bool) {
 // UpdateLatency updates the latency shown in the header.
 // Safe to call from any goroutine.
 func (c *ChatView) UpdateLatency(latency int) {
+	c.draw("UpdateLatency", func() {
+		c.headerLatency = latency
+		c.redrawHeader()
+	})
+}
+
+// SetConnectionQuality shows how the connection is doing in the header:
+// "good", "degraded" or "poor"; "" hides it. Must be called from the tview
+// event loop.
+func (c *ChatView) SetConnectionQuality(quality string) {
+	c.headerQuality = quality
+	c.redrawHeader()
+}
+
+// ── Notices ───────────────────────────────────────────────────────────────
+
+// Notice levels for ShowNotice.
+const (
+	NoticeInfo = iota
+	NoticeError
+)
+
+// How long a notice stays up. Errors linger so they can be read.
+const (
+	noticeInfoTTL  = 4 * time.Second
+	noticeErrorTTL = 8 * time.Second
+)
+
+// ShowNotice shows text in a one-line banner above the command bar and
+// dismisses it after a few seconds; a newer notice replaces it. text is
+// trusted tview markup. Must be called from the tview event loop.
+func (c *ChatView) ShowNotice(text string, level int) {
 	if atomic.LoadInt32(&c.stopped) == 1 {
 		return
 	}
-	c.app.QueueUpdateDraw(func() {
-		if atomic.LoadInt32(&c.stopped) == 1 {
-			return
-		}
-		c.headerLatency = latency
-		c.redrawHeader()
+	icon, color, ttl := "●", "[green]", noticeInfoTTL
+	if level == NoticeError {
+		icon, color, ttl = "✗", "[red]", noticeErrorTTL
+	}
+	c.notice.SetText(fmt.Sprintf("  %s%s[-] %s", color, icon, text))
+	if !c.noticeVisible {
+		c.noticeVisible = true
+		c.layout()
+	}
+
+	c.noticeGen++
+	gen := c.noticeGen
+	time.AfterFunc(ttl, func() {
+		c.draw("notice timeout", func() {
+			if gen != c.noticeGen {
+				return
+			}
+			c.noticeVisible = false
+			c.notice.SetText("")
+			c.layout()
+		})
 	})
 }
 
@@ -714,38 +1273,81 @@ func (c *ChatView) redrawCommandBar() {
 	if atomic.LoadInt32(&c.animMode) == 0 {
 		modeLabel = "[dim]mode:[cyan]STATIC[-]"
 	}
-	nickLabel := ""
-	if c.nickActive {
-		nickLabel = "  [cyan]nick:ON ←→[-]"
+	if c.hint != "" {
+		c.commandBar.SetText(" " + c.hint) // the command being typed, see hints.go
+	} else {
+		c.commandBar.SetText(fmt.Sprintf(
+			"[dim]/ commands: clear  whois  nick  mode  user_color  latency  info  exit  help   ↑↓ history[-]   %s",
+			modeLabel,
+		))
 	}
-	c.commandBar.SetText(fmt.Sprintf(
-		"[dim]/ commands: clear  whois  nick  mode  user_color  latency  info  exit  help[-]   %s%s",
-		modeLabel, nickLabel,
-	))
 	c.redrawFooter() // keep mode label in footer in sync
 }
 
-// redrawFooter repaints the bottom status bar with secondary server info.
+// DefaultFooterFormat is the footer template used when the config has none.
+// {name} is replaced by the segment of that name — see models.FooterSegments.
+// Badge segments ({reconnect}, {scroll}, {tor}, {dnd}, {quiet}, {scheduled},
+// {update}) carry their own leading space and are empty when inactive.
+const DefaultFooterFormat = "[dim]server:[cyan]{server}[-]{reconnect}{scroll}{tor}{dnd}{quiet}{hush}{away}{accounts}{scheduled}{update}{pow}{slow}  [dim]│  mode:{mode}[-]  [dim]│[-]  [magenta]SecTherminal v1.0[-]"
+
+var segmentPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// redrawFooter repaints the bottom status bar from the footer template.
 // Must be called from within the tview event loop.
 func (c *ChatView) redrawFooter() {
 	if c.footer == nil {
 		return // called before buildUI() finished initializing c.footer
 	}
 
-	modeLabel := "[cyan]ANIM[-]"
-	if atomic.LoadInt32(&c.animMode) == 0 {
-		modeLabel = "[green]STATIC[-]"
+	format := c.footerFormat
+	if format == "" {
+		format = DefaultFooterFormat
 	}
+	c.footer.SetText(segmentPattern.ReplaceAllStringFunc(format, func(m string) string {
+		return c.segment(m[1 : len(m)-1])
+	}))
+}
 
-	url := c.statsServerURL
-	if url == "" {
-		url = "localhost:8034"
+// segment returns the footer text for {name}: a built-in view value or one
+// contributed with SetSegment.
+func (c *ChatView) segment(name string) string {
+	switch name {
+	case "server":
+		if c.statsServerURL == "" {
+			return "localhost:8034"
+		}
+		return Escape(c.statsServerURL)
+	case "mode":
+		if atomic.LoadInt32(&c.animMode) == 0 {
+			return "[green]STATIC[-]"
+		}
+		return "[cyan]ANIM[-]"
+	case "clock":
+		return models.InZone(models.Now()).Format("15:04:05")
+	case "latency":
+		if c.headerLatency < 0 {
+			return "--ms"
+		}
+		return fmt.Sprintf("%dms", c.headerLatency)
+	case "user":
+		return Escape(c.headerUsername)
+	case "status":
+		if c.headerOnline {
+			return "[green]online[-]"
+		}
+		return "[red]offline[-]"
+	case "scroll":
+		switch {
+		case c.unseen > 0:
+			return fmt.Sprintf("  [yellow]↓ %d new · Ctrl+End[-]", c.unseen)
+		case c.scrolledBack && c.atTop && (c.onTop != nil || c.Archived() > 0):
+			return "  [dim]↑ top · PgUp loads older[-]"
+		case c.scrolledBack:
+			return "  [dim]↑ scrolled back[-]"
+		}
+		return ""
 	}
-
-	c.footer.SetText(fmt.Sprintf(
-		"[dim]server:[cyan]%s[-]  [dim]│  mode:%s[-]  [dim]│[-]  [magenta]SecTherminal v1.0[-]",
-		url, modeLabel,
-	))
+	return c.footerSegments[name]
 }
 
 // ── Animation mode ────────────────────────────────────────────────────────
@@ -774,15 +1376,53 @@ func (c *ChatView) IsAnimationMode() bool {
 	return atomic.LoadInt32(&c.animMode) == 1
 }
 
-// ── Nick mode ─────────────────────────────────────────────────────────────
+// SetHighlights sets the keywords to highlight in others' messages from
+// now on. Must be called from the tview event loop.
+func (c *ChatView) SetHighlights(rules []models.HighlightRule) {
+	c.highlights = newHighlighter(rules)
+}
 
-func (c *ChatView) ToggleNickMode() bool {
-	c.nickActive = !c.nickActive
-	c.historyIdx = -1
-	c.redrawCommandBar()
-	return c.nickActive
+// SetDiffs sets whether edited messages mark what changed. Lines already in
+// the transcript keep their look until UpdateMessage redraws them.
+// Must be called from the tview event loop.
+func (c *ChatView) SetDiffs(on bool) {
+	c.hideDiffs = !on
 }
 
+// ── Drafts ────────────────────────────────────────────────────────────────
+
+// trackDraft remembers unsent chat text as it is typed. Commands never
+// overwrite it, and neither does emptying the field (or backspacing it
+// toward empty) to make room for one — so "/server …" can stash the draft.
+func (c *ChatView) trackDraft(text string) {
+	if text == "" || strings.HasPrefix(text, "/") {
+		return
+	}
+	if len(text) < len(c.draft) && strings.HasPrefix(c.draft, text) {
+		return
+	}
+	c.draft = text
+}
+
+// Draft returns the unsent chat text: what is in the input, or the text
+// it held before a /command was started there.
+// Must be called from the tview event loop.
+func (c *ChatView) Draft() string {
+	if text := c.inputField.GetText(); text != "" && !strings.HasPrefix(text, "/") {
+		return text
+	}
+	return c.draft
+}
+
+// SetDraft replaces the unsent chat text and puts it in the input.
+// Must be called from the tview event loop.
+func (c *ChatView) SetDraft(text string) {
+	c.draft = text
+	c.inputField.SetText(text)
+}
+
+// ── Sent-message history ──────────────────────────────────────────────────
+
 func (c *ChatView) AddToHistory(msg string) {
 	if msg == "" {
 		return
@@ -799,20 +1439,50 @@ func (c *ChatView) AddToHistory(msg string) {
 // ── Footer ────────────────────────────────────────────────────────────────
 
 func (c *ChatView) UpdateCursorPosition(line, col int) {
-	if atomic.LoadInt32(&c.stopped) == 1 {
-		return
-	}
-	c.app.QueueUpdateDraw(func() {
-		if atomic.LoadInt32(&c.stopped) == 1 {
-			return
-		}
+	c.draw("UpdateCursorPosition", func() {
 		c.footer.SetText(fmt.Sprintf(
 			"[magenta]NORMAL[-]    SecTherminal              UTF-8    L:%d, C:%d", line, col,
 		))
 	})
 }
 
+// OnShow puts the cursor in the message input.
+func (c *ChatView) OnShow() {
+	c.app.SetFocus(c.pasteField)
+}
+
+func (c *ChatView) OnHide() {}
+
 // Stop signals this view is permanently done. No further UI updates will run.
 func (c *ChatView) Stop() {
 	atomic.StoreInt32(&c.stopped, 1)
 }
+
+// draw is ui.SafeQueueUpdateDraw for the view: f is dropped once the view
+// has stopped, whether before it was queued or while it waited. Never call
+// it from inside the tview event loop.
+func (c *ChatView) draw(name string, f func()) {
+	if atomic.LoadInt32(&c.stopped) == 1 {
+		return
+	}
+	ui.SafeQueueUpdateDraw(c.app, name, func() {
+		if atomic.LoadInt32(&c.stopped) == 1 {
+			return
+		}
+		f()
+	})
+}
+
+// halted reports whether background work for the view should end: the view
+// was stopped, or the client is shutting down. Safe to call from any goroutine.
+func (c *ChatView) halted() bool {
+	if atomic.LoadInt32(&c.stopped) == 1 {
+		return true
+	}
+	select {
+	case <-tasks.Stopping():
+		return true
+	default:
+		return false
+	}
+}
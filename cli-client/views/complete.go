@@ -0,0 +1,42 @@
+package views
+
+import "strings"
+
+// ── Tab completion ────────────────────────────────────────────────────────────
+//
+// Tab completes the last word of the input from the completer's candidates —
+// usernames, found by name or alias. Pressing it again cycles through the
+// other matches. An "@" in front of the word is kept.
+
+// SetCompleter sets the function that lists completions for a word prefix;
+// it runs on the tview event loop. Must be called from the tview event loop.
+func (c *ChatView) SetCompleter(fn func(prefix string) []string) {
+	c.completer = fn
+	c.completions = nil
+}
+
+// completeWord handles Tab in the input.
+func (c *ChatView) completeWord() {
+	text := c.inputField.GetText()
+	if len(c.completions) > 0 && text == c.completed {
+		c.completion = (c.completion + 1) % len(c.completions)
+	} else {
+		c.completions = nil
+		if c.completer == nil {
+			return
+		}
+		start := strings.LastIndexAny(text, " \t") + 1
+		word := text[start:]
+		prefix := strings.TrimPrefix(word, "@")
+		if prefix == "" {
+			return
+		}
+		if c.completions = c.completer(prefix); len(c.completions) == 0 {
+			return
+		}
+		c.completeAt = text[:start] + word[:len(word)-len(prefix)]
+		c.completion = 0
+	}
+	c.completed = c.completeAt + c.completions[c.completion] + " "
+	c.inputField.SetText(c.completed)
+}
@@ -0,0 +1,98 @@
+package views
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ── Contacts pane ─────────────────────────────────────────────────────────────
+//
+// /contacts lists the people seen so far in a pane above the input, like
+// /bookmarks. Enter starts a message to the selected one, f pins or unpins
+// them as a favorite, w asks /whois, d forgets them and Esc closes the pane;
+// the controller decides what each means.
+
+// ContactItem is one row of the contacts pane, in plain text.
+type ContactItem struct {
+	Title  string // "★ alice · online"
+	Detail string // "last seen 15:04 · 12 messages"
+}
+
+// ContactActions are the contacts pane's keys. Each gets the index of the
+// selected item; the pane closes itself before Mention and Whois run.
+type ContactActions struct {
+	Mention  func(i int) // Enter
+	Favorite func(i int) // f
+	Whois    func(i int) // w
+	Forget   func(i int) // d, Delete
+}
+
+// ShowContacts opens the pane on items, or refreshes it if it is open, and
+// gives it focus. The bookmarks pane and the browse menu are closed. Must
+// be called from the tview event loop.
+func (c *ChatView) ShowContacts(items []ContactItem, actions ContactActions) {
+	if c.contacts == nil {
+		c.contacts = tview.NewList()
+		c.contacts.SetBackgroundColor(tcell.ColorBlack)
+		c.contacts.SetBorder(true)
+		c.contacts.SetBorderColor(tcell.ColorDarkCyan)
+		c.contacts.SetTitle(" contacts · Enter message · f favorite · w whois · d forget · Esc close ")
+		c.contacts.SetMainTextColor(tcell.ColorWhite)
+		c.contacts.SetSecondaryTextColor(tcell.ColorGray)
+		c.contacts.SetSelectedBackgroundColor(tcell.ColorDarkCyan)
+	}
+	c.HideBookmarks()
+	c.HideMessageMenu()
+
+	selected := c.contacts.GetCurrentItem()
+	c.contacts.Clear()
+	for _, it := range items {
+		c.contacts.AddItem(Escape(it.Title), Escape(it.Detail), 0, nil)
+	}
+	if selected < len(items) {
+		c.contacts.SetCurrentItem(selected)
+	}
+	c.contacts.SetSelectedFunc(func(i int, _, _ string, _ rune) {
+		c.HideContacts()
+		actions.Mention(i)
+	})
+	c.contacts.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			c.HideContacts()
+			return nil
+		}
+		if c.contacts.GetItemCount() == 0 {
+			return event
+		}
+		i := c.contacts.GetCurrentItem()
+		switch {
+		case event.Key() == tcell.KeyDelete, event.Key() == tcell.KeyRune && event.Rune() == 'd':
+			actions.Forget(i)
+		case event.Key() == tcell.KeyRune && event.Rune() == 'f':
+			actions.Favorite(i)
+		case event.Key() == tcell.KeyRune && event.Rune() == 'w':
+			c.HideContacts()
+			actions.Whois(i)
+		default:
+			return event
+		}
+		return nil
+	})
+
+	if !c.contactsVisible {
+		c.contactsVisible = true
+		c.layout()
+	}
+	c.app.SetFocus(c.contacts)
+}
+
+// HideContacts closes the pane and puts the cursor back in the input.
+// Must be called from the tview event loop.
+func (c *ChatView) HideContacts() {
+	if !c.contactsVisible {
+		return
+	}
+	c.contactsVisible = false
+	c.layout()
+	c.app.SetFocus(c.pasteField)
+}
@@ -0,0 +1,111 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"cli-client/models"
+)
+
+// ── Dashboard pane ─────────────────────────────────────────────────────────
+//
+// /dashboard shows sparkline trends of the relay's /api/stats over the last
+// hour, one row per metric, between the header and the message area.
+
+var sparkRunes = []rune("▁▂▃▄▅▆▇█")
+
+// ToggleDashboard shows or hides the dashboard pane and returns the new state.
+// Must be called from the tview event loop.
+func (c *ChatView) ToggleDashboard() bool {
+	c.dashboardVisible = !c.dashboardVisible
+	c.layout()
+	return c.dashboardVisible
+}
+
+// UpdateDashboard replaces the plotted samples (oldest first).
+// Safe to call from any goroutine.
+func (c *ChatView) UpdateDashboard(samples []models.StatsSample) {
+	c.draw("UpdateDashboard", func() {
+		c.dashSamples = samples
+		c.redrawDashboard()
+	})
+}
+
+// redrawDashboard renders one sparkline per metric.
+// Must be called from within the tview event loop.
+func (c *ChatView) redrawDashboard() {
+	if !c.dashboardVisible {
+		return
+	}
+	if len(c.dashSamples) == 0 {
+		c.dashboard.SetText("[dim]waiting for the first /api/stats sample…[-]")
+		return
+	}
+
+	_, _, w, _ := c.dashboard.GetInnerRect()
+	width := w - 24 // label + current value
+	if width < 10 {
+		width = 10
+	}
+
+	msgs := make([]int, len(c.dashSamples))
+	active := make([]int, len(c.dashSamples))
+	waiting := make([]int, len(c.dashSamples))
+	for i, s := range c.dashSamples {
+		msgs[i] = s.TotalMessages
+		active[i] = s.Active
+		waiting[i] = s.Waiting
+	}
+
+	row := func(label, color string, values []int) string {
+		return fmt.Sprintf("[dim]%-8s[-] [%s]%s[-] [dim]now[-] %d",
+			label, color, sparkline(values, width), values[len(values)-1])
+	}
+	c.dashboard.SetText(strings.Join([]string{
+		row("msgs", "cyan", msgs),
+		row("active", "green", active),
+		row("waiting", "yellow", waiting),
+	}, "\n"))
+}
+
+// sparkline draws values as block characters, squeezed or padded to width.
+// When there are more values than columns, each column shows its bucket's
+// maximum so short spikes stay visible.
+func sparkline(values []int, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+	cols := values
+	if len(values) > width {
+		cols = make([]int, width)
+		for i := range cols {
+			lo := i * len(values) / width
+			hi := (i + 1) * len(values) / width
+			for _, v := range values[lo:hi] {
+				if v > cols[i] {
+					cols[i] = v
+				}
+			}
+		}
+	}
+
+	lo, hi := cols[0], cols[0]
+	for _, v := range cols {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+
+	var b strings.Builder
+	for _, v := range cols {
+		idx := 0
+		if hi > lo {
+			idx = (v - lo) * (len(sparkRunes) - 1) / (hi - lo)
+		}
+		b.WriteRune(sparkRunes[idx])
+	}
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package views
+
+import (
+	"strings"
+	"time"
+
+	"cli-client/models"
+)
+
+// ── Day separators ────────────────────────────────────────────────────────────
+//
+// Where the day changes from one message to the next, a dim line names the
+// new one — today, yesterday, a weekday of this week or the date — in the
+// locale set in models (see models/locale.go). The first message gets one
+// too unless it is from today. The clock ticker relabels them when the
+// date changes, so at midnight "Today" becomes "Yesterday".
+
+// dayRegion starts the region ID of a separator; its date follows.
+const dayRegion = "day:"
+
+// dayLayout is how days are keyed: sortable, in the display time zone.
+const dayLayout = "2006-01-02"
+
+// dayOf returns the day of t.
+func dayOf(t time.Time) string {
+	return models.InZone(t).Format(dayLayout)
+}
+
+// dayLine renders the separator for day.
+func dayLine(day string) string {
+	t, _ := time.ParseInLocation(dayLayout, day, models.InZone(time.Now()).Location())
+	label := models.DayLabel(t.Add(12*time.Hour), models.Now()) // noon, clear of DST shifts
+	return tagLine(dayRegion+day, "[dim]─── "+Escape(label)+" ───[-]\n")
+}
+
+// dayBreak returns the separator to put before a message from at that goes
+// below every other, and notes its day. Messages without a time, or older
+// than the latest, get none.
+func (c *ChatView) dayBreak(at time.Time) string {
+	if at.IsZero() {
+		return ""
+	}
+	day, prev := dayOf(at), c.lastDay
+	if day <= prev {
+		return ""
+	}
+	c.lastDay = day
+	if prev == "" {
+		c.firstDay = day
+		if day == dayOf(models.Now()) {
+			return ""
+		}
+	}
+	return dayLine(day)
+}
+
+// olderDays returns the separators for msgs, older messages going above
+// the transcript: one to put before each of them, and one to put after the
+// last, where the batch meets the transcript's first day. A separator for
+// that day already in the transcript is taken out if the batch ends on it.
+func (c *ChatView) olderDays(msgs []*models.Message) (before []string, after string) {
+	before = make([]string, len(msgs))
+	today := dayOf(models.Now())
+	first, last := "", ""
+	for i, msg := range msgs {
+		if msg.Timestamp.IsZero() {
+			continue
+		}
+		day := dayOf(msg.Timestamp)
+		if day == last {
+			continue
+		}
+		if last != "" || day != today {
+			before[i] = dayLine(day)
+		}
+		if first == "" {
+			first = day
+		}
+		last = day
+	}
+	switch {
+	case last == "":
+		return before, ""
+	case c.firstDay == "":
+		c.lastDay = last
+	case c.firstDay == last:
+		c.removeLine(dayRegion + last)
+	case !strings.Contains(c.committedText, `["`+dayRegion+c.firstDay+`"]`):
+		after = dayLine(c.firstDay)
+	}
+	c.firstDay = first
+	return before, after
+}
+
+// relabelDays names the separators afresh once the date has changed since
+// they were last named, and gives the first day one if it has become
+// yesterday. Must be called from the tview event loop.
+func (c *ChatView) relabelDays() {
+	today := dayOf(models.Now())
+	if today == c.labelDay {
+		return
+	}
+	c.labelDay = today
+	changed := false
+	for _, m := range messageRegion.FindAllStringSubmatch(c.committedText, -1) {
+		if day, ok := strings.CutPrefix(m[1], dayRegion); ok {
+			changed = c.replaceLine(m[1], strings.TrimSuffix(dayLine(day), "\n")) || changed
+		}
+	}
+	first := `["` + dayRegion + c.firstDay + `"]`
+	if c.firstDay != "" && c.firstDay != today && c.Archived() == 0 && !strings.Contains(c.committedText, first) {
+		c.committedText = dayLine(c.firstDay) + c.committedText
+		changed = true
+	}
+	if changed {
+		c.renderMessages()
+	}
+}
@@ -0,0 +1,76 @@
+package views
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"cli-client/models"
+)
+
+func TestDayBreak(t *testing.T) {
+	now := models.Now()
+	yesterday := now.AddDate(0, 0, -1)
+	c := &ChatView{}
+	if got := c.dayBreak(yesterday); !strings.Contains(got, "─── Yesterday ───") {
+		t.Errorf("first message from yesterday: separator %q", got)
+	}
+	if got := c.dayBreak(yesterday); got != "" {
+		t.Errorf("same day again: separator %q", got)
+	}
+	if got := c.dayBreak(now); !strings.Contains(got, "Today") {
+		t.Errorf("next day: separator %q", got)
+	}
+	if got := c.dayBreak(yesterday); got != "" {
+		t.Errorf("a late older message: separator %q", got)
+	}
+
+	c = &ChatView{}
+	if got := c.dayBreak(now); got != "" {
+		t.Errorf("first message from today: separator %q", got)
+	}
+}
+
+func TestOlderDays(t *testing.T) {
+	now := models.Now()
+	yesterday := now.AddDate(0, 0, -1)
+	c := &ChatView{}
+	c.committedText = c.dayBreak(yesterday) + "bob: late\n"
+
+	older := []*models.Message{{Timestamp: yesterday.Add(-time.Minute)}, {Timestamp: yesterday}}
+	if day := dayOf(older[0].Timestamp); day != dayOf(yesterday) {
+		t.Skip("too close to midnight")
+	}
+	before, after := c.olderDays(older)
+	if !strings.Contains(before[0], "Yesterday") || before[1] != "" || after != "" {
+		t.Errorf("olderDays = %q, %q", before, after)
+	}
+	if strings.Contains(c.committedText, dayRegion) {
+		t.Errorf("the transcript's own separator was kept: %q", c.committedText)
+	}
+}
+
+func TestDayLabelLocale(t *testing.T) {
+	defer models.SetLocale(nil)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local) // a Friday
+	models.SetLocale(models.ResolveLocale("de_DE.UTF-8", ""))
+	for at, want := range map[time.Time]string{
+		now.AddDate(0, 0, -1):  "Gestern",
+		now.AddDate(0, 0, -3):  "Dienstag",
+		now.AddDate(0, 0, -5):  "Sonntag, 11. Oktober",
+		now.AddDate(-1, 0, 0):  "Donnerstag, 16. Oktober 2025",
+		now.AddDate(0, -1, -1): "Dienstag, 15. September",
+	} {
+		if got := models.DayLabel(at, now); got != want {
+			t.Errorf("DayLabel(%s) = %q, want %q", at.Format("Mon Jan 2"), got, want)
+		}
+	}
+	// A week starting on Sunday takes in the Sunday before.
+	models.SetLocale(models.ResolveLocale("en_US", "sunday"))
+	if got := models.DayLabel(now.AddDate(0, 0, -5), now); got != "Sunday" {
+		t.Errorf("DayLabel(last Sunday) = %q, want Sunday", got)
+	}
+	if got := models.FormatDate(now, true); got != "Oct 16, 2026" {
+		t.Errorf("FormatDate = %q", got)
+	}
+}
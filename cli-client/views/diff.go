@@ -0,0 +1,131 @@
+package views
+
+import (
+	"strings"
+
+	"cli-client/models"
+)
+
+// ── Edit diffs ────────────────────────────────────────────────────────────────
+//
+// An edited message shows its new text with what the edit changed marked
+// inline, word by word: dropped words dim and struck through, added words in
+// reverse video. The marks are style-only tags, so the words keep the
+// sender's color. /diffs off shows the new text alone.
+
+const (
+	deletedMark = "[::ds]" // dim, struck through
+	addedMark   = "[::r]"  // reverse video
+	plainMark   = "[::-]"
+)
+
+// editedMark follows the body of an edited message.
+const editedMark = "[-] [dim](edited)"
+
+// maxDiffCells bounds the table diffWords fills; longer edits are shown as
+// the whole old text replaced by the whole new one.
+const maxDiffCells = 1 << 18
+
+type wordOp int
+
+const (
+	wordSame wordOp = iota
+	wordDeleted
+	wordAdded
+)
+
+type wordEdit struct {
+	op   wordOp
+	word string
+}
+
+// diffWords returns the edits turning a into b, by longest common
+// subsequence: unchanged words in order, with the deleted words of each
+// change before the added ones.
+func diffWords(a, b []string) []wordEdit {
+	if len(a)*len(b) > maxDiffCells {
+		edits := make([]wordEdit, 0, len(a)+len(b))
+		for _, w := range a {
+			edits = append(edits, wordEdit{wordDeleted, w})
+		}
+		for _, w := range b {
+			edits = append(edits, wordEdit{wordAdded, w})
+		}
+		return edits
+	}
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	w := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*w)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+			case lcs[(i+1)*w+j] >= lcs[i*w+j+1]:
+				lcs[i*w+j] = lcs[(i+1)*w+j]
+			default:
+				lcs[i*w+j] = lcs[i*w+j+1]
+			}
+		}
+	}
+	var edits []wordEdit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, wordEdit{wordSame, a[i]})
+			i, j = i+1, j+1
+		case j == len(b) || (i < len(a) && lcs[(i+1)*w+j] >= lcs[i*w+j+1]):
+			edits = append(edits, wordEdit{wordDeleted, a[i]})
+			i++
+		default:
+			edits = append(edits, wordEdit{wordAdded, b[j]})
+			j++
+		}
+	}
+	return edits
+}
+
+// formatDiff renders the change from before to after as escaped markup,
+// one space between words; runs of deleted or added words share one mark.
+func formatDiff(before, after string) string {
+	var b strings.Builder
+	last := wordSame
+	for i, e := range diffWords(strings.Fields(before), strings.Fields(after)) {
+		if e.op != last {
+			if last != wordSame {
+				b.WriteString(plainMark)
+			}
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			switch e.op {
+			case wordDeleted:
+				b.WriteString(deletedMark)
+			case wordAdded:
+				b.WriteString(addedMark)
+			}
+			last = e.op
+		} else if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(Escape(e.word))
+	}
+	if last != wordSame {
+		b.WriteString(plainMark)
+	}
+	return b.String()
+}
+
+// editedBody renders the body of an edited message: the diff, or with diffs
+// off or for code blocks (whose lines a word diff would lose), the new text.
+func editedBody(msg *models.Message, diffs bool) string {
+	if block, ok := formatCodeBlock(msg.Content); ok {
+		return block + editedMark
+	}
+	if _, ok := formatCodeBlock(msg.Previous); ok || !diffs {
+		return Escape(msg.Content) + editedMark
+	}
+	return formatDiff(msg.Previous, msg.Content) + editedMark
+}
@@ -0,0 +1,32 @@
+package views
+
+import "testing"
+
+func TestFormatDiff(t *testing.T) {
+	tests := []struct {
+		before, after, want string
+	}{
+		{"the relay is fast", "the relay is fast", "the relay is fast"},
+		{"teh relay is fast", "the relay is fast", "[::ds]teh[::-] [::r]the[::-] relay is fast"},
+		{"the relay is fast", "the relay is very fast today", "the relay is [::r]very[::-] fast [::r]today[::-]"},
+		{"a b c d", "a d", "a [::ds]b c[::-] d"},
+		{"see [red]", "see [blue]", "see [::ds][red[][::-] [::r][blue[][::-]"},
+	}
+	for _, tt := range tests {
+		if got := formatDiff(tt.before, tt.after); got != tt.want {
+			t.Errorf("formatDiff(%q, %q) = %q, want %q", tt.before, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestDiffWordsLongEditReplacesWhole(t *testing.T) {
+	a := make([]string, 600)
+	b := make([]string, 600)
+	for i := range a {
+		a[i], b[i] = "x", "x"
+	}
+	edits := diffWords(a, b)
+	if len(edits) != len(a)+len(b) || edits[0].op != wordDeleted || edits[len(edits)-1].op != wordAdded {
+		t.Fatalf("over maxDiffCells: got %d edits, want all %d deleted then added", len(edits), len(a)+len(b))
+	}
+}
@@ -0,0 +1,65 @@
+package views
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ErrorAction is one button on the error screen.
+type ErrorAction struct {
+	Label string
+	Run   func()
+}
+
+// ErrorView is the screen shown for conditions the app cannot carry on
+// from by itself — it states what went wrong and lets the user pick what
+// happens next instead of exiting on a timer.
+type ErrorView struct {
+	app     *tview.Application
+	modal   *tview.Modal
+	actions []ErrorAction
+}
+
+func NewErrorView(app *tview.Application) *ErrorView {
+	e := &ErrorView{app: app}
+	e.modal = tview.NewModal()
+	e.modal.SetBackgroundColor(tcell.ColorBlack)
+	e.modal.SetTextColor(tcell.ColorWhite)
+	e.modal.SetDoneFunc(e.done)
+	return e
+}
+
+// SetError replaces the message and buttons. title and detail are plain
+// text; they are escaped here. Must be called from the tview event loop.
+func (e *ErrorView) SetError(title, detail string, actions ...ErrorAction) {
+	e.modal.SetText(fmt.Sprintf("[red]✗  %s[-]\n\n%s", Escape(title), Escape(detail)))
+	e.actions = actions
+	labels := make([]string, len(actions))
+	for i, a := range actions {
+		labels[i] = a.Label
+	}
+	e.modal.ClearButtons()
+	e.modal.AddButtons(labels)
+	e.modal.SetFocus(0)
+}
+
+func (e *ErrorView) done(index int, _ string) {
+	if index < 0 || index >= len(e.actions) {
+		return // Escape — an action has to be chosen
+	}
+	if run := e.actions[index].Run; run != nil {
+		run()
+	}
+}
+
+func (e *ErrorView) Primitive() tview.Primitive { return e.modal }
+
+// OnShow takes focus so the buttons answer the keyboard.
+func (e *ErrorView) OnShow() {
+	e.app.SetFocus(e.modal)
+}
+
+func (e *ErrorView) OnHide() {}
+func (e *ErrorView) Stop()   {}
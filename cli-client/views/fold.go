@@ -0,0 +1,100 @@
+package views
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ── Long messages ─────────────────────────────────────────────────────────────
+//
+// A message over foldChars characters, or a code block over foldLines
+// lines, is cut short with "… show more (12,400 chars)" after it, so a
+// pasted log neither buries the conversation nor slows every redraw. "Show
+// more" in the browse menu (F3) shows it in full. Anything over
+// animateChars is shown at once, even in animation mode. Lines that
+// scroll out to the archive come back cut short.
+
+const (
+	foldChars    = 2000
+	foldLines    = 40
+	animateChars = 500
+)
+
+// fold returns content cut short if it is too long, and how many
+// characters were left out; 0 means it is whole. A code block stays one,
+// cut at a line end.
+func fold(content string) (string, int) {
+	body, code := content, false
+	if _, ok := formatCodeBlock(content); ok {
+		body, code = content[:len(content)-4], true // without the closing fence
+	}
+	cut := len(body)
+	if utf8.RuneCountInString(body) > foldChars {
+		cut = 0
+		for i := 0; i < foldChars; i++ {
+			_, size := utf8.DecodeRuneInString(body[cut:])
+			cut += size
+		}
+	}
+	if code {
+		if n := nthIndex(body[:cut], '\n', foldLines+1); n >= 0 {
+			cut = n
+		} else if nl := strings.LastIndexByte(body[:cut], '\n'); cut < len(body) && nl > 3 {
+			cut = nl // past the opening fence
+		}
+	} else if cut < len(body) {
+		// At a space if one is near, so no word is split.
+		if sp := strings.LastIndexByte(body[:cut], ' '); sp > cut-80 && sp > 0 {
+			cut = sp
+		}
+	}
+	if cut >= len(body) {
+		return content, 0
+	}
+	hidden := utf8.RuneCountInString(body[cut:])
+	if code {
+		return body[:cut] + "\n```", hidden
+	}
+	return body[:cut], hidden
+}
+
+// nthIndex returns the index of the nth b in s, or -1 if there are fewer.
+func nthIndex(s string, b byte, n int) int {
+	at := -1
+	for ; n > 0; n-- {
+		i := strings.IndexByte(s[at+1:], b)
+		if i < 0 {
+			return -1
+		}
+		at += i + 1
+	}
+	return at
+}
+
+// foldMark follows a message cut short by hidden characters.
+func foldMark(hidden int) string {
+	return fmt.Sprintf("[-] [dim]… show more (%s chars)", thousands(hidden))
+}
+
+// thousands formats n with commas between groups of three digits.
+func thousands(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// unfold shows the message id in full, if it was cut short.
+func (c *ChatView) unfold(id string) {
+	full, ok := c.folded[id]
+	if !ok {
+		return
+	}
+	delete(c.folded, id)
+	c.whole[id] = true
+	c.replaceLine(id, `["`+id+`"]`+strings.TrimSuffix(full(), "\n")+`[""]`)
+	c.renderMessages()
+}
@@ -0,0 +1,40 @@
+package views
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	short := strings.Repeat("word ", 10)
+	if got, hidden := fold(short); got != short || hidden != 0 {
+		t.Errorf("fold(short) = %q, %d; want it whole", got, hidden)
+	}
+
+	long := strings.Repeat("word ", 3000) // 15,000 chars
+	got, hidden := fold(long)
+	if len(got) > foldChars || strings.HasSuffix(got, "wo") {
+		t.Errorf("fold(long) kept %d chars ending %q", len(got), got[len(got)-10:])
+	}
+	if len(got)+hidden != len(long) {
+		t.Errorf("fold(long) = %d + %d hidden, want %d", len(got), hidden, len(long))
+	}
+
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	code := "```\n" + strings.Join(lines, "\n") + "\n```"
+	got, hidden = fold(code)
+	if _, ok := formatCodeBlock(got); !ok || strings.Count(got, "line") != foldLines || hidden == 0 {
+		t.Errorf("fold(code) = %d lines, %d hidden; want a code block of %d", strings.Count(got, "line"), hidden, foldLines)
+	}
+}
+
+func TestThousands(t *testing.T) {
+	for n, want := range map[int]string{0: "0", 999: "999", 1000: "1,000", 12400: "12,400", 1234567: "1,234,567"} {
+		if got := thousands(n); got != want {
+			t.Errorf("thousands(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// HelpEntry is one line of the help overlay: a command with its arguments,
+// or a key binding.
+type HelpEntry struct {
+	Category string
+	Usage    string // "/nick <name>", "F1"
+	Summary  string
+}
+
+// ChatKeybindings are the keys handled by the chat screen and the overlay.
+var ChatKeybindings = []HelpEntry{
+	{"Keys", "Enter", "Send the message, or run the /command"},
+	{"Keys", "↑ / ↓", "Browse sent messages"},
+	{"Keys", "Tab", "Complete a username, or find one by alias; again for the next match"},
+	{"Keys", "PgUp / PgDn", "Scroll the conversation; back at the end it follows new messages again"},
+	{"Keys", "Ctrl+End", "Jump to the latest message after scrolling back (End works too while the input is empty)"},
+	{"Keys", "F2", "Number the latest messages, for /bookmark n and /translate n (shown while typing those too)"},
+	{"Keys", "F3", "Browse messages with ↑/↓; Enter opens show more, copy, reply, react, pin, whois, ignore and expand repeats; Esc stops"},
+	{"Keys", "F4", "Preview the message being typed as it will be sent, above the input"},
+	{"Keys", "Alt+1 … Alt+9", "Switch to conversation N, as numbered by /rooms"},
+	{"Keys", "Alt+A", "Switch to the other conversation with the latest message"},
+	{"Keys", "Alt+S", "Switch to the next account, as listed by /account"},
+	{"Keys", "Ctrl+← / Ctrl+→", "Focus the left or right pane after /split, switching to its conversation"},
+	{"Keys", "F5", "Open, focus or close the rooms sidebar: unread counts, last activity; ↑/↓ and Enter to switch"},
+	{"Keys", "F1", "Open or close this help"},
+	{"Keys", "Esc", "Close this help"},
+	{"Keys", "PgUp / PgDn", "Scroll this help"},
+	{"Keys", "Ctrl+C", "Quit"},
+}
+
+// HelpView is the F1 overlay: every command and key binding grouped by
+// category, filtered live by the search field at its top.
+type HelpView struct {
+	app     *tview.Application
+	root    *tview.Flex // centers frame over whatever is behind it
+	search  *tview.InputField
+	list    *tview.TextView
+	entries []HelpEntry
+	onClose func()
+}
+
+func NewHelpView(app *tview.Application, entries []HelpEntry, onClose func()) *HelpView {
+	h := &HelpView{app: app, entries: entries, onClose: onClose}
+	h.buildUI()
+	return h
+}
+
+func (h *HelpView) Primitive() tview.Primitive      { return h.root }
+func (h *HelpView) InputPrimitive() tview.Primitive { return h.search }
+
+func (h *HelpView) buildUI() {
+	h.search = tview.NewInputField()
+	h.search.SetLabel(" search: ")
+	h.search.SetFieldBackgroundColor(tcell.ColorBlack)
+	h.search.SetFieldTextColor(tcell.ColorWhite)
+	h.search.SetChangedFunc(h.render)
+	h.search.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape, tcell.KeyF1:
+			h.onClose()
+			return nil
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			// Scroll the list while typing stays in the search field.
+			h.list.InputHandler()(event, nil)
+			return nil
+		}
+		return event
+	})
+
+	h.list = tview.NewTextView()
+	h.list.SetDynamicColors(true)
+	h.list.SetScrollable(true)
+	h.list.SetWrap(false)
+	h.list.SetBackgroundColor(tcell.ColorBlack)
+
+	frame := tview.NewFlex()
+	frame.SetDirection(tview.FlexRow)
+	frame.SetBorder(true)
+	frame.SetBorderColor(tcell.ColorDarkCyan)
+	frame.SetTitle(" Help · F1 / Esc to close ")
+	frame.SetBackgroundColor(tcell.ColorBlack)
+	frame.AddItem(h.search, 1, 0, true)
+	frame.AddItem(tview.NewBox().SetBackgroundColor(tcell.ColorBlack), 1, 0, false)
+	frame.AddItem(h.list, 0, 1, false)
+
+	// 80×24 box in the middle of the screen, shrinking with the terminal.
+	middle := tview.NewFlex()
+	middle.SetDirection(tview.FlexRow)
+	middle.AddItem(nil, 0, 1, false)
+	middle.AddItem(frame, 24, 0, true)
+	middle.AddItem(nil, 0, 1, false)
+	h.root = tview.NewFlex()
+	h.root.AddItem(nil, 0, 1, false)
+	h.root.AddItem(middle, 80, 0, true)
+	h.root.AddItem(nil, 0, 1, false)
+
+	h.render("")
+}
+
+// Reset clears the search so the overlay opens on the full list.
+// Must be called from the tview event loop.
+func (h *HelpView) Reset() {
+	h.search.SetText("") // re-renders through the changed func
+	h.list.ScrollToBeginning()
+}
+
+// helpUsageWidth is the usage column; longer usages put their summary on
+// the next line instead of pushing it off the edge.
+const helpUsageWidth = 28
+
+// render lists the entries matching query, grouped by category in the order
+// categories first appear.
+func (h *HelpView) render(query string) {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	var b strings.Builder
+	category := ""
+	for _, e := range h.entries {
+		if query != "" && !strings.Contains(strings.ToLower(e.Category+" "+e.Usage+" "+e.Summary), query) {
+			continue
+		}
+		if e.Category != category {
+			if category != "" {
+				b.WriteString("\n")
+			}
+			category = e.Category
+			fmt.Fprintf(&b, "[cyan]%s[-]\n", Escape(category))
+		}
+		sep := "  "
+		if n := len([]rune(e.Usage)); n <= helpUsageWidth {
+			sep += strings.Repeat(" ", helpUsageWidth-n)
+		} else {
+			sep = "\n" + strings.Repeat(" ", helpUsageWidth+4)
+		}
+		fmt.Fprintf(&b, "  [yellow]%s[-]%s%s\n", Escape(e.Usage), sep, Escape(e.Summary))
+	}
+	if category == "" {
+		b.WriteString("[dim]No command or key matches.[-]\n")
+	}
+	h.list.SetText(b.String())
+	h.list.ScrollToBeginning()
+}
@@ -0,0 +1,93 @@
+package views
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"cli-client/models"
+)
+
+// ── Keyword highlights ────────────────────────────────────────────────────────
+//
+// Words from the highlights config are shown bold in their own color in
+// other people's messages, matched case-insensitively and as whole words, so
+// "go" lights up in "go test" but not in "good". Highlighting works on the
+// raw text and escapes the pieces between matches itself.
+
+const defaultHighlightColor = "yellow"
+
+// highlighter marks the configured words in message text.
+type highlighter struct {
+	pattern *regexp.Regexp
+	tags    map[string]string // lower-cased word → its opening tag
+}
+
+// newHighlighter compiles rules; it returns nil for none.
+func newHighlighter(rules []models.HighlightRule) *highlighter {
+	h := &highlighter{tags: make(map[string]string)}
+	var alts []string
+	for _, r := range rules {
+		word := strings.ToLower(strings.Join(strings.Fields(r.Word), " "))
+		if word == "" {
+			continue
+		}
+		if _, dup := h.tags[word]; dup {
+			continue
+		}
+		color := r.Color
+		if color == "" {
+			color = defaultHighlightColor
+		}
+		// Colors are checked on the way in (Config.validate, /highlight).
+		h.tags[word] = "[" + models.ColorName(models.ParseColorToTag(color)) + "::b]"
+		alts = append(alts, strings.ReplaceAll(regexp.QuoteMeta(word), " ", `\s+`))
+	}
+	if len(alts) == 0 {
+		return nil
+	}
+	// Longest first: the leftmost alternative that matches wins.
+	sort.SliceStable(alts, func(i, j int) bool { return len(alts[i]) > len(alts[j]) })
+	h.pattern = regexp.MustCompile(`(?i)(?:` + strings.Join(alts, "|") + `)`)
+	return h
+}
+
+// apply returns text escaped, with each highlighted word wrapped in its tag
+// and followed by restore — the color tag of the surrounding text.
+func (h *highlighter) apply(text, restore string) string {
+	if h == nil {
+		return Escape(text)
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range h.pattern.FindAllStringIndex(text, -1) {
+		if !wordEdge(text, m[0], m[1]) {
+			continue
+		}
+		word := strings.ToLower(strings.Join(strings.Fields(text[m[0]:m[1]]), " "))
+		tag, ok := h.tags[word]
+		if !ok {
+			continue
+		}
+		b.WriteString(Escape(text[last:m[0]]))
+		b.WriteString(tag + Escape(text[m[0]:m[1]]) + restore + "[::-]")
+		last = m[1]
+	}
+	b.WriteString(Escape(text[last:]))
+	return b.String()
+}
+
+// wordEdge reports whether text[start:end] is neither preceded nor followed
+// by a letter, digit or underscore.
+func wordEdge(text string, start, end int) bool {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' }
+	if r, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWord(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWord(r) {
+		return false
+	}
+	return true
+}
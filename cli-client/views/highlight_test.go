@@ -0,0 +1,30 @@
+package views
+
+import (
+	"testing"
+
+	"cli-client/models"
+)
+
+func TestHighlighterApply(t *testing.T) {
+	h := newHighlighter([]models.HighlightRule{
+		{Word: "go"},
+		{Word: "night  shift", Color: "#ff0000"},
+		{Word: "[red]", Color: "cyan"},
+	})
+	tests := []struct{ in, want string }{
+		{"good morning", "good morning"},
+		{"Go test ./...", "[yellow::b]Go[green][::-] test ./..."},
+		{"on the Night shift?", "on the [#ff0000::b]Night shift[green][::-]?"},
+		{"say [red] twice", "say [cyan::b][red[][green][::-] twice"},
+		{"go_fmt", "go_fmt"},
+	}
+	for _, tt := range tests {
+		if got := h.apply(tt.in, "[green]"); got != tt.want {
+			t.Errorf("apply(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := newHighlighter(nil).apply("a [b]", "[green]"); got != Escape("a [b]") {
+		t.Errorf("no rules: got %q, want the text escaped", got)
+	}
+}
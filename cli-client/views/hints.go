@@ -0,0 +1,99 @@
+package views
+
+import "strings"
+
+// ── Input hints ───────────────────────────────────────────────────────────────
+//
+// The empty input's placeholder shows a different tip after every message
+// or command sent. While a command is typed, the command bar above the
+// input shows what it takes: the commands starting with what is typed so
+// far, then, once the name is complete, its usage and what it does.
+
+// placeholderTips are shown in turn in the empty input; the first is the
+// one a new session starts with.
+var placeholderTips = []string{
+	"Type a message or /command...",
+	"Tab completes @names, also by alias",
+	"↑ brings back what you sent",
+	"F1 lists every command and key",
+	"F3 browses messages — Enter to copy, reply or react",
+	"PgUp scrolls back, Ctrl+End returns",
+	"/help lists the commands",
+}
+
+// CommandHint is one command as the command bar describes it.
+type CommandHint struct {
+	Name    string // without the slash
+	Usage   string // "/vote <poll> <n|option>"
+	Summary string
+}
+
+// maxHintNames is how many matching commands the bar lists.
+const maxHintNames = 8
+
+// SetCommandHints sets the commands described while one is typed. Must be
+// called from the tview event loop.
+func (c *ChatView) SetCommandHints(hints []CommandHint) {
+	c.hints = hints
+}
+
+// nextTip moves the placeholder on to the next tip.
+func (c *ChatView) nextTip() {
+	c.tip = (c.tip + 1) % len(placeholderTips)
+	if c.ask == "" {
+		c.inputField.SetPlaceholder(placeholderTips[c.tip])
+	}
+}
+
+// AskInput shows question in the empty input instead of a tip, while the
+// controller takes what is sent next as the answer; "" goes back to the
+// tips. Must be called from the tview event loop.
+func (c *ChatView) AskInput(question string) {
+	c.ask = question
+	if question == "" {
+		question = placeholderTips[c.tip]
+	}
+	c.inputField.SetPlaceholder(question)
+}
+
+// hintForInput describes the command being typed in text, if any.
+func (c *ChatView) hintForInput(text string) {
+	hint := ""
+	if name, ok := strings.CutPrefix(text, "/"); ok && !strings.HasPrefix(name, " ") {
+		name, _, complete := strings.Cut(name, " ")
+		hint = c.commandHint(strings.ToLower(name), complete)
+	}
+	if hint != c.hint {
+		c.hint = hint
+		c.redrawCommandBar()
+	}
+}
+
+// commandHint is the bar's text for a command name typed so far, complete
+// once a space follows it.
+func (c *ChatView) commandHint(name string, complete bool) string {
+	var matches []CommandHint
+	for _, h := range c.hints {
+		if h.Name == name {
+			return "[yellow]" + Escape(h.Usage) + "[-]  [dim]" + Escape(h.Summary) + "[-]"
+		}
+		if !complete && strings.HasPrefix(h.Name, name) {
+			matches = append(matches, h)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "" // unknown, or an alias such as /anim
+	case 1:
+		return "[yellow]" + Escape(matches[0].Usage) + "[-]  [dim]" + Escape(matches[0].Summary) + "[-]"
+	}
+	names := make([]string, 0, maxHintNames+1)
+	for i, h := range matches {
+		if i == maxHintNames {
+			names = append(names, "…")
+			break
+		}
+		names = append(names, "/"+h.Name)
+	}
+	return "[dim]" + strings.Join(names, "  ") + "[-]"
+}
@@ -3,6 +3,8 @@ package views
 import (
 	"fmt"
 
+	"cli-client/ui"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -12,7 +14,6 @@ type LoadingView struct {
 	container    *tview.Flex
 	progressText *tview.TextView
 	statusText   *tview.TextView
-	errorText    *tview.TextView // shown only on fatal error
 	animFrame    int
 }
 
@@ -43,13 +44,6 @@ func (l *LoadingView) buildUI() {
 	l.statusText.SetTextAlign(tview.AlignCenter)
 	l.statusText.SetText("[dim]Initializing…[-]")
 
-	// errorText is invisible until ShowFatalError is called.
-	l.errorText = tview.NewTextView()
-	l.errorText.SetDynamicColors(true)
-	l.errorText.SetTextAlign(tview.AlignCenter)
-	l.errorText.SetText("")
-	l.errorText.SetBackgroundColor(tcell.ColorBlack)
-
 	l.container = tview.NewFlex()
 	l.container.SetDirection(tview.FlexRow)
 	l.container.SetBackgroundColor(tcell.ColorBlack)
@@ -57,17 +51,22 @@ func (l *LoadingView) buildUI() {
 	l.container.AddItem(tview.NewBox().SetBackgroundColor(tcell.ColorBlack), 1, 0, false)
 	l.container.AddItem(l.progressText, 1, 0, false)
 	l.container.AddItem(l.statusText, 1, 0, false)
-	l.container.AddItem(tview.NewBox().SetBackgroundColor(tcell.ColorBlack), 1, 0, false)
-	l.container.AddItem(l.errorText, 3, 0, false) // 3 lines: gap + error + countdown
+	l.container.AddItem(tview.NewBox().SetBackgroundColor(tcell.ColorBlack), 4, 0, false)
 }
 
 func (l *LoadingView) GetPrimitive() tview.Primitive {
 	return l.container
 }
 
-// UpdateProgress redraws the progress bar. Safe to call from any goroutine.
+func (l *LoadingView) Primitive() tview.Primitive { return l.container }
+func (l *LoadingView) OnShow()                    {}
+func (l *LoadingView) OnHide()                    {}
+func (l *LoadingView) Stop()                      {}
+
+// UpdateProgress redraws the progress bar. Safe to call from any goroutine
+// but the tview event loop's.
 func (l *LoadingView) UpdateProgress(progress int) {
-	l.app.QueueUpdateDraw(func() {
+	ui.SafeQueueUpdateDraw(l.app, "UpdateProgress", func() {
 		filled := progress / 5
 		empty := 20 - filled
 		bar := ""
@@ -82,59 +81,9 @@ func (l *LoadingView) UpdateProgress(progress int) {
 }
 
 // SetStatus updates the small status line under the progress bar.
-// Safe to call from any goroutine.
+// Safe to call from any goroutine but the tview event loop's.
 func (l *LoadingView) SetStatus(text string) {
-	l.app.QueueUpdateDraw(func() {
+	ui.SafeQueueUpdateDraw(l.app, "SetStatus", func() {
 		l.statusText.SetText(fmt.Sprintf("[dim]%s[-]", text))
 	})
 }
-
-// ShowFatalError replaces the status line with a red error banner.
-// Call SetCountdown immediately after to start the countdown ticker.
-// Must be called via QueueUpdateDraw (or from within the event loop).
-func (l *LoadingView) ShowFatalError(message string) {
-	// Freeze the progress bar in red to signal failure.
-	l.progressText.SetText("[red]████████████████████[-]  ERROR")
-	l.statusText.SetText("")
-	l.errorText.SetText(fmt.Sprintf(
-		"[red]✗  %s[-]",
-		message,
-	))
-}
-
-// SetCountdown updates the countdown line inside the error area.
-// Must be called via QueueUpdateDraw (or from within the event loop).
-func (l *LoadingView) SetCountdown(seconds int) {
-	current := l.errorText.GetText(false)
-	// Keep only the first line (the error message itself) and replace line 2.
-	lines := splitFirstLine(current)
-	dots := ""
-	for i := 0; i < seconds; i++ {
-		dots += "●"
-	}
-	for i := seconds; i < 4; i++ {
-		dots += "○"
-	}
-	l.errorText.SetText(fmt.Sprintf(
-		"%s\n[dim]Exiting in %d second%s…  %s[-]",
-		lines, seconds, pluralS(seconds), dots,
-	))
-}
-
-// ── helpers ───────────────────────────────────────────────────────────────────
-
-func splitFirstLine(s string) string {
-	for i, ch := range s {
-		if ch == '\n' {
-			return s[:i]
-		}
-	}
-	return s
-}
-
-func pluralS(n int) string {
-	if n == 1 {
-		return ""
-	}
-	return "s"
-}
@@ -5,6 +5,8 @@ import (
 	"strings"
 	"time"
 
+	"cli-client/ui"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -60,6 +62,15 @@ func NewLoginView(
 func (l *LoginView) Primitive() tview.Primitive    { return l.container }
 func (l *LoginView) GetPrimitive() tview.Primitive { return l.container }
 
+// OnShow starts the username prompt from the top and takes focus.
+func (l *LoginView) OnShow() {
+	l.StartUsernamePrompt()
+	l.app.SetFocus(l.container)
+}
+
+func (l *LoginView) OnHide() {}
+func (l *LoginView) Stop()   {}
+
 func (l *LoginView) buildUI() {
 	l.headerBox = tview.NewBox()
 	l.headerBox.SetBorder(true)
@@ -163,15 +174,15 @@ func (l *LoginView) parseColorInput(input string) *colorOption {
 
 // typewriterText displays text character by character for the terminal feel.
 func (l *LoginView) typewriterText(text string) {
-	go func() {
+	ui.SafeGo("typewriter", func() {
 		for _, char := range text {
-			l.app.QueueUpdateDraw(func() {
+			ui.SafeQueueUpdateDraw(l.app, "typewriter", func() {
 				current := l.textView.GetText(false)
 				l.textView.SetText(current + string(char))
 			})
 			time.Sleep(10 * time.Millisecond)
 		}
-	}()
+	})
 }
 
 func (l *LoginView) StartUsernamePrompt() {
@@ -0,0 +1,155 @@
+package views
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// ── Markup ────────────────────────────────────────────────────────────────────
+//
+// Every text view in the app has dynamic colors on, so any "[...]" handed to
+// one may be read as a style tag: "[alice]" vanishes, and malformed tags have
+// crashed tview outright. The rules:
+//
+//   - text from outside the app (usernames, content, URLs, errors) goes
+//     through Escape or Label;
+//   - color tags built from remote values go through ColorTag;
+//   - markup the user wrote themselves (the footer template) goes through
+//     Sanitize;
+//   - only strings literal in this codebase are passed through as they are.
+
+// Escape makes s display exactly as written. Unlike the old "[" → "[[]"
+// replacement it leaves nothing visible behind: every bracket run gets the
+// "[" before its "]" that tview reads as an escape ("[red]" → "[red[]").
+// tview.Escape does the same only for runs that look like color tags, which
+// misses "a [/b[]" and the like. Tags with a URL part ("[:::http://…]") parse
+// before any escape, so those also get an invisible word joiner after the
+// "[" that stops them parsing as a tag at all.
+func Escape(s string) string {
+	s = urlTag.ReplaceAllStringFunc(s, func(m string) string { return "[" + wordJoiner + m[1:] })
+	return bracketRun.ReplaceAllString(s, "$1[]")
+}
+
+// unescape reverses Escape.
+func unescape(s string) string {
+	s = escapedRun.ReplaceAllString(s, "$1]")
+	return strings.ReplaceAll(s, "["+wordJoiner, "[")
+}
+
+const wordJoiner = "\u2060"
+
+var (
+	bracketRun = regexp.MustCompile(`(\[[^\[\]]+\[*)\]`)        // tview's escapedTagPattern, less the final "["
+	escapedRun = regexp.MustCompile(`(\[[^\[\]]+\[*)\[\]`)      // its escaped form
+	urlTag     = regexp.MustCompile(`\[([^\[\]]*:){3}[^\]]*\]`) // a URL may hold "["
+)
+
+// Label renders name in literal brackets, "[alice]", for the transcript.
+func Label(name string) string { return Escape("[" + name + "]") }
+
+// ColorTag returns tag if it is a plain foreground color tag — "[cyan]",
+// "[#ff8800]" — and "[white]" otherwise, so a value from the wire can never
+// smuggle in another tag, a region or a style change.
+func ColorTag(tag string) string {
+	if len(tag) < 3 || tag[0] != '[' || tag[len(tag)-1] != ']' || !isColor(tag[1:len(tag)-1]) {
+		return "[white]"
+	}
+	return tag
+}
+
+// Colorize renders text literally in the color of tag, and resets it after.
+func Colorize(tag, text string) string { return ColorTag(tag) + Escape(text) + "[-]" }
+
+// Sanitize keeps the well-formed style tags in markup — "[red]", "[-]",
+// "[::b]", "[black:yellow:b]" — and escapes everything else, including
+// region and URL tags. Like tview it takes any name for a color, so "[dim]"
+// stays a tag; unknown names just mean the default color.
+func Sanitize(markup string) string {
+	var b strings.Builder
+	for _, t := range tokenize(markup) {
+		if t.tag {
+			b.WriteString(t.text)
+		} else {
+			b.WriteString(Escape(t.text))
+		}
+	}
+	return b.String()
+}
+
+// Strip removes the style tags from markup and undoes escaping, leaving the
+// text as it would be displayed — for logs.
+func Strip(markup string) string {
+	var b strings.Builder
+	for _, t := range tokenize(markup) {
+		if !t.tag {
+			b.WriteString(unescape(t.text))
+		}
+	}
+	return b.String()
+}
+
+// token is a run of markup: a style tag or the text between tags.
+type token struct {
+	text string
+	tag  bool
+}
+
+// tokenize splits markup into style tags and text. A bracket run counts as a
+// tag only if isStyleTag accepts it; anything else, escaped tags included,
+// stays text.
+func tokenize(s string) []token {
+	var out []token
+	text := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != '[' {
+			continue
+		}
+		end := strings.IndexAny(s[i+1:], "[]")
+		if end < 0 || s[i+1+end] != ']' || !isStyleTag(s[i+1:i+1+end]) {
+			continue
+		}
+		if text < i {
+			out = append(out, token{text: s[text:i]})
+		}
+		out = append(out, token{text: s[i : i+2+end], tag: true})
+		i += 1 + end
+		text = i + 1
+	}
+	if text < len(s) {
+		out = append(out, token{text: s[text:]})
+	}
+	return out
+}
+
+var (
+	hexColor  = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+	tagColor  = regexp.MustCompile(`^([a-zA-Z]+|#[0-9a-fA-F]{6})$`)
+	attrFlags = regexp.MustCompile(`^(-|[buildsrBUILDSR]+)$`)
+)
+
+// isStyleTag reports whether body, the text between the brackets, is a
+// "fg:bg:attrs" style tag with every part empty, "-" or valid.
+func isStyleTag(body string) bool {
+	parts := strings.Split(body, ":")
+	if body == "" || len(parts) > 3 {
+		return false
+	}
+	for i, p := range parts {
+		switch {
+		case p == "" || p == "-":
+		case i < 2 && tagColor.MatchString(p):
+		case i == 2 && attrFlags.MatchString(p):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isColor reports whether name is a color tcell knows or #rrggbb.
+func isColor(name string) bool {
+	_, known := tcell.ColorNames[strings.ToLower(name)]
+	return known || hexColor.MatchString(name)
+}
@@ -0,0 +1,106 @@
+package views
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rivo/tview"
+)
+
+func TestEscape(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"plain", "plain"},
+		{"[red]", "[red[]"},
+		{"[alice] hi", "[alice[] hi"},
+		{"a [/b[]", "a [/b[[]"},
+		{"[:::http://x]", "[" + wordJoiner + ":::http://x[]"},
+		{"[[red]]", "[[red[]]"},
+		{"[a[b]c]", "[a[b[]c]"},
+		{"[red][::b]x[-]", "[red[][::b[]x[-[]"},
+		{"[]", "[]"},
+		{"]", "]"},
+	} {
+		got := Escape(tc.in)
+		if got != tc.want {
+			t.Errorf("Escape(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+		if back := unescape(got); back != tc.in {
+			t.Errorf("unescape(Escape(%q)) = %q", tc.in, back)
+		}
+		if w := tview.TaggedStringWidth(got); w != len(tc.in) {
+			t.Errorf("Escape(%q) displays %d wide, want %d", tc.in, w, len(tc.in))
+		}
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"[red]hi[-]", "[red]hi[-]"},
+		{"[::b]bold[::-]", "[::b]bold[::-]"},
+		{"[black:yellow:b]x", "[black:yellow:b]x"},
+		{"[#ff8800]x[dim]y", "[#ff8800]x[dim]y"},
+		{`["r"]x[""]`, `["r"[]x[""[]`},
+		{"[:::http://x]link", "[" + wordJoiner + ":::http://x[]link"},
+		{"[red]a [b c]", "[red]a [b c[]"},
+		{"[red:blue:b:x]", "[" + wordJoiner + "red:blue:b:x[]"}, // read as a URL tag
+		{"[::q]", "[::q[]"},
+		{"[red", "[red"},
+	} {
+		if got := Sanitize(tc.in); got != tc.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestStrip(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"[red]hi[-]", "hi"},
+		{"[dim]" + Escape("[alice]") + " [::b]x", "[alice] x"},
+		{Label("bob") + ": hi", "[bob]: hi"},
+		{Escape("[:::http://x]"), "[:::http://x]"},
+	} {
+		if got := Strip(tc.in); got != tc.want {
+			t.Errorf("Strip(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestColorTag(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"[aqua]", "[aqua]"},
+		{"[RED]", "[RED]"},
+		{"[#ff8800]", "[#ff8800]"},
+		{"[#ff88]", "[white]"},
+		{"[red:blue]", "[white]"},
+		{"[red][::b]", "[white]"},
+		{`["region"]`, "[white]"},
+		{"[::b]", "[white]"},
+		{"[:::http://x]", "[white]"},
+		{"[-]", "[white]"},
+		{"[nocolor]", "[white]"},
+		{"cyan", "[white]"},
+		{"[]", "[white]"},
+		{"", "[white]"},
+	} {
+		if got := ColorTag(tc.in); got != tc.want {
+			t.Errorf("ColorTag(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+// longContent is a message of about 100 kB, mixing text with brackets.
+var longContent = strings.Repeat("see [red] and a [/b[] at [:::http://x] or [::b]plain[-] words ", 1600)
+
+func BenchmarkEscape(b *testing.B) {
+	b.SetBytes(int64(len(longContent)))
+	for i := 0; i < b.N; i++ {
+		Escape(longContent)
+	}
+}
+
+func BenchmarkSanitize(b *testing.B) {
+	b.SetBytes(int64(len(longContent)))
+	for i := 0; i < b.N; i++ {
+		Sanitize(longContent)
+	}
+}
@@ -0,0 +1,316 @@
+package views
+
+import (
+	"regexp"
+	"strings"
+
+	"cli-client/models"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ── Message pane layout ────────────────────────────────────────────────────
+//
+// The transcript is wrapped here rather than by the TextView, so continuation
+// lines can hang under the message body instead of starting at the left edge.
+// Formatters mark where the body starts with bodyMark; renderMessages wraps
+// every line to the pane's current width, and messagePane re-renders when
+// that width changes, so a resize re-wraps everything already shown.
+
+const (
+	// bodyMark marks where a line's body starts; continuation lines are
+	// indented to it. Only the first one on a line counts.
+	bodyMark = "\x00"
+	// fillMark is replaced by enough spaces to fill the line to the pane's
+	// width, for lines drawn as bars (announcement titles).
+	fillMark = "\x01"
+	// minBodyWidth is the narrowest body worth a hanging indent; on a pane
+	// narrower than indent+minBodyWidth wrapped lines start at the edge.
+	minBodyWidth = 16
+)
+
+// regionTag matches the ["id"] and [""] tags that mark own lines in
+// committedText. They are only bookkeeping and are dropped before display.
+var regionTag = regexp.MustCompile(`\["[^"\[\]]*"\]`)
+
+// messagePane is the transcript TextView, held to a maximum width and
+// centered when the terminal is wider, and told when its width changes.
+type messagePane struct {
+	*tview.TextView
+	maxWidth int    // 0 = use the full width
+	width    int    // inner width at the last draw
+	onResize func() // called from Draw when the inner width changed
+}
+
+// Draw implements tview.Primitive.
+func (p *messagePane) Draw(screen tcell.Screen) {
+	x, y, w, h := p.GetRect()
+	if p.maxWidth > 0 && w > p.maxWidth {
+		p.SetRect(x+(w-p.maxWidth)/2, y, p.maxWidth, h)
+	}
+	if _, _, inner, _ := p.GetInnerRect(); inner != p.width {
+		p.width = inner
+		if p.onResize != nil {
+			p.onResize()
+		}
+	}
+	p.TextView.Draw(screen)
+}
+
+// SetLayout sets the transcript's maximum width (0 = full width), centering
+// it on wider terminals, and whether wrapped lines hang under the message
+// body. Must be called from the tview event loop.
+func (c *ChatView) SetLayout(maxWidth int, hangingIndent bool) {
+	c.messageView.maxWidth = maxWidth
+	c.hangingIndent = hangingIndent
+	c.renderMessages()
+}
+
+// MessageWidth returns the transcript's width in columns at the last draw,
+// or 0 before the first. Must be called from the tview event loop.
+func (c *ChatView) MessageWidth() int {
+	return c.messageView.width
+}
+
+// wrapText lays text out for a pane width columns wide. Before the first
+// draw (width 0) it only removes the marks and leaves wrapping to tview.
+// It also notes the row of every line in c.pending and c.watched, of the
+// browsed message, and the row count.
+func (c *ChatView) wrapText(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	row := 0
+	c.browseRow = -1
+	for i, line := range lines {
+		if len(c.pending) > 0 || len(c.watched) > 0 || c.browseID != "" {
+			if tag := regionTag.FindStringIndex(line); tag != nil && tag[0] == 0 {
+				id := line[2 : tag[1]-2] // between [" and "]
+				if _, ok := c.pending[id]; ok {
+					c.pending[id] = row
+				}
+				if _, ok := c.watched[id]; ok {
+					c.watched[id] = row
+				}
+				if id == c.browseID {
+					c.browseRow = row
+				}
+			}
+		}
+		line = regionTag.ReplaceAllString(line, "")
+		if width <= 0 {
+			lines[i] = strings.NewReplacer(bodyMark, "", fillMark, "").Replace(line)
+		} else {
+			lines[i] = wrapLine(line, width, c.hangingIndent)
+		}
+		row += strings.Count(lines[i], "\n") + 1
+	}
+	c.rows = row
+	return strings.Join(lines, "\n")
+}
+
+// wrapLine breaks one formatted line into rows of at most width columns.
+func wrapLine(line string, width int, hanging bool) string {
+	if i := strings.Index(line, fillMark); i >= 0 {
+		rest := strings.ReplaceAll(line[i+len(fillMark):], fillMark, "")
+		head := line[:i]
+		if pad := width - tview.TaggedStringWidth(head+rest); pad > 0 {
+			head += strings.Repeat(" ", pad)
+		}
+		line = head + rest
+	}
+
+	indent := 0
+	if i := strings.Index(line, bodyMark); i >= 0 {
+		if hanging {
+			indent = tview.TaggedStringWidth(line[:i])
+		}
+		line = strings.ReplaceAll(line, bodyMark, "")
+	}
+	if tview.TaggedStringWidth(line) <= width {
+		return line
+	}
+	if width-indent < minBodyWidth {
+		indent = 0
+	}
+
+	rows := tview.WordWrap(line, width)
+	if indent == 0 || len(rows) < 2 {
+		return strings.Join(rows, "\n")
+	}
+	// Re-wrap everything after the first row to the narrower body column.
+	rest := line[len(rows[0]):]
+	pad := strings.Repeat(" ", indent)
+	var b strings.Builder
+	b.WriteString(rows[0])
+	for _, row := range tview.WordWrap(rest, width-indent) {
+		b.WriteString("\n")
+		b.WriteString(pad)
+		b.WriteString(row)
+	}
+	return b.String()
+}
+
+// ── Scrolling ─────────────────────────────────────────────────────────────────
+//
+// The transcript follows new lines until PgUp scrolls back; then it stays
+// put, counting what arrives below for the {scroll} footer segment, until
+// PgDn reaches the end again or Ctrl+End (End on an empty input) jumps
+// there. Sending a message jumps there too. Lines whose body has not been fetched yet
+// (models.Message.Pending) are reported to the visible func whenever they
+// are on screen, so bodies load as they scroll into view; lines given to
+// WatchLine are reported once, the first time. PgUp once the top
+// is reached brings back lines moved out by the scrollback limit (see
+// scrollback.go), then calls the top func, which may load older lines above
+// it.
+
+// SetVisibleFunc sets fn to be told the IDs of pending and watched lines on
+// screen, after every render and scroll. Must be called from the tview
+// event loop.
+func (c *ChatView) SetVisibleFunc(fn func(ids []string)) {
+	c.onVisible = fn
+}
+
+// WatchLine has the visible func told about line id the first time it is
+// on screen, e.g. to acknowledge it only once it can have been read. Watch
+// a line before adding it: at the end of the transcript it is reported by
+// the render that shows it. Must be called from the tview event loop.
+func (c *ChatView) WatchLine(id string) {
+	c.watched[id] = -1 // placed by the next render
+}
+
+// SetTopFunc sets fn to be called for PgUp at the top of the transcript; nil
+// when there is nothing older to load. Must be called from the tview event
+// loop.
+func (c *ChatView) SetTopFunc(fn func()) {
+	c.onTop = fn
+	c.redrawFooter()
+}
+
+// window returns the first row on screen and the pane's height.
+func (c *ChatView) window() (first, height int) {
+	_, _, _, height = c.messageView.GetInnerRect()
+	bottom := c.rows - height
+	if bottom < 0 {
+		bottom = 0
+	}
+	if !c.scrolledBack {
+		return bottom, height
+	}
+	first, _ = c.messageView.GetScrollOffset()
+	if first > bottom {
+		first = bottom
+	}
+	return first, height
+}
+
+// scrollPage moves the transcript one page up (dir < 0) or down.
+func (c *ChatView) scrollPage(dir int) {
+	first, height := c.window()
+	if dir < 0 && first == 0 && c.restoreArchived() {
+		return
+	}
+	if dir < 0 && first == 0 && c.onTop != nil {
+		c.onTop()
+		return
+	}
+	step := height - 1
+	if step < 1 {
+		step = 1
+	}
+	first += dir * step
+	if first < 0 {
+		first = 0
+	}
+	if first >= c.rows-height {
+		c.ScrollToLatest()
+		return
+	}
+	if top := first == 0; !c.scrolledBack || top != c.atTop {
+		c.scrolledBack, c.atTop = true, top
+		c.redrawFooter()
+	}
+	c.messageView.ScrollTo(first, 0)
+	c.reportVisible()
+}
+
+// ScrollToLatest jumps to the end of the transcript, which follows new lines
+// again. Must be called from the tview event loop.
+func (c *ChatView) ScrollToLatest() {
+	c.scrolledBack, c.atTop = false, false
+	c.unseen = 0
+	c.messageView.ScrollToEnd()
+	c.redrawFooter()
+	c.reportVisible()
+}
+
+// noteUnseen counts a line added while scrolled back.
+func (c *ChatView) noteUnseen() {
+	if c.scrolledBack {
+		c.unseen++
+		c.redrawFooter()
+	}
+}
+
+// reportVisible tells the visible func about the pending and watched lines
+// on screen, and stops watching those.
+func (c *ChatView) reportVisible() {
+	if c.onVisible == nil || len(c.pending)+len(c.watched) == 0 {
+		return
+	}
+	first, height := c.window()
+	var ids []string
+	for id, row := range c.pending {
+		if row >= first && row < first+height {
+			ids = append(ids, id)
+		}
+	}
+	for id, row := range c.watched {
+		if row >= first && row < first+height {
+			ids = append(ids, id)
+			delete(c.watched, id)
+		}
+	}
+	if len(ids) > 0 {
+		c.onVisible(ids)
+	}
+}
+
+// unreadRegion tags the unread divider in committedText.
+const unreadRegion = "unread"
+
+// MarkUnread puts the unread divider, a system line with markup text, at the
+// end of the transcript, taking it from wherever it was. Must be called
+// from the tview event loop.
+func (c *ChatView) MarkUnread(text string) {
+	c.removeUnreadMark()
+	c.committedText += tagLine(unreadRegion, c.format(models.NewSystemMessage(text)))
+	c.renderMessages()
+}
+
+// ClearUnreadMark takes the unread divider away, if there is one. Must be
+// called from the tview event loop.
+func (c *ChatView) ClearUnreadMark() {
+	if c.removeUnreadMark() {
+		c.renderMessages()
+	}
+}
+
+// removeUnreadMark cuts the divider out of committedText without rendering.
+func (c *ChatView) removeUnreadMark() bool {
+	return c.removeLine(unreadRegion)
+}
+
+// removeLine cuts the one-line region id out of committedText without
+// rendering. It reports false if there is no such region.
+func (c *ChatView) removeLine(id string) bool {
+	start := strings.Index(c.committedText, `["`+id+`"]`)
+	if start < 0 {
+		return false
+	}
+	end := strings.IndexByte(c.committedText[start:], '\n')
+	if end < 0 {
+		return false
+	}
+	c.committedText = c.committedText[:start] + c.committedText[start+end+1:]
+	return true
+}
@@ -0,0 +1,40 @@
+package views
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/rivo/tview"
+)
+
+func TestWatchLine(t *testing.T) {
+	c := &ChatView{
+		messageView: &messagePane{TextView: tview.NewTextView()},
+		pending:     map[string]int{},
+		watched:     map[string]int{},
+	}
+	c.messageView.SetRect(0, 0, 40, 4)
+	var seen []string
+	c.SetVisibleFunc(func(ids []string) { seen = append(seen, ids...) })
+	c.WatchLine("m2")
+	c.WatchLine("m9")
+	for i := 0; i < 10; i++ {
+		c.committedText += tagLine(fmt.Sprintf("m%d", i), fmt.Sprintf("bob: line %d\n", i))
+	}
+
+	c.wrapText(c.committedText, 40)
+	c.reportVisible()
+	c.reportVisible()
+	if !reflect.DeepEqual(seen, []string{"m9"}) {
+		t.Fatalf("at the end, reported %q; want m9 once", seen)
+	}
+
+	seen = nil
+	c.scrolledBack = true
+	c.messageView.ScrollTo(0, 0)
+	c.reportVisible()
+	if !reflect.DeepEqual(seen, []string{"m2"}) || len(c.watched) != 0 {
+		t.Errorf("scrolled to the top, reported %q, still watching %v", seen, c.watched)
+	}
+}
@@ -0,0 +1,59 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ── Message numbers ───────────────────────────────────────────────────────────
+//
+// Some commands act on "the nth latest message" — /bookmark 3, /translate 2.
+// While one of them is being typed, or after F2, the latest messages carry
+// their n in a badge at the start of the line. The numbers are added when
+// the transcript is rendered, never stored in it, so they follow new
+// messages and go away without a trace. Which messages get numbers is up to
+// the controller, which knows the session history.
+
+// SetNumbering sets the commands, without the slash, that number the
+// messages while typed, and the function that lists the IDs to number,
+// latest first. Must be called from the tview event loop.
+func (c *ChatView) SetNumbering(commands []string, ids func() []string) {
+	c.numberCommands = commands
+	c.numberIDs = ids
+}
+
+// toggleNumbers handles F2.
+func (c *ChatView) toggleNumbers() {
+	c.numbersPinned = !c.numbersPinned
+	c.renderMessages()
+}
+
+// numbersForInput shows the numbers while the input holds one of the
+// numbering commands followed by a space.
+func (c *ChatView) numbersForInput(text string) {
+	typed := false
+	if name, _, ok := strings.Cut(strings.TrimPrefix(text, "/"), " "); ok && strings.HasPrefix(text, "/") {
+		for _, cmd := range c.numberCommands {
+			typed = typed || strings.EqualFold(name, cmd)
+		}
+	}
+	if typed != c.numbersTyped {
+		c.numbersTyped = typed
+		c.renderMessages()
+	}
+}
+
+// numberLines puts the badges into text, the transcript about to be shown.
+func (c *ChatView) numberLines(text string) string {
+	if !(c.numbersPinned || c.numbersTyped) || c.numberIDs == nil {
+		return text
+	}
+	for i, id := range c.numberIDs() {
+		if id == "" {
+			continue
+		}
+		tag := `["` + id + `"]`
+		text = strings.Replace(text, tag, tag+fmt.Sprintf("[black:cyan]%2d [-:-] ", i+1), 1)
+	}
+	return text
+}
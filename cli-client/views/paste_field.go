@@ -0,0 +1,28 @@
+package views
+
+import (
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// pasteField is the chat input with bracketed paste routed through onPaste
+// first (see Application.EnablePaste), so a large paste can be handled as a
+// whole instead of becoming one message per line.
+type pasteField struct {
+	*tview.InputField
+	onPaste func(text string) bool // true = handled
+}
+
+// PasteHandler implements tview.Primitive.
+func (p *pasteField) PasteHandler() func(pastedText string, setFocus func(p tview.Primitive)) {
+	inner := p.InputField.PasteHandler()
+	return func(pastedText string, setFocus func(p tview.Primitive)) {
+		if p.onPaste != nil && p.onPaste(pastedText) {
+			return
+		}
+		// The field is a single line: keep the text, lose the line breaks.
+		flat := strings.Join(strings.Fields(pastedText), " ")
+		inner(flat, setFocus)
+	}
+}
@@ -0,0 +1,44 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"cli-client/models"
+
+	"github.com/rivo/tview"
+)
+
+// pollBarWidth is the width of a full bar in a poll's chart.
+const pollBarWidth = 12
+
+// formatPoll renders msg.Poll as the question under the sender's name and a
+// bar per option, sized by its share of the votes. label is the sender's,
+// from nameLabel.
+func formatPoll(msg *models.Message, label string) string {
+	p := msg.Poll
+	color := ColorTag(msg.Color)
+	var b strings.Builder
+	fmt.Fprintf(&b, "[gray]%s[-] %s%s[-] %s%s📊 %s[-]  [dim]poll %s[-]",
+		Label(msg.FormatTime()), color, label, bodyMark, color, Escape(p.Question), p.ID)
+
+	counts, total := p.Tally()
+	width := 0
+	for _, o := range p.Options {
+		if w := tview.TaggedStringWidth(Escape(o)); w > width {
+			width = w
+		}
+	}
+	for i, o := range p.Options {
+		label := Escape(o) + strings.Repeat(" ", width-tview.TaggedStringWidth(Escape(o)))
+		filled, pct := 0, 0
+		if total > 0 {
+			filled = (counts[i]*pollBarWidth + total/2) / total
+			pct = (counts[i]*100 + total/2) / total
+		}
+		fmt.Fprintf(&b, "\n    %s[white]%d[-] %s  [green]%s[gray]%s[-]  %d · %d%%",
+			bodyMark, i+1, label, strings.Repeat("█", filled), strings.Repeat("░", pollBarWidth-filled), counts[i], pct)
+	}
+	fmt.Fprintf(&b, "\n    %s[dim]%d vote(s) · /vote %s <n>[-]\n", bodyMark, total, p.ID)
+	return b.String()
+}
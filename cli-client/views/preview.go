@@ -0,0 +1,98 @@
+package views
+
+import (
+	"strings"
+
+	"cli-client/models"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ── Compose preview ───────────────────────────────────────────────────────────
+//
+// F4 opens a strip above the input that shows the message being typed the
+// way the transcript will show it once sent: escaped, as a code block,
+// highlighted and wrapped like any other line. The controller builds the
+// message, so it has the color others see, and adds a note for anything
+// sending will change, such as a split into parts. Commands are not sent as
+// messages and are not previewed. F4 again closes the strip.
+
+// maxPreviewRows is the most rows of the message the strip shows.
+const maxPreviewRows = 6
+
+// SetComposePreview sets how the preview gets the message text would be
+// sent as, and a note about it; a nil message previews nothing. Must be
+// called from the tview event loop.
+func (c *ChatView) SetComposePreview(fn func(text string) (*models.Message, string)) {
+	c.compose = fn
+}
+
+// SetPreview opens or closes the preview strip. Must be called from the
+// tview event loop.
+func (c *ChatView) SetPreview(on bool) {
+	if on == c.previewVisible {
+		return
+	}
+	c.previewVisible = on
+	c.previewRows = 0
+	if on {
+		c.updatePreview(c.inputField.GetText())
+	}
+	c.layout()
+}
+
+// togglePreview handles F4.
+func (c *ChatView) togglePreview() {
+	c.SetPreview(!c.previewVisible)
+}
+
+// updatePreview shows text in the strip as it would be sent.
+func (c *ChatView) updatePreview(text string) {
+	if !c.previewVisible {
+		return
+	}
+	if c.preview == nil {
+		c.preview = tview.NewTextView()
+		c.preview.SetDynamicColors(true)
+		c.preview.SetWrap(false)
+		c.preview.SetBackgroundColor(tcell.ColorBlack)
+	}
+
+	head := "[dim]preview · F4 hides"
+	body := ""
+	var msg *models.Message
+	note := ""
+	switch {
+	case strings.TrimSpace(text) == "":
+		head += " — type a message to see it as it will be sent"
+	case strings.HasPrefix(text, "/"):
+		head += " — commands are not sent as messages"
+	case c.compose != nil:
+		msg, note = c.compose(text)
+	}
+	if msg != nil {
+		width := c.messageView.width
+		line := strings.TrimSuffix(c.format(msg), "\n")
+		if width > 0 {
+			line = wrapLine(line, width, c.hangingIndent)
+		} else {
+			line = strings.NewReplacer(bodyMark, "", fillMark, "").Replace(line)
+		}
+		rows := strings.Split(line, "\n")
+		if len(rows) > maxPreviewRows {
+			rows = append(rows[:maxPreviewRows-1], "[dim]…[-]")
+		}
+		body = "\n" + strings.Join(rows, "\n")
+	}
+	if note != "" {
+		head += " — [yellow]" + Escape(note) + "[-]"
+	}
+	c.preview.SetText(head + "[-]" + body)
+	c.preview.ScrollToBeginning()
+
+	if rows := strings.Count(body, "\n") + 1; rows != c.previewRows {
+		c.previewRows = rows
+		c.layout()
+	}
+}
@@ -0,0 +1,48 @@
+package views
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ── Small terminals ────────────────────────────────────────────────────────
+//
+// Below compactHeight rows the chat screen drops its chrome: the header
+// shrinks to one unbordered line and the command bar is hidden, leaving the
+// rows to the transcript. Growing the terminal brings both back.
+
+// compactHeight is the terminal height, in rows, below which the chat
+// screen goes compact.
+const compactHeight = 20
+
+// chatContainer is the chat screen's root flex, told when its height
+// changes so the layout can follow.
+type chatContainer struct {
+	*tview.Flex
+	height   int
+	onResize func(height int) // called from Draw when the height changed
+}
+
+// Draw implements tview.Primitive.
+func (f *chatContainer) Draw(screen tcell.Screen) {
+	if _, _, _, h := f.GetRect(); h != f.height {
+		f.height = h
+		if f.onResize != nil {
+			f.onResize(h)
+		}
+	}
+	f.Flex.Draw(screen)
+}
+
+// fitHeight switches between the full and compact layouts for a screen
+// height rows tall. Called from Draw, inside the tview event loop.
+func (c *ChatView) fitHeight(height int) {
+	compact := height < compactHeight
+	if compact == c.compact {
+		return
+	}
+	c.compact = compact
+	c.header.SetBorder(!compact)
+	c.redrawHeader()
+	c.layout()
+}
@@ -0,0 +1,99 @@
+package views
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ── Rooms sidebar ─────────────────────────────────────────────────────────────
+//
+// F5 opens a sidebar left of the chat panes listing the conversations, each
+// with its unread count and last activity, and gives it focus. ↑/↓ pick
+// one, Enter switches to it and hands the keyboard back to the input, and
+// Esc does so without switching; the sidebar stays open and up to date
+// until F5 closes it. F5 with the input focused focuses the sidebar again.
+
+// roomsWidth is the sidebar's width in columns, borders included.
+const roomsWidth = 30
+
+// RoomItem is one row of the rooms sidebar. Title and Detail are plain text.
+type RoomItem struct {
+	Title  string // "2 relay.example.org"
+	Detail string // "last message 15:04"
+	Unread int    // messages not on screen yet
+	Active bool   // the conversation of the focused pane
+}
+
+// SetRooms fills the sidebar with items, keeping the selected row. It may
+// be called whether the sidebar is open or not. Must be called from the
+// tview event loop.
+func (s *SplitView) SetRooms(items []RoomItem) {
+	selected := s.rooms.GetCurrentItem()
+	s.rooms.Clear()
+	for _, it := range items {
+		title := Escape(it.Title)
+		if it.Active {
+			title = "[green]●[-] " + title
+		}
+		if it.Unread > 0 {
+			title += fmt.Sprintf(" [black:yellow:b] %d [-:-:-]", it.Unread)
+		}
+		s.rooms.AddItem(title, Escape(it.Detail), 0, nil)
+	}
+	if selected < len(items) {
+		s.rooms.SetCurrentItem(selected)
+	}
+}
+
+// SetRoomFunc sets fn to be called with the index of the row Enter picks.
+// Must be called from the tview event loop.
+func (s *SplitView) SetRoomFunc(fn func(i int)) {
+	s.onRoom = fn
+}
+
+// ShowRooms opens or closes the sidebar; open, it has the focus. Must be
+// called from the tview event loop.
+func (s *SplitView) ShowRooms(show bool) {
+	if s.sidebar != show {
+		s.sidebar = show
+		s.layout()
+	}
+	if show {
+		s.app.SetFocus(s.rooms)
+	} else {
+		s.app.SetFocus(s.Active().InputPrimitive())
+	}
+}
+
+// RoomsShown reports whether the sidebar is open. Must be called from the
+// tview event loop.
+func (s *SplitView) RoomsShown() bool { return s.sidebar }
+
+// newRoomList builds the sidebar's list.
+func (s *SplitView) newRoomList() *tview.List {
+	l := tview.NewList()
+	l.SetBackgroundColor(tcell.ColorBlack)
+	l.SetBorder(true)
+	l.SetBorderColor(tcell.ColorDarkCyan)
+	l.SetTitle(" rooms · F5 close ")
+	l.SetMainTextColor(tcell.ColorWhite)
+	l.SetSecondaryTextColor(tcell.ColorGray)
+	l.SetSelectedBackgroundColor(tcell.ColorDarkCyan)
+	l.SetWrapAround(false)
+	l.SetSelectedFunc(func(i int, _, _ string, _ rune) {
+		s.app.SetFocus(s.Active().InputPrimitive())
+		if s.onRoom != nil {
+			s.onRoom(i)
+		}
+	})
+	l.SetDoneFunc(func() { s.app.SetFocus(s.Active().InputPrimitive()) })
+	return l
+}
+
+// roomsKey runs F5: it opens the sidebar, focuses it if it is open but
+// not focused, and closes it otherwise.
+func (s *SplitView) roomsKey() {
+	s.ShowRooms(!s.sidebar || !s.rooms.HasFocus())
+}
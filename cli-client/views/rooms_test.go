@@ -0,0 +1,56 @@
+package views
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func TestSetRooms(t *testing.T) {
+	app := tview.NewApplication()
+	s := NewSplitView(app, NewChatView(app, func(string) {}, func(string) {}), NewChatView(app, func(string) {}, func(string) {}))
+	s.SetRooms([]RoomItem{
+		{Title: "1 lan", Detail: "active", Active: true},
+		{Title: "2 relay[x]", Detail: "last message 15:04", Unread: 3},
+	})
+	if n := s.rooms.GetItemCount(); n != 2 {
+		t.Fatalf("%d rows, want 2", n)
+	}
+	s.rooms.SetCurrentItem(1)
+	main, detail := s.rooms.GetItemText(1)
+	if !strings.Contains(main, Escape("relay[x]")) || !strings.Contains(main, " 3 ") || detail != "last message 15:04" {
+		t.Errorf("row 2 = %q, %q", main, detail)
+	}
+	if main, _ := s.rooms.GetItemText(0); !strings.Contains(main, "●") {
+		t.Errorf("active row %q has no dot", main)
+	}
+
+	s.SetRooms([]RoomItem{{Title: "1 lan"}, {Title: "2 relay"}, {Title: "3 other"}})
+	if got := s.rooms.GetCurrentItem(); got != 1 {
+		t.Errorf("selection moved to %d on refresh", got)
+	}
+
+	f5 := tcell.NewEventKey(tcell.KeyF5, 0, tcell.ModNone)
+	picked := -1
+	s.SetRoomFunc(func(i int) { picked = i })
+	if s.captureKey(f5) != nil {
+		t.Error("F5 was passed on")
+	}
+	if !s.RoomsShown() || !s.rooms.HasFocus() {
+		t.Fatal("F5 did not open and focus the sidebar")
+	}
+	s.rooms.InputHandler()(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), func(p tview.Primitive) { app.SetFocus(p) })
+	if picked != 1 {
+		t.Errorf("Enter picked %d, want 1", picked)
+	}
+	s.captureKey(f5)
+	if !s.rooms.HasFocus() {
+		t.Error("F5 with the input focused did not focus the sidebar")
+	}
+	s.captureKey(f5)
+	if s.RoomsShown() {
+		t.Error("F5 in the sidebar did not close it")
+	}
+}
@@ -0,0 +1,104 @@
+package views
+
+import "strings"
+
+// ── Scrollback limit ──────────────────────────────────────────────────────────
+//
+// The transcript keeps at most scrollback lines in memory. While it follows
+// new messages, the oldest entries beyond that — a line with the lines that
+// belong under it, such as a code block or a translation — move to the
+// archive. PgUp at the top brings them back a page at a time, before any
+// older messages are asked of the relay; once the transcript follows new
+// messages again, they go back out. Clearing the transcript empties the
+// archive too.
+
+// Archive keeps transcript entries moved out of memory. models.Scrollback
+// keeps them on disk.
+type Archive interface {
+	Push(entries []string) // oldest first
+	Pop(n int) []string    // up to n of the newest, oldest first
+	Len() int
+	Reset()
+}
+
+// archivePage is how many entries one PgUp at the top brings back.
+const archivePage = 100
+
+// SetScrollback keeps at most lines transcript lines in memory, moving
+// older ones to archive; 0 keeps them all. Must be called from the tview
+// event loop.
+func (c *ChatView) SetScrollback(lines int, archive Archive) {
+	c.scrollback = lines
+	c.archive = archive
+	c.renderMessages()
+}
+
+// Archived returns how many transcript entries are in the archive. Must be
+// called from the tview event loop.
+func (c *ChatView) Archived() int {
+	if c.archive == nil {
+		return 0
+	}
+	return c.archive.Len()
+}
+
+// resetArchive empties the archive, for a cleared transcript.
+func (c *ChatView) resetArchive() {
+	if c.Archived() > 0 {
+		c.archive.Reset()
+	}
+}
+
+// trimScrollback moves the oldest entries out of committedText while it is
+// over the limit. Scrolled back, everything stays where the reader is.
+func (c *ChatView) trimScrollback() {
+	if c.scrollback <= 0 || c.scrolledBack {
+		return
+	}
+	over := strings.Count(c.committedText, "\n") - c.scrollback
+	if over <= 0 {
+		return
+	}
+	var entries []string
+	cut := 0
+	for over > 0 && cut < len(c.committedText) {
+		// An entry ends before the next line that does not start with a
+		// space; indented lines belong to the one above.
+		end := cut
+		for {
+			nl := strings.IndexByte(c.committedText[end:], '\n')
+			if nl < 0 {
+				end = len(c.committedText)
+				break
+			}
+			end += nl + 1
+			over--
+			if end == len(c.committedText) || c.committedText[end] != ' ' {
+				break
+			}
+		}
+		entries = append(entries, c.committedText[cut:end])
+		cut = end
+	}
+	for _, e := range entries {
+		for _, m := range messageRegion.FindAllStringSubmatch(e, -1) {
+			delete(c.pending, m[1])
+			delete(c.watched, m[1])
+			delete(c.folded, m[1])
+		}
+	}
+	if c.archive != nil {
+		c.archive.Push(entries)
+	}
+	c.committedText = c.committedText[cut:]
+}
+
+// restoreArchived brings the newest page of the archive back above the
+// transcript. It reports false if the archive is empty.
+func (c *ChatView) restoreArchived() bool {
+	if c.Archived() == 0 {
+		return false
+	}
+	c.prependText(strings.Join(c.archive.Pop(archivePage), ""))
+	return true
+}
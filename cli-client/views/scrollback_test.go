@@ -0,0 +1,53 @@
+package views
+
+import (
+	"strings"
+	"testing"
+)
+
+// memArchive is an Archive in memory.
+type memArchive struct{ entries []string }
+
+func (a *memArchive) Push(entries []string) { a.entries = append(a.entries, entries...) }
+func (a *memArchive) Len() int              { return len(a.entries) }
+func (a *memArchive) Reset()                { a.entries = nil }
+func (a *memArchive) Pop(n int) []string {
+	n = min(n, len(a.entries))
+	out := a.entries[len(a.entries)-n:]
+	a.entries = a.entries[:len(a.entries)-n]
+	return out
+}
+
+func TestTrimScrollback(t *testing.T) {
+	archive := &memArchive{}
+	c := &ChatView{
+		scrollback: 3,
+		archive:    archive,
+		pending:    map[string]int{"a": -1},
+		committedText: `["a"]alice: one[""]` + "\n" +
+			"```code\n" +
+			"  │ indented\n" +
+			"  ↳ note\n" +
+			"bob: two\n" +
+			"carol: three\n",
+	}
+	c.trimScrollback()
+
+	want := []string{`["a"]alice: one[""]` + "\n", "```code\n  │ indented\n  ↳ note\n"}
+	if strings.Join(archive.entries, "|") != strings.Join(want, "|") {
+		t.Errorf("archived %q, want %q", archive.entries, want)
+	}
+	if c.committedText != "bob: two\ncarol: three\n" {
+		t.Errorf("kept %q", c.committedText)
+	}
+	if _, ok := c.pending["a"]; ok {
+		t.Error("pending line still tracked after it was archived")
+	}
+
+	c.scrolledBack = true
+	c.committedText += "dave: four\nerin: five\n"
+	c.trimScrollback()
+	if archive.Len() != 2 {
+		t.Errorf("trimmed while scrolled back: %d entries archived", archive.Len())
+	}
+}
@@ -0,0 +1,179 @@
+package views
+
+import (
+	"sync/atomic"
+	"time"
+
+	"cli-client/models"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ── Split panes ───────────────────────────────────────────────────────────────
+//
+// The chat screen holds two ChatViews. With the split closed the focused
+// one fills the screen; open, they sit side by side, each with its own
+// transcript, scrollback and input. Ctrl+Left and Ctrl+Right move the focus
+// between them. The screen's sinks go to the focused pane, so which
+// conversation a pane shows is up to the controller: it is told of every
+// Ctrl+arrow through the focus func and calls Focus to follow it. The rooms
+// sidebar goes left of both (see rooms.go).
+
+// SplitView lays out the two chat panes of the chat screen.
+type SplitView struct {
+	app     *tview.Application
+	flex    *tview.Flex
+	divider *tview.Box
+	panes   [2]*ChatView
+	open    bool           // both panes shown — tview event loop only
+	active  atomic.Int32   // the focused pane; read from any goroutine
+	onFocus func(pane int) // Ctrl+arrow asked for pane; nil = just focus it
+
+	// Rooms sidebar — only touched inside tview event loop. See rooms.go.
+	rooms   *tview.List
+	sidebar bool        // shown
+	onRoom  func(i int) // Enter on row i
+}
+
+// NewSplitView returns the chat screen for left and right, with the split
+// closed and left focused.
+func NewSplitView(app *tview.Application, left, right *ChatView) *SplitView {
+	s := &SplitView{app: app, panes: [2]*ChatView{left, right}}
+	s.divider = tview.NewBox().SetBackgroundColor(tcell.ColorBlack)
+	s.flex = tview.NewFlex().SetDirection(tview.FlexColumn)
+	s.flex.SetInputCapture(s.captureKey)
+	s.rooms = s.newRoomList()
+	s.layout()
+	return s
+}
+
+func (s *SplitView) Primitive() tview.Primitive { return s.flex }
+
+// Active returns the focused pane. Safe to call from any goroutine.
+func (s *SplitView) Active() *ChatView { return s.panes[s.active.Load()] }
+
+// ActivePane returns the index of the focused pane, 0 for the left one.
+// Safe to call from any goroutine.
+func (s *SplitView) ActivePane() int { return int(s.active.Load()) }
+
+// Pane returns pane i, 0 for the left one, whether shown or not.
+func (s *SplitView) Pane(i int) *ChatView { return s.panes[i] }
+
+// Panes returns both panes, for setting them up alike.
+func (s *SplitView) Panes() []*ChatView { return s.panes[:] }
+
+// IsOpen reports whether both panes are shown. Must be called from the
+// tview event loop.
+func (s *SplitView) IsOpen() bool { return s.open }
+
+// SetFocusFunc sets fn to be called with the pane Ctrl+Left or Ctrl+Right
+// asks for while the split is open; fn moves the focus with Focus. Must be
+// called from the tview event loop.
+func (s *SplitView) SetFocusFunc(fn func(pane int)) {
+	s.onFocus = fn
+}
+
+// SetOpen shows both panes, or only the focused one. Must be called from
+// the tview event loop.
+func (s *SplitView) SetOpen(open bool) {
+	if s.open != open {
+		s.open = open
+		s.layout()
+	}
+}
+
+// Focus makes pane i the focused one and gives its input the keyboard.
+// Must be called from the tview event loop.
+func (s *SplitView) Focus(i int) {
+	s.active.Store(int32(i))
+	s.layout()
+	s.app.SetFocus(s.Active().InputPrimitive())
+}
+
+// layout puts the panes in the flex, after the sidebar if it is shown: the
+// focused one alone, or both with a gap between them and the other one's
+// borders dimmed.
+func (s *SplitView) layout() {
+	s.flex.Clear()
+	if s.sidebar {
+		s.flex.AddItem(s.rooms, roomsWidth, 0, false)
+	}
+	active := s.ActivePane()
+	for i, pane := range s.panes {
+		pane.setFocused(i == active)
+		if !s.open && i != active {
+			continue
+		}
+		if i == 1 && s.open {
+			s.flex.AddItem(s.divider, 1, 0, false)
+		}
+		s.flex.AddItem(pane.Primitive(), 0, 1, i == active)
+	}
+}
+
+// captureKey runs F5, and moves the focus for Ctrl+Left and Ctrl+Right
+// while both panes are shown.
+func (s *SplitView) captureKey(event *tcell.EventKey) *tcell.EventKey {
+	if event.Key() == tcell.KeyF5 {
+		s.roomsKey()
+		return nil
+	}
+	if !s.open || event.Modifiers()&tcell.ModCtrl == 0 {
+		return event
+	}
+	want := -1
+	switch event.Key() {
+	case tcell.KeyLeft:
+		want = 0
+	case tcell.KeyRight:
+		want = 1
+	default:
+		return event
+	}
+	switch {
+	case want == s.ActivePane():
+	case s.onFocus != nil:
+		s.onFocus(want)
+	default:
+		s.Focus(want)
+	}
+	return nil
+}
+
+// ── View and sinks ────────────────────────────────────────────────────────────
+//
+// The chat screen is shown, hidden and stopped as a whole; what it is sent
+// goes to the focused pane.
+
+func (s *SplitView) OnShow() { s.Active().OnShow() }
+
+func (s *SplitView) OnHide() {
+	for _, pane := range s.panes {
+		pane.OnHide()
+	}
+}
+
+func (s *SplitView) Stop() {
+	for _, pane := range s.panes {
+		pane.Stop()
+	}
+}
+
+func (s *SplitView) AddMessage(msg *models.Message)     { s.Active().AddMessage(msg) }
+func (s *SplitView) AddMessages(msgs []*models.Message) { s.Active().AddMessages(msgs) }
+func (s *SplitView) UpdateMessage(msg *models.Message)  { s.Active().UpdateMessage(msg) }
+func (s *SplitView) ClearMessages()                     { s.Active().ClearMessages() }
+func (s *SplitView) UpdateDashboard(samples []models.StatsSample) {
+	s.Active().UpdateDashboard(samples)
+}
+func (s *SplitView) StatsUnavailable(serverURL string) { s.Active().StatsUnavailable(serverURL) }
+func (s *SplitView) UpdateLatency(latency int)         { s.Active().UpdateLatency(latency) }
+
+func (s *SplitView) AddIncomingMessage(id string, at time.Time, username, content, colorTag string) {
+	s.Active().AddIncomingMessage(id, at, username, content, colorTag)
+}
+
+func (s *SplitView) UpdateStats(totalMsgs, active, waiting, maxMsgs, maxWaiters int, serverURL string) {
+	s.Active().UpdateStats(totalMsgs, active, waiting, maxMsgs, maxWaiters, serverURL)
+}
@@ -0,0 +1,43 @@
+package views
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func TestSplitViewFocus(t *testing.T) {
+	app := tview.NewApplication()
+	left, right := NewChatView(app, func(string) {}, func(string) {}), NewChatView(app, func(string) {}, func(string) {})
+	defer left.Stop()
+	defer right.Stop()
+	s := NewSplitView(app, left, right)
+	ctrlRight := tcell.NewEventKey(tcell.KeyRight, 0, tcell.ModCtrl)
+
+	if s.captureKey(ctrlRight) == nil || s.flex.GetItemCount() != 1 {
+		t.Fatal("Ctrl+→ taken, or two panes shown, with the split closed")
+	}
+	s.SetOpen(true)
+	if s.flex.GetItemCount() != 3 {
+		t.Fatalf("split open: %d items, want both panes and the divider", s.flex.GetItemCount())
+	}
+	if s.captureKey(ctrlRight) != nil || s.Active() != right || !left.unfocused || right.unfocused {
+		t.Fatal("Ctrl+→ did not focus the right pane")
+	}
+
+	asked := -1
+	s.SetFocusFunc(func(pane int) { asked = pane })
+	s.captureKey(tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModCtrl))
+	if asked != 0 || s.Active() != right {
+		t.Errorf("with a focus func, Ctrl+← asked for %d and moved the focus itself", asked)
+	}
+	if s.captureKey(tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone)) == nil {
+		t.Error("plain ← taken from the input")
+	}
+
+	s.SetOpen(false)
+	if item := s.flex.GetItem(0); s.flex.GetItemCount() != 1 || item != right.Primitive() {
+		t.Error("closing the split did not leave the focused pane alone")
+	}
+}
@@ -0,0 +1,99 @@
+package views
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"cli-client/models"
+)
+
+// ── Stickers ──────────────────────────────────────────────────────────────────
+//
+// A sticker travels as its name only; the art is built in. A name this
+// client does not know is shown as its ":name:" text.
+
+type sticker struct {
+	color string
+	art   []string
+}
+
+var stickers = map[string]sticker{
+	"shrug": {"[yellow]", []string{
+		`¯\_(ツ)_/¯`,
+	}},
+	"tableflip": {"[red]", []string{
+		`(╯°□°)╯︵ ┻━┻`,
+	}},
+	"cat": {"[white]", []string{
+		` /\_/\ `,
+		`( o.o )`,
+		` > ^ < `,
+	}},
+	"heart": {"[red]", []string{
+		` ♥♥   ♥♥ `,
+		`♥♥♥♥ ♥♥♥♥`,
+		` ♥♥♥♥♥♥♥ `,
+		`   ♥♥♥   `,
+		`    ♥    `,
+	}},
+	"coffee": {"[orange]", []string{
+		`   ( (  `,
+		`    ) ) `,
+		` ........`,
+		` |      |]`,
+		` \      / `,
+		"  `----'  ",
+	}},
+	"party": {"[yellow]", []string{
+		`*:･ﾟ✧ \(^o^)/ ✧ﾟ･:*`,
+	}},
+	"wave": {"[green]", []string{
+		`  o/ `,
+		` /|  `,
+		` / \ `,
+	}},
+	"thumbsup": {"[green]", []string{
+		`   _   `,
+		`  ( )  `,
+		` _| |__`,
+		`(____  )`,
+		`(_____ )`,
+		` (____/ `,
+	}},
+}
+
+// StickerNames returns the names of the built-in stickers, sorted.
+func StickerNames() []string {
+	names := make([]string, 0, len(stickers))
+	for name := range stickers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsSticker reports whether name is a built-in sticker.
+func IsSticker(name string) bool {
+	_, ok := stickers[name]
+	return ok
+}
+
+// formatSticker renders msg.Sticker under the sender's name, or its text
+// form if the sticker is not built in. label is the sender's, from
+// nameLabel.
+func formatSticker(msg *models.Message, label string) string {
+	color := ColorTag(msg.Color)
+	head := fmt.Sprintf("[gray]%s[-] %s%s[-] %s", Label(msg.FormatTime()), color, label, bodyMark)
+	s, ok := stickers[msg.Sticker]
+	if !ok {
+		return head + color + Escape(msg.Content) + "[-]\n"
+	}
+	var b strings.Builder
+	b.WriteString(head + "[dim]sticker · " + msg.Sticker + "[-]")
+	for _, line := range s.art {
+		b.WriteString("\n    " + bodyMark + s.color + Escape(line) + "[-]")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package views
+
+import "cli-client/models"
+
+// ── Trust badges ──────────────────────────────────────────────────────────────
+//
+// After another user's name the transcript shows how far their identity key
+// is trusted: ? for no key, ○ for the key pinned on first use, ✓ for one
+// whose fingerprint the user compared. Our own name gets no badge — and a
+// message that claims it but came from another client gets impostorBadge in
+// front instead, in impostorColor.
+
+// impostorBadge goes before the name on a message posing as us.
+const impostorBadge = "[black:yellow:b]⚠ impostor[-:-:-] "
+
+// impostorColor replaces the sender's color on a message posing as us, so
+// it does not pass for one of ours at a glance.
+const impostorColor = "[yellow]"
+
+// trustLevels is what SetTrust stores.
+type trustLevels struct {
+	me     string
+	levels map[string]models.Trust
+}
+
+// SetTrust sets the trust shown for users from now on; lines already in the
+// transcript keep their badges. me is our own username. Safe to call from
+// any goroutine.
+func (c *ChatView) SetTrust(me string, levels map[string]models.Trust) {
+	t := &trustLevels{me: me, levels: make(map[string]models.Trust, len(levels))}
+	for user, level := range levels {
+		t.levels[user] = level
+	}
+	c.trust.Store(t)
+}
+
+// TrustBadge returns the badge for level, as markup.
+func TrustBadge(level models.Trust) string {
+	switch level {
+	case models.TrustVerified:
+		return "[green]✓"
+	case models.TrustPinned:
+		return "[gray]○"
+	default:
+		return "[darkgray]?"
+	}
+}
+
+// senderLabel returns the label for msg's sender, with impostorBadge in
+// front if it is posing as us.
+func (c *ChatView) senderLabel(msg *models.Message) string {
+	label := c.nameLabel(msg.Username)
+	if msg.Impostor {
+		label = impostorBadge + label
+	}
+	return label
+}
+
+// trustBadge returns the badge to show after username's label, or "" before
+// SetTrust and for our own name. The badge sets its own color, so it must
+// come last before a color reset. Safe to call from any goroutine.
+func (c *ChatView) trustBadge(username string) string {
+	t := c.trust.Load()
+	if t == nil || username == t.me {
+		return ""
+	}
+	return TrustBadge(t.levels[username])
+}
@@ -0,0 +1,60 @@
+package views
+
+import (
+	"time"
+
+	"cli-client/models"
+
+	"github.com/rivo/tview"
+)
+
+// ── Screens ───────────────────────────────────────────────────────────────────
+//
+// Each models.Screen is backed by one View. The controller drives it through
+// the lifecycle and feeds it through the optional sink interfaces below,
+// which a screen implements only for what it actually shows — a new screen
+// needs no changes to the controller.
+
+// View is one screen of the app. All methods must be called from the tview
+// event loop.
+type View interface {
+	Primitive() tview.Primitive // root primitive, added to the page stack
+	OnShow()                    // the screen became active
+	OnHide()                    // the screen was left; it may be shown again
+	Stop()                      // the screen is done for good
+}
+
+// MessageSink is a view that shows the conversation transcript.
+// AddIncomingMessage is safe to call from any goroutine; its id, if not
+// empty, tags the line like AddMessage does for msg.ID, and at is the time
+// shown. AddMessages adds a batch with a single redraw.
+type MessageSink interface {
+	AddMessage(msg *models.Message)
+	AddMessages(msgs []*models.Message)
+	UpdateMessage(msg *models.Message)
+	AddIncomingMessage(id string, at time.Time, username, content, colorTag string)
+	ClearMessages()
+}
+
+// StatsSink is a view that shows relay statistics and link latency.
+// All of them are safe to call from any goroutine.
+type StatsSink interface {
+	UpdateStats(totalMsgs, active, waiting, maxMsgs, maxWaiters int, serverURL string)
+	// StatsUnavailable hides the statistics: the relay at serverURL has no
+	// /api/stats. The next UpdateStats shows them again.
+	StatsUnavailable(serverURL string)
+	UpdateDashboard(samples []models.StatsSample)
+	UpdateLatency(latency int)
+}
+
+var (
+	_ View        = (*ChatView)(nil)
+	_ MessageSink = (*ChatView)(nil)
+	_ StatsSink   = (*ChatView)(nil)
+	_ View        = (*SplitView)(nil)
+	_ MessageSink = (*SplitView)(nil)
+	_ StatsSink   = (*SplitView)(nil)
+	_ View        = (*LoginView)(nil)
+	_ View        = (*LoadingView)(nil)
+	_ View        = (*ErrorView)(nil)
+)
@@ -17,16 +17,22 @@ import (
 )
 
 type Server struct {
-	chatController  *controllers.SendController
-	pollController  *controllers.PollController
-	statsController *controllers.StatsController
+	chatController     *controllers.SendController
+	pollController     *controllers.PollController
+	bodiesController   *controllers.BodiesController
+	historyController  *controllers.HistoryController
+	statsController    *controllers.StatsController
+	helloController    *controllers.HelloController
+	streamController   *controllers.StreamController
+	moderateController *controllers.ModerateController
 
 	loggingMiddleware  *middleware.LoggingMiddleware
 	recoveryMiddleware *middleware.RecoveryMiddleware
 	corsMiddleware     *middleware.CORSMiddleware
 
-	chatService *services.ChatService
-	authService *services.AuthService
+	chatService       *services.ChatService
+	authService       *services.AuthService
+	moderationService *services.ModerationService
 
 	httpServer *http.Server
 	config     *Config
@@ -35,7 +41,11 @@ type Server struct {
 type Config struct {
 	Port            string
 	AccessKey       string
+	AdminKey        string
+	PowBits         int
+	MaxContent      int
 	MaxMessages     int
+	HereCooldown    time.Duration
 	MessageTTL      time.Duration
 	CleanupInterval time.Duration
 }
@@ -44,13 +54,24 @@ func NewServer(config *Config) *Server {
 	buffer := models.NewMessageBuffer(config.MaxMessages, config.MessageTTL)
 
 	chatService := services.NewChatService(buffer)
-	authService := services.NewAuthService(config.AccessKey)
+	authService := services.NewAuthService(config.AccessKey, config.AdminKey)
+
+	moderationService := services.NewModerationService()
+	moderationService.SetHereCooldown(config.HereCooldown)
+	powService := services.NewPowService(config.PowBits)
 
 	authService.CleanupOldClients(24 * time.Hour)
+	moderationService.CleanupExpired(time.Minute)
+	powService.CleanupExpired(time.Minute)
 
-	chatController := controllers.NewSendController(chatService, authService)
+	chatController := controllers.NewSendController(chatService, authService, moderationService, powService, config.MaxContent)
 	pollController := controllers.NewPollController(chatService, authService)
+	bodiesController := controllers.NewBodiesController(chatService, authService)
+	historyController := controllers.NewHistoryController(chatService, authService)
 	statsController := controllers.NewStatsController(chatService, authService)
+	helloController := controllers.NewHelloController(authService, powService, config.MaxContent)
+	streamController := controllers.NewStreamController(chatService, authService)
+	moderateController := controllers.NewModerateController(chatService, authService, moderationService)
 
 	loggingMiddleware := middleware.NewLoggingMiddleware()
 	recoveryMiddleware := middleware.NewRecoveryMiddleware()
@@ -59,12 +80,18 @@ func NewServer(config *Config) *Server {
 	return &Server{
 		chatController:     chatController,
 		pollController:     pollController,
+		bodiesController:   bodiesController,
+		historyController:  historyController,
 		statsController:    statsController,
+		helloController:    helloController,
+		streamController:   streamController,
+		moderateController: moderateController,
 		loggingMiddleware:  loggingMiddleware,
 		recoveryMiddleware: recoveryMiddleware,
 		corsMiddleware:     corsMiddleware,
 		chatService:        chatService,
 		authService:        authService,
+		moderationService:  moderationService,
 		config:             config,
 	}
 }
@@ -80,7 +107,12 @@ func (s *Server) registerRoutes() {
 
 	http.HandleFunc("/api/send", wrap(s.chatController.Handle))
 	http.HandleFunc("/api/poll", wrap(s.pollController.Handle))
+	http.HandleFunc("/api/bodies", wrap(s.bodiesController.Handle))
+	http.HandleFunc("/api/history", wrap(s.historyController.Handle))
 	http.HandleFunc("/api/stats", wrap(s.statsController.Handle))
+	http.HandleFunc("/api/hello", wrap(s.helloController.Handle))
+	http.HandleFunc("/api/stream", wrap(s.streamController.Handle))
+	http.HandleFunc("/api/moderate", wrap(s.moderateController.Handle))
 
 	http.HandleFunc("/health", wrap(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -100,7 +132,13 @@ func (s *Server) Start() error {
 
 	log.Printf("Server started on port %s", s.config.Port)
 	log.Printf("Access Key: %s", s.config.AccessKey)
-	log.Printf("Max Messages: %d, Message TTL: %v", s.config.MaxMessages, s.config.MessageTTL)
+	if s.config.AdminKey != "" {
+		log.Printf("Admin key set — announcements and moderation enabled")
+	}
+	if s.config.PowBits > 0 {
+		log.Printf("Proof of work: %d bits per message", s.config.PowBits)
+	}
+	log.Printf("Max Messages: %d, Message TTL: %v, Max Content: %d bytes", s.config.MaxMessages, s.config.MessageTTL, s.config.MaxContent)
 
 	return s.httpServer.ListenAndServe()
 }
@@ -116,14 +154,27 @@ func (s *Server) Shutdown() error {
 func main() {
 	port := flag.String("port", "8034", "Port to run the server on")
 	accessKey := flag.String("key", "secure_chat_key_2024", "Access key for clients")
+	adminKey := flag.String("admin-key", "", "Admin key for announcements and moderation (empty = disabled)")
+	powBits := flag.Int("pow-bits", 0, "Proof-of-work difficulty for sending, in leading zero bits (0 = off)")
 	maxMessages := flag.Int("max-msgs", 1000, "Maximum number of messages to store")
+	maxContent := flag.Int("max-content", 4096, "Largest message content accepted, in bytes (0 = unlimited)")
 	msgTTL := flag.Duration("ttl", 1*time.Minute, "Time to live for messages")
+	hereCooldown := flag.Duration("here-cooldown", 5*time.Minute, "Minimum gap between one client's @here mentions (0 = no limit)")
 	flag.Parse()
 
+	// The bundled client gives up above 24 bits (maxPowBits in its pow.go).
+	if *powBits < 0 || *powBits > 24 {
+		log.Fatalf("-pow-bits must be between 0 and 24")
+	}
+
 	config := &Config{
 		Port:            *port,
 		AccessKey:       *accessKey,
+		AdminKey:        *adminKey,
+		PowBits:         *powBits,
+		MaxContent:      *maxContent,
 		MaxMessages:     *maxMessages,
+		HereCooldown:    *hereCooldown,
 		MessageTTL:      *msgTTL,
 		CleanupInterval: 10 * time.Second,
 	}
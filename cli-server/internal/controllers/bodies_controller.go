@@ -0,0 +1,62 @@
+// internal/controllers/bodies_controller.go
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"secure-chat-backend/internal/models"
+	"secure-chat-backend/internal/services"
+)
+
+// maxBodyIDs bounds one /api/bodies request, like a poll answer.
+const maxBodyIDs = 50
+
+// BodiesController serves the chat bodies left out of lazy poll answers
+// (poll?bodies=lazy), so clients fetch only the messages they show.
+type BodiesController struct {
+	chatService *services.ChatService
+	authService *services.AuthService
+}
+
+func NewBodiesController(chatService *services.ChatService, authService *services.AuthService) *BodiesController {
+	return &BodiesController{
+		chatService: chatService,
+		authService: authService,
+	}
+}
+
+// Handle answers GET /api/bodies?ids=a,b,c with the bodies still buffered;
+// expired ones are left out.
+func (c *BodiesController) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	accessKey := r.URL.Query().Get("access_key")
+	clientID := r.URL.Query().Get("client_id")
+	if !c.authService.ValidateAccess(accessKey, clientID) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if c.authService.IsKicked(clientID) {
+		http.Error(w, "Kicked", http.StatusForbidden)
+		return
+	}
+
+	ids := strings.Split(r.URL.Query().Get("ids"), ",")
+	if len(ids) == 0 || ids[0] == "" || len(ids) > maxBodyIDs {
+		http.Error(w, "Need 1 to 50 ids", http.StatusBadRequest)
+		return
+	}
+
+	messages := c.chatService.GetBodies(ids)
+	response := make([]models.BodyEntry, len(messages))
+	for i, msg := range messages {
+		response[i] = models.BodyEntry{ID: msg.ID, Content: msg.Content}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"secure-chat-backend/internal/services"
+)
+
+// ProtocolVersion is the relay wire protocol spoken by this server.
+// Bump it when the meaning of an existing field changes; new optional
+// behaviour is advertised through ServerFeatures instead.
+const ProtocolVersion = 1
+
+// ServerVersion identifies this relay build in the handshake.
+const ServerVersion = "secure-chat-backend/1.0.0"
+
+// ServerFeatures lists the optional capabilities this relay supports.
+var ServerFeatures = []string{"send", "poll", "stats", "health", "schema-v2", "types", "stream", "announcements", "moderation", "plain-colors", "stickers", "lazy-bodies", "history"}
+
+type HelloController struct {
+	authService *services.AuthService
+	powService  *services.PowService
+	maxContent  int
+}
+
+// HelloRequest is the client half of the version/capability handshake.
+type HelloRequest struct {
+	AccessKey string   `json:"access_key"`
+	ClientID  string   `json:"client_id"`
+	Protocol  int      `json:"protocol"`
+	Client    string   `json:"client"`
+	Features  []string `json:"features"`
+}
+
+// HelloResponse is the server half of the handshake.
+type HelloResponse struct {
+	Protocol   int       `json:"protocol"`
+	Server     string    `json:"server"`
+	Features   []string  `json:"features"`
+	Time       time.Time `json:"time"`
+	MaxContent int       `json:"max_content,omitempty"` // bytes per message; 0 = unlimited
+}
+
+func NewHelloController(authService *services.AuthService, powService *services.PowService, maxContent int) *HelloController {
+	return &HelloController{
+		authService: authService,
+		powService:  powService,
+		maxContent:  maxContent,
+	}
+}
+
+func (c *HelloController) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req HelloRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !c.authService.ValidateAccess(req.AccessKey, req.ClientID) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if c.authService.IsKicked(req.ClientID) {
+		http.Error(w, "Kicked", http.StatusForbidden)
+		return
+	}
+
+	features := ServerFeatures
+	if c.powService.Enabled() {
+		features = append(features[:len(features):len(features)], "pow")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(HelloResponse{
+		Protocol:   ProtocolVersion,
+		Server:     ServerVersion,
+		Features:   features,
+		Time:       time.Now().UTC(),
+		MaxContent: c.maxContent,
+	})
+}
@@ -0,0 +1,67 @@
+// internal/controllers/history_controller.go
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"secure-chat-backend/internal/models"
+	"secure-chat-backend/internal/services"
+)
+
+// maxHistoryPage bounds one /api/history answer, like a poll answer.
+const maxHistoryPage = 50
+
+// HistoryController serves the messages still buffered from before a given
+// one, so clients can page back past what they received live.
+type HistoryController struct {
+	chatService *services.ChatService
+	authService *services.AuthService
+}
+
+func NewHistoryController(chatService *services.ChatService, authService *services.AuthService) *HistoryController {
+	return &HistoryController{
+		chatService: chatService,
+		authService: authService,
+	}
+}
+
+// Handle answers GET /api/history?before=<id>&limit=<n> with up to n (at
+// most 50, the default) of the messages before id, oldest first, in the v2
+// schema. Without before it answers with the newest.
+func (c *HistoryController) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	accessKey := r.URL.Query().Get("access_key")
+	clientID := r.URL.Query().Get("client_id")
+	if !c.authService.ValidateAccess(accessKey, clientID) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if c.authService.IsKicked(clientID) {
+		http.Error(w, "Kicked", http.StatusForbidden)
+		return
+	}
+
+	limit := maxHistoryPage
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxHistoryPage {
+			http.Error(w, "limit must be 1 to 50", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	messages, more := c.chatService.GetHistory(r.URL.Query().Get("before"), limit)
+	response := models.HistoryResponse{Messages: make([]models.WireMessage, len(messages)), More: more}
+	for i, msg := range messages {
+		response.Messages[i] = msg.ToWireFormat()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
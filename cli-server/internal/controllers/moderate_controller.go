@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"secure-chat-backend/internal/models"
+	"secure-chat-backend/internal/services"
+)
+
+// maxModerationSeconds caps mute lengths and the slow-mode gap.
+const maxModerationSeconds = 7 * 24 * 60 * 60
+
+// ModerateController serves /api/moderate: admin-only kick, mute, unmute and
+// slow mode. Every accepted action is also posted as a system message so
+// clients can show what happened.
+type ModerateController struct {
+	chatService       *services.ChatService
+	authService       *services.AuthService
+	moderationService *services.ModerationService
+}
+
+// ModerateRequest is one admin action. Username is required except for
+// slowmode; Seconds is the mute length or the slow-mode gap (0 = off).
+type ModerateRequest struct {
+	AccessKey string `json:"access_key"`
+	ClientID  string `json:"client_id"`
+	AdminKey  string `json:"admin_key"`
+	Action    string `json:"action"`
+	Username  string `json:"username"`
+	Seconds   int64  `json:"seconds"`
+}
+
+// ModerateResponse reports the outcome; Affected is the number of clients
+// kicked.
+type ModerateResponse struct {
+	Status   string `json:"status"`
+	Affected int    `json:"affected,omitempty"`
+}
+
+func NewModerateController(chatService *services.ChatService, authService *services.AuthService, moderationService *services.ModerationService) *ModerateController {
+	return &ModerateController{
+		chatService:       chatService,
+		authService:       authService,
+		moderationService: moderationService,
+	}
+}
+
+func (c *ModerateController) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ModerateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !c.authService.ValidateAccess(req.AccessKey, req.ClientID) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !c.authService.IsAdmin(req.AdminKey) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if req.Seconds < 0 || req.Seconds > maxModerationSeconds {
+		http.Error(w, "Invalid duration", http.StatusBadRequest)
+		return
+	}
+	if req.Action != models.ActionSlowMode && req.Username == "" {
+		http.Error(w, "Username is required", http.StatusBadRequest)
+		return
+	}
+
+	resp := ModerateResponse{Status: "ok"}
+	event := models.ModerationEvent{Action: req.Action, Target: req.Username}
+	switch req.Action {
+	case models.ActionKick:
+		resp.Affected = c.authService.Kick(req.Username)
+		if resp.Affected == 0 {
+			http.Error(w, "No such user", http.StatusNotFound)
+			return
+		}
+	case models.ActionMute:
+		if req.Seconds == 0 {
+			http.Error(w, "Invalid duration", http.StatusBadRequest)
+			return
+		}
+		ids := c.authService.ClientsAs(req.Username)
+		c.moderationService.Mute(req.Username, ids, time.Duration(req.Seconds)*time.Second)
+		event.Seconds = req.Seconds
+	case models.ActionUnmute:
+		if !c.moderationService.Unmute(req.Username) {
+			http.Error(w, "User is not muted", http.StatusNotFound)
+			return
+		}
+	case models.ActionSlowMode:
+		c.moderationService.SetSlowMode(time.Duration(req.Seconds) * time.Second)
+		event.Target = ""
+		event.Seconds = req.Seconds
+	default:
+		http.Error(w, "Invalid action", http.StatusBadRequest)
+		return
+	}
+
+	if content, err := json.Marshal(event); err == nil {
+		c.chatService.SendMessage("relay", string(content), "", models.TypeSystem, req.ClientID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
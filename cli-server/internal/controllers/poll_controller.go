@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"secure-chat-backend/internal/models"
 	"secure-chat-backend/internal/services"
 )
 
@@ -35,13 +36,27 @@ func (c *PollController) Handle(w http.ResponseWriter, r *http.Request) {
 	accessKey := r.URL.Query().Get("access_key")
 	clientID := r.URL.Query().Get("client_id")
 	lastID := r.URL.Query().Get("last_id")
+	// format=2 → schema با فیلدهای ثابت و type؛ در غیر این صورت فرمت قدیمی
+	v2 := r.URL.Query().Get("format") == "2"
+	// bodies=lazy → فقط header پیام‌های چت بلند؛ متن از /api/bodies
+	lazy := v2 && r.URL.Query().Get("bodies") == "lazy"
 
 	if !c.authService.ValidateAccess(accessKey, clientID) {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
+	if c.authService.IsKicked(clientID) {
+		http.Error(w, "Kicked", http.StatusForbidden)
+		return
+	}
 
-	messages, err := c.chatService.WaitForMessages(clientID, lastID, c.pollTimeout)
+	var messages []*models.Message
+	var err error
+	if v2 {
+		messages, err = c.chatService.WaitForMessages(clientID, lastID, c.pollTimeout)
+	} else {
+		messages, err = c.chatService.WaitForChatMessages(clientID, lastID, c.pollTimeout)
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -52,13 +67,26 @@ func (c *PollController) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if v2 {
+		response := make([]models.WireMessage, len(messages))
+		for i, msg := range messages {
+			if lazy {
+				response[i] = msg.ToHeaderFormat()
+			} else {
+				response[i] = msg.ToWireFormat()
+			}
+		}
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
 	// تبدیل پیام‌ها به فرمت مورد نظر کلاینت
 	response := make([]map[string]interface{}, len(messages))
 	for i, msg := range messages {
 		response[i] = msg.ToClientFormat()
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
@@ -3,39 +3,62 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
+	"secure-chat-backend/internal/models"
 	"secure-chat-backend/internal/services"
 )
 
 // SendController کنترلر ارسال پیام
 type SendController struct {
-	chatService *services.ChatService
-	authService *services.AuthService
+	chatService       *services.ChatService
+	authService       *services.AuthService
+	moderationService *services.ModerationService
+	powService        *services.PowService
+	maxContent        int // bytes; 0 = unlimited
 }
 
 // SendRequest ساختار درخواست با فرمت جدید
 type SendRequest struct {
 	AccessKey string `json:"access_key"`
 	ClientID  string `json:"client_id"`
-	Username  string `json:"username"` // مثلا "script_kiddie"
-	Content   string `json:"content"`  // متن پیام
-	Color     string `json:"color"`    // مثل "[yellow]"
+	Username  string `json:"username"`  // مثلا "script_kiddie"
+	Content   string `json:"content"`   // متن پیام
+	Color     string `json:"color"`     // مثل "[yellow]"
+	Type      string `json:"type"`      // "chat" (پیش‌فرض), "presence", "control", "reaction", "sticker", "announcement"
+	AdminKey  string `json:"admin_key"` // required for "announcement"
+
+	// اثبات کار وقتی رله با -pow-bits اجرا شده
+	PowChallenge string `json:"pow_challenge"`
+	PowNonce     string `json:"pow_nonce"`
 }
 
 // SendResponse ساختار پاسخ
 type SendResponse struct {
-	Status string `json:"status"`
-	ID     string `json:"id"`
-	Time   string `json:"time"`
+	Status    string `json:"status"`
+	ID        string `json:"id"`
+	Time      string `json:"time"`
+	Challenge string `json:"challenge,omitempty"` // for the next send, when PoW is on
+}
+
+// PowRequired is the 428 body when a send lacks valid proof of work.
+type PowRequired struct {
+	Challenge string `json:"challenge"`
+	Bits      int    `json:"bits"`
 }
 
 // NewSendController سازنده
-func NewSendController(chatService *services.ChatService, authService *services.AuthService) *SendController {
+func NewSendController(chatService *services.ChatService, authService *services.AuthService, moderationService *services.ModerationService, powService *services.PowService, maxContent int) *SendController {
 	return &SendController{
-		chatService: chatService,
-		authService: authService,
+		chatService:       chatService,
+		authService:       authService,
+		moderationService: moderationService,
+		powService:        powService,
+		maxContent:        maxContent,
 	}
 }
 
@@ -58,28 +81,99 @@ func (c *SendController) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if c.authService.IsKicked(req.ClientID) {
+		http.Error(w, "Kicked", http.StatusForbidden)
+		return
+	}
+
+	// Before any challenge is issued, so unsolved sends cannot fill the
+	// relay's table of them.
 	if !c.authService.CheckRateLimit(req.ClientID) {
 		http.Error(w, "Too many requests", http.StatusTooManyRequests)
 		return
 	}
 
+	if c.powService.Enabled() && !c.powService.Verify(req.ClientID, req.PowChallenge, req.PowNonce) {
+		challenge := c.powService.Issue(req.ClientID)
+		if challenge == "" {
+			http.Error(w, "Server is busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPreconditionRequired)
+		json.NewEncoder(w).Encode(PowRequired{Challenge: challenge, Bits: c.powService.Bits()})
+		return
+	}
+
+	if c.maxContent > 0 && len(req.Content) > c.maxContent {
+		http.Error(w, fmt.Sprintf("Message too large (max %d bytes)", c.maxContent), http.StatusRequestEntityTooLarge)
+		return
+	}
+
 	// تنظیم رنگ پیش‌فرض اگر خالی بود
 	if req.Color == "" {
-		req.Color = "[white]"
+		req.Color = "white"
+	}
+
+	if req.Type == models.TypeAnnouncement {
+		if !c.authService.IsAdmin(req.AdminKey) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+	} else if req.Type != "" && !models.IsClientType(req.Type) {
+		http.Error(w, "Invalid message type", http.StatusBadRequest)
+		return
+	} else if req.Type == models.TypeSticker && !models.IsStickerName(req.Content) {
+		http.Error(w, "Invalid sticker name", http.StatusBadRequest)
+		return
+	}
+
+	// مدیریت: بی‌صدا و حالت آهسته
+	c.authService.NoteUsername(req.ClientID, req.Username)
+	if req.Type != models.TypePresence && req.Type != models.TypeAnnouncement {
+		if left := c.moderationService.MutedFor(req.ClientID, req.Username); left > 0 {
+			http.Error(w, fmt.Sprintf("Muted for %v", left.Round(time.Second)), http.StatusForbidden)
+			return
+		}
+	}
+	here := (req.Type == "" || req.Type == models.TypeChat) && models.MentionsHere(req.Content)
+	if here {
+		if wait := c.moderationService.HereWait(req.ClientID); wait > 0 {
+			secs := int(math.Ceil(wait.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+			http.Error(w, fmt.Sprintf("@here cooldown: wait %ds", secs), http.StatusTooManyRequests)
+			return
+		}
+	}
+	if req.Type == "" || req.Type == models.TypeChat || req.Type == models.TypeSticker {
+		if wait := c.moderationService.SlowModeWait(req.ClientID); wait > 0 {
+			secs := int(math.Ceil(wait.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+			http.Error(w, fmt.Sprintf("Slow mode: wait %ds", secs), http.StatusTooManyRequests)
+			return
+		}
 	}
 
 	// ارسال پیام
-	msg, err := c.chatService.SendMessage(req.Username, req.Content, req.Color, req.ClientID)
+	msg, err := c.chatService.SendMessage(req.Username, req.Content, req.Color, req.Type, req.ClientID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if here {
+		c.moderationService.NoteHere(req.ClientID)
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(SendResponse{
+	resp := SendResponse{
 		Status: "sent",
 		ID:     msg.ID,
 		Time:   time.Now().Format(time.RFC3339),
-	})
+	}
+	if c.powService.Enabled() {
+		resp.Challenge = c.powService.Issue(req.ClientID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
 }
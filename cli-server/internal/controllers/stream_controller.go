@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"secure-chat-backend/internal/services"
+)
+
+// StreamController serves /api/stream: the same messages as /api/poll (v2
+// schema) pushed over one long-lived Server-Sent Events response. It suits
+// networks where streaming HTTP works but reconnecting every poll is costly.
+//
+// Each message is one event whose id is the message ID, so a client that
+// reconnects with Last-Event-ID (or last_id) resumes without gaps. A comment
+// line is sent every keepAlive so clients and proxies can tell an idle stream
+// from a dead one. Streams are closed after maxLifetime; clients reconnect.
+type StreamController struct {
+	chatService *services.ChatService
+	authService *services.AuthService
+	keepAlive   time.Duration
+	maxLifetime time.Duration
+}
+
+func NewStreamController(chatService *services.ChatService, authService *services.AuthService) *StreamController {
+	return &StreamController{
+		chatService: chatService,
+		authService: authService,
+		keepAlive:   15 * time.Second,
+		maxLifetime: 10 * time.Minute,
+	}
+}
+
+func (c *StreamController) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	accessKey := r.URL.Query().Get("access_key")
+	clientID := r.URL.Query().Get("client_id")
+	lastID := r.URL.Query().Get("last_id")
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		lastID = id
+	}
+
+	if !c.authService.ValidateAccess(accessKey, clientID) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if c.authService.IsKicked(clientID) {
+		http.Error(w, "Kicked", http.StatusForbidden)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // stop nginx buffering the stream
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return // writer cannot stream
+	}
+
+	end := time.Now().Add(c.maxLifetime)
+	for time.Now().Before(end) {
+		if r.Context().Err() != nil {
+			return
+		}
+
+		messages, err := c.chatService.WaitForMessages(clientID, lastID, c.keepAlive)
+		if err != nil {
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+			rc.Flush()
+			return
+		}
+
+		// The server-wide WriteTimeout would cut the stream; extend it per write.
+		rc.SetWriteDeadline(time.Now().Add(c.keepAlive + 10*time.Second))
+		if len(messages) == 0 {
+			fmt.Fprint(w, ": keepalive\n\n")
+		}
+		for _, msg := range messages {
+			data, err := json.Marshal(msg.ToWireFormat())
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: message\ndata: %s\n\n", msg.ID, data)
+			lastID = msg.ID
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+		if c.authService.IsKicked(clientID) {
+			return // the kick event itself has just been delivered
+		}
+	}
+}
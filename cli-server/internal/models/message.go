@@ -2,33 +2,173 @@ package models
 
 import (
 	"encoding/json"
+	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
+// Message types. Chat is the default; the other types carry client-defined
+// payloads in Content and are only delivered to clients polling the v2 schema.
+const (
+	TypeChat     = "chat"
+	TypeSystem   = "system" // relay-originated; clients may not send it
+	TypePresence = "presence"
+	TypeControl  = "control"
+	TypeReaction = "reaction"
+
+	// TypeSticker is chat whose Content is only a sticker name; clients
+	// draw the art themselves. See IsStickerName.
+	TypeSticker = "sticker"
+
+	// TypeAnnouncement is a broadcast banner. Only senders holding the
+	// relay's admin key may post it, so clients can trust its origin.
+	TypeAnnouncement = "announcement"
+)
+
+// IsClientType reports whether clients may send messages of type t.
+func IsClientType(t string) bool {
+	switch t {
+	case TypeChat, TypePresence, TypeControl, TypeReaction, TypeSticker:
+		return true
+	}
+	return false
+}
+
+// MentionsHere reports whether content mentions "@here" as a whole word,
+// which rings the bell on every client.
+func MentionsHere(content string) bool {
+	lower := strings.ToLower(content)
+	for i := 0; ; {
+		j := strings.Index(lower[i:], "@here")
+		if j < 0 {
+			return false
+		}
+		end := i + j + len("@here")
+		if r, _ := utf8.DecodeRuneInString(lower[end:]); end == len(lower) || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+			return true
+		}
+		i = end
+	}
+}
+
+// IsStickerName reports whether s is a valid sticker name: 1 to 32 lower
+// case letters, digits, '-' or '_'.
+func IsStickerName(s string) bool {
+	if s == "" || len(s) > 32 {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// Moderation actions, carried in a TypeSystem message whose Content is a
+// JSON ModerationEvent.
+const (
+	ActionKick     = "kick"
+	ActionMute     = "mute"
+	ActionUnmute   = "unmute"
+	ActionSlowMode = "slowmode"
+)
+
+// ModerationEvent tells clients about an admin action so they can show it.
+type ModerationEvent struct {
+	Action  string `json:"action"`
+	Target  string `json:"target,omitempty"`  // username; empty for slowmode
+	Seconds int64  `json:"seconds,omitempty"` // mute length or slow-mode gap
+}
+
 type Message struct {
 	ID        string    `json:"id"`
 	Username  string    `json:"username"`
 	Content   string    `json:"content"`
 	Color     string    `json:"color"`
+	Type      string    `json:"type"`
 	Timestamp time.Time `json:"timestamp"`
 	ExpireAt  time.Time `json:"-"`
 }
 
+// WireMessage is the v2 poll schema: every field has a fixed key.
+type WireMessage struct {
+	Username  string `json:"username"`
+	Content   string `json:"content"`
+	Color     string `json:"color"`
+	ID        string `json:"id"`
+	Timestamp string `json:"timestamp"`
+	Type      string `json:"type"`
+
+	// Length is set instead of Content in a header: the body's size in
+	// bytes. See ToHeaderFormat.
+	Length int `json:"length,omitempty"`
+}
+
+// InlineBodyMax is the longest chat body a header carries in full; a
+// shorter one costs less than the request to fetch it.
+const InlineBodyMax = 64
+
+// BodyEntry is one entry of the /api/bodies response.
+type BodyEntry struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+}
+
+// HistoryResponse is the /api/history answer: a page of messages, oldest
+// first, and whether older ones are still buffered.
+type HistoryResponse struct {
+	Messages []WireMessage `json:"messages"`
+	More     bool          `json:"more"`
+}
+
 func (m *Message) MarshalJSON() ([]byte, error) {
-	msgMap := map[string]interface{}{
-		m.Username:  m.Content,
-		"color":     m.Color,
-		"id":        m.ID,
-		"timestamp": m.Timestamp.Format(time.RFC3339),
+	return json.Marshal(m.ToWireFormat())
+}
+
+// ToWireFormat converts the message to the v2 poll schema.
+func (m *Message) ToWireFormat() WireMessage {
+	msgType := m.Type
+	if msgType == "" {
+		msgType = TypeChat
+	}
+	return WireMessage{
+		Username:  m.Username,
+		Content:   m.Content,
+		Color:     m.Color,
+		ID:        m.ID,
+		Timestamp: m.Timestamp.Format(time.RFC3339),
+		Type:      msgType,
 	}
-	return json.Marshal(msgMap)
 }
 
+// ToHeaderFormat is ToWireFormat for lazy pollers: a chat body longer than
+// InlineBodyMax is left out and only its Length sent, for the client to fetch
+// from /api/bodies if it shows the message. Other types are small and acted
+// on at once, so they are always sent whole.
+func (m *Message) ToHeaderFormat() WireMessage {
+	w := m.ToWireFormat()
+	if w.Type == TypeChat && len(w.Content) > InlineBodyMax {
+		w.Length = len(w.Content)
+		w.Content = ""
+	}
+	return w
+}
+
+// ToClientFormat converts the message to the legacy v1 poll format, where
+// the username itself is the key holding the content. Only chat messages
+// are representable in it, and colors go back to tview tags for the old
+// clients that read it.
 func (m *Message) ToClientFormat() map[string]interface{} {
+	color := m.Color
+	if color != "" && color[0] != '[' {
+		color = "[" + color + "]"
+	}
 	return map[string]interface{}{
 		m.Username: m.Content,
-		"color":    m.Color,
+		"color":    color,
 		"id":       m.ID,
 	}
 }
@@ -89,6 +229,52 @@ func (mb *MessageBuffer) GetAfter(afterID string, limit int) []*Message {
 	return result
 }
 
+// GetByIDs returns the messages with the given IDs that are still buffered,
+// in buffer order.
+func (mb *MessageBuffer) GetByIDs(ids []string) []*Message {
+	want := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+
+	mb.mu.RLock()
+	defer mb.mu.RUnlock()
+
+	result := make([]*Message, 0, len(ids))
+	for _, msg := range mb.messages {
+		if want[msg.ID] {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// GetBefore returns the messages buffered before beforeID, oldest first, or
+// all of them for an empty beforeID. If beforeID is no longer buffered there
+// is nothing before it to return.
+func (mb *MessageBuffer) GetBefore(beforeID string) []*Message {
+	mb.mu.RLock()
+	defer mb.mu.RUnlock()
+
+	end := len(mb.messages)
+	if beforeID != "" {
+		end = -1
+		for i, msg := range mb.messages {
+			if msg.ID == beforeID {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return []*Message{}
+		}
+	}
+
+	result := make([]*Message, end)
+	copy(result, mb.messages[:end])
+	return result
+}
+
 func (mb *MessageBuffer) getLastMessages(limit int) []*Message {
 	if len(mb.messages) == 0 {
 		return []*Message{}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"crypto/subtle"
+	"strings"
 	"sync"
 	"time"
 
@@ -9,8 +11,10 @@ import (
 
 type AuthService struct {
 	accessKey    string
+	adminKey     string // empty = admin features disabled
 	mu           sync.RWMutex
 	clients      map[string]*ClientInfo
+	kicked       map[string]time.Time // client ID → when; refused until cleanup
 	rateLimiters map[string]*rate.Limiter
 	rateLimit    rate.Limit
 	rateBurst    int
@@ -18,15 +22,18 @@ type AuthService struct {
 
 type ClientInfo struct {
 	ID           string
+	Username     string // last name it sent as
 	FirstSeen    time.Time
 	LastSeen     time.Time
 	MessageCount int64
 }
 
-func NewAuthService(accessKey string) *AuthService {
+func NewAuthService(accessKey, adminKey string) *AuthService {
 	return &AuthService{
 		accessKey:    accessKey,
+		adminKey:     adminKey,
 		clients:      make(map[string]*ClientInfo),
+		kicked:       make(map[string]time.Time),
 		rateLimiters: make(map[string]*rate.Limiter),
 		rateLimit:    10,
 		rateBurst:    20,
@@ -62,6 +69,62 @@ func (s *AuthService) ValidateAccess(key, clientID string) bool {
 	return true
 }
 
+// IsAdmin reports whether key is the relay's admin key. Always false when
+// the relay was started without one.
+func (s *AuthService) IsAdmin(key string) bool {
+	if s.adminKey == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(key), []byte(s.adminKey)) == 1
+}
+
+// NoteUsername records the name clientID is sending as, so Kick can find it.
+func (s *AuthService) NoteUsername(clientID, username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if client, exists := s.clients[clientID]; exists {
+		client.Username = username
+	}
+}
+
+// ClientsAs returns the clients last seen sending as username, so a mute
+// holds when they change name.
+func (s *AuthService) ClientsAs(username string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var ids []string
+	for id, client := range s.clients {
+		if strings.EqualFold(client.Username, username) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Kick refuses every client last seen sending as username and returns how
+// many there were. A kicked client stays refused until CleanupOldClients
+// forgets it; rejoining takes a restart, which picks a new client ID.
+func (s *AuthService) Kick(username string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for id, client := range s.clients {
+		if strings.EqualFold(client.Username, username) {
+			s.kicked[id] = time.Now()
+			n++
+		}
+	}
+	return n
+}
+
+// IsKicked reports whether clientID has been kicked.
+func (s *AuthService) IsKicked(clientID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, kicked := s.kicked[clientID]
+	return kicked
+}
+
 func (s *AuthService) CheckRateLimit(clientID string) bool {
 	s.mu.RLock()
 	limiter, exists := s.rateLimiters[clientID]
@@ -86,6 +149,11 @@ func (s *AuthService) CleanupOldClients(maxAge time.Duration) {
 					delete(s.rateLimiters, id)
 				}
 			}
+			for id, at := range s.kicked {
+				if now.Sub(at) > maxAge {
+					delete(s.kicked, id)
+				}
+			}
 			s.mu.Unlock()
 		}
 	}()
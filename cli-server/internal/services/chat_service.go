@@ -26,15 +26,17 @@ func NewChatService(buffer *models.MessageBuffer) *ChatService {
 	}
 }
 
-func (s *ChatService) SendMessage(username, content, color, clientID string) (*models.Message, error) {
+func (s *ChatService) SendMessage(username, content, color, msgType, clientID string) (*models.Message, error) {
 	if username == "" || content == "" {
 		return nil, errors.New("username and content cannot be empty")
 	}
 
-	if color != "" && !utils.IsValidColor(color) {
-		color = "[white]"
+	if msgType == "" {
+		msgType = models.TypeChat
 	}
 
+	color = utils.NormalizeColor(color)
+
 	s.msgCounter++
 	msgID := utils.GenerateID()
 
@@ -43,6 +45,7 @@ func (s *ChatService) SendMessage(username, content, color, clientID string) (*m
 		Username:  username,
 		Content:   content,
 		Color:     color,
+		Type:      msgType,
 		Timestamp: time.Now(),
 	}
 
@@ -57,6 +60,43 @@ func (s *ChatService) GetMessages(afterID string) ([]*models.Message, error) {
 	return s.buffer.GetAfter(afterID, 50), nil
 }
 
+// GetBodies returns the chat messages with the given IDs that have not
+// expired yet, for clients that polled headers only.
+func (s *ChatService) GetBodies(ids []string) []*models.Message {
+	messages := s.buffer.GetByIDs(ids)
+	chat := messages[:0]
+	for _, msg := range messages {
+		if msg.Type == models.TypeChat {
+			chat = append(chat, msg)
+		}
+	}
+	return chat
+}
+
+// historyTypes are the message types /api/history serves: the ones clients
+// show as lines. Presence, control and reactions only matter live.
+var historyTypes = map[string]bool{
+	models.TypeChat:         true,
+	models.TypeSticker:      true,
+	models.TypeAnnouncement: true,
+}
+
+// GetHistory returns up to limit of the shown messages from before
+// beforeID, oldest first, and whether older ones remain.
+func (s *ChatService) GetHistory(beforeID string, limit int) ([]*models.Message, bool) {
+	messages := s.buffer.GetBefore(beforeID)
+	shown := messages[:0]
+	for _, msg := range messages {
+		if historyTypes[msg.Type] {
+			shown = append(shown, msg)
+		}
+	}
+	if len(shown) <= limit {
+		return shown, false
+	}
+	return shown[len(shown)-limit:], true
+}
+
 func (s *ChatService) WaitForMessages(clientID, afterID string, timeout time.Duration) ([]*models.Message, error) {
 	if messages := s.buffer.GetAfter(afterID, 50); len(messages) > 0 {
 		return messages, nil
@@ -87,6 +127,35 @@ func (s *ChatService) WaitForMessages(clientID, afterID string, timeout time.Dur
 	}
 }
 
+// WaitForChatMessages is WaitForMessages for legacy (v1 format) pollers,
+// which cannot represent typed events. Non-chat messages are skipped, and the
+// wait continues past them so a legacy client is not woken up — and sent
+// straight back with an empty answer — by traffic it will never see.
+func (s *ChatService) WaitForChatMessages(clientID, afterID string, timeout time.Duration) ([]*models.Message, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		messages, err := s.WaitForMessages(clientID, afterID, time.Until(deadline))
+		if err != nil || len(messages) == 0 {
+			return messages, err
+		}
+
+		chat := make([]*models.Message, 0, len(messages))
+		for _, msg := range messages {
+			if msg.Type == models.TypeChat {
+				chat = append(chat, msg)
+			}
+		}
+		if len(chat) > 0 {
+			return chat, nil
+		}
+
+		afterID = messages[len(messages)-1].ID
+		if time.Until(deadline) <= 0 {
+			return []*models.Message{}, nil
+		}
+	}
+}
+
 func (s *ChatService) notifyWaiters() {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// ModerationService holds the mutes and slow mode set through /api/moderate.
+// Kicks live in AuthService, since they refuse a client on every endpoint.
+// A mute holds for the name and for the clients sending as it when it was
+// set, since the name is whatever a client says it is.
+type ModerationService struct {
+	mu       sync.Mutex
+	muted    map[string]time.Time  // lower-case username → muted until
+	mutedIDs map[string]clientMute // client ID → the mute it is under
+	slowMode time.Duration         // minimum gap between chat messages; 0 = off
+	lastChat map[string]time.Time  // client ID → last chat message accepted
+
+	hereCooldown time.Duration        // minimum gap between one client's @here; 0 = off
+	lastHere     map[string]time.Time // client ID → last @here accepted
+}
+
+// clientMute is a mute on one client, set for the name it sent as.
+type clientMute struct {
+	name  string // lower-case
+	until time.Time
+}
+
+func NewModerationService() *ModerationService {
+	return &ModerationService{
+		muted:    make(map[string]time.Time),
+		mutedIDs: make(map[string]clientMute),
+		lastChat: make(map[string]time.Time),
+		lastHere: make(map[string]time.Time),
+	}
+}
+
+// Mute stops username, and clientIDs whatever name they send as, from
+// sending for d.
+func (s *ModerationService) Mute(username string, clientIDs []string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, until := strings.ToLower(username), time.Now().Add(d)
+	s.muted[key] = until
+	for _, id := range clientIDs {
+		s.mutedIDs[id] = clientMute{name: key, until: until}
+	}
+}
+
+// Unmute lifts a mute early, from the name and its clients. Reports whether
+// username was muted.
+func (s *ModerationService) Unmute(username string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.ToLower(username)
+	until, ok := s.muted[key]
+	delete(s.muted, key)
+	for id, m := range s.mutedIDs {
+		if m.name == key {
+			delete(s.mutedIDs, id)
+		}
+	}
+	return ok && time.Now().Before(until)
+}
+
+// MutedFor returns how long clientID, sending as username, stays muted; 0
+// if neither is.
+func (s *ModerationService) MutedFor(clientID, username string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.ToLower(username)
+	left := time.Until(s.muted[key])
+	if m, ok := s.mutedIDs[clientID]; ok {
+		if byID := time.Until(m.until); byID > left {
+			left = byID
+		}
+	}
+	if left <= 0 {
+		delete(s.muted, key)
+		delete(s.mutedIDs, clientID)
+		return 0
+	}
+	return left
+}
+
+// SetSlowMode sets the minimum gap between one client's chat messages.
+// 0 turns slow mode off.
+func (s *ModerationService) SetSlowMode(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.slowMode = d
+	if d == 0 {
+		s.lastChat = make(map[string]time.Time)
+	}
+}
+
+// SlowModeWait returns how long clientID must wait before its next chat
+// message. 0 means it may send now, and the send is recorded.
+func (s *ModerationService) SlowModeWait(clientID string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.slowMode == 0 {
+		return 0
+	}
+	now := time.Now()
+	if wait := s.lastChat[clientID].Add(s.slowMode).Sub(now); wait > 0 {
+		return wait
+	}
+	s.lastChat[clientID] = now
+	return 0
+}
+
+// SetHereCooldown sets the minimum gap between one client's messages that
+// mention @here. 0 turns the limit off.
+func (s *ModerationService) SetHereCooldown(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hereCooldown = d
+}
+
+// HereWait returns how long clientID must wait before it may mention @here
+// again; 0 if it may now. Unlike SlowModeWait it records nothing — call
+// NoteHere once the message is accepted.
+func (s *ModerationService) HereWait(clientID string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hereCooldown == 0 {
+		return 0
+	}
+	if wait := time.Until(s.lastHere[clientID].Add(s.hereCooldown)); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// NoteHere records that clientID just mentioned @here.
+func (s *ModerationService) NoteHere(clientID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hereCooldown > 0 {
+		s.lastHere[clientID] = time.Now()
+	}
+}
+
+// CleanupExpired drops ended mutes and stale slow-mode entries periodically.
+func (s *ModerationService) CleanupExpired(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			s.mu.Lock()
+			now := time.Now()
+			for name, until := range s.muted {
+				if now.After(until) {
+					delete(s.muted, name)
+				}
+			}
+			for id, m := range s.mutedIDs {
+				if now.After(m.until) {
+					delete(s.mutedIDs, id)
+				}
+			}
+			for id, at := range s.lastChat {
+				if now.Sub(at) > s.slowMode {
+					delete(s.lastChat, id)
+				}
+			}
+			for id, at := range s.lastHere {
+				if now.Sub(at) > s.hereCooldown {
+					delete(s.lastHere, id)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}()
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// PowService issues and checks hashcash-style proof-of-work challenges for
+// /api/send. A sender must find a nonce such that SHA-256(challenge+nonce)
+// starts with Bits zero bits. Each challenge is bound to one client and can
+// be used once, so the work cannot be shared or replayed. A client holds at
+// most one: a new one replaces the last, so no client can fill the table.
+type PowService struct {
+	bits      int
+	ttl       time.Duration
+	maxIssued int
+
+	mu     sync.Mutex
+	issued map[string]powChallenge // challenge → owner
+	latest map[string]string       // client → its outstanding challenge
+}
+
+type powChallenge struct {
+	clientID string
+	expires  time.Time
+}
+
+// NewPowService returns a service requiring bits leading zero bits.
+// bits == 0 disables proof of work.
+func NewPowService(bits int) *PowService {
+	return &PowService{
+		bits:      bits,
+		ttl:       2 * time.Minute,
+		maxIssued: 10000,
+		issued:    make(map[string]powChallenge),
+		latest:    make(map[string]string),
+	}
+}
+
+// Enabled reports whether sends must carry proof of work.
+func (s *PowService) Enabled() bool {
+	return s.bits > 0
+}
+
+// Bits returns the required number of leading zero bits.
+func (s *PowService) Bits() int {
+	return s.bits
+}
+
+// Issue returns a fresh challenge for clientID, replacing the one it was
+// issued before, or "" when too many are outstanding — the caller answers
+// 503 and the client retries later.
+func (s *PowService) Issue(clientID string) string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	challenge := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.latest[clientID]; ok {
+		delete(s.issued, old)
+	}
+	if len(s.issued) >= s.maxIssued {
+		delete(s.latest, clientID)
+		return ""
+	}
+	s.issued[challenge] = powChallenge{clientID: clientID, expires: time.Now().Add(s.ttl)}
+	s.latest[clientID] = challenge
+	return challenge
+}
+
+// Verify consumes challenge and reports whether nonce solves it for clientID.
+func (s *PowService) Verify(clientID, challenge, nonce string) bool {
+	if challenge == "" {
+		return false
+	}
+	s.mu.Lock()
+	c, ok := s.issued[challenge]
+	if ok && c.clientID == clientID {
+		delete(s.issued, challenge)
+		delete(s.latest, clientID)
+	}
+	s.mu.Unlock()
+
+	if !ok || c.clientID != clientID || time.Now().After(c.expires) {
+		return false
+	}
+	sum := sha256.Sum256([]byte(challenge + nonce))
+	return leadingZeroBits(sum[:]) >= s.bits
+}
+
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, x := range b {
+		if x != 0 {
+			return n + bits.LeadingZeros8(x)
+		}
+		n += 8
+	}
+	return n
+}
+
+// CleanupExpired drops unused challenges periodically.
+func (s *PowService) CleanupExpired(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			s.mu.Lock()
+			now := time.Now()
+			for k, c := range s.issued {
+				if now.After(c.expires) {
+					delete(s.issued, k)
+					if s.latest[c.clientID] == k {
+						delete(s.latest, c.clientID)
+					}
+				}
+			}
+			s.mu.Unlock()
+		}
+	}()
+}
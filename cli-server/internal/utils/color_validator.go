@@ -1,40 +1,50 @@
 package utils
 
 import (
+	"regexp"
 	"strings"
 )
 
+// Colors travel as plain names ("cyan") or "#rrggbb". Older clients send
+// tview tags ("[cyan]"), which are still accepted and stored in plain form.
 var validColors = map[string]bool{
-	"[red]":     true,
-	"[green]":   true,
-	"[yellow]":  true,
-	"[blue]":    true,
-	"[magenta]": true,
-	"[cyan]":    true,
-	"[white]":   true,
-	"[black]":   true,
-	"":          true,
+	"red":     true,
+	"green":   true,
+	"yellow":  true,
+	"blue":    true,
+	"magenta": true,
+	"cyan":    true,
+	"white":   true,
+	"black":   true,
+	"orange":  true,
+	"purple":  true,
+	"teal":    true,
+	"lime":    true,
+	"pink":    true,
 }
 
-func IsValidColor(color string) bool {
-	return validColors[color]
-}
+var hexColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
 
-func NormalizeColor(color string) string {
-	if color == "" {
-		return "[white]"
+// plainColor strips the brackets of a legacy tag and lower-cases the name.
+func plainColor(color string) string {
+	if strings.HasPrefix(color, "[") && strings.HasSuffix(color, "]") {
+		color = color[1 : len(color)-1]
 	}
+	return strings.ToLower(color)
+}
 
-	if !strings.HasPrefix(color, "[") {
-		color = "[" + color
-	}
-	if !strings.HasSuffix(color, "]") {
-		color = color + "]"
-	}
+// IsValidColor reports whether color is empty, a known name, #rrggbb, or one
+// of those as a bracketed tag.
+func IsValidColor(color string) bool {
+	c := plainColor(color)
+	return color == "" || validColors[c] || hexColorPattern.MatchString(c)
+}
 
-	if validColors[color] {
-		return color
+// NormalizeColor returns color in its plain wire form, or "white" if it is
+// empty or invalid.
+func NormalizeColor(color string) string {
+	if color == "" || !IsValidColor(color) {
+		return "white"
 	}
-
-	return "[white]"
+	return plainColor(color)
 }